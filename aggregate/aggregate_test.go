@@ -1,6 +1,7 @@
 package aggregate_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/modernice/goes/aggregate/test"
 	"github.com/modernice/goes/event"
 	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/pick"
+	"github.com/modernice/goes/helper/recovery"
 )
 
 func TestNew(t *testing.T) {
@@ -108,6 +111,47 @@ func TestApplyHistory(t *testing.T) {
 	etest.AssertEqualEvents(t, events, applied)
 }
 
+func TestApplyHistory_RecoverPanics(t *testing.T) {
+	var applied []event.Event
+	foo := test.NewFoo(uuid.New(), test.ApplyEventFunc("foo", func(evt event.Event) {
+		if pick.AggregateVersion(evt) == 2 {
+			panic("mock panic")
+		}
+		applied = append(applied, evt)
+	}))
+
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(foo.AggregateID(), foo.AggregateName(), 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(foo.AggregateID(), foo.AggregateName(), 2)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(foo.AggregateID(), foo.AggregateName(), 3)),
+	}
+
+	err := aggregate.ApplyHistory(foo, events, aggregate.RecoverPanics())
+
+	var perr *recovery.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ApplyHistory should fail with a %T; got %T (%v)", perr, err, err)
+	}
+
+	if perr.Event != "foo" {
+		t.Errorf("PanicError.Event should be %q; is %q", "foo", perr.Event)
+	}
+
+	if perr.Aggregate != foo.Ref() {
+		t.Errorf("PanicError.Aggregate should be %v; is %v", foo.Ref(), perr.Aggregate)
+	}
+
+	if perr.Value != "mock panic" {
+		t.Errorf("PanicError.Value should be %q; is %v", "mock panic", perr.Value)
+	}
+
+	if v := foo.AggregateVersion(); v != 0 {
+		t.Errorf("aggregate should not be committed; version is %d", v)
+	}
+
+	etest.AssertEqualEvents(t, events[:1], applied)
+}
+
 func TestUncommittedVersion(t *testing.T) {
 	a := aggregate.New("foo", uuid.New())
 
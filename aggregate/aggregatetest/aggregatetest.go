@@ -0,0 +1,178 @@
+// Package aggregatetest provides a given/when/then test harness for
+// aggregates.
+//
+//	func TestList_Remove(t *testing.T) {
+//		list := todo.NewList(uuid.New())
+//
+//		aggregatetest.Given(
+//			event.New("todo.list.task_added", "foo").Any(),
+//		).When(list, func() error {
+//			return list.Remove("foo")
+//		}).Then(t,
+//			event.New("todo.list.task_removed", "foo").Any(),
+//		)
+//	}
+package aggregatetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/event"
+)
+
+// Scenario is the "given" part of an aggregate test. It holds the event
+// history that is applied to the aggregate before the tested behavior is
+// executed.
+type Scenario struct {
+	given []event.Event
+}
+
+// Given returns a Scenario with the given event history. The events do not need
+// to provide the aggregate they belong to: the aggregate id, name, and version
+// of the events are set to the aggregate that is passed to When or
+// WhenCommand.
+func Given(events ...event.Event) Scenario {
+	return Scenario{given: events}
+}
+
+// Result is the result of an aggregate test, which can be checked using Then
+// and ThenErr.
+type Result struct {
+	aggregate aggregate.Aggregate
+	setupErr  error
+	err       error
+	changes   []event.Event
+}
+
+// When applies the event history of the Scenario to the aggregate and then
+// calls fn, which should execute the tested behavior of the aggregate.
+func (s Scenario) When(a aggregate.Aggregate, fn func() error) Result {
+	res := Result{aggregate: a}
+
+	if err := s.apply(a); err != nil {
+		res.setupErr = err
+		return res
+	}
+
+	res.err = fn()
+	res.changes = a.AggregateChanges()
+
+	return res
+}
+
+// WhenCommand applies the event history of the Scenario to the aggregate and
+// then lets the aggregate handle the given command. The aggregate must
+// implement a HandleCommand(command.Context) error method, which is the case
+// for aggregates that embed *handler.BaseHandler.
+func (s Scenario) WhenCommand(a aggregate.Aggregate, cmd command.Command) Result {
+	h, ok := a.(interface{ HandleCommand(command.Context) error })
+	if !ok {
+		return Result{
+			aggregate: a,
+			setupErr:  fmt.Errorf("%T does not implement HandleCommand(command.Context) error", a),
+		}
+	}
+
+	return s.When(a, func() error {
+		return h.HandleCommand(command.NewContext[any](context.Background(), cmd))
+	})
+}
+
+func (s Scenario) apply(a aggregate.Aggregate) error {
+	if len(s.given) == 0 {
+		return nil
+	}
+
+	id, name, _ := a.Aggregate()
+	version := aggregate.UncommittedVersion(a)
+
+	history := make([]event.Event, len(s.given))
+	for i, evt := range s.given {
+		history[i] = event.New(
+			evt.Name(),
+			evt.Data(),
+			event.ID(evt.ID()),
+			event.Time(evt.Time()),
+			event.Aggregate(id, name, version+i+1),
+		).Any()
+	}
+
+	if err := aggregate.ApplyHistory(a, history); err != nil {
+		return fmt.Errorf("apply given events: %w", err)
+	}
+
+	return nil
+}
+
+// Changes returns the events that were recorded by the aggregate in the "when"
+// part of the test.
+func (r Result) Changes() []event.Event {
+	return r.changes
+}
+
+// Then asserts that the tested behavior succeeded and that the aggregate
+// recorded exactly the given events. Events are compared by their names and
+// data; ids, times, and aggregate references are ignored. Pass no events to
+// assert that the aggregate recorded no changes.
+func (r Result) Then(t testing.TB, events ...event.Event) {
+	t.Helper()
+
+	if r.setupErr != nil {
+		t.Fatal(r.setupErr)
+	}
+
+	if r.err != nil {
+		t.Fatalf("expected no error; got %q", r.err)
+	}
+
+	if diff := cmp.Diff(compared(events), compared(r.changes), exportAll); diff != "" {
+		t.Fatalf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+// ThenErr asserts that the tested behavior failed with the given error. The
+// error is compared using errors.Is, or by its message if errors.Is returns
+// false. If err is nil, ThenErr asserts that the tested behavior failed with
+// any error.
+func (r Result) ThenErr(t testing.TB, err error) {
+	t.Helper()
+
+	if r.setupErr != nil {
+		t.Fatal(r.setupErr)
+	}
+
+	if r.err == nil {
+		if err == nil {
+			t.Fatalf("expected an error; got nil")
+		}
+		t.Fatalf("expected error %q; got nil", err)
+	}
+
+	if err == nil || errors.Is(r.err, err) || r.err.Error() == err.Error() {
+		return
+	}
+
+	t.Fatalf("expected error %q; got %q", err, r.err)
+}
+
+var exportAll = cmp.Exporter(func(reflect.Type) bool { return true })
+
+type comparedEvent struct {
+	Name string
+	Data any
+}
+
+func compared(events []event.Event) []comparedEvent {
+	out := make([]comparedEvent, len(events))
+	for i, evt := range events {
+		out[i] = comparedEvent{Name: evt.Name(), Data: evt.Data()}
+	}
+	return out
+}
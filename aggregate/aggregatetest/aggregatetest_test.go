@@ -0,0 +1,154 @@
+package aggregatetest_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/aggregatetest"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/handler"
+	"github.com/modernice/goes/event"
+)
+
+var errNotFound = errors.New("task not found")
+
+type list struct {
+	*aggregate.Base
+	*handler.BaseHandler
+
+	tasks []string
+}
+
+func newList(id uuid.UUID) *list {
+	l := &list{
+		Base:        aggregate.New("list", id),
+		BaseHandler: handler.NewBase(),
+	}
+
+	event.ApplyWith(l, func(evt event.Of[string]) { l.tasks = append(l.tasks, evt.Data()) }, "task_added")
+	event.ApplyWith(l, func(evt event.Of[string]) {
+		for i, task := range l.tasks {
+			if task == evt.Data() {
+				l.tasks = append(l.tasks[:i], l.tasks[i+1:]...)
+				return
+			}
+		}
+	}, "task_removed")
+
+	command.HandleWith(l, func(ctx command.Ctx[string]) error { return l.remove(ctx.Payload()) }, "remove_task")
+
+	return l
+}
+
+func (l *list) remove(task string) error {
+	for _, t := range l.tasks {
+		if t == task {
+			aggregate.Next(l, "task_removed", task)
+			return nil
+		}
+	}
+	return fmt.Errorf("remove %q: %w", task, errNotFound)
+}
+
+func TestScenario_When(t *testing.T) {
+	l := newList(uuid.New())
+
+	aggregatetest.Given(
+		event.New("task_added", "foo").Any(),
+		event.New("task_added", "bar").Any(),
+	).When(l, func() error {
+		return l.remove("foo")
+	}).Then(t,
+		event.New("task_removed", "foo").Any(),
+	)
+
+	if l.AggregateVersion() != 2 {
+		t.Fatalf("aggregate should be at version %d; is at %d", 2, l.AggregateVersion())
+	}
+}
+
+func TestScenario_WhenCommand(t *testing.T) {
+	l := newList(uuid.New())
+
+	aggregatetest.Given(
+		event.New("task_added", "foo").Any(),
+	).WhenCommand(l, command.New("remove_task", "foo").Any()).Then(t,
+		event.New("task_removed", "foo").Any(),
+	)
+}
+
+func TestResult_ThenErr(t *testing.T) {
+	l := newList(uuid.New())
+
+	aggregatetest.Given().When(l, func() error {
+		return l.remove("foo")
+	}).ThenErr(t, errNotFound)
+}
+
+func TestResult_Then_mismatch(t *testing.T) {
+	l := newList(uuid.New())
+
+	res := aggregatetest.Given(
+		event.New("task_added", "foo").Any(),
+	).When(l, func() error {
+		return l.remove("foo")
+	})
+
+	rec := run(func(tb testing.TB) {
+		res.Then(tb, event.New("task_removed", "bar").Any())
+	})
+
+	if !rec.failed {
+		t.Fatalf("Then() should fail if the events don't match")
+	}
+}
+
+func TestResult_ThenErr_noError(t *testing.T) {
+	l := newList(uuid.New())
+
+	res := aggregatetest.Given(
+		event.New("task_added", "foo").Any(),
+	).When(l, func() error {
+		return l.remove("foo")
+	})
+
+	rec := run(func(tb testing.TB) { res.ThenErr(tb, errNotFound) })
+
+	if !rec.failed {
+		t.Fatalf("ThenErr() should fail if no error occurred")
+	}
+}
+
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatal(...any) {
+	r.failed = true
+	runtime.Goexit()
+}
+
+func (r *recorder) Fatalf(string, ...any) {
+	r.failed = true
+	runtime.Goexit()
+}
+
+func run(fn func(testing.TB)) *recorder {
+	var rec recorder
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fn(&rec)
+	}()
+	wg.Wait()
+	return &rec
+}
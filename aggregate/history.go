@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/recovery"
 )
 
 // History represents an interface for managing the application and consistency
@@ -21,11 +22,34 @@ type History interface {
 	Apply(Aggregate)
 }
 
+// ApplyHistoryOption is an option for ApplyHistory.
+type ApplyHistoryOption func(*applyHistoryConfig)
+
+type applyHistoryConfig struct {
+	recoverPanics bool
+}
+
+// RecoverPanics returns an ApplyHistoryOption that recovers from panics that
+// occur while applying an event to the aggregate. A recovered panic is
+// returned as a *recovery.PanicError that references the event that caused
+// the panic. Events that come after the panicking event are not applied, and
+// no changes are recorded or committed.
+func RecoverPanics() ApplyHistoryOption {
+	return func(cfg *applyHistoryConfig) {
+		cfg.recoverPanics = true
+	}
+}
+
 // ApplyHistory applies a sequence of events to the given Aggregate, ensuring
 // consistency before applying. If the Aggregate implements the Committer
 // interface, changes are recorded and committed after applying the events.
 // Returns an error if consistency validation fails.
-func ApplyHistory[Events ~[]event.Of[any]](a Aggregate, events Events) error {
+func ApplyHistory[Events ~[]event.Of[any]](a Aggregate, events Events, opts ...ApplyHistoryOption) error {
+	var cfg applyHistoryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	id, name, _ := a.Aggregate()
 	version := UncommittedVersion(a)
 
@@ -34,7 +58,14 @@ func ApplyHistory[Events ~[]event.Of[any]](a Aggregate, events Events) error {
 	}
 
 	for _, evt := range events {
-		a.ApplyEvent(evt)
+		if !cfg.recoverPanics {
+			a.ApplyEvent(evt)
+			continue
+		}
+
+		if err := TryApplyEvent(a, evt); err != nil {
+			return err
+		}
 	}
 
 	if c, ok := a.(Committer); ok {
@@ -44,3 +75,10 @@ func ApplyHistory[Events ~[]event.Of[any]](a Aggregate, events Events) error {
 
 	return nil
 }
+
+// TryApplyEvent applies the given event to the aggregate and recovers from a
+// panic that occurs while doing so. A recovered panic is returned as a
+// *recovery.PanicError that references the event and the aggregate.
+func TryApplyEvent(a Aggregate, evt event.Event) error {
+	return recovery.Event(evt, func() { a.ApplyEvent(evt) })
+}
@@ -0,0 +1,138 @@
+// Package natural derives deterministic aggregate ids from the natural keys of
+// external entities, e.g. the customer number of a CRM system or the SKU of a
+// product. Deriving the aggregate id from the natural key makes ingestion
+// idempotent: an entity that is ingested twice maps to the same aggregate, so
+// the second ingestion can be detected by the aggregate's version instead of
+// by a lookup:
+//
+//	id := natural.ID("customer", crmCustomer.Number)
+//	c := NewCustomer(id)
+//	if err := repo.Fetch(ctx, c); err != nil {
+//		return err
+//	}
+//	if c.AggregateVersion() > 0 {
+//		return nil // already ingested
+//	}
+//
+// Aggregates whose ids were not derived, e.g. aggregates that were created
+// before their entities were ingested, can register their natural keys in a
+// lookup table (see Registration and Resolve).
+package natural
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/projection/lookup"
+)
+
+// LookupKey is the lookup key under which the natural keys of aggregates are
+// provided to a lookup table (see Registration).
+const LookupKey = "naturalKey"
+
+// namespace is the namespace of the ids that are derived by ID.
+var namespace = uuid.NameSpaceURL
+
+// Lookup is a lookup table that provides the natural keys of aggregates, e.g.
+// a *lookup.Lookup.
+type Lookup interface {
+	// Lookup returns the lookup value for the given key of the given
+	// aggregate.
+	Lookup(ctx context.Context, aggregateName, key string, aggregateID uuid.UUID) (any, bool)
+
+	// Reverse returns the aggregate that has the given lookup value for the
+	// given key.
+	Reverse(ctx context.Context, aggregateName, key string, value any) (uuid.UUID, bool)
+}
+
+// Key returns the natural key that consists of the given parts, for entities
+// that are identified by more than one value:
+//
+//	id := natural.ID("product", natural.Key(shopID, sku))
+//
+// Each part is prefixed with its length, so different parts always result in
+// different keys, regardless of the characters they contain.
+func Key(parts ...string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(strconv.Itoa(len(part)))
+		b.WriteByte(':')
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// ID returns the deterministic aggregate id of the aggregate with the given
+// name and natural key. The id is a version 5 UUID in the URL namespace of RFC
+// 4122, so the same name and key always result in the same id. The aggregates
+// of the contrib packages derive their ids using ID, so that, for example,
+// unique.ID(scope, value) equals ID(unique.Aggregate, Key(scope, value)).
+func ID(aggregateName, key string) uuid.UUID {
+	return NewID(namespace, aggregateName, key)
+}
+
+// NewID returns the deterministic aggregate id of the aggregate with the given
+// name and natural key in the given namespace. Use NewID instead of ID to
+// derive ids that differ from the ids of other applications that ingest the
+// same entities into the same store.
+func NewID(namespace uuid.UUID, aggregateName, key string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(Key(aggregateName, key)))
+}
+
+// Ref returns the reference to the aggregate with the given name and natural
+// key (see ID).
+func Ref(aggregateName, key string) aggregate.Ref {
+	return aggregate.Ref{Name: aggregateName, ID: ID(aggregateName, key)}
+}
+
+// Registration is event data that registers the natural key of an aggregate
+// in a lookup table. Embed Registration into the data of the event that
+// creates the aggregate of an external entity:
+//
+//	type CustomerImportedData struct {
+//		natural.Registration
+//		Name string
+//	}
+//
+//	data := CustomerImportedData{Registration: natural.Registration{NaturalKey: number}}
+//
+// The lookup table must be built from the events that embed Registration:
+//
+//	l := lookup.New(store, bus, []string{"customer_imported"})
+type Registration struct {
+	NaturalKey string
+}
+
+// ProvideLookup implements lookup.Data.
+func (r Registration) ProvideLookup(p lookup.Provider) {
+	if r.NaturalKey != "" {
+		p.Provide(LookupKey, r.NaturalKey)
+	}
+}
+
+// Find returns the id of the aggregate that registered the given natural key
+// in the lookup table, or false if no aggregate registered the key.
+func Find(ctx context.Context, l Lookup, aggregateName, key string) (uuid.UUID, bool) {
+	return l.Reverse(ctx, aggregateName, LookupKey, key)
+}
+
+// KeyOf returns the natural key that the given aggregate registered in the
+// lookup table, or false if the aggregate registered no key.
+func KeyOf(ctx context.Context, l Lookup, aggregateName string, aggregateID uuid.UUID) (string, bool) {
+	key, err := lookup.Expect[string](ctx, l, aggregateName, LookupKey, aggregateID)
+	return key, err == nil
+}
+
+// Resolve returns the id of the aggregate with the given natural key. If an
+// aggregate registered the key in the lookup table, its id is returned.
+// Otherwise, the derived id of the key is returned (see ID). Use Resolve while
+// migrating aggregates with random ids to derived ids.
+func Resolve(ctx context.Context, l Lookup, aggregateName, key string) uuid.UUID {
+	if id, ok := Find(ctx, l, aggregateName, key); ok {
+		return id
+	}
+	return ID(aggregateName, key)
+}
@@ -0,0 +1,107 @@
+package natural_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/natural"
+	"github.com/modernice/goes/contrib/unique"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/projection/lookup"
+)
+
+type customerImported struct {
+	natural.Registration
+	Name string
+}
+
+func TestID(t *testing.T) {
+	id := natural.ID("customer", "C-1")
+	if id != natural.ID("customer", "C-1") {
+		t.Fatalf("ID() should be deterministic")
+	}
+
+	if id.Version() != 5 {
+		t.Fatalf("ID() should return a version %d UUID; got version %d", 5, id.Version())
+	}
+
+	if id == natural.ID("supplier", "C-1") {
+		t.Fatalf("ID() should return different ids for different aggregate names")
+	}
+
+	if id == natural.NewID(uuid.New(), "customer", "C-1") {
+		t.Fatalf("NewID() should return different ids in different namespaces")
+	}
+
+	if ref := natural.Ref("customer", "C-1"); ref.Name != "customer" || ref.ID != id {
+		t.Fatalf("Ref() should return the reference to %s(%s); got %v", "customer", id, ref)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if natural.Key("a\x00", "b") == natural.Key("a", "\x00b") {
+		t.Fatalf("Key() should return different keys for different parts")
+	}
+
+	if natural.Key("ab", "c") == natural.Key("a", "bc") {
+		t.Fatalf("Key() should return different keys for different parts")
+	}
+
+	if natural.ID("a\x00b", "c") == natural.ID("a", "b\x00c") {
+		t.Fatalf("ID() should return different ids for different names and keys")
+	}
+}
+
+func TestID_contrib(t *testing.T) {
+	if got, want := natural.ID(unique.Aggregate, natural.Key("email", "bob@example.com")), unique.ID("email", "bob@example.com"); got != want {
+		t.Fatalf("ID() should return the id of unique.ID() (%s); got %s", want, got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+
+	legacyID := uuid.New()
+	if err := store.Insert(ctx, event.New[any](
+		"customer_imported",
+		customerImported{Registration: natural.Registration{NaturalKey: "C-1"}, Name: "Bob"},
+		event.Aggregate(legacyID, "customer", 1),
+	)); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	l := lookup.New(store, bus, []string{"customer_imported"})
+	errs, err := l.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() failed with %q", err)
+	}
+
+	go func() {
+		for err := range errs {
+			panic(err)
+		}
+	}()
+
+	if id, ok := natural.Find(ctx, l, "customer", "C-1"); !ok || id != legacyID {
+		t.Fatalf("Find() should return %s; got %s (%v)", legacyID, id, ok)
+	}
+
+	if key, ok := natural.KeyOf(ctx, l, "customer", legacyID); !ok || key != "C-1" {
+		t.Fatalf("KeyOf() should return %q; got %q (%v)", "C-1", key, ok)
+	}
+
+	if id := natural.Resolve(ctx, l, "customer", "C-1"); id != legacyID {
+		t.Fatalf("Resolve() should return the registered id %s; got %s", legacyID, id)
+	}
+
+	if id, want := natural.Resolve(ctx, l, "customer", "C-2"), natural.ID("customer", "C-2"); id != want {
+		t.Fatalf("Resolve() should return the derived id %s for an unregistered key; got %s", want, id)
+	}
+}
@@ -0,0 +1,229 @@
+package ref
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// DefaultBatchSize is the default number of aggregates whose existence is
+// checked by a single query.
+const DefaultBatchSize = 100
+
+// Extractor extracts the references to other aggregates from an event,
+// usually from aggregate.Ref values that are embedded in the event data:
+//
+//	func(evt event.Event) []aggregate.Ref {
+//		if data, ok := evt.Data().(OrderPlaced); ok {
+//			return []aggregate.Ref{{Name: "customer", ID: data.CustomerID}}
+//		}
+//		return nil
+//	}
+type Extractor func(event.Event) []aggregate.Ref
+
+// Reason is the reason why a reference is orphaned.
+type Reason string
+
+const (
+	// NotFound means that the referenced aggregate has no events, either
+	// because it never existed or because its events were deleted.
+	NotFound = Reason("not_found")
+
+	// SoftDeleted means that the referenced aggregate is soft-deleted (see
+	// aggregate.SoftDeleter).
+	SoftDeleted = Reason("soft_deleted")
+)
+
+// Orphan is a reference to a nonexistent or deleted aggregate.
+type Orphan struct {
+	// EventID is the id of the event that contains the reference.
+	EventID uuid.UUID
+
+	// EventName is the name of the event that contains the reference.
+	EventName string
+
+	// Source is the aggregate of the event that contains the reference. Zero
+	// if the event does not belong to an aggregate.
+	Source aggregate.Ref
+
+	// Ref is the referenced aggregate.
+	Ref aggregate.Ref
+
+	// Reason is the reason why the reference is orphaned.
+	Reason Reason
+}
+
+// Report is the result of a scan.
+type Report struct {
+	// Events is the number of scanned events.
+	Events int
+
+	// References is the number of references to each referenced aggregate.
+	References map[aggregate.Ref]int
+
+	// Orphans are the references to nonexistent or deleted aggregates, in
+	// the order of the scanned events.
+	Orphans []Orphan
+}
+
+// ScanOption is an option for Scan.
+type ScanOption func(*scanner)
+
+type scanner struct {
+	names            []string
+	batchSize        int
+	allowSoftDeleted bool
+}
+
+// Events returns a ScanOption that only scans the events with the given names
+// for references. By default, all events are scanned.
+func Events(names ...string) ScanOption {
+	return func(s *scanner) {
+		s.names = append(s.names, names...)
+	}
+}
+
+// BatchSize returns a ScanOption that specifies the number of aggregates whose
+// existence is checked by a single query. Defaults to DefaultBatchSize.
+func BatchSize(n int) ScanOption {
+	return func(s *scanner) {
+		s.batchSize = n
+	}
+}
+
+// AllowSoftDeleted returns a ScanOption that does not report references to
+// soft-deleted aggregates as orphans.
+func AllowSoftDeleted() ScanOption {
+	return func(s *scanner) {
+		s.allowSoftDeleted = true
+	}
+}
+
+// Scan scans the events of the given store for references to other
+// aggregates, using the provided Extractor, and reports the references to
+// aggregates that do not exist or are deleted. Scan counts the references to
+// each aggregate, which can be used to find out whether an aggregate can be
+// deleted safely.
+//
+// Events are scanned in two passes: first, the references are collected from
+// the events; second, the existence of the referenced aggregates is checked
+// by querying their events in batches (see BatchSize).
+func Scan(ctx context.Context, store event.Store, extract Extractor, opts ...ScanOption) (Report, error) {
+	s := scanner{batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = DefaultBatchSize
+	}
+
+	report := Report{References: make(map[aggregate.Ref]int)}
+
+	type reference struct {
+		evt event.Event
+		ref aggregate.Ref
+	}
+
+	var refs []reference
+
+	qopts := []query.Option{query.SortByTime()}
+	if len(s.names) > 0 {
+		qopts = append(qopts, query.Name(s.names...))
+	}
+
+	str, errs, err := store.Query(ctx, query.New(qopts...))
+	if err != nil {
+		return report, fmt.Errorf("query events: %w", err)
+	}
+
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		report.Events++
+		for _, r := range extract(evt) {
+			if r.Name == "" || r.ID == uuid.Nil {
+				continue
+			}
+			report.References[r]++
+			refs = append(refs, reference{evt: evt, ref: r})
+		}
+		return nil
+	}, str, errs); err != nil {
+		return report, fmt.Errorf("scan events: %w", err)
+	}
+
+	states, err := s.states(ctx, store, report.References)
+	if err != nil {
+		return report, err
+	}
+
+	for _, r := range refs {
+		deleted, exists := states[r.ref]
+
+		var reason Reason
+		switch {
+		case !exists:
+			reason = NotFound
+		case deleted && !s.allowSoftDeleted:
+			reason = SoftDeleted
+		default:
+			continue
+		}
+
+		id, name, _ := r.evt.Aggregate()
+		report.Orphans = append(report.Orphans, Orphan{
+			EventID:   r.evt.ID(),
+			EventName: r.evt.Name(),
+			Source:    aggregate.Ref{Name: name, ID: id},
+			Ref:       r.ref,
+			Reason:    reason,
+		})
+	}
+
+	return report, nil
+}
+
+// states returns whether the given aggregates are soft-deleted. Aggregates
+// without events are not contained in the returned map.
+func (s scanner) states(ctx context.Context, store event.Store, refs map[aggregate.Ref]int) (map[aggregate.Ref]bool, error) {
+	all := make([]aggregate.Ref, 0, len(refs))
+	for r := range refs {
+		all = append(all, r)
+	}
+
+	states := make(map[aggregate.Ref]bool, len(all))
+
+	for len(all) > 0 {
+		n := min(len(all), s.batchSize)
+		batch := all[:n]
+		all = all[n:]
+
+		str, errs, err := store.Query(ctx, query.New(query.Aggregates(batch...), query.SortByAggregate()))
+		if err != nil {
+			return states, fmt.Errorf("query referenced aggregates: %w", err)
+		}
+
+		if err := streams.Walk(ctx, func(evt event.Event) error {
+			id, name, _ := evt.Aggregate()
+			r := aggregate.Ref{Name: name, ID: id}
+
+			deleted := states[r]
+			if data, ok := evt.Data().(aggregate.SoftDeleter); ok && data.SoftDelete() {
+				deleted = true
+			}
+			if data, ok := evt.Data().(aggregate.SoftRestorer); ok && data.SoftRestore() {
+				deleted = false
+			}
+			states[r] = deleted
+
+			return nil
+		}, str, errs); err != nil {
+			return states, fmt.Errorf("query referenced aggregates: %w", err)
+		}
+	}
+
+	return states, nil
+}
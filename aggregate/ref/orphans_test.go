@@ -0,0 +1,88 @@
+package ref_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/ref"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+)
+
+type orderPlaced struct {
+	Customer aggregate.Ref
+}
+
+type customerDeleted struct{}
+
+func (customerDeleted) SoftDelete() bool { return true }
+
+func TestScan(t *testing.T) {
+	existing := aggregate.Ref{Name: "customer", ID: uuid.New()}
+	deleted := aggregate.Ref{Name: "customer", ID: uuid.New()}
+	missing := aggregate.Ref{Name: "customer", ID: uuid.New()}
+
+	now := time.Now()
+	at := func(i int) event.Option { return event.Time(now.Add(time.Duration(i) * time.Millisecond)) }
+
+	order := uuid.New()
+	events := []event.Event{
+		event.New[any]("customer.registered", struct{}{}, at(0), event.Aggregate(existing.ID, existing.Name, 1)),
+		event.New[any]("customer.registered", struct{}{}, at(1), event.Aggregate(deleted.ID, deleted.Name, 1)),
+		event.New[any]("customer.deleted", customerDeleted{}, at(2), event.Aggregate(deleted.ID, deleted.Name, 2)),
+		event.New[any]("order.placed", orderPlaced{Customer: existing}, at(3), event.Aggregate(order, "order", 1)),
+		event.New[any]("order.placed", orderPlaced{Customer: existing}, at(4), event.Aggregate(order, "order", 2)),
+		event.New[any]("order.placed", orderPlaced{Customer: deleted}, at(5), event.Aggregate(order, "order", 3)),
+		event.New[any]("order.placed", orderPlaced{Customer: missing}, at(6), event.Aggregate(order, "order", 4)),
+	}
+
+	store := eventstore.New(events...)
+
+	extract := func(evt event.Event) []aggregate.Ref {
+		if data, ok := evt.Data().(orderPlaced); ok {
+			return []aggregate.Ref{data.Customer}
+		}
+		return nil
+	}
+
+	report, err := ref.Scan(context.Background(), store, extract, ref.BatchSize(1))
+	if err != nil {
+		t.Fatalf("Scan() failed with %q", err)
+	}
+
+	if report.Events != len(events) {
+		t.Errorf("Report.Events should be %d; got %d", len(events), report.Events)
+	}
+
+	if report.References[existing] != 2 || report.References[deleted] != 1 || report.References[missing] != 1 {
+		t.Errorf("unexpected reference counts: %v", report.References)
+	}
+
+	if len(report.Orphans) != 2 {
+		t.Fatalf("Scan() should report %d orphans; got %d", 2, len(report.Orphans))
+	}
+
+	if o := report.Orphans[0]; o.Ref != deleted || o.Reason != ref.SoftDeleted || o.EventID != events[5].ID() || o.Source != (aggregate.Ref{Name: "order", ID: order}) {
+		t.Errorf("unexpected orphan: %+v", o)
+	}
+
+	if o := report.Orphans[1]; o.Ref != missing || o.Reason != ref.NotFound || o.EventID != events[6].ID() {
+		t.Errorf("unexpected orphan: %+v", o)
+	}
+
+	report, err = ref.Scan(context.Background(), store, extract, ref.AllowSoftDeleted(), ref.Events("order.placed"))
+	if err != nil {
+		t.Fatalf("Scan() failed with %q", err)
+	}
+
+	if report.Events != 4 {
+		t.Errorf("Scan() should only scan %d events; scanned %d", 4, report.Events)
+	}
+
+	if len(report.Orphans) != 1 || report.Orphans[0].Ref != missing {
+		t.Fatalf("Scan() should only report the missing aggregate; got %+v", report.Orphans)
+	}
+}
@@ -0,0 +1,148 @@
+package rename
+
+import (
+	"context"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+type alias struct {
+	event.Store
+
+	from string
+	to   string
+}
+
+// Alias returns an event store that makes the aggregates of the old name
+// `from` and the new name `to` available under both names while a rename is
+// rolled out:
+//
+//   - Events of `from` aggregates are inserted under the name `to`.
+//   - Queries for one of the names also return the events of the other name,
+//     with the aggregate name that was queried for. Services that still use the
+//     old name therefore keep receiving the events of their aggregates, and
+//     so do services that already use the new name.
+//
+// Queries that sort by aggregate name are sorted again in memory after the
+// events were renamed. Find and Delete are passed through to the wrapped
+// store. Until Migrate has
+// renamed an aggregate, the store checks the versions of newly inserted events
+// only against the events that were inserted under the new name, so Migrate
+// should run soon after every service uses the Alias.
+func Alias(store event.Store, from, to string) event.Store {
+	return &alias{Store: store, from: from, to: to}
+}
+
+func (a *alias) Insert(ctx context.Context, events ...event.Event) error {
+	renamed := make([]event.Event, len(events))
+	for i, evt := range events {
+		if _, name, _ := evt.Aggregate(); name == a.from {
+			renamed[i] = withAggregateName(evt, a.to)
+			continue
+		}
+		renamed[i] = evt
+	}
+	return a.Store.Insert(ctx, renamed...)
+}
+
+func (a *alias) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	name, other, ok := a.queried(q)
+	if !ok {
+		return a.Store.Query(ctx, q)
+	}
+
+	var opts []query.Option
+	if contains(q.AggregateNames(), name) {
+		opts = append(opts, query.AggregateName(other))
+	}
+	for _, ref := range q.Aggregates() {
+		if ref.Name == name {
+			opts = append(opts, query.Aggregate(other, ref.ID))
+		}
+	}
+
+	events, errs, err := a.Store.Query(ctx, query.Merge(q, query.New(opts...)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapped := streams.Map(ctx, events, func(evt event.Event) event.Event {
+		if _, n, _ := evt.Aggregate(); n == other {
+			return withAggregateName(evt, name)
+		}
+		return evt
+	})
+
+	if !sortsByAggregateName(q) {
+		return mapped, errs, nil
+	}
+
+	// The store sorted the events by their stored aggregate names, so the
+	// renamed events must be sorted again.
+	out := make(chan event.Event)
+	outErrs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(outErrs)
+
+		all, err := streams.Drain(ctx, mapped, errs)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case outErrs <- err:
+			}
+			return
+		}
+
+		for _, evt := range event.SortMulti(all, q.Sortings()...) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, outErrs, nil
+}
+
+func sortsByAggregateName(q event.Query) bool {
+	for _, s := range q.Sortings() {
+		if s.Sort == event.SortAggregateName {
+			return true
+		}
+	}
+	return false
+}
+
+// queried returns the aliased name that the given query filters by and the
+// other name of the alias. If the query filters by neither or both names, no
+// other events need to be queried and ok is false.
+func (a *alias) queried(q event.Query) (name, other string, ok bool) {
+	names := append([]string(nil), q.AggregateNames()...)
+	for _, ref := range q.Aggregates() {
+		names = append(names, ref.Name)
+	}
+
+	hasFrom, hasTo := contains(names, a.from), contains(names, a.to)
+	switch {
+	case hasFrom && !hasTo:
+		return a.from, a.to, true
+	case hasTo && !hasFrom:
+		return a.to, a.from, true
+	default:
+		return "", "", false
+	}
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+// Package rename renames aggregate types in existing event and snapshot
+// stores.
+//
+// Renaming an aggregate type changes the aggregate name of all events of the
+// type. Because services that still use the old name must keep working while
+// the rename is rolled out, a rename usually has three steps:
+//
+//  1. Wrap the event store of every service in an Alias, so that events are
+//     written under the new name and queries for either name return the events
+//     of both names.
+//  2. Run Migrate to move the existing events and snapshots to the new name.
+//  3. Switch the services to the new name and remove the Alias.
+package rename
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/snapshot"
+	squery "github.com/modernice/goes/aggregate/snapshot/query"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// ErrInvalidNames is returned by Migrate if an aggregate name is empty or if
+// both names are the same.
+var ErrInvalidNames = errors.New("invalid aggregate names")
+
+// Renamer is an event store that can rename the events of an aggregate in
+// place. Migrate uses a Renamer, if the event store implements it, and falls
+// back to copying the events of each aggregate otherwise, or if the Renamer
+// fails with errors.ErrUnsupported.
+type Renamer interface {
+	// RenameAggregate renames the aggregate with the given id from one
+	// aggregate name to another and returns the number of renamed events.
+	RenameAggregate(ctx context.Context, id uuid.UUID, from, to string) (int, error)
+}
+
+// Progress is the progress of a migration.
+type Progress struct {
+	// Aggregate is the aggregate that was renamed last, under its old name.
+	// Zero while snapshots are renamed.
+	Aggregate aggregate.Ref
+
+	// Aggregates is the number of renamed aggregates.
+	Aggregates int
+
+	// Total is the total number of aggregates to rename.
+	Total int
+
+	// Events is the number of renamed events.
+	Events int
+
+	// Snapshots is the number of renamed snapshots.
+	Snapshots int
+}
+
+// Report is the result of a migration.
+type Report struct {
+	// Aggregates is the number of renamed aggregates.
+	Aggregates int
+
+	// Events is the number of renamed events.
+	Events int
+
+	// Snapshots is the number of renamed snapshots.
+	Snapshots int
+}
+
+// Option is an option for Migrate.
+type Option func(*migration)
+
+type migration struct {
+	snapshots  snapshot.Store
+	onProgress func(Progress)
+}
+
+// Snapshots returns an Option that also renames the snapshots of the given
+// snapshot store.
+func Snapshots(store snapshot.Store) Option {
+	return func(m *migration) {
+		m.snapshots = store
+	}
+}
+
+// OnProgress returns an Option that calls fn after each renamed aggregate and
+// snapshot.
+func OnProgress(fn func(Progress)) Option {
+	return func(m *migration) {
+		m.onProgress = fn
+	}
+}
+
+// Migrate renames the aggregates with the aggregate name `from` to `to` and
+// returns a report of the renamed aggregates, events and snapshots:
+//
+//	report, err := rename.Migrate(ctx, store, "shop.order", "order",
+//		rename.Snapshots(snapshots),
+//		rename.OnProgress(func(p rename.Progress) {
+//			log.Printf("renamed %d/%d aggregates", p.Aggregates, p.Total)
+//		}),
+//	)
+//
+// Aggregates are renamed one after another. If the event store implements
+// Renamer, the events of an aggregate are renamed in place. Otherwise, the
+// events of an aggregate are deleted and inserted again under the new name,
+// which is not atomic: if the insert fails, Migrate tries to restore the
+// original events and returns the error.
+//
+// Migrate can be run again after a failure; it continues with the aggregates
+// that still have events under the old name.
+func Migrate(ctx context.Context, store event.Store, from, to string, opts ...Option) (Report, error) {
+	var m migration
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	if from == "" || to == "" || from == to {
+		return Report{}, fmt.Errorf("%w: rename %q to %q", ErrInvalidNames, from, to)
+	}
+
+	var report Report
+
+	ids, err := aggregateIDs(ctx, store, from)
+	if err != nil {
+		return report, err
+	}
+
+	for _, id := range ids {
+		n, err := renameEvents(ctx, store, id, from, to)
+		if err != nil {
+			return report, fmt.Errorf("rename %s(%s): %w", from, id, err)
+		}
+
+		report.Aggregates++
+		report.Events += n
+
+		m.progress(Progress{
+			Aggregate:  aggregate.Ref{Name: from, ID: id},
+			Aggregates: report.Aggregates,
+			Total:      len(ids),
+			Events:     report.Events,
+			Snapshots:  report.Snapshots,
+		})
+	}
+
+	if m.snapshots == nil {
+		return report, nil
+	}
+
+	snaps, errs, err := m.snapshots.Query(ctx, squery.New(squery.Name(from)))
+	if err != nil {
+		return report, fmt.Errorf("query snapshots: %w", err)
+	}
+
+	all, err := streams.Drain(ctx, snaps, errs)
+	if err != nil {
+		return report, fmt.Errorf("query snapshots: %w", err)
+	}
+
+	for _, snap := range all {
+		if err := renameSnapshot(ctx, m.snapshots, snap, to); err != nil {
+			return report, fmt.Errorf("rename snapshot %s(%s)@%d: %w", from, snap.AggregateID(), snap.AggregateVersion(), err)
+		}
+
+		report.Snapshots++
+
+		m.progress(Progress{
+			Aggregates: report.Aggregates,
+			Total:      len(ids),
+			Events:     report.Events,
+			Snapshots:  report.Snapshots,
+		})
+	}
+
+	return report, nil
+}
+
+func (m migration) progress(p Progress) {
+	if m.onProgress != nil {
+		m.onProgress(p)
+	}
+}
+
+// aggregateIDs returns the ids of the aggregates with the given name,
+// including the aggregates whose events are soft-deleted by the store.
+func aggregateIDs(ctx context.Context, store event.Store, name string) ([]uuid.UUID, error) {
+	str, errs, err := store.Query(ctx, query.New(
+		query.AggregateName(name),
+		query.SortByAggregate(),
+		query.IncludeDeleted(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("query aggregates: %w", err)
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		if id, _, _ := evt.Aggregate(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		return nil
+	}, str, errs); err != nil {
+		return ids, fmt.Errorf("query aggregates: %w", err)
+	}
+
+	return ids, nil
+}
+
+func renameEvents(ctx context.Context, store event.Store, id uuid.UUID, from, to string) (int, error) {
+	if r, ok := store.(Renamer); ok {
+		n, err := r.RenameAggregate(ctx, id, from, to)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return n, err
+		}
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.Aggregate(from, id), query.SortByAggregate()))
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	renamed := make([]event.Event, len(events))
+	for i, evt := range events {
+		renamed[i] = withAggregateName(evt, to)
+	}
+
+	if err := store.Delete(ctx, events...); err != nil {
+		return 0, fmt.Errorf("delete events: %w", err)
+	}
+
+	if err := store.Insert(ctx, renamed...); err != nil {
+		if restoreErr := store.Insert(ctx, events...); restoreErr != nil {
+			return 0, fmt.Errorf("insert renamed events: %w (restore original events: %v)", err, restoreErr)
+		}
+		return 0, fmt.Errorf("insert renamed events: %w", err)
+	}
+
+	return len(events), nil
+}
+
+func renameSnapshot(ctx context.Context, store snapshot.Store, snap snapshot.Snapshot, to string) error {
+	renamed, err := snapshot.New(
+		aggregate.New(to, snap.AggregateID(), aggregate.Version(snap.AggregateVersion())),
+		snapshot.Time(snap.Time()),
+		snapshot.Data(snap.State()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(ctx, renamed); err != nil {
+		return fmt.Errorf("save renamed snapshot: %w", err)
+	}
+
+	if err := store.Delete(ctx, snap); err != nil {
+		return fmt.Errorf("delete snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// withAggregateName returns a copy of the given event with a different
+// aggregate name.
+func withAggregateName(evt event.Event, name string) event.Event {
+	id, _, v := evt.Aggregate()
+	return event.New(
+		evt.Name(),
+		evt.Data(),
+		event.ID(evt.ID()),
+		event.Time(evt.Time()),
+		event.Aggregate(id, name, v),
+		event.Tag(event.TagsOf(evt)...),
+	).Any()
+}
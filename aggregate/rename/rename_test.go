@@ -0,0 +1,153 @@
+package rename_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/rename"
+	"github.com/modernice/goes/aggregate/snapshot"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	at := func(i int) event.Option { return event.Time(now.Add(time.Duration(i) * time.Millisecond)) }
+
+	a, b, other := uuid.New(), uuid.New(), uuid.New()
+	store := eventstore.New(
+		event.New[any]("foo", struct{}{}, at(0), event.Aggregate(a, "old", 1), event.Tag("x")),
+		event.New[any]("foo", struct{}{}, at(1), event.Aggregate(a, "old", 2)),
+		event.New[any]("foo", struct{}{}, at(2), event.Aggregate(b, "old", 1)),
+		event.New[any]("foo", struct{}{}, at(3), event.Aggregate(other, "other", 1)),
+	)
+
+	snapshots := snapshot.NewStore()
+	snap, err := snapshot.New(aggregate.New("old", a, aggregate.Version(2)), snapshot.Data([]byte("state")))
+	if err != nil {
+		t.Fatalf("snapshot.New() failed with %q", err)
+	}
+	if err := snapshots.Save(ctx, snap); err != nil {
+		t.Fatalf("Save() failed with %q", err)
+	}
+
+	var progress []rename.Progress
+	report, err := rename.Migrate(ctx, store, "old", "new", rename.Snapshots(snapshots), rename.OnProgress(func(p rename.Progress) {
+		progress = append(progress, p)
+	}))
+	if err != nil {
+		t.Fatalf("Migrate() failed with %q", err)
+	}
+
+	want := rename.Report{Aggregates: 2, Events: 3, Snapshots: 1}
+	if report != want {
+		t.Fatalf("Migrate() should return %+v; got %+v", want, report)
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("progress should be reported %d times; got %d", 3, len(progress))
+	}
+	if p := progress[0]; p.Aggregates != 1 || p.Total != 2 || p.Aggregate.Name != "old" {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+
+	if events := queryAll(t, store, query.AggregateName("old")); len(events) != 0 {
+		t.Fatalf("no events should remain under the old name; got %d", len(events))
+	}
+
+	renamed := queryAll(t, store, query.Aggregate("new", a), query.SortByAggregate())
+	if len(renamed) != 2 {
+		t.Fatalf("%d events should be renamed; got %d", 2, len(renamed))
+	}
+	if tags := event.TagsOf(renamed[0]); len(tags) != 1 || tags[0] != "x" {
+		t.Fatalf("renamed event should keep its tags; got %v", tags)
+	}
+
+	if events := queryAll(t, store, query.AggregateName("other")); len(events) != 1 {
+		t.Fatalf("events of other aggregates should not be renamed")
+	}
+
+	latest, err := snapshots.Latest(ctx, "new", a)
+	if err != nil {
+		t.Fatalf("Latest() failed with %q", err)
+	}
+	if latest.AggregateVersion() != 2 || string(latest.State()) != "state" {
+		t.Fatalf("renamed snapshot should keep its version and state; got version %d and state %q", latest.AggregateVersion(), latest.State())
+	}
+	if _, err := snapshots.Latest(ctx, "old", a); err == nil {
+		t.Fatalf("snapshot under the old name should be deleted")
+	}
+}
+
+func TestMigrate_invalidNames(t *testing.T) {
+	if _, err := rename.Migrate(context.Background(), eventstore.New(), "foo", "foo"); !errors.Is(err, rename.ErrInvalidNames) {
+		t.Fatalf("Migrate() should fail with %q; got %q", rename.ErrInvalidNames, err)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	ctx := context.Background()
+
+	id := uuid.New()
+	store := eventstore.New(event.New[any]("foo", struct{}{}, event.Aggregate(id, "old", 1)))
+	aliased := rename.Alias(store, "old", "new")
+
+	if err := aliased.Insert(ctx, event.New[any]("foo", struct{}{}, event.Aggregate(id, "old", 2))); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if events := queryAll(t, store, query.Aggregate("new", id)); len(events) != 1 {
+		t.Fatalf("Insert() should insert events under the new name")
+	}
+
+	for _, name := range []string{"old", "new"} {
+		for _, q := range []query.Query{
+			query.New(query.AggregateName(name), query.SortByAggregate()),
+			query.New(query.Aggregate(name, id), query.SortByAggregate()),
+		} {
+			str, errs, err := aliased.Query(ctx, q)
+			if err != nil {
+				t.Fatalf("Query() failed with %q", err)
+			}
+
+			events, err := streams.Drain(ctx, str, errs)
+			if err != nil {
+				t.Fatalf("Query() failed with %q", err)
+			}
+
+			if len(events) != 2 {
+				t.Fatalf("query for %q should return the events of both names; got %d events", name, len(events))
+			}
+
+			for i, evt := range events {
+				if _, n, v := evt.Aggregate(); n != name || v != i+1 {
+					t.Fatalf("event should be returned as %s@%d; got %s@%d", name, i+1, n, v)
+				}
+			}
+		}
+	}
+}
+
+func queryAll(t *testing.T, store event.Store, opts ...query.Option) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), query.New(opts...))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	return events
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// EventOverhead is the estimated size in bytes of the metadata of a stored
+// event (id, name, time, and aggregate), which is added to the size of the
+// encoded event data when batching inserts by size.
+const EventOverhead = 256
+
+// BatchInserts returns an Option that makes Save split the changes of an
+// aggregate into multiple Insert calls, each containing at most maxEvents
+// events and at most maxBytes bytes of estimated event size. A limit of 0
+// disables the respective bound. Use BatchInserts when aggregates can have
+// thousands of uncommitted events, e.g. in imports or migrations, to stay
+// within the transaction and document size limits of the event store (16MB
+// for MongoDB).
+//
+// The size of an event is estimated as the size of its encoded data (see
+// Encoding) plus EventOverhead. An event that exceeds maxBytes on its own is
+// inserted in a batch of its own.
+//
+// The batches are inserted in order, after the consistency of all changes has
+// been validated. Each batch is inserted in its own transaction, so if a batch
+// fails, the previous batches remain inserted; the returned error reports how
+// many events were inserted, and the changes of the aggregate are not
+// committed.
+func BatchInserts(maxEvents, maxBytes int) Option {
+	return func(r *Repository) {
+		r.batchEvents = maxEvents
+		r.batchBytes = maxBytes
+	}
+}
+
+func (r *Repository) insert(ctx context.Context, events []event.Event) error {
+	if r.batchEvents <= 0 && r.batchBytes <= 0 {
+		return r.store.Insert(ctx, events...)
+	}
+
+	batches, err := r.batches(events)
+	if err != nil {
+		return err
+	}
+
+	var inserted int
+	for i, batch := range batches {
+		if err := r.store.Insert(ctx, batch...); err != nil {
+			if inserted == 0 {
+				return err
+			}
+			return fmt.Errorf("batch %d/%d (%d of %d events inserted): %w", i+1, len(batches), inserted, len(events), err)
+		}
+		inserted += len(batch)
+	}
+
+	return nil
+}
+
+func (r *Repository) batches(events []event.Event) ([][]event.Event, error) {
+	enc := r.enc
+	if enc == nil && r.batchBytes > 0 {
+		enc = codec.New()
+	}
+
+	var (
+		out   [][]event.Event
+		batch []event.Event
+		size  int
+	)
+
+	for _, evt := range events {
+		var evtSize int
+		if r.batchBytes > 0 {
+			b, err := enc.Marshal(evt.Data())
+			if err != nil {
+				return nil, fmt.Errorf("estimate size of %q event: %w [id=%s]", evt.Name(), err, evt.ID())
+			}
+			evtSize = len(b) + len(evt.Name()) + EventOverhead
+		}
+
+		full := len(batch) > 0 &&
+			((r.batchEvents > 0 && len(batch) >= r.batchEvents) ||
+				(r.batchBytes > 0 && size+evtSize > r.batchBytes))
+
+		if full {
+			out = append(out, batch)
+			batch, size = nil, 0
+		}
+
+		batch = append(batch, evt)
+		size += evtSize
+	}
+
+	if len(batch) > 0 {
+		out = append(out, batch)
+	}
+
+	return out, nil
+}
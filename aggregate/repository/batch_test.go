@@ -0,0 +1,103 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/pick"
+)
+
+type batchRecordingStore struct {
+	event.Store
+
+	batches [][]event.Event
+	failAt  int
+}
+
+func (s *batchRecordingStore) Insert(ctx context.Context, events ...event.Event) error {
+	if s.failAt > 0 && len(s.batches)+1 == s.failAt {
+		return errors.New("mock error")
+	}
+	s.batches = append(s.batches, events)
+	return s.Store.Insert(ctx, events...)
+}
+
+func TestBatchInserts_maxEvents(t *testing.T) {
+	store := &batchRecordingStore{Store: eventstore.New()}
+	r := repository.New(store, repository.BatchInserts(3, 0))
+
+	a := newLifecycleAggregate(uuid.New())
+	for i := 0; i < 10; i++ {
+		a.foo()
+	}
+
+	if err := r.Save(context.Background(), a); err != nil {
+		t.Fatalf("Save failed with %q", err)
+	}
+
+	if len(store.batches) != 4 {
+		t.Fatalf("events should be inserted in %d batches; got %d", 4, len(store.batches))
+	}
+
+	var version int
+	for i, batch := range store.batches {
+		if i < 3 && len(batch) != 3 {
+			t.Errorf("batch #%d should contain %d events; got %d", i, 3, len(batch))
+		}
+		for _, evt := range batch {
+			version++
+			if pick.AggregateVersion(evt) != version {
+				t.Fatalf("events should be inserted in order; expected version %d; got %d", version, pick.AggregateVersion(evt))
+			}
+		}
+	}
+
+	if a.AggregateVersion() != 10 || len(a.AggregateChanges()) != 0 {
+		t.Fatalf("changes should be committed after all batches were inserted")
+	}
+}
+
+func TestBatchInserts_maxBytes(t *testing.T) {
+	store := &batchRecordingStore{Store: eventstore.New()}
+	r := repository.New(store, repository.BatchInserts(0, 2*repository.EventOverhead+200))
+
+	a := aggregate.New("foo", uuid.New())
+	for i := 0; i < 4; i++ {
+		aggregate.Next(a, "foo", etest.FooEventData{A: strings.Repeat("x", 50)})
+	}
+
+	if err := r.Save(context.Background(), a); err != nil {
+		t.Fatalf("Save failed with %q", err)
+	}
+
+	if len(store.batches) != 2 {
+		t.Fatalf("events should be inserted in %d batches; got %d", 2, len(store.batches))
+	}
+}
+
+func TestBatchInserts_partialFailure(t *testing.T) {
+	store := &batchRecordingStore{Store: eventstore.New(), failAt: 2}
+	r := repository.New(store, repository.BatchInserts(2, 0))
+
+	a := newLifecycleAggregate(uuid.New())
+	for i := 0; i < 5; i++ {
+		a.foo()
+	}
+
+	err := r.Save(context.Background(), a)
+	if err == nil || !strings.Contains(err.Error(), "2 of 5 events inserted") {
+		t.Fatalf("Save should report the number of inserted events; got %v", err)
+	}
+
+	if len(a.AggregateChanges()) != 5 {
+		t.Fatalf("changes should not be committed if a batch fails")
+	}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	equery "github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Encoding returns an Option that configures the encoding that DryRunSave
+// uses to encode event data. Use the same encoding as the event store.
+// Defaults to a codec.Registry that encodes event data as JSON.
+func Encoding(enc codec.Encoding) Option {
+	return func(r *Repository) {
+		r.enc = enc
+	}
+}
+
+// SaveReport is the result of DryRunSave.
+type SaveReport struct {
+	// Aggregate is the saved aggregate.
+	Aggregate aggregate.Ref
+
+	// Version is the version of the aggregate before its changes.
+	Version int
+
+	// StoredVersion is the latest version of the aggregate in the event store.
+	StoredVersion int
+
+	// Events are the reports of the uncommitted events of the aggregate.
+	Events []EventReport
+
+	// Size is the total size of the encoded event data in bytes.
+	Size int
+
+	// BeforeSaveError is the error that was returned by the BeforeSave hook
+	// of the aggregate.
+	BeforeSaveError error
+
+	// ConsistencyError is the error that was returned by the consistency
+	// validation of the changes.
+	ConsistencyError error
+
+	// ConflictError reports that the event store contains events that the
+	// aggregate has not applied, so that saving the aggregate would fail.
+	ConflictError error
+}
+
+// EventReport is the report of a single uncommitted event.
+type EventReport struct {
+	ID      uuid.UUID
+	Name    string
+	Version int
+
+	// Size is the size of the encoded event data in bytes.
+	Size int
+
+	// Err is the error that occurred while encoding the event data.
+	Err error
+}
+
+// Err returns the problems of the report as a single error, or nil if the
+// aggregate could be saved.
+func (r SaveReport) Err() error {
+	var errs []error
+	if r.BeforeSaveError != nil {
+		errs = append(errs, fmt.Errorf("BeforeSave: %w", r.BeforeSaveError))
+	}
+	if r.ConsistencyError != nil {
+		errs = append(errs, fmt.Errorf("validate consistency: %w", r.ConsistencyError))
+	}
+	if r.ConflictError != nil {
+		errs = append(errs, r.ConflictError)
+	}
+	for _, evt := range r.Events {
+		if evt.Err != nil {
+			errs = append(errs, fmt.Errorf("encode %q event data: %w [id=%s, version=%d]", evt.Name, evt.Err, evt.ID, evt.Version))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DryRunSave performs the checks of Save without persisting anything, which
+// is useful for pre-flight checks in import pipelines. It calls the
+// BeforeSave hook of the aggregate, validates the consistency of the
+// uncommitted changes, checks the event store for conflicting events, and
+// encodes the data of each change to report its size (see Encoding). The
+// BeforeInsert and AfterInsert hooks of the Repository are not called, and the
+// changes of the aggregate are not committed.
+//
+// The returned report is always filled as far as possible. The returned error
+// is non-nil if the event store could not be queried, or if the aggregate
+// could not be saved, in which case it is equal to report.Err().
+func (r *Repository) DryRunSave(ctx context.Context, a aggregate.Aggregate) (SaveReport, error) {
+	id, name, version := a.Aggregate()
+	changes := a.AggregateChanges()
+
+	report := SaveReport{
+		Aggregate: aggregate.Ref{Name: name, ID: id},
+		Version:   version,
+		Events:    make([]EventReport, len(changes)),
+	}
+
+	if hook, ok := a.(BeforeSaveHook); ok {
+		report.BeforeSaveError = hook.BeforeSave(ctx)
+	}
+
+	report.ConsistencyError = aggregate.ValidateConsistency(report.Aggregate, version, changes)
+
+	stored, err := r.storedVersion(ctx, report.Aggregate)
+	if err != nil {
+		return report, fmt.Errorf("query stored version: %w", err)
+	}
+	report.StoredVersion = stored
+
+	if stored > version {
+		report.ConflictError = &aggregate.ConsistencyError{
+			Kind:           aggregate.InconsistentVersion,
+			Aggregate:      report.Aggregate,
+			CurrentVersion: stored,
+			Events:         changes,
+		}
+	}
+
+	enc := r.enc
+	if enc == nil {
+		enc = codec.New()
+	}
+
+	for i, evt := range changes {
+		_, _, v := evt.Aggregate()
+		er := EventReport{ID: evt.ID(), Name: evt.Name(), Version: v}
+
+		b, err := enc.Marshal(evt.Data())
+		if err != nil {
+			er.Err = err
+		} else {
+			er.Size = len(b)
+			report.Size += len(b)
+		}
+
+		report.Events[i] = er
+	}
+
+	return report, report.Err()
+}
+
+func (r *Repository) storedVersion(ctx context.Context, ref aggregate.Ref) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := r.store.Query(ctx, equery.New(
+		equery.AggregateName(ref.Name),
+		equery.AggregateID(ref.ID),
+		equery.SortBy(event.SortAggregateVersion, event.SortDesc),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		_, _, version = evt.Aggregate()
+		return errStopWalk
+	}, str, errs); err != nil && !errors.Is(err, errStopWalk) {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+var errStopWalk = errors.New("stop walk")
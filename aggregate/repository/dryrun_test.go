@@ -0,0 +1,114 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestRepository_DryRunSave(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	r := repository.New(store)
+
+	a := newLifecycleAggregate(uuid.New())
+	a.foo()
+	a.foo()
+
+	report, err := r.DryRunSave(ctx, a)
+	if err != nil {
+		t.Fatalf("DryRunSave failed with %q", err)
+	}
+
+	if len(report.Events) != 2 {
+		t.Fatalf("report should contain %d events; got %d", 2, len(report.Events))
+	}
+
+	for i, evt := range report.Events {
+		if evt.Name != "foo" || evt.Version != i+1 || evt.Size == 0 || evt.Err != nil {
+			t.Errorf("unexpected event report %+v", evt)
+		}
+	}
+
+	if report.Size != report.Events[0].Size+report.Events[1].Size {
+		t.Errorf("report should contain the total size of the events")
+	}
+
+	if len(a.AggregateChanges()) != 2 {
+		t.Fatalf("DryRunSave should not commit the changes")
+	}
+
+	fetched := newLifecycleAggregate(a.AggregateID())
+	if err := r.Fetch(ctx, fetched); err != nil {
+		t.Fatalf("Fetch failed with %q", err)
+	}
+
+	if fetched.count != 0 {
+		t.Fatalf("DryRunSave should not insert events")
+	}
+}
+
+func TestRepository_DryRunSave_conflict(t *testing.T) {
+	ctx := context.Background()
+	r := repository.New(eventstore.New())
+
+	id := uuid.New()
+	saved := newLifecycleAggregate(id)
+	saved.foo()
+	if err := r.Save(ctx, saved); err != nil {
+		t.Fatalf("Save failed with %q", err)
+	}
+
+	stale := newLifecycleAggregate(id)
+	stale.foo()
+
+	report, err := r.DryRunSave(ctx, stale)
+	if err == nil {
+		t.Fatalf("DryRunSave should fail for conflicting changes")
+	}
+
+	if report.StoredVersion != 1 || !aggregate.IsConsistencyError(report.ConflictError) {
+		t.Fatalf("report should contain a conflict with stored version %d; got %+v", 1, report)
+	}
+}
+
+func TestRepository_DryRunSave_encodingError(t *testing.T) {
+	ctx := context.Background()
+	mockError := errors.New("mock error")
+	r := repository.New(eventstore.New(), repository.Encoding(codec.New(codec.Default(
+		func(any) ([]byte, error) { return nil, mockError },
+		func([]byte, any) error { return nil },
+	))))
+
+	a := newLifecycleAggregate(uuid.New())
+	a.foo()
+
+	report, err := r.DryRunSave(ctx, a)
+	if !errors.Is(err, mockError) {
+		t.Fatalf("DryRunSave should fail with %q; got %q", mockError, err)
+	}
+
+	if !errors.Is(report.Events[0].Err, mockError) {
+		t.Fatalf("event report should contain the encoding error; got %q", report.Events[0].Err)
+	}
+}
+
+func TestRepository_DryRunSave_inconsistent(t *testing.T) {
+	r := repository.New(eventstore.New())
+
+	a := newLifecycleAggregate(uuid.New())
+	a.RecordChange(event.New("foo", etest.FooEventData{}, event.Aggregate(a.AggregateID(), "other", 1)).Any())
+
+	report, err := r.DryRunSave(context.Background(), a)
+	if err == nil || report.ConsistencyError == nil {
+		t.Fatalf("DryRunSave should report the consistency error; got %+v", report)
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// HookError is returned by Save if the OnCreated or AfterSave hook of an
+// aggregate fails. The changes of the aggregate have already been inserted and
+// committed when these hooks are called, so the save must not be retried.
+type HookError struct {
+	// Hook is the name of the failed hook.
+	Hook string
+
+	// Err is the error returned by the hook.
+	Err error
+}
+
+func (err *HookError) Error() string {
+	return fmt.Sprintf("%s (changes were committed): %v", err.Hook, err.Err)
+}
+
+// Unwrap returns the error returned by the hook.
+func (err *HookError) Unwrap() error {
+	return err.Err
+}
+
+// CreatedHook can be implemented by aggregates to be notified when the
+// Repository has saved the first events of the aggregate.
+type CreatedHook interface {
+	// OnCreated is called by Save after the first events of the aggregate have
+	// been inserted and committed. OnCreated is called before AfterSave. If
+	// OnCreated fails, Save still calls AfterSave, takes a scheduled snapshot,
+	// and returns the error wrapped in a *HookError.
+	OnCreated(context.Context) error
+}
+
+// LoadedHook can be implemented by aggregates to compute derived fields after
+// the Repository has fetched them.
+type LoadedHook interface {
+	// OnLoaded is called by Fetch, FetchVersion, and FetchAt after the event
+	// history of the aggregate has been applied.
+	OnLoaded(context.Context) error
+}
+
+// BeforeSaveHook can be implemented by aggregates to enforce invariants
+// before the Repository saves them.
+type BeforeSaveHook interface {
+	// BeforeSave is called by Save before the consistency of the changes is
+	// validated and before the BeforeInsert hooks of the Repository are
+	// called. If BeforeSave returns an error, the aggregate is not saved.
+	BeforeSave(context.Context) error
+}
+
+// AfterSaveHook can be implemented by aggregates to be notified when the
+// Repository has saved them.
+type AfterSaveHook interface {
+	// AfterSave is called by Save after the changes of the aggregate have been
+	// inserted and committed, and before a snapshot is taken. If AfterSave
+	// fails, Save still takes a scheduled snapshot and returns the error
+	// wrapped in a *HookError.
+	AfterSave(context.Context) error
+}
@@ -0,0 +1,171 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/aggregate/snapshot"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	etest "github.com/modernice/goes/event/test"
+)
+
+type lifecycleAggregate struct {
+	*aggregate.Base
+
+	calls           []string
+	count           int
+	beforeSaveError error
+	onCreatedError  error
+	afterSaveError  error
+}
+
+func newLifecycleAggregate(id uuid.UUID) *lifecycleAggregate {
+	a := &lifecycleAggregate{Base: aggregate.New("lifecycle", id)}
+	event.ApplyWith(a, func(event.Of[etest.FooEventData]) { a.count++ }, "foo")
+	return a
+}
+
+func (a *lifecycleAggregate) OnCreated(context.Context) error {
+	a.calls = append(a.calls, "OnCreated")
+	return a.onCreatedError
+}
+
+func (a *lifecycleAggregate) OnLoaded(context.Context) error {
+	a.calls = append(a.calls, "OnLoaded")
+	return nil
+}
+
+func (a *lifecycleAggregate) BeforeSave(context.Context) error {
+	a.calls = append(a.calls, "BeforeSave")
+	return a.beforeSaveError
+}
+
+func (a *lifecycleAggregate) AfterSave(context.Context) error {
+	a.calls = append(a.calls, "AfterSave")
+	return a.afterSaveError
+}
+
+func (a *lifecycleAggregate) foo() {
+	aggregate.Next(a, "foo", etest.FooEventData{})
+}
+
+func TestRepository_lifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	r := repository.New(eventstore.New(), repository.AfterInsert(func(_ context.Context, a aggregate.Aggregate) error {
+		a.(*lifecycleAggregate).calls = append(a.(*lifecycleAggregate).calls, "AfterInsert")
+		return nil
+	}))
+
+	a := newLifecycleAggregate(uuid.New())
+	a.foo()
+
+	if err := r.Save(ctx, a); err != nil {
+		t.Fatalf("Save failed with %q", err)
+	}
+
+	want := []string{"BeforeSave", "AfterInsert", "OnCreated", "AfterSave"}
+	if !reflect.DeepEqual(a.calls, want) {
+		t.Fatalf("hooks should be called in order %v; got %v", want, a.calls)
+	}
+
+	a.calls = nil
+	a.foo()
+	if err := r.Save(ctx, a); err != nil {
+		t.Fatalf("Save failed with %q", err)
+	}
+
+	want = []string{"BeforeSave", "AfterInsert", "AfterSave"}
+	if !reflect.DeepEqual(a.calls, want) {
+		t.Fatalf("OnCreated should only be called for new aggregates; got %v", a.calls)
+	}
+
+	fetched := newLifecycleAggregate(a.AggregateID())
+	if err := r.Fetch(ctx, fetched); err != nil {
+		t.Fatalf("Fetch failed with %q", err)
+	}
+
+	if !reflect.DeepEqual(fetched.calls, []string{"OnLoaded"}) || fetched.count != 2 {
+		t.Fatalf("OnLoaded should be called after the history was applied; calls=%v count=%d", fetched.calls, fetched.count)
+	}
+}
+
+func TestRepository_lifecycleHooks_beforeSaveError(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	r := repository.New(store)
+
+	mockError := errors.New("mock error")
+	a := newLifecycleAggregate(uuid.New())
+	a.beforeSaveError = mockError
+	a.foo()
+
+	if err := r.Save(ctx, a); !errors.Is(err, mockError) {
+		t.Fatalf("Save should fail with %q; got %q", mockError, err)
+	}
+
+	if len(a.AggregateChanges()) != 1 {
+		t.Fatalf("changes should not be committed if BeforeSave fails")
+	}
+
+	fetched := newLifecycleAggregate(a.AggregateID())
+	if err := r.Fetch(ctx, fetched); err != nil {
+		t.Fatalf("Fetch failed with %q", err)
+	}
+
+	if fetched.count != 0 {
+		t.Fatalf("events should not be inserted if BeforeSave fails")
+	}
+}
+
+func TestRepository_lifecycleHooks_afterCommitError(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	snapstore := snapshot.NewStore()
+	r := repository.New(store, repository.WithSnapshots(snapstore, snapshot.Every(1)))
+
+	createdError := errors.New("created error")
+	afterSaveError := errors.New("after save error")
+	a := newLifecycleAggregate(uuid.New())
+	a.onCreatedError = createdError
+	a.afterSaveError = afterSaveError
+	a.foo()
+
+	err := r.Save(ctx, a)
+
+	var hookError *repository.HookError
+	if !errors.As(err, &hookError) {
+		t.Fatalf("Save should fail with a %T; got %q", hookError, err)
+	}
+
+	if !errors.Is(err, createdError) || !errors.Is(err, afterSaveError) {
+		t.Fatalf("Save should fail with %q and %q; got %q", createdError, afterSaveError, err)
+	}
+
+	want := []string{"BeforeSave", "OnCreated", "AfterSave"}
+	if !reflect.DeepEqual(a.calls, want) {
+		t.Fatalf("AfterSave should be called even if OnCreated fails; got %v", a.calls)
+	}
+
+	if len(a.AggregateChanges()) != 0 {
+		t.Fatalf("changes should be committed if a post-commit hook fails")
+	}
+
+	fetched := newLifecycleAggregate(a.AggregateID())
+	if err := repository.New(store).Fetch(ctx, fetched); err != nil {
+		t.Fatalf("Fetch failed with %q", err)
+	}
+
+	if fetched.count != 1 {
+		t.Fatalf("events should be inserted if a post-commit hook fails")
+	}
+
+	if _, err := snapstore.Latest(ctx, "lifecycle", a.AggregateID()); err != nil {
+		t.Fatalf("snapshot should be taken if a post-commit hook fails; Latest() failed with %q", err)
+	}
+}
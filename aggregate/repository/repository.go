@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	stdtime "time"
 
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/aggregate/query"
 	"github.com/modernice/goes/aggregate/snapshot"
 	"github.com/modernice/goes/aggregate/stream"
+	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
 	equery "github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
 	"github.com/modernice/goes/event/query/version"
 	"github.com/modernice/goes/helper/streams"
 )
@@ -46,8 +49,12 @@ type Repository struct {
 	afterInsert    []func(context.Context, aggregate.Aggregate) error
 	onFailedInsert []func(context.Context, aggregate.Aggregate, error) error
 	onDelete       []func(context.Context, aggregate.Aggregate) error
+	enc            codec.Encoding
+	batchEvents    int
+	batchBytes     int
 
 	validateConsistency bool
+	recoverPanics       bool
 }
 
 // WithSnapshots configures the Repository to use the provided snapshot.Store
@@ -74,6 +81,16 @@ func ValidateConsistency(validate bool) Option {
 	}
 }
 
+// RecoverPanics is an Option for the Repository that configures whether panics
+// that occur while applying the event history of an aggregate should be
+// recovered. If enabled, a panicking event handler of an aggregate causes the
+// fetch to fail with a *recovery.PanicError instead of crashing the process.
+func RecoverPanics(recoverPanics bool) Option {
+	return func(r *Repository) {
+		r.recoverPanics = recoverPanics
+	}
+}
+
 // ModifyQueries appends the provided query modifiers to the Repository's
 // queryModifiers slice. These modifiers are applied to event queries when
 // executing an aggregate.Query with the Repository.
@@ -144,8 +161,20 @@ func newRepository(store event.Store, opts ...Option) *Repository {
 // Save stores the changes of an Aggregate into the event store and creates a
 // snapshot of the Aggregate if the snapshot schedule is met. It validates
 // consistency and calls the appropriate hooks before and after inserting
-// events. If an error occurs, it calls the OnFailedInsert hook.
+// events. If an error occurs, it calls the OnFailedInsert hook. Aggregates that
+// implement BeforeSaveHook, CreatedHook, or AfterSaveHook are notified at the
+// corresponding points. Failures of OnCreated and AfterSave are returned as
+// *HookError, because the changes have already been committed at that point.
 func (r *Repository) Save(ctx context.Context, a aggregate.Aggregate) error {
+	if hook, ok := a.(BeforeSaveHook); ok {
+		if err := hook.BeforeSave(ctx); err != nil {
+			return fmt.Errorf("BeforeSave: %w", err)
+		}
+	}
+
+	_, _, prevVersion := a.Aggregate()
+	created := prevVersion == 0 && len(a.AggregateChanges()) > 0
+
 	if r.validateConsistency {
 		id, name, version := a.Aggregate()
 		ref := aggregate.Ref{Name: name, ID: id}
@@ -165,7 +194,7 @@ func (r *Repository) Save(ctx context.Context, a aggregate.Aggregate) error {
 		}
 	}
 
-	if err := r.store.Insert(ctx, a.AggregateChanges()...); err != nil {
+	if err := r.insert(ctx, a.AggregateChanges()); err != nil {
 		for _, fn := range r.onFailedInsert {
 			if hookError := fn(ctx, a, err); hookError != nil {
 				return fmt.Errorf("OnFailedInsert (%s): %w", err, hookError)
@@ -185,13 +214,27 @@ func (r *Repository) Save(ctx context.Context, a aggregate.Aggregate) error {
 		c.Commit()
 	}
 
+	var errs []error
+
+	if hook, ok := a.(CreatedHook); ok && created {
+		if err := hook.OnCreated(ctx); err != nil {
+			errs = append(errs, &HookError{Hook: "OnCreated", Err: err})
+		}
+	}
+
+	if hook, ok := a.(AfterSaveHook); ok {
+		if err := hook.AfterSave(ctx); err != nil {
+			errs = append(errs, &HookError{Hook: "AfterSave", Err: err})
+		}
+	}
+
 	if snap {
 		if err := r.makeSnapshot(ctx, a); err != nil {
-			return fmt.Errorf("make snapshot: %w", err)
+			errs = append(errs, fmt.Errorf("make snapshot: %w", err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (r *Repository) makeSnapshot(ctx context.Context, a aggregate.Aggregate) error {
@@ -256,10 +299,21 @@ func (r *Repository) fetch(ctx context.Context, a aggregate.Aggregate, opts ...e
 		return fmt.Errorf("query events: %w", err)
 	}
 
-	if err = aggregate.ApplyHistory(a, events); err != nil {
+	var applyOpts []aggregate.ApplyHistoryOption
+	if r.recoverPanics {
+		applyOpts = append(applyOpts, aggregate.RecoverPanics())
+	}
+
+	if err = aggregate.ApplyHistory(a, events, applyOpts...); err != nil {
 		return fmt.Errorf("apply history: %w", err)
 	}
 
+	if hook, ok := a.(LoadedHook); ok {
+		if err := hook.OnLoaded(ctx); err != nil {
+			return fmt.Errorf("OnLoaded: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -346,6 +400,27 @@ func (r *Repository) fetchVersion(ctx context.Context, a aggregate.Aggregate, v
 	return nil
 }
 
+// FetchAt fetches the aggregate as it was at the given time by applying all of
+// its events that occurred at or before t. FetchAt complements FetchVersion
+// for audit and support scenarios where the version of the aggregate is not
+// known. Snapshots are not used by FetchAt. It returns ErrVersionNotFound if
+// the aggregate has no events at or before t, and ErrDeleted if the aggregate
+// was soft-deleted at that time.
+func (r *Repository) FetchAt(ctx context.Context, a aggregate.Aggregate, t stdtime.Time) error {
+	if err := r.fetch(ctx, a,
+		equery.AggregateVersion(version.Min(aggregate.UncommittedVersion(a)+1)),
+		equery.Time(time.Max(t)),
+	); err != nil {
+		return err
+	}
+
+	if _, _, v := a.Aggregate(); v == 0 {
+		return ErrVersionNotFound
+	}
+
+	return nil
+}
+
 // Delete fetches the aggregate's events from the event store, deletes them, and
 // calls OnDelete hooks. It returns an error if the deletion fails or any of the
 // OnDelete hooks return an error.
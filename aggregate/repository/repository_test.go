@@ -279,6 +279,66 @@ func TestRepository_FetchVersion_versionNotReached(t *testing.T) {
 	}
 }
 
+func TestRepository_FetchAt(t *testing.T) {
+	aggregateName := "foo"
+	aggregateID := uuid.New()
+	now := time.Now()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(aggregateID, aggregateName, 1), event.Time(now)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(aggregateID, aggregateName, 2), event.Time(now.Add(time.Hour))),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(aggregateID, aggregateName, 3), event.Time(now.Add(2*time.Hour))),
+	}
+
+	org := test.NewFoo(aggregateID)
+	org.RecordChange(events...)
+
+	r := repository.New(eventstore.New())
+	if err := r.Save(context.Background(), org); err != nil {
+		t.Fatalf("expected r.Save to succeed; got %#v", err)
+	}
+
+	var appliedEvents []event.Event
+	foo := test.NewFoo(aggregateID, test.ApplyEventFunc("foo", func(evt event.Event) {
+		appliedEvents = append(appliedEvents, evt)
+	}))
+
+	if err := r.FetchAt(context.Background(), foo, now.Add(90*time.Minute)); err != nil {
+		t.Fatalf("r.FetchAt should not return an error; got %#v", err)
+	}
+
+	etest.AssertEqualEvents(t, events[:2], appliedEvents)
+
+	if foo.AggregateVersion() != 2 {
+		t.Errorf("foo.AggregateVersion should return %d; got %d", 2, foo.AggregateVersion())
+	}
+
+	foo = test.NewFoo(aggregateID)
+	if err := r.FetchAt(context.Background(), foo, now.Add(time.Hour)); err != nil {
+		t.Fatalf("r.FetchAt should not return an error; got %#v", err)
+	}
+
+	if foo.AggregateVersion() != 2 {
+		t.Errorf("foo.AggregateVersion should return %d; got %d", 2, foo.AggregateVersion())
+	}
+}
+
+func TestRepository_FetchAt_beforeFirstEvent(t *testing.T) {
+	aggregateID := uuid.New()
+
+	org := test.NewFoo(aggregateID)
+	aggregate.Next(org, "foo", etest.FooEventData{A: "foo"})
+
+	r := repository.New(eventstore.New())
+	if err := r.Save(context.Background(), org); err != nil {
+		t.Fatalf("expected r.Save to succeed; got %#v", err)
+	}
+
+	foo := test.NewFoo(aggregateID)
+	if err := r.FetchAt(context.Background(), foo, time.Now().Add(-time.Hour)); !errors.Is(err, repository.ErrVersionNotFound) {
+		t.Fatalf("r.FetchAt should fail with %q; got %q", repository.ErrVersionNotFound, err)
+	}
+}
+
 func TestRepository_Delete(t *testing.T) {
 	foo := test.NewFoo(uuid.New())
 	aggregate.Next(foo, "foo", etest.FooEventData{A: "foo"})
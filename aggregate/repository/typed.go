@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/modernice/goes/aggregate"
@@ -82,6 +84,20 @@ func (r *TypedRepository[Aggregate]) FetchVersion(ctx context.Context, id uuid.U
 	return out, r.repo.FetchVersion(ctx, out, version)
 }
 
+// FetchAt retrieves the aggregate identified by its UUID as it was at the
+// given time. The underlying repository must implement FetchAt, which
+// *Repository does.
+func (r *TypedRepository[Aggregate]) FetchAt(ctx context.Context, id uuid.UUID, t time.Time) (Aggregate, error) {
+	out := r.make(id)
+	repo, ok := r.repo.(interface {
+		FetchAt(context.Context, aggregate.Aggregate, time.Time) error
+	})
+	if !ok {
+		return out, fmt.Errorf("%T does not implement FetchAt", r.repo)
+	}
+	return out, repo.FetchAt(ctx, out, t)
+}
+
 // Query returns a channel of Aggregates and a channel of errors found during
 // the query execution. The Aggregates are retrieved from the underlying
 // repository and are of the type that the TypedRepository is configured for.
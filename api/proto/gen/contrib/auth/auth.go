@@ -46,6 +46,9 @@ func NewPermissions(perms auth.PermissionsDTO) *Permissions {
 		Roles:   slice.Map(perms.Roles, commonpb.NewUUID),
 		OfActor: NewActions(perms.OfActor),
 		OfRoles: NewActions(perms.OfRoles),
+
+		DeniedOfActor: NewActions(perms.DeniedOfActor),
+		DeniedOfRoles: NewActions(perms.DeniedOfRoles),
 	}
 }
 
@@ -59,6 +62,9 @@ func (perms *Permissions) AsDTO() auth.PermissionsDTO {
 		Roles:   slice.Map(perms.GetRoles(), func(id *commonpb.UUID) uuid.UUID { return id.AsUUID() }),
 		OfActor: perms.GetOfActor().AsMap(),
 		OfRoles: perms.GetOfRoles().AsMap(),
+
+		DeniedOfActor: perms.GetDeniedOfActor().AsMap(),
+		DeniedOfRoles: perms.GetDeniedOfRoles().AsMap(),
 	}
 }
 
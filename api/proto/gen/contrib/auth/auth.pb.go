@@ -29,10 +29,12 @@ type Permissions struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ActorId *common.UUID   `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
-	Roles   []*common.UUID `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
-	OfActor *Actions       `protobuf:"bytes,3,opt,name=of_actor,json=ofActor,proto3" json:"of_actor,omitempty"`
-	OfRoles *Actions       `protobuf:"bytes,4,opt,name=of_roles,json=ofRoles,proto3" json:"of_roles,omitempty"`
+	ActorId       *common.UUID   `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	Roles         []*common.UUID `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	OfActor       *Actions       `protobuf:"bytes,3,opt,name=of_actor,json=ofActor,proto3" json:"of_actor,omitempty"`
+	OfRoles       *Actions       `protobuf:"bytes,4,opt,name=of_roles,json=ofRoles,proto3" json:"of_roles,omitempty"`
+	DeniedOfActor *Actions       `protobuf:"bytes,5,opt,name=denied_of_actor,json=deniedOfActor,proto3" json:"denied_of_actor,omitempty"`
+	DeniedOfRoles *Actions       `protobuf:"bytes,6,opt,name=denied_of_roles,json=deniedOfRoles,proto3" json:"denied_of_roles,omitempty"`
 }
 
 func (x *Permissions) Reset() {
@@ -95,6 +97,20 @@ func (x *Permissions) GetOfRoles() *Actions {
 	return nil
 }
 
+func (x *Permissions) GetDeniedOfActor() *Actions {
+	if x != nil {
+		return x.DeniedOfActor
+	}
+	return nil
+}
+
+func (x *Permissions) GetDeniedOfRoles() *Actions {
+	if x != nil {
+		return x.DeniedOfRoles
+	}
+	return nil
+}
+
 // Actions maps aggregate names to permitted actions on these aggregates.
 type Actions struct {
 	state         protoimpl.MessageState
@@ -475,7 +491,7 @@ var file_goes_contrib_auth_auth_proto_rawDesc = []byte{
 	0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x2f, 0x72, 0x65, 0x66, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x22, 0xd2, 0x01, 0x0a, 0x0b, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0xda, 0x02, 0x0a, 0x0b, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
 	0x12, 0x2c, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e,
 	0x2e, 0x55, 0x55, 0x49, 0x44, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x27,
@@ -488,94 +504,102 @@ var file_goes_contrib_auth_auth_proto_rawDesc = []byte{
 	0x0a, 0x08, 0x6f, 0x66, 0x5f, 0x72, 0x6f, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
 	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e,
 	0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x66,
-	0x52, 0x6f, 0x6c, 0x65, 0x73, 0x22, 0xa9, 0x01, 0x0a, 0x07, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x12, 0x41, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03,
-	0x28, 0x0b, 0x32, 0x27, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69,
-	0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x41,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x1a, 0x5b, 0x0a, 0x0c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x35, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e,
-	0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x47, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
-	0x01, 0x22, 0x92, 0x01, 0x0a, 0x0c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x61, 0x6e,
-	0x74, 0x73, 0x12, 0x46, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
-	0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72,
-	0x61, 0x6e, 0x74, 0x73, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x41, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
-	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x84, 0x01, 0x0a, 0x09, 0x41, 0x6c, 0x6c, 0x6f, 0x77,
-	0x73, 0x52, 0x65, 0x71, 0x12, 0x2c, 0x0a, 0x08, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f,
-	0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72,
-	0x49, 0x64, 0x12, 0x31, 0x0a, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x61, 0x67, 0x67,
-	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x52, 0x65, 0x66, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72,
-	0x65, 0x67, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x26, 0x0a,
-	0x0a, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x61,
-	0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x61, 0x6c,
-	0x6c, 0x6f, 0x77, 0x65, 0x64, 0x22, 0x2d, 0x0a, 0x0e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x41,
-	0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x69, 0x6e,
-	0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x69,
-	0x6e, 0x67, 0x49, 0x64, 0x22, 0x23, 0x0a, 0x0d, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f,
-	0x6c, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x8e, 0x01, 0x0a, 0x0e, 0x47, 0x72,
-	0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x12, 0x35, 0x0a, 0x0d,
-	0x72, 0x6f, 0x6c, 0x65, 0x5f, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f,
-	0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x52, 0x0b, 0x72, 0x6f, 0x6c, 0x65, 0x4f, 0x72, 0x41, 0x63,
-	0x74, 0x6f, 0x72, 0x12, 0x2b, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x61, 0x67, 0x67, 0x72, 0x65,
-	0x67, 0x61, 0x74, 0x65, 0x2e, 0x52, 0x65, 0x66, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
-	0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x32, 0xd1, 0x04, 0x0a, 0x0b, 0x41,
-	0x75, 0x74, 0x68, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x47, 0x65,
-	0x74, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x11, 0x2e, 0x67,
-	0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x1a,
-	0x1e, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61,
-	0x75, 0x74, 0x68, 0x2e, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12,
-	0x45, 0x0a, 0x06, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x12, 0x1c, 0x2e, 0x67, 0x6f, 0x65, 0x73,
-	0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x6c,
-	0x6c, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x1d, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63,
-	0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x6c, 0x6c, 0x6f,
-	0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x12, 0x43, 0x0a, 0x0b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70,
-	0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e,
-	0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70,
-	0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e,
-	0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x12, 0x41, 0x0a, 0x0a, 0x4c,
-	0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x20, 0x2e, 0x67, 0x6f, 0x65, 0x73,
-	0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x4c, 0x6f,
-	0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x11, 0x2e, 0x67, 0x6f,
-	0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x12, 0x49,
-	0x0a, 0x0c, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x54, 0x6f, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21,
+	0x52, 0x6f, 0x6c, 0x65, 0x73, 0x12, 0x42, 0x0a, 0x0f, 0x64, 0x65, 0x6e, 0x69, 0x65, 0x64, 0x5f,
+	0x6f, 0x66, 0x5f, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
 	0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75,
-	0x74, 0x68, 0x2e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65,
-	0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x48, 0x0a, 0x0b, 0x47, 0x72, 0x61,
-	0x6e, 0x74, 0x54, 0x6f, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e,
-	0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x47, 0x72, 0x61,
-	0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x12, 0x4c, 0x0a, 0x0f, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x72, 0x6f,
-	0x6d, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f,
+	0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0d, 0x64, 0x65, 0x6e, 0x69,
+	0x65, 0x64, 0x4f, 0x66, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x42, 0x0a, 0x0f, 0x64, 0x65, 0x6e,
+	0x69, 0x65, 0x64, 0x5f, 0x6f, 0x66, 0x5f, 0x72, 0x6f, 0x6c, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69,
+	0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0d,
+	0x64, 0x65, 0x6e, 0x69, 0x65, 0x64, 0x4f, 0x66, 0x52, 0x6f, 0x6c, 0x65, 0x73, 0x22, 0xa9, 0x01,
+	0x0a, 0x07, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x41, 0x0a, 0x07, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x67, 0x6f, 0x65,
+	0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x1a, 0x5b, 0x0a, 0x0c,
+	0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x35,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e,
+	0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74,
+	0x68, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x92, 0x01, 0x0a, 0x0c, 0x41, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x12, 0x46, 0x0a, 0x07, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x67, 0x6f,
+	0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e,
+	0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x2e, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x84,
+	0x01, 0x0a, 0x09, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x12, 0x2c, 0x0a, 0x08,
+	0x61, 0x63, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49,
+	0x44, 0x52, 0x07, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x31, 0x0a, 0x09, 0x61, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x67, 0x6f, 0x65, 0x73, 0x2e, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x52,
+	0x65, 0x66, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x26, 0x0a, 0x0a, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x22, 0x2d, 0x0a,
+	0x0e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x12,
+	0x1b, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x49, 0x64, 0x22, 0x23, 0x0a, 0x0d,
+	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x22, 0x8e, 0x01, 0x0a, 0x0e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b,
+	0x65, 0x52, 0x65, 0x71, 0x12, 0x35, 0x0a, 0x0d, 0x72, 0x6f, 0x6c, 0x65, 0x5f, 0x6f, 0x72, 0x5f,
+	0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6f,
+	0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x52, 0x0b,
+	0x72, 0x6f, 0x6c, 0x65, 0x4f, 0x72, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x2b, 0x0a, 0x06, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x67, 0x6f,
+	0x65, 0x73, 0x2e, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x52, 0x65, 0x66,
+	0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x32, 0xd1, 0x04, 0x0a, 0x0b, 0x41, 0x75, 0x74, 0x68, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d,
+	0x6f, 0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x1a, 0x1e, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x50, 0x65, 0x72, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x45, 0x0a, 0x06, 0x41, 0x6c, 0x6c, 0x6f, 0x77,
+	0x73, 0x12, 0x1c, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62,
+	0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x1a,
+	0x1d, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61,
+	0x75, 0x74, 0x68, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x12, 0x43,
+	0x0a, 0x0b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x2e,
+	0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74,
+	0x68, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71,
+	0x1a, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x55,
+	0x55, 0x49, 0x44, 0x12, 0x41, 0x0a, 0x0a, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f, 0x6c,
+	0x65, 0x12, 0x20, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62,
+	0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x6f, 0x6c, 0x65,
+	0x52, 0x65, 0x71, 0x1a, 0x11, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f,
+	0x6e, 0x2e, 0x55, 0x55, 0x49, 0x44, 0x12, 0x49, 0x0a, 0x0c, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x54,
+	0x6f, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f,
 	0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x47, 0x72, 0x61, 0x6e, 0x74,
 	0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
 	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x12, 0x4b, 0x0a, 0x0e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52,
-	0x6f, 0x6c, 0x65, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
-	0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76,
-	0x6f, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x3d,
-	0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x6f, 0x64,
-	0x65, 0x72, 0x6e, 0x69, 0x63, 0x65, 0x2f, 0x67, 0x6f, 0x65, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69,
-	0x62, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x3b, 0x61, 0x75, 0x74, 0x68, 0x70, 0x62, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x79, 0x12, 0x48, 0x0a, 0x0b, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x54, 0x6f, 0x52, 0x6f, 0x6c, 0x65,
+	0x12, 0x21, 0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e,
+	0x61, 0x75, 0x74, 0x68, 0x2e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x4c, 0x0a, 0x0f, 0x52,
+	0x65, 0x76, 0x6f, 0x6b, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x41, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x21,
+	0x2e, 0x67, 0x6f, 0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75,
+	0x74, 0x68, 0x2e, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65,
+	0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x4b, 0x0a, 0x0e, 0x52, 0x65, 0x76,
+	0x6f, 0x6b, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x21, 0x2e, 0x67, 0x6f,
+	0x65, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x2e,
+	0x47, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x6e, 0x69, 0x63, 0x65, 0x2f, 0x67,
+	0x6f, 0x65, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65,
+	0x6e, 0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x3b, 0x61,
+	0x75, 0x74, 0x68, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -611,34 +635,36 @@ var file_goes_contrib_auth_auth_proto_depIdxs = []int32{
 	10, // 1: goes.contrib.auth.Permissions.roles:type_name -> goes.common.UUID
 	1,  // 2: goes.contrib.auth.Permissions.of_actor:type_name -> goes.contrib.auth.Actions
 	1,  // 3: goes.contrib.auth.Permissions.of_roles:type_name -> goes.contrib.auth.Actions
-	8,  // 4: goes.contrib.auth.Actions.actions:type_name -> goes.contrib.auth.Actions.ActionsEntry
-	9,  // 5: goes.contrib.auth.ActionGrants.actions:type_name -> goes.contrib.auth.ActionGrants.ActionsEntry
-	10, // 6: goes.contrib.auth.AllowsReq.actor_id:type_name -> goes.common.UUID
-	11, // 7: goes.contrib.auth.AllowsReq.aggregate:type_name -> goes.aggregate.Ref
-	10, // 8: goes.contrib.auth.GrantRevokeReq.role_or_actor:type_name -> goes.common.UUID
-	11, // 9: goes.contrib.auth.GrantRevokeReq.target:type_name -> goes.aggregate.Ref
-	2,  // 10: goes.contrib.auth.Actions.ActionsEntry.value:type_name -> goes.contrib.auth.ActionGrants
-	10, // 11: goes.contrib.auth.AuthService.GetPermissions:input_type -> goes.common.UUID
-	3,  // 12: goes.contrib.auth.AuthService.Allows:input_type -> goes.contrib.auth.AllowsReq
-	5,  // 13: goes.contrib.auth.AuthService.LookupActor:input_type -> goes.contrib.auth.LookupActorReq
-	6,  // 14: goes.contrib.auth.AuthService.LookupRole:input_type -> goes.contrib.auth.LookupRoleReq
-	7,  // 15: goes.contrib.auth.AuthService.GrantToActor:input_type -> goes.contrib.auth.GrantRevokeReq
-	7,  // 16: goes.contrib.auth.AuthService.GrantToRole:input_type -> goes.contrib.auth.GrantRevokeReq
-	7,  // 17: goes.contrib.auth.AuthService.RevokeFromActor:input_type -> goes.contrib.auth.GrantRevokeReq
-	7,  // 18: goes.contrib.auth.AuthService.RevokeFromRole:input_type -> goes.contrib.auth.GrantRevokeReq
-	0,  // 19: goes.contrib.auth.AuthService.GetPermissions:output_type -> goes.contrib.auth.Permissions
-	4,  // 20: goes.contrib.auth.AuthService.Allows:output_type -> goes.contrib.auth.AllowsResp
-	10, // 21: goes.contrib.auth.AuthService.LookupActor:output_type -> goes.common.UUID
-	10, // 22: goes.contrib.auth.AuthService.LookupRole:output_type -> goes.common.UUID
-	12, // 23: goes.contrib.auth.AuthService.GrantToActor:output_type -> google.protobuf.Empty
-	12, // 24: goes.contrib.auth.AuthService.GrantToRole:output_type -> google.protobuf.Empty
-	12, // 25: goes.contrib.auth.AuthService.RevokeFromActor:output_type -> google.protobuf.Empty
-	12, // 26: goes.contrib.auth.AuthService.RevokeFromRole:output_type -> google.protobuf.Empty
-	19, // [19:27] is the sub-list for method output_type
-	11, // [11:19] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	1,  // 4: goes.contrib.auth.Permissions.denied_of_actor:type_name -> goes.contrib.auth.Actions
+	1,  // 5: goes.contrib.auth.Permissions.denied_of_roles:type_name -> goes.contrib.auth.Actions
+	8,  // 6: goes.contrib.auth.Actions.actions:type_name -> goes.contrib.auth.Actions.ActionsEntry
+	9,  // 7: goes.contrib.auth.ActionGrants.actions:type_name -> goes.contrib.auth.ActionGrants.ActionsEntry
+	10, // 8: goes.contrib.auth.AllowsReq.actor_id:type_name -> goes.common.UUID
+	11, // 9: goes.contrib.auth.AllowsReq.aggregate:type_name -> goes.aggregate.Ref
+	10, // 10: goes.contrib.auth.GrantRevokeReq.role_or_actor:type_name -> goes.common.UUID
+	11, // 11: goes.contrib.auth.GrantRevokeReq.target:type_name -> goes.aggregate.Ref
+	2,  // 12: goes.contrib.auth.Actions.ActionsEntry.value:type_name -> goes.contrib.auth.ActionGrants
+	10, // 13: goes.contrib.auth.AuthService.GetPermissions:input_type -> goes.common.UUID
+	3,  // 14: goes.contrib.auth.AuthService.Allows:input_type -> goes.contrib.auth.AllowsReq
+	5,  // 15: goes.contrib.auth.AuthService.LookupActor:input_type -> goes.contrib.auth.LookupActorReq
+	6,  // 16: goes.contrib.auth.AuthService.LookupRole:input_type -> goes.contrib.auth.LookupRoleReq
+	7,  // 17: goes.contrib.auth.AuthService.GrantToActor:input_type -> goes.contrib.auth.GrantRevokeReq
+	7,  // 18: goes.contrib.auth.AuthService.GrantToRole:input_type -> goes.contrib.auth.GrantRevokeReq
+	7,  // 19: goes.contrib.auth.AuthService.RevokeFromActor:input_type -> goes.contrib.auth.GrantRevokeReq
+	7,  // 20: goes.contrib.auth.AuthService.RevokeFromRole:input_type -> goes.contrib.auth.GrantRevokeReq
+	0,  // 21: goes.contrib.auth.AuthService.GetPermissions:output_type -> goes.contrib.auth.Permissions
+	4,  // 22: goes.contrib.auth.AuthService.Allows:output_type -> goes.contrib.auth.AllowsResp
+	10, // 23: goes.contrib.auth.AuthService.LookupActor:output_type -> goes.common.UUID
+	10, // 24: goes.contrib.auth.AuthService.LookupRole:output_type -> goes.common.UUID
+	12, // 25: goes.contrib.auth.AuthService.GrantToActor:output_type -> google.protobuf.Empty
+	12, // 26: goes.contrib.auth.AuthService.GrantToRole:output_type -> google.protobuf.Empty
+	12, // 27: goes.contrib.auth.AuthService.RevokeFromActor:output_type -> google.protobuf.Empty
+	12, // 28: goes.contrib.auth.AuthService.RevokeFromRole:output_type -> google.protobuf.Empty
+	21, // [21:29] is the sub-list for method output_type
+	13, // [13:21] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_goes_contrib_auth_auth_proto_init() }
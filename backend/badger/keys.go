@@ -0,0 +1,91 @@
+package badger
+
+import (
+	"encoding/binary"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/internal/storage"
+)
+
+// Keys of the store:
+//
+//	e/<id>                                        -> entry
+//	t/<time><id>                                  -> (empty)
+//	n/<name>\x00<time><id>                        -> (empty)
+//	a/<aggregate name>\x00<aggregate id><version> -> <id>
+//
+// Times and versions are encoded in big-endian order, so that the keys of an
+// index are sorted by time or version. Events of an aggregate that have no
+// version are indexed with their id appended to the aggregate key.
+const (
+	eventPrefix     = 'e'
+	timePrefix      = 't'
+	namePrefix      = 'n'
+	aggregatePrefix = 'a'
+)
+
+func eventKey(id uuid.UUID) []byte {
+	return append([]byte{eventPrefix, '/'}, id[:]...)
+}
+
+func timeIndexPrefix() []byte {
+	return []byte{timePrefix, '/'}
+}
+
+func nameIndexPrefix(name string) []byte {
+	b := make([]byte, 0, 3+len(name))
+	b = append(b, namePrefix, '/')
+	b = append(b, name...)
+	return append(b, 0)
+}
+
+func aggregateNamePrefix(name string) []byte {
+	b := make([]byte, 0, 3+len(name))
+	b = append(b, aggregatePrefix, '/')
+	b = append(b, name...)
+	return append(b, 0)
+}
+
+func aggregateIndexPrefix(name string, id uuid.UUID) []byte {
+	return append(aggregateNamePrefix(name), id[:]...)
+}
+
+func aggregateKey(name string, id uuid.UUID, version int) []byte {
+	return binary.BigEndian.AppendUint64(aggregateIndexPrefix(name, id), uint64(version))
+}
+
+func timeIndexKey(prefix []byte, t int64, id uuid.UUID) []byte {
+	b := make([]byte, 0, len(prefix)+8+16)
+	b = append(b, prefix...)
+	b = storage.AppendTime(b, t)
+	return append(b, id[:]...)
+}
+
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+// entryKeys returns the keys of the entry and its index keys.
+func entryKeys(e storage.Entry) ([]keyValue, error) {
+	b, err := e.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []keyValue{
+		{key: eventKey(e.ID), value: b},
+		{key: timeIndexKey(timeIndexPrefix(), e.Time, e.ID)},
+		{key: timeIndexKey(nameIndexPrefix(e.Name), e.Time, e.ID)},
+	}
+	if e.AggregateName != "" {
+		key := aggregateKey(e.AggregateName, e.AggregateID, e.AggregateVersion)
+		if e.AggregateVersion == 0 {
+			// events without a version do not occupy a version of the
+			// aggregate, so their keys must be unique
+			key = append(key, e.ID[:]...)
+		}
+		out = append(out, keyValue{key: key, value: e.ID[:]})
+	}
+	return out, nil
+}
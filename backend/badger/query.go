@@ -0,0 +1,315 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/event"
+)
+
+// Query queries the event store for events. A query is answered by iterating
+// over the index that fits the query best, and events are streamed directly
+// from the iterator if the order of the index matches the sorting of the
+// query. Otherwise, the matching events are sorted in memory before they are
+// returned.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.Open(); err != nil {
+		return nil, nil, fmt.Errorf("open: %w", err)
+	}
+
+	p := planQuery(q)
+	skip := event.UndecodableHandler(q)
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		var buffer []event.Event
+
+		push := func(e storage.Entry) error {
+			evt, err := e.Raw().DecodeContext(ctx, s.enc)
+			if err != nil {
+				var decodeErr *event.DecodeError
+				if skip != nil && errors.As(err, &decodeErr) {
+					skip(decodeErr)
+					return nil
+				}
+				return fmt.Errorf("decode event: %w", err)
+			}
+
+			if !p.sorted {
+				buffer = append(buffer, evt)
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- evt:
+				return nil
+			}
+		}
+
+		if err := s.db.View(func(txn *badger.Txn) error {
+			return p.run(ctx, txn, q, push)
+		}); err != nil {
+			select {
+			case <-ctx.Done():
+			case errs <- err:
+			}
+			return
+		}
+
+		if p.sorted {
+			return
+		}
+
+		for _, evt := range event.SortMulti(buffer, q.Sortings()...) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// plan describes how a query is answered.
+type plan struct {
+	// ids are the ids of the events that are looked up directly.
+	ids []uuid.UUID
+
+	// scans are the index scans that yield the candidates of the query.
+	scans []scan
+
+	// sorted reports whether the candidates are yielded in the order that is
+	// requested by the query.
+	sorted bool
+}
+
+type scan struct {
+	prefix []byte
+
+	// min and max are inclusive bounds of the keys, or nil.
+	min, max []byte
+
+	reverse bool
+
+	// idFromValue reports whether the event id is the value of the index
+	// keys. Otherwise, the event id is the suffix of the keys.
+	idFromValue bool
+}
+
+func planQuery(q event.Query) plan {
+	sorts := q.Sortings()
+
+	if ids := q.IDs(); len(ids) > 0 {
+		return plan{ids: ids, sorted: len(sorts) == 0}
+	}
+
+	if refs := q.Aggregates(); len(refs) > 0 && allNamed(refs) {
+		return planAggregates(refs, sorts)
+	}
+
+	if names, ids := q.AggregateNames(), q.AggregateIDs(); len(names) > 0 {
+		refs := make([]event.AggregateRef, 0, len(names)*max(len(ids), 1))
+		for _, name := range names {
+			if len(ids) == 0 {
+				refs = append(refs, event.AggregateRef{Name: name})
+				continue
+			}
+			for _, id := range ids {
+				refs = append(refs, event.AggregateRef{Name: name, ID: id})
+			}
+		}
+		return planAggregates(refs, sorts)
+	}
+
+	dir, inOrder := storage.TimeOrder(sorts)
+	lower, upper := timeBounds(q)
+
+	if names := q.Names(); len(names) > 0 {
+		p := plan{sorted: len(sorts) == 0 || (inOrder && len(names) == 1)}
+		for _, name := range names {
+			p.scans = append(p.scans, boundedScan(nameIndexPrefix(name), lower, upper, dir == event.SortDesc))
+		}
+		return p
+	}
+
+	return plan{
+		scans:  []scan{boundedScan(timeIndexPrefix(), lower, upper, dir == event.SortDesc)},
+		sorted: inOrder,
+	}
+}
+
+func planAggregates(refs []event.AggregateRef, sorts []event.SortOptions) plan {
+	dir, inOrder := storage.VersionOrder(sorts)
+	p := plan{sorted: len(sorts) == 0 || (inOrder && len(refs) == 1 && refs[0].ID != uuid.Nil)}
+	for _, ref := range refs {
+		prefix := aggregateNamePrefix(ref.Name)
+		if ref.ID != uuid.Nil {
+			prefix = aggregateIndexPrefix(ref.Name, ref.ID)
+		}
+		p.scans = append(p.scans, scan{
+			prefix:      prefix,
+			reverse:     p.sorted && dir == event.SortDesc,
+			idFromValue: true,
+		})
+	}
+	return p
+}
+
+func allNamed(refs []event.AggregateRef) bool {
+	for _, ref := range refs {
+		if ref.Name == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// timeBounds returns the bounds of the time index suffixes of a query.
+func timeBounds(q event.Query) (lower, upper []byte) {
+	times := q.Times()
+	if times == nil {
+		return nil, nil
+	}
+
+	if t := times.Min(); !t.IsZero() {
+		lower = storage.AppendTime(nil, t.UnixNano())
+	}
+
+	if t := times.Max(); !t.IsZero() {
+		upper = append(storage.AppendTime(nil, t.UnixNano()), bytes.Repeat([]byte{0xff}, 16)...)
+	}
+
+	return
+}
+
+func boundedScan(prefix, lower, upper []byte, reverse bool) scan {
+	sc := scan{prefix: prefix, reverse: reverse}
+	if lower != nil {
+		sc.min = append(append([]byte(nil), prefix...), lower...)
+	}
+	if upper != nil {
+		sc.max = append(append([]byte(nil), prefix...), upper...)
+	}
+	return sc
+}
+
+func (p plan) run(ctx context.Context, txn *badger.Txn, q event.Query, push func(storage.Entry) error) error {
+	var seen map[uuid.UUID]bool
+	if len(p.ids) > 1 || len(p.scans) > 1 {
+		seen = make(map[uuid.UUID]bool)
+	}
+
+	visit := func(id uuid.UUID) error {
+		if seen != nil {
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+
+		e, err := getEntry(txn, id)
+		if errors.Is(err, ErrEventNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !e.Matches(q) {
+			return nil
+		}
+
+		return push(e)
+	}
+
+	for _, id := range p.ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	for _, sc := range p.scans {
+		if err := sc.run(ctx, txn, visit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sc scan) run(ctx context.Context, txn *badger.Txn, visit func(uuid.UUID) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = sc.prefix
+	opts.Reverse = sc.reverse
+	opts.PrefetchValues = sc.idFromValue
+
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	start := sc.min
+	if start == nil {
+		start = sc.prefix
+	}
+	if sc.reverse {
+		if start = sc.max; start == nil {
+			start = append(append([]byte(nil), sc.prefix...), bytes.Repeat([]byte{0xff}, 32)...)
+		}
+	}
+
+	for it.Seek(start); it.ValidForPrefix(sc.prefix); it.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		item := it.Item()
+		key := item.Key()
+
+		if !sc.reverse && sc.max != nil && bytes.Compare(key, sc.max) > 0 {
+			return nil
+		}
+
+		if sc.reverse && sc.min != nil && bytes.Compare(key, sc.min) < 0 {
+			return nil
+		}
+
+		var id uuid.UUID
+		if sc.idFromValue {
+			if err := item.Value(func(v []byte) error {
+				copy(id[:], v)
+				return nil
+			}); err != nil {
+				return fmt.Errorf("read index: %w", err)
+			}
+		} else {
+			copy(id[:], key[len(key)-16:])
+		}
+
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
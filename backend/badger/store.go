@@ -0,0 +1,436 @@
+// Package badger provides an embedded event store that is backed by BadgerDB.
+// The store does not depend on an external database server, which makes it
+// suitable for edge devices, CLIs, and single-node services.
+package badger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	stdtime "time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+const (
+	// DefaultGCInterval is the default interval in which the value log of the
+	// database is garbage collected.
+	DefaultGCInterval = 5 * stdtime.Minute
+
+	// DefaultGCDiscardRatio is the default discard ratio of the value log
+	// garbage collection.
+	DefaultGCDiscardRatio = 0.5
+)
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = storage.ErrEventNotFound
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = storage.ErrDuplicateEvent
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = storage.ErrVersionExists
+)
+
+// EventStore is an event store that is backed by BadgerDB. Events are stored
+// by id, and are indexed by time, by event name, and by aggregate, so that
+// most queries are answered by iterating over a single index.
+type EventStore struct {
+	enc         codec.Encoding
+	dir         string
+	inMemory    bool
+	badgerOpts  func(badger.Options) badger.Options
+	gcInterval  stdtime.Duration
+	gcRatio     float64
+	ttl         func(event.Event) stdtime.Duration
+	ownsDB      bool
+	onceOpen    sync.Once
+	onceClose   sync.Once
+	db          *badger.DB
+	stopGC      chan struct{}
+	gcDone      chan struct{}
+	openErr     error
+	closeResult error
+}
+
+// EventStoreOption is an option for the BadgerDB event store.
+type EventStoreOption func(*EventStore)
+
+// Dir returns an EventStoreOption that specifies the directory in which the
+// database is stored.
+func Dir(dir string) EventStoreOption {
+	return func(s *EventStore) {
+		s.dir = dir
+	}
+}
+
+// InMemory returns an EventStoreOption that keeps the database in memory
+// instead of on disk. In-memory stores are intended for testing.
+func InMemory() EventStoreOption {
+	return func(s *EventStore) {
+		s.inMemory = true
+	}
+}
+
+// DB returns an EventStoreOption that uses the provided database instead of
+// opening one. Close does not close a provided database.
+func DB(db *badger.DB) EventStoreOption {
+	return func(s *EventStore) {
+		s.db = db
+	}
+}
+
+// BadgerOptions returns an EventStoreOption that allows to modify the options
+// that are used to open the database, e.g., to tune memory usage on devices
+// with limited resources:
+//
+//	badger.NewEventStore(enc, badger.Dir("/data/events"), badger.BadgerOptions(func(opts bdb.Options) bdb.Options {
+//		return opts.WithMemTableSize(16 << 20).WithNumCompactors(2)
+//	}))
+func BadgerOptions(fn func(badger.Options) badger.Options) EventStoreOption {
+	return func(s *EventStore) {
+		s.badgerOpts = fn
+	}
+}
+
+// GCInterval returns an EventStoreOption that specifies the interval in which
+// the value log of the database is garbage collected in the background.
+// Defaults to DefaultGCInterval. A non-positive interval disables the
+// background garbage collection.
+func GCInterval(d stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.gcInterval = d
+	}
+}
+
+// GCDiscardRatio returns an EventStoreOption that specifies the discard ratio
+// of the value log garbage collection. A value log file is rewritten if at
+// least the given ratio of the file can be discarded. Defaults to
+// DefaultGCDiscardRatio.
+func GCDiscardRatio(ratio float64) EventStoreOption {
+	return func(s *EventStore) {
+		s.gcRatio = ratio
+	}
+}
+
+// TTL returns an EventStoreOption that expires the events with the given names
+// after the given duration. If no names are provided, all events expire. TTL
+// can be used to store ephemeral events, e.g. telemetry, alongside durable
+// domain events. TTL overrides previous TTL and EventTTL options for the given
+// names.
+func TTL(ttl stdtime.Duration, names ...string) EventStoreOption {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	return func(s *EventStore) {
+		prev := s.ttl
+		EventTTL(func(evt event.Event) stdtime.Duration {
+			if len(wanted) == 0 || wanted[evt.Name()] {
+				return ttl
+			}
+			if prev != nil {
+				return prev(evt)
+			}
+			return 0
+		})(s)
+	}
+}
+
+// EventTTL returns an EventStoreOption that expires events after the duration
+// that is returned by fn. Events for which fn returns a non-positive duration
+// do not expire.
+//
+// Expired events are no longer returned by Find and Query, and their disk
+// space is reclaimed by the compaction and the value log garbage collection of
+// BadgerDB. The time-to-live is stored with the events and cannot be changed
+// after insertion.
+func EventTTL(fn func(event.Event) stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.ttl = fn
+	}
+}
+
+// NewEventStore returns a new BadgerDB event store. The database is opened on
+// first use, or by calling Open explicitly. Either the Dir, InMemory, or DB
+// option must be provided.
+func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:        enc,
+		gcInterval: DefaultGCInterval,
+		gcRatio:    DefaultGCDiscardRatio,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// DB returns the underlying database. DB must only be called AFTER the
+// database has been opened, unless the database was provided using the DB
+// option.
+func (s *EventStore) DB() *badger.DB {
+	return s.db
+}
+
+// Open opens the database and starts the background garbage collection of
+// the value log. Open is automatically called from the Insert, Find, Query,
+// and Delete methods if not called explicitly.
+func (s *EventStore) Open() error {
+	s.onceOpen.Do(func() {
+		if s.db == nil {
+			if s.openErr = s.open(); s.openErr != nil {
+				return
+			}
+			s.ownsDB = true
+		}
+
+		if s.gcInterval > 0 && !s.db.Opts().InMemory {
+			s.stopGC = make(chan struct{})
+			s.gcDone = make(chan struct{})
+			go s.runGC()
+		}
+	})
+	return s.openErr
+}
+
+func (s *EventStore) open() error {
+	if s.dir == "" && !s.inMemory {
+		return errors.New("missing directory")
+	}
+
+	opts := badger.DefaultOptions(s.dir).WithLogger(nil)
+	if s.inMemory {
+		opts = opts.WithDir("").WithValueDir("").WithInMemory(true)
+	}
+
+	if s.badgerOpts != nil {
+		opts = s.badgerOpts(opts)
+	}
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	s.db = db
+
+	return nil
+}
+
+// Close stops the background garbage collection and closes the database, if
+// it was opened by the store.
+func (s *EventStore) Close() error {
+	s.onceClose.Do(func() {
+		if s.stopGC != nil {
+			close(s.stopGC)
+			<-s.gcDone
+		}
+		if s.ownsDB && s.db != nil {
+			s.closeResult = s.db.Close()
+		}
+	})
+	return s.closeResult
+}
+
+// Insert inserts events into the event store. Either all or none of the
+// events are inserted. Inserting events that exceed the transaction limits of
+// BadgerDB fails with badger.ErrTxnTooBig; insert such events in batches.
+func (s *EventStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]storage.Entry, len(events))
+	ttls := make([]stdtime.Duration, len(events))
+	for i, evt := range events {
+		data, err := storage.Marshal(ctx, s.enc, evt)
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+		entries[i] = storage.NewEntry(evt, data)
+		if s.ttl != nil {
+			ttls[i] = s.ttl(evt)
+		}
+	}
+
+	err := s.db.Update(func(txn *badger.Txn) error {
+		for i, e := range entries {
+			if err := insertEntry(txn, e, ttls[i]); err != nil {
+				return fmt.Errorf("%s:%s %w", e.Name, e.ID, err)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return fmt.Errorf("insert events: %w (%w)", ErrVersionExists, err)
+	}
+	if err != nil {
+		return fmt.Errorf("insert events: %w", err)
+	}
+
+	return nil
+}
+
+// insertEntry inserts the entry and its index keys. If ttl is positive, all
+// keys expire together after ttl.
+func insertEntry(txn *badger.Txn, e storage.Entry, ttl stdtime.Duration) error {
+	keys, err := entryKeys(e)
+	if err != nil {
+		return err
+	}
+
+	if err := mustNotExist(txn, eventKey(e.ID), ErrDuplicateEvent); err != nil {
+		return err
+	}
+
+	if e.AggregateVersion > 0 {
+		if err := mustNotExist(txn, aggregateKey(e.AggregateName, e.AggregateID, e.AggregateVersion), ErrVersionExists); err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range keys {
+		entry := badger.NewEntry(kv.key, kv.value)
+		if ttl > 0 {
+			entry = entry.WithTTL(ttl)
+		}
+		if err := txn.SetEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mustNotExist(txn *badger.Txn, key []byte, exists error) error {
+	_, err := txn.Get(key)
+	if err == nil {
+		return exists
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	return s.Insert(ctx, storage.Events(events)...)
+}
+
+// Find fetches the event with the given id from the event store.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Open(); err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	var e storage.Entry
+	if err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		e, err = getEntry(txn, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return e.Raw().DecodeContext(ctx, s.enc)
+}
+
+func getEntry(txn *badger.Txn, id uuid.UUID) (storage.Entry, error) {
+	item, err := txn.Get(eventKey(id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return storage.Entry{}, fmt.Errorf("%s: %w", id, ErrEventNotFound)
+	}
+	if err != nil {
+		return storage.Entry{}, fmt.Errorf("get event: %w", err)
+	}
+
+	var e storage.Entry
+	if err := item.Value(e.Unmarshal); err != nil {
+		return storage.Entry{}, fmt.Errorf("decode event %s: %w", id, err)
+	}
+
+	return e, nil
+}
+
+// Delete deletes events from the event store. Events that do not exist are
+// ignored.
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.db.Update(func(txn *badger.Txn) error {
+		for _, evt := range events {
+			e, err := getEntry(txn, evt.ID())
+			if errors.Is(err, ErrEventNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			keys, err := entryKeys(e)
+			if err != nil {
+				return fmt.Errorf("delete %q event: %w", e.Name, err)
+			}
+
+			for _, kv := range keys {
+				if err := txn.Delete(kv.key); err != nil {
+					return fmt.Errorf("delete %q event: %w", e.Name, err)
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("delete events: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EventStore) runGC() {
+	defer close(s.gcDone)
+
+	ticker := stdtime.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopGC:
+			return
+		case <-ticker.C:
+			// RunValueLogGC rewrites at most one file per call, so it is called
+			// until there is nothing left to rewrite.
+			for s.db.RunValueLogGC(s.gcRatio) == nil {
+				select {
+				case <-s.stopGC:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
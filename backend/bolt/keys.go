@@ -0,0 +1,43 @@
+package bolt
+
+import (
+	"encoding/binary"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/internal/storage"
+)
+
+// Buckets of the store:
+//
+//	events                             <id>             -> entry
+//	time                               <time><id>       -> (empty)
+//	names/<name>                       <time><id>       -> (empty)
+//	aggregates/<aggregate name>/<id>   <version>        -> <id>
+//
+// Times and versions are encoded in big-endian order, so that the keys of an
+// index bucket are sorted by time or version. Events of an aggregate that have
+// no version are indexed with their id appended to the version key.
+var (
+	eventsBucket     = []byte("events")
+	timeBucket       = []byte("time")
+	namesBucket      = []byte("names")
+	aggregatesBucket = []byte("aggregates")
+)
+
+var rootBuckets = [][]byte{eventsBucket, timeBucket, namesBucket, aggregatesBucket}
+
+func timeKey(t int64, id uuid.UUID) []byte {
+	b := make([]byte, 0, 8+16)
+	b = storage.AppendTime(b, t)
+	return append(b, id[:]...)
+}
+
+func versionKey(version int, id uuid.UUID) []byte {
+	key := binary.BigEndian.AppendUint64(make([]byte, 0, 8+16), uint64(version))
+	if version == 0 {
+		// events without a version do not occupy a version of the aggregate,
+		// so their keys must be unique
+		key = append(key, id[:]...)
+	}
+	return key
+}
@@ -0,0 +1,341 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/event"
+)
+
+// Query queries the event store for events. A query is answered by iterating
+// over the bucket that fits the query best. The matching events are read
+// within a single read transaction before they are returned, so that
+// consumers of the returned channel may write to the store without blocking
+// the transaction. The events are sorted in memory if the order of the bucket
+// does not match the sorting of the query.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.Open(); err != nil {
+		return nil, nil, fmt.Errorf("open: %w", err)
+	}
+
+	p := planQuery(q)
+
+	var entries []storage.Entry
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		return p.run(ctx, tx, q, func(e storage.Entry) {
+			entries = append(entries, e)
+		})
+	}); err != nil {
+		return nil, nil, fmt.Errorf("query events: %w", err)
+	}
+
+	skip := event.UndecodableHandler(q)
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		events := make([]event.Event, 0, len(entries))
+		for _, e := range entries {
+			evt, err := e.Raw().DecodeContext(ctx, s.enc)
+			if err != nil {
+				var decodeErr *event.DecodeError
+				if skip != nil && errors.As(err, &decodeErr) {
+					skip(decodeErr)
+					continue
+				}
+				select {
+				case <-ctx.Done():
+				case errs <- fmt.Errorf("decode event: %w", err):
+				}
+				return
+			}
+			events = append(events, evt)
+		}
+
+		if !p.sorted {
+			events = event.SortMulti(events, q.Sortings()...)
+		}
+
+		for _, evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// plan describes how a query is answered.
+type plan struct {
+	// ids are the ids of the events that are looked up directly.
+	ids []uuid.UUID
+
+	// scans are the bucket scans that yield the candidates of the query.
+	scans []scan
+
+	// sorted reports whether the candidates are yielded in the order that is
+	// requested by the query.
+	sorted bool
+}
+
+type scan struct {
+	// path is the path to the bucket, starting at a root bucket.
+	path [][]byte
+
+	// nested reports whether the nested buckets of the bucket are scanned
+	// instead of the bucket itself.
+	nested bool
+
+	// min and max are inclusive bounds of the keys, or nil.
+	min, max []byte
+
+	reverse bool
+
+	// idFromValue reports whether the event id is the value of the keys.
+	// Otherwise, the event id is the suffix of the keys.
+	idFromValue bool
+}
+
+func planQuery(q event.Query) plan {
+	sorts := q.Sortings()
+
+	if ids := q.IDs(); len(ids) > 0 {
+		return plan{ids: ids, sorted: len(sorts) == 0}
+	}
+
+	if refs := q.Aggregates(); len(refs) > 0 && allNamed(refs) {
+		return planAggregates(refs, sorts)
+	}
+
+	if names, ids := q.AggregateNames(), q.AggregateIDs(); len(names) > 0 {
+		refs := make([]event.AggregateRef, 0, len(names)*max(len(ids), 1))
+		for _, name := range names {
+			if len(ids) == 0 {
+				refs = append(refs, event.AggregateRef{Name: name})
+				continue
+			}
+			for _, id := range ids {
+				refs = append(refs, event.AggregateRef{Name: name, ID: id})
+			}
+		}
+		return planAggregates(refs, sorts)
+	}
+
+	dir, inOrder := storage.TimeOrder(sorts)
+	lower, upper := timeBounds(q)
+	reverse := dir == event.SortDesc
+
+	if names := q.Names(); len(names) > 0 {
+		p := plan{sorted: len(sorts) == 0 || (inOrder && len(names) == 1)}
+		for _, name := range names {
+			p.scans = append(p.scans, scan{
+				path:    [][]byte{namesBucket, []byte(name)},
+				min:     lower,
+				max:     upper,
+				reverse: reverse,
+			})
+		}
+		return p
+	}
+
+	return plan{
+		scans: []scan{{
+			path:    [][]byte{timeBucket},
+			min:     lower,
+			max:     upper,
+			reverse: reverse,
+		}},
+		sorted: inOrder,
+	}
+}
+
+func planAggregates(refs []event.AggregateRef, sorts []event.SortOptions) plan {
+	dir, inOrder := storage.VersionOrder(sorts)
+	p := plan{sorted: len(sorts) == 0 || (inOrder && len(refs) == 1 && refs[0].ID != uuid.Nil)}
+	for _, ref := range refs {
+		sc := scan{
+			path:        [][]byte{aggregatesBucket, []byte(ref.Name)},
+			nested:      ref.ID == uuid.Nil,
+			reverse:     p.sorted && dir == event.SortDesc,
+			idFromValue: true,
+		}
+		if id := ref.ID; id != uuid.Nil {
+			sc.path = append(sc.path, id[:])
+		}
+		p.scans = append(p.scans, sc)
+	}
+	return p
+}
+
+func allNamed(refs []event.AggregateRef) bool {
+	for _, ref := range refs {
+		if ref.Name == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// timeBounds returns the bounds of the time index keys of a query.
+func timeBounds(q event.Query) (lower, upper []byte) {
+	times := q.Times()
+	if times == nil {
+		return nil, nil
+	}
+
+	if t := times.Min(); !t.IsZero() {
+		lower = storage.AppendTime(nil, t.UnixNano())
+	}
+
+	if t := times.Max(); !t.IsZero() {
+		upper = append(storage.AppendTime(nil, t.UnixNano()), bytes.Repeat([]byte{0xff}, 16)...)
+	}
+
+	return
+}
+
+func (p plan) run(ctx context.Context, tx *bbolt.Tx, q event.Query, push func(storage.Entry)) error {
+	var seen map[uuid.UUID]bool
+	if len(p.ids) > 1 || len(p.scans) > 1 {
+		seen = make(map[uuid.UUID]bool)
+	}
+
+	visit := func(id uuid.UUID) error {
+		if seen != nil {
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+
+		e, err := getEntry(tx, id)
+		if errors.Is(err, ErrEventNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if e.Matches(q) {
+			push(e)
+		}
+
+		return nil
+	}
+
+	for _, id := range p.ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	for _, sc := range p.scans {
+		if err := sc.run(ctx, tx, visit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sc scan) run(ctx context.Context, tx *bbolt.Tx, visit func(uuid.UUID) error) error {
+	b := tx.Bucket(sc.path[0])
+	for _, name := range sc.path[1:] {
+		if b == nil {
+			return nil
+		}
+		b = b.Bucket(name)
+	}
+
+	if b == nil {
+		return nil
+	}
+
+	if !sc.nested {
+		return sc.scan(ctx, b, visit)
+	}
+
+	return b.ForEachBucket(func(name []byte) error {
+		return sc.scan(ctx, b.Bucket(name), visit)
+	})
+}
+
+func (sc scan) scan(ctx context.Context, b *bbolt.Bucket, visit func(uuid.UUID) error) error {
+	c := b.Cursor()
+
+	next := c.Next
+	k, v := sc.first(c)
+	if sc.reverse {
+		next = c.Prev
+	}
+
+	for ; k != nil; k, v = next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !sc.reverse && sc.max != nil && bytes.Compare(k, sc.max) > 0 {
+			return nil
+		}
+
+		if sc.reverse && sc.min != nil && bytes.Compare(k, sc.min) < 0 {
+			return nil
+		}
+
+		var id uuid.UUID
+		if sc.idFromValue {
+			copy(id[:], v)
+		} else {
+			copy(id[:], k[len(k)-16:])
+		}
+
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// first positions the cursor at the first key of the scan.
+func (sc scan) first(c *bbolt.Cursor) ([]byte, []byte) {
+	if !sc.reverse {
+		if sc.min != nil {
+			return c.Seek(sc.min)
+		}
+		return c.First()
+	}
+
+	if sc.max == nil {
+		return c.Last()
+	}
+
+	k, v := c.Seek(sc.max)
+	if k == nil {
+		return c.Last()
+	}
+	if bytes.Compare(k, sc.max) > 0 {
+		return c.Prev()
+	}
+	return k, v
+}
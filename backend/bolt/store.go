@@ -0,0 +1,387 @@
+// Package bolt provides an embedded event store that is backed by bbolt. The
+// store is pure Go, keeps all events in a single file, and does not depend on
+// an external database server, which makes it a simple persistent option for
+// single-node services.
+package bolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// DefaultOpenTimeout is the default time to wait for the file lock of the
+// database when opening it.
+const DefaultOpenTimeout = 5 * stdtime.Second
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = storage.ErrEventNotFound
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = storage.ErrDuplicateEvent
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = storage.ErrVersionExists
+)
+
+// EventStore is an event store that is backed by bbolt. Events are stored by
+// id, and are indexed by time, by event name, and by aggregate in separate
+// buckets, so that most queries are answered by iterating over a single
+// bucket. Each aggregate has its own bucket, in which its events are sorted by
+// version.
+type EventStore struct {
+	enc         codec.Encoding
+	path        string
+	mode        os.FileMode
+	boltOpts    *bbolt.Options
+	ownsDB      bool
+	onceOpen    sync.Once
+	onceClose   sync.Once
+	db          *bbolt.DB
+	openErr     error
+	closeResult error
+}
+
+// EventStoreOption is an option for the bbolt event store.
+type EventStoreOption func(*EventStore)
+
+// Path returns an EventStoreOption that specifies the file in which the
+// database is stored. The file is created if it does not exist.
+func Path(path string) EventStoreOption {
+	return func(s *EventStore) {
+		s.path = path
+	}
+}
+
+// FileMode returns an EventStoreOption that specifies the permissions of the
+// database file if it is created by the store. Defaults to 0600.
+func FileMode(mode os.FileMode) EventStoreOption {
+	return func(s *EventStore) {
+		s.mode = mode
+	}
+}
+
+// DB returns an EventStoreOption that uses the provided database instead of
+// opening one. Close does not close a provided database.
+func DB(db *bbolt.DB) EventStoreOption {
+	return func(s *EventStore) {
+		s.db = db
+	}
+}
+
+// BoltOptions returns an EventStoreOption that specifies the options that are
+// used to open the database. Defaults to a timeout of DefaultOpenTimeout.
+func BoltOptions(opts *bbolt.Options) EventStoreOption {
+	return func(s *EventStore) {
+		s.boltOpts = opts
+	}
+}
+
+// NewEventStore returns a new bbolt event store. The database is opened on
+// first use, or by calling Open explicitly. Either the Path or DB option must
+// be provided.
+func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:      enc,
+		mode:     0o600,
+		boltOpts: &bbolt.Options{Timeout: DefaultOpenTimeout},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// DB returns the underlying database. DB must only be called AFTER the
+// database has been opened, unless the database was provided using the DB
+// option.
+func (s *EventStore) DB() *bbolt.DB {
+	return s.db
+}
+
+// Open opens the database and creates its buckets. Open is automatically
+// called from the Insert, Find, Query, and Delete methods if not called
+// explicitly.
+func (s *EventStore) Open() error {
+	s.onceOpen.Do(func() {
+		if s.db == nil {
+			if s.path == "" {
+				s.openErr = errors.New("missing path")
+				return
+			}
+
+			db, err := bbolt.Open(s.path, s.mode, s.boltOpts)
+			if err != nil {
+				s.openErr = fmt.Errorf("open database: %w", err)
+				return
+			}
+			s.db = db
+			s.ownsDB = true
+		}
+
+		if s.db.IsReadOnly() {
+			return
+		}
+
+		if err := s.db.Update(func(tx *bbolt.Tx) error {
+			for _, name := range rootBuckets {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return fmt.Errorf("create %q bucket: %w", name, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			s.openErr = err
+		}
+	})
+	return s.openErr
+}
+
+// Close closes the database, if it was opened by the store.
+func (s *EventStore) Close() error {
+	s.onceClose.Do(func() {
+		if s.ownsDB && s.db != nil {
+			s.closeResult = s.db.Close()
+		}
+	})
+	return s.closeResult
+}
+
+// Insert inserts events into the event store. Either all or none of the
+// events are inserted.
+func (s *EventStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]storage.Entry, len(events))
+	for i, evt := range events {
+		data, err := storage.Marshal(ctx, s.enc, evt)
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+		entries[i] = storage.NewEntry(evt, data)
+	}
+
+	if err := s.db.Update(func(tx *bbolt.Tx) error {
+		for _, e := range entries {
+			if err := insertEntry(tx, e); err != nil {
+				return fmt.Errorf("%s:%s %w", e.Name, e.ID, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("insert events: %w", err)
+	}
+
+	return nil
+}
+
+func insertEntry(tx *bbolt.Tx, e storage.Entry) error {
+	b, err := e.Marshal()
+	if err != nil {
+		return err
+	}
+
+	events := tx.Bucket(eventsBucket)
+	if events.Get(e.ID[:]) != nil {
+		return ErrDuplicateEvent
+	}
+
+	if err := events.Put(e.ID[:], b); err != nil {
+		return err
+	}
+
+	tkey := timeKey(e.Time, e.ID)
+	if err := tx.Bucket(timeBucket).Put(tkey, nil); err != nil {
+		return err
+	}
+
+	names, err := tx.Bucket(namesBucket).CreateBucketIfNotExists([]byte(e.Name))
+	if err != nil {
+		return fmt.Errorf("create name bucket: %w", err)
+	}
+	if err := names.Put(tkey, nil); err != nil {
+		return err
+	}
+
+	if e.AggregateName == "" {
+		return nil
+	}
+
+	aggregate, err := createAggregateBucket(tx, e.AggregateName, e.AggregateID)
+	if err != nil {
+		return fmt.Errorf("create aggregate bucket: %w", err)
+	}
+
+	vkey := versionKey(e.AggregateVersion, e.ID)
+	if aggregate.Get(vkey) != nil {
+		return ErrVersionExists
+	}
+
+	return aggregate.Put(vkey, e.ID[:])
+}
+
+func createAggregateBucket(tx *bbolt.Tx, name string, id uuid.UUID) (*bbolt.Bucket, error) {
+	b, err := tx.Bucket(aggregatesBucket).CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return b.CreateBucketIfNotExists(id[:])
+}
+
+func aggregateBucket(tx *bbolt.Tx, name string, id uuid.UUID) *bbolt.Bucket {
+	if b := tx.Bucket(aggregatesBucket).Bucket([]byte(name)); b != nil {
+		return b.Bucket(id[:])
+	}
+	return nil
+}
+
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	return s.Insert(ctx, storage.Events(events)...)
+}
+
+// Find fetches the event with the given id from the event store.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Open(); err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	var e storage.Entry
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		e, err = getEntry(tx, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return e.Raw().DecodeContext(ctx, s.enc)
+}
+
+func getEntry(tx *bbolt.Tx, id uuid.UUID) (storage.Entry, error) {
+	b := tx.Bucket(eventsBucket).Get(id[:])
+	if b == nil {
+		return storage.Entry{}, fmt.Errorf("%s: %w", id, ErrEventNotFound)
+	}
+
+	var e storage.Entry
+	if err := e.Unmarshal(b); err != nil {
+		return storage.Entry{}, fmt.Errorf("decode event %s: %w", id, err)
+	}
+
+	return e, nil
+}
+
+// Delete deletes events from the event store. Events that do not exist are
+// ignored.
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.db.Update(func(tx *bbolt.Tx) error {
+		for _, evt := range events {
+			e, err := getEntry(tx, evt.ID())
+			if errors.Is(err, ErrEventNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := deleteEntry(tx, e); err != nil {
+				return fmt.Errorf("delete %q event: %w", e.Name, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("delete events: %w", err)
+	}
+
+	return nil
+}
+
+func deleteEntry(tx *bbolt.Tx, e storage.Entry) error {
+	if err := tx.Bucket(eventsBucket).Delete(e.ID[:]); err != nil {
+		return err
+	}
+
+	tkey := timeKey(e.Time, e.ID)
+	if err := tx.Bucket(timeBucket).Delete(tkey); err != nil {
+		return err
+	}
+
+	if err := deleteKey(tx.Bucket(namesBucket), []byte(e.Name), tkey); err != nil {
+		return err
+	}
+
+	if e.AggregateName == "" {
+		return nil
+	}
+
+	aggregates := tx.Bucket(aggregatesBucket).Bucket([]byte(e.AggregateName))
+	if aggregates == nil {
+		return nil
+	}
+
+	if err := deleteKey(aggregates, e.AggregateID[:], versionKey(e.AggregateVersion, e.ID)); err != nil {
+		return err
+	}
+
+	if isEmpty(aggregates) {
+		return tx.Bucket(aggregatesBucket).DeleteBucket([]byte(e.AggregateName))
+	}
+
+	return nil
+}
+
+// deleteKey deletes the key from the nested bucket of parent, and deletes the
+// nested bucket if it is empty afterwards.
+func deleteKey(parent *bbolt.Bucket, bucket, key []byte) error {
+	b := parent.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+
+	if isEmpty(b) {
+		return parent.DeleteBucket(bucket)
+	}
+
+	return nil
+}
+
+func isEmpty(b *bbolt.Bucket) bool {
+	k, _ := b.Cursor().First()
+	return k == nil
+}
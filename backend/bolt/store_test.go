@@ -0,0 +1,191 @@
+package bolt_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/bolt"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore(t *testing.T) {
+	eventstoretest.Run(t, "bolt", func(enc codec.Encoding) event.Store {
+		store := bolt.NewEventStore(enc, bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestEventStore_reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(path))
+	evt := event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed with %q", err)
+	}
+
+	store = bolt.NewEventStore(etest.NewEncoder(), bolt.Path(path))
+	defer store.Close()
+
+	found, err := store.Find(ctx, evt.ID())
+	if err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+
+	if !event.Equal(found, evt.Any().Event()) {
+		t.Fatalf("reopened store should return the inserted event.\n\nwant: %v\n\ngot: %v", evt, found)
+	}
+}
+
+func TestEventStore_Insert_versionExists(t *testing.T) {
+	ctx := context.Background()
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+	defer store.Close()
+
+	id := uuid.New()
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1))); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1)),
+	)
+	if !errors.Is(err, bolt.ErrVersionExists) {
+		t.Fatalf("Insert should fail with %q; got %q", bolt.ErrVersionExists, err)
+	}
+
+	events := queryAll(t, store, query.New(query.Aggregate("foo", id)))
+	if len(events) != 1 {
+		t.Fatalf("failed Insert should not insert any events; got %d events", len(events))
+	}
+}
+
+func TestEventStore_Query_sortedIndex(t *testing.T) {
+	ctx := context.Background()
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+	defer store.Close()
+
+	id := uuid.New()
+	now := stdtime.Now()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1), event.Time(now.Add(-stdtime.Hour))),
+		event.New[any]("bar", etest.BarEventData{}, event.Aggregate(id, "foo", 2), event.Time(now.Add(-stdtime.Minute))),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 3), event.Time(now)),
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now.Add(-2*stdtime.Hour))),
+	}
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	tests := []struct {
+		name  string
+		query event.Query
+		want  []event.Event
+	}{
+		{
+			name:  "aggregate, version desc",
+			query: query.New(query.Aggregate("foo", id), query.SortBy(event.SortAggregateVersion, event.SortDesc)),
+			want:  []event.Event{events[2], events[1], events[0]},
+		},
+		{
+			name:  "name, time desc",
+			query: query.New(query.Name("foo"), query.SortBy(event.SortTime, event.SortDesc)),
+			want:  []event.Event{events[2], events[0], events[3]},
+		},
+		{
+			name:  "name, time range desc",
+			query: query.New(query.Name("foo"), query.Time(time.Max(now.Add(-stdtime.Second))), query.SortBy(event.SortTime, event.SortDesc)),
+			want:  []event.Event{events[0], events[3]},
+		},
+		{
+			name:  "time range",
+			query: query.New(query.Time(time.Min(now.Add(-90*stdtime.Minute)), time.Max(now.Add(-stdtime.Second))), query.SortByTime()),
+			want:  []event.Event{events[0], events[1]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			etest.AssertEqualEvents(t, tt.want, queryAll(t, store, tt.query))
+		})
+	}
+}
+
+func TestEventStore_Delete_removesBuckets(t *testing.T) {
+	ctx := context.Background()
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+	defer store.Close()
+
+	evt := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if err := store.Delete(ctx, evt); err != nil {
+		t.Fatalf("Delete failed with %q", err)
+	}
+
+	if events := queryAll(t, store, query.New(query.AggregateName("foo"))); len(events) != 0 {
+		t.Fatalf("Query should return no events; got %d", len(events))
+	}
+
+	// the version of a deleted event can be reused
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(evt.Aggregate()))); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+}
+
+func queryAll(t *testing.T, store event.Store, q event.Query) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	return events
+}
+
+func TestEventStore_Query_tags(t *testing.T) {
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+	defer store.Close()
+
+	_, _, err := store.Query(context.Background(), query.New(query.Tag("import:42")))
+	if !errors.Is(err, event.ErrTagsUnsupported) {
+		t.Fatalf("Query() should fail with %q; got %q", event.ErrTagsUnsupported, err)
+	}
+}
+
+func TestEventStore_Insert_nameTooLong(t *testing.T) {
+	store := bolt.NewEventStore(etest.NewEncoder(), bolt.Path(filepath.Join(t.TempDir(), "events.db")))
+	defer store.Close()
+
+	evt := event.New[any](strings.Repeat("a", math.MaxUint16+1), etest.FooEventData{})
+	if err := store.Insert(context.Background(), evt); err == nil {
+		t.Fatalf("Insert() should fail for event names that exceed %d bytes", math.MaxUint16)
+	}
+}
@@ -0,0 +1,182 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+)
+
+// queryBuilder builds the WHERE clause of a query. Values are passed as query
+// parameters to prevent SQL injection.
+type queryBuilder struct {
+	where []string
+	args  params
+}
+
+func buildQuery(from string, q event.Query) (string, params) {
+	b := queryBuilder{args: make(params)}
+
+	if names := q.Names(); len(names) > 0 {
+		b.in("name", "String", names)
+	}
+
+	if ids := q.IDs(); len(ids) > 0 {
+		b.in("id", "UUID", uuidStrings(ids))
+	}
+
+	if names := q.AggregateNames(); len(names) > 0 {
+		b.in("aggregate_name", "String", names)
+	}
+
+	if ids := q.AggregateIDs(); len(ids) > 0 {
+		b.in("aggregate_id", "UUID", uuidStrings(ids))
+	}
+
+	if versions := q.AggregateVersions(); versions != nil {
+		if exact := versions.Exact(); len(exact) > 0 {
+			b.in("aggregate_version", "UInt32", intStrings(exact))
+		}
+
+		if min := versions.Min(); len(min) > 0 {
+			b.or(len(min), func(i int) string {
+				return "aggregate_version >= " + b.param("UInt32", strconv.Itoa(min[i]))
+			})
+		}
+
+		if max := versions.Max(); len(max) > 0 {
+			b.or(len(max), func(i int) string {
+				return "aggregate_version <= " + b.param("UInt32", strconv.Itoa(max[i]))
+			})
+		}
+
+		if ranges := versions.Ranges(); len(ranges) > 0 {
+			b.or(len(ranges), func(i int) string {
+				return fmt.Sprintf(
+					"aggregate_version BETWEEN %s AND %s",
+					b.param("UInt32", strconv.Itoa(ranges[i].Start())),
+					b.param("UInt32", strconv.Itoa(ranges[i].End())),
+				)
+			})
+		}
+	}
+
+	if refs := q.Aggregates(); len(refs) > 0 {
+		b.or(len(refs), func(i int) string {
+			cond := "aggregate_name = " + b.param("String", refs[i].Name)
+			if refs[i].ID != uuid.Nil {
+				cond += " AND aggregate_id = " + b.param("UUID", refs[i].ID.String())
+			}
+			return cond
+		})
+	}
+
+	if times := q.Times(); times != nil {
+		if exact := times.Exact(); len(exact) > 0 {
+			values := make([]string, len(exact))
+			for i, t := range exact {
+				values[i] = strconv.FormatInt(t.UnixNano(), 10)
+			}
+			b.in("time", "Int64", values)
+		}
+
+		if min := times.Min(); !min.IsZero() {
+			b.where = append(b.where, "time >= "+b.param("Int64", strconv.FormatInt(min.UnixNano(), 10)))
+		}
+
+		if max := times.Max(); !max.IsZero() {
+			b.where = append(b.where, "time <= "+b.param("Int64", strconv.FormatInt(max.UnixNano(), 10)))
+		}
+
+		if ranges := times.Ranges(); len(ranges) > 0 {
+			b.or(len(ranges), func(i int) string {
+				return fmt.Sprintf(
+					"time BETWEEN %s AND %s",
+					b.param("Int64", strconv.FormatInt(ranges[i].Start().UnixNano(), 10)),
+					b.param("Int64", strconv.FormatInt(ranges[i].End().UnixNano(), 10)),
+				)
+			})
+		}
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s", selectColumns, from)
+
+	if len(b.where) > 0 {
+		sql += " WHERE " + strings.Join(b.where, " AND ")
+	}
+
+	if sortings := event.TieBreak(q.Sortings()); len(sortings) > 0 {
+		orders := make([]string, 0, len(sortings))
+		for _, sorting := range sortings {
+			var field string
+			switch sorting.Sort {
+			case event.SortTime:
+				field = "time"
+			case event.SortAggregateName:
+				field = "aggregate_name"
+			case event.SortAggregateID:
+				field = "toString(aggregate_id)"
+			case event.SortAggregateVersion:
+				field = "aggregate_version"
+			case event.SortID:
+				field = "toString(id)"
+			default:
+				continue
+			}
+
+			dir := "ASC"
+			if sorting.Dir == event.SortDesc {
+				dir = "DESC"
+			}
+
+			orders = append(orders, field+" "+dir)
+		}
+
+		if len(orders) > 0 {
+			sql += " ORDER BY " + strings.Join(orders, ", ")
+		}
+	}
+
+	return sql, b.args
+}
+
+// param adds a query parameter and returns its placeholder.
+func (b *queryBuilder) param(typ, value string) string {
+	name := fmt.Sprintf("p%d", len(b.args))
+	b.args[name] = value
+	return fmt.Sprintf("{%s:%s}", name, typ)
+}
+
+func (b *queryBuilder) in(field, typ string, values []string) {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.param(typ, v)
+	}
+	b.where = append(b.where, fmt.Sprintf("%s IN (%s)", field, strings.Join(placeholders, ", ")))
+}
+
+func (b *queryBuilder) or(n int, cond func(int) string) {
+	conds := make([]string, n)
+	for i := range conds {
+		conds[i] = "(" + cond(i) + ")"
+	}
+	b.where = append(b.where, "("+strings.Join(conds, " OR ")+")")
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
+
+func intStrings(values []int) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strconv.Itoa(v)
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// DefaultBackfillBatchSize is the default number of events that Backfill
+// writes to ClickHouse per request.
+const DefaultBackfillBatchSize = 10000
+
+// Replicating returns an event store that writes events to the primary store
+// and replicates them to the ClickHouse store. Find and Query are served by
+// the primary store; analytical projections should query the ClickHouse store
+// directly.
+//
+// Events are replicated after they were inserted into the primary store. If
+// the replication fails, Insert returns an error although the events are
+// already stored in the primary store. Inserting the events again into the
+// replica (e.g. using Backfill) is safe, because ClickHouse deduplicates them.
+func Replicating(primary event.Store, replica *EventStore) event.Store {
+	return &replicating{Store: primary, replica: replica}
+}
+
+type replicating struct {
+	event.Store
+	replica *EventStore
+}
+
+func (r *replicating) Insert(ctx context.Context, events ...event.Event) error {
+	if err := r.Store.Insert(ctx, events...); err != nil {
+		return err
+	}
+
+	if err := r.replica.Replicate(ctx, events...); err != nil {
+		return fmt.Errorf("replicate events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *replicating) Delete(ctx context.Context, events ...event.Event) error {
+	if err := r.Store.Delete(ctx, events...); err != nil {
+		return err
+	}
+
+	if err := r.replica.ReplicateDelete(ctx, events...); err != nil {
+		return fmt.Errorf("replicate deletion: %w", err)
+	}
+
+	return nil
+}
+
+// Replicate writes events that were inserted into the primary event store to
+// ClickHouse.
+func (s *EventStore) Replicate(ctx context.Context, events ...event.Event) error {
+	raws := make([]event.RawEvent, len(events))
+	for i, evt := range events {
+		data, err := codec.MarshalContext(ctx, s.enc, evt.Data())
+		if err != nil {
+			return fmt.Errorf("marshal %q event data: %w", evt.Name(), err)
+		}
+
+		id, name, v := evt.Aggregate()
+		raws[i] = event.RawEvent{
+			ID:               evt.ID(),
+			Name:             evt.Name(),
+			Time:             evt.Time(),
+			Aggregate:        event.AggregateRef{Name: name, ID: id},
+			AggregateVersion: v,
+			Data:             data,
+		}
+	}
+	return s.ReplicateRaw(ctx, raws...)
+}
+
+// ReplicateRaw writes events whose data is already encoded to ClickHouse. The
+// encoded data is stored as-is, without using the encoder of the store.
+func (s *EventStore) ReplicateRaw(ctx context.Context, events ...event.RawEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, raw := range events {
+		if err := enc.Encode(row{
+			ID:               raw.ID,
+			Name:             raw.Name,
+			Time:             raw.Time.UnixNano(),
+			AggregateName:    raw.Aggregate.Name,
+			AggregateID:      raw.Aggregate.ID,
+			AggregateVersion: raw.AggregateVersion,
+			Data:             base64.StdEncoding.EncodeToString(raw.Data),
+		}); err != nil {
+			return fmt.Errorf("encode %q event: %w", raw.Name, err)
+		}
+	}
+
+	if err := s.exec(ctx, fmt.Sprintf(
+		"INSERT INTO %s SELECT id, name, time, aggregate_name, aggregate_id, aggregate_version, base64Decode(data) FROM input(%s) FORMAT JSONEachRow",
+		s.tableName(),
+		"'id UUID, name String, time Int64, aggregate_name String, aggregate_id UUID, aggregate_version UInt32, data String'",
+	), nil, &body); err != nil {
+		return fmt.Errorf("insert events: %w", err)
+	}
+
+	return nil
+}
+
+// ReplicateDelete deletes events that were deleted from the primary event
+// store from ClickHouse.
+func (s *EventStore) ReplicateDelete(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	b := queryBuilder{args: make(params)}
+	ids := make([]uuid.UUID, len(events))
+	for i, evt := range events {
+		ids[i] = evt.ID()
+	}
+	b.in("id", "UUID", uuidStrings(ids))
+
+	if err := s.exec(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE %s",
+		s.tableName(),
+		strings.Join(b.where, " AND "),
+	), b.args, nil); err != nil {
+		return fmt.Errorf("delete events: %w", err)
+	}
+
+	return nil
+}
+
+// BackfillOption is an option for Backfill.
+type BackfillOption func(*backfill)
+
+type backfill struct {
+	batchSize int
+}
+
+// BackfillBatchSize returns a BackfillOption that sets the number of events
+// that are written to ClickHouse per request. Defaults to
+// DefaultBackfillBatchSize.
+func BackfillBatchSize(n int) BackfillOption {
+	return func(b *backfill) {
+		b.batchSize = n
+	}
+}
+
+// Backfill replicates the events of the source store that match the query to
+// ClickHouse, e.g. to fill the ClickHouse store with the existing events of
+// the primary store. If the source store implements event.RawQuerier, the
+// events are copied without decoding their data. Backfill returns the number
+// of replicated events.
+func (s *EventStore) Backfill(ctx context.Context, source event.Store, q event.Query, opts ...BackfillOption) (int, error) {
+	cfg := backfill{batchSize: DefaultBackfillBatchSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.batchSize < 1 {
+		cfg.batchSize = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		raws <-chan event.RawEvent
+		errs <-chan error
+		err  error
+	)
+	if rq, ok := source.(event.RawQuerier); ok {
+		raws, errs, err = rq.QueryRaw(ctx, q)
+	} else {
+		var events <-chan event.Event
+		if events, errs, err = source.Query(ctx, q); err == nil {
+			raws = s.encodeEvents(ctx, events)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query source events: %w", err)
+	}
+
+	var n int
+	batch := make([]event.RawEvent, 0, cfg.batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.ReplicateRaw(ctx, batch...); err != nil {
+			return err
+		}
+		n += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	if err := streams.Walk(ctx, func(raw event.RawEvent) error {
+		batch = append(batch, raw)
+		if len(batch) < cfg.batchSize {
+			return nil
+		}
+		return flush()
+	}, raws, errs); err != nil {
+		return n, err
+	}
+
+	if err := flush(); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// encodeEvents encodes the data of the given events using the encoder of the
+// store. Events whose data cannot be encoded are dropped.
+func (s *EventStore) encodeEvents(ctx context.Context, events <-chan event.Event) <-chan event.RawEvent {
+	out := make(chan event.RawEvent)
+	go func() {
+		defer close(out)
+		for evt := range events {
+			data, err := codec.MarshalContext(ctx, s.enc, evt.Data())
+			if err != nil {
+				continue
+			}
+
+			id, name, v := evt.Aggregate()
+			select {
+			case <-ctx.Done():
+				return
+			case out <- event.RawEvent{
+				ID:               evt.ID(),
+				Name:             evt.Name(),
+				Time:             evt.Time(),
+				Aggregate:        event.AggregateRef{Name: name, ID: id},
+				AggregateVersion: v,
+				Data:             data,
+			}:
+			}
+		}
+	}()
+	return out
+}
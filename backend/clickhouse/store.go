@@ -0,0 +1,547 @@
+// Package clickhouse provides a read-only event store that is backed by
+// ClickHouse. Analytical projections can query billions of events from
+// ClickHouse, while events continue to be written to the primary event store
+// and are replicated to ClickHouse using Replicating, Replicate, or Backfill.
+//
+// The store talks to ClickHouse over its HTTP interface and does not require a
+// native driver.
+package clickhouse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = errors.New("event not found")
+
+	// ErrReadOnly is returned by Insert and Delete. Events are written to the
+	// primary event store and replicated to ClickHouse.
+	ErrReadOnly = errors.New("event store is read-only")
+)
+
+var (
+	_ event.Store         = (*EventStore)(nil)
+	_ event.RawQuerier    = (*EventStore)(nil)
+	_ event.StatsProvider = (*EventStore)(nil)
+)
+
+// EventStore is a read-only event store that is backed by ClickHouse. Events
+// are stored in a ReplacingMergeTree table that is ordered by event name,
+// time, and id, so that queries for specific events within a time range are
+// fast:
+//
+//	CREATE TABLE events (
+//		id UUID,
+//		name LowCardinality(String),
+//		time Int64, -- unix nanoseconds
+//		aggregate_name LowCardinality(String),
+//		aggregate_id UUID,
+//		aggregate_version UInt32,
+//		data String
+//	) ENGINE = ReplacingMergeTree ORDER BY (name, time, id)
+//
+// Events that are replicated more than once are deduplicated when ClickHouse
+// merges the parts of the table. Until then, queries may return duplicates,
+// unless the Final option is used.
+type EventStore struct {
+	enc      codec.Encoding
+	url      string
+	database string
+	table    string
+	username string
+	password string
+	final    bool
+	client   *http.Client
+
+	onceConnect sync.Once
+}
+
+// EventStoreOption is an option for the ClickHouse event store.
+type EventStoreOption func(*EventStore)
+
+// URL returns an EventStoreOption that specifies the URL of the HTTP interface
+// of ClickHouse, e.g. "http://localhost:8123".
+func URL(url string) EventStoreOption {
+	return func(s *EventStore) {
+		s.url = strings.TrimRight(url, "/")
+	}
+}
+
+// Database returns an EventStoreOption that specifies the database of the
+// event store. Defaults to "goes".
+func Database(name string) EventStoreOption {
+	if name = strings.TrimSpace(name); name == "" {
+		panic("database name cannot be empty")
+	}
+
+	return func(s *EventStore) {
+		s.database = name
+	}
+}
+
+// Table returns an EventStoreOption that specifies the table of the event
+// store. Defaults to "events".
+func Table(name string) EventStoreOption {
+	if name = strings.TrimSpace(name); name == "" {
+		panic("table name cannot be empty")
+	}
+
+	return func(s *EventStore) {
+		s.table = name
+	}
+}
+
+// Credentials returns an EventStoreOption that specifies the user and password
+// that are used to authenticate against ClickHouse.
+func Credentials(username, password string) EventStoreOption {
+	return func(s *EventStore) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// HTTPClient returns an EventStoreOption that specifies the HTTP client that is
+// used to send requests to ClickHouse. Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) EventStoreOption {
+	return func(s *EventStore) {
+		s.client = client
+	}
+}
+
+// Final returns an EventStoreOption that queries the table using the FINAL
+// modifier, which deduplicates events that were replicated more than once at
+// query time. FINAL makes queries slower.
+func Final() EventStoreOption {
+	return func(s *EventStore) {
+		s.final = true
+	}
+}
+
+// NewEventStore returns a new ClickHouse event store. If not otherwise
+// specified using the URL option, os.Getenv("CLICKHOUSE_URL") is used as the
+// URL of the HTTP interface.
+func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:      enc,
+		url:      strings.TrimRight(os.Getenv("CLICKHOUSE_URL"), "/"),
+		database: "goes",
+		table:    "events",
+		client:   http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Connect creates the database and table of the event store if they do not
+// exist. Connect is automatically called from the Find, Query, and replication
+// methods if not called explicitly.
+func (s *EventStore) Connect(ctx context.Context) error {
+	var err error
+	s.onceConnect.Do(func() {
+		if s.url == "" {
+			err = errors.New("missing url")
+			return
+		}
+
+		if err = s.exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", quoteIdent(s.database)), nil, nil); err != nil {
+			err = fmt.Errorf("create %q database: %w", s.database, err)
+			return
+		}
+
+		if err = s.exec(ctx, eventTableSQL(s.tableName()), nil, nil); err != nil {
+			err = fmt.Errorf("create %q table: %w", s.table, err)
+			return
+		}
+	})
+	return err
+}
+
+// Insert returns ErrReadOnly. Use Replicating or Replicate to write events
+// to ClickHouse.
+func (s *EventStore) Insert(context.Context, ...event.Event) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly. Use Replicating or ReplicateDelete to delete
+// events from ClickHouse.
+func (s *EventStore) Delete(context.Context, ...event.Event) error {
+	return ErrReadOnly
+}
+
+// Find fetches the event with the given id.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE id = {id:UUID} LIMIT 1", selectColumns, s.from())
+
+	var found *row
+	if err := s.query(ctx, sql, params{"id": id.String()}, func(r row) error {
+		found = &r
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w [id=%s]", ErrEventNotFound, id)
+	}
+
+	raw, err := found.raw()
+	if err != nil {
+		return nil, err
+	}
+
+	evt, err := raw.DecodeContext(ctx, s.enc)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q event data: %w", raw.Name, err)
+	}
+
+	return evt, nil
+}
+
+// Query queries the event store for events.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	raws, rawErrs, err := s.QueryRaw(ctx, q)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+	skip := event.UndecodableHandler(q)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+		defer cancel()
+
+		for raws != nil || rawErrs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-rawErrs:
+				if !ok {
+					rawErrs = nil
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+			case raw, ok := <-raws:
+				if !ok {
+					raws = nil
+					break
+				}
+
+				evt, err := raw.DecodeContext(ctx, s.enc)
+				if err != nil {
+					if skip != nil {
+						skip(&event.DecodeError{RawEvent: raw, Err: err})
+						continue
+					}
+					err = fmt.Errorf("decode %q event data: %w", raw.Name, err)
+					select {
+					case <-ctx.Done():
+					case errs <- err:
+					}
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- evt:
+				}
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// QueryRaw queries the event store for events, like Query does, but returns
+// the events without decoding their data.
+//
+// QueryRaw implements event.RawQuerier.
+func (s *EventStore) QueryRaw(ctx context.Context, q event.Query) (<-chan event.RawEvent, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.Connect(ctx); err != nil {
+		return nil, nil, fmt.Errorf("connect: %w", err)
+	}
+
+	sql, args := buildQuery(s.from(), q)
+
+	out := make(chan event.RawEvent)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		if err := s.query(ctx, sql, args, func(r row) error {
+			raw, err := r.raw()
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case out <- raw:
+				return nil
+			}
+		}); err != nil && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case errs <- err:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// Stats returns statistics about the events in the store, computed by
+// aggregate functions of ClickHouse.
+//
+// Stats implements event.StatsProvider.
+func (s *EventStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	if err := s.Connect(ctx); err != nil {
+		return event.StoreStats{}, fmt.Errorf("connect: %w", err)
+	}
+
+	out := event.StoreStats{EventsPerName: make(map[string]int)}
+
+	if err := s.queryJSON(ctx, fmt.Sprintf(
+		"SELECT name, count() AS count, min(time) AS oldest, max(time) AS newest FROM %s GROUP BY name",
+		s.from(),
+	), nil, func(line []byte) error {
+		var r struct {
+			Name   string `json:"name"`
+			Count  int    `json:"count"`
+			Oldest int64  `json:"oldest"`
+			Newest int64  `json:"newest"`
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("decode row: %w", err)
+		}
+		out = out.Merge(event.StoreStats{
+			Events:        r.Count,
+			EventsPerName: map[string]int{r.Name: r.Count},
+			Oldest:        stdtime.Unix(0, r.Oldest),
+			Newest:        stdtime.Unix(0, r.Newest),
+		})
+		return nil
+	}); err != nil {
+		return event.StoreStats{}, fmt.Errorf("query event counts: %w", err)
+	}
+
+	if err := s.queryJSON(ctx, fmt.Sprintf(
+		"SELECT uniqExact(aggregate_name, aggregate_id) AS aggregates FROM %s WHERE aggregate_name != ''",
+		s.from(),
+	), nil, func(line []byte) error {
+		var r struct {
+			Aggregates int `json:"aggregates"`
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("decode row: %w", err)
+		}
+		out.Aggregates = r.Aggregates
+		return nil
+	}); err != nil {
+		return event.StoreStats{}, fmt.Errorf("count aggregates: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *EventStore) tableName() string {
+	return quoteIdent(s.database) + "." + quoteIdent(s.table)
+}
+
+func (s *EventStore) from() string {
+	if s.final {
+		return s.tableName() + " FINAL"
+	}
+	return s.tableName()
+}
+
+// params are the query parameters of a ClickHouse query, which are referenced
+// in the query as {name:Type}.
+type params map[string]string
+
+// exec executes a statement that does not return rows. If body is not nil, it
+// is sent as the input data of the statement.
+func (s *EventStore) exec(ctx context.Context, sql string, args params, body io.Reader) error {
+	res, err := s.do(ctx, sql, args, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+// query executes a SELECT query that returns events and calls fn for each row.
+func (s *EventStore) query(ctx context.Context, sql string, args params, fn func(row) error) error {
+	return s.queryJSON(ctx, sql, args, func(line []byte) error {
+		var r row
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("decode row: %w", err)
+		}
+		return fn(r)
+	})
+}
+
+// queryJSON executes a SELECT query in the JSONEachRow format and calls fn for
+// each row.
+func (s *EventStore) queryJSON(ctx context.Context, sql string, args params, fn func([]byte) error) error {
+	res, err := s.do(ctx, sql+" FORMAT JSONEachRow", args, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxRowSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return nil
+}
+
+// maxRowSize is the maximum size of a single row in a query response.
+const maxRowSize = 64 << 20
+
+func (s *EventStore) do(ctx context.Context, sql string, args params, body io.Reader) (*http.Response, error) {
+	values := url.Values{}
+	values.Set("output_format_json_quote_64bit_integers", "0")
+	for name, v := range args {
+		values.Set("param_"+name, escapeParam(v))
+	}
+
+	// The statement is sent as the query parameter if the request has a body,
+	// because the body is then used as the input data of the statement.
+	if body == nil {
+		body = strings.NewReader(sql)
+	} else {
+		values.Set("query", sql)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/?"+values.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if s.username != "" {
+		req.Header.Set("X-ClickHouse-User", s.username)
+		req.Header.Set("X-ClickHouse-Key", s.password)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return nil, fmt.Errorf("clickhouse: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return res, nil
+}
+
+// row is an event as it is returned by ClickHouse.
+type row struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Time             int64     `json:"time"`
+	AggregateName    string    `json:"aggregate_name"`
+	AggregateID      uuid.UUID `json:"aggregate_id"`
+	AggregateVersion int       `json:"aggregate_version"`
+	Data             string    `json:"data"`
+}
+
+func (r row) raw() (event.RawEvent, error) {
+	data, err := base64.StdEncoding.DecodeString(r.Data)
+	if err != nil {
+		return event.RawEvent{}, fmt.Errorf("decode %q event data: %w [id=%s]", r.Name, err, r.ID)
+	}
+
+	return event.RawEvent{
+		ID:               r.ID,
+		Name:             r.Name,
+		Time:             stdtime.Unix(0, r.Time),
+		Aggregate:        event.AggregateRef{Name: r.AggregateName, ID: r.AggregateID},
+		AggregateVersion: r.AggregateVersion,
+		Data:             data,
+	}, nil
+}
+
+// selectColumns are the columns of queries that return events. The data is
+// base64-encoded, because the JSONEachRow format only supports UTF-8 strings.
+const selectColumns = "id, name, time, aggregate_name, aggregate_id, aggregate_version, base64Encode(data) AS data"
+
+func eventTableSQL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id UUID,
+		name LowCardinality(String),
+		time Int64,
+		aggregate_name LowCardinality(String),
+		aggregate_id UUID,
+		aggregate_version UInt32,
+		data String
+	) ENGINE = ReplacingMergeTree ORDER BY (name, time, id)`, table)
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// escapeParam escapes the value of a query parameter, which ClickHouse parses
+// in the TSV format.
+func escapeParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`).Replace(v)
+}
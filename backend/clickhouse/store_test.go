@@ -0,0 +1,150 @@
+//go:build clickhouse
+
+package clickhouse_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/clickhouse"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/event/query/version"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+func TestEventStore_Query(t *testing.T) {
+	ctx := context.Background()
+	enc := etest.NewEncoder()
+	replica := clickhouse.NewEventStore(enc, clickhouse.Database(nextDatabase()))
+	primary := eventstore.New()
+	store := clickhouse.Replicating(primary, replica)
+
+	now := xtime.Now()
+	fooID, barID := uuid.New(), uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Time(now), event.Aggregate(fooID, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Time(now.Add(stdtime.Minute)), event.Aggregate(fooID, "foo", 2)),
+		event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Time(now.Add(stdtime.Hour)), event.Aggregate(barID, "bar", 1)),
+		event.New[any]("baz", etest.BazEventData{A: "baz"}, event.Time(now.Add(-stdtime.Hour))),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	queries := []event.Query{
+		query.New(),
+		query.New(query.Name("foo", "baz")),
+		query.New(query.ID(events[2].ID())),
+		query.New(query.AggregateName("foo")),
+		query.New(query.AggregateID(barID)),
+		query.New(query.Aggregate("foo", fooID), query.AggregateVersion(version.Min(2))),
+		query.New(query.AggregateVersion(version.InRange(version.Range{1, 1}))),
+		query.New(query.Time(time.Min(now), time.Max(now.Add(stdtime.Minute)))),
+		query.New(query.Time(time.Exact(events[3].Time()))),
+		query.New(query.SortByAggregate()),
+		query.New(query.SortBy(event.SortTime, event.SortDesc)),
+	}
+
+	for i, q := range queries {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			want := runQuery(t, primary, q)
+			got := runQuery(t, replica, q)
+			if len(q.Sortings()) > 0 {
+				etest.AssertEqualEvents(t, want, got)
+			} else {
+				etest.AssertEqualEventsUnsorted(t, want, got)
+			}
+		})
+	}
+
+	found, err := replica.Find(ctx, events[0].ID())
+	if err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+	etest.AssertEqualEvents(t, []event.Event{events[0]}, []event.Event{found})
+
+	if _, err := replica.Find(ctx, uuid.New()); !errors.Is(err, clickhouse.ErrEventNotFound) {
+		t.Fatalf("Find should fail with %q; got %q", clickhouse.ErrEventNotFound, err)
+	}
+
+	stats, err := replica.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed with %q", err)
+	}
+	if stats.Events != 4 || stats.Aggregates != 2 {
+		t.Fatalf("Stats should count %d events of %d aggregates; got %+v", 4, 2, stats)
+	}
+
+	if err := store.Delete(ctx, events[0]); err != nil {
+		t.Fatalf("Delete failed with %q", err)
+	}
+	etest.AssertEqualEventsUnsorted(t, events[1:], runQuery(t, replica, query.New()))
+}
+
+func TestEventStore_readOnly(t *testing.T) {
+	store := clickhouse.NewEventStore(etest.NewEncoder())
+	if err := store.Insert(context.Background(), event.New[any]("foo", etest.FooEventData{})); !errors.Is(err, clickhouse.ErrReadOnly) {
+		t.Fatalf("Insert should fail with %q; got %q", clickhouse.ErrReadOnly, err)
+	}
+}
+
+func TestEventStore_Backfill(t *testing.T) {
+	ctx := context.Background()
+	enc := etest.NewEncoder()
+	replica := clickhouse.NewEventStore(enc, clickhouse.Database(nextDatabase()), clickhouse.Final())
+
+	var events []event.Event
+	for i := 0; i < 25; i++ {
+		events = append(events, event.New[any]("foo", etest.FooEventData{A: fmt.Sprint(i)}))
+	}
+	source := eventstore.New(events...)
+
+	n, err := replica.Backfill(ctx, source, query.New(), clickhouse.BackfillBatchSize(10))
+	if err != nil {
+		t.Fatalf("Backfill failed with %q", err)
+	}
+	if n != len(events) {
+		t.Fatalf("Backfill should replicate %d events; replicated %d", len(events), n)
+	}
+
+	// replicating the same events again does not duplicate them
+	if _, err := replica.Backfill(ctx, source, query.New()); err != nil {
+		t.Fatalf("Backfill failed with %q", err)
+	}
+
+	etest.AssertEqualEventsUnsorted(t, events, runQuery(t, replica, query.New()))
+}
+
+func runQuery(t *testing.T, store event.Store, q event.Query) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	return events
+}
+
+var databaseN uint64
+
+func nextDatabase() string {
+	n := atomic.AddUint64(&databaseN, 1)
+	return fmt.Sprintf("goes_%d_%d", stdtime.Now().UnixNano(), n)
+}
@@ -0,0 +1,99 @@
+// Package eventbridge provides a Sink that forwards goes events to Amazon
+// EventBridge, so that AWS-native automation can react to domain events. The
+// package does not depend on the AWS SDK. Instead, the Sink sends events
+// through a Client, which is usually implemented using the PutEvents operation
+// of the EventBridge client of the AWS SDK:
+//
+//	client := eventbridge.ClientFunc(func(ctx context.Context, entries []eventbridge.Entry) ([]eventbridge.Result, error) {
+//		input := &awseb.PutEventsInput{}
+//		for _, e := range entries {
+//			input.Entries = append(input.Entries, types.PutEventsRequestEntry{
+//				Source:       aws.String(e.Source),
+//				DetailType:   aws.String(e.DetailType),
+//				Detail:       aws.String(e.Detail),
+//				EventBusName: aws.String(e.EventBusName),
+//				Time:         aws.Time(e.Time),
+//				Resources:    e.Resources,
+//			})
+//		}
+//		out, err := sdk.PutEvents(ctx, input)
+//		if err != nil {
+//			return nil, err
+//		}
+//		results := make([]eventbridge.Result, len(out.Entries))
+//		for i, r := range out.Entries {
+//			results[i] = eventbridge.Result{
+//				EventID:      aws.ToString(r.EventId),
+//				ErrorCode:    aws.ToString(r.ErrorCode),
+//				ErrorMessage: aws.ToString(r.ErrorMessage),
+//			}
+//		}
+//		return results, nil
+//	})
+package eventbridge
+
+import (
+	"context"
+	"time"
+)
+
+// MaxEntries is the maximum number of entries of a single PutEvents request.
+const MaxEntries = 10
+
+// Entry is an event that is sent to EventBridge. Entry mirrors the
+// PutEventsRequestEntry of the AWS SDK.
+type Entry struct {
+	// Source identifies the service that produced the event.
+	Source string
+
+	// DetailType describes the type of the event.
+	DetailType string
+
+	// Detail is the JSON-encoded event.
+	Detail string
+
+	// EventBusName is the name or ARN of the event bus that receives the
+	// event. If empty, the default event bus is used.
+	EventBusName string
+
+	// Time is the time of the event.
+	Time time.Time
+
+	// Resources are the ARNs of the AWS resources that the event involves.
+	Resources []string
+}
+
+// Result is the result of a single Entry of a PutEvents request. Result
+// mirrors the PutEventsResultEntry of the AWS SDK.
+type Result struct {
+	// EventID is the id that EventBridge assigned to the event.
+	EventID string
+
+	// ErrorCode is the error code of a failed entry. It is empty if the entry
+	// was sent successfully.
+	ErrorCode string
+
+	// ErrorMessage is the error message of a failed entry.
+	ErrorMessage string
+}
+
+// Failed reports whether the entry failed.
+func (r Result) Failed() bool {
+	return r.ErrorCode != ""
+}
+
+// Client sends events to EventBridge. PutEvents is called with at most
+// MaxEntries entries, and must return one Result per entry, in the order of
+// the entries. Like the PutEvents operation of EventBridge, a request may fail
+// partially; failed entries are reported by their Result.
+type Client interface {
+	PutEvents(context.Context, []Entry) ([]Result, error)
+}
+
+// ClientFunc allows a function to be used as a Client.
+type ClientFunc func(context.Context, []Entry) ([]Result, error)
+
+// PutEvents calls fn(ctx, entries).
+func (fn ClientFunc) PutEvents(ctx context.Context, entries []Entry) ([]Result, error) {
+	return fn(ctx, entries)
+}
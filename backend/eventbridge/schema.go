@@ -0,0 +1,115 @@
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/codec/schema"
+)
+
+// SchemaType is the type of the schemas that are returned by Schemas.
+const SchemaType = "JSONSchemaDraft4"
+
+// Schema is the schema of the EventBridge events of a goes event.
+type Schema struct {
+	// Name is the name of the schema, which is the name of the goes event.
+	Name string
+
+	// Type is the type of the schema. Always SchemaType.
+	Type string
+
+	// Content is the JSON-encoded schema.
+	Content string
+}
+
+// SchemaRegistry stores schemas, e.g. in an EventBridge schema registry using
+// the CreateSchema and UpdateSchema operations of the schemas client of the
+// AWS SDK. PutSchema must create the schema if it does not exist, and update
+// it otherwise.
+type SchemaRegistry interface {
+	PutSchema(context.Context, Schema) error
+}
+
+// SchemaRegistryFunc allows a function to be used as a SchemaRegistry.
+type SchemaRegistryFunc func(context.Context, Schema) error
+
+// PutSchema calls fn(ctx, s).
+func (fn SchemaRegistryFunc) PutSchema(ctx context.Context, s Schema) error {
+	return fn(ctx, s)
+}
+
+// Schemas returns the JSON Schemas of the detail of the EventBridge events
+// that are sent by the Sink, one per event that is registered in reg and
+// selected by the Events option. The schemas describe the Detail envelope,
+// and the "data" property is described by the recorded schema of the event
+// data (see schema.Record). Schemas are named by the event name and sorted by
+// name.
+func (s *Sink) Schemas(reg *codec.Registry) ([]Schema, error) {
+	snap, err := schema.Record(reg)
+	if err != nil {
+		return nil, fmt.Errorf("record schemas: %w", err)
+	}
+
+	names := make([]string, 0, len(snap))
+	for name := range snap {
+		if s.names != nil && !s.names[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]Schema, 0, len(names))
+	for _, name := range names {
+		content, err := json.Marshal(detailSchema(name, snap[name]))
+		if err != nil {
+			return out, fmt.Errorf("encode %q schema: %w", name, err)
+		}
+		out = append(out, Schema{
+			Name:    name,
+			Type:    SchemaType,
+			Content: string(content),
+		})
+	}
+
+	return out, nil
+}
+
+// RegisterSchemas puts the schemas that are returned by Schemas into the given
+// registry, so that consumers of the events can discover their structure and
+// generate code bindings.
+func (s *Sink) RegisterSchemas(ctx context.Context, registry SchemaRegistry, reg *codec.Registry) error {
+	schemas, err := s.Schemas(reg)
+	if err != nil {
+		return err
+	}
+
+	for _, sc := range schemas {
+		if err := registry.PutSchema(ctx, sc); err != nil {
+			return fmt.Errorf("put %q schema: %w", sc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func detailSchema(name string, data schema.Type) map[string]any {
+	return map[string]any{
+		"$schema": schema.JSONSchemaDraft4,
+		"title":   name,
+		"type":    "object",
+		"properties": map[string]any{
+			"id":               map[string]any{"type": "string", "format": "uuid"},
+			"name":             map[string]any{"type": "string", "enum": []string{name}},
+			"time":             map[string]any{"type": "string", "format": "date-time"},
+			"aggregateName":    map[string]any{"type": "string"},
+			"aggregateId":      map[string]any{"type": "string", "format": "uuid"},
+			"aggregateVersion": map[string]any{"type": "integer"},
+			"data":             data.JSONSchema(),
+		},
+		"required": []string{"id", "name", "time", "data"},
+	}
+}
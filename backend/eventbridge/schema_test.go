@@ -0,0 +1,54 @@
+package eventbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/modernice/goes/backend/eventbridge"
+	"github.com/modernice/goes/codec/schema"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestSink_RegisterSchemas(t *testing.T) {
+	sink := eventbridge.NewSink(&recordingClient{}, test.NewEncoder(), eventbridge.Events("foo", "bar"))
+
+	var schemas []eventbridge.Schema
+	registry := eventbridge.SchemaRegistryFunc(func(_ context.Context, s eventbridge.Schema) error {
+		schemas = append(schemas, s)
+		return nil
+	})
+
+	if err := sink.RegisterSchemas(context.Background(), registry, test.NewEncoder()); err != nil {
+		t.Fatalf("RegisterSchemas() failed with %q", err)
+	}
+
+	if len(schemas) != 2 || schemas[0].Name != "bar" || schemas[1].Name != "foo" {
+		t.Fatalf("schemas of %q and %q should have been registered; got %v", "bar", "foo", schemas)
+	}
+
+	var doc struct {
+		Schema     string `json:"$schema"`
+		Properties struct {
+			Data struct {
+				Type       string                    `json:"type"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"data"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schemas[1].Content), &doc); err != nil {
+		t.Fatalf("failed to decode schema: %v", err)
+	}
+
+	if schemas[1].Type != eventbridge.SchemaType {
+		t.Errorf("schema type should be %q; got %q", eventbridge.SchemaType, schemas[1].Type)
+	}
+
+	if doc.Schema != schema.JSONSchemaDraft4 {
+		t.Errorf("$schema should be %q; got %q", schema.JSONSchemaDraft4, doc.Schema)
+	}
+
+	if doc.Properties.Data.Type != "object" || doc.Properties.Data.Properties["A"]["type"] != "string" {
+		t.Errorf("data schema should describe the event data; got %+v", doc.Properties.Data)
+	}
+}
@@ -0,0 +1,329 @@
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+const (
+	// DefaultSource is the default source of the events that are sent by a
+	// Sink.
+	DefaultSource = "goes"
+
+	// DefaultMaxAttempts is the default number of attempts to send an event.
+	DefaultMaxAttempts = 5
+
+	// DefaultBackoff is the default delay before the first retry. The delay
+	// doubles with each retry.
+	DefaultBackoff = 100 * stdtime.Millisecond
+)
+
+// Detail is the JSON-encoded detail of the EventBridge events that are sent
+// by a Sink. EventBridge rules can match on the fields of the detail, e.g. on
+// "detail.aggregateName".
+type Detail struct {
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Time             stdtime.Time    `json:"time"`
+	AggregateName    string          `json:"aggregateName,omitempty"`
+	AggregateID      *uuid.UUID      `json:"aggregateId,omitempty"`
+	AggregateVersion int             `json:"aggregateVersion,omitempty"`
+	Data             json.RawMessage `json:"data"`
+}
+
+// Sink forwards events to EventBridge. Events are sent in batches of at most
+// MaxEntries events. Entries that fail, e.g. because of throttling, are
+// retried with exponential backoff until they succeed or the maximum number of
+// attempts is reached.
+//
+// The data of the events is encoded using the encoding of the Sink and must be
+// JSON, because the detail of EventBridge events is a JSON object (see
+// Detail).
+type Sink struct {
+	client      Client
+	enc         codec.Encoding
+	source      string
+	bus         string
+	detailType  func(event.Event) string
+	resources   func(event.Event) []string
+	names       map[string]bool
+	maxAttempts int
+	backoff     stdtime.Duration
+}
+
+// SinkOption is an option for a Sink.
+type SinkOption func(*Sink)
+
+// Source returns a SinkOption that specifies the source of the events.
+// Defaults to DefaultSource.
+func Source(source string) SinkOption {
+	return func(s *Sink) {
+		s.source = source
+	}
+}
+
+// EventBus returns a SinkOption that specifies the name or ARN of the event
+// bus that receives the events. By default, the default event bus of the
+// account is used.
+func EventBus(name string) SinkOption {
+	return func(s *Sink) {
+		s.bus = name
+	}
+}
+
+// DetailType returns a SinkOption that specifies the detail type of the
+// EventBridge event of a goes event. By default, the detail type is the event
+// name.
+func DetailType(fn func(event.Event) string) SinkOption {
+	return func(s *Sink) {
+		s.detailType = fn
+	}
+}
+
+// Resources returns a SinkOption that adds the ARNs that are returned by fn to
+// the EventBridge events.
+func Resources(fn func(event.Event) []string) SinkOption {
+	return func(s *Sink) {
+		s.resources = fn
+	}
+}
+
+// Events returns a SinkOption that forwards only the events with the given
+// names. By default, all events are forwarded.
+func Events(names ...string) SinkOption {
+	return func(s *Sink) {
+		if s.names == nil {
+			s.names = make(map[string]bool)
+		}
+		for _, name := range names {
+			s.names[name] = true
+		}
+	}
+}
+
+// Retry returns a SinkOption that configures the retries of failed entries.
+// An entry is sent at most maxAttempts times. The delay before the first retry
+// is backoff and doubles with each retry. Defaults to DefaultMaxAttempts and
+// DefaultBackoff.
+func Retry(maxAttempts int, backoff stdtime.Duration) SinkOption {
+	return func(s *Sink) {
+		s.maxAttempts = maxAttempts
+		s.backoff = backoff
+	}
+}
+
+// NewSink returns a Sink that sends events to EventBridge using the given
+// client. The provided encoding is used to encode the event data.
+func NewSink(client Client, enc codec.Encoding, opts ...SinkOption) *Sink {
+	s := Sink{
+		client:      client,
+		enc:         enc,
+		source:      DefaultSource,
+		detailType:  event.Event.Name,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = 1
+	}
+	return &s
+}
+
+// Send sends the given events to EventBridge. Events that are not selected by
+// the Events option are skipped. Send returns an error if any of the events
+// could not be sent after the configured number of attempts; the error
+// contains the error codes that were returned by EventBridge.
+func (s *Sink) Send(ctx context.Context, events ...event.Event) error {
+	entries := make([]Entry, 0, len(events))
+	for _, evt := range events {
+		if s.names != nil && !s.names[evt.Name()] {
+			continue
+		}
+
+		entry, err := s.Entry(ctx, evt)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	for len(entries) > 0 {
+		n := min(len(entries), MaxEntries)
+		if err := s.send(ctx, entries[:n]); err != nil {
+			return err
+		}
+		entries = entries[n:]
+	}
+
+	return nil
+}
+
+func (s *Sink) send(ctx context.Context, entries []Entry) error {
+	backoff := s.backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if entries, err = s.put(ctx, entries); err == nil {
+			return nil
+		}
+
+		if attempt >= s.maxAttempts {
+			return fmt.Errorf("send %d events after %d attempts: %w", len(entries), attempt, err)
+		}
+
+		timer := stdtime.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// put sends the entries and returns the entries that failed.
+func (s *Sink) put(ctx context.Context, entries []Entry) ([]Entry, error) {
+	results, err := s.client.PutEvents(ctx, entries)
+	if err != nil {
+		return entries, err
+	}
+
+	if len(results) != len(entries) {
+		return entries, fmt.Errorf("client returned %d results for %d entries", len(results), len(entries))
+	}
+
+	var (
+		failed []Entry
+		errs   []string
+	)
+	for i, res := range results {
+		if res.Failed() {
+			failed = append(failed, entries[i])
+			errs = append(errs, fmt.Sprintf("%s: %s (%s)", entries[i].DetailType, res.ErrorCode, res.ErrorMessage))
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed, errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil, nil
+}
+
+// Entry returns the EventBridge entry of the given event.
+func (s *Sink) Entry(ctx context.Context, evt event.Event) (Entry, error) {
+	data, err := codec.MarshalContext(ctx, s.enc, evt.Data())
+	if err != nil {
+		return Entry{}, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	if !json.Valid(data) {
+		return Entry{}, fmt.Errorf("encode %q event data: encoded data is not JSON", evt.Name())
+	}
+
+	detail := Detail{
+		ID:   evt.ID(),
+		Name: evt.Name(),
+		Time: evt.Time(),
+		Data: data,
+	}
+
+	if id, name, v := evt.Aggregate(); name != "" {
+		detail.AggregateName = name
+		detail.AggregateID = &id
+		detail.AggregateVersion = v
+	}
+
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return Entry{}, fmt.Errorf("encode %q event detail: %w", evt.Name(), err)
+	}
+
+	entry := Entry{
+		Source:       s.source,
+		DetailType:   s.detailType(evt),
+		Detail:       string(b),
+		EventBusName: s.bus,
+		Time:         evt.Time(),
+	}
+
+	if s.resources != nil {
+		entry.Resources = s.resources(evt)
+	}
+
+	return entry, nil
+}
+
+// Run subscribes to the events that are selected by the Events option and
+// sends them to EventBridge until ctx is canceled. If no events are selected,
+// Run returns an error. Errors that occur while sending events are sent to the
+// returned channel, which is closed when ctx is canceled. An error channel
+// must be drained by the caller.
+//
+// Events that are published while the Sink is not running are not sent. Use
+// Send from a component that tracks its progress, e.g. a projection job, if
+// every event must be forwarded.
+func (s *Sink) Run(ctx context.Context, bus event.Bus) (<-chan error, error) {
+	if len(s.names) == 0 {
+		return nil, errors.New("no events selected; use the Events option")
+	}
+
+	names := make([]string, 0, len(s.names))
+	for name := range s.names {
+		names = append(names, name)
+	}
+
+	events, errs, err := bus.Subscribe(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to events: %w", err)
+	}
+
+	out := make(chan error)
+
+	go func() {
+		defer close(out)
+
+		fail := func(err error) {
+			select {
+			case <-ctx.Done():
+			case out <- err:
+			}
+		}
+
+		for events != nil || errs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					break
+				}
+				fail(err)
+			case evt, ok := <-events:
+				if !ok {
+					events = nil
+					break
+				}
+				if err := s.Send(ctx, evt); err != nil && ctx.Err() == nil {
+					fail(err)
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
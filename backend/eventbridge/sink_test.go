@@ -0,0 +1,212 @@
+package eventbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/eventbridge"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestSink_Send(t *testing.T) {
+	aggregateID := uuid.New()
+	events := make([]event.Event, 0, 13)
+	for i := 0; i < 12; i++ {
+		events = append(events, event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(aggregateID, "foobar", i+1)).Any())
+	}
+	events = append(events, event.New("bar", test.BarEventData{A: "bar"}).Any())
+
+	client := &recordingClient{}
+	sink := eventbridge.NewSink(client, test.NewEncoder(), eventbridge.Source("shop"), eventbridge.EventBus("orders"), eventbridge.Events("foo"))
+
+	if err := sink.Send(context.Background(), events...); err != nil {
+		t.Fatalf("Send() failed with %q", err)
+	}
+
+	if len(client.requests) != 2 {
+		t.Fatalf("%d requests should have been sent; got %d", 2, len(client.requests))
+	}
+
+	if len(client.requests[0]) != eventbridge.MaxEntries || len(client.requests[1]) != 2 {
+		t.Fatalf("requests should contain %d and %d entries; got %d and %d", eventbridge.MaxEntries, 2, len(client.requests[0]), len(client.requests[1]))
+	}
+
+	entries := client.entries()
+	for i, entry := range entries {
+		evt := events[i]
+
+		if entry.Source != "shop" {
+			t.Errorf("Source should be %q; got %q", "shop", entry.Source)
+		}
+
+		if entry.DetailType != "foo" {
+			t.Errorf("DetailType should be %q; got %q", "foo", entry.DetailType)
+		}
+
+		if entry.EventBusName != "orders" {
+			t.Errorf("EventBusName should be %q; got %q", "orders", entry.EventBusName)
+		}
+
+		var detail eventbridge.Detail
+		if err := json.Unmarshal([]byte(entry.Detail), &detail); err != nil {
+			t.Fatalf("failed to decode detail: %v", err)
+		}
+
+		if detail.ID != evt.ID() {
+			t.Errorf("detail id should be %s; got %s", evt.ID(), detail.ID)
+		}
+
+		if detail.AggregateName != "foobar" || detail.AggregateID == nil || *detail.AggregateID != aggregateID || detail.AggregateVersion != i+1 {
+			t.Errorf("detail should contain the aggregate of the event; got %q %v v%d", detail.AggregateName, detail.AggregateID, detail.AggregateVersion)
+		}
+
+		if string(detail.Data) != `{"A":"foo"}` {
+			t.Errorf("detail data should be %s; got %s", `{"A":"foo"}`, detail.Data)
+		}
+	}
+}
+
+func TestSink_Send_retry(t *testing.T) {
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}).Any(),
+		event.New("baz", test.BazEventData{A: "baz"}).Any(),
+	}
+
+	client := &recordingClient{
+		fail: func(attempt int, entry eventbridge.Entry) bool {
+			return attempt == 1 && entry.DetailType == "bar"
+		},
+	}
+	sink := eventbridge.NewSink(client, test.NewEncoder(), eventbridge.Retry(3, time.Millisecond))
+
+	if err := sink.Send(context.Background(), events...); err != nil {
+		t.Fatalf("Send() failed with %q", err)
+	}
+
+	if len(client.requests) != 2 {
+		t.Fatalf("%d requests should have been sent; got %d", 2, len(client.requests))
+	}
+
+	if len(client.requests[1]) != 1 || client.requests[1][0].DetailType != "bar" {
+		t.Fatalf("only the failed entry should be retried; got %v", client.requests[1])
+	}
+}
+
+func TestSink_Send_retryExhausted(t *testing.T) {
+	client := &recordingClient{
+		fail: func(int, eventbridge.Entry) bool { return true },
+	}
+	sink := eventbridge.NewSink(client, test.NewEncoder(), eventbridge.Retry(3, time.Millisecond))
+
+	if err := sink.Send(context.Background(), event.New("foo", test.FooEventData{}).Any()); err == nil {
+		t.Fatalf("Send() should fail if an entry fails after all attempts")
+	}
+
+	if len(client.requests) != 3 {
+		t.Fatalf("%d requests should have been sent; got %d", 3, len(client.requests))
+	}
+}
+
+func TestSink_Send_requestError(t *testing.T) {
+	var calls int
+	mockError := errors.New("mock error")
+	client := eventbridge.ClientFunc(func(_ context.Context, entries []eventbridge.Entry) ([]eventbridge.Result, error) {
+		calls++
+		if calls == 1 {
+			return nil, mockError
+		}
+		return make([]eventbridge.Result, len(entries)), nil
+	})
+	sink := eventbridge.NewSink(client, test.NewEncoder(), eventbridge.Retry(2, time.Millisecond))
+
+	if err := sink.Send(context.Background(), event.New("foo", test.FooEventData{}).Any()); err != nil {
+		t.Fatalf("Send() failed with %q", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("request should have been retried; got %d calls", calls)
+	}
+}
+
+func TestSink_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	client := &recordingClient{}
+	sink := eventbridge.NewSink(client, test.NewEncoder(), eventbridge.Events("foo"))
+
+	errs, err := sink.Run(ctx, bus)
+	if err != nil {
+		t.Fatalf("Run() failed with %q", err)
+	}
+	go func() {
+		for err := range errs {
+			t.Errorf("sink: %v", err)
+		}
+	}()
+
+	evt := event.New("foo", test.FooEventData{A: "foo"}).Any()
+	if err := bus.Publish(ctx, evt, event.New("bar", test.BarEventData{}).Any()); err != nil {
+		t.Fatalf("Publish() failed with %q", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(client.entries()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	entries := client.entries()
+	if len(entries) != 1 || entries[0].DetailType != "foo" {
+		t.Fatalf("only the %q event should have been sent; got %v", "foo", entries)
+	}
+}
+
+func TestSink_Run_noEvents(t *testing.T) {
+	sink := eventbridge.NewSink(&recordingClient{}, test.NewEncoder())
+	if _, err := sink.Run(context.Background(), eventbus.New()); err == nil {
+		t.Fatalf("Run() should fail if no events are selected")
+	}
+}
+
+type recordingClient struct {
+	mux      sync.Mutex
+	requests [][]eventbridge.Entry
+	fail     func(attempt int, entry eventbridge.Entry) bool
+}
+
+func (c *recordingClient) PutEvents(_ context.Context, entries []eventbridge.Entry) ([]eventbridge.Result, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.requests = append(c.requests, entries)
+
+	results := make([]eventbridge.Result, len(entries))
+	for i, entry := range entries {
+		if c.fail != nil && c.fail(len(c.requests), entry) {
+			results[i] = eventbridge.Result{ErrorCode: "ThrottlingException", ErrorMessage: "rate exceeded"}
+			continue
+		}
+		results[i] = eventbridge.Result{EventID: uuid.NewString()}
+	}
+
+	return results, nil
+}
+
+func (c *recordingClient) entries() []eventbridge.Entry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var out []eventbridge.Entry
+	for _, req := range c.requests {
+		out = append(out, req...)
+	}
+	return out
+}
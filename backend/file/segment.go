@@ -0,0 +1,269 @@
+package file
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/internal/storage"
+)
+
+// segmentMagic is the header of segment files.
+var segmentMagic = []byte("GOESSEG1")
+
+const (
+	segmentExt   = ".seg"
+	recordHeader = 4 + 4 // length + checksum
+	maxRecord    = 1 << 30
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Kinds of records.
+const (
+	recordInsert = byte(1)
+	recordDelete = byte(2)
+)
+
+var errTornRecord = errors.New("torn record")
+
+// segment is a segment file of the event store. Records are appended to a
+// segment as
+//
+//	<len(kind+payload) uint32><crc32c(kind+payload) uint32><kind><payload>
+//
+// An insert record contains the entries of a single Insert call, so that a
+// crash never leaves a partial insert behind. A delete record contains the
+// ids of the deleted events.
+type segment struct {
+	seq  int
+	path string
+	f    *os.File
+	size int64
+}
+
+func segmentPath(dir string, seq int) string {
+	return filepath.Join(dir, fmt.Sprintf("%08d%s", seq, segmentExt))
+}
+
+// listSegments returns the sequence numbers of the segment files in dir in
+// ascending order.
+func listSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []int
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(name, segmentExt))
+		if err != nil {
+			continue
+		}
+		out = append(out, seq)
+	}
+	sort.Ints(out)
+
+	return out, nil
+}
+
+func createSegment(dir string, seq int) (*segment, error) {
+	path := segmentPath(dir, seq)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Write(segmentMagic); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	return &segment{seq: seq, path: path, f: f, size: int64(len(segmentMagic))}, nil
+}
+
+func openSegment(dir string, seq int, readOnly bool) (*segment, error) {
+	flag := os.O_RDWR
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+
+	path := segmentPath(dir, seq)
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(segmentMagic))
+	if _, err := io.ReadFull(f, header); err != nil || string(header) != string(segmentMagic) {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w: invalid header", path, ErrCorrupted)
+	}
+
+	return &segment{seq: seq, path: path, f: f, size: int64(len(segmentMagic))}, nil
+}
+
+// replay reads the records of the segment and calls fn for each record with
+// the offset of its payload. replay stops at the first torn or corrupted
+// record and returns errTornRecord; the size of the segment is then the end
+// of the last valid record.
+func (s *segment) replay(fn func(kind byte, payload []byte, offset int64) error) error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	end := info.Size()
+
+	header := make([]byte, recordHeader)
+	for s.size < end {
+		if end-s.size < recordHeader {
+			return errTornRecord
+		}
+
+		if _, err := s.f.ReadAt(header, s.size); err != nil {
+			return errTornRecord
+		}
+
+		n := int64(binary.BigEndian.Uint32(header))
+		if n < 1 || n > maxRecord || s.size+recordHeader+n > end {
+			return errTornRecord
+		}
+
+		body := make([]byte, n)
+		if _, err := s.f.ReadAt(body, s.size+recordHeader); err != nil {
+			return errTornRecord
+		}
+
+		if crc32.Checksum(body, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+			return errTornRecord
+		}
+
+		if err := fn(body[0], body[1:], s.size+recordHeader+1); err != nil {
+			return err
+		}
+
+		s.size += recordHeader + n
+	}
+
+	return nil
+}
+
+// append appends a record to the segment and returns the offset of its
+// payload.
+func (s *segment) append(kind byte, payload []byte, sync bool) (int64, error) {
+	b := make([]byte, recordHeader, recordHeader+1+len(payload))
+	b = append(b, kind)
+	b = append(b, payload...)
+	binary.BigEndian.PutUint32(b, uint32(1+len(payload)))
+	binary.BigEndian.PutUint32(b[4:], crc32.Checksum(b[recordHeader:], crcTable))
+
+	if _, err := s.f.WriteAt(b, s.size); err != nil {
+		// drop a partially written record, so that the next record is not
+		// appended after garbage
+		s.f.Truncate(s.size)
+		return 0, err
+	}
+
+	if sync {
+		if err := s.f.Sync(); err != nil {
+			return 0, err
+		}
+	}
+
+	offset := s.size + recordHeader + 1
+	s.size += int64(len(b))
+
+	return offset, nil
+}
+
+func (s *segment) read(offset int64, size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := s.f.ReadAt(b, offset); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// marshalInsert encodes the entries of an insert record:
+//
+//	<count uvarint>(<len(entry) uvarint><entry>)...
+//
+// and returns the offsets and sizes of the entries within the payload.
+func marshalInsert(entries []storage.Entry) (payload []byte, offsets []int, sizes []int, err error) {
+	payload = binary.AppendUvarint(nil, uint64(len(entries)))
+	offsets = make([]int, len(entries))
+	sizes = make([]int, len(entries))
+	for i, e := range entries {
+		b, err := e.Marshal()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("marshal %q event: %w", e.Name, err)
+		}
+		payload = binary.AppendUvarint(payload, uint64(len(b)))
+		offsets[i] = len(payload)
+		sizes[i] = len(b)
+		payload = append(payload, b...)
+	}
+	return
+}
+
+// unmarshalInsert calls fn for each entry of an insert record payload with
+// the offset and size of the entry within the payload.
+func unmarshalInsert(payload []byte, fn func(e storage.Entry, offset, size int) error) error {
+	count, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return storage.ErrMalformedEntry
+	}
+	pos := n
+
+	for i := uint64(0); i < count; i++ {
+		size, n := binary.Uvarint(payload[pos:])
+		if n <= 0 || uint64(len(payload)-pos-n) < size {
+			return storage.ErrMalformedEntry
+		}
+		pos += n
+
+		var e storage.Entry
+		if err := e.Unmarshal(payload[pos : pos+int(size)]); err != nil {
+			return err
+		}
+
+		if err := fn(e, pos, int(size)); err != nil {
+			return err
+		}
+
+		pos += int(size)
+	}
+
+	return nil
+}
+
+func marshalDelete(ids []uuid.UUID) []byte {
+	b := make([]byte, 0, 16*len(ids))
+	for _, id := range ids {
+		b = append(b, id[:]...)
+	}
+	return b
+}
+
+func unmarshalDelete(payload []byte) ([]uuid.UUID, error) {
+	if len(payload)%16 != 0 {
+		return nil, storage.ErrMalformedEntry
+	}
+	ids := make([]uuid.UUID, len(payload)/16)
+	for i := range ids {
+		copy(ids[i][:], payload[i*16:])
+	}
+	return ids, nil
+}
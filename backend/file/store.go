@@ -0,0 +1,599 @@
+// Package file provides an append-only event store that is backed by segment
+// files in a local directory. The store does not depend on any database,
+// which makes it suitable for air-gapped deployments, and its files can be
+// checked in as reproducible test fixtures.
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// DefaultMaxSegmentSize is the default size in bytes after which a new
+// segment file is started.
+const DefaultMaxSegmentSize = 64 << 20
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = storage.ErrEventNotFound
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = storage.ErrDuplicateEvent
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = storage.ErrVersionExists
+
+	// ErrReadOnly is returned by Insert and Delete if the store was opened
+	// in read-only mode.
+	ErrReadOnly = errors.New("store is read-only")
+
+	// ErrCorrupted is returned by Open if a segment file other than the last
+	// one is corrupted.
+	ErrCorrupted = errors.New("corrupted segment")
+
+	// ErrClosed is returned if the store is used after it was closed.
+	ErrClosed = errors.New("store closed")
+)
+
+// EventStore is an append-only event store that is backed by segment files.
+// Inserts and deletes are appended as records to the active segment, and a
+// new segment is started when the active segment exceeds the maximum segment
+// size. Deleted events are recorded as tombstones; their data stays in the
+// segment files.
+//
+// The store keeps an index of all events in memory and reads the event data
+// from the segment files. The index is rebuilt from the segment files when
+// the store is opened. If the store crashed while appending a record, the
+// incomplete record at the end of the last segment is discarded.
+//
+// A directory must only be used by a single EventStore at a time.
+type EventStore struct {
+	enc            codec.Encoding
+	dir            string
+	maxSegmentSize int64
+	noSync         bool
+	readOnly       bool
+
+	onceOpen  sync.Once
+	openErr   error
+	onceClose sync.Once
+	closeErr  error
+
+	mux      sync.RWMutex
+	closed   bool
+	segments map[int]*segment
+	active   *segment
+	events   map[uuid.UUID]*indexEntry
+	versions map[event.AggregateRef]map[int]uuid.UUID
+}
+
+// indexEntry is an indexed event. The entry contains the metadata of the
+// event without its data, which is read from the segment on demand.
+type indexEntry struct {
+	storage.Entry
+	segment int
+	offset  int64
+	size    int
+}
+
+// EventStoreOption is an option for the file event store.
+type EventStoreOption func(*EventStore)
+
+// MaxSegmentSize returns an EventStoreOption that specifies the size in bytes
+// after which a new segment file is started. Defaults to
+// DefaultMaxSegmentSize.
+func MaxSegmentSize(size int64) EventStoreOption {
+	return func(s *EventStore) {
+		s.maxSegmentSize = size
+	}
+}
+
+// NoSync returns an EventStoreOption that disables the syncing of segment
+// files to disk after each write. Writes become faster, but events that were
+// inserted shortly before a power loss may be lost.
+func NoSync() EventStoreOption {
+	return func(s *EventStore) {
+		s.noSync = true
+	}
+}
+
+// ReadOnly returns an EventStoreOption that opens the store in read-only
+// mode. A read-only store never modifies its directory, which makes it
+// suitable for test fixtures. Insert and Delete return ErrReadOnly.
+func ReadOnly() EventStoreOption {
+	return func(s *EventStore) {
+		s.readOnly = true
+	}
+}
+
+// NewEventStore returns a new file event store that stores its segment files
+// in dir. The directory is created on first use, or by calling Open
+// explicitly.
+func NewEventStore(enc codec.Encoding, dir string, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:            enc,
+		dir:            dir,
+		maxSegmentSize: DefaultMaxSegmentSize,
+		segments:       make(map[int]*segment),
+		events:         make(map[uuid.UUID]*indexEntry),
+		versions:       make(map[event.AggregateRef]map[int]uuid.UUID),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Dir returns the directory of the store.
+func (s *EventStore) Dir() string {
+	return s.dir
+}
+
+// Open opens the segment files of the store and builds the index. Open is
+// automatically called from the Insert, Find, Query, and Delete methods if
+// not called explicitly.
+func (s *EventStore) Open() error {
+	s.onceOpen.Do(func() {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		s.openErr = s.open()
+	})
+	return s.openErr
+}
+
+func (s *EventStore) open() error {
+	if s.dir == "" {
+		return errors.New("missing directory")
+	}
+
+	if !s.readOnly {
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+	}
+
+	seqs, err := listSegments(s.dir)
+	if err != nil {
+		return fmt.Errorf("list segments: %w", err)
+	}
+
+	for i, seq := range seqs {
+		last := i == len(seqs)-1
+
+		seg, err := openSegment(s.dir, seq, s.readOnly)
+		if err != nil {
+			s.closeSegments()
+			return fmt.Errorf("open segment: %w", err)
+		}
+		s.segments[seq] = seg
+
+		if err := seg.replay(func(kind byte, payload []byte, offset int64) error {
+			return s.apply(seq, kind, payload, offset)
+		}); err != nil {
+			if !errors.Is(err, errTornRecord) || !last {
+				s.closeSegments()
+				return fmt.Errorf("replay %s: %w", seg.path, errors.Join(ErrCorrupted, err))
+			}
+
+			if !s.readOnly {
+				log.Printf("[goes/backend/file] Discarding incomplete record at the end of %s (offset %d).", seg.path, seg.size)
+				if err := seg.f.Truncate(seg.size); err != nil {
+					s.closeSegments()
+					return fmt.Errorf("truncate %s: %w", seg.path, err)
+				}
+			}
+		}
+
+		if last {
+			s.active = seg
+		}
+	}
+
+	return nil
+}
+
+// apply applies a record to the index.
+func (s *EventStore) apply(seq int, kind byte, payload []byte, offset int64) error {
+	switch kind {
+	case recordInsert:
+		return unmarshalInsert(payload, func(e storage.Entry, pos, size int) error {
+			s.index(&indexEntry{Entry: e, segment: seq, offset: offset + int64(pos), size: size})
+			return nil
+		})
+	case recordDelete:
+		ids, err := unmarshalDelete(payload)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			s.unindex(id)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown record kind %d", kind)
+	}
+}
+
+func (s *EventStore) index(e *indexEntry) {
+	e.Data = nil
+	s.events[e.ID] = e
+	if e.AggregateName != "" && e.AggregateVersion > 0 {
+		ref := event.AggregateRef{Name: e.AggregateName, ID: e.AggregateID}
+		if s.versions[ref] == nil {
+			s.versions[ref] = make(map[int]uuid.UUID)
+		}
+		s.versions[ref][e.AggregateVersion] = e.ID
+	}
+}
+
+func (s *EventStore) unindex(id uuid.UUID) {
+	e, ok := s.events[id]
+	if !ok {
+		return
+	}
+	delete(s.events, id)
+
+	ref := event.AggregateRef{Name: e.AggregateName, ID: e.AggregateID}
+	if versions := s.versions[ref]; versions != nil && versions[e.AggregateVersion] == id {
+		delete(versions, e.AggregateVersion)
+		if len(versions) == 0 {
+			delete(s.versions, ref)
+		}
+	}
+}
+
+// Close closes the segment files of the store.
+func (s *EventStore) Close() error {
+	s.onceClose.Do(func() {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+		s.closed = true
+		s.closeErr = s.closeSegments()
+	})
+	return s.closeErr
+}
+
+func (s *EventStore) closeSegments() error {
+	var errs []error
+	for _, seg := range s.segments {
+		if err := seg.f.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", seg.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Insert appends events to the store. Either all or none of the events are
+// inserted.
+func (s *EventStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]storage.Entry, len(events))
+	for i, evt := range events {
+		data, err := storage.Marshal(ctx, s.enc, evt)
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+		entries[i] = storage.NewEntry(evt, data)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if err := s.checkUnique(entries); err != nil {
+		return err
+	}
+
+	payload, positions, sizes, err := marshalInsert(entries)
+	if err != nil {
+		return err
+	}
+
+	seg, err := s.segmentFor(len(payload))
+	if err != nil {
+		return err
+	}
+
+	offset, err := seg.append(recordInsert, payload, !s.noSync)
+	if err != nil {
+		return fmt.Errorf("append to %s: %w", seg.path, err)
+	}
+
+	for i, e := range entries {
+		s.index(&indexEntry{Entry: e, segment: seg.seq, offset: offset + int64(positions[i]), size: sizes[i]})
+	}
+
+	return nil
+}
+
+func (s *EventStore) checkUnique(entries []storage.Entry) error {
+	ids := make(map[uuid.UUID]bool, len(entries))
+	versions := make(map[event.AggregateRef]map[int]bool)
+
+	for _, e := range entries {
+		if _, ok := s.events[e.ID]; ok || ids[e.ID] {
+			return fmt.Errorf("%s:%s %w", e.Name, e.ID, ErrDuplicateEvent)
+		}
+		ids[e.ID] = true
+
+		if e.AggregateName == "" || e.AggregateVersion <= 0 {
+			continue
+		}
+
+		ref := event.AggregateRef{Name: e.AggregateName, ID: e.AggregateID}
+		if _, ok := s.versions[ref][e.AggregateVersion]; ok || versions[ref][e.AggregateVersion] {
+			return fmt.Errorf("%s:%s %w [version=%d]", e.Name, e.ID, ErrVersionExists, e.AggregateVersion)
+		}
+		if versions[ref] == nil {
+			versions[ref] = make(map[int]bool)
+		}
+		versions[ref][e.AggregateVersion] = true
+	}
+
+	return nil
+}
+
+// segmentFor returns the segment to which a record with the given payload
+// size is appended, and starts a new segment if the active segment would
+// exceed the maximum segment size.
+func (s *EventStore) segmentFor(payloadSize int) (*segment, error) {
+	if s.active != nil {
+		size := s.active.size + recordHeader + 1 + int64(payloadSize)
+		if s.active.size <= int64(len(segmentMagic)) || size <= s.maxSegmentSize {
+			return s.active, nil
+		}
+
+		if !s.noSync {
+			if err := s.active.f.Sync(); err != nil {
+				return nil, fmt.Errorf("sync %s: %w", s.active.path, err)
+			}
+		}
+	}
+
+	seq := 1
+	if s.active != nil {
+		seq = s.active.seq + 1
+	}
+
+	seg, err := createSegment(s.dir, seq)
+	if err != nil {
+		return nil, fmt.Errorf("create segment: %w", err)
+	}
+	s.segments[seq] = seg
+	s.active = seg
+
+	return seg, nil
+}
+
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	return s.Insert(ctx, storage.Events(events)...)
+}
+
+// Find fetches the event with the given id from the store.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Open(); err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	ie, ok := s.events[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, ErrEventNotFound)
+	}
+
+	e, err := s.load(ie)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Raw().DecodeContext(ctx, s.enc)
+}
+
+// load reads the data of an indexed event from its segment.
+func (s *EventStore) load(ie *indexEntry) (storage.Entry, error) {
+	seg, ok := s.segments[ie.segment]
+	if !ok {
+		return storage.Entry{}, fmt.Errorf("%w: missing segment %d", ErrCorrupted, ie.segment)
+	}
+
+	b, err := seg.read(ie.offset, ie.size)
+	if err != nil {
+		return storage.Entry{}, fmt.Errorf("read event %s from %s: %w", ie.ID, seg.path, err)
+	}
+
+	var e storage.Entry
+	if err := e.Unmarshal(b); err != nil {
+		return storage.Entry{}, fmt.Errorf("unmarshal event %s: %w", ie.ID, err)
+	}
+
+	return e, nil
+}
+
+// Delete deletes events from the store by appending a tombstone record.
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.Open(); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, evt := range events {
+		if _, ok := s.events[evt.ID()]; ok {
+			ids = append(ids, evt.ID())
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	payload := marshalDelete(ids)
+
+	seg, err := s.segmentFor(len(payload))
+	if err != nil {
+		return err
+	}
+
+	if _, err := seg.append(recordDelete, payload, !s.noSync); err != nil {
+		return fmt.Errorf("append to %s: %w", seg.path, err)
+	}
+
+	for _, id := range ids {
+		s.unindex(id)
+	}
+
+	return nil
+}
+
+// Query queries the store for events. The query is tested against the
+// in-memory index, and only the data of matching events is read from the
+// segment files.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.Open(); err != nil {
+		return nil, nil, fmt.Errorf("open: %w", err)
+	}
+
+	events, err := s.query(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		for _, evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+func (s *EventStore) query(ctx context.Context, q event.Query) ([]event.Event, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	var candidates []*indexEntry
+	if ids := q.IDs(); len(ids) > 0 {
+		for _, id := range ids {
+			if ie, ok := s.events[id]; ok && ie.Matches(q) {
+				candidates = append(candidates, ie)
+			}
+		}
+	} else {
+		for _, ie := range s.events {
+			if ie.Matches(q) {
+				candidates = append(candidates, ie)
+			}
+		}
+	}
+
+	skip := event.UndecodableHandler(q)
+	events := make([]event.Event, 0, len(candidates))
+	for _, ie := range candidates {
+		e, err := s.load(ie)
+		if err != nil {
+			return nil, err
+		}
+
+		evt, err := e.Raw().DecodeContext(ctx, s.enc)
+		if err != nil {
+			var decodeErr *event.DecodeError
+			if skip != nil && errors.As(err, &decodeErr) {
+				skip(decodeErr)
+				continue
+			}
+			return nil, fmt.Errorf("decode event: %w", err)
+		}
+
+		events = append(events, evt)
+	}
+
+	return event.SortMulti(events, q.Sortings()...), nil
+}
+
+// Stats returns statistics about the events in the store, which are computed
+// from the in-memory index.
+func (s *EventStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	if err := s.Open(); err != nil {
+		return event.StoreStats{}, fmt.Errorf("open: %w", err)
+	}
+
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	out := event.StoreStats{EventsPerName: make(map[string]int)}
+	aggregates := make(map[event.AggregateRef]struct{})
+	for _, ie := range s.events {
+		out.Add(ie.Name, ie.Raw().Time)
+		if ie.AggregateName != "" && ie.AggregateID != uuid.Nil {
+			aggregates[event.AggregateRef{Name: ie.AggregateName, ID: ie.AggregateID}] = struct{}{}
+		}
+	}
+	out.Aggregates = len(aggregates)
+
+	return out, nil
+}
@@ -0,0 +1,226 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/file"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore(t *testing.T) {
+	eventstoretest.Run(t, "file", func(enc codec.Encoding) event.Store {
+		store := file.NewEventStore(enc, t.TempDir(), file.NoSync())
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestEventStore_reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := file.NewEventStore(etest.NewEncoder(), dir)
+
+	id := uuid.New()
+	foo := event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1))
+	bar := event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Aggregate(id, "foo", 2))
+	if err := store.Insert(ctx, foo, bar); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if err := store.Delete(ctx, foo); err != nil {
+		t.Fatalf("Delete failed with %q", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed with %q", err)
+	}
+
+	store = file.NewEventStore(etest.NewEncoder(), dir)
+	defer store.Close()
+
+	if _, err := store.Find(ctx, foo.ID()); !errors.Is(err, file.ErrEventNotFound) {
+		t.Fatalf("deleted event should not be found after reopening; got %q", err)
+	}
+
+	found, err := store.Find(ctx, bar.ID())
+	if err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+
+	if !event.Equal(found, bar.Event()) {
+		t.Fatalf("reopened store should return the inserted event.\n\nwant: %v\n\ngot: %v", bar, found)
+	}
+
+	// the version of the deleted event is free again
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1))); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2))); !errors.Is(err, file.ErrVersionExists) {
+		t.Fatalf("Insert should fail with %q; got %q", file.ErrVersionExists, err)
+	}
+}
+
+func TestEventStore_rotation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := file.NewEventStore(etest.NewEncoder(), dir, file.NoSync(), file.MaxSegmentSize(256))
+
+	var events []event.Event
+	for i := 0; i < 20; i++ {
+		evt := event.New[any]("foo", etest.FooEventData{A: "foo"})
+		if err := store.Insert(ctx, evt); err != nil {
+			t.Fatalf("Insert failed with %q", err)
+		}
+		events = append(events, evt)
+	}
+	store.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segments) < 2 {
+		t.Fatalf("store should rotate segments; got %d segments", len(segments))
+	}
+
+	store = file.NewEventStore(etest.NewEncoder(), dir, file.ReadOnly())
+	defer store.Close()
+
+	etest.AssertEqualEventsUnsorted(t, events, queryAll(t, store, query.New()))
+}
+
+func TestEventStore_recovery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := file.NewEventStore(etest.NewEncoder(), dir)
+	foo := event.New[any]("foo", etest.FooEventData{A: "foo"})
+	if err := store.Insert(ctx, foo); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+	store.Close()
+
+	segment := filepath.Join(dir, "00000001.seg")
+	info, err := os.Stat(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash while appending a record
+	f, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 42, 1, 2, 3})
+	f.Close()
+
+	store = file.NewEventStore(etest.NewEncoder(), dir)
+	defer store.Close()
+
+	if _, err := store.Find(ctx, foo.ID()); err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+
+	if info2, _ := os.Stat(segment); info2.Size() != info.Size() {
+		t.Fatalf("incomplete record should be truncated; size is %d, want %d", info2.Size(), info.Size())
+	}
+
+	bar := event.New[any]("bar", etest.BarEventData{A: "bar"})
+	if err := store.Insert(ctx, bar); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	etest.AssertEqualEventsUnsorted(t, []event.Event{foo, bar}, queryAll(t, store, query.New()))
+}
+
+func TestEventStore_recovery_corrupted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := file.NewEventStore(etest.NewEncoder(), dir, file.MaxSegmentSize(64))
+	for i := 0; i < 3; i++ {
+		if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"})); err != nil {
+			t.Fatalf("Insert failed with %q", err)
+		}
+	}
+	store.Close()
+
+	// corrupt a record of the first segment
+	segment := filepath.Join(dir, "00000001.seg")
+	b, err := os.ReadFile(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 0xff
+	if err := os.WriteFile(segment, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store = file.NewEventStore(etest.NewEncoder(), dir)
+	defer store.Close()
+
+	if err := store.Open(); !errors.Is(err, file.ErrCorrupted) {
+		t.Fatalf("Open should fail with %q; got %q", file.ErrCorrupted, err)
+	}
+}
+
+func TestEventStore_ReadOnly(t *testing.T) {
+	store := file.NewEventStore(etest.NewEncoder(), t.TempDir(), file.ReadOnly())
+	defer store.Close()
+
+	if err := store.Insert(context.Background(), event.New[any]("foo", etest.FooEventData{})); !errors.Is(err, file.ErrReadOnly) {
+		t.Fatalf("Insert should fail with %q; got %q", file.ErrReadOnly, err)
+	}
+}
+
+func queryAll(t *testing.T, store event.Store, q event.Query) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	return events
+}
+
+func TestEventStore_Query_tags(t *testing.T) {
+	store := file.NewEventStore(etest.NewEncoder(), t.TempDir(), file.NoSync())
+	defer store.Close()
+
+	_, _, err := store.Query(context.Background(), query.New(query.Tag("import:42")))
+	if !errors.Is(err, event.ErrTagsUnsupported) {
+		t.Fatalf("Query() should fail with %q; got %q", event.ErrTagsUnsupported, err)
+	}
+}
+
+func TestEventStore_Insert_nameTooLong(t *testing.T) {
+	store := file.NewEventStore(etest.NewEncoder(), t.TempDir(), file.NoSync())
+	defer store.Close()
+
+	evt := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(uuid.New(), strings.Repeat("a", math.MaxUint16+1), 1))
+	if err := store.Insert(context.Background(), evt); err == nil {
+		t.Fatalf("Insert() should fail for aggregate names that exceed %d bytes", math.MaxUint16)
+	}
+
+	if _, err := store.Find(context.Background(), evt.ID()); !errors.Is(err, file.ErrEventNotFound) {
+		t.Fatalf("Find() should fail with %q; got %q", file.ErrEventNotFound, err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package storage provides the storage representation of events that is
+// shared by the event stores that store events as opaque entries (badger,
+// bolt, file, redis and s3).
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = errors.New("event not found")
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = errors.New("duplicate event")
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = errors.New("aggregate version already exists")
+
+	// ErrMalformedEntry is returned by Entry.Unmarshal if the encoded entry is
+	// malformed.
+	ErrMalformedEntry = errors.New("malformed entry")
+
+	// ErrNameTooLong is returned by Entry.Marshal if the event name or the
+	// aggregate name of an entry exceeds math.MaxUint16 bytes.
+	ErrNameTooLong = fmt.Errorf("name exceeds %d bytes", math.MaxUint16)
+)
+
+// Entry is an event as it is stored by an event store.
+type Entry struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Time             int64     `json:"time"`
+	AggregateName    string    `json:"aggregateName,omitempty"`
+	AggregateID      uuid.UUID `json:"aggregateId,omitempty"`
+	AggregateVersion int       `json:"aggregateVersion,omitempty"`
+	Data             []byte    `json:"data"`
+}
+
+// NewEntry returns the entry of the given event and its encoded data.
+func NewEntry(evt event.Event, data []byte) Entry {
+	id, name, v := evt.Aggregate()
+	return Entry{
+		ID:               evt.ID(),
+		Name:             evt.Name(),
+		Time:             evt.Time().UnixNano(),
+		AggregateName:    name,
+		AggregateID:      id,
+		AggregateVersion: v,
+		Data:             data,
+	}
+}
+
+// Raw returns the entry as a raw event.
+func (e Entry) Raw() event.RawEvent {
+	return event.RawEvent{
+		ID:               e.ID,
+		Name:             e.Name,
+		Time:             stdtime.Unix(0, e.Time),
+		Aggregate:        event.AggregateRef{Name: e.AggregateName, ID: e.AggregateID},
+		AggregateVersion: e.AggregateVersion,
+		Data:             e.Data,
+	}
+}
+
+// Matches reports whether the entry matches the query, without decoding the
+// event data.
+func (e Entry) Matches(q event.Query) bool {
+	return event.Test(q, event.New[any](
+		e.Name,
+		EncodedData(e.Data),
+		event.ID(e.ID),
+		event.Time(stdtime.Unix(0, e.Time)),
+		event.Aggregate(e.AggregateID, e.AggregateName, e.AggregateVersion),
+	))
+}
+
+// Marshal encodes the entry in a compact binary format:
+//
+//	<id><time><aggregate id><version><len(name)><name><len(aggregate name)><aggregate name><data>
+//
+// The lengths of the names are encoded as uint16, so Marshal fails with
+// ErrNameTooLong if a name exceeds math.MaxUint16 bytes.
+func (e Entry) Marshal() ([]byte, error) {
+	if len(e.Name) > math.MaxUint16 {
+		return nil, fmt.Errorf("event name: %w", ErrNameTooLong)
+	}
+
+	if len(e.AggregateName) > math.MaxUint16 {
+		return nil, fmt.Errorf("aggregate name: %w", ErrNameTooLong)
+	}
+
+	b := make([]byte, 0, 16+8+16+8+2+len(e.Name)+2+len(e.AggregateName)+len(e.Data))
+	b = append(b, e.ID[:]...)
+	b = binary.BigEndian.AppendUint64(b, uint64(e.Time))
+	b = append(b, e.AggregateID[:]...)
+	b = binary.BigEndian.AppendUint64(b, uint64(e.AggregateVersion))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(e.Name)))
+	b = append(b, e.Name...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(e.AggregateName)))
+	b = append(b, e.AggregateName...)
+	return append(b, e.Data...), nil
+}
+
+// Unmarshal decodes an entry that was encoded by Marshal. The data is copied,
+// so b may be reused after Unmarshal returns.
+func (e *Entry) Unmarshal(b []byte) error {
+	const fixed = 16 + 8 + 16 + 8
+	if len(b) < fixed+2 {
+		return ErrMalformedEntry
+	}
+
+	copy(e.ID[:], b[:16])
+	e.Time = int64(binary.BigEndian.Uint64(b[16:24]))
+	copy(e.AggregateID[:], b[24:40])
+	e.AggregateVersion = int(binary.BigEndian.Uint64(b[40:48]))
+	b = b[fixed:]
+
+	name, b, err := readString(b)
+	if err != nil {
+		return fmt.Errorf("event name: %w", err)
+	}
+	e.Name = name
+
+	aggregateName, b, err := readString(b)
+	if err != nil {
+		return fmt.Errorf("aggregate name: %w", err)
+	}
+	e.AggregateName = aggregateName
+
+	e.Data = append([]byte(nil), b...)
+
+	return nil
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, ErrMalformedEntry
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	if len(b) < n {
+		return "", nil, ErrMalformedEntry
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+// EncodedData is the data of events that are inserted using InsertRaw.
+type EncodedData []byte
+
+// Events returns the given raw events as events whose data is EncodedData, so
+// that their data is stored as-is (see Marshal).
+func Events(events []event.RawEvent) []event.Event {
+	out := make([]event.Event, len(events))
+	for i, raw := range events {
+		out[i] = event.New[any](
+			raw.Name,
+			EncodedData(raw.Data),
+			event.ID(raw.ID),
+			event.Time(raw.Time),
+			event.Aggregate(raw.Aggregate.ID, raw.Aggregate.Name, raw.AggregateVersion),
+		)
+	}
+	return out
+}
+
+// Marshal encodes the data of the given event using the provided encoding.
+// EncodedData is returned as-is.
+func Marshal(ctx context.Context, enc codec.Encoding, evt event.Event) ([]byte, error) {
+	if data, ok := evt.Data().(EncodedData); ok {
+		return data, nil
+	}
+	return codec.MarshalContext(ctx, enc, evt.Data())
+}
@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/event"
+)
+
+func TestEntry_Marshal(t *testing.T) {
+	evt := event.New[any]("foo", storage.EncodedData("data"), event.Time(time.Unix(-3, 0)), event.Aggregate(uuid.New(), "bar", 3))
+	e := storage.NewEntry(evt, []byte("data"))
+
+	b, err := e.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() failed with %q", err)
+	}
+
+	var got storage.Entry
+	if err := got.Unmarshal(b); err != nil {
+		t.Fatalf("Unmarshal() failed with %q", err)
+	}
+
+	if !cmp.Equal(e, got) {
+		t.Fatalf("Unmarshal() returned wrong entry\n%s", cmp.Diff(e, got))
+	}
+
+	if err := got.Unmarshal(b[:20]); !errors.Is(err, storage.ErrMalformedEntry) {
+		t.Fatalf("Unmarshal() should fail with %q; got %q", storage.ErrMalformedEntry, err)
+	}
+}
+
+func TestEntry_Marshal_nameTooLong(t *testing.T) {
+	long := strings.Repeat("a", math.MaxUint16+1)
+
+	for _, evt := range []event.Event{
+		event.New[any](long, storage.EncodedData(nil)),
+		event.New[any]("foo", storage.EncodedData(nil), event.Aggregate(uuid.New(), long, 1)),
+	} {
+		if _, err := storage.NewEntry(evt, nil).Marshal(); !errors.Is(err, storage.ErrNameTooLong) {
+			t.Fatalf("Marshal() should fail with %q; got %q", storage.ErrNameTooLong, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/modernice/goes/event"
+)
+
+// AppendTime appends the big-endian encoding of t with a flipped sign bit, so
+// that times before the Unix epoch are sorted before later times.
+func AppendTime(b []byte, t int64) []byte {
+	return binary.BigEndian.AppendUint64(b, uint64(t)^(1<<63))
+}
+
+// VersionOrder returns the direction of the aggregate versions if the sorting
+// of a query matches the order of the events of a single aggregate.
+func VersionOrder(sorts []event.SortOptions) (event.SortDirection, bool) {
+	if len(sorts) == 0 {
+		return event.SortAsc, true
+	}
+
+	last := sorts[len(sorts)-1]
+	if last.Sort != event.SortAggregateVersion {
+		return event.SortAsc, false
+	}
+
+	for _, opt := range sorts[:len(sorts)-1] {
+		if opt.Sort != event.SortAggregateName && opt.Sort != event.SortAggregateID {
+			return event.SortAsc, false
+		}
+	}
+
+	return last.Dir, true
+}
+
+// TimeOrder returns the direction of the event times if the sorting of a query
+// matches the order of a time index.
+func TimeOrder(sorts []event.SortOptions) (event.SortDirection, bool) {
+	switch {
+	case len(sorts) == 0:
+		return event.SortAsc, true
+	case len(sorts) == 1 && sorts[0].Sort == event.SortTime:
+		return sorts[0].Dir, true
+	default:
+		return event.SortAsc, false
+	}
+}
@@ -0,0 +1,419 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	stdtime "time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var (
+	_ event.Bus           = (*EventBus)(nil)
+	_ event.RawPublisher  = (*EventBus)(nil)
+	_ event.RawSubscriber = (*EventBus)(nil)
+)
+
+// EventBus is an event bus that publishes and subscribes to events using
+// Kafka. By default, each event is published to the topic that has the same
+// name as the event (see TopicMapping):
+//
+//	bus := kafka.NewEventBus(enc, kafka.Brokers("localhost:9092"))
+//	events, errs, err := bus.Subscribe(ctx, "order_placed", "order_canceled")
+//
+// Events are published as records with the same format as the records of a
+// Sink: the value of a record is the encoded event data, and the event
+// metadata is stored in the "goes-" headers. Records are keyed by aggregate
+// id, so the events of an aggregate keep their order. An EventBus can
+// therefore also subscribe to the topics of a Sink.
+//
+// Subscriptions without a queue group (see QueueGroup) receive all events
+// that are published after they subscribed. Records are timestamped by the
+// publisher, so the clocks of publishers and subscribers should be in sync.
+// Subscriptions with a queue group join the Kafka consumer group of that name,
+// so that each event is received by only one subscriber of the group. The
+// offset of a record is committed only after its event was received from the
+// event channel of the subscription, so a restarted subscriber continues after
+// the last received event. An event that was received but not yet handled when
+// the subscriber stopped is not redelivered.
+type EventBus struct {
+	enc        codec.Encoding
+	brokers    []string
+	clientOpts []kgo.Opt
+	producer   Producer
+	topicFunc  func(eventName string) string
+	queueFunc  func(eventName string) string
+
+	onceConnect sync.Once
+	client      *kgo.Client
+}
+
+// EventBusOption is an option for an EventBus.
+type EventBusOption func(*EventBus)
+
+// Brokers returns an EventBusOption that specifies the seed brokers of the
+// Kafka cluster. If no brokers are specified, the comma-separated list of the
+// environment variable "KAFKA_BROKERS" is used.
+func Brokers(addrs ...string) EventBusOption {
+	return func(bus *EventBus) {
+		bus.brokers = append(bus.brokers, addrs...)
+	}
+}
+
+// ClientOptions returns an EventBusOption that adds options to the Kafka
+// clients that are created by the event bus, e.g. for authentication. The
+// options are applied after the options of the event bus, so they can
+// override them. For example, subscriptions start at the time they were
+// created by default, which can be changed using kgo.ConsumeResetOffset.
+func ClientOptions(opts ...kgo.Opt) EventBusOption {
+	return func(bus *EventBus) {
+		bus.clientOpts = append(bus.clientOpts, opts...)
+	}
+}
+
+// WithProducer returns an EventBusOption that specifies the Producer that
+// publishes events, e.g. a Transactional producer. By default, the event bus
+// creates a client from its brokers and client options (see Client).
+func WithProducer(p Producer) EventBusOption {
+	return func(bus *EventBus) {
+		bus.producer = p
+	}
+}
+
+// TopicMapping returns an EventBusOption that specifies the topic of each
+// event name. Multiple events can share a topic, e.g. to publish all events
+// of an aggregate to a single topic. Subscriptions filter the records of a
+// shared topic by event name. Subscriptions to event.All receive all events
+// of the topic that fn returns for event.All.
+func TopicMapping(fn func(eventName string) string) EventBusOption {
+	return func(bus *EventBus) {
+		bus.topicFunc = fn
+	}
+}
+
+// TopicPrefix returns an EventBusOption that prefixes the topics of events
+// with the given prefix, e.g. "goes." for the topic "goes.order_placed".
+func TopicPrefix(prefix string) EventBusOption {
+	return TopicMapping(func(eventName string) string {
+		return prefix + eventName
+	})
+}
+
+// QueueGroup returns an EventBusOption that specifies the queue group of
+// subscriptions. When subscribing to an event, fn(eventName) is called to
+// determine the queue group of that event. Queue groups are mapped to Kafka
+// consumer groups: events are load-balanced between the subscribers of the
+// same group, and the offsets of the group are committed to Kafka. If the
+// returned queue group is an empty string, the event is received without a
+// consumer group.
+func QueueGroup(fn func(eventName string) string) EventBusOption {
+	return func(bus *EventBus) {
+		bus.queueFunc = fn
+	}
+}
+
+// LoadBalancer returns an EventBusOption that uses the given service name as
+// the queue group of all subscriptions, so that the replicas of a service
+// share the events (see QueueGroup).
+func LoadBalancer(serviceName string) EventBusOption {
+	return QueueGroup(func(string) string {
+		return serviceName
+	})
+}
+
+// NewEventBus returns a Kafka event bus. The provided encoding is used to
+// encode and decode event data.
+func NewEventBus(enc codec.Encoding, opts ...EventBusOption) *EventBus {
+	if enc == nil {
+		enc = event.NewRegistry()
+	}
+
+	bus := &EventBus{enc: enc}
+	for _, opt := range opts {
+		opt(bus)
+	}
+
+	if len(bus.brokers) == 0 {
+		if env := os.Getenv("KAFKA_BROKERS"); env != "" {
+			bus.brokers = strings.Split(env, ",")
+		}
+	}
+	if bus.topicFunc == nil {
+		bus.topicFunc = func(eventName string) string { return eventName }
+	}
+	if bus.queueFunc == nil {
+		bus.queueFunc = func(string) string { return "" }
+	}
+
+	return bus
+}
+
+// Connect creates the Kafka client that publishes events, unless a Producer
+// was provided using the WithProducer option. Connect doesn't need to be
+// called manually as it's called automatically by Publish.
+func (bus *EventBus) Connect(ctx context.Context) error {
+	var err error
+	bus.onceConnect.Do(func() {
+		if bus.producer != nil {
+			return
+		}
+
+		var client *kgo.Client
+		if client, err = bus.newClient(); err != nil {
+			err = fmt.Errorf("create client: %w", err)
+			return
+		}
+
+		bus.client = client
+		bus.producer = Client(client)
+	})
+	return err
+}
+
+// Close closes the Kafka client that publishes events, if it was created by
+// the event bus. Subscriptions are closed when their context is canceled.
+func (bus *EventBus) Close() {
+	if bus.client != nil {
+		bus.client.Close()
+	}
+}
+
+// Publish publishes the given events. Publish returns when all events were
+// acknowledged by Kafka.
+func (bus *EventBus) Publish(ctx context.Context, events ...event.Event) error {
+	raws := make([]event.RawEvent, len(events))
+	for i, evt := range events {
+		b, err := codec.MarshalContext(ctx, bus.enc, evt.Data())
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+
+		id, name, v := evt.Aggregate()
+		raws[i] = event.RawEvent{
+			ID:               evt.ID(),
+			Name:             evt.Name(),
+			Time:             evt.Time(),
+			Aggregate:        event.AggregateRef{Name: name, ID: id},
+			AggregateVersion: v,
+			Data:             b,
+		}
+	}
+	return bus.PublishRaw(ctx, raws...)
+}
+
+// PublishRaw publishes events whose data is already encoded. The encoded data
+// is published as-is, without using the encoder of the event bus.
+//
+// PublishRaw implements event.RawPublisher.
+func (bus *EventBus) PublishRaw(ctx context.Context, events ...event.RawEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := bus.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	records := make([]*kgo.Record, len(events))
+	for i, evt := range events {
+		records[i] = newRecord(bus.topicFunc(evt.Name), evt)
+
+		// Subscriptions start at the offsets of their subscription time, so
+		// the records are timestamped when they are produced instead of with
+		// the time of the event.
+		records[i].Timestamp = stdtime.Time{}
+	}
+
+	if err := bus.producer.Produce(ctx, records...); err != nil {
+		return fmt.Errorf("produce %d records: %w", len(records), err)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to the events with the given names. The returned
+// channels are closed when ctx is canceled. Events whose data cannot be
+// decoded are reported to the error channel and skipped.
+func (bus *EventBus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	deliver := func(raw event.RawEvent) bool {
+		evt, err := raw.DecodeContext(ctx, bus.enc)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return false
+			case errs <- err:
+				return true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- evt:
+			return true
+		}
+	}
+
+	if err := bus.subscribe(ctx, names, deliver, errs, func() { close(out) }); err != nil {
+		return nil, nil, err
+	}
+
+	return out, errs, nil
+}
+
+// SubscribeRaw subscribes to events like Subscribe does, but returns the
+// events without decoding their data.
+//
+// SubscribeRaw implements event.RawSubscriber.
+func (bus *EventBus) SubscribeRaw(ctx context.Context, names ...string) (<-chan event.RawEvent, <-chan error, error) {
+	out := make(chan event.RawEvent)
+	errs := make(chan error)
+
+	deliver := func(evt event.RawEvent) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- evt:
+			return true
+		}
+	}
+
+	if err := bus.subscribe(ctx, names, deliver, errs, func() { close(out) }); err != nil {
+		return nil, nil, err
+	}
+
+	return out, errs, nil
+}
+
+// subscribe starts consuming the events with the given names. deliver is
+// called for each event and must return false if the event could not be
+// delivered because ctx was canceled. When all consumers stopped, done is
+// called and errs is closed.
+func (bus *EventBus) subscribe(ctx context.Context, names []string, deliver func(event.RawEvent) bool, errs chan error, done func()) error {
+	// Events of the same queue group are consumed by a single client, because
+	// a client can only be a member of a single consumer group.
+	var groups []string
+	topics := make(map[string][]string)
+	for _, name := range names {
+		group, topic := bus.queueFunc(name), bus.topicFunc(name)
+		if _, ok := topics[group]; !ok {
+			groups = append(groups, group)
+		}
+		if !contains(topics[group], topic) {
+			topics[group] = append(topics[group], topic)
+		}
+	}
+
+	clients := make([]*kgo.Client, len(groups))
+	for i, group := range groups {
+		opts := []kgo.Opt{kgo.ConsumeTopics(topics[group]...)}
+		if group != "" {
+			opts = append(opts, kgo.ConsumerGroup(group), kgo.AutoCommitMarks())
+		}
+
+		client, err := bus.newClient(opts...)
+		if err != nil {
+			for _, c := range clients[:i] {
+				c.Close()
+			}
+			return fmt.Errorf("create client for queue group %q: %w", group, err)
+		}
+		clients[i] = client
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(clients))
+	for i, client := range clients {
+		go func(client *kgo.Client, grouped bool) {
+			defer wg.Done()
+			defer client.Close()
+			bus.consume(ctx, client, grouped, names, deliver, errs)
+		}(client, groups[i] != "")
+	}
+
+	go func() {
+		wg.Wait()
+		done()
+		close(errs)
+	}()
+
+	return nil
+}
+
+// consume polls the records of the given client and delivers the events with
+// the given names until ctx is canceled. The records of a consumer group are
+// marked for the next commit after their events were delivered, so a record
+// is never committed before its event was received by the subscriber.
+func (bus *EventBus) consume(ctx context.Context, client *kgo.Client, grouped bool, names []string, deliver func(event.RawEvent) bool, errs chan<- error) {
+	fail := func(err error) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case errs <- err:
+			return true
+		}
+	}
+
+	for {
+		fetches := client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+
+		var failed bool
+		fetches.EachError(func(topic string, partition int32, err error) {
+			if !failed && !fail(fmt.Errorf("fetch %s/%d: %w", topic, partition, err)) {
+				failed = true
+			}
+		})
+		if failed {
+			return
+		}
+
+		for iter := fetches.RecordIter(); !iter.Done(); {
+			rec := iter.Next()
+
+			evt, err := recordEvent(rec)
+			if err != nil {
+				if !fail(err) {
+					return
+				}
+			} else if contains(names, evt.Name) || contains(names, event.All) {
+				if !deliver(evt) {
+					return
+				}
+			}
+
+			if grouped {
+				client.MarkCommitRecords(rec)
+			}
+		}
+	}
+}
+
+func (bus *EventBus) newClient(opts ...kgo.Opt) (*kgo.Client, error) {
+	// Starting at the end of the partitions would miss events that are
+	// published before the client resolved the end offsets.
+	opts = append([]kgo.Opt{
+		kgo.SeedBrokers(bus.brokers...),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AfterMilli(stdtime.Now().UnixMilli())),
+	}, opts...)
+	return kgo.NewClient(append(opts, bus.clientOpts...)...)
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
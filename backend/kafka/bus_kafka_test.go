@@ -0,0 +1,39 @@
+//go:build kafka
+
+package kafka_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/kafka"
+	"github.com/modernice/goes/backend/testing/eventbustest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+func TestEventBus(t *testing.T) {
+	eventbustest.RunCore(t, newEventBus, eventbustest.Cleanup(cleanup))
+}
+
+func TestEventBus_sharedTopic(t *testing.T) {
+	eventbustest.RunCore(t, newSharedTopicEventBus, eventbustest.Cleanup(cleanup))
+	eventbustest.RunWildcard(t, newSharedTopicEventBus, eventbustest.Cleanup(cleanup))
+}
+
+// newEventBus returns an event bus with unique topics, so that tests don't
+// receive the events of previous tests.
+func newEventBus(enc codec.Encoding) event.Bus {
+	return kafka.NewEventBus(enc, kafka.TopicPrefix(fmt.Sprintf("goes.%s.", uuid.New())))
+}
+
+func newSharedTopicEventBus(enc codec.Encoding) event.Bus {
+	topic := fmt.Sprintf("goes.%s", uuid.New())
+	return kafka.NewEventBus(enc, kafka.TopicMapping(func(string) string { return topic }))
+}
+
+func cleanup(bus *kafka.EventBus) error {
+	bus.Close()
+	return nil
+}
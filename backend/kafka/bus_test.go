@@ -0,0 +1,67 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/kafka"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestEventBus_Publish(t *testing.T) {
+	ctx := context.Background()
+	producer := &recordingProducer{}
+	bus := kafka.NewEventBus(test.NewEncoder(), kafka.WithProducer(producer), kafka.TopicPrefix("goes."))
+
+	aggregateID := uuid.New()
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(aggregateID, "foobar", 1)).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}).Any(),
+	}
+
+	if err := bus.Publish(ctx, events...); err != nil {
+		t.Fatalf("Publish() failed with %q", err)
+	}
+
+	records := producer.records()
+	if len(records) != len(events) {
+		t.Fatalf("%d records should have been produced; got %d", len(events), len(records))
+	}
+
+	for i, rec := range records {
+		evt := events[i]
+		if want := "goes." + evt.Name(); rec.Topic != want {
+			t.Errorf("record should be produced to topic %q; got %q", want, rec.Topic)
+		}
+
+		wantKey := evt.ID()
+		if id, _, _ := evt.Aggregate(); id != uuid.Nil {
+			wantKey = id
+		}
+		if string(rec.Key) != wantKey.String() {
+			t.Errorf("record key should be %q; got %q", wantKey, rec.Key)
+		}
+
+		if got := header(rec, kafka.HeaderEventID); got != evt.ID().String() {
+			t.Errorf("%q header should be %q; got %q", kafka.HeaderEventID, evt.ID(), got)
+		}
+
+		if got := header(rec, kafka.HeaderEventName); got != evt.Name() {
+			t.Errorf("%q header should be %q; got %q", kafka.HeaderEventName, evt.Name(), got)
+		}
+	}
+}
+
+func TestEventBus_Publish_error(t *testing.T) {
+	mockError := errors.New("mock error")
+	producer := &recordingProducer{fail: func() error { return mockError }}
+	bus := kafka.NewEventBus(test.NewEncoder(), kafka.WithProducer(producer))
+
+	err := bus.Publish(context.Background(), event.New("foo", test.FooEventData{}).Any())
+	if !errors.Is(err, mockError) {
+		t.Fatalf("Publish() should fail with %q; got %q", mockError, err)
+	}
+}
@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Checkpoint is the position of a Sink within the event store. If the event
+// store assigns global positions to its events (see event.PositionQuerier),
+// the checkpoint is the position of the last forwarded event. Otherwise, it
+// consists of the time of the last forwarded event and the ids of the
+// forwarded events that have this exact time. Because multiple events can
+// have the same time, the ids are needed to resume a Sink without forwarding
+// events twice.
+type Checkpoint struct {
+	Position int64
+	Time     time.Time
+	IDs      []uuid.UUID
+}
+
+// CheckpointStore persists the checkpoints of Sinks.
+type CheckpointStore interface {
+	// Checkpoint returns the checkpoint of the given sink. If the sink has no
+	// checkpoint, the zero Checkpoint is returned.
+	Checkpoint(ctx context.Context, sink string) (Checkpoint, error)
+
+	// SaveCheckpoint saves the checkpoint of the given sink.
+	SaveCheckpoint(ctx context.Context, sink string, cp Checkpoint) error
+}
+
+// Includes returns whether the given event is already covered by the
+// checkpoint, i.e. whether the event has already been forwarded.
+func (cp Checkpoint) Includes(id uuid.UUID, t time.Time) bool {
+	if cp.Time.IsZero() || t.After(cp.Time) {
+		return false
+	}
+
+	if t.Before(cp.Time) {
+		return true
+	}
+
+	for _, cid := range cp.IDs {
+		if cid == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Advance returns the checkpoint after forwarding the event with the given id
+// and time.
+func (cp Checkpoint) Advance(id uuid.UUID, t time.Time) Checkpoint {
+	if t.Equal(cp.Time) {
+		return Checkpoint{Time: cp.Time, IDs: append(cp.IDs[:len(cp.IDs):len(cp.IDs)], id)}
+	}
+	return Checkpoint{Time: t, IDs: []uuid.UUID{id}}
+}
+
+type memoryCheckpoints struct {
+	mux         sync.RWMutex
+	checkpoints map[string]Checkpoint
+}
+
+// MemoryCheckpoints returns an in-memory CheckpointStore. Checkpoints are lost
+// when the process exits, so the in-memory store is mostly useful for testing.
+func MemoryCheckpoints() CheckpointStore {
+	return &memoryCheckpoints{checkpoints: make(map[string]Checkpoint)}
+}
+
+func (s *memoryCheckpoints) Checkpoint(_ context.Context, sink string) (Checkpoint, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.checkpoints[sink], nil
+}
+
+func (s *memoryCheckpoints) SaveCheckpoint(_ context.Context, sink string, cp Checkpoint) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.checkpoints[sink] = cp
+	return nil
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"fmt"
+	stdtime "time"
+
+	"github.com/modernice/goes/event/ingest"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// IngestMessage converts a consumed record into a message that can be
+// ingested by an ingest.Ingestor. The topic of the record is used as the
+// source of the message, and its partition and offset as the message id, so
+// that redelivered records are recognized. The message type is read from the
+// header with the given key; if typeHeader is empty or the header is missing,
+// the topic is used as the message type.
+//
+//	fetches := client.PollFetches(ctx)
+//	fetches.EachRecord(func(rec *kgo.Record) {
+//		_, err := ingestor.Ingest(ctx, kafka.IngestMessage(rec, "type"))
+//	})
+func IngestMessage(rec *kgo.Record, typeHeader string) ingest.Message {
+	msg := ingest.Message{
+		Source:   rec.Topic,
+		ID:       fmt.Sprintf("%d/%d", rec.Partition, rec.Offset),
+		Type:     rec.Topic,
+		Time:     rec.Timestamp,
+		Payload:  rec.Value,
+		Metadata: make(map[string]string, len(rec.Headers)),
+	}
+
+	for _, h := range rec.Headers {
+		msg.Metadata[h.Key] = string(h.Value)
+		if typeHeader != "" && h.Key == typeHeader {
+			msg.Type = string(h.Value)
+		}
+	}
+
+	if msg.Time.IsZero() {
+		msg.Time = stdtime.Now()
+	}
+
+	return msg
+}
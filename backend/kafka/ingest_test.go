@@ -0,0 +1,35 @@
+package kafka_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/backend/kafka"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestIngestMessage(t *testing.T) {
+	now := time.Now()
+	rec := &kgo.Record{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Timestamp: now,
+		Value:     []byte(`{"id": "order-1"}`),
+		Headers:   []kgo.RecordHeader{{Key: "type", Value: []byte("order_created")}},
+	}
+
+	msg := kafka.IngestMessage(rec, "type")
+
+	if msg.Source != "orders" || msg.ID != "2/42" || msg.Type != "order_created" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	if !msg.Time.Equal(now) || string(msg.Payload) != string(rec.Value) || msg.Metadata["type"] != "order_created" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	if msg := kafka.IngestMessage(rec, ""); msg.Type != "orders" {
+		t.Fatalf("message type should default to the topic; got %q", msg.Type)
+	}
+}
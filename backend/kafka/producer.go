@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Producer produces records to Kafka. Produce must return only after all
+// records have been acknowledged by Kafka, and must return an error if any of
+// the records could not be produced.
+type Producer interface {
+	Produce(context.Context, ...*kgo.Record) error
+}
+
+// ProducerFunc allows a function to be used as a Producer.
+type ProducerFunc func(context.Context, ...*kgo.Record) error
+
+// Produce calls fn(ctx, records...).
+func (fn ProducerFunc) Produce(ctx context.Context, records ...*kgo.Record) error {
+	return fn(ctx, records...)
+}
+
+// Client returns a Producer that produces records synchronously using the
+// provided client. franz-go clients produce idempotently by default, so
+// records that are retried internally by the client are written only once.
+func Client(client *kgo.Client) Producer {
+	return ProducerFunc(func(ctx context.Context, records ...*kgo.Record) error {
+		return client.ProduceSync(ctx, records...).FirstErr()
+	})
+}
+
+// Transactional returns a Producer that produces each batch of records within
+// a Kafka transaction. The provided client must be configured with a
+// transactional id (kgo.TransactionalID). Consumers that read with the
+// "read_committed" isolation level either see all records of a batch or none.
+func Transactional(client *kgo.Client) Producer {
+	return ProducerFunc(func(ctx context.Context, records ...*kgo.Record) error {
+		if err := client.BeginTransaction(); err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+
+		if err := client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+			abortErr := client.AbortBufferedRecords(ctx)
+			if endErr := client.EndTransaction(ctx, kgo.TryAbort); endErr != nil {
+				abortErr = errors.Join(abortErr, endErr)
+			}
+			if abortErr != nil {
+				return fmt.Errorf("produce records: %w (abort transaction: %v)", err, abortErr)
+			}
+			return fmt.Errorf("produce records: %w", err)
+		}
+
+		if err := client.EndTransaction(ctx, kgo.TryCommit); err != nil {
+			return fmt.Errorf("commit transaction: %w", err)
+		}
+
+		return nil
+	})
+}
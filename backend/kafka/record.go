@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// newRecord returns the record of the given event. Records are keyed by the
+// aggregate id of the event (or the event id for events that do not belong to
+// an aggregate), so that the events of an aggregate are written to the same
+// partition and keep their order.
+func newRecord(topic string, evt event.RawEvent) *kgo.Record {
+	key := evt.ID
+	if evt.Aggregate.ID != uuid.Nil {
+		key = evt.Aggregate.ID
+	}
+
+	return &kgo.Record{
+		Topic:     topic,
+		Key:       []byte(key.String()),
+		Value:     evt.Data,
+		Timestamp: evt.Time,
+		Headers: []kgo.RecordHeader{
+			{Key: HeaderEventID, Value: []byte(evt.ID.String())},
+			{Key: HeaderEventName, Value: []byte(evt.Name)},
+			{Key: HeaderEventTime, Value: []byte(evt.Time.Format(stdtime.RFC3339Nano))},
+			{Key: HeaderAggregateName, Value: []byte(evt.Aggregate.Name)},
+			{Key: HeaderAggregateID, Value: []byte(evt.Aggregate.ID.String())},
+			{Key: HeaderAggregateVersion, Value: []byte(strconv.Itoa(evt.AggregateVersion))},
+		},
+	}
+}
+
+// recordEvent returns the event of a record that was produced by newRecord.
+func recordEvent(rec *kgo.Record) (event.RawEvent, error) {
+	evt := event.RawEvent{Data: rec.Value, Time: rec.Timestamp}
+
+	var err error
+	for _, h := range rec.Headers {
+		v := string(h.Value)
+		switch h.Key {
+		case HeaderEventID:
+			evt.ID, err = uuid.Parse(v)
+		case HeaderEventName:
+			evt.Name = v
+		case HeaderEventTime:
+			evt.Time, err = stdtime.Parse(stdtime.RFC3339Nano, v)
+		case HeaderAggregateName:
+			evt.Aggregate.Name = v
+		case HeaderAggregateID:
+			evt.Aggregate.ID, err = uuid.Parse(v)
+		case HeaderAggregateVersion:
+			evt.AggregateVersion, err = strconv.Atoi(v)
+		}
+		if err != nil {
+			return evt, fmt.Errorf("%q header: %w", h.Key, err)
+		}
+	}
+
+	if evt.ID == uuid.Nil || evt.Name == "" {
+		return evt, fmt.Errorf("record %s/%d/%d is not a goes event", rec.Topic, rec.Partition, rec.Offset)
+	}
+
+	return evt, nil
+}
@@ -0,0 +1,377 @@
+// Package kafka provides an event bus that publishes and subscribes to events
+// using Apache Kafka, and a Sink that forwards the events of an event store to
+// Kafka, so that downstream data platforms can consume goes events.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdtime "time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Record headers that are set by a Sink.
+const (
+	HeaderEventID          = "goes-event-id"
+	HeaderEventName        = "goes-event-name"
+	HeaderEventTime        = "goes-event-time"
+	HeaderAggregateName    = "goes-aggregate-name"
+	HeaderAggregateID      = "goes-aggregate-id"
+	HeaderAggregateVersion = "goes-aggregate-version"
+)
+
+const (
+	// DefaultBatchSize is the default number of records that a Sink produces
+	// at once.
+	DefaultBatchSize = 100
+
+	// DefaultPollInterval is the default interval at which a running Sink
+	// polls the event store for new events.
+	DefaultPollInterval = stdtime.Second
+)
+
+// Sink forwards the events of an event store to Kafka. Records are keyed by
+// the aggregate id of the events (or the event id for events that do not
+// belong to an aggregate), so that the events of an aggregate are written to
+// the same partition and keep their order.
+//
+// The Sink keeps track of the last forwarded event using a Checkpoint that is
+// saved after each batch of records has been acknowledged by Kafka. If the
+// event store assigns global positions to its events (see
+// event.PositionQuerier), the Sink forwards the events in the order of their
+// positions and checkpoints the position of the last forwarded event.
+// Otherwise, the Sink forwards the events sorted by time and checkpoints the
+// time of the last forwarded event, which skips events that are inserted
+// after the checkpoint was saved but have an earlier time. Records
+// are produced by an idempotent producer, so retries within a batch never
+// write duplicates. Combined with a transactional producer (see
+// Transactional), a batch and its checkpoint form a unit: events are
+// forwarded again only if the process crashes after a batch was committed but
+// before its checkpoint was saved. Records carry the event id in the
+// "goes-event-id" header, so consumers can drop such redelivered events.
+type Sink struct {
+	name         string
+	store        event.Store
+	enc          codec.Encoding
+	producer     Producer
+	checkpoints  CheckpointStore
+	topic        func(event.RawEvent) string
+	names        []string
+	batchSize    int
+	pollInterval stdtime.Duration
+}
+
+// SinkOption is an option for a Sink.
+type SinkOption func(*Sink)
+
+// Topic returns a SinkOption that forwards all events to the given topic.
+func Topic(topic string) SinkOption {
+	return TopicFunc(func(event.RawEvent) string { return topic })
+}
+
+// TopicFunc returns a SinkOption that forwards events to the topics that are
+// returned by fn.
+func TopicFunc(fn func(event.RawEvent) string) SinkOption {
+	return func(s *Sink) {
+		s.topic = fn
+	}
+}
+
+// Events returns a SinkOption that forwards only the events with the given
+// names. By default, all events are forwarded.
+func Events(names ...string) SinkOption {
+	return func(s *Sink) {
+		s.names = append(s.names, names...)
+	}
+}
+
+// Checkpoints returns a SinkOption that configures the CheckpointStore of the
+// Sink. By default, checkpoints are kept in memory (see MemoryCheckpoints).
+func Checkpoints(store CheckpointStore) SinkOption {
+	return func(s *Sink) {
+		s.checkpoints = store
+	}
+}
+
+// BatchSize returns a SinkOption that configures the maximum number of records
+// that are produced at once. A checkpoint is saved after each batch. Default
+// is DefaultBatchSize.
+func BatchSize(size int) SinkOption {
+	return func(s *Sink) {
+		s.batchSize = size
+	}
+}
+
+// PollInterval returns a SinkOption that configures the interval at which a
+// running Sink polls the event store for new events. Default is
+// DefaultPollInterval.
+func PollInterval(d stdtime.Duration) SinkOption {
+	return func(s *Sink) {
+		s.pollInterval = d
+	}
+}
+
+// NewSink returns a Sink with the given name that forwards the events of the
+// provided store to Kafka using the given producer. The name identifies the
+// checkpoint of the Sink. If the store does not implement event.RawQuerier,
+// the provided encoding is used to encode the event data.
+//
+// If no topic is configured using the Topic or TopicFunc option, events are
+// forwarded to the topic that has the same name as the Sink.
+func NewSink(name string, store event.Store, enc codec.Encoding, producer Producer, opts ...SinkOption) *Sink {
+	s := Sink{
+		name:         name,
+		store:        store,
+		enc:          enc,
+		producer:     producer,
+		batchSize:    DefaultBatchSize,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.checkpoints == nil {
+		s.checkpoints = MemoryCheckpoints()
+	}
+	if s.topic == nil {
+		s.topic = func(event.RawEvent) string { return name }
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = DefaultBatchSize
+	}
+	return &s
+}
+
+// Sync forwards all events that have not yet been forwarded by the Sink and
+// returns the number of forwarded events. Sync returns when all events that
+// were in the store at the time of the call have been forwarded.
+func (s *Sink) Sync(ctx context.Context) (int, error) {
+	cp, err := s.checkpoints.Checkpoint(ctx, s.name)
+	if err != nil {
+		return 0, fmt.Errorf("get checkpoint: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := s.query(ctx, cp)
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	var (
+		forwarded int
+		batch     []*kgo.Record
+		next      = cp
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := s.producer.Produce(ctx, batch...); err != nil {
+			return fmt.Errorf("produce %d records: %w", len(batch), err)
+		}
+
+		if err := s.checkpoints.SaveCheckpoint(ctx, s.name, next); err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+
+		forwarded += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	if err := streams.Walk(ctx, func(evt sinkEvent) error {
+		if evt.position > 0 {
+			next = Checkpoint{Position: evt.position}
+		} else {
+			if next.Includes(evt.ID, evt.Time) {
+				return nil
+			}
+			next = next.Advance(evt.ID, evt.Time)
+		}
+
+		batch = append(batch, s.record(evt.RawEvent))
+
+		if len(batch) >= s.batchSize {
+			return flush()
+		}
+
+		return nil
+	}, events, errs); err != nil {
+		return forwarded, err
+	}
+
+	if err := flush(); err != nil {
+		return forwarded, err
+	}
+
+	return forwarded, nil
+}
+
+// Run runs the Sink in the background until ctx is canceled. The Sink
+// forwards new events immediately and then polls the event store for new
+// events at the configured interval. Errors that occur while forwarding
+// events are sent to the returned channel, which is closed when ctx is
+// canceled. An error channel must be drained by the caller.
+func (s *Sink) Run(ctx context.Context) (<-chan error, error) {
+	if s.pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid poll interval: %v", s.pollInterval)
+	}
+
+	out := make(chan error)
+
+	go func() {
+		defer close(out)
+
+		ticker := stdtime.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Sync(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- err:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// sinkEvent is an event that is forwarded by a Sink. position is the global
+// position of the event, or 0 if the store does not assign positions.
+type sinkEvent struct {
+	event.RawEvent
+
+	position int64
+}
+
+func (s *Sink) query(ctx context.Context, cp Checkpoint) (<-chan sinkEvent, <-chan error, error) {
+	var opts []query.Option
+	if len(s.names) > 0 {
+		opts = append(opts, query.Name(s.names...))
+	}
+
+	if pq, ok := s.store.(event.PositionQuerier); ok {
+		events, errs, err := pq.QueryAfter(ctx, cp.Position, query.New(opts...))
+		if err == nil {
+			return convert(ctx, events, errs, func(evt event.Positioned) (sinkEvent, error) {
+				raw, err := event.Raw(s.enc, evt.Event)
+				return sinkEvent{RawEvent: raw, position: evt.Position}, err
+			})
+		}
+
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil, err
+		}
+	}
+
+	opts = append(opts, query.SortByTime())
+	if !cp.Time.IsZero() {
+		// Events that have the same time as the checkpoint may not have been
+		// forwarded yet, so we include them and filter them using the ids of
+		// the checkpoint.
+		opts = append(opts, query.Time(time.After(cp.Time.Add(-stdtime.Nanosecond))))
+	}
+	q := query.New(opts...)
+
+	if raw, ok := s.store.(event.RawQuerier); ok {
+		events, errs, err := raw.QueryRaw(ctx, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		return convert(ctx, events, errs, func(evt event.RawEvent) (sinkEvent, error) {
+			return sinkEvent{RawEvent: evt}, nil
+		})
+	}
+
+	events, errs, err := s.store.Query(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return convert(ctx, events, errs, func(evt event.Event) (sinkEvent, error) {
+		raw, err := event.Raw(s.enc, evt)
+		return sinkEvent{RawEvent: raw}, err
+	})
+}
+
+// convert converts the events of a query into sinkEvents. Conversion errors
+// are sent to the returned error channel.
+func convert[E any](ctx context.Context, events <-chan E, errs <-chan error, fn func(E) (sinkEvent, error)) (<-chan sinkEvent, <-chan error, error) {
+	out := make(chan sinkEvent)
+	outErrs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(outErrs)
+
+		fail := func(err error) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case outErrs <- err:
+				return true
+			}
+		}
+
+		for events != nil || errs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					break
+				}
+				if !fail(err) {
+					return
+				}
+			case evt, ok := <-events:
+				if !ok {
+					events = nil
+					break
+				}
+
+				converted, err := fn(evt)
+				if err != nil {
+					if !fail(err) {
+						return
+					}
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- converted:
+				}
+			}
+		}
+	}()
+
+	return out, outErrs, nil
+}
+
+func (s *Sink) record(evt event.RawEvent) *kgo.Record {
+	return newRecord(s.topic(evt), evt)
+}
@@ -0,0 +1,255 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/kafka"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/test"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestSink_Sync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	aggregateID := uuid.New()
+	now := time.Now()
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}, event.Time(now), event.Aggregate(aggregateID, "foobar", 1)).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Time(now.Add(time.Millisecond)), event.Aggregate(aggregateID, "foobar", 2)).Any(),
+		event.New("baz", test.BazEventData{A: "baz"}, event.Time(now.Add(time.Second))).Any(),
+	}
+
+	store := eventstore.New(events...)
+	producer := &recordingProducer{}
+	sink := kafka.NewSink("sink", store, test.NewEncoder(), producer, kafka.Topic("events"), kafka.BatchSize(2))
+
+	n, err := sink.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if n != len(events) {
+		t.Fatalf("Sync should forward %d events; forwarded %d", len(events), n)
+	}
+
+	if len(producer.batches) != 2 {
+		t.Fatalf("%d batches should have been produced; got %d", 2, len(producer.batches))
+	}
+
+	records := producer.records()
+	for i, rec := range records {
+		evt := events[i]
+		if rec.Topic != "events" {
+			t.Errorf("record should be produced to topic %q; got %q", "events", rec.Topic)
+		}
+
+		wantKey := evt.ID()
+		if id, _, _ := evt.Aggregate(); id != uuid.Nil {
+			wantKey = id
+		}
+		if string(rec.Key) != wantKey.String() {
+			t.Errorf("record key should be %q; got %q", wantKey, rec.Key)
+		}
+
+		if header(rec, kafka.HeaderEventID) != evt.ID().String() {
+			t.Errorf("%q header should be %q; got %q", kafka.HeaderEventID, evt.ID(), header(rec, kafka.HeaderEventID))
+		}
+
+		if header(rec, kafka.HeaderEventName) != evt.Name() {
+			t.Errorf("%q header should be %q; got %q", kafka.HeaderEventName, evt.Name(), header(rec, kafka.HeaderEventName))
+		}
+
+		if !strings.Contains(string(rec.Value), `"A":"`+evt.Name()+`"`) {
+			t.Errorf("record value should contain the encoded event data; got %s", rec.Value)
+		}
+	}
+
+	n, err = sink.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if n != 0 {
+		t.Fatalf("Sync should not forward events twice; forwarded %d events", n)
+	}
+
+	// The new event has the same time as the last forwarded event.
+	evt := event.New("foo", test.FooEventData{A: "foo"}, event.Time(now.Add(time.Second))).Any()
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	n, err = sink.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("Sync should forward %d event; forwarded %d", 1, n)
+	}
+
+	if got := header(producer.records()[len(events)], kafka.HeaderEventID); got != evt.ID().String() {
+		t.Fatalf("new event should be forwarded; got record for event %q", got)
+	}
+}
+
+func TestSink_Sync_produceError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Time(time.Now().Add(time.Second))).Any(),
+	}
+
+	store := eventstore.New(events...)
+	checkpoints := kafka.MemoryCheckpoints()
+	mockError := errors.New("mock error")
+
+	var fail bool
+	producer := &recordingProducer{fail: func() error {
+		if fail {
+			return mockError
+		}
+		return nil
+	}}
+
+	sink := kafka.NewSink("sink", store, test.NewEncoder(), producer, kafka.Checkpoints(checkpoints), kafka.BatchSize(1))
+
+	fail = true
+	if _, err := sink.Sync(ctx); !errors.Is(err, mockError) {
+		t.Fatalf("Sync should fail with %q; got %q", mockError, err)
+	}
+
+	cp, err := checkpoints.Checkpoint(ctx, "sink")
+	if err != nil {
+		t.Fatalf("get checkpoint: %v", err)
+	}
+
+	if cp.Position != 0 {
+		t.Fatalf("checkpoint should not be saved if producing fails; got %v", cp)
+	}
+
+	fail = false
+	n, err := sink.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if n != len(events) {
+		t.Fatalf("Sync should forward %d events; forwarded %d", len(events), n)
+	}
+
+	cp, err = checkpoints.Checkpoint(ctx, "sink")
+	if err != nil {
+		t.Fatalf("get checkpoint: %v", err)
+	}
+
+	if cp.Position != 2 {
+		t.Fatalf("checkpoint should point to the position of the last forwarded event; got %v", cp)
+	}
+}
+
+func TestSink_Sync_lateEvent(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	store := eventstore.New(event.New("foo", test.FooEventData{A: "foo"}, event.Time(now)).Any())
+	producer := &recordingProducer{}
+	sink := kafka.NewSink("sink", store, test.NewEncoder(), producer)
+
+	if _, err := sink.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	// inserted after the first Sync, but with an earlier time
+	late := event.New("bar", test.BarEventData{A: "bar"}, event.Time(now.Add(-time.Hour))).Any()
+	if err := store.Insert(ctx, late); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	n, err := sink.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("Sync should forward %d event; forwarded %d", 1, n)
+	}
+
+	if got := header(producer.records()[1], kafka.HeaderEventID); got != late.ID().String() {
+		t.Fatalf("late event should be forwarded; got record for event %q", got)
+	}
+}
+
+func TestSink_Sync_timeCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Time(time.Now().Add(time.Second))).Any(),
+	}
+
+	// the embedded store hides the positions of the in-memory store
+	store := struct{ event.Store }{eventstore.New(events...)}
+	checkpoints := kafka.MemoryCheckpoints()
+	producer := &recordingProducer{}
+	sink := kafka.NewSink("sink", store, test.NewEncoder(), producer, kafka.Checkpoints(checkpoints))
+
+	if n, err := sink.Sync(ctx); err != nil || n != len(events) {
+		t.Fatalf("Sync should forward %d events; forwarded %d (err=%v)", len(events), n, err)
+	}
+
+	cp, err := checkpoints.Checkpoint(ctx, "sink")
+	if err != nil {
+		t.Fatalf("get checkpoint: %v", err)
+	}
+
+	if !cp.Time.Equal(events[1].Time()) || len(cp.IDs) != 1 || cp.IDs[0] != events[1].ID() {
+		t.Fatalf("checkpoint should point to the last forwarded event; got %v", cp)
+	}
+
+	if n, err := sink.Sync(ctx); err != nil || n != 0 {
+		t.Fatalf("Sync should not forward events twice; forwarded %d (err=%v)", n, err)
+	}
+}
+
+type recordingProducer struct {
+	batches [][]*kgo.Record
+	fail    func() error
+}
+
+func (p *recordingProducer) Produce(_ context.Context, records ...*kgo.Record) error {
+	if p.fail != nil {
+		if err := p.fail(); err != nil {
+			return err
+		}
+	}
+	p.batches = append(p.batches, append([]*kgo.Record(nil), records...))
+	return nil
+}
+
+func (p *recordingProducer) records() []*kgo.Record {
+	var out []*kgo.Record
+	for _, batch := range p.batches {
+		out = append(out, batch...)
+	}
+	return out
+}
+
+func header(rec *kgo.Record, key string) string {
+	for _, h := range rec.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
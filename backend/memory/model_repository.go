@@ -10,7 +10,10 @@ import (
 	"github.com/modernice/goes/persistence/model"
 )
 
-var _ model.Repository[model.Model[uuid.UUID], uuid.UUID] = (*ModelRepository[model.Model[uuid.UUID], uuid.UUID])(nil)
+var (
+	_ model.Repository[model.Model[uuid.UUID], uuid.UUID]     = (*ModelRepository[model.Model[uuid.UUID], uuid.UUID])(nil)
+	_ model.VersionedSaver[model.Model[uuid.UUID], uuid.UUID] = (*ModelRepository[model.Model[uuid.UUID], uuid.UUID])(nil)
+)
 
 // ModelRepository is thread-safe in-memory model repository. Useful for testing.
 type ModelRepository[Model model.Model[ID], ID model.ID] struct {
@@ -73,6 +76,30 @@ func (r *ModelRepository[Model, ID]) Save(ctx context.Context, m Model) error {
 	return nil
 }
 
+// SaveVersioned saves the model to the repository if the repository does not
+// already contain the model with the same or a newer version. Otherwise, an
+// error that unwraps to model.ErrStale is returned. The model must implement
+// model.Versioned.
+func (r *ModelRepository[Model, ID]) SaveVersioned(ctx context.Context, m Model) error {
+	v, ok := any(m).(model.Versioned)
+	if !ok {
+		return fmt.Errorf("%T does not implement model.Versioned", m)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if stored, ok := r.models[m.ModelID()]; ok {
+		if sv, ok := any(stored).(model.Versioned); ok && sv.ModelVersion() >= v.ModelVersion() {
+			return fmt.Errorf("%w: stored version %d, saved version %d", model.ErrStale, sv.ModelVersion(), v.ModelVersion())
+		}
+	}
+
+	r.save(m)
+
+	return nil
+}
+
 func (r *ModelRepository[Model, ID]) save(m Model) {
 	r.models[m.ModelID()] = m
 }
@@ -130,6 +130,49 @@ func TestModelRepository_Fetch_ModelFactory(t *testing.T) {
 	}
 }
 
+func TestModelRepository_SaveVersioned(t *testing.T) {
+	ctx := context.Background()
+	r := memory.NewModelRepository[*versionedModel, uuid.UUID]()
+
+	id := uuid.New()
+	if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: 2, Foo: "v2"}); err != nil {
+		t.Fatalf("SaveVersioned() failed with %q", err)
+	}
+
+	for _, v := range []int{1, 2} {
+		if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: v, Foo: "stale"}); !errors.Is(err, model.ErrStale) {
+			t.Fatalf("SaveVersioned() should fail with %q for version %d; got %v", model.ErrStale, v, err)
+		}
+	}
+
+	if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: 3, Foo: "v3"}); err != nil {
+		t.Fatalf("SaveVersioned() failed with %q", err)
+	}
+
+	m, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch() failed with %q", err)
+	}
+
+	if m.Foo != "v3" {
+		t.Fatalf(`"Foo" field should be %q; is %q`, "v3", m.Foo)
+	}
+}
+
+type versionedModel struct {
+	ID      uuid.UUID
+	Version int
+	Foo     string
+}
+
+func (m versionedModel) ModelID() uuid.UUID {
+	return m.ID
+}
+
+func (m versionedModel) ModelVersion() int {
+	return m.Version
+}
+
 type uuidModel struct {
 	ID  uuid.UUID `bson:"customid"`
 	Foo string
@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespaceExists is the error code of MongoDB for collections that already
+// exist.
+const namespaceExists = 48
+
+// Collation returns an EventStoreOption that applies the given collation to
+// the event collection. The collation is used by all queries, sortings and
+// bulk operations of the store on the event collection, so that filtering by
+// event and aggregate names behaves as configured, e.g. case-insensitive:
+//
+//	store := mongo.NewEventStore(enc, mongo.Collation(&options.Collation{
+//		Locale:   "en",
+//		Strength: 2, // case-insensitive
+//	}))
+//
+// If the event collection does not exist yet, it is created with the
+// collation as its default collation, so that the indexes of the store are
+// created with the same collation. MongoDB only uses an index for string
+// comparisons if the index has the same collation as the operation, and the
+// collation of an existing collection cannot be changed. For existing
+// collections, the indexes on the "name" and "aggregateName" fields must be
+// rebuilt with the collation to be used by queries.
+func Collation(c *options.Collation) EventStoreOption {
+	return func(s *EventStore) {
+		s.collation = c
+	}
+}
+
+// createEntriesCollection creates the event collection with the configured
+// collation if it does not exist.
+func (s *EventStore) createEntriesCollection(ctx context.Context) error {
+	if s.collation == nil {
+		return nil
+	}
+
+	err := s.db.CreateCollection(ctx, s.entriesCol, options.CreateCollection().SetCollation(s.collation))
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == namespaceExists {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("create %q collection: %w", s.entriesCol, err)
+	}
+
+	return nil
+}
+
+func (s *EventStore) changeStreamOptions() *options.ChangeStreamOptions {
+	opts := options.ChangeStream()
+	if s.collation != nil {
+		opts.SetCollation(*s.collation)
+	}
+	return opts
+}
@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// DefaultCompactionCollection is the default name of the collection that
+// stores the compaction markers of aggregates (see EventStore.Compact).
+const DefaultCompactionCollection = "compactions"
+
+// ErrCompactBeyondVersion is returned by Compact if the version to compact
+// through is higher than the current version of the aggregate.
+var ErrCompactBeyondVersion = errors.New("cannot compact beyond the current version of the aggregate")
+
+// CompactionCollection returns an EventStoreOption that specifies the name of
+// the collection that stores the compaction markers of aggregates. Defaults to
+// DefaultCompactionCollection.
+func CompactionCollection(name string) EventStoreOption {
+	return func(s *EventStore) {
+		s.compactionsCol = name
+	}
+}
+
+// Compaction is the marker of a compacted aggregate event stream.
+type Compaction struct {
+	// Aggregate is the compacted aggregate.
+	Aggregate aggregate.Ref
+
+	// Version is the version through which the events of the aggregate were
+	// deleted.
+	Version int
+
+	// Time is the time of the last compaction.
+	Time stdtime.Time
+}
+
+type compactionID struct {
+	AggregateName string    `bson:"aggregateName"`
+	AggregateID   uuid.UUID `bson:"aggregateId"`
+}
+
+type compaction struct {
+	ID      compactionID `bson:"_id"`
+	Version int          `bson:"version"`
+	Time    stdtime.Time `bson:"time"`
+}
+
+// Compact deletes the events of the given aggregate up to and including the
+// given version, and returns the number of deleted events. Use Compact to
+// limit the history of long-lived aggregates whose state is covered by a
+// snapshot of at least the given version:
+//
+//	snap, err := snapshots.Latest(ctx, "foo", id)
+//	// handle err
+//	n, err := store.Compact(ctx, aggregate.Ref{Name: "foo", ID: id}, snap.AggregateVersion())
+//
+// Compact records a compaction marker for the aggregate (see Compaction),
+// which tells readers that the events up to the marker version no longer
+// exist. If transactions are enabled, the events are deleted and the marker is
+// recorded in a single transaction. Compacting an aggregate through a version
+// that is not higher than its current marker is a no-op. The aggregate state
+// is kept, so new events of the aggregate continue with the next version.
+// Compacted events are removed permanently, even if soft deletes are enabled,
+// and their offloaded data is deleted after the events.
+func (s *EventStore) Compact(ctx context.Context, ref aggregate.Ref, throughVersion int) (int64, error) {
+	if s.isTransactionStore {
+		return s.root.Compact(ctx, ref, throughVersion)
+	}
+
+	if throughVersion <= 0 {
+		return 0, nil
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	var deleted int64
+	var offloaded []string
+	if err := s.retry(ctx, func() (err error) {
+		deleted, offloaded, err = s.compact(ctx, ref, throughVersion)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := s.deleteBlobs(ctx, offloaded); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+func (s *EventStore) compact(ctx context.Context, ref aggregate.Ref, throughVersion int) (int64, []string, error) {
+	tx, err := s.createTransaction(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Session().EndSession(ctx)
+
+	sessionCtx := mongo.NewSessionContext(ctx, tx.Session())
+
+	if s.transactions {
+		if err := sessionCtx.StartTransaction(s.transactionOptions()); err != nil {
+			return 0, nil, fmt.Errorf("start transaction: %w", err)
+		}
+	}
+
+	var st state
+	if err := s.states.FindOne(sessionCtx, bson.D{
+		{Key: "aggregateName", Value: ref.Name},
+		{Key: "aggregateId", Value: ref.ID},
+	}).Decode(&st); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil, s.abortTransaction(sessionCtx, fmt.Errorf("fetch aggregate state: %w", err))
+	}
+
+	if throughVersion > st.Version {
+		return 0, nil, s.abortTransaction(sessionCtx, fmt.Errorf("%w: compact %s(%s) through version %d, current version is %d", ErrCompactBeyondVersion, ref.Name, ref.ID, throughVersion, st.Version))
+	}
+
+	id := compactionID{AggregateName: ref.Name, AggregateID: ref.ID}
+
+	res, err := s.compactions.UpdateOne(sessionCtx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "version", Value: bson.D{{Key: "$lt", Value: throughVersion}}},
+	}, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "version", Value: throughVersion},
+			{Key: "time", Value: xtime.Now()},
+		}},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// The aggregate is already compacted through the version.
+			return 0, nil, s.abortTransaction(sessionCtx, nil)
+		}
+		return 0, nil, s.abortTransaction(sessionCtx, fmt.Errorf("record compaction: %w", err))
+	}
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		return 0, nil, s.abortTransaction(sessionCtx, nil)
+	}
+
+	filter := bson.D{
+		{Key: "aggregateName", Value: ref.Name},
+		{Key: "aggregateId", Value: ref.ID},
+		{Key: "aggregateVersion", Value: bson.D{{Key: "$lte", Value: throughVersion}}},
+	}
+
+	offloaded, err := s.offloadedEvents(sessionCtx, filter)
+	if err != nil {
+		return 0, nil, s.abortTransaction(sessionCtx, fmt.Errorf("find offloaded events: %w", err))
+	}
+
+	deleted, err := s.entries.DeleteMany(sessionCtx, filter)
+	if err != nil {
+		return 0, nil, s.abortTransaction(sessionCtx, fmt.Errorf("delete events: %w", err))
+	}
+
+	if s.transactions {
+		if err := s.commitTransaction(sessionCtx); err != nil {
+			return 0, nil, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+
+	return deleted.DeletedCount, offloaded, nil
+}
+
+// Compaction returns the compaction marker of the given aggregate. If the
+// aggregate was never compacted, the returned Compaction has a version of 0.
+func (s *EventStore) Compaction(ctx context.Context, ref aggregate.Ref) (Compaction, error) {
+	if s.isTransactionStore {
+		return s.root.Compaction(ctx, ref)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return Compaction{}, fmt.Errorf("connect: %w", err)
+	}
+
+	out := Compaction{Aggregate: ref}
+
+	var doc compaction
+	if err := s.compactions.FindOne(ctx, bson.D{
+		{Key: "_id", Value: compactionID{AggregateName: ref.Name, AggregateID: ref.ID}},
+	}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return out, nil
+		}
+		return out, fmt.Errorf("fetch compaction: %w", err)
+	}
+
+	out.Version = doc.Version
+	out.Time = doc.Time
+
+	return out, nil
+}
@@ -0,0 +1,76 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_Compact(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	ref := aggregate.Ref{Name: "foo", ID: uuid.New()}
+	var events []event.Event
+	for v := 1; v <= 5; v++ {
+		events = append(events, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(ref.ID, ref.Name, v)))
+	}
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if _, err := store.Compact(ctx, ref, 6); !errors.Is(err, mongo.ErrCompactBeyondVersion) {
+		t.Fatalf("Compact() should fail with %q; got %v", mongo.ErrCompactBeyondVersion, err)
+	}
+
+	n, err := store.Compact(ctx, ref, 3)
+	if err != nil {
+		t.Fatalf("Compact() failed with %q", err)
+	}
+	if n != 3 {
+		t.Fatalf("Compact() should delete %d events; deleted %d", 3, n)
+	}
+
+	if n := queryCount(t, store, query.New(query.Aggregate(ref.Name, ref.ID))); n != 2 {
+		t.Fatalf("Query() should return %d events after compaction; got %d", 2, n)
+	}
+
+	c, err := store.Compaction(ctx, ref)
+	if err != nil {
+		t.Fatalf("Compaction() failed with %q", err)
+	}
+	if c.Version != 3 || c.Time.IsZero() {
+		t.Fatalf("unexpected compaction marker: %+v", c)
+	}
+
+	if n, err := store.Compact(ctx, ref, 2); err != nil || n != 0 {
+		t.Fatalf("Compact() through an older version should be a no-op; deleted %d (%v)", n, err)
+	}
+
+	if c, _ := store.Compaction(ctx, ref); c.Version != 3 {
+		t.Fatalf("compaction marker should remain at version %d; got %d", 3, c.Version)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(ref.ID, ref.Name, 6))); err != nil {
+		t.Fatalf("Insert() should continue with the next version after compaction; failed with %q", err)
+	}
+
+	if c, err := store.Compaction(ctx, aggregate.Ref{Name: "foo", ID: uuid.New()}); err != nil || c.Version != 0 {
+		t.Fatalf("Compaction() should return version 0 for an uncompacted aggregate; got %d (%v)", c.Version, err)
+	}
+}
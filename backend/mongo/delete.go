@@ -0,0 +1,176 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+)
+
+// ErrPagedDelete is returned by DeleteQuery if the query has a limit or an
+// offset, which cannot be applied to a bulk delete.
+var ErrPagedDelete = errors.New("limit and offset are not supported by bulk deletes")
+
+// DeleteQuery deletes the events that are matched by the given query using a
+// single bulk delete, and returns the number of deleted events. Use
+// DeleteQuery instead of Delete for retention jobs and aggregate purges, so
+// that the events do not need to be queried and deleted one by one:
+//
+//	// delete all events of an aggregate
+//	n, err := store.DeleteQuery(ctx, query.New(query.Aggregate("foo", id)))
+//
+//	// delete all "foo" events that are older than 30 days
+//	n, err := store.DeleteQuery(ctx, query.New(
+//		query.Name("foo"),
+//		query.Time(time.Max(stdtime.Now().AddDate(0, 0, -30))),
+//	))
+//
+// The sortings of the query are ignored. If the query has a limit or an
+// offset, DeleteQuery returns ErrPagedDelete. A query without filters deletes
+// all events.
+//
+// After the events are deleted, the states of the affected aggregates are
+// updated to the highest remaining version of their event streams, and the
+// states of aggregates without remaining events are removed, so that purged
+// aggregates can be recreated. The bulk delete itself is not part of a
+// transaction.
+//
+// DeleteQuery always removes the events permanently, even if soft deletes
+// are enabled (see SoftDelete). Soft-deleted events are only removed if the
+// query uses the query.IncludeDeleted option.
+func (s *EventStore) DeleteQuery(ctx context.Context, q event.Query) (int64, error) {
+	if s.isTransactionStore {
+		return s.root.DeleteQuery(ctx, q)
+	}
+
+	if limit, offset := event.Paging(q); limit > 0 || offset > 0 {
+		return 0, ErrPagedDelete
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	filter := s.filter(q)
+
+	var aggregates []stateKey
+	if err := s.retry(ctx, func() (err error) {
+		aggregates, err = s.matchedAggregates(ctx, filter)
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("find affected aggregates: %w", err)
+	}
+
+	var offloaded []string
+	if err := s.retry(ctx, func() (err error) {
+		offloaded, err = s.offloadedEvents(ctx, filter)
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("find offloaded events: %w", err)
+	}
+
+	var res *mongo.DeleteResult
+	if err := s.retry(ctx, func() (err error) {
+		res, err = s.entries.DeleteMany(ctx, filter, options.Delete().SetCollation(s.collation))
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("mongo: %w", err)
+	}
+
+	if err := s.deleteBlobs(ctx, offloaded); err != nil {
+		return res.DeletedCount, err
+	}
+
+	for _, key := range aggregates {
+		if err := s.repairState(ctx, StateRepair{AggregateName: key.name, AggregateID: key.id}); err != nil {
+			return res.DeletedCount, fmt.Errorf("update state of %s(%s): %w", key.name, key.id, err)
+		}
+	}
+
+	return res.DeletedCount, nil
+}
+
+// matchedAggregates returns the aggregates that have events that are matched
+// by the given filter.
+func (s *EventStore) matchedAggregates(ctx context.Context, filter bson.D) ([]stateKey, error) {
+	match := bson.D{{Key: "$and", Value: bson.A{
+		bson.D{
+			{Key: "aggregateName", Value: bson.D{{Key: "$ne", Value: ""}}},
+			{Key: "aggregateId", Value: bson.D{{Key: "$ne", Value: uuid.Nil}}},
+		},
+		filter,
+	}}}
+
+	cur, err := s.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "aggregateName", Value: "$aggregateName"},
+				{Key: "aggregateId", Value: "$aggregateId"},
+			}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true).SetCollation(s.collation))
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var out []stateKey
+	for cur.Next(ctx) {
+		var doc struct {
+			ID struct {
+				AggregateName string    `bson:"aggregateName"`
+				AggregateID   uuid.UUID `bson:"aggregateId"`
+			} `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode document: %w", err)
+		}
+		out = append(out, stateKey{name: doc.ID.AggregateName, id: doc.ID.AggregateID})
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("cursor: %w", err)
+	}
+
+	return out, nil
+}
+
+// offloadedEvents returns the blob keys of the events that are matched by the
+// given filter and whose data is offloaded (see Offload).
+func (s *EventStore) offloadedEvents(ctx context.Context, filter bson.D) ([]string, error) {
+	if !s.offloading() {
+		return nil, nil
+	}
+
+	cur, err := s.entries.Find(
+		ctx,
+		append(bson.D{{Key: "offloaded", Value: true}}, filter...),
+		options.Find().SetProjection(bson.D{{Key: "id", Value: 1}, {Key: "blob", Value: 1}}).SetCollation(s.collation),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var keys []string
+	for cur.Next(ctx) {
+		var e entry
+		if err := cur.Decode(&e); err != nil {
+			return nil, fmt.Errorf("decode document: %w", err)
+		}
+		keys = append(keys, e.blobKey())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("cursor: %w", err)
+	}
+
+	return keys, nil
+}
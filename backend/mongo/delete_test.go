@@ -0,0 +1,88 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore_DeleteQuery(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	purged := uuid.New()
+	kept := uuid.New()
+
+	if err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(purged, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(purged, "foo", 2)),
+	); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(kept, "foo", 1)),
+		event.New[any]("bar", etest.BarEventData{}, event.Aggregate(kept, "foo", 2)),
+	); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if _, err := store.DeleteQuery(ctx, query.New(query.Limit(1))); !errors.Is(err, mongo.ErrPagedDelete) {
+		t.Fatalf("DeleteQuery() should fail with %q; got %v", mongo.ErrPagedDelete, err)
+	}
+
+	n, err := store.DeleteQuery(ctx, query.New(query.Aggregate("foo", purged)))
+	if err != nil {
+		t.Fatalf("DeleteQuery() failed with %q", err)
+	}
+	if n != 2 {
+		t.Fatalf("DeleteQuery() should delete %d events; deleted %d", 2, n)
+	}
+
+	// the purged aggregate can be recreated
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(purged, "foo", 1))); err != nil {
+		t.Fatalf("Insert() should succeed for a purged aggregate; failed with %q", err)
+	}
+
+	n, err = store.DeleteQuery(ctx, query.New(query.Name("bar")))
+	if err != nil {
+		t.Fatalf("DeleteQuery() failed with %q", err)
+	}
+	if n != 1 {
+		t.Fatalf("DeleteQuery() should delete %d event; deleted %d", 1, n)
+	}
+
+	// the state of the aggregate is reset to its remaining events
+	if err := store.Insert(ctx, event.New[any]("bar", etest.BarEventData{}, event.Aggregate(kept, "foo", 2))); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New())
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("store should contain %d events; got %d", 3, len(events))
+	}
+}
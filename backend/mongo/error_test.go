@@ -1,10 +1,14 @@
 package mongo_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/backend/mongo"
+	gomongo "go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestVersionError_IsInconsistencyError(t *testing.T) {
@@ -18,3 +22,28 @@ func TestVersionError_IsInconsistencyError(t *testing.T) {
 		t.Fatalf("aggregate.IsConsistencyError() should return %v for a mongo.CommandError; got %v", true, got)
 	}
 }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"other error", errors.New("foo"), false},
+		{"canceled", context.Canceled, false},
+		{"version error", mongo.VersionError{}, false},
+		{"not writable primary", gomongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}, true},
+		{"wrapped election error", fmt.Errorf("insert: %w", gomongo.CommandError{Code: 11602}), true},
+		{"retryable write", gomongo.CommandError{Code: 1, Labels: []string{"RetryableWriteError"}}, true},
+		{"duplicate key", gomongo.CommandError{Code: 11000}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mongo.IsTransient(tt.err); got != tt.want {
+				t.Fatalf("IsTransient(%v) should return %v; got %v", tt.err, tt.want, got)
+			}
+		})
+	}
+}
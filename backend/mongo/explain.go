@@ -0,0 +1,168 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OpQueryAfter is the operation of QueryAfter. It is reported only to
+// Diagnostics, not to Metrics.
+const OpQueryAfter = "query_after"
+
+// QueryDiagnostic describes the query plan that MongoDB chose for a query of
+// the EventStore (see Diagnostics).
+type QueryDiagnostic struct {
+	// Operation is the name of the operation that ran the query, e.g. OpQuery.
+	Operation string
+
+	// Filter is the filter that the store generated for the query.
+	Filter bson.D
+
+	// Sort is the sort order of the query, if any.
+	Sort bson.D
+
+	// Stages are the stages of the winning query plan, e.g. "FETCH" and
+	// "IXSCAN", from the outermost to the innermost stage.
+	Stages []string
+
+	// Indexes are the names of the indexes that are used by the winning plan.
+	Indexes []string
+
+	// GoesIndex reports whether the winning plan uses at least one of the
+	// indexes that are created by the store, i.e. the core indexes and the
+	// indexes of the WithIndices option.
+	GoesIndex bool
+
+	// CollectionScan reports whether the winning plan scans the whole
+	// collection.
+	CollectionScan bool
+
+	// Plan is the winning plan as returned by MongoDB.
+	Plan bson.Raw
+
+	// Err is the error of the explain command, if any. The query itself
+	// runs regardless of this error.
+	Err error
+}
+
+// Diagnostics returns an EventStoreOption that explains the queries of the
+// store and reports the chosen query plans to fn. Use Diagnostics to find
+// queries that are not covered by the indexes of the store, e.g. queries that
+// filter by aggregate versions, which are translated into $or filters:
+//
+//	store := mongo.NewEventStore(enc, mongo.Diagnostics(func(d mongo.QueryDiagnostic) {
+//		if d.CollectionScan {
+//			log.Printf("collection scan for %s: %v", d.Operation, d.Filter)
+//		}
+//	}))
+//
+// The explain command runs before the query of Query, QueryRaw and
+// QueryAfter, which adds a roundtrip to every query, so Diagnostics should
+// only be enabled while debugging. fn is called synchronously and must be
+// safe for concurrent use.
+func Diagnostics(fn func(QueryDiagnostic)) EventStoreOption {
+	return func(s *EventStore) {
+		s.diagnostics = fn
+	}
+}
+
+// explain runs the explain command for a find command with the given filter
+// and options and reports the result to the diagnostics callback.
+func (s *EventStore) explain(ctx context.Context, op string, filter bson.D, opts *options.FindOptions) {
+	if s.diagnostics == nil {
+		return
+	}
+
+	d := QueryDiagnostic{Operation: op, Filter: filter}
+	if sort, ok := opts.Sort.(bson.D); ok && len(sort) > 0 {
+		d.Sort = sort
+	}
+
+	find := bson.D{
+		{Key: "find", Value: s.entries.Name()},
+		{Key: "filter", Value: filter},
+	}
+	if d.Sort != nil {
+		find = append(find, bson.E{Key: "sort", Value: d.Sort})
+	}
+	if opts.Skip != nil {
+		find = append(find, bson.E{Key: "skip", Value: *opts.Skip})
+	}
+	if opts.Limit != nil {
+		find = append(find, bson.E{Key: "limit", Value: *opts.Limit})
+	}
+	if opts.Collation != nil {
+		find = append(find, bson.E{Key: "collation", Value: opts.Collation.ToDocument()})
+	}
+
+	res, err := s.db.RunCommand(ctx, bson.D{
+		{Key: "explain", Value: find},
+		{Key: "verbosity", Value: "queryPlanner"},
+	}).DecodeBytes()
+	if err != nil {
+		d.Err = fmt.Errorf("explain: %w", err)
+		s.diagnostics(d)
+		return
+	}
+
+	plan, err := res.LookupErr("queryPlanner", "winningPlan")
+	if err != nil {
+		d.Err = fmt.Errorf("explain: missing winning plan: %w", err)
+		s.diagnostics(d)
+		return
+	}
+
+	if doc, ok := plan.DocumentOK(); ok {
+		d.Plan = doc
+	}
+
+	events, _ := s.indexModels()
+	goesIndexes := indexNames(events)
+
+	walkPlan(plan, func(stage, index string) {
+		if stage != "" {
+			d.Stages = append(d.Stages, stage)
+			if stage == "COLLSCAN" {
+				d.CollectionScan = true
+			}
+		}
+		if index != "" && !contains(d.Indexes, index) {
+			d.Indexes = append(d.Indexes, index)
+			if contains(goesIndexes, index) {
+				d.GoesIndex = true
+			}
+		}
+	})
+
+	s.diagnostics(d)
+}
+
+// walkPlan calls fn for every stage of the given query plan. The plans of
+// sharded clusters and of the slot-based execution engine nest their stages
+// in other fields than "inputStage", so walkPlan looks into every embedded
+// document and array.
+func walkPlan(val bson.RawValue, fn func(stage, index string)) {
+	switch val.Type {
+	case bsontype.EmbeddedDocument:
+		doc := val.Document()
+		stage, _ := doc.Lookup("stage").StringValueOK()
+		index, _ := doc.Lookup("indexName").StringValueOK()
+		if stage != "" || index != "" {
+			fn(stage, index)
+		}
+
+		elems, _ := doc.Elements()
+		for _, elem := range elems {
+			walkPlan(elem.Value(), fn)
+		}
+	case bsontype.Array:
+		vals, _ := val.Array().Values()
+		for _, v := range vals {
+			walkPlan(v, fn)
+		}
+	}
+}
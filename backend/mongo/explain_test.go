@@ -0,0 +1,66 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestDiagnostics(t *testing.T) {
+	ctx := context.Background()
+
+	var mux sync.Mutex
+	var diagnostics []mongo.QueryDiagnostic
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Diagnostics(func(d mongo.QueryDiagnostic) {
+			mux.Lock()
+			defer mux.Unlock()
+			diagnostics = append(diagnostics, d)
+		}),
+	)
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"})); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	queryCount(t, store, query.New(query.Name("foo"), query.SortByTime()))
+	queryCount(t, store, query.New())
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if len(diagnostics) != 2 {
+		t.Fatalf("Diagnostics should be reported for %d queries; got %d", 2, len(diagnostics))
+	}
+
+	indexed := diagnostics[0]
+	if indexed.Err != nil {
+		t.Fatalf("explain failed with %q", indexed.Err)
+	}
+	if indexed.Operation != mongo.OpQuery {
+		t.Fatalf("Operation should be %q; got %q", mongo.OpQuery, indexed.Operation)
+	}
+	if !indexed.GoesIndex || indexed.CollectionScan {
+		t.Fatalf("query by name and time should use a goes index; got stages %v and indexes %v", indexed.Stages, indexed.Indexes)
+	}
+
+	scan := diagnostics[1]
+	if scan.Err != nil {
+		t.Fatalf("explain failed with %q", scan.Err)
+	}
+	if !scan.CollectionScan || scan.GoesIndex {
+		t.Fatalf("empty query should scan the collection; got stages %v and indexes %v", scan.Stages, scan.Indexes)
+	}
+}
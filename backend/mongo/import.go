@@ -0,0 +1,650 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// DefaultImportCollection is the default name of the collection that keeps
+// track of the imports of the event store (see EventStore.Import).
+const DefaultImportCollection = "imports"
+
+// importBatchSize is the number of imported events that are updated by a
+// single write when an import is committed.
+const importBatchSize = 1000
+
+var (
+	// ErrImportNotFound is returned by ResumeImport if the import does not
+	// exist.
+	ErrImportNotFound = errors.New("import not found")
+
+	// ErrImportClosed is returned by the methods of an Import that was already
+	// committed or aborted.
+	ErrImportClosed = errors.New("import is already committed or aborted")
+
+	// ErrInvalidImport is returned by Import.Commit if the verification of the
+	// imported events fails.
+	ErrInvalidImport = errors.New("invalid import")
+)
+
+// ImportStatus is the status of an Import.
+type ImportStatus string
+
+const (
+	// ImportPending is the status of an import whose events are not visible
+	// yet.
+	ImportPending ImportStatus = "pending"
+
+	// ImportPublishing is the status of an import that was verified, but
+	// whose events are not all visible yet. Commit continues to publish the
+	// events of such an import.
+	ImportPublishing ImportStatus = "publishing"
+
+	// ImportCommitted is the status of a committed import.
+	ImportCommitted ImportStatus = "committed"
+
+	// ImportAborted is the status of an aborted import.
+	ImportAborted ImportStatus = "aborted"
+)
+
+// ImportCollection returns an EventStoreOption that specifies the name of the
+// collection that keeps track of the imports of the event store. Defaults to
+// DefaultImportCollection.
+func ImportCollection(name string) EventStoreOption {
+	return func(s *EventStore) {
+		s.importsCol = name
+	}
+}
+
+// Import is a batch of events that is inserted into the event store, but only
+// becomes visible to queries when the import is committed. Use an Import to
+// migrate the history of a legacy system without exposing half-imported
+// aggregates to readers (see EventStore.Import).
+type Import struct {
+	store *EventStore
+	id    uuid.UUID
+}
+
+type importDoc struct {
+	ID          uuid.UUID    `bson:"_id"`
+	Status      ImportStatus `bson:"status"`
+	Events      int64        `bson:"events"`
+	Position    int64        `bson:"position,omitempty"`
+	CreatedAt   stdtime.Time `bson:"createdAt"`
+	CommittedAt stdtime.Time `bson:"committedAt,omitempty"`
+}
+
+// Import starts a new import of historical events:
+//
+//	imp, err := store.Import(ctx)
+//	// handle err
+//	for batch := range legacyEvents {
+//		if err := imp.Insert(ctx, batch...); err != nil {
+//			imp.Abort(ctx)
+//			// handle err
+//		}
+//	}
+//	if err := imp.Commit(ctx); err != nil {
+//		// handle err
+//	}
+//
+// The events of an import are stored in the event collection, but they are
+// excluded from Find, Query, QueryRaw, QueryAfter, Stats and Subscribe until
+// the import is committed. Commit verifies the imported events and makes them
+// visible in batches, so readers may observe a partially imported aggregate
+// while Commit runs. A Commit that fails after the verification leaves the
+// import partially visible; call Commit again to publish the remaining events.
+//
+// Imports are stored in the import collection (see ImportCollection), so an
+// import that was interrupted can be continued using ResumeImport.
+func (s *EventStore) Import(ctx context.Context) (*Import, error) {
+	if s.isTransactionStore {
+		return s.root.Import(ctx)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	doc := importDoc{
+		ID:        uuid.New(),
+		Status:    ImportPending,
+		CreatedAt: xtime.Now(),
+	}
+
+	if _, err := s.imports.InsertOne(ctx, doc); err != nil {
+		return nil, fmt.Errorf("create import: %w", err)
+	}
+
+	return &Import{store: s, id: doc.ID}, nil
+}
+
+// ResumeImport returns the pending import with the given id. ResumeImport
+// returns ErrImportNotFound if the import does not exist, and ErrImportClosed
+// if it was already committed or aborted, or if its events are being
+// published (see Import.Commit).
+func (s *EventStore) ResumeImport(ctx context.Context, id uuid.UUID) (*Import, error) {
+	if s.isTransactionStore {
+		return s.root.ResumeImport(ctx, id)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	imp := &Import{store: s, id: id}
+
+	doc, err := imp.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Status != ImportPending {
+		return nil, fmt.Errorf("%w: import %s is %s", ErrImportClosed, id, doc.Status)
+	}
+
+	return imp, nil
+}
+
+// ID returns the id of the import.
+func (imp *Import) ID() uuid.UUID {
+	return imp.id
+}
+
+// Status returns the status of the import.
+func (imp *Import) Status(ctx context.Context) (ImportStatus, error) {
+	doc, err := imp.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	return doc.Status, nil
+}
+
+// Insert inserts the given events into the import. The events are not
+// visible until the import is committed, and the states of their aggregates
+// are not updated before the commit. The versions of the events are verified
+// by Commit. Transaction hooks are not called for imported events.
+//
+// If Insert fails, some of the events may have been inserted nevertheless,
+// which makes Commit fail. Abort the import in that case.
+func (imp *Import) Insert(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s := imp.store
+
+	doc, err := imp.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if doc.Status != ImportPending {
+		return fmt.Errorf("%w: import %s is %s", ErrImportClosed, imp.id, doc.Status)
+	}
+
+	size := s.insertBatchSize
+	if size <= 0 {
+		size = len(events)
+	}
+
+	for len(events) > 0 {
+		n := min(size, len(events))
+
+		var blobs []string
+		docs := make([]any, n)
+		for i, evt := range events[:n] {
+			e, err := s.newEntry(ctx, evt)
+			if err != nil {
+				return s.discardFailedInsert(ctx, blobs, err)
+			}
+			if e.Blob != "" {
+				blobs = append(blobs, e.Blob)
+			}
+			e.ImportID = &imp.id
+			docs[i] = e
+		}
+
+		if _, err := s.entries.InsertMany(ctx, docs); err != nil {
+			return s.discardFailedInsert(ctx, blobs, fmt.Errorf("mongo: %w", err))
+		}
+
+		if _, err := s.imports.UpdateOne(ctx, bson.D{{Key: "_id", Value: imp.id}}, bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "events", Value: int64(n)}}},
+		}); err != nil {
+			return fmt.Errorf("update import: %w", err)
+		}
+
+		events = events[n:]
+	}
+
+	return nil
+}
+
+// Commit verifies the imported events and makes them visible. Commit verifies
+// that all inserted events are stored, and that the imported events of each
+// aggregate have consecutive versions that continue the current version of
+// the aggregate. If the verification fails, Commit returns an error that
+// wraps ErrInvalidImport, and the import stays pending, so it can be aborted.
+//
+// After the verification, Commit updates the states of the imported
+// aggregates and sets the status of the import to ImportPublishing. If
+// transactions are enabled (see Transactions), this happens in a single
+// transaction. The imported events are then made visible in batches of
+// bounded size, in the order of their time, so that no single write has to
+// update all events of a large import. If Commit fails while the events are
+// published, the import stays in the ImportPublishing status with some of its
+// events visible, and it can no longer be aborted. Calling Commit again
+// publishes the remaining events.
+//
+// If global positions are enabled (see GlobalPositions), Commit assigns the
+// positions of the imported events in the order of their time, so that
+// consumers that checkpoint their position receive the imported events after
+// the events they already consumed.
+func (imp *Import) Commit(ctx context.Context) error {
+	s := imp.store
+
+	var doc importDoc
+	if err := s.retry(ctx, func() error {
+		return s.retryTransaction(ctx, func() (err error) {
+			doc, err = imp.prepare(ctx)
+			return err
+		})
+	}); err != nil {
+		return err
+	}
+
+	if err := imp.publish(ctx, doc.Position); err != nil {
+		return err
+	}
+
+	if _, err := s.imports.UpdateOne(ctx, bson.D{{Key: "_id", Value: imp.id}}, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: ImportCommitted},
+			{Key: "committedAt", Value: xtime.Now()},
+		}},
+	}); err != nil {
+		return fmt.Errorf("update import: %w", err)
+	}
+
+	return nil
+}
+
+// prepare verifies a pending import, updates the states of its aggregates,
+// reserves the positions of its events and sets its status to
+// ImportPublishing. An import that is already publishing is returned as-is.
+func (imp *Import) prepare(ctx context.Context) (importDoc, error) {
+	s := imp.store
+
+	tx, err := s.createTransaction(ctx)
+	if err != nil {
+		return importDoc{}, err
+	}
+	defer tx.Session().EndSession(ctx)
+
+	sessionCtx := mongo.NewSessionContext(ctx, tx.Session())
+
+	if s.transactions {
+		if err := sessionCtx.StartTransaction(s.transactionOptions()); err != nil {
+			return importDoc{}, fmt.Errorf("start transaction: %w", err)
+		}
+	}
+
+	doc, err := imp.fetch(sessionCtx)
+	if err != nil {
+		return doc, s.abortTransaction(sessionCtx, err)
+	}
+	if doc.Status == ImportPublishing {
+		return doc, s.abortTransaction(sessionCtx, nil)
+	}
+	if doc.Status != ImportPending {
+		return doc, s.abortTransaction(sessionCtx, fmt.Errorf("%w: import %s is %s", ErrImportClosed, imp.id, doc.Status))
+	}
+
+	filter := bson.D{{Key: "importId", Value: imp.id}}
+
+	count, err := s.entries.CountDocuments(sessionCtx, filter)
+	if err != nil {
+		return doc, s.abortTransaction(sessionCtx, fmt.Errorf("count imported events: %w", err))
+	}
+	if count != doc.Events {
+		return doc, s.abortTransaction(sessionCtx, fmt.Errorf("%w: %d events were inserted, but %d are stored", ErrInvalidImport, doc.Events, count))
+	}
+
+	ranges, err := imp.versionRanges(sessionCtx, filter)
+	if err != nil {
+		return doc, s.abortTransaction(sessionCtx, err)
+	}
+
+	for _, r := range ranges {
+		if err := imp.updateState(sessionCtx, r); err != nil {
+			return doc, s.abortTransaction(sessionCtx, err)
+		}
+	}
+
+	position, err := s.reservePositions(sessionCtx, int(count))
+	if err != nil {
+		return doc, s.abortTransaction(sessionCtx, err)
+	}
+
+	res, err := s.imports.UpdateOne(sessionCtx, bson.D{
+		{Key: "_id", Value: imp.id},
+		{Key: "status", Value: ImportPending},
+	}, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: ImportPublishing},
+			{Key: "position", Value: position},
+		}},
+	})
+	if err != nil {
+		return doc, s.abortTransaction(sessionCtx, fmt.Errorf("update import: %w", err))
+	}
+	if res.ModifiedCount == 0 {
+		return doc, s.abortTransaction(sessionCtx, fmt.Errorf("%w: import %s was closed concurrently", ErrImportClosed, imp.id))
+	}
+
+	if s.transactions {
+		if err := s.commitTransaction(sessionCtx); err != nil {
+			return doc, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+
+	doc.Status = ImportPublishing
+	doc.Position = position
+
+	return doc, nil
+}
+
+// versionRange is the range of versions of the imported events of an
+// aggregate.
+type versionRange struct {
+	ID struct {
+		Name string    `bson:"name"`
+		ID   uuid.UUID `bson:"id"`
+	} `bson:"_id"`
+	Min   int `bson:"min"`
+	Max   int `bson:"max"`
+	Count int `bson:"count"`
+}
+
+func (r versionRange) ref() aggregate.Ref {
+	return aggregate.Ref{Name: r.ID.Name, ID: r.ID.ID}
+}
+
+// versionRanges returns the version ranges of the imported aggregates and
+// verifies that the versions of each aggregate are consecutive and continue
+// the current version of the aggregate.
+func (imp *Import) versionRanges(ctx context.Context, filter bson.D) ([]versionRange, error) {
+	s := imp.store
+
+	cur, err := s.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: append(filter,
+			bson.E{Key: "aggregateName", Value: bson.D{{Key: "$gt", Value: ""}}},
+			bson.E{Key: "aggregateVersion", Value: bson.D{{Key: "$gt", Value: 0}}},
+		)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "name", Value: "$aggregateName"},
+				{Key: "id", Value: "$aggregateId"},
+			}},
+			{Key: "min", Value: bson.D{{Key: "$min", Value: "$aggregateVersion"}}},
+			{Key: "max", Value: bson.D{{Key: "$max", Value: "$aggregateVersion"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true).SetCollation(s.collation))
+	if err != nil {
+		return nil, fmt.Errorf("group imported events: %w", err)
+	}
+
+	var ranges []versionRange
+	if err := cur.All(ctx, &ranges); err != nil {
+		return nil, fmt.Errorf("group imported events: %w", err)
+	}
+
+	for _, r := range ranges {
+		ref := r.ref()
+
+		if r.Count != r.Max-r.Min+1 {
+			return nil, fmt.Errorf("%w: versions %d to %d of %s(%s) are not consecutive", ErrInvalidImport, r.Min, r.Max, ref.Name, ref.ID)
+		}
+
+		var st state
+		if err := s.states.FindOne(ctx, bson.D{
+			{Key: "aggregateName", Value: ref.Name},
+			{Key: "aggregateId", Value: ref.ID},
+		}).Decode(&st); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("fetch state of %s(%s): %w", ref.Name, ref.ID, err)
+		}
+
+		if st.Version != r.Min-1 {
+			return nil, fmt.Errorf("%w: imported events of %s(%s) start at version %d, but the current version is %d", ErrInvalidImport, ref.Name, ref.ID, r.Min, st.Version)
+		}
+	}
+
+	return ranges, nil
+}
+
+// updateState sets the version of an imported aggregate to the highest
+// imported version, unless the aggregate was modified since the verification.
+func (imp *Import) updateState(ctx context.Context, r versionRange) error {
+	s := imp.store
+	ref := r.ref()
+
+	st := state{AggregateName: ref.Name, AggregageID: ref.ID, Version: r.Max}
+
+	res, err := s.states.ReplaceOne(ctx, bson.D{
+		{Key: "aggregateName", Value: ref.Name},
+		{Key: "aggregateId", Value: ref.ID},
+		{Key: "version", Value: r.Min - 1},
+	}, st, options.Replace().SetUpsert(r.Min == 1))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %s(%s) was modified concurrently", ErrInvalidImport, ref.Name, ref.ID)
+		}
+		return fmt.Errorf("update state of %s(%s): %w", ref.Name, ref.ID, err)
+	}
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		return fmt.Errorf("%w: %s(%s) was modified concurrently", ErrInvalidImport, ref.Name, ref.ID)
+	}
+
+	return nil
+}
+
+// publish makes the imported events visible in batches. If global positions
+// are enabled, the events are first assigned the positions that were reserved
+// for the import, starting at position. The positions are derived from the
+// order of the events, so that publish can be retried after a failure.
+func (imp *Import) publish(ctx context.Context, position int64) error {
+	s := imp.store
+	filter := bson.D{{Key: "importId", Value: imp.id}}
+
+	if position > 0 {
+		if err := imp.assignPositions(ctx, filter, position); err != nil {
+			return err
+		}
+	}
+
+	for {
+		ids, err := imp.nextBatch(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if _, err := s.entries.UpdateMany(ctx, bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+		}, bson.D{
+			{Key: "$unset", Value: bson.D{{Key: "importId", Value: ""}}},
+		}); err != nil {
+			return fmt.Errorf("publish imported events: %w", err)
+		}
+	}
+}
+
+// assignPositions assigns the reserved positions to the imported events that
+// are not visible yet. The events of a retried publish that were already
+// published keep their positions, because events are published in the order
+// of their positions.
+func (imp *Import) assignPositions(ctx context.Context, filter bson.D, position int64) error {
+	s := imp.store
+
+	doc, err := imp.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	remaining, err := s.entries.CountDocuments(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count imported events: %w", err)
+	}
+	position += doc.Events - remaining
+
+	cur, err := s.entries.Find(ctx, filter, options.Find().
+		SetProjection(bson.D{{Key: "_id", Value: 1}}).
+		SetSort(importSort).
+		SetAllowDiskUse(true).
+		SetCollation(s.collation))
+	if err != nil {
+		return fmt.Errorf("find imported events: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	models := make([]mongo.WriteModel, 0, importBatchSize)
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		if _, err := s.entries.BulkWrite(ctx, models); err != nil {
+			return fmt.Errorf("assign positions: %w", err)
+		}
+		models = models[:0]
+		return nil
+	}
+
+	for cur.Next(ctx) {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: cur.Current.Lookup("_id")}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "position", Value: position}}}}))
+		position++
+
+		if len(models) >= importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("find imported events: %w", err)
+	}
+
+	return flush()
+}
+
+// nextBatch returns the ids of the next imported events that are published.
+func (imp *Import) nextBatch(ctx context.Context, filter bson.D) ([]any, error) {
+	s := imp.store
+
+	cur, err := s.entries.Find(ctx, filter, options.Find().
+		SetProjection(bson.D{{Key: "_id", Value: 1}}).
+		SetSort(importSort).
+		SetLimit(importBatchSize).
+		SetAllowDiskUse(true).
+		SetCollation(s.collation))
+	if err != nil {
+		return nil, fmt.Errorf("find imported events: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var ids []any
+	for cur.Next(ctx) {
+		ids = append(ids, cur.Current.Lookup("_id"))
+	}
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("find imported events: %w", err)
+	}
+
+	return ids, nil
+}
+
+// importSort is the order in which imported events are assigned positions and
+// published.
+var importSort = bson.D{
+	{Key: "timeNano", Value: 1},
+	{Key: "aggregateName", Value: 1},
+	{Key: "aggregateId", Value: 1},
+	{Key: "aggregateVersion", Value: 1},
+	{Key: "_id", Value: 1},
+}
+
+// Abort marks the import as aborted and deletes its inserted events,
+// including their offloaded data. Abort can be called again for an aborted
+// import if the deletion failed. Imports that are committed or whose events
+// are being published cannot be aborted.
+func (imp *Import) Abort(ctx context.Context) error {
+	s := imp.store
+
+	doc, err := imp.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if doc.Status == ImportCommitted || doc.Status == ImportPublishing {
+		return fmt.Errorf("%w: import %s is %s", ErrImportClosed, imp.id, doc.Status)
+	}
+
+	if doc.Status == ImportPending {
+		res, err := s.imports.UpdateOne(ctx, bson.D{
+			{Key: "_id", Value: imp.id},
+			{Key: "status", Value: ImportPending},
+		}, bson.D{
+			{Key: "$set", Value: bson.D{{Key: "status", Value: ImportAborted}}},
+		})
+		if err != nil {
+			return fmt.Errorf("update import: %w", err)
+		}
+		if res.ModifiedCount == 0 {
+			return fmt.Errorf("%w: import %s was committed concurrently", ErrImportClosed, imp.id)
+		}
+	}
+
+	filter := bson.D{{Key: "importId", Value: imp.id}}
+
+	offloaded, err := s.offloadedEvents(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("find offloaded events: %w", err)
+	}
+
+	if _, err := s.entries.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("delete imported events: %w", err)
+	}
+
+	return s.deleteBlobs(ctx, offloaded)
+}
+
+func (imp *Import) fetch(ctx context.Context) (importDoc, error) {
+	var doc importDoc
+	if err := imp.store.imports.FindOne(ctx, bson.D{{Key: "_id", Value: imp.id}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return doc, fmt.Errorf("%w: %s", ErrImportNotFound, imp.id)
+		}
+		return doc, fmt.Errorf("fetch import: %w", err)
+	}
+	return doc, nil
+}
+
+// excludeImported adds the exclusion of the events of pending imports to the
+// given filter.
+func excludeImported(filter bson.D) bson.D {
+	return append(filter, bson.E{Key: "importId", Value: bson.D{{Key: "$exists", Value: false}}})
+}
@@ -0,0 +1,187 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_Import(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.GlobalPositions(true),
+	)
+
+	live := event.New[any]("foo", etest.FooEventData{A: "live"}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(ctx, live); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	imp, err := store.Import(ctx)
+	if err != nil {
+		t.Fatalf("Import() failed with %q", err)
+	}
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 3)),
+	}
+
+	if err := imp.Insert(ctx, events[:2]...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id))); n != 0 {
+		t.Fatalf("Query() should not return events of a pending import; got %d", n)
+	}
+
+	if _, err := store.Find(ctx, events[0].ID()); err == nil {
+		t.Fatalf("Find() should not find an event of a pending import")
+	}
+
+	resumed, err := store.ResumeImport(ctx, imp.ID())
+	if err != nil {
+		t.Fatalf("ResumeImport() failed with %q", err)
+	}
+
+	if err := resumed.Insert(ctx, events[2]); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := imp.Commit(ctx); err != nil {
+		t.Fatalf("Commit() failed with %q", err)
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id))); n != 3 {
+		t.Fatalf("Query() should return %d events of a committed import; got %d", 3, n)
+	}
+
+	positioned, errs, err := store.QueryAfter(ctx, 1, query.New())
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+	var n int
+	for range positioned {
+		n++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+	if n != 3 {
+		t.Fatalf("QueryAfter() should return the %d imported events after the live event; got %d", 3, n)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 3)).Any()); err == nil {
+		t.Fatalf("Insert() should fail for a version of an imported aggregate")
+	}
+
+	if err := imp.Commit(ctx); !errors.Is(err, mongo.ErrImportClosed) {
+		t.Fatalf("Commit() should fail with %q for a committed import; got %q", mongo.ErrImportClosed, err)
+	}
+}
+
+func TestEventStore_Import_invalid(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	imp, err := store.Import(ctx)
+	if err != nil {
+		t.Fatalf("Import() failed with %q", err)
+	}
+
+	id := uuid.New()
+	if err := imp.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 3)),
+	); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := imp.Commit(ctx); !errors.Is(err, mongo.ErrInvalidImport) {
+		t.Fatalf("Commit() should fail with %q for a version gap; got %q", mongo.ErrInvalidImport, err)
+	}
+
+	if err := imp.Abort(ctx); err != nil {
+		t.Fatalf("Abort() failed with %q", err)
+	}
+
+	if status, err := imp.Status(ctx); err != nil || status != mongo.ImportAborted {
+		t.Fatalf("Status() should return %q; got %q (%v)", mongo.ImportAborted, status, err)
+	}
+
+	if n, err := store.Collection().CountDocuments(ctx, bson.D{{Key: "aggregateId", Value: id}}); err != nil || n != 0 {
+		t.Fatalf("Abort() should delete the imported events; %d events remain (%v)", n, err)
+	}
+}
+
+func TestEventStore_Import_batches(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.GlobalPositions(true),
+	)
+
+	imp, err := store.Import(ctx)
+	if err != nil {
+		t.Fatalf("Import() failed with %q", err)
+	}
+
+	id := uuid.New()
+	events := make([]event.Event, 2500)
+	for i := range events {
+		events[i] = event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", i+1)).Any()
+	}
+
+	if err := imp.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := imp.Commit(ctx); err != nil {
+		t.Fatalf("Commit() failed with %q", err)
+	}
+
+	if status, err := imp.Status(ctx); err != nil || status != mongo.ImportCommitted {
+		t.Fatalf("Status() should return %q; got %q (%v)", mongo.ImportCommitted, status, err)
+	}
+
+	positioned, errs, err := store.QueryAfter(ctx, 0, query.New())
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	var n int
+	for evt := range positioned {
+		if _, _, v := evt.Aggregate(); v != n+1 || evt.Position != int64(n+1) {
+			t.Fatalf("event %d should have version and position %d; got version %d and position %d", n, n+1, v, evt.Position)
+		}
+		n++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+	if n != len(events) {
+		t.Fatalf("QueryAfter() should return %d events; got %d", len(events), n)
+	}
+}
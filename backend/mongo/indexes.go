@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/backend/mongo/indices"
+)
+
+// IndexVersion is the version of the builtin indexes of the event store. It is
+// incremented whenever the builtin index models change, so that existing
+// stores create the new indexes on their next connect.
+const IndexVersion = 4
+
+// DefaultMigrationCollection is the default name of the collection that keeps
+// track of the index migrations of the event store.
+const DefaultMigrationCollection = "migrations"
+
+// Indexes returns an EventStoreOption that replaces the core indexes of the
+// event collection (indices.EventStoreCore) with the given index models. Use
+// WithIndices to create indexes in addition to the core indexes.
+//
+// The core indexes enforce the uniqueness of event ids and aggregate versions.
+// If they are replaced, the provided models should contain equivalent unique
+// indexes, or version validation will not be safe for concurrent inserts.
+func Indexes(models ...mongo.IndexModel) EventStoreOption {
+	return func(s *EventStore) {
+		s.coreIndices = models
+		s.customCoreIndices = true
+	}
+}
+
+// MigrationCollection returns an EventStoreOption that specifies the name of
+// the collection that keeps track of the index migrations of the event store.
+// Defaults to DefaultMigrationCollection.
+func MigrationCollection(name string) EventStoreOption {
+	return func(s *EventStore) {
+		s.migrationsCol = name
+	}
+}
+
+// indexMigration is the document that records the indexes that were created
+// for a collection.
+type indexMigration struct {
+	Collection string       `bson:"_id"`
+	Version    int          `bson:"version"`
+	Indexes    []string     `bson:"indexes"`
+	MigratedAt stdtime.Time `bson:"migratedAt"`
+}
+
+// MigrateIndexes creates the indexes of the event store. Indexes are migrated
+// when the store connects, unless the NoIndex option is used, in which case
+// MigrateIndexes can be called explicitly, e.g. from a deployment job.
+//
+// The indexes that were created are recorded in the migration collection
+// together with IndexVersion. If the recorded migration matches the
+// configured indexes, MigrateIndexes returns without listing or creating any
+// indexes, which keeps connecting to large stores cheap. Pass force to create
+// missing indexes regardless of the recorded migration.
+func (s *EventStore) MigrateIndexes(ctx context.Context, force bool) error {
+	if s.isTransactionStore {
+		return s.root.MigrateIndexes(ctx, force)
+	}
+	if _, err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	return s.migrateIndexes(ctx, force)
+}
+
+func (s *EventStore) migrateIndexes(ctx context.Context, force bool) error {
+	events, states := s.indexModels()
+
+	migrations := s.db.Collection(s.migrationsCol)
+
+	for _, m := range []struct {
+		col    *mongo.Collection
+		models []mongo.IndexModel
+	}{
+		{col: s.entries, models: events},
+		{col: s.states, models: states},
+	} {
+		if len(m.models) == 0 {
+			continue
+		}
+
+		names := indexNames(m.models)
+
+		if !force {
+			var prev indexMigration
+			err := migrations.FindOne(ctx, bson.D{{Key: "_id", Value: m.col.Name()}}).Decode(&prev)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return fmt.Errorf("find %q index migration: %w", m.col.Name(), err)
+			}
+			if err == nil && prev.Version >= IndexVersion && sameNames(prev.Indexes, names) {
+				continue
+			}
+		}
+
+		if err := createIndexes(ctx, m.col, m.models); err != nil {
+			return fmt.Errorf("%q collection: %w", m.col.Name(), err)
+		}
+
+		if _, err := migrations.ReplaceOne(ctx, bson.D{{Key: "_id", Value: m.col.Name()}}, indexMigration{
+			Collection: m.col.Name(),
+			Version:    IndexVersion,
+			Indexes:    names,
+			MigratedAt: stdtime.Now(),
+		}, options.Replace().SetUpsert(true)); err != nil {
+			return fmt.Errorf("save %q index migration: %w", m.col.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// indexModels returns the index models of the event and state collections.
+func (s *EventStore) indexModels() (events, states []mongo.IndexModel) {
+	core := indices.EventStoreCore()
+	if s.customCoreIndices {
+		core = s.coreIndices
+	}
+
+	events = append(append([]mongo.IndexModel{}, core...), s.additionalIndices...)
+	events = append(events, s.retentionIndexes()...)
+	events = append(events, s.positionIndexes()...)
+
+	if s.sharded {
+		var sharded []mongo.IndexModel
+		sharded, states = s.shardedIndexes()
+		events = append(withoutIndexes(events, "goes_id", "goes_aname_aid_aversion"), sharded...)
+	}
+
+	return events, states
+}
+
+// indexNames returns the sorted names of the given index models. Models
+// without a name are not created by the store and are therefore skipped.
+func indexNames(models []mongo.IndexModel) []string {
+	names := make([]string, 0, len(models))
+	for _, model := range models {
+		if model.Options != nil && model.Options.Name != nil {
+			names = append(names, *model.Options.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/backend/mongo/indices"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_MigrateIndexes(t *testing.T) {
+	ctx := context.Background()
+	dbname := nextEventDatabase()
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(dbname),
+		mongo.NoIndex(true),
+	)
+
+	if _, err := store.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed with %q", err)
+	}
+
+	if names := listIndexes(t, store.Collection()); names["goes_id"] {
+		t.Fatalf("NoIndex option should disable index creation on connect")
+	}
+
+	if err := store.MigrateIndexes(ctx, false); err != nil {
+		t.Fatalf("MigrateIndexes() failed with %q", err)
+	}
+
+	names := listIndexes(t, store.Collection())
+	for _, model := range indices.EventStoreCore() {
+		if model.Options == nil {
+			continue
+		}
+		if !names[*model.Options.Name] {
+			t.Errorf("%q index should have been created", *model.Options.Name)
+		}
+	}
+
+	var migration bson.M
+	if err := store.Database().Collection(mongo.DefaultMigrationCollection).FindOne(ctx, bson.D{{Key: "_id", Value: "events"}}).Decode(&migration); err != nil {
+		t.Fatalf("index migration should have been recorded: %v", err)
+	}
+
+	if migration["version"] != int32(mongo.IndexVersion) {
+		t.Errorf("recorded version should be %d; got %v", mongo.IndexVersion, migration["version"])
+	}
+
+	if _, err := store.Collection().Indexes().DropOne(ctx, "goes_name_time"); err != nil {
+		t.Fatalf("failed to drop index: %v", err)
+	}
+
+	if err := store.MigrateIndexes(ctx, false); err != nil {
+		t.Fatalf("MigrateIndexes() failed with %q", err)
+	}
+
+	if listIndexes(t, store.Collection())["goes_name_time"] {
+		t.Fatalf("MigrateIndexes() should not create indexes if the recorded migration is up-to-date")
+	}
+
+	if err := store.MigrateIndexes(ctx, true); err != nil {
+		t.Fatalf("MigrateIndexes() failed with %q", err)
+	}
+
+	if !listIndexes(t, store.Collection())["goes_name_time"] {
+		t.Fatalf("MigrateIndexes() should create missing indexes if forced")
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	ctx := context.Background()
+
+	custom := mongodb.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetName("custom_name"),
+	}
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Indexes(indices.EventStore.ID, custom),
+	)
+
+	if _, err := store.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed with %q", err)
+	}
+
+	names := listIndexes(t, store.Collection())
+	if !names["goes_id"] || !names["custom_name"] {
+		t.Fatalf("provided indexes should have been created; got %v", names)
+	}
+
+	if names["goes_name_time"] {
+		t.Fatalf("core indexes should have been replaced by the provided indexes")
+	}
+}
+
+func listIndexes(t *testing.T, col *mongodb.Collection) map[string]bool {
+	cur, err := col.Indexes().List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list indexes: %v", err)
+	}
+
+	var specs []struct{ Name string }
+	if err := cur.All(context.Background(), &specs); err != nil {
+		t.Fatalf("failed to decode indexes: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+	return names
+}
@@ -24,6 +24,14 @@ var EventStore = EventStoreIndices{
 		Options: options.Index().SetName("goes_name_time"),
 	},
 
+	TimeAndID: mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "timeNano", Value: 1},
+			{Key: "id", Value: 1},
+		},
+		Options: options.Index().SetName("goes_time_id"),
+	},
+
 	AggregateNameAndVersion: mongo.IndexModel{
 		Keys: bson.D{
 			{Key: "aggregateName", Value: 1},
@@ -64,6 +72,16 @@ var EventStore = EventStoreIndices{
 		},
 		Options: options.Index().SetName("goes_name_aversion"),
 	},
+
+	Tags: mongo.IndexModel{
+		Keys:    bson.D{{Key: "tags", Value: 1}},
+		Options: options.Index().SetName("goes_tags").SetSparse(true),
+	},
+
+	Import: mongo.IndexModel{
+		Keys:    bson.D{{Key: "importId", Value: 1}},
+		Options: options.Index().SetName("goes_import").SetSparse(true),
+	},
 }
 
 // EventStoreIndices provides the builtin index models for the MongoDB event store.
@@ -79,12 +97,23 @@ type EventStoreIndices struct {
 	// NameAndTime creates a compound index for the event name and id.
 	NameAndTime mongo.IndexModel
 
+	// TimeAndID creates a compound index for the nano time and event id,
+	// which serves queries that are sorted by time and tie-broken by event id.
+	TimeAndID mongo.IndexModel
+
 	// AggregateNameAndVersion creates a compound index for the aggregate name and version.
 	AggregateNameAndVersion mongo.IndexModel
 
 	// AggregateNameAndIDAndVersion creates a compound index for the aggregate name, id, and version.
 	AggregateNameAndIDAndVersion mongo.IndexModel
 
+	// Tags creates a sparse multikey index for the event tags.
+	Tags mongo.IndexModel
+
+	// Import creates a sparse index for the import id of events that are
+	// part of a pending import.
+	Import mongo.IndexModel
+
 	// Edge-case indices
 
 	// ISOTime creates an index for the ISO time field. Usually, this is not
@@ -107,8 +136,11 @@ func EventStoreCore() []mongo.IndexModel {
 		EventStore.ID,
 		EventStore.Name,
 		EventStore.NameAndTime,
+		EventStore.TimeAndID,
 		EventStore.AggregateNameAndVersion,
 		EventStore.AggregateNameAndIDAndVersion,
+		EventStore.Tags,
+		EventStore.Import,
 	}
 }
 
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	stdtime "time"
+
+	mongoevent "go.mongodb.org/mongo-driver/event"
+)
+
+// Operations of the EventStore that are reported to Metrics.
+const (
+	OpInsert   = "insert"
+	OpFind     = "find"
+	OpDelete   = "delete"
+	OpQuery    = "query"
+	OpQueryRaw = "query_raw"
+	OpPipeline = "pipeline"
+)
+
+// Metrics are callbacks that are called by the EventStore to report the
+// latency, document counts and transaction retries of its operations, e.g. to
+// export them to Prometheus. Callbacks may be nil and must be safe for
+// concurrent use.
+type Metrics struct {
+	// Operation is called after an operation of the store has finished. The
+	// operations of queries finish when their cursor is exhausted, so their
+	// duration includes the time that the caller needs to consume the events.
+	Operation func(OperationMetric)
+
+	// TransactionRetry is called before a transaction of an insert is retried
+	// (see TransactionRetry). The attempt is the number of the attempt that
+	// is about to be made, and err is the error of the previous attempt.
+	// Retried commits are reported with commit set to true.
+	TransactionRetry func(attempt int, commit bool, err error)
+}
+
+// OperationMetric describes a finished operation of the EventStore.
+type OperationMetric struct {
+	// Operation is the name of the operation, e.g. OpInsert.
+	Operation string
+
+	// Duration is the time the operation took.
+	Duration stdtime.Duration
+
+	// Documents is the number of event documents that were inserted, found
+	// or deleted by the operation.
+	Documents int
+
+	// Err is the error of the operation, if any.
+	Err error
+}
+
+// WithMetrics returns an EventStoreOption that reports the metrics of the
+// store's operations to the given callbacks:
+//
+//	store := mongo.NewEventStore(enc, mongo.WithMetrics(mongo.Metrics{
+//		Operation: func(m mongo.OperationMetric) {
+//			latency.WithLabelValues(m.Operation).Observe(m.Duration.Seconds())
+//			documents.WithLabelValues(m.Operation).Add(float64(m.Documents))
+//		},
+//		TransactionRetry: func(int, bool, error) {
+//			retries.Inc()
+//		},
+//	}))
+func WithMetrics(m Metrics) EventStoreOption {
+	return func(s *EventStore) {
+		s.metrics = m
+	}
+}
+
+// CommandMonitor returns an EventStoreOption that registers a command monitor
+// with the MongoDB client, which is notified about every command that is sent
+// to MongoDB. The monitor is only registered if the store creates the client
+// itself; a client that is provided with the Client option must be
+// configured with the monitor by the caller.
+func CommandMonitor(m *mongoevent.CommandMonitor) EventStoreOption {
+	return func(s *EventStore) {
+		s.monitor = m
+	}
+}
+
+// observer returns a function that reports an operation that starts now, or
+// nil if no Operation callback is configured.
+func (s *EventStore) observer(op string) func(documents int, err error) {
+	if s.metrics.Operation == nil {
+		return nil
+	}
+	start := stdtime.Now()
+	return func(documents int, err error) {
+		s.metrics.Operation(OperationMetric{
+			Operation: op,
+			Duration:  stdtime.Since(start),
+			Documents: documents,
+			Err:       err,
+		})
+	}
+}
+
+func (s *EventStore) observeRetry(attempt int, commit bool, err error) {
+	if s.metrics.TransactionRetry != nil {
+		s.metrics.TransactionRetry(attempt, commit, err)
+	}
+}
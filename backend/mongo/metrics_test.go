@@ -0,0 +1,119 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+	mongoevent "go.mongodb.org/mongo-driver/event"
+)
+
+func TestWithMetrics(t *testing.T) {
+	var mux sync.Mutex
+	var metrics []mongo.OperationMetric
+
+	store := mongo.NewEventStore(
+		test.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.WithMetrics(mongo.Metrics{
+			Operation: func(m mongo.OperationMetric) {
+				mux.Lock()
+				defer mux.Unlock()
+				metrics = append(metrics, m)
+			},
+		}),
+	)
+
+	ctx := context.Background()
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", test.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if _, err := store.Find(ctx, events[0].ID()); err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.AggregateID(id)))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+	if _, err := streams.Drain(ctx, str, errs); err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	if err := store.Delete(ctx, events[1]); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	want := []struct {
+		op        string
+		documents int
+	}{
+		{mongo.OpInsert, 2},
+		{mongo.OpFind, 1},
+		{mongo.OpQuery, 2},
+		{mongo.OpDelete, 1},
+	}
+
+	if len(metrics) != len(want) {
+		t.Fatalf("%d operations should be reported; got %d", len(want), len(metrics))
+	}
+
+	for i, w := range want {
+		m := metrics[i]
+		if m.Operation != w.op || m.Documents != w.documents || m.Err != nil {
+			t.Errorf("unexpected metric #%d: %+v", i, m)
+		}
+		if m.Duration <= 0 {
+			t.Errorf("Duration of %q should be positive; got %s", m.Operation, m.Duration)
+		}
+	}
+}
+
+func TestCommandMonitor(t *testing.T) {
+	var mux sync.Mutex
+	commands := make(map[string]int)
+
+	store := mongo.NewEventStore(
+		test.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.CommandMonitor(&mongoevent.CommandMonitor{
+			Succeeded: func(_ context.Context, evt *mongoevent.CommandSucceededEvent) {
+				mux.Lock()
+				defer mux.Unlock()
+				commands[evt.CommandName]++
+			},
+		}),
+	)
+
+	if err := store.Insert(context.Background(), event.New[any]("foo", test.FooEventData{A: "foo"})); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if commands["insert"] == 0 {
+		t.Fatalf("command monitor should be notified about the insert command; got %v", commands)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/modernice/goes/persistence/model"
@@ -12,12 +13,22 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var _ model.Repository[model.Model[uuid.UUID], uuid.UUID] = &ModelRepository[model.Model[uuid.UUID], uuid.UUID]{}
+var (
+	_ model.Repository[model.Model[uuid.UUID], uuid.UUID]     = &ModelRepository[model.Model[uuid.UUID], uuid.UUID]{}
+	_ model.VersionedSaver[model.Model[uuid.UUID], uuid.UUID] = &ModelRepository[model.Model[uuid.UUID], uuid.UUID]{}
+)
+
+// DefaultModelVersionKey is the default field of model documents that
+// contains the version of the model (see ModelRepository.SaveVersioned).
+const DefaultModelVersionKey = "version"
 
 // ModelRepository is a MongoDB backed model repository.
 type ModelRepository[Model model.Model[ID], ID model.ID] struct {
 	modelRepositoryOptions
 	col *mongo.Collection
+
+	indexMux sync.Mutex
+	indexed  bool
 }
 
 // ModelRepositoryOption is an option for the model repository.
@@ -25,6 +36,7 @@ type ModelRepositoryOption func(*modelRepositoryOptions)
 
 type modelRepositoryOptions struct {
 	key              string
+	versionKey       string
 	transactions     bool
 	factory          func(any) any
 	createIfNotFound bool
@@ -40,6 +52,16 @@ func ModelIDKey(key string) ModelRepositoryOption {
 	}
 }
 
+// ModelVersionKey returns a ModelRepositoryOption that specifies which field
+// of the model documents contains the version of the model. The field is used
+// by SaveVersioned to reject stale writes and defaults to
+// DefaultModelVersionKey. The encoded model must contain the field.
+func ModelVersionKey(key string) ModelRepositoryOption {
+	return func(o *modelRepositoryOptions) {
+		o.versionKey = key
+	}
+}
+
 // ModelTransactions returns a ModelRepositoryOption that enables MongoDB
 // transactions for the repository. Currently, only the Use() function makes use
 // of transactions. Transactions are disabled by default and must be supported
@@ -98,6 +120,10 @@ func NewModelRepository[Model model.Model[ID], ID model.ID](col *mongo.Collectio
 		options.key = "_id"
 	}
 
+	if options.versionKey == "" {
+		options.versionKey = DefaultModelVersionKey
+	}
+
 	return &ModelRepository[Model, ID]{
 		modelRepositoryOptions: options,
 		col:                    col,
@@ -139,6 +165,75 @@ func (r *ModelRepository[Model, ID]) Save(ctx context.Context, m Model) error {
 	return err
 }
 
+// SaveVersioned saves the given model to the database if the stored document
+// has a lower version than the model, or if it does not exist. Otherwise, an
+// error that unwraps to model.ErrStale is returned. The model must implement
+// model.Versioned, and its encoded document must contain the version in the
+// field that is configured by the ModelVersionKey option.
+//
+// The check and the write are a single conditional upsert, so that
+// concurrent writers cannot overwrite newer documents with older ones. Stale
+// writes are detected by the duplicate key error of the rejected upsert, which
+// requires a unique index on the id field of the model. If a custom id field is
+// configured, SaveVersioned creates that index before the first write (see
+// CreateIndexes) and fails if it cannot be created.
+func (r *ModelRepository[Model, ID]) SaveVersioned(ctx context.Context, m Model) error {
+	v, ok := any(m).(model.Versioned)
+	if !ok {
+		return fmt.Errorf("%T does not implement model.Versioned", m)
+	}
+
+	if err := r.ensureIndexes(ctx); err != nil {
+		return fmt.Errorf("create indexes: %w", err)
+	}
+
+	var replacement any = m
+	if r.customEncoder != nil {
+		repl, err := r.customEncoder(m)
+		if err != nil {
+			return fmt.Errorf("custom encoder: %w", err)
+		}
+		replacement = repl
+	}
+
+	filter := bson.D{
+		{Key: r.key, Value: m.ModelID()},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: r.versionKey, Value: bson.D{{Key: "$lt", Value: v.ModelVersion()}}}},
+			bson.D{{Key: r.versionKey, Value: bson.D{{Key: "$exists", Value: false}}}},
+		}},
+	}
+
+	if _, err := r.col.ReplaceOne(ctx, filter, replacement, options.Replace().SetUpsert(true)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: version %d of model %v", model.ErrStale, v.ModelVersion(), m.ModelID())
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *ModelRepository[Model, ID]) ensureIndexes(ctx context.Context) error {
+	if r.key == "_id" {
+		return nil
+	}
+
+	r.indexMux.Lock()
+	defer r.indexMux.Unlock()
+
+	if r.indexed {
+		return nil
+	}
+
+	if err := r.CreateIndexes(ctx); err != nil {
+		return err
+	}
+	r.indexed = true
+
+	return nil
+}
+
 // Fetch fetches the given model from the database. If the model cannot be found,
 // an error that unwraps to model.ErrNotFound is returned.
 func (r *ModelRepository[Model, ID]) Fetch(ctx context.Context, id ID) (Model, error) {
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/modernice/goes/backend/mongo"
 	"github.com/modernice/goes/persistence/model"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	gomongo "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -224,6 +225,62 @@ func TestModelRepository_Fetch_ModelFactory_CreateIfNotFound(t *testing.T) {
 	}
 }
 
+func TestModelRepository_SaveVersioned(t *testing.T) {
+	ctx := context.Background()
+	col := connect(t)
+
+	r := mongo.NewModelRepository[*versionedModel, primitive.ObjectID](col)
+
+	id := primitive.NewObjectID()
+	if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: 2, Foo: "v2"}); err != nil {
+		t.Fatalf("SaveVersioned() failed with %q", err)
+	}
+
+	for _, v := range []int{1, 2} {
+		if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: v, Foo: "stale"}); !errors.Is(err, model.ErrStale) {
+			t.Fatalf("SaveVersioned() should fail with %q for version %d; got %v", model.ErrStale, v, err)
+		}
+	}
+
+	if err := r.SaveVersioned(ctx, &versionedModel{ID: id, Version: 3, Foo: "v3"}); err != nil {
+		t.Fatalf("SaveVersioned() failed with %q", err)
+	}
+
+	m, err := r.Fetch(ctx, id)
+	if err != nil {
+		t.Fatalf("Fetch() failed with %q", err)
+	}
+
+	if m.Foo != "v3" {
+		t.Fatalf(`"Foo" field should be %q; is %q`, "v3", m.Foo)
+	}
+}
+
+func TestModelRepository_SaveVersioned_ModelIDKey(t *testing.T) {
+	ctx := context.Background()
+	col := connect(t)
+
+	r := mongo.NewModelRepository[*customKeyVersionedModel, uuid.UUID](col, mongo.ModelIDKey("id"))
+
+	id := uuid.New()
+	if err := r.SaveVersioned(ctx, &customKeyVersionedModel{ID: id, Version: 2}); err != nil {
+		t.Fatalf("SaveVersioned() failed with %q", err)
+	}
+
+	if err := r.SaveVersioned(ctx, &customKeyVersionedModel{ID: id, Version: 1}); !errors.Is(err, model.ErrStale) {
+		t.Fatalf("SaveVersioned() should fail with %q; got %v", model.ErrStale, err)
+	}
+
+	count, err := col.CountDocuments(ctx, bson.D{{Key: "id", Value: id}})
+	if err != nil {
+		t.Fatalf("CountDocuments() failed with %q", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("stale write should not insert a second document; got %d documents", count)
+	}
+}
+
 func connect(t *testing.T) *gomongo.Collection {
 	client, err := gomongo.Connect(context.Background(), options.Client().ApplyURI(os.Getenv("MONGOMODEL_URL")))
 	if err != nil {
@@ -258,3 +315,30 @@ type uuidModel struct {
 func (m uuidModel) ModelID() uuid.UUID {
 	return m.ID
 }
+
+type versionedModel struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Version int                `bson:"version"`
+	Foo     string
+}
+
+func (m versionedModel) ModelID() primitive.ObjectID {
+	return m.ID
+}
+
+func (m versionedModel) ModelVersion() int {
+	return m.Version
+}
+
+type customKeyVersionedModel struct {
+	ID      uuid.UUID `bson:"id"`
+	Version int       `bson:"version"`
+}
+
+func (m customKeyVersionedModel) ModelID() uuid.UUID {
+	return m.ID
+}
+
+func (m customKeyVersionedModel) ModelVersion() int {
+	return m.Version
+}
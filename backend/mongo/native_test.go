@@ -0,0 +1,63 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestNativeData(t *testing.T) {
+	ctx := context.Background()
+
+	reg := mongo.NewBSONRegistry()
+	codec.Register[etest.FooEventData](reg, "foo")
+
+	store := mongo.NewEventStore(
+		reg,
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.NativeData(true),
+	)
+
+	evt := event.New[any]("foo", etest.FooEventData{A: "bar"}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	// event data is queryable
+	var doc bson.M
+	if err := store.Collection().FindOne(ctx, bson.M{"data.a": "bar"}).Decode(&doc); err != nil {
+		t.Fatalf("FindOne() failed with %q", err)
+	}
+
+	found, err := store.Find(ctx, evt.ID())
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+
+	etest.AssertEqualEvents(t, []event.Event{evt}, []event.Event{found})
+}
+
+func TestNativeData_notBSON(t *testing.T) {
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.NativeData(true),
+	)
+
+	evt := event.New[any]("foo", etest.FooEventData{A: "bar"}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(context.Background(), evt); err == nil {
+		t.Fatalf("Insert() should fail for event data that is not encoded as a BSON document")
+	}
+}
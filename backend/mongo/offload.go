@@ -0,0 +1,216 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// DefaultOffloadBucket is the name of the GridFS bucket that stores offloaded
+// event data if no BlobStore is provided to the Offload option.
+const DefaultOffloadBucket = "goes_data"
+
+// BlobStore stores the event data that is offloaded from the event documents
+// (see Offload). Blobs are identified by keys that are generated by the event
+// store. Every upload uses a new key, so PutBlob never replaces existing data.
+type BlobStore interface {
+	// PutBlob stores the given data under the given key.
+	PutBlob(ctx context.Context, key string, data []byte) error
+
+	// GetBlob returns the data that is stored under the given key.
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+
+	// DeleteBlob deletes the data that is stored under the given key.
+	// Deleting data that does not exist is not an error.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// Offload returns an EventStoreOption that stores event data that is larger
+// than threshold bytes in the given BlobStore instead of the event document,
+// to avoid hitting the 16MB document limit of MongoDB for events that carry
+// large payloads. The event document then only contains a pointer to the
+// blob, and the data is loaded from the BlobStore when the event is queried.
+// If blobs is nil, the data is stored in the DefaultOffloadBucket GridFS
+// bucket of the event database:
+//
+//	store := mongo.NewEventStore(enc, mongo.Offload(1<<20, nil))
+//
+// Blobs are written before the event documents, and not as part of the
+// Insert transaction. Every upload is stored under a new key that the event
+// document references, so an insert that fails, e.g. because an event with
+// the same id already exists, never replaces the data of a stored event. The
+// blobs of a failed insert are deleted unless a document references them.
+// Blobs are deleted when their events are deleted using Delete, but not when
+// events are removed by retention policies (see TTL).
+//
+// Offloaded data is not part of the event documents, so it cannot be matched
+// by the stages of a QueryPipeline.
+func Offload(threshold int, blobs BlobStore) EventStoreOption {
+	return func(s *EventStore) {
+		s.offloadThreshold = threshold
+		s.blobs = blobs
+	}
+}
+
+// NewGridFS returns a BlobStore that stores event data in the given GridFS
+// bucket of db.
+func NewGridFS(db *mongo.Database, bucket string) (BlobStore, error) {
+	b, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucket))
+	if err != nil {
+		return nil, fmt.Errorf("create %q bucket: %w", bucket, err)
+	}
+	return &gridFS{bucket: b}, nil
+}
+
+type gridFS struct {
+	bucket *gridfs.Bucket
+}
+
+func (fs *gridFS) PutBlob(_ context.Context, key string, data []byte) error {
+	return fs.bucket.UploadFromStreamWithID(key, key, bytes.NewReader(data))
+}
+
+func (fs *gridFS) GetBlob(_ context.Context, key string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := fs.bucket.DownloadToStream(key, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (fs *gridFS) DeleteBlob(ctx context.Context, key string) error {
+	if err := fs.bucket.DeleteContext(ctx, key); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *EventStore) offloading() bool {
+	return s.offloadThreshold > 0
+}
+
+// connectBlobs creates the default BlobStore if offloading is enabled and no
+// BlobStore was provided.
+func (s *EventStore) connectBlobs() error {
+	if !s.offloading() || s.blobs != nil {
+		return nil
+	}
+
+	blobs, err := NewGridFS(s.db, DefaultOffloadBucket)
+	if err != nil {
+		return err
+	}
+	s.blobs = blobs
+
+	return nil
+}
+
+// offload stores the encoded data of an event in the BlobStore if it exceeds
+// the offload threshold, and returns the key of the blob, or an empty string
+// if the data is not offloaded.
+func (s *EventStore) offload(ctx context.Context, eventID uuid.UUID, b []byte) (string, error) {
+	if !s.offloading() || len(b) <= s.offloadThreshold {
+		return "", nil
+	}
+
+	key := fmt.Sprintf("%s.%s", eventID, uuid.New())
+	if err := s.blobs.PutBlob(ctx, key, b); err != nil {
+		return "", fmt.Errorf("offload data: %w", err)
+	}
+
+	return key, nil
+}
+
+// blobKey returns the key of the offloaded data of an entry. Entries that were
+// offloaded before blob keys were introduced are stored under their event id.
+func (e entry) blobKey() string {
+	if e.Blob != "" {
+		return e.Blob
+	}
+	return e.ID.String()
+}
+
+// loadData loads the offloaded data of an entry from the BlobStore.
+func (s *EventStore) loadData(ctx context.Context, e *entry) error {
+	if !e.Offloaded {
+		return nil
+	}
+
+	if s.blobs == nil {
+		return fmt.Errorf("load offloaded %q event data: no blob store configured (use the Offload option)", e.Name)
+	}
+
+	b, err := s.blobs.GetBlob(ctx, e.blobKey())
+	if err != nil {
+		return fmt.Errorf("load offloaded %q event data: %w", e.Name, err)
+	}
+
+	e.Data = bson.RawValue{Type: bsontype.Binary, Value: bsoncore.AppendBinary(nil, bsontype.BinaryGeneric, b)}
+
+	return nil
+}
+
+// deleteBlobs deletes the offloaded data with the given keys.
+func (s *EventStore) deleteBlobs(ctx context.Context, keys []string) error {
+	if !s.offloading() {
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := s.blobs.DeleteBlob(ctx, key); err != nil {
+			return fmt.Errorf("delete offloaded data %q: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// discardBlobs deletes the blobs with the given keys that were uploaded by a
+// failed insert, unless an event document references them. Documents that
+// were inserted before the insert failed keep their blobs if the insert was
+// not transactional.
+func (s *EventStore) discardBlobs(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	cur, err := s.entries.Find(
+		ctx,
+		bson.D{{Key: "blob", Value: bson.D{{Key: "$in", Value: keys}}}},
+		options.Find().SetProjection(bson.D{{Key: "blob", Value: 1}}),
+	)
+	if err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	referenced := make(map[string]bool)
+	for cur.Next(ctx) {
+		var e entry
+		if err := cur.Decode(&e); err != nil {
+			return fmt.Errorf("decode document: %w", err)
+		}
+		referenced[e.Blob] = true
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("cursor: %w", err)
+	}
+
+	var orphans []string
+	for _, key := range keys {
+		if !referenced[key] {
+			orphans = append(orphans, key)
+		}
+	}
+
+	return s.deleteBlobs(ctx, orphans)
+}
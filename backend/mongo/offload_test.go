@@ -0,0 +1,176 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestOffload(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Offload(100, nil),
+	)
+
+	small := event.New[any]("foo", etest.FooEventData{A: "small"})
+	large := event.New[any]("foo", etest.FooEventData{A: strings.Repeat("x", 1000)})
+
+	if err := store.Insert(ctx, small, large); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	var doc, smallDoc struct {
+		Offloaded bool   `bson:"offloaded"`
+		Blob      string `bson:"blob"`
+	}
+	if err := store.Collection().FindOne(ctx, bson.D{{Key: "id", Value: large.ID()}}).Decode(&doc); err != nil {
+		t.Fatalf("find document: %v", err)
+	}
+	if !doc.Offloaded || doc.Blob == "" {
+		t.Fatalf("data of the large event should be offloaded")
+	}
+
+	if err := store.Collection().FindOne(ctx, bson.D{{Key: "id", Value: small.ID()}}).Decode(&smallDoc); err != nil {
+		t.Fatalf("find document: %v", err)
+	}
+	if smallDoc.Offloaded {
+		t.Fatalf("data of the small event should not be offloaded")
+	}
+
+	found, err := store.Find(ctx, large.ID())
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+	if found.Data() != large.Data() {
+		t.Fatalf("Find() should load the offloaded data")
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.SortByTime()))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Query() should return %d events; got %d", 2, len(events))
+	}
+	for _, evt := range events {
+		if evt.ID() == large.ID() && evt.Data() != large.Data() {
+			t.Fatalf("Query() should load the offloaded data")
+		}
+	}
+
+	if err := store.Delete(ctx, large); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+
+	blobs, err := mongo.NewGridFS(store.Database(), mongo.DefaultOffloadBucket)
+	if err != nil {
+		t.Fatalf("NewGridFS() failed with %q", err)
+	}
+	if _, err := blobs.GetBlob(ctx, doc.Blob); err == nil {
+		t.Fatalf("offloaded data should be deleted together with the event")
+	}
+}
+
+func TestOffload_blobStore(t *testing.T) {
+	ctx := context.Background()
+	blobs := &memoryBlobs{blobs: make(map[string][]byte)}
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Offload(100, blobs),
+	)
+
+	large := event.New[any]("foo", etest.FooEventData{A: strings.Repeat("x", 1000)})
+	if err := store.Insert(ctx, large); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if len(blobs.blobs) != 1 {
+		t.Fatalf("data should be stored in the provided BlobStore")
+	}
+
+	found, err := store.Find(ctx, large.ID())
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+	if found.Data() != large.Data() {
+		t.Fatalf("Find() should load the offloaded data")
+	}
+}
+
+func TestOffload_duplicateInsert(t *testing.T) {
+	ctx := context.Background()
+	blobs := &memoryBlobs{blobs: make(map[string][]byte)}
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Offload(100, blobs),
+	)
+
+	id := uuid.New()
+	original := event.New[any]("foo", etest.FooEventData{A: strings.Repeat("x", 1000)}, event.ID(id))
+	if err := store.Insert(ctx, original); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	duplicate := event.New[any]("foo", etest.FooEventData{A: strings.Repeat("y", 1000)}, event.ID(id))
+	if err := store.Insert(ctx, duplicate); err == nil {
+		t.Fatalf("Insert() should fail for a duplicate event id")
+	}
+
+	if len(blobs.blobs) != 1 {
+		t.Fatalf("blobs of the failed insert should be deleted; %d blobs remain", len(blobs.blobs))
+	}
+
+	found, err := store.Find(ctx, id)
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+	if found.Data() != original.Data() {
+		t.Fatalf("a failed insert should not replace the data of the stored event")
+	}
+}
+
+type memoryBlobs struct {
+	blobs map[string][]byte
+}
+
+func (m *memoryBlobs) PutBlob(_ context.Context, key string, data []byte) error {
+	if _, ok := m.blobs[key]; ok {
+		return fmt.Errorf("blob %q already exists", key)
+	}
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *memoryBlobs) GetBlob(_ context.Context, key string) ([]byte, error) {
+	return m.blobs[key], nil
+}
+
+func (m *memoryBlobs) DeleteBlob(_ context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
@@ -11,9 +11,14 @@ import (
 	"github.com/modernice/goes/backend/mongo"
 	"github.com/modernice/goes/backend/mongo/mongotest"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+	"go.mongodb.org/mongo-driver/bson"
 	gomongo "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 func TestClient(t *testing.T) {
@@ -84,3 +89,65 @@ func TestCollection(t *testing.T) {
 		t.Errorf("expected store.Collection().Name() to return %q; got %q", "custom", col.Name())
 	}
 }
+
+func TestReadPreference_ReadConcern(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		test.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.ReadPreference(readpref.PrimaryPreferred()),
+		mongo.ReadConcern(readconcern.Majority()),
+	)
+
+	evt := event.New("foo", test.FooEventData{A: "foo"}).Any()
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	found, err := store.Find(ctx, evt.ID())
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+
+	if found.ID() != evt.ID() {
+		t.Fatalf("Find() should return the inserted event")
+	}
+}
+
+func TestCollation(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		test.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Collation(&options.Collation{Locale: "en", Strength: 2}),
+	)
+
+	evt := event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "Order", 1)).Any()
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	specs, err := store.Database().ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: store.Collection().Name()}})
+	if err != nil {
+		t.Fatalf("ListCollectionSpecifications() failed with %q", err)
+	}
+	if len(specs) != 1 || specs[0].Options.Lookup("collation", "locale").StringValue() != "en" {
+		t.Fatalf("event collection should be created with the collation")
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.AggregateName("order")))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	if len(events) != 1 || events[0].ID() != evt.ID() {
+		t.Fatalf("Query() should match aggregate names case-insensitively; got %v", events)
+	}
+}
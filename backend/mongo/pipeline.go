@@ -0,0 +1,53 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+)
+
+// QueryPipeline runs an aggregation pipeline on the event collection and
+// returns the resulting documents as an event.Stream. QueryPipeline is an
+// escape hatch for queries that cannot be expressed using event.Query, e.g.
+// queries that join other collections or filter by event data (see
+// NativeData):
+//
+//	str, err := store.QueryPipeline(ctx, mongo.Pipeline{
+//		{{Key: "$match", Value: bson.M{"name": "order.placed", "data.total": bson.M{"$gte": 100}}}},
+//		{{Key: "$sort", Value: bson.M{"timeNano": 1}}},
+//	})
+//
+// The documents that are returned by the pipeline must be event documents,
+// i.e. they must keep the fields of the stored events: "id", "name",
+// "timeNano", "aggregateName", "aggregateId", "aggregateVersion", and "data".
+// Additional fields are ignored. The pipeline is run with allowDiskUse enabled.
+func (s *EventStore) QueryPipeline(ctx context.Context, pipeline mongo.Pipeline) (event.Stream, error) {
+	if s.isTransactionStore {
+		return s.root.QueryPipeline(ctx, pipeline)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	done := s.observer(OpPipeline)
+
+	var cur *mongo.Cursor
+	if err := s.retry(ctx, func() (err error) {
+		cur, err = s.reads.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetCollation(s.collation))
+		return err
+	}); err != nil {
+		if done != nil {
+			done(0, err)
+		}
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	events, errs := s.decodeCursor(ctx, cur, nil, done)
+
+	return event.NewStream(events, errs), nil
+}
@@ -0,0 +1,50 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore_QueryPipeline(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(etest.NewEncoder(), mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(nextEventDatabase()))
+
+	id := uuid.New()
+	inserted := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 3)),
+	}
+
+	if err := store.Insert(ctx, inserted...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	str, err := store.QueryPipeline(ctx, mongodb.Pipeline{
+		{{Key: "$match", Value: bson.M{"name": "foo"}}},
+		{{Key: "$sort", Value: bson.M{"aggregateVersion": -1}}},
+	})
+	if err != nil {
+		t.Fatalf("QueryPipeline() failed with %q", err)
+	}
+
+	events, errs := str.Events(ctx)
+	result, err := streams.Drain(ctx, events, errs)
+	if err != nil {
+		t.Fatalf("Drain() failed with %q", err)
+	}
+
+	etest.AssertEqualEvents(t, []event.Event{inserted[2], inserted[0]}, result)
+}
@@ -0,0 +1,236 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+)
+
+const (
+	// DefaultCounterCollection is the default name of the collection that
+	// stores the counter of the global positions of events.
+	DefaultCounterCollection = "counters"
+
+	// PositionIndex is the name of the index on the global positions of events.
+	PositionIndex = "goes_position"
+
+	// DefaultPositionRetryAttempts is the default number of attempts of
+	// inserts that conflict on the position counter (see GlobalPositions).
+	DefaultPositionRetryAttempts = 10
+
+	// DefaultPositionRetryBackoff is the default delay before the first retry
+	// of inserts that conflict on the position counter (see GlobalPositions).
+	DefaultPositionRetryBackoff = 5 * stdtime.Millisecond
+)
+
+// GlobalPositions returns an EventStoreOption that assigns a global position
+// to each inserted event. Positions are taken from a counter in the counter
+// collection (see CounterCollection), start at 1 and are strictly increasing
+// in the order of insertion. Projections can use the positions to checkpoint
+// their progress exactly (see QueryAfter and LastPosition), which is not
+// possible with event times, because multiple events may share a time.
+//
+// Positions are unique but not gap-free: the positions of failed inserts are
+// not reused. If transactions are enabled (see Transactions), the counter is
+// incremented within the insert transaction, so that events become visible in
+// the order of their positions. Concurrent inserts then conflict on the
+// counter: all but one of them abort with a transient write conflict and must
+// be retried. Unless TransactionRetry is configured explicitly, such inserts
+// are therefore retried up to DefaultPositionRetryAttempts times, starting
+// with a delay of DefaultPositionRetryBackoff. Without transactions, an event
+// may become visible after an event with a higher position, so consumers that
+// checkpoint their position could skip it.
+//
+// Events that were inserted before the option was enabled have no position.
+func GlobalPositions(enabled bool) EventStoreOption {
+	return func(s *EventStore) {
+		s.positions = enabled
+	}
+}
+
+// CounterCollection returns an EventStoreOption that specifies the name of the
+// collection that stores the counter of the global positions of events.
+// Defaults to DefaultCounterCollection.
+func CounterCollection(name string) EventStoreOption {
+	return func(s *EventStore) {
+		s.countersCol = name
+	}
+}
+
+// Positioned is an event and its global position.
+type Positioned = event.Positioned
+
+type counter struct {
+	Collection string `bson:"_id"`
+	Value      int64  `bson:"value"`
+}
+
+// reservePositions reserves n positions for the events that are inserted into
+// the event collection and returns the first reserved position, or 0 if
+// global positions are disabled.
+func (s *EventStore) reservePositions(ctx context.Context, n int) (int64, error) {
+	if !s.positions || n == 0 {
+		return 0, nil
+	}
+
+	var c counter
+	if err := s.counters.FindOneAndUpdate(
+		ctx,
+		bson.D{{Key: "_id", Value: s.entries.Name()}},
+		bson.D{{Key: "$inc", Value: bson.D{{Key: "value", Value: int64(n)}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&c); err != nil {
+		return 0, fmt.Errorf("reserve positions: %w", err)
+	}
+
+	return c.Value - int64(n) + 1, nil
+}
+
+// LastPosition returns the last position that was assigned to an event, or 0
+// if no positions were assigned. The event with that position may not be
+// visible yet if it is part of an uncommitted insert.
+func (s *EventStore) LastPosition(ctx context.Context) (int64, error) {
+	if s.isTransactionStore {
+		return s.root.LastPosition(ctx)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	var c counter
+	if err := s.counters.FindOne(ctx, bson.D{{Key: "_id", Value: s.entries.Name()}}).Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("mongo: %w", err)
+	}
+
+	return c.Value, nil
+}
+
+// QueryAfter queries the events that have a global position greater than the
+// given position and are matched by the given query, sorted by their
+// position. The sortings of the query are ignored; its limit and offset are
+// applied. Events without a position are never returned. QueryAfter fails with
+// an error that wraps errors.ErrUnsupported if global positions are disabled
+// (see GlobalPositions). Pass 0 to query from the first event:
+//
+//	var checkpoint int64
+//	events, errs, err := store.QueryAfter(ctx, checkpoint, query.New(query.Limit(100)))
+//	err = streams.Walk(ctx, func(evt mongo.Positioned) error {
+//		project(evt)
+//		checkpoint = evt.Position
+//		return nil
+//	}, events, errs)
+//
+// QueryAfter implements event.PositionQuerier.
+func (s *EventStore) QueryAfter(ctx context.Context, position int64, q event.Query) (<-chan Positioned, <-chan error, error) {
+	if s.isTransactionStore {
+		return s.root.QueryAfter(ctx, position, q)
+	}
+
+	if !s.positions {
+		return nil, nil, fmt.Errorf("global positions are disabled: %w", errors.ErrUnsupported)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, nil, fmt.Errorf("connect: %w", err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}}).SetCollation(s.collation)
+	if s.findBatchSize > 0 {
+		opts = opts.SetBatchSize(s.findBatchSize)
+	}
+	if limit, offset := event.Paging(q); limit > 0 || offset > 0 {
+		opts = opts.SetLimit(int64(limit)).SetSkip(int64(offset))
+	}
+
+	filter := append(s.filter(q), bson.E{Key: "position", Value: bson.D{{Key: "$gt", Value: position}}})
+
+	s.explain(ctx, OpQueryAfter, filter, opts)
+
+	var cur *mongo.Cursor
+	if err := s.retry(ctx, func() (err error) {
+		cur, err = s.reads.Find(ctx, filter, opts)
+		return err
+	}); err != nil {
+		return nil, nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	events := make(chan Positioned)
+	errs := make(chan error)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+		defer cur.Close(ctx)
+
+		fail := func(err error) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case errs <- err:
+				return true
+			}
+		}
+
+		for cur.Next(ctx) {
+			var e entry
+			if err := cur.Decode(&e); err != nil {
+				if !fail(err) {
+					return
+				}
+				continue
+			}
+
+			if err := s.loadData(ctx, &e); err != nil {
+				if !fail(err) {
+					return
+				}
+				continue
+			}
+
+			evt, err := e.event(ctx, s.enc)
+			if err != nil {
+				if !fail(err) {
+					return
+				}
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case events <- Positioned{Event: evt, Position: e.Position}:
+			}
+		}
+
+		if err := cur.Err(); err != nil {
+			fail(err)
+		}
+	}()
+
+	return events, errs, nil
+}
+
+func (s *EventStore) positionIndexes() []mongo.IndexModel {
+	if !s.positions {
+		return nil
+	}
+
+	return []mongo.IndexModel{{
+		Keys: bson.D{{Key: "position", Value: 1}},
+		Options: options.Index().
+			SetName(PositionIndex).
+			SetUnique(!s.sharded).
+			SetPartialFilterExpression(bson.D{{Key: "position", Value: bson.D{{Key: "$exists", Value: true}}}}),
+	}}
+}
@@ -0,0 +1,140 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestGlobalPositions(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.GlobalPositions(true),
+		mongo.InsertBatchSize(2),
+	)
+
+	aggregateID := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(aggregateID, "foo", 1)),
+		event.New[any]("bar", etest.BarEventData{}, event.Aggregate(aggregateID, "foo", 2)),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(aggregateID, "foo", 3)),
+	}
+
+	if err := store.Insert(ctx, events[:1]...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+	if err := store.Insert(ctx, events[1:]...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	last, err := store.LastPosition(ctx)
+	if err != nil {
+		t.Fatalf("LastPosition() failed with %q", err)
+	}
+	if last != 3 {
+		t.Fatalf("LastPosition() should return %d; got %d", 3, last)
+	}
+
+	str, errs, err := store.QueryAfter(ctx, 0, query.New())
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	all, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if len(all) != len(events) {
+		t.Fatalf("QueryAfter() should return %d events; got %d", len(events), len(all))
+	}
+	for i, evt := range all {
+		if evt.ID() != events[i].ID() || evt.Position != int64(i+1) {
+			t.Fatalf("event #%d should be %s at position %d; got %s at position %d", i, events[i].ID(), i+1, evt.ID(), evt.Position)
+		}
+	}
+
+	str, errs, err = store.QueryAfter(ctx, 1, query.New(query.Name("foo")))
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	after, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if len(after) != 1 || after[0].ID() != events[2].ID() || after[0].Position != 3 {
+		t.Fatalf("QueryAfter() should only return the last %q event; got %v", "foo", after)
+	}
+}
+
+func TestGlobalPositions_concurrentTransactions(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOREPLSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Transactions(true),
+		mongo.GlobalPositions(true),
+	)
+
+	if _, err := store.Connect(ctx); err != nil {
+		t.Fatalf("Connect() failed with %q", err)
+	}
+
+	const n = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			evt := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(uuid.New(), "foo", 1))
+			errs <- store.Insert(ctx, evt)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Insert() failed with %q", err)
+		}
+	}
+
+	str, serrs, err := store.QueryAfter(ctx, 0, query.New())
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	all, err := streams.Drain(ctx, str, serrs)
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if len(all) != n {
+		t.Fatalf("QueryAfter() should return %d events; got %d", n, len(all))
+	}
+
+	for i := 1; i < len(all); i++ {
+		if all[i].Position <= all[i-1].Position {
+			t.Fatalf("positions should be strictly increasing; got %d after %d", all[i].Position, all[i-1].Position)
+		}
+	}
+}
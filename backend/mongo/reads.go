@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// ReadPreference returns an EventStoreOption that specifies the read
+// preference of queries, e.g. to direct projection catch-up queries to
+// secondaries and reduce the load on the primary:
+//
+//	store := mongo.NewEventStore(enc, mongo.ReadPreference(readpref.SecondaryPreferred()))
+//
+// The read preference applies to Find, Query, QueryPipeline and Stats. Inserts,
+// and the reads that validate aggregate versions during inserts, always use
+// the primary. Queries that are directed to secondaries may not return the
+// latest events; combine the option with ReadConcern to control the
+// consistency of queries.
+func ReadPreference(rp *readpref.ReadPref) EventStoreOption {
+	return func(s *EventStore) {
+		s.readPref = rp
+	}
+}
+
+// ReadConcern returns an EventStoreOption that specifies the read concern of
+// queries. Like ReadPreference, the read concern applies to Find, Query,
+// QueryPipeline and Stats:
+//
+//	store := mongo.NewEventStore(enc, mongo.ReadConcern(readconcern.Majority()))
+func ReadConcern(rc *readconcern.ReadConcern) EventStoreOption {
+	return func(s *EventStore) {
+		s.readConcern = rc
+	}
+}
+
+// readOptions returns the collection options of the collection that is used
+// for queries.
+func (s *EventStore) readOptions() *options.CollectionOptions {
+	opts := options.Collection()
+	if s.readPref != nil {
+		opts.SetReadPreference(s.readPref)
+	}
+	if s.readConcern != nil {
+		opts.SetReadConcern(s.readConcern)
+	}
+	if s.uuidRegistry != nil {
+		opts.SetRegistry(s.uuidRegistry)
+	}
+	return opts
+}
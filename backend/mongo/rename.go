@@ -0,0 +1,113 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RenameAggregate renames the aggregate with the given id from one aggregate
+// name to another and returns the number of renamed events. The events,
+// the aggregate state and the compaction marker of the aggregate are updated
+// in place. If transactions are enabled, they are updated in a single
+// transaction. If the aggregate already has a state under the new name, e.g.
+// because events were inserted through a rename.Alias, the higher version of
+// both states is kept.
+//
+// RenameAggregate implements rename.Renamer. Use rename.Migrate to rename all
+// aggregates of a type. If the store is sharded by aggregate name, events
+// cannot be moved between shard key values by a multi-document update, so
+// RenameAggregate fails with errors.ErrUnsupported.
+func (s *EventStore) RenameAggregate(ctx context.Context, id uuid.UUID, from, to string) (int, error) {
+	if s.isTransactionStore {
+		return s.root.RenameAggregate(ctx, id, from, to)
+	}
+
+	if s.sharded && contains(s.shardKey, "aggregateName") {
+		return 0, fmt.Errorf("rename aggregate: %q is part of the shard key: %w", "aggregateName", errors.ErrUnsupported)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	var renamed int
+	if err := s.retry(ctx, func() (err error) {
+		renamed, err = s.renameAggregate(ctx, id, from, to)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return renamed, nil
+}
+
+func (s *EventStore) renameAggregate(ctx context.Context, id uuid.UUID, from, to string) (int, error) {
+	tx, err := s.createTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Session().EndSession(ctx)
+
+	sessionCtx := mongo.NewSessionContext(ctx, tx.Session())
+
+	if s.transactions {
+		if err := sessionCtx.StartTransaction(s.transactionOptions()); err != nil {
+			return 0, fmt.Errorf("start transaction: %w", err)
+		}
+	}
+
+	res, err := s.entries.UpdateMany(sessionCtx, bson.D{
+		{Key: "aggregateName", Value: from},
+		{Key: "aggregateId", Value: id},
+	}, bson.D{{Key: "$set", Value: bson.D{{Key: "aggregateName", Value: to}}}})
+	if err != nil {
+		return 0, s.abortTransaction(sessionCtx, fmt.Errorf("rename events: %w", err))
+	}
+
+	var st state
+	if err := s.states.FindOneAndDelete(sessionCtx, bson.D{
+		{Key: "aggregateName", Value: from},
+		{Key: "aggregateId", Value: id},
+	}).Decode(&st); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, s.abortTransaction(sessionCtx, fmt.Errorf("delete aggregate state: %w", err))
+	} else if err == nil {
+		if _, err := s.states.UpdateOne(sessionCtx, bson.D{
+			{Key: "aggregateName", Value: to},
+			{Key: "aggregateId", Value: id},
+		}, bson.D{
+			{Key: "$max", Value: bson.D{{Key: "version", Value: st.Version}}},
+		}, options.Update().SetUpsert(true)); err != nil {
+			return 0, s.abortTransaction(sessionCtx, fmt.Errorf("rename aggregate state: %w", err))
+		}
+	}
+
+	var marker compaction
+	if err := s.compactions.FindOneAndDelete(sessionCtx, bson.D{
+		{Key: "_id", Value: compactionID{AggregateName: from, AggregateID: id}},
+	}).Decode(&marker); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, s.abortTransaction(sessionCtx, fmt.Errorf("delete compaction marker: %w", err))
+	} else if err == nil {
+		if _, err := s.compactions.UpdateOne(sessionCtx, bson.D{
+			{Key: "_id", Value: compactionID{AggregateName: to, AggregateID: id}},
+		}, bson.D{
+			{Key: "$max", Value: bson.D{{Key: "version", Value: marker.Version}}},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "time", Value: marker.Time}}},
+		}, options.Update().SetUpsert(true)); err != nil {
+			return 0, s.abortTransaction(sessionCtx, fmt.Errorf("rename compaction marker: %w", err))
+		}
+	}
+
+	if s.transactions {
+		if err := s.commitTransaction(sessionCtx); err != nil {
+			return 0, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+
+	return int(res.ModifiedCount), nil
+}
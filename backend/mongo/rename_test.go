@@ -0,0 +1,55 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/aggregate/rename"
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_RenameAggregate(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	id := uuid.New()
+	if err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "old", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "old", 2)),
+	); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	report, err := rename.Migrate(ctx, store, "old", "new")
+	if err != nil {
+		t.Fatalf("Migrate() failed with %q", err)
+	}
+
+	if report.Aggregates != 1 || report.Events != 2 {
+		t.Fatalf("Migrate() should rename %d aggregate with %d events; got %+v", 1, 2, report)
+	}
+
+	if n := queryCount(t, store, query.New(query.Aggregate("new", id))); n != 2 {
+		t.Fatalf("Query() should return %d renamed events; got %d", 2, n)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "new", 2)).Any()); err == nil {
+		t.Fatalf("Insert() should fail for an existing version of the renamed aggregate")
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "new", 3)).Any()); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+}
@@ -0,0 +1,335 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MissingState is reported for aggregates that have events but no
+	// document in the state collection.
+	MissingState = StateProblem("missing_state")
+
+	// VersionMismatch is reported for aggregates whose state document holds a
+	// version that differs from the highest version in their event stream.
+	VersionMismatch = StateProblem("version_mismatch")
+
+	// OrphanedState is reported for state documents of aggregates that have
+	// no events at all.
+	OrphanedState = StateProblem("orphaned_state")
+)
+
+// StateProblem describes the kind of inconsistency between an aggregate's
+// state document and its event stream.
+type StateProblem string
+
+// StateRepair is a single inconsistency that was detected by
+// [EventStore.Repair]. StoredVersion is the version of the state document (0
+// if the document is missing), ActualVersion is the highest version in the
+// event stream (0 if there are no events). Repaired reports whether the state
+// document was fixed; it is false for dry runs.
+type StateRepair struct {
+	AggregateName string
+	AggregateID   uuid.UUID
+	Problem       StateProblem
+	StoredVersion int
+	ActualVersion int
+	Repaired      bool
+}
+
+// RepairReport is returned by [EventStore.Repair]. Checked is the number of
+// distinct aggregates that were inspected.
+type RepairReport struct {
+	Checked int
+	Repairs []StateRepair
+}
+
+// RepairOption is an option for [EventStore.Repair].
+type RepairOption func(*repairConfig)
+
+type repairConfig struct {
+	dryRun     bool
+	aggregates map[string]bool
+}
+
+// RepairDryRun returns a RepairOption that only detects inconsistencies
+// without modifying the state collection.
+func RepairDryRun() RepairOption {
+	return func(cfg *repairConfig) {
+		cfg.dryRun = true
+	}
+}
+
+// RepairAggregates returns a RepairOption that restricts the repair to the
+// given aggregate names.
+func RepairAggregates(names ...string) RepairOption {
+	return func(cfg *repairConfig) {
+		if cfg.aggregates == nil {
+			cfg.aggregates = make(map[string]bool)
+		}
+		for _, name := range names {
+			cfg.aggregates[name] = true
+		}
+	}
+}
+
+type stateKey struct {
+	name string
+	id   uuid.UUID
+}
+
+// Repair detects aggregates whose state document disagrees with their event
+// stream and fixes the state collection accordingly. Missing state documents
+// are created, wrong versions are corrected, and state documents of
+// aggregates without events are removed. Each aggregate is repaired
+// separately; if transactions are enabled (see [Transactions]), the event
+// stream is re-read and the state is written within a single transaction, so
+// that concurrent inserts cannot be overwritten with a stale version.
+//
+// The returned report lists every detected inconsistency, even if the repair
+// fails part-way through.
+func (s *EventStore) Repair(ctx context.Context, opts ...RepairOption) (RepairReport, error) {
+	var cfg repairConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if s.isTransactionStore {
+		return RepairReport{}, errors.New("repair cannot be run within a transaction")
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return RepairReport{}, fmt.Errorf("connect: %w", err)
+	}
+
+	actual, err := s.streamVersions(ctx, cfg)
+	if err != nil {
+		return RepairReport{}, fmt.Errorf("read event streams: %w", err)
+	}
+
+	stored, err := s.storedVersions(ctx, cfg)
+	if err != nil {
+		return RepairReport{}, fmt.Errorf("read aggregate states: %w", err)
+	}
+
+	var report RepairReport
+	checked := make(map[stateKey]bool, len(actual))
+
+	for key, version := range actual {
+		checked[key] = true
+		storedVersion, ok := stored[key]
+		switch {
+		case !ok:
+			report.Repairs = append(report.Repairs, StateRepair{
+				AggregateName: key.name,
+				AggregateID:   key.id,
+				Problem:       MissingState,
+				ActualVersion: version,
+			})
+		case storedVersion != version:
+			report.Repairs = append(report.Repairs, StateRepair{
+				AggregateName: key.name,
+				AggregateID:   key.id,
+				Problem:       VersionMismatch,
+				StoredVersion: storedVersion,
+				ActualVersion: version,
+			})
+		}
+	}
+
+	for key, version := range stored {
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+		report.Repairs = append(report.Repairs, StateRepair{
+			AggregateName: key.name,
+			AggregateID:   key.id,
+			Problem:       OrphanedState,
+			StoredVersion: version,
+		})
+	}
+
+	report.Checked = len(checked)
+
+	sort.Slice(report.Repairs, func(i, j int) bool {
+		a, b := report.Repairs[i], report.Repairs[j]
+		if a.AggregateName != b.AggregateName {
+			return a.AggregateName < b.AggregateName
+		}
+		return a.AggregateID.String() < b.AggregateID.String()
+	})
+
+	if cfg.dryRun {
+		return report, nil
+	}
+
+	for i, r := range report.Repairs {
+		if err := s.repairState(ctx, r); err != nil {
+			return report, fmt.Errorf("repair %s(%s): %w", r.AggregateName, r.AggregateID, err)
+		}
+		report.Repairs[i].Repaired = true
+	}
+
+	return report, nil
+}
+
+func (s *EventStore) streamVersions(ctx context.Context, cfg repairConfig) (map[stateKey]int, error) {
+	nameFilter := bson.D{{Key: "$ne", Value: ""}}
+	if len(cfg.aggregates) > 0 {
+		nameFilter = append(nameFilter, bson.E{Key: "$in", Value: aggregateNames(cfg)})
+	}
+	match := bson.D{
+		{Key: "aggregateName", Value: nameFilter},
+		{Key: "aggregateId", Value: bson.D{{Key: "$ne", Value: uuid.Nil}}},
+	}
+
+	cur, err := s.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "aggregateName", Value: "$aggregateName"},
+				{Key: "aggregateId", Value: "$aggregateId"},
+			}},
+			{Key: "version", Value: bson.D{{Key: "$max", Value: "$aggregateVersion"}}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	out := make(map[stateKey]int)
+	for cur.Next(ctx) {
+		var doc struct {
+			ID struct {
+				AggregateName string    `bson:"aggregateName"`
+				AggregateID   uuid.UUID `bson:"aggregateId"`
+			} `bson:"_id"`
+			Version int `bson:"version"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode document: %w", err)
+		}
+		out[stateKey{name: doc.ID.AggregateName, id: doc.ID.AggregateID}] = doc.Version
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("cursor: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *EventStore) storedVersions(ctx context.Context, cfg repairConfig) (map[stateKey]int, error) {
+	filter := bson.D{}
+	if len(cfg.aggregates) > 0 {
+		filter = bson.D{{Key: "aggregateName", Value: bson.D{{Key: "$in", Value: aggregateNames(cfg)}}}}
+	}
+
+	cur, err := s.states.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	out := make(map[stateKey]int)
+	for cur.Next(ctx) {
+		var st state
+		if err := cur.Decode(&st); err != nil {
+			return nil, fmt.Errorf("decode state: %w", err)
+		}
+		out[stateKey{name: st.AggregateName, id: st.AggregageID}] = st.Version
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("cursor: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *EventStore) repairState(ctx context.Context, r StateRepair) error {
+	tx, err := s.createTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Session().EndSession(ctx)
+
+	sessionCtx := mongo.NewSessionContext(ctx, tx.Session())
+
+	if s.transactions {
+		if err := sessionCtx.StartTransaction(); err != nil {
+			return fmt.Errorf("start transaction: %w", err)
+		}
+	}
+
+	version, err := s.currentStreamVersion(sessionCtx, r.AggregateName, r.AggregateID)
+	if err != nil {
+		return s.abortTransaction(sessionCtx, fmt.Errorf("read event stream: %w", err))
+	}
+
+	filter := bson.D{
+		{Key: "aggregateName", Value: r.AggregateName},
+		{Key: "aggregateId", Value: r.AggregateID},
+	}
+
+	if version == 0 {
+		if _, err := s.states.DeleteOne(sessionCtx, filter); err != nil {
+			return s.abortTransaction(sessionCtx, fmt.Errorf("delete aggregate state: %w", err))
+		}
+	} else if _, err := s.states.ReplaceOne(
+		sessionCtx,
+		filter,
+		state{AggregateName: r.AggregateName, AggregageID: r.AggregateID, Version: version},
+		options.Replace().SetUpsert(true),
+	); err != nil {
+		return s.abortTransaction(sessionCtx, fmt.Errorf("update aggregate state: %w", err))
+	}
+
+	if s.transactions {
+		if err := sessionCtx.CommitTransaction(ctx); err != nil {
+			return fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *EventStore) currentStreamVersion(ctx mongo.SessionContext, name string, id uuid.UUID) (int, error) {
+	res := s.entries.FindOne(
+		ctx,
+		bson.D{
+			{Key: "aggregateName", Value: name},
+			{Key: "aggregateId", Value: id},
+		},
+		options.FindOne().
+			SetSort(bson.D{{Key: "aggregateVersion", Value: -1}}).
+			SetProjection(bson.D{{Key: "aggregateVersion", Value: 1}}),
+	)
+
+	var e entry
+	if err := res.Decode(&e); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("decode document: %w", err)
+	}
+
+	return e.AggregateVersion, nil
+}
+
+func aggregateNames(cfg repairConfig) []string {
+	names := make([]string, 0, len(cfg.aggregates))
+	for name := range cfg.aggregates {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,112 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_Repair(t *testing.T) {
+	ctx := context.Background()
+	s := mongo.NewEventStore(etest.NewEncoder(), mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(nextEventDatabase()))
+
+	if _, err := s.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect to mongodb: %v", err)
+	}
+
+	healthyID, missingID, wrongID, orphanID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	for _, id := range []uuid.UUID{healthyID, missingID, wrongID} {
+		if err := s.Insert(ctx,
+			event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1)),
+			event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2)),
+		); err != nil {
+			t.Fatalf("Insert failed with %q", err)
+		}
+	}
+
+	states := s.StateCollection()
+	if _, err := states.DeleteOne(ctx, bson.M{"aggregateName": "foo", "aggregateId": missingID}); err != nil {
+		t.Fatalf("failed to delete state: %v", err)
+	}
+	if _, err := states.UpdateOne(ctx, bson.M{"aggregateName": "foo", "aggregateId": wrongID}, bson.M{"$set": bson.M{"version": 7}}); err != nil {
+		t.Fatalf("failed to update state: %v", err)
+	}
+	if _, err := states.InsertOne(ctx, bson.M{"aggregateName": "foo", "aggregateId": orphanID, "version": 3}); err != nil {
+		t.Fatalf("failed to insert state: %v", err)
+	}
+
+	report, err := s.Repair(ctx, mongo.RepairDryRun())
+	if err != nil {
+		t.Fatalf("Repair failed with %q", err)
+	}
+
+	if report.Checked != 4 {
+		t.Errorf("report should have checked %d aggregates; got %d", 4, report.Checked)
+	}
+
+	want := map[uuid.UUID]mongo.StateRepair{
+		missingID: {AggregateName: "foo", AggregateID: missingID, Problem: mongo.MissingState, ActualVersion: 2},
+		wrongID:   {AggregateName: "foo", AggregateID: wrongID, Problem: mongo.VersionMismatch, StoredVersion: 7, ActualVersion: 2},
+		orphanID:  {AggregateName: "foo", AggregateID: orphanID, Problem: mongo.OrphanedState, StoredVersion: 3},
+	}
+
+	if len(report.Repairs) != len(want) {
+		t.Fatalf("report should contain %d repairs; got %d\n\n%v", len(want), len(report.Repairs), report.Repairs)
+	}
+
+	for _, r := range report.Repairs {
+		if r != want[r.AggregateID] {
+			t.Errorf("unexpected repair in dry run.\n\nwant: %+v\n\ngot: %+v", want[r.AggregateID], r)
+		}
+	}
+
+	if n, err := states.CountDocuments(ctx, bson.M{"aggregateId": orphanID}); err != nil || n != 1 {
+		t.Fatalf("dry run should not modify states; orphaned state count is %d (err=%v)", n, err)
+	}
+
+	report, err = s.Repair(ctx)
+	if err != nil {
+		t.Fatalf("Repair failed with %q", err)
+	}
+
+	for _, r := range report.Repairs {
+		if !r.Repaired {
+			t.Errorf("%s should be repaired", r.AggregateID)
+		}
+	}
+
+	for _, id := range []uuid.UUID{healthyID, missingID, wrongID} {
+		var st struct {
+			Version int `bson:"version"`
+		}
+		if err := states.FindOne(ctx, bson.M{"aggregateName": "foo", "aggregateId": id}).Decode(&st); err != nil {
+			t.Fatalf("failed to find state of %s: %v", id, err)
+		}
+		if st.Version != 2 {
+			t.Errorf("state of %s should have version %d; got %d", id, 2, st.Version)
+		}
+	}
+
+	if n, err := states.CountDocuments(ctx, bson.M{"aggregateId": orphanID}); err != nil || n != 0 {
+		t.Fatalf("orphaned state should be deleted; count is %d (err=%v)", n, err)
+	}
+
+	report, err = s.Repair(ctx)
+	if err != nil {
+		t.Fatalf("Repair failed with %q", err)
+	}
+
+	if len(report.Repairs) != 0 {
+		t.Errorf("repaired store should not report any repairs; got %v", report.Repairs)
+	}
+}
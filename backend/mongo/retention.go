@@ -0,0 +1,201 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// ExpiresAtIndex is the name of the TTL index that is created for the
+// retention policies of the event store.
+const ExpiresAtIndex = "goes_expires_at"
+
+// retention is a retention policy of the event store. A policy with neither
+// names nor aggregate names applies to all events.
+type retention struct {
+	ttl            stdtime.Duration
+	names          []string
+	aggregateNames []string
+}
+
+// TTL returns an EventStoreOption that expires the events with the given names
+// after the given duration, so that high-volume integration events or
+// telemetry do not grow the event collection forever. If no names are
+// provided, all events expire. TTL overrides previous TTL and AggregateTTL
+// options for the given events.
+//
+// The expiry of an event is computed from the time of the event and stored in
+// the "expiresAt" field when the event is inserted. Unless index creation is
+// disabled (see NoIndex), the store creates a TTL index on that field, so that
+// MongoDB deletes expired events in the background. Events that were inserted
+// before the policy was configured have no expiry; use Purge or RunPurge to
+// delete them.
+func TTL(ttl stdtime.Duration, names ...string) EventStoreOption {
+	return func(s *EventStore) {
+		s.retention = append(s.retention, retention{ttl: ttl, names: names})
+	}
+}
+
+// AggregateTTL returns an EventStoreOption that expires the events of the
+// aggregates with the given names after the given duration. AggregateTTL
+// overrides previous TTL and AggregateTTL options for the given events. See
+// TTL for details.
+//
+// Events of an aggregate expire individually. Only use AggregateTTL for
+// aggregates that can be rebuilt from their remaining events, or whose state
+// is kept in snapshots.
+func AggregateTTL(ttl stdtime.Duration, aggregateNames ...string) EventStoreOption {
+	return func(s *EventStore) {
+		if len(aggregateNames) == 0 {
+			return
+		}
+		s.retention = append(s.retention, retention{ttl: ttl, aggregateNames: aggregateNames})
+	}
+}
+
+func (r retention) applies(evt event.Event) bool {
+	if len(r.names) == 0 && len(r.aggregateNames) == 0 {
+		return true
+	}
+
+	for _, name := range r.names {
+		if name == evt.Name() {
+			return true
+		}
+	}
+
+	_, aggregateName, _ := evt.Aggregate()
+	for _, name := range r.aggregateNames {
+		if name == aggregateName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r retention) filter() bson.D {
+	switch {
+	case len(r.names) > 0:
+		return bson.D{{Key: "name", Value: bson.D{{Key: "$in", Value: r.names}}}}
+	case len(r.aggregateNames) > 0:
+		return bson.D{{Key: "aggregateName", Value: bson.D{{Key: "$in", Value: r.aggregateNames}}}}
+	default:
+		return bson.D{}
+	}
+}
+
+// expiresAt returns the expiry of the given event, or nil if the event does
+// not expire. The last policy that applies to the event wins.
+func (s *EventStore) expiresAt(evt event.Event) *stdtime.Time {
+	for i := len(s.retention) - 1; i >= 0; i-- {
+		r := s.retention[i]
+		if !r.applies(evt) {
+			continue
+		}
+
+		if r.ttl <= 0 {
+			return nil
+		}
+
+		t := evt.Time().Add(r.ttl)
+		return &t
+	}
+	return nil
+}
+
+func (s *EventStore) retentionIndexes() []mongo.IndexModel {
+	if len(s.retention) == 0 {
+		return nil
+	}
+
+	return []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetName(ExpiresAtIndex).SetExpireAfterSeconds(0),
+	}}
+}
+
+// Purge deletes the events that have expired according to the TTL and
+// AggregateTTL options of the store, and returns the number of deleted events.
+// In contrast to the TTL index, Purge also deletes expired events that were
+// inserted before the retention policies were configured. Purge does not
+// update the aggregate states of the store.
+func (s *EventStore) Purge(ctx context.Context) (int64, error) {
+	if s.isTransactionStore {
+		return s.root.Purge(ctx)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	now := xtime.Now()
+
+	var deleted int64
+	for i, r := range s.retention {
+		if r.ttl <= 0 {
+			continue
+		}
+
+		// Events that are matched by later policies are purged by those.
+		var overridden bson.A
+		for _, later := range s.retention[i+1:] {
+			overridden = append(overridden, later.filter())
+		}
+
+		filter := append(r.filter(), bson.E{
+			Key:   "timeNano",
+			Value: bson.D{{Key: "$lt", Value: now.Add(-r.ttl).UnixNano()}},
+		})
+		if len(overridden) > 0 {
+			filter = append(filter, bson.E{Key: "$nor", Value: overridden})
+		}
+
+		res, err := s.entries.DeleteMany(ctx, filter, options.Delete().SetCollation(s.collation))
+		if err != nil {
+			return deleted, fmt.Errorf("mongo: %w", err)
+		}
+		deleted += res.DeletedCount
+	}
+
+	return deleted, nil
+}
+
+// RunPurge calls Purge in the given interval until ctx is canceled. Errors are
+// sent to the returned channel, which is closed when ctx is canceled. The
+// first purge runs immediately.
+func (s *EventStore) RunPurge(ctx context.Context, interval stdtime.Duration) <-chan error {
+	errs := make(chan error)
+
+	go func() {
+		defer close(errs)
+
+		ticker := stdtime.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Purge(ctx); err != nil && ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- fmt.Errorf("purge expired events: %w", err):
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return errs
+}
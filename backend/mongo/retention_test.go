@@ -0,0 +1,127 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.TTL(time.Hour, "foo"),
+		mongo.AggregateTTL(2*time.Hour, "baz"),
+	)
+
+	now := time.Now()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now)),
+		event.New[any]("bar", etest.BarEventData{}, event.Time(now)),
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now), event.Aggregate(uuid.New(), "baz", 1)),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	want := []*time.Time{ptr(now.Add(time.Hour)), nil, ptr(now.Add(2 * time.Hour))}
+	for i, evt := range events {
+		var doc struct {
+			ExpiresAt *time.Time `bson:"expiresAt"`
+		}
+		if err := store.Collection().FindOne(ctx, bson.M{"id": evt.ID()}).Decode(&doc); err != nil {
+			t.Fatalf("FindOne() failed with %q", err)
+		}
+
+		if (want[i] == nil) != (doc.ExpiresAt == nil) {
+			t.Fatalf("expiresAt of event #%d should be %v; got %v", i, want[i], doc.ExpiresAt)
+		}
+
+		if want[i] != nil && !doc.ExpiresAt.Equal(want[i].Truncate(time.Millisecond)) {
+			t.Fatalf("expiresAt of event #%d should be %v; got %v", i, want[i], doc.ExpiresAt)
+		}
+	}
+
+	specs, err := store.Collection().Indexes().ListSpecifications(ctx)
+	if err != nil {
+		t.Fatalf("ListSpecifications() failed with %q", err)
+	}
+
+	var found bool
+	for _, spec := range specs {
+		if spec.Name == mongo.ExpiresAtIndex {
+			found = true
+			if spec.ExpireAfterSeconds == nil || *spec.ExpireAfterSeconds != 0 {
+				t.Fatalf("%q index should expire documents at their expiresAt time", spec.Name)
+			}
+		}
+	}
+
+	if !found {
+		t.Fatalf("store should create the %q index", mongo.ExpiresAtIndex)
+	}
+}
+
+func TestEventStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	db := nextEventDatabase()
+	url := os.Getenv("MONGOSTORE_URL")
+
+	old := time.Now().Add(-3 * time.Hour)
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.Time(old)),
+		event.New[any]("foo", etest.FooEventData{}),
+		event.New[any]("bar", etest.BarEventData{}, event.Time(old)),
+		event.New[any]("baz", etest.BazEventData{}, event.Time(old)),
+	}
+
+	// insert the events before the retention policies are configured
+	if err := mongo.NewEventStore(etest.NewEncoder(), mongo.URL(url), mongo.Database(db)).Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(url),
+		mongo.Database(db),
+		mongo.TTL(time.Hour),
+		mongo.TTL(0, "bar"),
+		mongo.TTL(24*time.Hour, "baz"),
+	)
+
+	deleted, err := store.Purge(ctx)
+	if err != nil {
+		t.Fatalf("Purge() failed with %q", err)
+	}
+
+	if deleted != 1 {
+		t.Fatalf("Purge() should delete 1 event; deleted %d", deleted)
+	}
+
+	if _, err := store.Find(ctx, events[0].ID()); err == nil {
+		t.Fatalf("expired event should be deleted")
+	}
+
+	for _, evt := range events[1:] {
+		if _, err := store.Find(ctx, evt.ID()); err != nil {
+			t.Fatalf("%q event should not be deleted; Find() failed with %q", evt.Name(), err)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
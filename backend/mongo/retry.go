@@ -0,0 +1,138 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+// RetryPolicy configures the retries of store operations that fail with a
+// transient error (see Retry).
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of an operation. A value
+	// <= 1 disables retries.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry. The delay doubles with
+	// every retry.
+	Backoff stdtime.Duration
+
+	// MaxBackoff limits the delay between two attempts. Zero means no limit.
+	MaxBackoff stdtime.Duration
+
+	// Retryable reports whether an operation that failed with the given error
+	// should be retried. Defaults to IsTransient.
+	Retryable func(error) bool
+}
+
+// Retry returns an EventStoreOption that retries store operations that fail
+// with a transient error, like network errors or errors that are caused by a
+// replica set election:
+//
+//	store := mongo.NewEventStore(enc, mongo.Retry(mongo.RetryPolicy{
+//		MaxAttempts: 5,
+//		Backoff:     100 * time.Millisecond,
+//		MaxBackoff:  2 * time.Second,
+//	}))
+//
+// The policy applies to Insert, Find, Delete, Query, QueryRaw, QueryAfter,
+// QueryPipeline, DeleteQuery and Stats. Queries are only retried until their
+// cursor is opened; errors that occur while the events are streamed are
+// reported in the error channel. Operations of transactional stores within
+// transaction hooks are never retried, because a failed operation aborts the
+// transaction.
+//
+// If the result of an insert is unknown, e.g. because the connection broke
+// before the acknowledgement was received, a retried insert of events that
+// were inserted by the failed attempt fails with a duplicate key or version
+// error. Retries are independent of the TransactionRetry option, which only
+// retries transient transaction errors.
+func Retry(p RetryPolicy) EventStoreOption {
+	return func(s *EventStore) {
+		s.retryPolicy = p
+	}
+}
+
+// IsTransient reports whether err is a transient error of MongoDB, i.e. a
+// network error, a timeout, an error with a retryable error label, or an
+// error that is caused by a change of the replica set primary. Version errors
+// are never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var versionError VersionError
+	if errors.As(err, &versionError) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var serverError mongo.ServerError
+	if !errors.As(err, &serverError) {
+		return false
+	}
+
+	if serverError.HasErrorLabel(driver.TransientTransactionError) || serverError.HasErrorLabel("RetryableWriteError") {
+		return true
+	}
+
+	for _, code := range transientCodes {
+		if serverError.HasErrorCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transientCodes are the codes of server errors that are caused by network
+// issues or a change of the replica set primary.
+var transientCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	262,   // ExceededTimeLimit
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// retryable, or the maximum number of attempts of the retry policy is
+// reached.
+func (s *EventStore) retry(ctx context.Context, fn func() error) error {
+	retryable := s.retryPolicy.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	backoff := s.retryPolicy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= s.retryPolicy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+
+		backoff *= 2
+		if s.retryPolicy.MaxBackoff > 0 && backoff > s.retryPolicy.MaxBackoff {
+			backoff = s.retryPolicy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/pick"
+)
+
+// DefaultShardKey is the default shard key of the event and state collections
+// in sharded mode.
+var DefaultShardKey = []string{"aggregateId"}
+
+// Sharded returns an EventStoreOption that configures the store for sharded
+// clusters. The event and state collections are sharded using a ranged shard
+// key that consists of the given fields. If no fields are provided,
+// DefaultShardKey is used:
+//
+//	store := mongo.NewEventStore(enc, mongo.Sharded())
+//	store := mongo.NewEventStore(enc, mongo.Sharded("aggregateName", "aggregateId"))
+//
+// The shard key may only consist of the "aggregateName" and "aggregateId"
+// fields, so that all events of an aggregate are stored on the same shard.
+// This allows the store to validate event versions per shard, without
+// multi-document transactions that span multiple shards:
+//
+//   - The events of an aggregate are protected by a unique index that is
+//     prefixed by the shard key, so that two events with the same aggregate
+//     version cannot be inserted concurrently.
+//   - The aggregate state is updated using a compare-and-set on its current
+//     version instead of an unconditional upsert.
+//
+// Unique indexes of sharded collections must be prefixed by the shard key, so
+// the uniqueness of event ids is not enforced by the database in sharded
+// mode. Events that do not belong to an aggregate are all stored in the same
+// chunk.
+//
+// When the store connects, it enables sharding for the database and shards
+// the event and state collections, if they are not sharded yet. This requires
+// a connection to a mongos router. Transactions can still be enabled; because
+// all events of an insert belong to the same aggregate, the transactions only
+// involve a single shard.
+func Sharded(fields ...string) EventStoreOption {
+	return func(s *EventStore) {
+		if len(fields) == 0 {
+			fields = DefaultShardKey
+		}
+		s.sharded = true
+		s.shardKey = fields
+	}
+}
+
+func (s *EventStore) validateShardKey() error {
+	seen := make(map[string]bool)
+	for _, field := range s.shardKey {
+		if field != "aggregateName" && field != "aggregateId" {
+			return fmt.Errorf("invalid shard key field %q: shard key may only consist of %q and %q", field, "aggregateName", "aggregateId")
+		}
+		if seen[field] {
+			return fmt.Errorf("duplicate shard key field %q", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// shardKeyDoc returns the shard key as a document, followed by the remaining
+// aggregate fields that are not part of the shard key.
+func (s *EventStore) shardKeyDoc(withAggregateFields bool) bson.D {
+	key := make(bson.D, 0, 2)
+	seen := make(map[string]bool)
+	for _, field := range s.shardKey {
+		key = append(key, bson.E{Key: field, Value: 1})
+		seen[field] = true
+	}
+
+	if withAggregateFields {
+		for _, field := range []string{"aggregateName", "aggregateId"} {
+			if !seen[field] {
+				key = append(key, bson.E{Key: field, Value: 1})
+			}
+		}
+	}
+
+	return key
+}
+
+// shardCollections enables sharding for the database and shards the event and
+// state collections.
+func (s *EventStore) shardCollections(ctx context.Context) error {
+	if err := s.validateShardKey(); err != nil {
+		return err
+	}
+
+	admin := s.client.Database("admin")
+
+	if err := admin.RunCommand(ctx, bson.D{{Key: "enableSharding", Value: s.dbname}}).Err(); err != nil && !alreadySharded(err) {
+		return fmt.Errorf("enable sharding for %q database: %w", s.dbname, err)
+	}
+
+	key := s.shardKeyDoc(false)
+	for _, col := range []string{s.entriesCol, s.statesCol} {
+		ns := s.dbname + "." + col
+		if err := admin.RunCommand(ctx, bson.D{
+			{Key: "shardCollection", Value: ns},
+			{Key: "key", Value: key},
+		}).Err(); err != nil && !alreadySharded(err) {
+			return fmt.Errorf("shard %q collection: %w", ns, err)
+		}
+	}
+
+	return nil
+}
+
+func alreadySharded(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Name == "AlreadyInitialized" || cmdErr.Code == 23)
+}
+
+// shardedIndexes returns the index models that replace the unique core
+// indexes in sharded mode.
+func (s *EventStore) shardedIndexes() (events, states []mongo.IndexModel) {
+	aggregateKey := s.shardKeyDoc(true)
+
+	versionKey := append(append(bson.D{}, aggregateKey...), bson.E{Key: "aggregateVersion", Value: 1})
+
+	events = []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("goes_id"),
+		},
+		{
+			Keys: versionKey,
+			Options: options.Index().SetName("goes_sharded_aggregate_version").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{
+					{Key: "aggregateName", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$gt", Value: ""}}},
+					{Key: "aggregateId", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$gt", Value: uuid.UUID{}}}},
+					{Key: "aggregateVersion", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$gt", Value: 0}}},
+				}),
+		},
+	}
+
+	states = []mongo.IndexModel{{
+		Keys:    aggregateKey,
+		Options: options.Index().SetName("goes_sharded_aggregate").SetUnique(true),
+	}}
+
+	return
+}
+
+// withoutIndexes returns the index models without the models with the given
+// names. The unique core indexes are replaced by indexes that are prefixed by
+// the shard key in sharded mode.
+func withoutIndexes(models []mongo.IndexModel, names ...string) []mongo.IndexModel {
+	out := make([]mongo.IndexModel, 0, len(models))
+	for _, model := range models {
+		if model.Options != nil && model.Options.Name != nil && contains(names, *model.Options.Name) {
+			continue
+		}
+		out = append(out, model)
+	}
+	return out
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compareAndSetState updates the version of an aggregate state only if the
+// state still has the version that was read before inserting the events. If
+// another insert has changed the state in the meantime, the update falls back
+// to an upsert that violates the unique state index, and a VersionError is
+// returned.
+func (s *EventStore) compareAndSetState(ctx mongo.SessionContext, st state, events []event.Event) error {
+	if len(events) == 0 || st.AggregateName == "" || st.AggregageID == uuid.Nil {
+		return nil
+	}
+
+	filter := bson.D{
+		{Key: "aggregateName", Value: st.AggregateName},
+		{Key: "aggregateId", Value: st.AggregageID},
+	}
+	if st.Version > 0 {
+		filter = append(filter, bson.E{Key: "version", Value: st.Version})
+	} else {
+		filter = append(filter, bson.E{Key: "version", Value: bson.D{{Key: "$exists", Value: false}}})
+	}
+
+	next := st
+	next.Version = pick.AggregateVersion(events[len(events)-1])
+
+	if _, err := s.states.ReplaceOne(ctx, filter, next, options.Replace().SetUpsert(true)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return VersionError{
+				AggregateName:  st.AggregateName,
+				AggregateID:    st.AggregageID,
+				CurrentVersion: st.Version,
+				Event:          events[0],
+				err:            fmt.Errorf("aggregate state was changed concurrently: %w", err),
+			}
+		}
+		return fmt.Errorf("mongo: %w", err)
+	}
+
+	return nil
+}
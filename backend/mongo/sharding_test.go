@@ -0,0 +1,58 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestSharded(t *testing.T) {
+	url := os.Getenv("MONGOSHARDED_URL")
+	if url == "" {
+		t.Skip("MONGOSHARDED_URL is not set")
+	}
+
+	ctx := context.Background()
+	store := mongo.NewEventStore(etest.NewEncoder(), mongo.URL(url), mongo.Database(nextEventDatabase()), mongo.Sharded())
+
+	id := uuid.New()
+	if err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2)),
+	); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2)).Any())
+
+	var versionErr mongo.VersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Insert() should fail with a VersionError; got %v", err)
+	}
+
+	if versionErr.CurrentVersion != 2 {
+		t.Fatalf("current version should be %d; got %d", 2, versionErr.CurrentVersion)
+	}
+}
+
+func TestSharded_invalidShardKey(t *testing.T) {
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.Sharded("name"),
+	)
+
+	if _, err := store.Connect(context.Background()); err == nil {
+		t.Fatalf("Connect() should fail with an invalid shard key")
+	}
+}
@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// SoftDelete returns an EventStoreOption that enables soft deletes. When soft
+// deletes are enabled, Delete does not remove the events from the database,
+// but marks them as deleted by setting the "deletedAt" field of their
+// documents. Deleted events are excluded from Find, Query, QueryRaw,
+// QueryAfter, DeleteQuery and Stats, unless a query uses the
+// query.IncludeDeleted option. Deleted events can be restored using Restore:
+//
+//	store := mongo.NewEventStore(enc, mongo.SoftDelete(true))
+//
+//	// query deleted events for an audit
+//	str, errs, err := store.Query(ctx, query.New(query.IncludeDeleted()))
+//
+// Soft-deleted events keep their offloaded data and still count towards the
+// versions of their aggregates, so that deleted aggregates cannot be
+// recreated with conflicting versions. Pipelines of QueryPipeline are not
+// modified and must exclude deleted events themselves. Use DeleteQuery to
+// permanently remove events.
+func SoftDelete(soft bool) EventStoreOption {
+	return func(s *EventStore) {
+		s.softDelete = soft
+	}
+}
+
+// Restore restores the given soft-deleted events. Events that are not
+// deleted are ignored.
+func (s *EventStore) Restore(ctx context.Context, ids ...uuid.UUID) error {
+	if s.isTransactionStore {
+		return s.root.Restore(ctx, ids...)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	if _, err := s.entries.UpdateMany(ctx, bson.D{
+		{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}},
+	}, bson.D{
+		{Key: "$unset", Value: bson.D{{Key: "deletedAt", Value: ""}}},
+	}); err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+
+	return nil
+}
+
+// softDeleteInSession marks the given events as deleted.
+func (s *EventStore) softDeleteInSession(ctx mongo.SessionContext, ids []uuid.UUID) error {
+	if _, err := s.entries.UpdateMany(ctx, bson.D{
+		{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}},
+	}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: xtime.Now()}}},
+	}); err != nil {
+		return s.abortTransaction(ctx, err)
+	}
+	return nil
+}
+
+// filter returns the filter for the given query, which excludes the events of
+// pending imports, and soft-deleted events unless the query includes them.
+func (s *EventStore) filter(q event.Query) bson.D {
+	return excludeImported(s.excludeDeleted(makeFilter(q), event.IncludesDeleted(q)))
+}
+
+// excludeDeleted adds the exclusion of soft-deleted events to the given
+// filter if soft deletes are enabled.
+func (s *EventStore) excludeDeleted(filter bson.D, include bool) bson.D {
+	if !s.softDelete || include {
+		return filter
+	}
+	return append(filter, bson.E{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}})
+}
@@ -0,0 +1,94 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.SoftDelete(true),
+	)
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := store.Delete(ctx, events[1]); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+
+	var doc bson.M
+	if err := store.Collection().FindOne(ctx, bson.D{{Key: "id", Value: events[1].ID()}}).Decode(&doc); err != nil {
+		t.Fatalf("soft-deleted event should remain in the database: %v", err)
+	}
+	if _, ok := doc["deletedAt"]; !ok {
+		t.Fatalf("soft-deleted event should have a %q field", "deletedAt")
+	}
+
+	if _, err := store.Find(ctx, events[1].ID()); err == nil {
+		t.Fatalf("Find() should not find a soft-deleted event")
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id))); n != 1 {
+		t.Fatalf("Query() should return %d event; got %d", 1, n)
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id), query.IncludeDeleted())); n != 2 {
+		t.Fatalf("Query() should return %d events with query.IncludeDeleted(); got %d", 2, n)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)).Any()); err == nil {
+		t.Fatalf("Insert() should fail for the version of a soft-deleted event")
+	}
+
+	if err := store.Restore(ctx, events[1].ID()); err != nil {
+		t.Fatalf("Restore() failed with %q", err)
+	}
+
+	if _, err := store.Find(ctx, events[1].ID()); err != nil {
+		t.Fatalf("Find() should find a restored event; failed with %q", err)
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id))); n != 2 {
+		t.Fatalf("Query() should return %d events after Restore(); got %d", 2, n)
+	}
+}
+
+func queryCount(t *testing.T, store *mongo.EventStore, q event.Query) int {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	return len(events)
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/modernice/goes/event"
+)
+
+// Stats returns statistics about the events in the store. Event counts and
+// times are computed by a single aggregation over the event collection, which
+// is covered by the event name index. The number of aggregates is read from
+// the state collection instead of scanning the event streams; use
+// [EventStore.Repair] if the state collection may be out of sync.
+func (s *EventStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	if err := s.connectOnce(ctx); err != nil {
+		return event.StoreStats{}, fmt.Errorf("connect: %w", err)
+	}
+
+	var out event.StoreStats
+	err := s.retry(ctx, func() (err error) {
+		out, err = s.stats(ctx)
+		return err
+	})
+
+	return out, err
+}
+
+func (s *EventStore) stats(ctx context.Context) (event.StoreStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: excludeImported(s.excludeDeleted(bson.D{}, false))}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$name"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "oldest", Value: bson.D{{Key: "$min", Value: "$timeNano"}}},
+			{Key: "newest", Value: bson.D{{Key: "$max", Value: "$timeNano"}}},
+		}}},
+	}
+
+	cur, err := s.reads.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetCollation(s.collation))
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("aggregate events: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	out := event.StoreStats{EventsPerName: make(map[string]int)}
+	for cur.Next(ctx) {
+		var doc struct {
+			Name   string `bson:"_id"`
+			Count  int    `bson:"count"`
+			Oldest int64  `bson:"oldest"`
+			Newest int64  `bson:"newest"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return event.StoreStats{}, fmt.Errorf("decode document: %w", err)
+		}
+		out = out.Merge(event.StoreStats{
+			Events:        doc.Count,
+			EventsPerName: map[string]int{doc.Name: doc.Count},
+			Oldest:        stdtime.Unix(0, doc.Oldest),
+			Newest:        stdtime.Unix(0, doc.Newest),
+		})
+	}
+	if err := cur.Err(); err != nil {
+		return event.StoreStats{}, fmt.Errorf("cursor: %w", err)
+	}
+
+	aggregates, err := s.states.CountDocuments(ctx, bson.D{{Key: "version", Value: bson.D{{Key: "$gt", Value: 0}}}})
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("count aggregates: %w", err)
+	}
+	out.Aggregates = int(aggregates)
+
+	return out, nil
+}
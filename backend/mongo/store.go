@@ -11,11 +11,17 @@ import (
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	mongoevent "go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 	"go.mongodb.org/mongo-driver/x/mongo/driver"
 
-	"github.com/modernice/goes/backend/mongo/indices"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/query/time"
@@ -23,6 +29,10 @@ import (
 	"github.com/modernice/goes/helper/pick"
 )
 
+// DefaultInsertBatchSize is the default maximum number of events that are
+// inserted with a single InsertMany call.
+const DefaultInsertBatchSize = 1000
+
 const (
 	// PreInsert represents a hook that is executed before inserting events into the
 	// EventStore. This allows for additional operations to be performed within the
@@ -57,14 +67,49 @@ type EventStore struct {
 	noIndex           bool
 	transactions      bool
 	validateVersions  bool
+	insertBatchSize   int
+	findBatchSize     int32
+	defaultSort       []event.SortOptions
+	nativeData        bool
+	retention         []retention
+	sharded           bool
+	shardKey          []string
+	migrationsCol     string
+	countersCol       string
+	compactionsCol    string
+	importsCol        string
+	positions         bool
+	collation         *options.Collation
+	readPref          *readpref.ReadPref
+	readConcern       *readconcern.ReadConcern
+	writeConcern      *writeconcern.WriteConcern
+	maxCommitTime     *stdtime.Duration
+	causalConsistency *bool
+	txRetryAttempts   int
+	txRetryBackoff    stdtime.Duration
+	retryPolicy       RetryPolicy
+	uuidFormat        UUIDFormat
+	uuidRegistry      *bsoncodec.Registry
+	offloadThreshold  int
+	blobs             BlobStore
+	coreIndices       []mongo.IndexModel
+	customCoreIndices bool
 	additionalIndices []mongo.IndexModel
+	softDelete        bool
 	preInsertHooks    []func(TransactionContext) error
 	postInsertHooks   []func(TransactionContext) error
-
-	client  *mongo.Client
-	db      *mongo.Database
-	entries *mongo.Collection
-	states  *mongo.Collection
+	metrics           Metrics
+	monitor           *mongoevent.CommandMonitor
+	diagnostics       func(QueryDiagnostic)
+
+	client      *mongo.Client
+	db          *mongo.Database
+	entries     *mongo.Collection
+	states      *mongo.Collection
+	reads       *mongo.Collection
+	counters    *mongo.Collection
+	compactions *mongo.Collection
+	imports     *mongo.Collection
 
 	isTransactionStore bool
 	tx                 *transaction
@@ -152,14 +197,20 @@ type state struct {
 }
 
 type entry struct {
-	ID               uuid.UUID    `bson:"id"`
-	Name             string       `bson:"name"`
-	Time             stdtime.Time `bson:"time"`
-	TimeNano         int64        `bson:"timeNano"`
-	AggregateName    string       `bson:"aggregateName"`
-	AggregateID      uuid.UUID    `bson:"aggregateId"`
-	AggregateVersion int          `bson:"aggregateVersion"`
-	Data             []byte       `bson:"data"`
+	ID               uuid.UUID     `bson:"id"`
+	Name             string        `bson:"name"`
+	Time             stdtime.Time  `bson:"time"`
+	TimeNano         int64         `bson:"timeNano"`
+	AggregateName    string        `bson:"aggregateName"`
+	AggregateID      uuid.UUID     `bson:"aggregateId"`
+	AggregateVersion int           `bson:"aggregateVersion"`
+	Data             bson.RawValue `bson:"data"`
+	Offloaded        bool          `bson:"offloaded,omitempty"`
+	Blob             string        `bson:"blob,omitempty"`
+	Position         int64         `bson:"position,omitempty"`
+	ExpiresAt        *stdtime.Time `bson:"expiresAt,omitempty"`
+	Tags             []string      `bson:"tags,omitempty"`
+	ImportID         *uuid.UUID    `bson:"importId,omitempty"`
 }
 
 // URL returns an Option that specifies the URL to the MongoDB instance. An
@@ -229,8 +280,71 @@ func ValidateVersions(v bool) EventStoreOption {
 	}
 }
 
+// InsertBatchSize returns an Option that sets the maximum number of events that
+// are inserted with a single InsertMany call. Events that are inserted with a
+// single call to Insert are always inserted within the same transaction (if
+// transactions are enabled), regardless of the batch size. A batch size <= 0
+// inserts all events with a single InsertMany call.
+//
+// Defaults to DefaultInsertBatchSize.
+func InsertBatchSize(n int) EventStoreOption {
+	return func(s *EventStore) {
+		s.insertBatchSize = n
+	}
+}
+
+// FindBatchSize returns an Option that sets the number of events that are
+// fetched from MongoDB per round trip when querying events. Projections that
+// catch up on millions of events should use a batch size that balances memory
+// usage and round trips. A batch size <= 0 uses the default batch size of
+// MongoDB.
+func FindBatchSize(n int32) EventStoreOption {
+	return func(s *EventStore) {
+		s.findBatchSize = n
+	}
+}
+
+// DefaultSort returns an Option that sets the sortings of queries that do not
+// specify any sortings. Without a default sort, unsorted queries return events
+// in the natural order of MongoDB, which is not guaranteed to be stable.
+// Sorted queries are tie-broken by event id (see event.TieBreak), so events
+// with equal times are returned in the same order by every query.
+func DefaultSort(sorts ...event.SortOptions) EventStoreOption {
+	return func(s *EventStore) {
+		s.defaultSort = sorts
+	}
+}
+
+// NativeData returns an Option that stores the encoded event data as BSON
+// sub-documents instead of binary data, which makes the data queryable and
+// inspectable using MongoDB queries and tooling. The encoding of the store
+// must then encode event data as BSON documents, e.g. a registry that is
+// created by NewBSONRegistry:
+//
+//	reg := mongo.NewBSONRegistry()
+//	myapp.RegisterEvents(reg)
+//	store := mongo.NewEventStore(reg, mongo.NativeData(true))
+//
+// Inserting events whose encoded data is not a BSON document fails. Existing
+// events are not migrated; events that were stored as binary data are still
+// decoded using the encoding of the store.
+func NativeData(native bool) EventStoreOption {
+	return func(s *EventStore) {
+		s.nativeData = native
+	}
+}
+
+// NewBSONRegistry returns a codec.Registry that encodes event data as BSON
+// documents, to be used together with the NativeData option. Event data types
+// must be structs or maps, and can use "bson" struct tags to control their
+// encoding.
+func NewBSONRegistry(opts ...codec.Option) *codec.Registry {
+	return codec.New(append([]codec.Option{codec.Default(bson.Marshal, bson.Unmarshal)}, opts...)...)
+}
+
 // NoIndex returns an option to completely disable index creation when
-// connecting to the event bus.
+// connecting to the event store. Indexes can then be created explicitly using
+// s.MigrateIndexes.
 func NoIndex(ni bool) EventStoreOption {
 	return func(es *EventStore) {
 		es.noIndex = ni
@@ -325,6 +439,7 @@ func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
 	s := EventStore{
 		enc:              enc,
 		validateVersions: true,
+		insertBatchSize:  DefaultInsertBatchSize,
 	}
 	for _, opt := range opts {
 		opt(&s)
@@ -338,6 +453,22 @@ func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
 	if strings.TrimSpace(s.statesCol) == "" {
 		s.statesCol = "states"
 	}
+	if strings.TrimSpace(s.migrationsCol) == "" {
+		s.migrationsCol = DefaultMigrationCollection
+	}
+	if strings.TrimSpace(s.countersCol) == "" {
+		s.countersCol = DefaultCounterCollection
+	}
+	if strings.TrimSpace(s.compactionsCol) == "" {
+		s.compactionsCol = DefaultCompactionCollection
+	}
+	if strings.TrimSpace(s.importsCol) == "" {
+		s.importsCol = DefaultImportCollection
+	}
+	if s.positions && s.transactions && s.txRetryAttempts == 0 {
+		s.txRetryAttempts = DefaultPositionRetryAttempts
+		s.txRetryBackoff = DefaultPositionRetryBackoff
+	}
 	return &s
 }
 
@@ -373,7 +504,9 @@ func (s *EventStore) StateCollection() *mongo.Collection {
 	return s.states
 }
 
-// Insert saves the given events into the database.
+// Insert saves the given events into the database. If transactions are
+// enabled and the TransactionRetry option is used, inserts that fail with a
+// transient transaction error (e.g. a write conflict) are retried.
 func (s *EventStore) Insert(ctx context.Context, events ...event.Event) (out error) {
 	defer func() {
 		if out == nil {
@@ -391,10 +524,22 @@ func (s *EventStore) Insert(ctx context.Context, events ...event.Event) (out err
 		return s.txInsert(ctx, events)
 	}
 
+	if done := s.observer(OpInsert); done != nil {
+		defer func() { done(len(events), out) }()
+	}
+
 	if err := s.connectOnce(ctx); err != nil {
 		return fmt.Errorf("connect: %w", err)
 	}
 
+	return s.retry(ctx, func() error {
+		return s.retryTransaction(ctx, func() error {
+			return s.insertTransaction(ctx, events)
+		})
+	})
+}
+
+func (s *EventStore) insertTransaction(ctx context.Context, events []event.Event) error {
 	tx, err := s.createTransaction(ctx)
 	if err != nil {
 		return err
@@ -404,7 +549,7 @@ func (s *EventStore) Insert(ctx context.Context, events ...event.Event) (out err
 	sessionCtx := mongo.NewSessionContext(ctx, tx.Session())
 
 	if s.transactions {
-		if err := sessionCtx.StartTransaction(); err != nil {
+		if err := sessionCtx.StartTransaction(s.transactionOptions()); err != nil {
 			return fmt.Errorf("start transaction: %w", err)
 		}
 	}
@@ -416,27 +561,59 @@ func (s *EventStore) Insert(ctx context.Context, events ...event.Event) (out err
 		}
 	}
 
-	if err := s.insertInSession(sessionCtx, events); err != nil {
-		return err
+	blobs, err := s.insertInSession(sessionCtx, events)
+	if err != nil {
+		return s.discardFailedInsert(ctx, blobs, err)
 	}
 
 	tx.appendEvents(events)
 
 	for _, hook := range s.postInsertHooks {
 		if err := hook(txCtx); err != nil {
-			return s.abortTransaction(sessionCtx, fmt.Errorf("post-insert hook: %w", err))
+			return s.discardFailedInsert(ctx, blobs, s.abortTransaction(sessionCtx, fmt.Errorf("post-insert hook: %w", err)))
 		}
 	}
 
 	if s.transactions {
-		if err := sessionCtx.CommitTransaction(sessionCtx); err != nil {
-			return fmt.Errorf("commit transaction: %w", err)
+		if err := s.commitTransaction(sessionCtx); err != nil {
+			return s.discardFailedInsert(ctx, blobs, fmt.Errorf("commit transaction: %w", err))
 		}
 	}
 
 	return nil
 }
 
+// discardFailedInsert deletes the offloaded data of a failed insert that is
+// not referenced by an event document, and returns the error of the insert.
+func (s *EventStore) discardFailedInsert(ctx context.Context, blobs []string, err error) error {
+	if discardError := s.discardBlobs(ctx, blobs); discardError != nil {
+		return fmt.Errorf("%w (discard offloaded data: %v)", err, discardError)
+	}
+	return err
+}
+
+// InsertRaw inserts events whose data is already encoded into the database.
+// The encoded data is stored as-is, without using the encoder of the store.
+// Other than that, InsertRaw behaves like Insert: versions are validated and
+// transaction hooks are called. Within transaction hooks, the data of the
+// inserted events is the encoded data.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	evts := make([]event.Event, len(events))
+	for i, raw := range events {
+		evts[i] = event.New[any](
+			raw.Name,
+			encodedData(raw.Data),
+			event.ID(raw.ID),
+			event.Time(raw.Time),
+			event.Aggregate(raw.Aggregate.ID, raw.Aggregate.Name, raw.AggregateVersion),
+			event.Tag(raw.Tags...),
+		)
+	}
+	return s.Insert(ctx, evts...)
+}
+
 func (s *EventStore) txInsert(ctx context.Context, events []event.Event) error {
 	if err := s.root.connectOnce(ctx); err != nil {
 		return fmt.Errorf("connect: %w", err)
@@ -444,8 +621,8 @@ func (s *EventStore) txInsert(ctx context.Context, events []event.Event) error {
 
 	sessionCtx := mongo.NewSessionContext(ctx, s.tx.Session())
 
-	if err := s.root.insertInSession(sessionCtx, events); err != nil {
-		return err
+	if blobs, err := s.root.insertInSession(sessionCtx, events); err != nil {
+		return s.root.discardFailedInsert(ctx, blobs, err)
 	}
 
 	s.tx.appendEvents(events)
@@ -454,7 +631,7 @@ func (s *EventStore) txInsert(ctx context.Context, events []event.Event) error {
 }
 
 func (s *EventStore) createTransaction(ctx context.Context) (tx *transaction, err error) {
-	session, err := s.client.StartSession()
+	session, err := s.client.StartSession(s.sessionOptions())
 	if err != nil {
 		return nil, fmt.Errorf("start session: %w", err)
 	}
@@ -477,21 +654,38 @@ func (s *EventStore) abortTransaction(ctx mongo.SessionContext, err error) error
 	return err
 }
 
-func (s *EventStore) insertInSession(ctx mongo.SessionContext, events []event.Event) (out error) {
+// insertInSession inserts the given events and returns the keys of the data
+// that was offloaded, even if the insert fails.
+func (s *EventStore) insertInSession(ctx mongo.SessionContext, events []event.Event) ([]string, error) {
 	st, err := s.validateEventVersions(ctx, events)
 	if err != nil {
-		return s.abortTransaction(ctx, fmt.Errorf("validate versions: %w", err))
+		return nil, s.abortTransaction(ctx, fmt.Errorf("validate versions: %w", err))
 	}
 
-	if err := s.insert(ctx, events); err != nil {
-		return s.abortTransaction(ctx, err)
+	blobs, err := s.insert(ctx, events)
+	if err != nil {
+		if s.sharded && mongo.IsDuplicateKeyError(err) && st.AggregateName != "" {
+			err = VersionError{
+				AggregateName:  st.AggregateName,
+				AggregateID:    st.AggregageID,
+				CurrentVersion: st.Version,
+				Event:          events[0],
+				err:            err,
+			}
+		}
+		return blobs, s.abortTransaction(ctx, err)
 	}
 
-	if err := s.updateState(ctx, st, events); err != nil {
-		return s.abortTransaction(ctx, fmt.Errorf("update aggregate state: %w", err))
+	update := s.updateState
+	if s.sharded && s.validateVersions {
+		update = s.compareAndSetState
 	}
 
-	return nil
+	if err := update(ctx, st, events); err != nil {
+		return blobs, s.abortTransaction(ctx, fmt.Errorf("update aggregate state: %w", err))
+	}
+
+	return blobs, nil
 }
 
 func (s *EventStore) validateEventVersions(ctx mongo.SessionContext, events []event.Event) (state, error) {
@@ -556,58 +750,157 @@ func (s *EventStore) updateState(ctx mongo.SessionContext, st state, events []ev
 	return nil
 }
 
-func (s *EventStore) insert(ctx context.Context, events []event.Event) error {
-	if len(events) == 0 {
-		return nil
+func (s *EventStore) insert(ctx context.Context, events []event.Event) ([]string, error) {
+	size := s.insertBatchSize
+	if size <= 0 {
+		size = len(events)
+	}
+
+	var blobs []string
+	for len(events) > 0 {
+		n := size
+		if n > len(events) {
+			n = len(events)
+		}
+
+		batchBlobs, err := s.insertBatch(ctx, events[:n])
+		blobs = append(blobs, batchBlobs...)
+		if err != nil {
+			return blobs, err
+		}
+
+		events = events[n:]
 	}
 
+	return blobs, nil
+}
+
+func (s *EventStore) insertBatch(ctx context.Context, events []event.Event) ([]string, error) {
+	position, err := s.reservePositions(ctx, len(events))
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []string
 	docs := make([]any, len(events))
 	for i, evt := range events {
-		b, err := s.enc.Marshal(evt.Data())
+		e, err := s.newEntry(ctx, evt)
 		if err != nil {
-			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+			return blobs, err
 		}
-
-		id, name, v := evt.Aggregate()
-		docs[i] = entry{
-			ID:               evt.ID(),
-			Name:             evt.Name(),
-			Time:             evt.Time(),
-			TimeNano:         int64(evt.Time().UnixNano()),
-			AggregateName:    name,
-			AggregateID:      id,
-			AggregateVersion: v,
-			Data:             b,
+		if e.Blob != "" {
+			blobs = append(blobs, e.Blob)
 		}
+		if position > 0 {
+			e.Position = position + int64(i)
+		}
+		docs[i] = e
 	}
 	if _, err := s.entries.InsertMany(ctx, docs); err != nil {
-		return fmt.Errorf("mongo: %w", err)
+		return blobs, fmt.Errorf("mongo: %w", err)
 	}
-	return nil
+	return blobs, nil
 }
 
+// newEntry encodes the given event into an event document. The data of the
+// event is offloaded if it exceeds the offload threshold (see Offload).
+func (s *EventStore) newEntry(ctx context.Context, evt event.Event) (entry, error) {
+	b, err := s.marshal(ctx, evt)
+	if err != nil {
+		return entry{}, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	data, err := s.entryData(b)
+	if err != nil {
+		return entry{}, fmt.Errorf("%q event data: %w", evt.Name(), err)
+	}
+
+	blob, err := s.offload(ctx, evt.ID(), b)
+	if err != nil {
+		return entry{}, fmt.Errorf("%q event data: %w", evt.Name(), err)
+	}
+	if blob != "" {
+		data = bson.RawValue{Type: bsontype.Binary, Value: bsoncore.AppendBinary(nil, bsontype.BinaryGeneric, nil)}
+	}
+
+	id, name, v := evt.Aggregate()
+	return entry{
+		ID:               evt.ID(),
+		Name:             evt.Name(),
+		Time:             evt.Time(),
+		TimeNano:         int64(evt.Time().UnixNano()),
+		AggregateName:    name,
+		AggregateID:      id,
+		AggregateVersion: v,
+		Data:             data,
+		Offloaded:        blob != "",
+		Blob:             blob,
+		ExpiresAt:        s.expiresAt(evt),
+		Tags:             event.TagsOf(evt),
+	}, nil
+}
+
+func (s *EventStore) marshal(ctx context.Context, evt event.Event) ([]byte, error) {
+	if data, ok := evt.Data().(encodedData); ok {
+		return data, nil
+	}
+	return codec.MarshalContext(ctx, s.enc, evt.Data())
+}
+
+// entryData returns the encoded data as it is stored in the database: either
+// as binary data, or as a BSON document if the NativeData option is used.
+func (s *EventStore) entryData(b []byte) (bson.RawValue, error) {
+	if !s.nativeData {
+		return bson.RawValue{Type: bsontype.Binary, Value: bsoncore.AppendBinary(nil, bsontype.BinaryGeneric, b)}, nil
+	}
+
+	if err := bson.Raw(b).Validate(); err != nil {
+		return bson.RawValue{}, fmt.Errorf("not a BSON document (use an encoding that encodes BSON documents together with the NativeData option): %w", err)
+	}
+
+	return bson.RawValue{Type: bsontype.EmbeddedDocument, Value: b}, nil
+}
+
+// encodedData is the data of events that are inserted using InsertRaw.
+type encodedData []byte
+
 // Find returns the event with the specified UUID from the database if it exists.
-func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (_ event.Event, out error) {
 	if s.isTransactionStore {
 		return s.root.Find(ctx, id)
 	}
 
+	if done := s.observer(OpFind); done != nil {
+		defer func() {
+			var found int
+			if out == nil {
+				found = 1
+			}
+			done(found, out)
+		}()
+	}
+
 	if err := s.connectOnce(ctx); err != nil {
 		return nil, fmt.Errorf("connect: %w", err)
 	}
 
-	res := s.entries.FindOne(ctx, bson.M{"id": id})
-
 	var e entry
-	if err := res.Decode(&e); err != nil {
+	if err := s.retry(ctx, func() error {
+		return s.reads.FindOne(ctx, excludeImported(s.excludeDeleted(bson.D{{Key: "id", Value: id}}, false))).Decode(&e)
+	}); err != nil {
 		return nil, fmt.Errorf("decode document: %w", err)
 	}
 
-	return e.event(s.enc)
+	if err := s.loadData(ctx, &e); err != nil {
+		return nil, err
+	}
+
+	return e.event(ctx, s.enc)
 }
 
-// Delete deletes the given event from the database.
-func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+// Delete deletes the given event from the database. If soft deletes are
+// enabled, the events are marked as deleted instead (see SoftDelete).
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) (out error) {
 	if s.root != nil {
 		return s.txDelete(ctx, events)
 	}
@@ -616,10 +909,20 @@ func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
 		return nil
 	}
 
+	if done := s.observer(OpDelete); done != nil {
+		defer func() { done(len(events), out) }()
+	}
+
 	if err := s.connectOnce(ctx); err != nil {
 		return fmt.Errorf("connect: %w", err)
 	}
 
+	return s.retry(ctx, func() error {
+		return s.delete(ctx, events)
+	})
+}
+
+func (s *EventStore) delete(ctx context.Context, events []event.Event) error {
 	tx, err := s.createTransaction(ctx)
 	if err != nil {
 		return err
@@ -634,18 +937,25 @@ func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
 		}
 	}
 
+	ids := make([]uuid.UUID, len(events))
+	for i, evt := range events {
+		ids[i] = evt.ID()
+	}
+
+	var blobs []string
+	if !s.softDelete {
+		if blobs, err = s.offloadedEvents(sessionCtx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}}); err != nil {
+			return s.abortTransaction(sessionCtx, fmt.Errorf("find offloaded events: %w", err))
+		}
+	}
+
 	commit := func() error {
 		if s.transactions {
 			if err := sessionCtx.CommitTransaction(ctx); err != nil {
 				return fmt.Errorf("commit transaction: %w", err)
 			}
 		}
-		return nil
-	}
-
-	ids := make([]uuid.UUID, len(events))
-	for i, evt := range events {
-		ids[i] = evt.ID()
+		return s.deleteBlobs(ctx, blobs)
 	}
 
 	if err := s.deleteInSession(sessionCtx, ids); err != nil {
@@ -653,7 +963,7 @@ func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
 	}
 
 	name, id, version, hasAggregateData := checkDeletion(events)
-	if !hasAggregateData {
+	if !hasAggregateData || s.softDelete {
 		return commit()
 	}
 
@@ -669,6 +979,10 @@ func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
 }
 
 func (s *EventStore) deleteInSession(ctx mongo.SessionContext, ids []uuid.UUID) error {
+	if s.softDelete {
+		return s.softDeleteInSession(ctx, ids)
+	}
+
 	if _, err := s.entries.DeleteMany(ctx, bson.D{
 		{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}},
 	}); err != nil {
@@ -698,7 +1012,7 @@ func (s *EventStore) txDelete(ctx context.Context, events []event.Event) error {
 	}
 
 	name, id, version, hasAggregateData := checkDeletion(events)
-	if !hasAggregateData {
+	if !hasAggregateData || s.root.softDelete {
 		return nil
 	}
 
@@ -714,25 +1028,33 @@ func (s *EventStore) txDelete(ctx context.Context, events []event.Event) error {
 }
 
 // Query queries the database for events filtered by Query q and returns an
-// streams.New for those events.
+// streams.New for those events. The limit and offset of the query (see
+// event.Paging) are applied by the database.
 func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
 	if s.isTransactionStore {
 		return s.root.Query(ctx, q)
 	}
 
-	if err := s.connectOnce(ctx); err != nil {
-		return nil, nil, fmt.Errorf("connect: %w", err)
-	}
-	opts := options.Find().SetAllowDiskUse(true)
-	opts = applySortings(opts, q.Sortings()...)
+	done := s.observer(OpQuery)
 
-	f := makeFilter(q)
-
-	cur, err := s.entries.Find(ctx, f, opts)
+	cur, err := s.find(ctx, OpQuery, q)
 	if err != nil {
-		return nil, nil, fmt.Errorf("mongo: %w", err)
+		if done != nil {
+			done(0, err)
+		}
+		return nil, nil, err
 	}
 
+	events, errs := s.decodeCursor(ctx, cur, event.UndecodableHandler(q), done)
+
+	return events, errs, nil
+}
+
+// decodeCursor decodes the event documents of the cursor and returns them as
+// events. If skip is non-nil, events whose data cannot be decoded are passed
+// to skip instead of being reported as errors. If done is non-nil, it is
+// called with the number of decoded documents when the cursor is exhausted.
+func (s *EventStore) decodeCursor(ctx context.Context, cur *mongo.Cursor, skip func(*event.DecodeError), done func(int, error)) (<-chan event.Event, <-chan error) {
 	events := make(chan event.Event)
 	errs := make(chan error)
 
@@ -740,6 +1062,11 @@ func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Eve
 		defer close(events)
 		defer close(errs)
 
+		var n int
+		if done != nil {
+			defer func() { done(n, cur.Err()) }()
+		}
+
 	L:
 		for cur.Next(ctx) {
 			var e entry
@@ -751,8 +1078,21 @@ func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Eve
 				}
 				continue
 			}
-			evt, err := e.event(s.enc)
+			if err := s.loadData(ctx, &e); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+				continue
+			}
+			evt, err := e.event(ctx, s.enc)
 			if err != nil {
+				if skip != nil {
+					skip(e.decodeError(err))
+					continue
+				}
+
 				select {
 				case <-ctx.Done():
 					break L
@@ -764,6 +1104,75 @@ func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Eve
 			case <-ctx.Done():
 				return
 			case events <- evt:
+				n++
+			}
+		}
+
+		if err := cur.Err(); err != nil {
+			select {
+			case <-ctx.Done():
+			case errs <- fmt.Errorf("mongo cursor: %w", err):
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+// QueryRaw queries the database for events filtered by Query q, like Query
+// does, but returns the events without decoding their data.
+//
+// QueryRaw implements event.RawQuerier.
+func (s *EventStore) QueryRaw(ctx context.Context, q event.Query) (<-chan event.RawEvent, <-chan error, error) {
+	if s.isTransactionStore {
+		return s.root.QueryRaw(ctx, q)
+	}
+
+	done := s.observer(OpQueryRaw)
+
+	cur, err := s.find(ctx, OpQueryRaw, q)
+	if err != nil {
+		if done != nil {
+			done(0, err)
+		}
+		return nil, nil, err
+	}
+
+	events := make(chan event.RawEvent)
+	errs := make(chan error)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		var n int
+		if done != nil {
+			defer func() { done(n, cur.Err()) }()
+		}
+
+		for cur.Next(ctx) {
+			var e entry
+			if err := cur.Decode(&e); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+				continue
+			}
+			if err := s.loadData(ctx, &e); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case events <- e.raw():
+				n++
 			}
 		}
 
@@ -778,6 +1187,36 @@ func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Eve
 	return events, errs, nil
 }
 
+func (s *EventStore) find(ctx context.Context, op string, q event.Query) (*mongo.Cursor, error) {
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	opts := options.Find().SetAllowDiskUse(true).SetCollation(s.collation)
+	opts = applySortings(opts, s.sortings(q)...)
+
+	if s.findBatchSize > 0 {
+		opts = opts.SetBatchSize(s.findBatchSize)
+	}
+
+	if limit, offset := event.Paging(q); limit > 0 || offset > 0 {
+		opts = opts.SetLimit(int64(limit)).SetSkip(int64(offset))
+	}
+
+	f := s.filter(q)
+
+	s.explain(ctx, op, f, opts)
+
+	var cur *mongo.Cursor
+	if err := s.retry(ctx, func() (err error) {
+		cur, err = s.reads.Find(ctx, f, opts)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	return cur, nil
+}
+
 // Connect establishes the connection to the underlying MongoDB and returns the
 // mongo.Client. Connect doesn't need to be called manually as it's called
 // automatically on the first call to s.Insert, s.Find, s.Delete or s.Query. Use
@@ -801,12 +1240,23 @@ func (s *EventStore) connectOnce(ctx context.Context, opts ...*options.ClientOpt
 			return
 		}
 
+		if err = s.createEntriesCollection(ctx); err != nil {
+			return
+		}
+
+		if s.sharded {
+			if err = s.shardCollections(ctx); err != nil {
+				err = fmt.Errorf("shard collections: %w", err)
+				return
+			}
+		}
+
 		if s.noIndex {
 			return
 		}
 
-		if err = s.ensureIndexes(ctx); err != nil {
-			err = fmt.Errorf("ensure indexes: %w", err)
+		if err = s.migrateIndexes(ctx, false); err != nil {
+			err = fmt.Errorf("migrate indexes: %w", err)
 			return
 		}
 	})
@@ -819,8 +1269,12 @@ func (s *EventStore) connect(ctx context.Context, opts ...*options.ClientOptions
 		if uri == "" {
 			uri = os.Getenv("MONGO_URL")
 		}
+		clientOpts := options.Client().ApplyURI(uri)
+		if s.monitor != nil {
+			clientOpts.SetMonitor(s.monitor)
+		}
 		opts = append(
-			[]*options.ClientOptions{options.Client().ApplyURI(uri)},
+			[]*options.ClientOptions{clientOpts},
 			opts...,
 		)
 
@@ -831,18 +1285,21 @@ func (s *EventStore) connect(ctx context.Context, opts ...*options.ClientOptions
 		}
 	}
 	s.db = s.client.Database(s.dbname)
-	s.entries = s.db.Collection(s.entriesCol)
-	s.states = s.db.Collection(s.statesCol)
-	return nil
+	s.entries = s.db.Collection(s.entriesCol, s.writeOptions())
+	s.reads = s.db.Collection(s.entriesCol, s.readOptions())
+	s.states = s.db.Collection(s.statesCol, s.writeOptions())
+	s.counters = s.db.Collection(s.countersCol, s.writeOptions())
+	s.compactions = s.db.Collection(s.compactionsCol, s.writeOptions())
+	s.imports = s.db.Collection(s.importsCol, s.writeOptions())
+	return s.connectBlobs()
 }
 
-func (s *EventStore) ensureIndexes(ctx context.Context) error {
+func createIndexes(ctx context.Context, col *mongo.Collection, models []mongo.IndexModel) error {
 	type existingIndex struct {
 		Name string
 	}
 
-	models := append(indices.EventStoreCore(), s.additionalIndices...)
-	cur, err := s.entries.Indexes().List(ctx)
+	cur, err := col.Indexes().List(ctx)
 	if err != nil {
 		return fmt.Errorf("list existing indexes: %w", err)
 	}
@@ -869,7 +1326,7 @@ func (s *EventStore) ensureIndexes(ctx context.Context) error {
 			continue
 		}
 
-		if _, err := s.entries.Indexes().CreateOne(ctx, model); err != nil {
+		if _, err := col.Indexes().CreateOne(ctx, model); err != nil {
 			return fmt.Errorf("create %q index: %w", *model.Options.Name, err)
 		}
 	}
@@ -877,8 +1334,8 @@ func (s *EventStore) ensureIndexes(ctx context.Context) error {
 	return nil
 }
 
-func (e entry) event(enc codec.Encoding) (event.Event, error) {
-	data, err := enc.Unmarshal(e.Data, e.Name)
+func (e entry) event(ctx context.Context, enc codec.Encoding) (event.Event, error) {
+	data, err := codec.UnmarshalContext(ctx, enc, e.data(), e.Name)
 	if err != nil {
 		return nil, fmt.Errorf("decode %q event data: %w", e.Name, err)
 	}
@@ -888,9 +1345,38 @@ func (e entry) event(enc codec.Encoding) (event.Event, error) {
 		event.ID(e.ID),
 		event.Time(stdtime.Unix(0, e.TimeNano)),
 		event.Aggregate(e.AggregateID, e.AggregateName, e.AggregateVersion),
+		event.Tag(e.Tags...),
 	), nil
 }
 
+func (e entry) raw() event.RawEvent {
+	return event.RawEvent{
+		ID:               e.ID,
+		Name:             e.Name,
+		Time:             stdtime.Unix(0, e.TimeNano),
+		Aggregate:        event.AggregateRef{Name: e.AggregateName, ID: e.AggregateID},
+		AggregateVersion: e.AggregateVersion,
+		Data:             e.data(),
+		Tags:             e.Tags,
+	}
+}
+
+// data returns the encoded event data, which is stored either as binary data
+// or as a BSON document (see NativeData).
+func (e entry) data() []byte {
+	if _, b, ok := e.Data.BinaryOK(); ok {
+		return b
+	}
+	if doc, ok := e.Data.DocumentOK(); ok {
+		return doc
+	}
+	return nil
+}
+
+func (e entry) decodeError(err error) *event.DecodeError {
+	return &event.DecodeError{RawEvent: e.raw(), Err: errors.Unwrap(err)}
+}
+
 func makeFilter(q event.Query) bson.D {
 	filter := make(bson.D, 0)
 	filter = withIDFilter(filter, q.IDs()...)
@@ -900,6 +1386,7 @@ func makeFilter(q event.Query) bson.D {
 	filter = withAggregateIDFilter(filter, q.AggregateIDs()...)
 	filter = withAggregateVersionFilter(filter, q.AggregateVersions())
 	filter = withAggregateRefFilter(filter, q.Aggregates())
+	filter = withTagFilter(filter, event.QueryTags(q)...)
 	return filter
 }
 
@@ -912,6 +1399,15 @@ func withNameFilter(filter bson.D, names ...string) bson.D {
 	})
 }
 
+func withTagFilter(filter bson.D, tags ...string) bson.D {
+	if len(tags) == 0 {
+		return filter
+	}
+	return append(filter, bson.E{
+		Key: "tags", Value: bson.D{{Key: "$in", Value: tags}},
+	})
+}
+
 func withIDFilter(filter bson.D, ids ...uuid.UUID) bson.D {
 	if len(ids) == 0 {
 		return filter
@@ -1047,6 +1543,16 @@ func withAggregateRefFilter(filter bson.D, tuples []event.AggregateRef) bson.D {
 	return append(filter, bson.E{Key: "$or", Value: or})
 }
 
+// sortings returns the sortings of the given query, or the default sort of
+// the store if the query is unsorted, tie-broken by event id.
+func (s *EventStore) sortings(q event.Query) []event.SortOptions {
+	sortings := q.Sortings()
+	if len(sortings) == 0 {
+		sortings = s.defaultSort
+	}
+	return event.TieBreak(sortings)
+}
+
 func applySortings(opts *options.FindOptions, sortings ...event.SortOptions) *options.FindOptions {
 	sorts := make(bson.D, len(sortings))
 	for i, opts := range sortings {
@@ -1064,6 +1570,8 @@ func applySortings(opts *options.FindOptions, sortings ...event.SortOptions) *op
 			sorts[i] = bson.E{Key: "aggregateVersion", Value: v}
 		case event.SortTime:
 			sorts[i] = bson.E{Key: "timeNano", Value: v}
+		case event.SortID:
+			sorts[i] = bson.E{Key: "id", Value: v}
 		}
 	}
 	return opts.SetSort(sorts)
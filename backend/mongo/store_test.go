@@ -9,6 +9,7 @@ import (
 	"os"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -23,7 +24,10 @@ import (
 	"github.com/modernice/goes/backend/testing/eventstoretest"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
 	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/internal/xtime"
 )
 
 func TestEventStore(t *testing.T) {
@@ -94,6 +98,129 @@ func TestEventStore_Insert_versionError(t *testing.T) {
 	}
 }
 
+func TestEventStore_Insert_batchSize(t *testing.T) {
+	enc := etest.NewEncoder()
+	s := mongo.NewEventStore(
+		enc,
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.InsertBatchSize(100),
+	)
+
+	id := uuid.New()
+	events := make([]event.Event, 250)
+	for i := range events {
+		events[i] = event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", i+1))
+	}
+
+	if err := s.Insert(context.Background(), events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	str, errs, err := s.Query(context.Background(), query.New(query.Aggregate("foo", id), query.SortByAggregate()))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	result, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain() failed with %q", err)
+	}
+
+	etest.AssertEqualEvents(t, events, result)
+
+	var st struct{ Version int }
+	if err := s.StateCollection().FindOne(context.Background(), bson.M{"aggregateId": id}).Decode(&st); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+
+	if st.Version != len(events) {
+		t.Fatalf("aggregate state should have version %d; got %d", len(events), st.Version)
+	}
+}
+
+func TestEventStore_Query_paging(t *testing.T) {
+	enc := etest.NewEncoder()
+	s := mongo.NewEventStore(
+		enc,
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.FindBatchSize(2),
+	)
+
+	id := uuid.New()
+	events := make([]event.Event, 10)
+	for i := range events {
+		events[i] = event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", i+1))
+	}
+
+	if err := s.Insert(context.Background(), events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	str, errs, err := s.Query(context.Background(), query.New(
+		query.Aggregate("foo", id),
+		query.SortByAggregate(),
+		query.Limit(5),
+		query.Offset(3),
+	))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	result, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain() failed with %q", err)
+	}
+
+	etest.AssertEqualEvents(t, events[3:8], result)
+}
+
+func TestEventStore_Query_tieBreak(t *testing.T) {
+	enc := etest.NewEncoder()
+	s := mongo.NewEventStore(
+		enc,
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.DefaultSort(event.SortOptions{Sort: event.SortTime, Dir: event.SortAsc}),
+	)
+
+	now := xtime.Now()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.ID(uuid.MustParse("C0000000-0000-0000-0000-000000000000")), event.Time(now)),
+		event.New[any]("foo", etest.FooEventData{}, event.ID(uuid.MustParse("A0000000-0000-0000-0000-000000000000")), event.Time(now.Add(time.Minute))),
+		event.New[any]("foo", etest.FooEventData{}, event.ID(uuid.MustParse("B0000000-0000-0000-0000-000000000000")), event.Time(now)),
+	}
+
+	if err := s.Insert(context.Background(), events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		q    event.Query
+		want []event.Event
+	}{
+		{"default", query.New(), []event.Event{events[2], events[0], events[1]}},
+		{"asc", query.New(query.SortBy(event.SortTime, event.SortAsc)), []event.Event{events[2], events[0], events[1]}},
+		{"desc", query.New(query.SortBy(event.SortTime, event.SortDesc)), []event.Event{events[1], events[0], events[2]}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			str, errs, err := s.Query(context.Background(), tt.q)
+			if err != nil {
+				t.Fatalf("Query() failed with %q", err)
+			}
+
+			result, err := streams.Drain(context.Background(), str, errs)
+			if err != nil {
+				t.Fatalf("Drain() failed with %q", err)
+			}
+
+			etest.AssertEqualEvents(t, tt.want, result)
+		})
+	}
+}
+
 // TestEventStore_Insert_preAndPostHooks tests the following scenario
 // Given: [0: "insert:pre", 1: "insert:pre", 2: "insert:post", 3: "insert:post"] hooks, then
 //
@@ -380,6 +507,99 @@ func TestEventStore_WithTxHook_failsWithoutTransactionsEnabled(t *testing.T) {
 	)
 }
 
+func TestEventStore_Query_SkipUndecodable(t *testing.T) {
+	enc := etest.NewEncoder()
+	s := mongo.NewEventStore(enc, mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(nextEventDatabase()))
+
+	ctx := context.Background()
+
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}).Any(),
+		event.New[any]("bar", etest.BarEventData{A: "bar"}).Any(),
+		event.New[any]("baz", etest.BazEventData{A: "baz"}).Any(),
+	}
+
+	if err := s.Insert(ctx, events...); err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+
+	// "bar" and "baz" events are unknown to the codec of this store.
+	readerEnc := codec.New()
+	codec.Register[etest.FooEventData](readerEnc, "foo")
+	reader := mongo.NewEventStore(readerEnc, mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(s.Database().Name()))
+
+	var skipped []*event.DecodeError
+	str, errs, err := reader.Query(ctx, query.New(
+		query.SortBy(event.SortTime, event.SortAsc),
+		query.SkipUndecodable(func(err *event.DecodeError) {
+			skipped = append(skipped, err)
+		}),
+	))
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+
+	result, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	etest.AssertEqualEvents(t, events[:1], result)
+
+	if len(skipped) != 2 {
+		t.Fatalf("%d events should have been skipped; got %d", 2, len(skipped))
+	}
+
+	for i, err := range skipped {
+		if err.ID != events[i+1].ID() || err.Name != events[i+1].Name() {
+			t.Errorf("skipped event #%d should be %q (%s); got %q (%s)", i, events[i+1].Name(), events[i+1].ID(), err.Name, err.ID)
+		}
+
+		if len(err.Data) == 0 {
+			t.Errorf("skipped event #%d should provide the encoded data", i)
+		}
+	}
+}
+
+func TestEventStore_InsertRaw_QueryRaw(t *testing.T) {
+	enc := etest.NewEncoder()
+	s := mongo.NewEventStore(enc, mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(nextEventDatabase()))
+
+	ctx := context.Background()
+
+	evt := event.New[any]("foo", etest.FooEventData{A: "foo"})
+	raw, err := event.Raw(enc, evt)
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+
+	// The store that inserts the raw event does not know the event data type.
+	relay := mongo.NewEventStore(codec.New(), mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(s.Database().Name()))
+	if err := relay.InsertRaw(ctx, raw); err != nil {
+		t.Fatalf("InsertRaw failed with %q", err)
+	}
+
+	found, err := s.Find(ctx, evt.ID())
+	if err != nil {
+		t.Fatalf("find event: %v", err)
+	}
+	etest.AssertEqualEvents(t, []event.Event{evt}, []event.Event{found})
+
+	str, errs, err := relay.QueryRaw(ctx, query.New(query.ID(evt.ID())))
+	if err != nil {
+		t.Fatalf("QueryRaw failed with %q", err)
+	}
+
+	result, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	if len(result) != 1 || result[0].ID != evt.ID() || string(result[0].Data) != string(raw.Data) {
+		t.Fatalf("QueryRaw should return the inserted raw event; got %v", result)
+	}
+}
+
 var evtDBID uint64
 
 func nextEventDatabase() string {
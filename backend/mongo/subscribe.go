@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/modernice/goes/event"
+)
+
+var (
+	_ event.Subscriber    = (*EventStore)(nil)
+	_ event.RawSubscriber = (*EventStore)(nil)
+)
+
+// Subscribe subscribes to events with the given names that are inserted into
+// the store after Subscribe returns. If no names are provided, all events are
+// received. Subscribe uses a MongoDB change stream on the event collection, so
+// that the store can deliver events to live subscribers without a separate
+// event bus:
+//
+//	store := mongo.NewEventStore(enc, mongo.URL("mongodb://localhost:27017/?replicaSet=rs0"))
+//	events, errs, err := store.Subscribe(ctx, "foo", "bar")
+//
+// Change streams are only available in replica sets and sharded clusters:
+// https://www.mongodb.com/docs/manual/changeStreams/
+//
+// The returned channels are closed when ctx is canceled, or after the change
+// stream failed with an error that was sent to the error channel. Events whose
+// data cannot be decoded are reported to the error channel and skipped.
+func (s *EventStore) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	raws, rawErrs, err := s.SubscribeRaw(ctx, names...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		for raws != nil || rawErrs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-rawErrs:
+				if !ok {
+					rawErrs = nil
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+			case raw, ok := <-raws:
+				if !ok {
+					raws = nil
+					break
+				}
+
+				evt, err := raw.DecodeContext(ctx, s.enc)
+				if err != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case errs <- err:
+					}
+					break
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- evt:
+				}
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// SubscribeRaw subscribes to events like Subscribe does, but returns the
+// events without decoding their data.
+//
+// SubscribeRaw implements event.RawSubscriber.
+func (s *EventStore) SubscribeRaw(ctx context.Context, names ...string) (<-chan event.RawEvent, <-chan error, error) {
+	if s.isTransactionStore {
+		return s.root.SubscribeRaw(ctx, names...)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return nil, nil, fmt.Errorf("connect: %w", err)
+	}
+
+	match := bson.D{
+		{Key: "operationType", Value: "insert"},
+		{Key: "fullDocument.importId", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	if len(names) > 0 {
+		match = append(match, bson.E{Key: "fullDocument.name", Value: bson.D{{Key: "$in", Value: names}}})
+	}
+
+	stream, err := s.entries.Watch(ctx, mongo.Pipeline{{{Key: "$match", Value: match}}}, s.changeStreamOptions())
+	if err != nil {
+		return nil, nil, fmt.Errorf("watch %q collection: %w", s.entriesCol, err)
+	}
+
+	out := make(chan event.RawEvent)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var change struct {
+				FullDocument entry `bson:"fullDocument"`
+			}
+
+			if err := stream.Decode(&change); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- fmt.Errorf("decode change event: %w", err):
+				}
+				continue
+			}
+
+			if err := s.loadData(ctx, &change.FullDocument); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case errs <- err:
+				}
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- change.FullDocument.raw():
+			}
+		}
+
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case errs <- fmt.Errorf("mongo change stream: %w", err):
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
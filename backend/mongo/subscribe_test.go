@@ -0,0 +1,64 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOREPLSTORE_URL")),
+		mongo.Transactions(true),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	events, errs, err := store.Subscribe(ctx, "foo", "baz")
+	if err != nil {
+		t.Fatalf("Subscribe() failed with %q", err)
+	}
+
+	id := uuid.New()
+	inserted := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("baz", etest.BazEventData{A: "baz"}, event.Aggregate(id, "foo", 3)),
+	}
+
+	if err := store.Insert(ctx, inserted...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	var received []event.Event
+	timeout := time.After(5 * time.Second)
+	for len(received) < 2 {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out; received %d/%d events", len(received), 2)
+		case err := <-errs:
+			t.Fatal(err)
+		case evt := <-events:
+			received = append(received, evt)
+		}
+	}
+
+	etest.AssertEqualEvents(t, []event.Event{inserted[0], inserted[2]}, received)
+
+	select {
+	case evt := <-events:
+		t.Fatalf("received unexpected %q event", evt.Name())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,62 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestEventStore_tags(t *testing.T) {
+	ctx := context.Background()
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+	)
+
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "foo", 1), event.Tag("import:42")),
+		event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Aggregate(uuid.New(), "bar", 1), event.Tag("import:42", "import:43")),
+		event.New[any]("baz", etest.BazEventData{A: "baz"}, event.Tag("import:43")),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	found, err := store.Find(ctx, events[1].ID())
+	if err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+
+	if tags := event.TagsOf(found); !reflect.DeepEqual(tags, []string{"import:42", "import:43"}) {
+		t.Fatalf("Find() should return the event with its tags; got %v", tags)
+	}
+
+	if n := queryCount(t, store, query.New(query.Tag("import:42"))); n != 2 {
+		t.Fatalf("Query() should return %d events with tag %q; got %d", 2, "import:42", n)
+	}
+
+	if n := queryCount(t, store, query.New(query.Tag("import:42", "import:43"))); n != 3 {
+		t.Fatalf("Query() should return %d events with any of the tags; got %d", 3, n)
+	}
+
+	if n := queryCount(t, store, query.New(query.Tag("import:43"), query.Name("baz"))); n != 1 {
+		t.Fatalf("Query() should return %d event; got %d", 1, n)
+	}
+
+	if !listIndexes(t, store.Collection())["goes_tags"] {
+		t.Fatalf("the %q index should be created", "goes_tags")
+	}
+}
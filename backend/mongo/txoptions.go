@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	stdtime "time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+// WriteConcern returns an EventStoreOption that specifies the write concern
+// of inserts. If transactions are enabled, the write concern is used for the
+// Insert transaction. Otherwise, it is used for the writes to the event and
+// state collections. By default, the write concern of the client is used.
+//
+//	store := mongo.NewEventStore(enc, mongo.WriteConcern(writeconcern.Majority()))
+func WriteConcern(wc *writeconcern.WriteConcern) EventStoreOption {
+	return func(s *EventStore) {
+		s.writeConcern = wc
+	}
+}
+
+// MaxCommitTime returns an EventStoreOption that specifies the maximum time a
+// commit of the Insert transaction may take. Has no effect if transactions
+// are disabled.
+func MaxCommitTime(d stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.maxCommitTime = &d
+	}
+}
+
+// CausalConsistency returns an EventStoreOption that specifies whether the
+// sessions of inserts are causally consistent. By default, the default of the
+// driver is used, which enables causal consistency.
+func CausalConsistency(causal bool) EventStoreOption {
+	return func(s *EventStore) {
+		s.causalConsistency = &causal
+	}
+}
+
+// TransactionRetry returns an EventStoreOption that retries inserts that fail
+// with a transient transaction error, e.g. because of a write conflict with a
+// concurrent transaction. An insert is attempted at most the given number of
+// times. The delay between two attempts starts at backoff and doubles with
+// every retry. Commits whose result is unknown are retried as well, without
+// running the insert again.
+//
+// By default, transient transaction errors are returned to the caller
+// immediately, unless global positions are enabled (see GlobalPositions). Has
+// no effect if transactions are disabled. Version errors are never retried.
+func TransactionRetry(attempts int, backoff stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.txRetryAttempts = attempts
+		s.txRetryBackoff = backoff
+	}
+}
+
+func (s *EventStore) sessionOptions() *options.SessionOptions {
+	opts := options.Session()
+	if s.causalConsistency != nil {
+		opts.SetCausalConsistency(*s.causalConsistency)
+	}
+	return opts
+}
+
+func (s *EventStore) transactionOptions() *options.TransactionOptions {
+	opts := options.Transaction()
+	if s.writeConcern != nil {
+		opts.SetWriteConcern(s.writeConcern)
+	}
+	if s.maxCommitTime != nil {
+		opts.SetMaxCommitTime(s.maxCommitTime)
+	}
+	return opts
+}
+
+// writeOptions returns the collection options of the collections that are
+// written to by inserts.
+func (s *EventStore) writeOptions() *options.CollectionOptions {
+	opts := options.Collection()
+	if s.writeConcern != nil {
+		opts.SetWriteConcern(s.writeConcern)
+	}
+	if s.uuidRegistry != nil {
+		opts.SetRegistry(s.uuidRegistry)
+	}
+	return opts
+}
+
+// retryTransaction calls fn until it succeeds, fails with an error that is
+// not a transient transaction error, or the configured number of attempts is
+// reached.
+func (s *EventStore) retryTransaction(ctx context.Context, fn func() error) error {
+	backoff := s.txRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !s.transactions || attempt >= s.txRetryAttempts || !hasErrorLabel(err, driver.TransientTransactionError) {
+			return err
+		}
+
+		s.observeRetry(attempt+1, false, err)
+
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+
+		backoff *= 2
+	}
+}
+
+// commitTransaction commits the transaction of the session. Commits whose
+// result is unknown are retried up to the configured number of attempts.
+func (s *EventStore) commitTransaction(ctx mongo.SessionContext) error {
+	for attempt := 1; ; attempt++ {
+		err := ctx.CommitTransaction(ctx)
+		if err == nil || attempt >= s.txRetryAttempts || !hasErrorLabel(err, driver.UnknownTransactionCommitResult) {
+			return err
+		}
+		s.observeRetry(attempt+1, true, err)
+	}
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var versionError VersionError
+	if errors.As(err, &versionError) {
+		return false
+	}
+
+	var serverError mongo.ServerError
+	return errors.As(err, &serverError) && serverError.HasErrorLabel(label)
+}
+
+func sleep(ctx context.Context, d stdtime.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := stdtime.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
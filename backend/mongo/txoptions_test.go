@@ -0,0 +1,122 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	gomongo "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestTransactionRetry(t *testing.T) {
+	var attempts int
+	hook := func(mongo.TransactionContext) error {
+		attempts++
+		if attempts < 3 {
+			return gomongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+		}
+		return nil
+	}
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOREPLSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.WithTransactionHook(mongo.PreInsert, hook),
+		mongo.TransactionRetry(3, time.Millisecond),
+		mongo.WriteConcern(writeconcern.Majority()),
+		mongo.MaxCommitTime(5*time.Second),
+		mongo.CausalConsistency(true),
+	)
+
+	evt := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(context.Background(), evt); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if attempts != 3 {
+		t.Fatalf("insert should be attempted %d times; got %d", 3, attempts)
+	}
+
+	if _, err := store.Find(context.Background(), evt.ID()); err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+}
+
+func TestTransactionRetry_exhausted(t *testing.T) {
+	var attempts int
+	mockError := gomongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+	hook := func(mongo.TransactionContext) error {
+		attempts++
+		return mockError
+	}
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOREPLSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.WithTransactionHook(mongo.PreInsert, hook),
+		mongo.TransactionRetry(2, time.Millisecond),
+	)
+
+	err := store.Insert(context.Background(), event.New[any]("foo", etest.FooEventData{}))
+
+	var cmdError mongo.CommandError
+	if !errors.As(err, &cmdError) || !cmdError.CommandError().HasErrorLabel("TransientTransactionError") {
+		t.Fatalf("Insert() should fail with the transient transaction error; got %v", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("insert should be attempted %d times; got %d", 2, attempts)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempts int
+	hook := func(mongo.TransactionContext) error {
+		attempts++
+		if attempts < 3 {
+			return gomongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}
+		}
+		return nil
+	}
+
+	var retryable []error
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(nextEventDatabase()),
+		mongo.WithTransactionHook(mongo.PreInsert, hook),
+		mongo.Retry(mongo.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable: func(err error) bool {
+				retryable = append(retryable, err)
+				return mongo.IsTransient(err)
+			},
+		}),
+	)
+
+	evt := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(uuid.New(), "foo", 1))
+	if err := store.Insert(context.Background(), evt); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if attempts != 3 {
+		t.Fatalf("insert should be attempted %d times; got %d", 3, attempts)
+	}
+
+	if len(retryable) != 2 {
+		t.Fatalf("Retryable should be called %d times; got %d", 2, len(retryable))
+	}
+}
@@ -0,0 +1,248 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// UUIDFormat is the BSON representation of UUIDs in the documents of the
+// event store (see UUIDs).
+type UUIDFormat int
+
+const (
+	// UUIDGeneric stores UUIDs as BSON binary data of the generic subtype
+	// 0x00, which is the default encoding of the MongoDB driver.
+	UUIDGeneric UUIDFormat = iota
+
+	// UUIDStandard stores UUIDs as BSON binary data of the UUID subtype 0x04,
+	// which is recognized as a UUID by other drivers and tools.
+	UUIDStandard
+
+	// UUIDString stores UUIDs as strings in their canonical form.
+	UUIDString
+)
+
+// uuidMigrationBatchSize is the number of documents that are updated by a
+// single bulk write of MigrateUUIDs.
+const uuidMigrationBatchSize = 1000
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+// UUIDs returns an EventStoreOption that specifies how the UUIDs of events
+// and aggregates are stored, i.e. the "id" and "aggregateId" fields of the
+// event documents and the "aggregateId" field of the aggregate states:
+//
+//	store := mongo.NewEventStore(enc, mongo.UUIDs(mongo.UUIDStandard))
+//
+// Binary UUIDs are smaller than string UUIDs and keep the indexes small.
+// UUIDStandard binary data is displayed as UUIDs by MongoDB tooling and
+// can be read by other drivers. Defaults to UUIDGeneric.
+//
+// If a format is specified, UUIDs of all formats can be decoded, but queries
+// only match documents that store their UUIDs in the configured format. Use
+// MigrateUUIDs to convert the UUIDs of existing documents when the format of
+// a store is changed. The collections of the store then use a BSON registry
+// that is created by bson.NewRegistry and extended by the UUID codec, instead
+// of the registry of the client.
+func UUIDs(format UUIDFormat) EventStoreOption {
+	return func(s *EventStore) {
+		s.uuidFormat = format
+		s.uuidRegistry = newUUIDRegistry(format)
+	}
+}
+
+// MigrateUUIDs converts the UUIDs of the existing event and state documents
+// into the format that is configured by the UUIDs option, and returns the
+// number of updated documents. Documents that already store their UUIDs in
+// the configured format are not updated, so the migration can be resumed if
+// it fails. Inserts that run during the migration are not affected, because
+// they already use the configured format.
+func (s *EventStore) MigrateUUIDs(ctx context.Context) (int64, error) {
+	if s.isTransactionStore {
+		return s.root.MigrateUUIDs(ctx)
+	}
+
+	if err := s.connectOnce(ctx); err != nil {
+		return 0, fmt.Errorf("connect: %w", err)
+	}
+
+	events, err := s.migrateUUIDs(ctx, s.entries, "id", "aggregateId")
+	if err != nil {
+		return events, fmt.Errorf("migrate events: %w", err)
+	}
+
+	states, err := s.migrateUUIDs(ctx, s.states, "aggregateId")
+	if err != nil {
+		return events + states, fmt.Errorf("migrate aggregate states: %w", err)
+	}
+
+	return events + states, nil
+}
+
+func (s *EventStore) migrateUUIDs(ctx context.Context, col *mongo.Collection, fields ...string) (int64, error) {
+	projection := bson.D{}
+	for _, field := range fields {
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+
+	cur, err := col.Find(ctx, bson.D{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return 0, fmt.Errorf("mongo: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var updated int64
+	var models []mongo.WriteModel
+
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		res, err := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("bulk write: %w", err)
+		}
+		updated += res.ModifiedCount
+		models = models[:0]
+		return nil
+	}
+
+	for cur.Next(ctx) {
+		var set bson.D
+		for _, field := range fields {
+			val, err := cur.Current.LookupErr(field)
+			if err != nil {
+				continue
+			}
+
+			id, err := decodeUUID(val)
+			if err != nil {
+				return updated, fmt.Errorf("decode %q field of document %v: %w", field, cur.Current.Lookup("_id"), err)
+			}
+
+			if s.isUUIDFormat(val) {
+				continue
+			}
+
+			set = append(set, bson.E{Key: field, Value: id})
+		}
+
+		if len(set) == 0 {
+			continue
+		}
+
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: cur.Current.Lookup("_id")}}).
+			SetUpdate(bson.D{{Key: "$set", Value: set}}))
+
+		if len(models) >= uuidMigrationBatchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return updated, fmt.Errorf("cursor: %w", err)
+	}
+
+	return updated, flush()
+}
+
+// isUUIDFormat reports whether the given value is a UUID in the configured
+// format.
+func (s *EventStore) isUUIDFormat(val bson.RawValue) bool {
+	switch s.uuidFormat {
+	case UUIDString:
+		return val.Type == bsontype.String
+	case UUIDStandard:
+		subtype, _, ok := val.BinaryOK()
+		return ok && subtype == bsontype.BinaryUUID
+	default:
+		subtype, _, ok := val.BinaryOK()
+		return ok && subtype == bsontype.BinaryGeneric
+	}
+}
+
+func newUUIDRegistry(format UUIDFormat) *bsoncodec.Registry {
+	reg := bson.NewRegistry()
+	reg.RegisterTypeEncoder(uuidType, bsoncodec.ValueEncoderFunc(func(_ bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+		if !val.IsValid() || val.Type() != uuidType {
+			return bsoncodec.ValueEncoderError{Name: "UUIDEncodeValue", Types: []reflect.Type{uuidType}, Received: val}
+		}
+		id := val.Interface().(uuid.UUID)
+		switch format {
+		case UUIDString:
+			return vw.WriteString(id.String())
+		case UUIDStandard:
+			return vw.WriteBinaryWithSubtype(id[:], bsontype.BinaryUUID)
+		default:
+			return vw.WriteBinaryWithSubtype(id[:], bsontype.BinaryGeneric)
+		}
+	}))
+	reg.RegisterTypeDecoder(uuidType, bsoncodec.ValueDecoderFunc(func(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+		if !val.CanSet() || val.Type() != uuidType {
+			return bsoncodec.ValueDecoderError{Name: "UUIDDecodeValue", Types: []reflect.Type{uuidType}, Received: val}
+		}
+
+		var raw bson.RawValue
+		switch vr.Type() {
+		case bsontype.String:
+			str, err := vr.ReadString()
+			if err != nil {
+				return err
+			}
+			raw = bson.RawValue{Type: bsontype.String, Value: bsoncore.AppendString(nil, str)}
+		case bsontype.Binary:
+			b, subtype, err := vr.ReadBinary()
+			if err != nil {
+				return err
+			}
+			raw = bson.RawValue{Type: bsontype.Binary, Value: bsoncore.AppendBinary(nil, subtype, b)}
+		case bsontype.Null:
+			val.Set(reflect.ValueOf(uuid.Nil))
+			return vr.ReadNull()
+		default:
+			return fmt.Errorf("cannot decode %v into a UUID", vr.Type())
+		}
+
+		id, err := decodeUUID(raw)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(id))
+
+		return nil
+	}))
+	return reg
+}
+
+// decodeUUID decodes a UUID that is stored as a string or as binary data.
+func decodeUUID(val bson.RawValue) (uuid.UUID, error) {
+	if str, ok := val.StringValueOK(); ok {
+		return uuid.Parse(str)
+	}
+
+	subtype, b, ok := val.BinaryOK()
+	if !ok {
+		return uuid.Nil, fmt.Errorf("cannot decode %v into a UUID", val.Type)
+	}
+
+	switch subtype {
+	case bsontype.BinaryGeneric, bsontype.BinaryBinaryOld, bsontype.BinaryUUIDOld, bsontype.BinaryUUID:
+	default:
+		return uuid.Nil, fmt.Errorf("cannot decode binary subtype %#x into a UUID", subtype)
+	}
+
+	return uuid.FromBytes(b)
+}
@@ -0,0 +1,77 @@
+//go:build mongo
+
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+
+	"github.com/modernice/goes/backend/mongo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+)
+
+func TestUUIDs(t *testing.T) {
+	ctx := context.Background()
+	db := nextEventDatabase()
+
+	legacy := mongo.NewEventStore(etest.NewEncoder(), mongo.URL(os.Getenv("MONGOSTORE_URL")), mongo.Database(db))
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)),
+	}
+	if err := legacy.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	store := mongo.NewEventStore(
+		etest.NewEncoder(),
+		mongo.URL(os.Getenv("MONGOSTORE_URL")),
+		mongo.Database(db),
+		mongo.UUIDs(mongo.UUIDStandard),
+	)
+
+	n, err := store.MigrateUUIDs(ctx)
+	if err != nil {
+		t.Fatalf("MigrateUUIDs() failed with %q", err)
+	}
+
+	// 2 events and 1 aggregate state
+	if n != 3 {
+		t.Fatalf("MigrateUUIDs() should update %d documents; updated %d", 3, n)
+	}
+
+	if n, err := store.MigrateUUIDs(ctx); err != nil || n != 0 {
+		t.Fatalf("MigrateUUIDs() should not update migrated documents; updated %d (%v)", n, err)
+	}
+
+	raw, err := store.Collection().FindOne(ctx, bson.D{{Key: "name", Value: "foo"}}).DecodeBytes()
+	if err != nil {
+		t.Fatalf("find document: %v", err)
+	}
+	for _, field := range []string{"id", "aggregateId"} {
+		if subtype, _, ok := raw.Lookup(field).BinaryOK(); !ok || subtype != bsontype.BinaryUUID {
+			t.Fatalf("%q field should be stored as binary subtype %#x", field, bsontype.BinaryUUID)
+		}
+	}
+
+	if _, err := store.Find(ctx, events[0].ID()); err != nil {
+		t.Fatalf("Find() failed with %q", err)
+	}
+
+	if n := queryCount(t, store, query.New(query.AggregateID(id))); n != 2 {
+		t.Fatalf("Query() should return %d events; got %d", 2, n)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)).Any()); err == nil {
+		t.Fatalf("Insert() should fail with a version error after the migration of the aggregate state")
+	}
+}
@@ -0,0 +1,269 @@
+// Package mqtt provides a bridge that ingests MQTT messages as goes events.
+// The package does not depend on a specific MQTT client. Instead, the messages
+// that are received by the client of your choice are passed to a Bridge, which
+// converts them into ingest.Messages and hands them to an ingest.Ingestor:
+//
+//	ingestor := ingest.New(store, ingest.Bus(bus), ingest.Route(
+//		"telemetry",
+//		mqtt.JSON[Reading]("device.reading_recorded", "device", "device"),
+//	))
+//
+//	bridge := mqtt.NewBridge(ingestor, mqtt.Route("devices/{device}/telemetry", "telemetry"))
+//
+//	for _, filter := range bridge.Filters() {
+//		client.Subscribe(filter, 1, func(_ paho.Client, msg paho.Message) {
+//			if _, err := bridge.Handle(ctx, msg); err != nil {
+//				log.Println(err)
+//			}
+//		})
+//	}
+//
+// Named topic segments like "{device}" match a single topic level. Their
+// values are added to the metadata of the ingested messages, and can be used
+// to assign the events to aggregates (see Aggregate and AggregateID).
+package mqtt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/ingest"
+)
+
+// DefaultSource is the default source of ingested MQTT messages.
+const DefaultSource = "mqtt"
+
+// TopicMetadata is the metadata key of the topic of an ingested message.
+const TopicMetadata = "topic"
+
+// ErrUnknownTopic is returned by Bridge.Handle for messages whose topic does
+// not match any route.
+var ErrUnknownTopic = errors.New("unknown topic")
+
+// Namespace is the namespace of the aggregate ids that are derived from topic
+// segments, e.g. device ids.
+var Namespace = uuid.MustParse("3b0c6f1e-58a4-4c2d-9f7e-2a61d84b5c93")
+
+// Message is a message that was received from an MQTT broker. The Message
+// type of the Eclipse Paho client implements Message.
+type Message interface {
+	// Topic returns the topic of the message.
+	Topic() string
+
+	// Payload returns the payload of the message.
+	Payload() []byte
+}
+
+// Bridge ingests MQTT messages as events. Each message is matched against the
+// routes of the bridge, converted into an ingest.Message, and ingested by an
+// ingest.Ingestor, which translates the message into events, inserts them
+// into the event store, and optionally publishes them over an event bus.
+type Bridge struct {
+	ingestor *ingest.Ingestor
+	source   string
+	id       func(Message) string
+	routes   []route
+}
+
+type route struct {
+	levels  []string
+	msgType string
+}
+
+// BridgeOption is an option for a Bridge.
+type BridgeOption func(*Bridge)
+
+// Route returns a BridgeOption that ingests the messages of topics that match
+// the given topic filter as messages of the given type. The type selects the
+// ingest.Translator of the ingestor. In addition to the "+" and "#" wildcards
+// of MQTT, the filter may contain named single-level wildcards like
+// "{device}", whose values are added to the metadata of the message:
+//
+//	mqtt.Route("devices/{device}/telemetry", "telemetry")
+//
+// Routes are matched in the order in which they are provided.
+func Route(filter, msgType string) BridgeOption {
+	return func(b *Bridge) {
+		b.routes = append(b.routes, route{
+			levels:  strings.Split(filter, "/"),
+			msgType: msgType,
+		})
+	}
+}
+
+// Source returns a BridgeOption that specifies the source of the ingested
+// messages. Defaults to DefaultSource. Use different sources for bridges that
+// are connected to different brokers.
+func Source(source string) BridgeOption {
+	return func(b *Bridge) {
+		b.source = source
+	}
+}
+
+// MessageID returns a BridgeOption that specifies how the id of a message is
+// determined. Messages with the same id are ingested only once. By default,
+// the id is the SHA-256 hash of the topic and payload of the message, because
+// the packet ids of MQTT are reused by the broker. If identical messages may
+// be sent on purpose, include a unique id or timestamp in the payload and
+// extract it using fn.
+func MessageID(fn func(Message) string) BridgeOption {
+	return func(b *Bridge) {
+		b.id = fn
+	}
+}
+
+// NewBridge returns a Bridge that ingests messages using the given ingestor.
+func NewBridge(ingestor *ingest.Ingestor, opts ...BridgeOption) *Bridge {
+	b := &Bridge{
+		ingestor: ingestor,
+		source:   DefaultSource,
+		id:       hashID,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Filters returns the MQTT topic filters of the routes of the bridge, with
+// named wildcards replaced by "+". Subscribe to these filters using your MQTT
+// client and pass the received messages to Handle.
+func (b *Bridge) Filters() []string {
+	filters := make([]string, len(b.routes))
+	for i, r := range b.routes {
+		levels := make([]string, len(r.levels))
+		for j, level := range r.levels {
+			if _, ok := paramName(level); ok {
+				level = "+"
+			}
+			levels[j] = level
+		}
+		filters[i] = strings.Join(levels, "/")
+	}
+	return filters
+}
+
+// Message converts an MQTT message into an ingest.Message. Message returns
+// false if the topic of the message does not match any route.
+func (b *Bridge) Message(msg Message) (ingest.Message, bool) {
+	topic := msg.Topic()
+	levels := strings.Split(topic, "/")
+
+	for _, r := range b.routes {
+		params, ok := r.match(levels)
+		if !ok {
+			continue
+		}
+
+		params[TopicMetadata] = topic
+
+		return ingest.Message{
+			Source:   b.source,
+			ID:       b.id(msg),
+			Type:     r.msgType,
+			Payload:  msg.Payload(),
+			Metadata: params,
+		}, true
+	}
+
+	return ingest.Message{}, false
+}
+
+// Handle ingests the given MQTT message. If the topic of the message does not
+// match any route, Handle returns ErrUnknownTopic. Acknowledge the message to
+// the broker only if Handle returns no error, so that the broker redelivers
+// messages that could not be ingested.
+func (b *Bridge) Handle(ctx context.Context, msg Message) (ingest.Result, error) {
+	m, ok := b.Message(msg)
+	if !ok {
+		return ingest.Result{}, fmt.Errorf("%s: %w", msg.Topic(), ErrUnknownTopic)
+	}
+	return b.ingestor.Ingest(ctx, m)
+}
+
+func (r route) match(levels []string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	for i, level := range r.levels {
+		if level == "#" {
+			return params, true
+		}
+
+		if i >= len(levels) {
+			return nil, false
+		}
+
+		if name, ok := paramName(level); ok {
+			params[name] = levels[i]
+			continue
+		}
+
+		if level != "+" && level != levels[i] {
+			return nil, false
+		}
+	}
+
+	if len(levels) != len(r.levels) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+func paramName(level string) (string, bool) {
+	if len(level) > 2 && strings.HasPrefix(level, "{") && strings.HasSuffix(level, "}") {
+		return level[1 : len(level)-1], true
+	}
+	return "", false
+}
+
+func hashID(msg Message) string {
+	h := sha256.New()
+	h.Write([]byte(msg.Topic()))
+	h.Write([]byte{0})
+	h.Write(msg.Payload())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AggregateID returns the deterministic id of the aggregate with the given
+// name that is identified by key, e.g. a device id. The same name and key
+// always result in the same id.
+func AggregateID(name, key string) uuid.UUID {
+	return uuid.NewSHA1(Namespace, []byte(name+"\x00"+key))
+}
+
+// Aggregate returns the reference to the aggregate with the given name that is
+// identified by the value of the named topic segment param of the message. If
+// the message has no such segment, the zero AggregateRef is returned.
+//
+//	// for a message of topic "devices/sensor-1/telemetry"
+//	// that matched the route "devices/{device}/telemetry"
+//	ref := mqtt.Aggregate(msg, "device", "device")
+//	// ref.ID == mqtt.AggregateID("device", "sensor-1")
+func Aggregate(msg ingest.Message, name, param string) event.AggregateRef {
+	key, ok := msg.Metadata[param]
+	if !ok || key == "" {
+		return event.AggregateRef{}
+	}
+	return event.AggregateRef{Name: name, ID: AggregateID(name, key)}
+}
+
+// JSON returns an ingest.Translator that decodes the JSON payload of messages
+// into a T, and translates each message into a single event with the given
+// name. The event is assigned to the aggregate with the given name that is
+// identified by the named topic segment param (see Aggregate).
+func JSON[T any](eventName, aggregateName, param string) ingest.Translator {
+	return ingest.JSON(func(msg ingest.Message, data T) ([]ingest.Translation, error) {
+		return []ingest.Translation{{
+			Name:      eventName,
+			Data:      data,
+			Aggregate: Aggregate(msg, aggregateName, param),
+		}}, nil
+	})
+}
@@ -0,0 +1,148 @@
+package mqtt_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/modernice/goes/backend/mqtt"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/ingest"
+)
+
+type reading struct {
+	Temperature float64 `json:"temperature"`
+}
+
+type message struct {
+	topic   string
+	payload []byte
+}
+
+func (m message) Topic() string   { return m.topic }
+func (m message) Payload() []byte { return m.payload }
+
+func TestBridge_Filters(t *testing.T) {
+	bridge := mqtt.NewBridge(
+		ingest.New(eventstore.New()),
+		mqtt.Route("devices/{device}/telemetry", "telemetry"),
+		mqtt.Route("sites/+/{device}/#", "status"),
+	)
+
+	want := []string{"devices/+/telemetry", "sites/+/+/#"}
+	if got := bridge.Filters(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Filters() should return %v; got %v", want, got)
+	}
+}
+
+func TestBridge_Message(t *testing.T) {
+	bridge := mqtt.NewBridge(
+		ingest.New(eventstore.New()),
+		mqtt.Route("devices/{device}/telemetry", "telemetry"),
+		mqtt.Route("sites/{site}/{device}/#", "status"),
+		mqtt.Source("broker-1"),
+	)
+
+	tests := []struct {
+		topic    string
+		wantType string
+		wantMeta map[string]string
+	}{
+		{
+			topic:    "devices/sensor-1/telemetry",
+			wantType: "telemetry",
+			wantMeta: map[string]string{"device": "sensor-1", "topic": "devices/sensor-1/telemetry"},
+		},
+		{
+			topic:    "sites/berlin/sensor-2/status/battery",
+			wantType: "status",
+			wantMeta: map[string]string{"site": "berlin", "device": "sensor-2", "topic": "sites/berlin/sensor-2/status/battery"},
+		},
+		{topic: "devices/sensor-1"},
+		{topic: "devices/sensor-1/telemetry/raw"},
+	}
+
+	for _, tt := range tests {
+		msg, ok := bridge.Message(message{topic: tt.topic, payload: []byte("{}")})
+
+		if tt.wantType == "" {
+			if ok {
+				t.Errorf("topic %q should not match any route; got %+v", tt.topic, msg)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Errorf("topic %q should match a route", tt.topic)
+			continue
+		}
+
+		if msg.Source != "broker-1" || msg.Type != tt.wantType || msg.ID == "" {
+			t.Errorf("unexpected message for topic %q: %+v", tt.topic, msg)
+		}
+
+		if !reflect.DeepEqual(msg.Metadata, tt.wantMeta) {
+			t.Errorf("metadata of topic %q should be %v; got %v", tt.topic, tt.wantMeta, msg.Metadata)
+		}
+	}
+}
+
+func TestBridge_Handle(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	ingestor := ingest.New(store, ingest.Route("telemetry", mqtt.JSON[reading]("device.reading_recorded", "device", "device")))
+	bridge := mqtt.NewBridge(ingestor, mqtt.Route("devices/{device}/telemetry", "telemetry"))
+
+	msg := message{topic: "devices/sensor-1/telemetry", payload: []byte(`{"temperature": 21.5}`)}
+
+	res, err := bridge.Handle(ctx, msg)
+	if err != nil {
+		t.Fatalf("Handle() failed with %q", err)
+	}
+
+	if len(res.Inserted) != 1 {
+		t.Fatalf("Handle() should insert 1 event; inserted %d", len(res.Inserted))
+	}
+
+	evt := res.Inserted[0]
+	if evt.Name() != "device.reading_recorded" {
+		t.Fatalf("event name should be %q; got %q", "device.reading_recorded", evt.Name())
+	}
+
+	if data, ok := evt.Data().(reading); !ok || data.Temperature != 21.5 {
+		t.Fatalf("unexpected event data %v", evt.Data())
+	}
+
+	id, name, version := evt.Aggregate()
+	if want := mqtt.AggregateID("device", "sensor-1"); id != want || name != "device" || version != 1 {
+		t.Fatalf("event should belong to device %s (v1); got %s %s (v%d)", want, name, id, version)
+	}
+
+	res, err = bridge.Handle(ctx, msg)
+	if err != nil {
+		t.Fatalf("Handle() failed with %q", err)
+	}
+
+	if len(res.Inserted) != 0 || res.Duplicates != 1 {
+		t.Fatalf("redelivered message should not be ingested twice; got %+v", res)
+	}
+
+	if _, err := bridge.Handle(ctx, message{topic: "unknown"}); !errors.Is(err, mqtt.ErrUnknownTopic) {
+		t.Fatalf("Handle() should fail with %q; got %v", mqtt.ErrUnknownTopic, err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	msg := ingest.Message{Metadata: map[string]string{"device": "sensor-1"}}
+
+	want := event.AggregateRef{Name: "device", ID: mqtt.AggregateID("device", "sensor-1")}
+	if ref := mqtt.Aggregate(msg, "device", "device"); ref != want {
+		t.Fatalf("Aggregate() should return %v; got %v", want, ref)
+	}
+
+	if ref := mqtt.Aggregate(msg, "device", "site"); !ref.IsZero() {
+		t.Fatalf("Aggregate() should return the zero ref for missing segments; got %v", ref)
+	}
+}
@@ -1,5 +1,7 @@
 // Package nats provides an event bus that uses NATS to publish and subscribe to
 // events over a network with support for both NATS Core and NATS JetStream.
+// It also provides snapshot and projection progress stores that are backed by
+// JetStream key-value buckets, and the NATS protocol binding of CloudEvents.
 package nats
 
 import (
@@ -8,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -48,6 +51,10 @@ type EventBus struct {
 	natsOpts []nats.Option
 	driver   Driver
 
+	errorHistory  int
+	errors        *errorLog
+	droppedErrors atomic.Uint64
+
 	onceConnect sync.Once
 	stop        chan struct{}
 }
@@ -60,7 +67,7 @@ type EventBusOption func(*EventBus)
 // a Driver.
 type Driver interface {
 	name() string
-	subscribe(ctx context.Context, bus *EventBus, subject string) (recipient, error)
+	subscribe(ctx context.Context, bus *EventBus, subject string, raw bool) (recipient, error)
 	publish(ctx context.Context, bus *EventBus, evt event.Event) error
 }
 
@@ -173,8 +180,50 @@ func (bus *EventBus) Publish(ctx context.Context, events ...event.Event) error {
 	return nil
 }
 
+// PublishRaw publishes events whose data is already encoded. The encoded data
+// is published as-is, without using the encoder of the event bus.
+//
+// PublishRaw implements event.RawPublisher.
+func (bus *EventBus) PublishRaw(ctx context.Context, events ...event.RawEvent) error {
+	evts := make([]event.Event, len(events))
+	for i, raw := range events {
+		evts[i] = rawToEvent(raw)
+	}
+	return bus.Publish(ctx, evts...)
+}
+
 // Subscribe subscribes to events.
 func (bus *EventBus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	return bus.subscribe(ctx, false, names...)
+}
+
+// SubscribeRaw subscribes to events, like Subscribe does, but returns the
+// events without decoding their data. Events that cannot be decoded by the
+// encoder of the event bus are still received by raw subscribers.
+//
+// SubscribeRaw implements event.RawSubscriber.
+func (bus *EventBus) SubscribeRaw(ctx context.Context, names ...string) (<-chan event.RawEvent, <-chan error, error) {
+	events, errs, err := bus.subscribe(ctx, true, names...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan event.RawEvent)
+	go func() {
+		defer close(out)
+		for evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- eventToRaw(evt):
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+func (bus *EventBus) subscribe(ctx context.Context, raw bool, names ...string) (<-chan event.Event, <-chan error, error) {
 	if err := bus.Connect(ctx); err != nil {
 		return nil, nil, fmt.Errorf("connect: %w", err)
 	}
@@ -182,7 +231,7 @@ func (bus *EventBus) Subscribe(ctx context.Context, names ...string) (<-chan eve
 	rcpts := make([]recipient, len(names))
 
 	for i, name := range names {
-		rcpt, err := bus.driver.subscribe(ctx, bus, name)
+		rcpt, err := bus.driver.subscribe(ctx, bus, name, raw)
 		if err != nil {
 			return nil, nil, fmt.Errorf("%s: %w", bus.driver.name(), err)
 		}
@@ -196,6 +245,29 @@ func (bus *EventBus) Subscribe(ctx context.Context, names ...string) (<-chan eve
 	return bus.fanInEvents(rcpts), fanInErrors(rcpts), nil
 }
 
+// Errors returns a channel of all asynchronous errors that occur within the
+// subscriptions of the event bus, e.g. errors that occur when decoding a
+// received event, or dropped events because of a slow consumer. Errors are
+// received regardless of the EatErrors option. The returned channel is closed
+// when ctx is canceled.
+//
+// The event bus never blocks because of a slow receiver of the returned
+// channel; errors are queued in memory until they are received. If the
+// ErrorHistory option is used, the last errors that occurred before the call
+// to Errors are sent into the channel first.
+func (bus *EventBus) Errors(ctx context.Context) <-chan error {
+	return bus.errors.subscribe(ctx)
+}
+
+// DroppedErrors returns the number of errors that were not sent into the
+// error channel of a subscription because its receiver did not keep up. A
+// subscription buffers a limited number of errors, so that a receiver that
+// does not receive from its error channel cannot block the delivery of
+// events. Dropped errors are still received from Errors.
+func (bus *EventBus) DroppedErrors() uint64 {
+	return bus.droppedErrors.Load()
+}
+
 func (bus *EventBus) init(opts ...EventBusOption) {
 	var envOpts []EventBusOption
 
@@ -215,6 +287,8 @@ func (bus *EventBus) init(opts ...EventBusOption) {
 	if bus.driver == nil {
 		bus.driver = Core()
 	}
+
+	bus.errors = newErrorLog(bus.errorHistory)
 }
 
 func (bus *EventBus) natsURL() string {
@@ -227,6 +301,13 @@ func (bus *EventBus) natsURL() string {
 	return nats.DefaultURL
 }
 
+func (bus *EventBus) marshal(ctx context.Context, evt event.Event) ([]byte, error) {
+	if data, ok := evt.Data().(encodedData); ok {
+		return data, nil
+	}
+	return codec.MarshalContext(ctx, bus.enc, evt.Data())
+}
+
 func (bus *EventBus) fanInEvents(rcpts []recipient) <-chan event.Event {
 	out := make(chan event.Event)
 
@@ -307,3 +388,41 @@ func discardErrors(rcpts ...recipient) {
 		}(rcpt)
 	}
 }
+
+func (env envelope) raw() event.RawEvent {
+	return event.RawEvent{
+		ID:               env.ID,
+		Name:             env.Name,
+		Time:             env.Time,
+		Aggregate:        event.AggregateRef{Name: env.AggregateName, ID: env.AggregateID},
+		AggregateVersion: env.AggregateVersion,
+		Data:             env.Data,
+	}
+}
+
+// encodedData is the data of events that are published using PublishRaw, and
+// of events that are sent to raw subscribers.
+type encodedData []byte
+
+func rawToEvent(raw event.RawEvent) event.Event {
+	return event.New[any](
+		raw.Name,
+		encodedData(raw.Data),
+		event.ID(raw.ID),
+		event.Time(raw.Time),
+		event.Aggregate(raw.Aggregate.ID, raw.Aggregate.Name, raw.AggregateVersion),
+	)
+}
+
+func eventToRaw(evt event.Event) event.RawEvent {
+	id, name, v := evt.Aggregate()
+	data, _ := evt.Data().(encodedData)
+	return event.RawEvent{
+		ID:               evt.ID(),
+		Name:             evt.Name(),
+		Time:             evt.Time(),
+		Aggregate:        event.AggregateRef{Name: name, ID: id},
+		AggregateVersion: v,
+		Data:             data,
+	}
+}
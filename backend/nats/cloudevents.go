@@ -0,0 +1,72 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/cloudevents"
+	"github.com/nats-io/nats.go"
+)
+
+// CloudEventMsg returns a NATS message that carries the given event as a
+// CloudEvent, using the NATS protocol binding of CloudEvents. In structured
+// mode (the default), the message data is the JSON-encoded CloudEvent. If the
+// Format uses the BinaryMode option, the attributes of the event are written
+// to "ce-" headers like in the HTTP binding, and the message data is the event
+// data.
+//
+//	f := cloudevents.New(enc, "https://example.com/orders")
+//	msg, err := nats.CloudEventMsg(ctx, f, "orders", evt)
+//	err = nc.PublishMsg(msg)
+func CloudEventMsg(ctx context.Context, f *cloudevents.Format, subject string, evt event.Event) (*nats.Msg, error) {
+	ce, err := f.Encode(ctx, evt)
+	if err != nil {
+		return nil, err
+	}
+
+	h := make(http.Header)
+	body, err := cloudevents.WriteHTTP(h, ce, f.Binary())
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = body
+	for key, values := range h {
+		for _, v := range values {
+			msg.Header.Add(key, v)
+		}
+	}
+
+	return msg, nil
+}
+
+// CloudEvent decodes the CloudEvent that is carried by the given NATS message
+// into a goes event. Messages without headers are read as structured
+// CloudEvents, as defined by version 1.0 of the NATS protocol binding.
+func CloudEvent(ctx context.Context, f *cloudevents.Format, msg *nats.Msg) (event.Event, error) {
+	h := make(http.Header, len(msg.Header))
+	for key, values := range msg.Header {
+		for _, v := range values {
+			h.Add(key, v)
+		}
+	}
+
+	var (
+		ce  cloudevents.Event
+		err error
+	)
+
+	if h.Get("Content-Type") == "" && h.Get("Ce-Specversion") == "" {
+		if err := json.Unmarshal(msg.Data, &ce); err != nil {
+			return nil, fmt.Errorf("decode cloudevent: %w", err)
+		}
+	} else if ce, err = cloudevents.ReadHTTP(h, msg.Data); err != nil {
+		return nil, err
+	}
+
+	return f.Decode(ctx, ce)
+}
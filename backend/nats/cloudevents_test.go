@@ -0,0 +1,59 @@
+//go:build nats
+
+package nats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/nats"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/cloudevents"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestCloudEventMsg(t *testing.T) {
+	ctx := context.Background()
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "order", 1))
+
+	for _, f := range []*cloudevents.Format{
+		cloudevents.New(test.NewEncoder(), "test"),
+		cloudevents.New(test.NewEncoder(), "test", cloudevents.BinaryMode()),
+	} {
+		msg, err := nats.CloudEventMsg(ctx, f, "orders", evt)
+		if err != nil {
+			t.Fatalf("CloudEventMsg() failed with %q", err)
+		}
+
+		if msg.Subject != "orders" {
+			t.Fatalf("message subject should be %q; got %q", "orders", msg.Subject)
+		}
+
+		decoded, err := nats.CloudEvent(ctx, f, msg)
+		if err != nil {
+			t.Fatalf("CloudEvent() failed with %q", err)
+		}
+
+		test.AssertEqualEvents(t, []event.Event{evt}, []event.Event{decoded})
+	}
+}
+
+func TestCloudEvent_structuredWithoutHeaders(t *testing.T) {
+	ctx := context.Background()
+	f := cloudevents.New(test.NewEncoder(), "test")
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"})
+
+	msg, err := nats.CloudEventMsg(ctx, f, "orders", evt)
+	if err != nil {
+		t.Fatalf("CloudEventMsg() failed with %q", err)
+	}
+	msg.Header = nil
+
+	decoded, err := nats.CloudEvent(ctx, f, msg)
+	if err != nil {
+		t.Fatalf("CloudEvent() failed with %q", err)
+	}
+
+	test.AssertEqualEvents(t, []event.Event{evt}, []event.Event{decoded})
+}
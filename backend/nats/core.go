@@ -29,13 +29,13 @@ type core struct {
 
 func (core *core) name() string { return coreDriverName }
 
-func (core *core) subscribe(ctx context.Context, bus *EventBus, event string) (recipient, error) {
+func (core *core) subscribe(ctx context.Context, bus *EventBus, event string, raw bool) (recipient, error) {
 	core.Lock()
 	defer core.Unlock()
 
 	// If a subscription for that event already exists, return it.
 	if sub, ok := core.subs[event]; ok {
-		return sub.subscribe(ctx)
+		return sub.subscribe(ctx, raw)
 	}
 
 	msgs := make(chan []byte)
@@ -64,7 +64,7 @@ func (core *core) subscribe(ctx context.Context, bus *EventBus, event string) (r
 	sub := newSubscription(event, bus, nsub, msgs)
 	core.subs[event] = sub
 
-	rcpt, err := sub.subscribe(ctx)
+	rcpt, err := sub.subscribe(ctx, raw)
 	if err != nil {
 		return rcpt, err
 	}
@@ -82,7 +82,7 @@ func (core *core) subscribe(ctx context.Context, bus *EventBus, event string) (r
 }
 
 func (core *core) publish(ctx context.Context, bus *EventBus, evt event.Event) error {
-	b, err := bus.enc.Marshal(evt.Data())
+	b, err := bus.marshal(ctx, evt)
 	if err != nil {
 		return fmt.Errorf("encode event data: %w [event=%v, type(data)=%T]", err, evt.Name(), evt.Data())
 	}
@@ -5,11 +5,13 @@ package nats_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/modernice/goes/backend/nats"
 	"github.com/modernice/goes/backend/testing/eventbustest"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
+	eventtest "github.com/modernice/goes/event/test"
 )
 
 func TestEventBus_Core(t *testing.T) {
@@ -37,3 +39,46 @@ func newQueueCoreEventBus(enc codec.Encoding) event.Bus {
 func coreCleanup(bus *nats.EventBus) error {
 	return bus.Disconnect(context.Background())
 }
+
+func TestEventBus_SubscribeRaw(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	enc := eventtest.NewEncoder()
+	bus := nats.NewEventBus(enc, nats.SubjectPrefix("core_raw:"))
+	defer coreCleanup(bus)
+
+	// The relay does not know any event data types.
+	relay := nats.NewEventBus(codec.New(), nats.SubjectPrefix("core_raw:"))
+	defer coreCleanup(relay)
+
+	events, errs, err := relay.SubscribeRaw(ctx, "foo")
+	if err != nil {
+		t.Fatalf("SubscribeRaw failed with %q", err)
+	}
+
+	evt := event.New("foo", eventtest.FooEventData{A: "foo"})
+	if err := bus.Publish(ctx, evt.Any()); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	var raw event.RawEvent
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for raw event")
+	case err := <-errs:
+		t.Fatalf("subscription failed with %q", err)
+	case raw = <-events:
+	}
+
+	if raw.ID != evt.ID() || raw.Name != evt.Name() {
+		t.Fatalf("received raw event should be the published event")
+	}
+
+	decoded, err := raw.Decode(enc)
+	if err != nil {
+		t.Fatalf("decode raw event: %v", err)
+	}
+
+	eventtest.AssertEqualEvents(t, []event.Event{evt.Any()}, []event.Event{decoded})
+}
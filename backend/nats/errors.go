@@ -0,0 +1,108 @@
+package nats
+
+import (
+	"context"
+	"sync"
+)
+
+// errorLog collects the asynchronous errors of an EventBus and distributes
+// them to the subscribers of EventBus.Errors(). Adding an error never blocks:
+// every subscriber has its own unbounded queue that is drained by a separate
+// goroutine. The last `size` errors are kept in memory and replayed to new
+// subscribers.
+type errorLog struct {
+	mux     sync.Mutex
+	size    int
+	history []error
+	subs    map[*errorSubscriber]struct{}
+}
+
+type errorSubscriber struct {
+	mux    sync.Mutex
+	queue  []error
+	notify chan struct{}
+}
+
+func newErrorLog(size int) *errorLog {
+	return &errorLog{
+		size: size,
+		subs: make(map[*errorSubscriber]struct{}),
+	}
+}
+
+func (l *errorLog) add(err error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.size > 0 {
+		if len(l.history) >= l.size {
+			l.history = append(l.history[:0], l.history[len(l.history)-l.size+1:]...)
+		}
+		l.history = append(l.history, err)
+	}
+
+	for sub := range l.subs {
+		sub.push(err)
+	}
+}
+
+func (l *errorLog) subscribe(ctx context.Context) <-chan error {
+	sub := &errorSubscriber{notify: make(chan struct{}, 1)}
+
+	l.mux.Lock()
+	sub.push(l.history...)
+	l.subs[sub] = struct{}{}
+	l.mux.Unlock()
+
+	out := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer func() {
+			l.mux.Lock()
+			defer l.mux.Unlock()
+			delete(l.subs, sub)
+		}()
+
+		for {
+			for _, err := range sub.pop() {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- err:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub.notify:
+			}
+		}
+	}()
+
+	return out
+}
+
+func (sub *errorSubscriber) push(errs ...error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	sub.mux.Lock()
+	sub.queue = append(sub.queue, errs...)
+	sub.mux.Unlock()
+
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *errorSubscriber) pop() []error {
+	sub.mux.Lock()
+	defer sub.mux.Unlock()
+	errs := sub.queue
+	sub.queue = nil
+	return errs
+}
@@ -0,0 +1,121 @@
+//go:build nats
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorHistory(t *testing.T) {
+	bus := NewEventBus(nil, ErrorHistory(2))
+
+	errs := []error{errors.New("foo"), errors.New("bar"), errors.New("baz")}
+	for _, err := range errs {
+		bus.errors.add(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := bus.Errors(ctx)
+
+	for _, want := range errs[1:] {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q error", want)
+		case err := <-received:
+			if err != want {
+				t.Fatalf("expected %q error; got %q", want, err)
+			}
+		}
+	}
+
+	select {
+	case err := <-received:
+		t.Fatalf("only the last %d errors should be replayed; got %q", 2, err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventBus_Errors_nonBlocking(t *testing.T) {
+	bus := NewEventBus(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := bus.Errors(ctx)
+
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		for i := 0; i < 100; i++ {
+			bus.errors.add(fmt.Errorf("error %d", i))
+		}
+	}()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("adding errors should not block")
+	case <-added:
+	}
+
+	for i := 0; i < 100; i++ {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for error %d", i)
+		case err := <-received:
+			if want := fmt.Sprintf("error %d", i); err.Error() != want {
+				t.Fatalf("expected %q error; got %q", want, err)
+			}
+		}
+	}
+
+	cancel()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("error channel should be closed after ctx is canceled")
+	case _, ok := <-received:
+		if ok {
+			t.Fatalf("error channel should be closed after ctx is canceled")
+		}
+	}
+}
+
+func TestSubscription_slowRecipient(t *testing.T) {
+	bus := NewEventBus(nil)
+	sub := newSubscription("foo", bus, nil, make(chan []byte))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := sub.subscribe(ctx, false); err != nil {
+		t.Fatalf("subscribe() failed with %q", err)
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < recipientErrorBuffer+10; i++ {
+			sub.err(fmt.Errorf("error %d", i))
+		}
+	}()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("a recipient that does not receive errors should not block the subscription")
+	case <-sent:
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for bus.DroppedErrors() != 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("DroppedErrors() should return %d; got %d", 10, bus.DroppedErrors())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -122,10 +122,10 @@ func (js *jetStream) init(bus *EventBus) (err error) {
 	return
 }
 
-func (js *jetStream) subscribe(ctx context.Context, bus *EventBus, event string) (recipient, error) {
+func (js *jetStream) subscribe(ctx context.Context, bus *EventBus, event string, raw bool) (recipient, error) {
 	// If a subscription already exists for the event, return it.
 	if sub, ok := js.subscription(event); ok {
-		return sub.subscribe(ctx)
+		return sub.subscribe(ctx, raw)
 	}
 
 	msgs := make(chan []byte)
@@ -135,7 +135,7 @@ func (js *jetStream) subscribe(ctx context.Context, bus *EventBus, event string)
 	js.Lock()
 	defer js.Unlock()
 	if sub, ok := js.subs[event]; ok {
-		return sub.subscribe(ctx)
+		return sub.subscribe(ctx, raw)
 	}
 
 	if err := js.ensureStream(ctx); err != nil {
@@ -171,7 +171,7 @@ func (js *jetStream) subscribe(ctx context.Context, bus *EventBus, event string)
 		return recipient{}, err
 	}
 
-	return js.addRecipient(ctx, bus, event, nsub, msgs)
+	return js.addRecipient(ctx, bus, event, nsub, msgs, raw)
 }
 
 func (js *jetStream) natsSubscribe(
@@ -220,11 +220,11 @@ func (js *jetStream) natsSubscribe(
 	return nsub, nil
 }
 
-func (js *jetStream) addRecipient(ctx context.Context, bus *EventBus, event string, nsub *nats.Subscription, msgs chan []byte) (recipient, error) {
+func (js *jetStream) addRecipient(ctx context.Context, bus *EventBus, event string, nsub *nats.Subscription, msgs chan []byte, raw bool) (recipient, error) {
 	sub := newSubscription(event, bus, nsub, msgs)
 	js.subs[event] = sub
 
-	rcpt, err := sub.subscribe(ctx)
+	rcpt, err := sub.subscribe(ctx, raw)
 	if err != nil {
 		return rcpt, err
 	}
@@ -242,7 +242,7 @@ func (js *jetStream) addRecipient(ctx context.Context, bus *EventBus, event stri
 }
 
 func (js *jetStream) publish(ctx context.Context, bus *EventBus, evt event.Event) error {
-	b, err := bus.enc.Marshal(evt.Data())
+	b, err := bus.marshal(ctx, evt)
 	if err != nil {
 		return fmt.Errorf("encode event data: %w [event=%v, type(data)=%T]", err, evt.Name(), evt.Data())
 	}
@@ -0,0 +1,68 @@
+package nats
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// KeyValue returns the JetStream key-value bucket with the given name. If the
+// bucket does not exist, it is created using the provided configuration. The
+// Bucket field of the configuration is set to bucket.
+//
+//	nc, err := nats.Connect(nats.DefaultURL)
+//	js, err := nc.JetStream()
+//	kv, err := nats.KeyValue(js, "snapshots", nats.KeyValueConfig{})
+//	store := nats.NewSnapshotStore(kv)
+func KeyValue(js nats.JetStreamContext, bucket string, cfg nats.KeyValueConfig) (nats.KeyValue, error) {
+	kv, err := js.KeyValue(bucket)
+	if err == nil {
+		return kv, nil
+	}
+
+	if !errors.Is(err, nats.ErrBucketNotFound) {
+		return nil, fmt.Errorf("get %q bucket: %w", bucket, err)
+	}
+
+	cfg.Bucket = bucket
+	if kv, err = js.CreateKeyValue(&cfg); err != nil {
+		return nil, fmt.Errorf("create %q bucket: %w", bucket, err)
+	}
+
+	return kv, nil
+}
+
+// encodeKeyToken encodes s into a token that can be used as part of a key.
+// Keys of key-value buckets may only contain a limited set of characters, and
+// use "." as the token separator.
+func encodeKeyToken(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// watchEntries returns the current entries of the keys that match the pattern.
+// Deleted keys are ignored.
+func watchEntries(ctx context.Context, kv nats.KeyValue, pattern string, opts ...nats.WatchOpt) ([]nats.KeyValueEntry, error) {
+	opts = append(opts, nats.IgnoreDeletes(), nats.Context(ctx))
+
+	w, err := kv.Watch(pattern, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("watch %q: %w", pattern, err)
+	}
+	defer w.Stop()
+
+	var entries []nats.KeyValueEntry
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case entry, ok := <-w.Updates():
+			if !ok || entry == nil {
+				return entries, nil
+			}
+			entries = append(entries, entry)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build nats
+
+package nats_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/snapshot"
+	"github.com/modernice/goes/aggregate/snapshot/storetest"
+	"github.com/modernice/goes/backend/nats"
+	"github.com/modernice/goes/projection"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+var bucketID int64
+
+func TestSnapshotStore(t *testing.T) {
+	storetest.Run(t, func() snapshot.Store {
+		return nats.NewSnapshotStore(newKeyValue(t))
+	})
+}
+
+func TestProgressStore(t *testing.T) {
+	ctx := context.Background()
+	store := nats.NewProgressStore(newKeyValue(t))
+
+	var p projection.Progressor
+	if err := store.Load(ctx, "foo.bar", &p); err != nil {
+		t.Fatalf("Load() failed with %q", err)
+	}
+
+	if progress, ids := p.Progress(); !progress.IsZero() || len(ids) != 0 {
+		t.Fatalf("projection should have no progress; got %v %v", progress, ids)
+	}
+
+	now := time.Now()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	p.SetProgress(now, ids...)
+
+	if err := store.Save(ctx, "foo.bar", &p); err != nil {
+		t.Fatalf("Save() failed with %q", err)
+	}
+
+	var loaded projection.Progressor
+	if err := store.Load(ctx, "foo.bar", &loaded); err != nil {
+		t.Fatalf("Load() failed with %q", err)
+	}
+
+	progress, loadedIDs := loaded.Progress()
+	if !progress.Equal(now) {
+		t.Fatalf("progress time should be %v; got %v", now, progress)
+	}
+
+	if len(loadedIDs) != len(ids) || loadedIDs[0] != ids[0] || loadedIDs[1] != ids[1] {
+		t.Fatalf("progress ids should be %v; got %v", ids, loadedIDs)
+	}
+
+	if err := store.DeleteProgress(ctx, "foo.bar"); err != nil {
+		t.Fatalf("DeleteProgress() failed with %q", err)
+	}
+
+	progress, loadedIDs, err := store.Progress(ctx, "foo.bar")
+	if err != nil {
+		t.Fatalf("Progress() failed with %q", err)
+	}
+
+	if !progress.IsZero() || len(loadedIDs) != 0 {
+		t.Fatalf("progress should be deleted; got %v %v", progress, loadedIDs)
+	}
+}
+
+func newKeyValue(t *testing.T) natsgo.KeyValue {
+	nc, err := natsgo.Connect(os.Getenv("JETSTREAM_URL"))
+	if err != nil {
+		t.Fatalf("connect to NATS: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("get JetStream context: %v", err)
+	}
+
+	bucket := fmt.Sprintf("kv_test_%d", atomic.AddInt64(&bucketID, 1))
+	kv, err := nats.KeyValue(js, bucket, natsgo.KeyValueConfig{})
+	if err != nil {
+		t.Fatalf("KeyValue() failed with %q", err)
+	}
+	t.Cleanup(func() { js.DeleteKeyValue(bucket) })
+
+	return kv
+}
@@ -48,6 +48,16 @@ func EatErrors() EventBusOption {
 	}
 }
 
+// ErrorHistory returns an option that keeps the last n asynchronous errors of
+// the event bus in memory. New receivers of EventBus.Errors() first receive
+// these errors, so that errors that occur before anyone calls Errors() are
+// not lost. Default is 0, which means that no errors are kept.
+func ErrorHistory(n int) EventBusOption {
+	return func(bus *EventBus) {
+		bus.errorHistory = n
+	}
+}
+
 // QueueGroup returns an option that specifies the NATS queue group for
 // new subscriptions. When subscribing to an event, fn(eventName) is called to
 // determine the queue group name for that subscription. If the returned queue
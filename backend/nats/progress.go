@@ -0,0 +1,132 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/projection"
+	"github.com/nats-io/nats.go"
+)
+
+// ProgressStore stores the progress of projections in a JetStream key-value
+// bucket, so that projections can be resumed after a restart without a second
+// database for checkpoints. The progress of a projection is stored under the
+// base64-encoded name of the projection.
+//
+//	store := nats.NewProgressStore(kv)
+//	if err := store.Load(ctx, "order-list", list); err != nil {
+//		return err
+//	}
+//	// apply events to the list
+//	if err := store.Save(ctx, "order-list", list); err != nil {
+//		return err
+//	}
+type ProgressStore struct {
+	kv nats.KeyValue
+}
+
+type progressEntry struct {
+	TimeNano int64       `json:"timeNano"`
+	IDs      []uuid.UUID `json:"ids"`
+}
+
+// NewProgressStore returns a ProgressStore that stores the progress of
+// projections in the given key-value bucket. Use KeyValue to get or create the
+// bucket.
+func NewProgressStore(kv nats.KeyValue) *ProgressStore {
+	return &ProgressStore{kv: kv}
+}
+
+// Progress returns the progress of the given projection in terms of the time
+// and ids of the last applied events. If no progress was saved for the
+// projection, the zero time and no ids are returned.
+func (s *ProgressStore) Progress(ctx context.Context, name string) (time.Time, []uuid.UUID, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, nil, err
+	}
+
+	entry, err := s.kv.Get(encodeKeyToken(name))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return time.Time{}, nil, nil
+	}
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("get %q progress: %w", name, err)
+	}
+
+	var e progressEntry
+	if err := json.Unmarshal(entry.Value(), &e); err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode %q progress: %w", name, err)
+	}
+
+	if e.TimeNano == 0 {
+		return time.Time{}, e.IDs, nil
+	}
+
+	return time.Unix(0, e.TimeNano), e.IDs, nil
+}
+
+// SaveProgress saves the progress of the given projection.
+func (s *ProgressStore) SaveProgress(ctx context.Context, name string, t time.Time, ids ...uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var e progressEntry
+	if !t.IsZero() {
+		e.TimeNano = t.UnixNano()
+	}
+	e.IDs = ids
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode %q progress: %w", name, err)
+	}
+
+	if _, err := s.kv.Put(encodeKeyToken(name), b); err != nil {
+		return fmt.Errorf("put %q progress: %w", name, err)
+	}
+
+	return nil
+}
+
+// DeleteProgress deletes the progress of the given projection, so that the
+// projection is rebuilt from scratch the next time it is loaded.
+func (s *ProgressStore) DeleteProgress(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.kv.Purge(encodeKeyToken(name)); err != nil {
+		return fmt.Errorf("purge %q progress: %w", name, err)
+	}
+
+	return nil
+}
+
+// Load sets the progress of the projection to the saved progress of the
+// projection with the given name. If no progress was saved, the projection is
+// not modified.
+func (s *ProgressStore) Load(ctx context.Context, name string, target projection.ProgressAware) error {
+	t, ids, err := s.Progress(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if t.IsZero() && len(ids) == 0 {
+		return nil
+	}
+
+	target.SetProgress(t, ids...)
+
+	return nil
+}
+
+// Save saves the current progress of the projection under the given name.
+func (s *ProgressStore) Save(ctx context.Context, name string, target projection.ProgressAware) error {
+	t, ids := target.Progress()
+	return s.SaveProgress(ctx, name, t, ids...)
+}
@@ -0,0 +1,211 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/snapshot"
+	"github.com/nats-io/nats.go"
+)
+
+var _ snapshot.Store = (*SnapshotStore)(nil)
+
+// SnapshotStore is a snapshot.Store that stores snapshots in a JetStream
+// key-value bucket, so that deployments that already use NATS do not need a
+// second database for snapshots. Each snapshot is stored under its own key:
+//
+//	<aggregate name>.<aggregate id>.<aggregate version>
+//
+// The aggregate name is base64-encoded, because aggregate names may contain
+// characters that are not allowed in keys.
+type SnapshotStore struct {
+	kv nats.KeyValue
+}
+
+type snapshotEntry struct {
+	AggregateName    string    `json:"aggregateName"`
+	AggregateID      uuid.UUID `json:"aggregateId"`
+	AggregateVersion int       `json:"aggregateVersion"`
+	TimeNano         int64     `json:"timeNano"`
+	Data             []byte    `json:"data"`
+}
+
+// NewSnapshotStore returns a SnapshotStore that stores snapshots in the given
+// key-value bucket. Use KeyValue to get or create the bucket.
+func NewSnapshotStore(kv nats.KeyValue) *SnapshotStore {
+	return &SnapshotStore{kv: kv}
+}
+
+// Save saves the given snapshot into the store. An existing snapshot with the
+// same aggregate version is replaced.
+func (s *SnapshotStore) Save(ctx context.Context, snap snapshot.Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(snapshotEntry{
+		AggregateName:    snap.AggregateName(),
+		AggregateID:      snap.AggregateID(),
+		AggregateVersion: snap.AggregateVersion(),
+		TimeNano:         snap.Time().UnixNano(),
+		Data:             snap.State(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	key := snapshotKey(snap.AggregateName(), snap.AggregateID(), snap.AggregateVersion())
+	if _, err := s.kv.Put(key, b); err != nil {
+		return fmt.Errorf("put %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// Latest returns the snapshot with the highest version of the given aggregate.
+func (s *SnapshotStore) Latest(ctx context.Context, name string, id uuid.UUID) (snapshot.Snapshot, error) {
+	return s.Limit(ctx, name, id, math.MaxInt)
+}
+
+// Version returns the snapshot with the given version of the given aggregate.
+func (s *SnapshotStore) Version(ctx context.Context, name string, id uuid.UUID, v int) (snapshot.Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key := snapshotKey(name, id, v)
+	entry, err := s.kv.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil, snapshot.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get %q: %w", key, err)
+	}
+
+	return decodeSnapshot(entry)
+}
+
+// Limit returns the latest snapshot of the given aggregate whose version is
+// equal to or lower than v.
+func (s *SnapshotStore) Limit(ctx context.Context, name string, id uuid.UUID, v int) (snapshot.Snapshot, error) {
+	entries, err := watchEntries(ctx, s.kv, aggregateSnapshotsKey(name, id)+".*", nats.MetaOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	found := -1
+	for _, entry := range entries {
+		version, err := snapshotKeyVersion(entry.Key())
+		if err != nil {
+			continue
+		}
+
+		if version > found && version <= v {
+			found = version
+		}
+	}
+
+	if found < 0 {
+		return nil, snapshot.ErrNotFound
+	}
+
+	return s.Version(ctx, name, id, found)
+}
+
+// Query queries the store for snapshots that match the query. If the query
+// filters by aggregate names, only the keys of those aggregates are read.
+// Otherwise, all snapshots in the bucket are read and filtered in memory.
+func (s *SnapshotStore) Query(ctx context.Context, q snapshot.Query) (<-chan snapshot.Snapshot, <-chan error, error) {
+	patterns := []string{">"}
+	if names := q.Names(); len(names) > 0 {
+		patterns = patterns[:0]
+		for _, name := range names {
+			patterns = append(patterns, encodeKeyToken(name)+".>")
+		}
+	}
+
+	var snaps []snapshot.Snapshot
+	for _, pattern := range patterns {
+		entries, err := watchEntries(ctx, s.kv, pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, entry := range entries {
+			snap, err := decodeSnapshot(entry)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if snapshot.Test(q, snap) {
+				snaps = append(snaps, snap)
+			}
+		}
+	}
+
+	snaps = snapshot.SortMulti(snaps, q.Sortings()...)
+
+	out, errs := make(chan snapshot.Snapshot), make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+		for _, snap := range snaps {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- snap:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// Delete deletes the given snapshot from the store. The key of the snapshot is
+// purged from the bucket.
+func (s *SnapshotStore) Delete(ctx context.Context, snap snapshot.Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := snapshotKey(snap.AggregateName(), snap.AggregateID(), snap.AggregateVersion())
+	if err := s.kv.Purge(key); err != nil {
+		return fmt.Errorf("purge %q: %w", key, err)
+	}
+
+	return nil
+}
+
+func decodeSnapshot(entry nats.KeyValueEntry) (snapshot.Snapshot, error) {
+	var e snapshotEntry
+	if err := json.Unmarshal(entry.Value(), &e); err != nil {
+		return nil, fmt.Errorf("decode snapshot %q: %w", entry.Key(), err)
+	}
+
+	return snapshot.New(
+		aggregate.New(e.AggregateName, e.AggregateID, aggregate.Version(e.AggregateVersion)),
+		snapshot.Time(stdtime.Unix(0, e.TimeNano)),
+		snapshot.Data(e.Data),
+	)
+}
+
+func aggregateSnapshotsKey(name string, id uuid.UUID) string {
+	return encodeKeyToken(name) + "." + id.String()
+}
+
+func snapshotKey(name string, id uuid.UUID, v int) string {
+	return aggregateSnapshotsKey(name, id) + "." + strconv.Itoa(v)
+}
+
+func snapshotKeyVersion(key string) (int, error) {
+	return strconv.Atoi(key[strings.LastIndexByte(key, '.')+1:])
+}
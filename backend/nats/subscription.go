@@ -12,6 +12,10 @@ import (
 	"github.com/nats-io/nats.go"
 )
 
+// recipientErrorBuffer is the number of errors that are buffered for a
+// recipient before further errors are dropped (see EventBus.DroppedErrors).
+const recipientErrorBuffer = 16
+
 type subscription struct {
 	event string
 
@@ -23,6 +27,7 @@ type subscription struct {
 	subscribeQueue   chan subscribeJob
 	unsubscribeQueue chan subscribeJob
 	logQueue         chan logJob
+	errors           *errorLog
 	stop             chan struct{}
 }
 
@@ -31,6 +36,9 @@ type recipient struct {
 	events   chan event.Event
 	errs     chan error
 	unsubbed chan struct{}
+
+	// If true, the recipient receives events without decoded data.
+	raw bool
 }
 
 type subscribeJob struct {
@@ -56,6 +64,7 @@ func newSubscription(
 		subscribeQueue:   make(chan subscribeJob),
 		unsubscribeQueue: make(chan subscribeJob),
 		logQueue:         make(chan logJob),
+		errors:           bus.errors,
 		stop:             bus.stop,
 	}
 	go out.work(bus)
@@ -70,17 +79,21 @@ func (sub *subscription) work(bus *EventBus) {
 			return
 
 		case job := <-sub.logQueue:
+			sub.errors.add(job.err)
+
 			rcpts := job.recipients
 			if rcpts == nil {
 				rcpts = sub.recipients
 			}
 
+			// A recipient that does not receive its errors must not block
+			// the delivery of events to the other recipients.
 			for _, rcpt := range rcpts {
 				select {
-				case <-sub.stop:
-					return
 				case <-rcpt.unsubbed:
 				case rcpt.errs <- job.err:
+				default:
+					bus.droppedErrors.Add(1)
 				}
 			}
 
@@ -123,24 +136,27 @@ func (sub *subscription) send(bus *EventBus, msg []byte) error {
 		return fmt.Errorf("gob decode envelope: %w", err)
 	}
 
-	data, err := bus.enc.Unmarshal(env.Data, env.Name)
-	if err != nil {
-		return fmt.Errorf("decode event data: %w [event=%v]", err, env.Name)
-	}
+	raw := env.raw()
 
-	evt := event.New(
-		env.Name,
-		data,
-		event.ID(env.ID),
-		event.Time(env.Time),
-		event.Aggregate(
-			env.AggregateID,
-			env.AggregateName,
-			env.AggregateVersion,
-		),
+	var (
+		decoded   event.Event
+		decodeErr error
 	)
 
 	for _, rcpt := range sub.recipients {
+		var evt event.Event
+		if rcpt.raw {
+			evt = rawToEvent(raw)
+		} else {
+			if decoded == nil && decodeErr == nil {
+				decoded, decodeErr = raw.Decode(bus.enc)
+			}
+			if decodeErr != nil {
+				continue
+			}
+			evt = decoded
+		}
+
 		select {
 		case <-rcpt.sub.stop:
 			return nil
@@ -148,17 +164,22 @@ func (sub *subscription) send(bus *EventBus, msg []byte) error {
 		}
 	}
 
+	if decodeErr != nil {
+		return fmt.Errorf("decode event data: %w [event=%v]", errors.Unwrap(decodeErr), env.Name)
+	}
+
 	return nil
 }
 
-func (sub *subscription) subscribe(ctx context.Context) (recipient, error) {
+func (sub *subscription) subscribe(ctx context.Context, raw bool) (recipient, error) {
 	done := make(chan struct{})
 
 	rcpt := recipient{
 		sub:      sub,
 		events:   make(chan event.Event),
-		errs:     make(chan error),
+		errs:     make(chan error, recipientErrorBuffer),
 		unsubbed: make(chan struct{}),
+		raw:      raw,
 	}
 
 	select {
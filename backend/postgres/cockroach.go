@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"github.com/modernice/goes/event"
+)
+
+// FollowerReads returns an EventStoreOption that runs catch-up queries as
+// CockroachDB follower reads, using
+//
+//	AS OF SYSTEM TIME follower_read_timestamp()
+//
+// Follower reads are served by the nearest replica instead of the leaseholder,
+// but do not see events that were inserted within the last few seconds.
+// Insert, Find, and Delete are unaffected and stay strongly consistent.
+//
+// By default, only queries that do not filter by aggregate are run as
+// follower reads, which covers the catch-up queries of projection jobs but
+// not the queries that the aggregate repository uses to fetch aggregates.
+// Use FollowerReadQueries to configure which queries are run as follower
+// reads.
+//
+// FollowerReads must only be used with CockroachDB; PostgreSQL does not
+// support AS OF SYSTEM TIME.
+func FollowerReads() EventStoreOption {
+	return func(store *EventStore) {
+		if store.followerReads == nil {
+			store.followerReads = isCatchUpQuery
+		}
+	}
+}
+
+// FollowerReadQueries returns an EventStoreOption that runs the queries for
+// which fn returns true as CockroachDB follower reads. See FollowerReads.
+func FollowerReadQueries(fn func(event.Query) bool) EventStoreOption {
+	return func(store *EventStore) {
+		store.followerReads = fn
+	}
+}
+
+const followerReadClause = "AS OF SYSTEM TIME follower_read_timestamp()"
+
+func (store *EventStore) isFollowerRead(q event.Query) bool {
+	return store.followerReads != nil && store.followerReads(q)
+}
+
+// isCatchUpQuery returns whether q does not filter by aggregate.
+func isCatchUpQuery(q event.Query) bool {
+	return len(q.AggregateIDs()) == 0 && len(q.Aggregates()) == 0
+}
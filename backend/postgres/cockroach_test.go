@@ -0,0 +1,88 @@
+//go:build cockroach
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/postgres"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore_cockroach(t *testing.T) {
+	eventstoretest.Run(t, "cockroach", func(enc codec.Encoding) event.Store {
+		return postgres.NewEventStore(enc, postgres.URL(os.Getenv("COCKROACH_EVENTSTORE")), postgres.Database(nextCockroachDatabase()))
+	})
+}
+
+func TestFollowerReads(t *testing.T) {
+	ctx := context.Background()
+
+	store := postgres.NewEventStore(
+		etest.NewEncoder(),
+		postgres.URL(os.Getenv("COCKROACH_EVENTSTORE")),
+		postgres.Database(nextCockroachDatabase()),
+		postgres.FollowerReads(),
+	)
+
+	if err := store.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed with %q", err)
+	}
+
+	// wait until the table is visible to follower reads
+	time.Sleep(6 * time.Second)
+
+	id := uuid.New()
+	evt := event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1))
+	if err := store.Insert(ctx, evt); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if events := queryCockroach(t, store, query.New(query.Name("foo"))); len(events) != 0 {
+		t.Fatalf("catch-up query should not see the event yet; got %d events", len(events))
+	}
+
+	if events := queryCockroach(t, store, query.New(query.Aggregate("foo", id))); len(events) != 1 {
+		t.Fatalf("aggregate query should see the event; got %d events", len(events))
+	}
+
+	time.Sleep(6 * time.Second)
+
+	if events := queryCockroach(t, store, query.New(query.Name("foo"))); len(events) != 1 {
+		t.Fatalf("catch-up query should see the event after the follower read delay; got %d events", len(events))
+	}
+}
+
+func queryCockroach(t *testing.T, store event.Store, q event.Query) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	return events
+}
+
+var cockroachDatabaseN uint64
+
+func nextCockroachDatabase() string {
+	n := atomic.AddUint64(&cockroachDatabaseN, 1)
+	return fmt.Sprintf("goes_cockroach_%d", n)
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/modernice/goes/event"
+)
+
+// Stats returns statistics about the events in the store. Event counts and
+// times are computed by a single grouped query over the event table; the
+// number of aggregates is counted using the aggregate index.
+func (store *EventStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	if err := store.Connect(ctx); err != nil {
+		return event.StoreStats{}, fmt.Errorf("connect: %w", err)
+	}
+
+	rows, err := store.pool.Query(ctx, fmt.Sprintf(
+		`SELECT name, COUNT(*), MIN(time), MAX(time) FROM %s GROUP BY name`,
+		store.table,
+	))
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("query event counts: %w", err)
+	}
+	defer rows.Close()
+
+	out := event.StoreStats{EventsPerName: make(map[string]int)}
+	for rows.Next() {
+		var (
+			name           string
+			count          int
+			oldest, newest int64
+		)
+		if err := rows.Scan(&name, &count, &oldest, &newest); err != nil {
+			return event.StoreStats{}, fmt.Errorf("scan row: %w", err)
+		}
+		out = out.Merge(event.StoreStats{
+			Events:        count,
+			EventsPerName: map[string]int{name: count},
+			Oldest:        time.Unix(0, oldest),
+			Newest:        time.Unix(0, newest),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return event.StoreStats{}, fmt.Errorf("rows: %w", err)
+	}
+
+	if err := store.pool.QueryRow(ctx, fmt.Sprintf(
+		`SELECT COUNT(*) FROM (SELECT DISTINCT aggregate_id, aggregate_name FROM %s WHERE aggregate_name IS NOT NULL AND aggregate_name <> '') AS aggregates`,
+		store.table,
+	)).Scan(&out.Aggregates); err != nil {
+		return event.StoreStats{}, fmt.Errorf("count aggregates: %w", err)
+	}
+
+	return out, nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -29,6 +30,7 @@ type EventStore struct {
 	table         string
 	pool          *pgxpool.Pool
 	enc           codec.Encoding
+	followerReads func(event.Query) bool
 }
 
 // EventStoreOption is an optionn for the PostgreSQL event store.
@@ -259,7 +261,7 @@ func (store *EventStore) Insert(ctx context.Context, events ...event.Event) erro
 	for _, evt := range events {
 		aggregateID, aggregateName, aggregateVersion := evt.Aggregate()
 
-		b, err := store.enc.Marshal(evt.Data())
+		b, err := store.marshal(ctx, evt)
 		if err != nil {
 			return fmt.Errorf("marshal %q event data: %w", evt.Name(), err)
 		}
@@ -295,6 +297,35 @@ func (store *EventStore) Insert(ctx context.Context, events ...event.Event) erro
 	return tx.Commit(ctx)
 }
 
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (store *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	evts := make([]event.Event, len(events))
+	for i, raw := range events {
+		evts[i] = event.New[any](
+			raw.Name,
+			encodedData(raw.Data),
+			event.ID(raw.ID),
+			event.Time(raw.Time),
+			event.Aggregate(raw.Aggregate.ID, raw.Aggregate.Name, raw.AggregateVersion),
+		)
+	}
+	return store.Insert(ctx, evts...)
+}
+
+func (store *EventStore) marshal(ctx context.Context, evt event.Event) ([]byte, error) {
+	if data, ok := evt.Data().(encodedData); ok {
+		return data, nil
+	}
+	return codec.MarshalContext(ctx, store.enc, evt.Data())
+}
+
+// encodedData is the data of events that are inserted using InsertRaw.
+type encodedData []byte
+
 // Find fetches the event with the given id from the event store.
 func (store *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
 	if err := store.Connect(ctx); err != nil {
@@ -318,10 +349,10 @@ func (store *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, e
 		return nil, fmt.Errorf("query event: %w", err)
 	}
 
-	return store.decodeEvent(evt)
+	return store.decodeEvent(ctx, evt)
 }
 
-func (store *EventStore) decodeEvent(devt dbevent) (event.Event, error) {
+func (store *EventStore) decodeEvent(ctx context.Context, devt dbevent) (event.Event, error) {
 	opts := []event.Option{event.ID(devt.ID), event.Time(time.Unix(0, devt.Time))}
 	if devt.AggregateID != nil && devt.AggregateName != nil && devt.AggregateVersion != nil {
 		opts = append(opts, event.Aggregate(
@@ -331,7 +362,7 @@ func (store *EventStore) decodeEvent(devt dbevent) (event.Event, error) {
 		))
 	}
 
-	data, err := store.enc.Unmarshal(devt.Data, devt.Name)
+	data, err := codec.UnmarshalContext(ctx, store.enc, devt.Data, devt.Name)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal event data: %w", err)
 	}
@@ -341,18 +372,14 @@ func (store *EventStore) decodeEvent(devt dbevent) (event.Event, error) {
 
 // Query queries the event store for events.
 func (store *EventStore) Query(ctx context.Context, query event.Query) (<-chan event.Event, <-chan error, error) {
-	sql, args, err := store.buildQuery(query)
-	if err != nil {
-		return nil, nil, fmt.Errorf("build query: %w", err)
-	}
-
-	res, err := store.pool.Query(ctx, sql, args...)
+	res, err := store.query(ctx, query)
 	if err != nil {
-		return nil, nil, fmt.Errorf("query events: %w", err)
+		return nil, nil, err
 	}
 
 	out := make(chan event.Event)
 	errs := make(chan error)
+	skip := event.UndecodableHandler(query)
 
 	go func() {
 		defer close(out)
@@ -369,8 +396,13 @@ func (store *EventStore) Query(ctx context.Context, query event.Query) (<-chan e
 				return
 			}
 
-			evt, err := store.decodeEvent(devt)
+			evt, err := store.decodeEvent(ctx, devt)
 			if err != nil {
+				if skip != nil {
+					skip(devt.decodeError(errors.Unwrap(err)))
+					continue
+				}
+
 				select {
 				case <-ctx.Done():
 				case errs <- fmt.Errorf("decode event: %w", err):
@@ -396,10 +428,80 @@ func (store *EventStore) Query(ctx context.Context, query event.Query) (<-chan e
 	return out, errs, nil
 }
 
+// QueryRaw queries the event store for events, like Query does, but returns
+// the events without decoding their data.
+//
+// QueryRaw implements event.RawQuerier.
+func (store *EventStore) QueryRaw(ctx context.Context, query event.Query) (<-chan event.RawEvent, <-chan error, error) {
+	res, err := store.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan event.RawEvent)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+		defer res.Close()
+
+		for res.Next() {
+			var devt dbevent
+			if err := res.Scan(&devt.ID, &devt.Name, &devt.Time, &devt.AggregateID, &devt.AggregateName, &devt.AggregateVersion, &devt.Data); err != nil {
+				select {
+				case <-ctx.Done():
+				case errs <- fmt.Errorf("scan row: %w", err):
+				}
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- devt.raw():
+			}
+		}
+
+		if err := res.Err(); err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case errs <- err:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+func (store *EventStore) query(ctx context.Context, query event.Query) (pgx.Rows, error) {
+	sql, args, err := store.buildQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	res, err := store.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+
+	return res, nil
+}
+
 func (store *EventStore) buildQuery(query event.Query) (string, []any, error) {
+	if err := event.RejectTags(query); err != nil {
+		return "", nil, err
+	}
+
+	from := store.table
+	if store.isFollowerRead(query) {
+		from += " " + followerReadClause
+	}
+
 	builder := squirrel.
 		Select("id", "name", "time", "aggregate_id", "aggregate_name", "aggregate_version", "data").
-		From(store.table).
+		From(from).
 		PlaceholderFormat(squirrel.Dollar)
 
 	if ids := query.AggregateIDs(); len(ids) > 0 {
@@ -484,7 +586,7 @@ func (store *EventStore) buildQuery(query event.Query) (string, []any, error) {
 		}
 	}
 
-	if sortings := query.Sortings(); len(sortings) > 0 {
+	if sortings := event.TieBreak(query.Sortings()); len(sortings) > 0 {
 		orders := make([]string, len(sortings))
 		for i, sorting := range sortings {
 			dir := "ASC"
@@ -502,6 +604,8 @@ func (store *EventStore) buildQuery(query event.Query) (string, []any, error) {
 				field = "aggregate_version"
 			case event.SortTime:
 				field = "time"
+			case event.SortID:
+				field = "id"
 			}
 
 			orders[i] = fmt.Sprintf("%s %s", field, dir)
@@ -554,6 +658,24 @@ type dbevent struct {
 	Data             []byte
 }
 
+func (devt dbevent) raw() event.RawEvent {
+	out := event.RawEvent{
+		ID:   devt.ID,
+		Name: devt.Name,
+		Time: time.Unix(0, devt.Time),
+		Data: devt.Data,
+	}
+	if devt.AggregateID != nil && devt.AggregateName != nil && devt.AggregateVersion != nil {
+		out.Aggregate = event.AggregateRef{Name: *devt.AggregateName, ID: *devt.AggregateID}
+		out.AggregateVersion = *devt.AggregateVersion
+	}
+	return out
+}
+
+func (devt dbevent) decodeError(err error) *event.DecodeError {
+	return &event.DecodeError{RawEvent: devt.raw(), Err: err}
+}
+
 func buildOREq[S ~[]E, E any](field string, values S) squirrel.Or {
 	or := make(squirrel.Or, len(values))
 	for i, v := range values {
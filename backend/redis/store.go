@@ -0,0 +1,573 @@
+// Package redis provides a Redis-backed event store that is intended for
+// short-lived aggregates, ephemeral streams, and tests. Events can be given a
+// TTL per aggregate name, after which Redis evicts them automatically.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist or has
+	// expired.
+	ErrEventNotFound = storage.ErrEventNotFound
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = storage.ErrDuplicateEvent
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = storage.ErrVersionExists
+)
+
+// EventStore is a Redis-backed event store. Each event is stored under its own
+// key, and is indexed in a sorted set of all events (scored by time) and in a
+// sorted set per aggregate (scored by aggregate version).
+//
+// When used with Redis Cluster, the prefix of the store should be a hash tag,
+// e.g. "{goes}", so that all keys of the store are located on the same node.
+type EventStore struct {
+	enc        codec.Encoding
+	url        string
+	prefix     string
+	defaultTTL stdtime.Duration
+	ttls       map[string]stdtime.Duration
+
+	onceConnect sync.Once
+	client      goredis.UniversalClient
+}
+
+// EventStoreOption is an option for the Redis event store.
+type EventStoreOption func(*EventStore)
+
+// Client returns an EventStoreOption that specifies the underlying Redis
+// client. If provided, the URL option is ignored.
+func Client(client goredis.UniversalClient) EventStoreOption {
+	return func(s *EventStore) {
+		s.client = client
+	}
+}
+
+// URL returns an EventStoreOption that specifies the URL of the Redis server,
+// e.g. "redis://localhost:6379/0".
+func URL(url string) EventStoreOption {
+	return func(s *EventStore) {
+		s.url = url
+	}
+}
+
+// Prefix returns an EventStoreOption that specifies the prefix of the keys
+// that are used by the event store. Defaults to "goes".
+func Prefix(prefix string) EventStoreOption {
+	if prefix = strings.TrimSpace(prefix); prefix == "" {
+		panic("prefix cannot be empty")
+	}
+
+	return func(s *EventStore) {
+		s.prefix = prefix
+	}
+}
+
+// TTL returns an EventStoreOption that expires the events of the given
+// aggregate after d. The TTL of an aggregate's events is applied on insert, so
+// changing the TTL does not affect events that are already stored.
+func TTL(aggregateName string, d stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.ttls[aggregateName] = d
+	}
+}
+
+// DefaultTTL returns an EventStoreOption that expires events after d, unless
+// a TTL for the aggregate of the event is configured using TTL. Events that
+// do not belong to an aggregate also expire after d. By default, events do
+// not expire.
+func DefaultTTL(d stdtime.Duration) EventStoreOption {
+	return func(s *EventStore) {
+		s.defaultTTL = d
+	}
+}
+
+// NewEventStore returns a new Redis event store. If not otherwise specified
+// using the Client or URL option, os.Getenv("REDIS_URL") is used as the URL of
+// the Redis server.
+func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:    enc,
+		url:    os.Getenv("REDIS_URL"),
+		prefix: "goes",
+		ttls:   make(map[string]stdtime.Duration),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Client returns the underlying Redis client. Client must only be called AFTER
+// the connection to Redis has been established, unless the client was
+// provided using the Client option.
+func (s *EventStore) Client() goredis.UniversalClient {
+	return s.client
+}
+
+// Connect connects to the Redis server. Connect is automatically called from
+// the Insert, Find, Query, and Delete methods if not called explicitly.
+func (s *EventStore) Connect(ctx context.Context) error {
+	var err error
+	s.onceConnect.Do(func() {
+		if s.client == nil {
+			if s.url == "" {
+				err = errors.New("missing url")
+				return
+			}
+
+			var opts *goredis.Options
+			if opts, err = goredis.ParseURL(s.url); err != nil {
+				err = fmt.Errorf("parse url: %w", err)
+				return
+			}
+			s.client = goredis.NewClient(opts)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 3*stdtime.Second)
+		defer cancel()
+
+		if err = s.client.Ping(ctx).Err(); err != nil {
+			err = fmt.Errorf("ping: %w", err)
+		}
+	})
+	return err
+}
+
+// TTLOf returns the TTL of events of the given aggregate, or 0 if the events
+// do not expire.
+func (s *EventStore) TTLOf(aggregateName string) stdtime.Duration {
+	if d, ok := s.ttls[aggregateName]; ok {
+		return d
+	}
+	return s.defaultTTL
+}
+
+// insertScript atomically checks that none of the events exist and that none
+// of the aggregate versions are taken, and then stores the events.
+//
+// KEYS: index key, then the event key and aggregate key of each event.
+// ARGV: id, time score, aggregate version, ttl in milliseconds, and the
+// encoded entry of each event, followed by the prefix of event keys.
+var insertScript = goredis.NewScript(`
+local n = (#KEYS - 1) / 2
+local prefix = ARGV[#ARGV]
+for i = 1, n do
+	local ek, ak, base = KEYS[2*i], KEYS[2*i+1], (i-1)*5
+	if redis.call('EXISTS', ek) == 1 then
+		return redis.error_reply('DUPLICATE ' .. ARGV[base+1])
+	end
+	local v = tonumber(ARGV[base+3])
+	if v > 0 then
+		local ids = redis.call('ZRANGEBYSCORE', ak, v, v)
+		for _, id in ipairs(ids) do
+			if redis.call('EXISTS', prefix .. id) == 1 then
+				return redis.error_reply('VERSION ' .. ARGV[base+1])
+			end
+			redis.call('ZREM', ak, id)
+		end
+	end
+end
+for i = 1, n do
+	local ek, ak, base = KEYS[2*i], KEYS[2*i+1], (i-1)*5
+	local id, v, ttl = ARGV[base+1], tonumber(ARGV[base+3]), tonumber(ARGV[base+4])
+	if ttl > 0 then
+		redis.call('SET', ek, ARGV[base+5], 'PX', ttl)
+	else
+		redis.call('SET', ek, ARGV[base+5])
+	end
+	redis.call('ZADD', KEYS[1], ARGV[base+2], id)
+	if v > 0 then
+		redis.call('ZADD', ak, v, id)
+		if ttl > 0 then
+			redis.call('PEXPIRE', ak, ttl)
+		else
+			redis.call('PERSIST', ak)
+		end
+	end
+end
+return n
+`)
+
+// Insert inserts events into the event store. Either all or none of the
+// events are inserted.
+func (s *EventStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 1, 1+2*len(events))
+	keys[0] = s.indexKey()
+	args := make([]any, 0, 5*len(events)+1)
+
+	ids := make(map[uuid.UUID]bool, len(events))
+	versions := make(map[string]bool, len(events))
+
+	for _, evt := range events {
+		if ids[evt.ID()] {
+			return fmt.Errorf("%s:%s %w", evt.Name(), evt.ID(), ErrDuplicateEvent)
+		}
+		ids[evt.ID()] = true
+
+		id, name, v := evt.Aggregate()
+		aggregateKey := s.aggregateKey(name, id)
+		if v > 0 {
+			versionKey := aggregateKey + ":" + strconv.Itoa(v)
+			if versions[versionKey] {
+				return fmt.Errorf("%s:%s %w [version=%d]", evt.Name(), evt.ID(), ErrVersionExists, v)
+			}
+			versions[versionKey] = true
+		}
+
+		data, err := storage.Marshal(ctx, s.enc, evt)
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+
+		b, err := json.Marshal(storage.NewEntry(evt, data))
+		if err != nil {
+			return fmt.Errorf("marshal %q event: %w", evt.Name(), err)
+		}
+
+		keys = append(keys, s.eventKey(evt.ID().String()), aggregateKey)
+		args = append(args, evt.ID().String(), timeScore(evt.Time()), v, s.TTLOf(name).Milliseconds(), b)
+	}
+
+	args = append(args, s.eventKey(""))
+
+	if err := insertScript.Run(ctx, s.client, keys, args...).Err(); err != nil {
+		return insertError(err)
+	}
+
+	return nil
+}
+
+func insertError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "ERR ")
+	switch {
+	case strings.HasPrefix(msg, "DUPLICATE "):
+		return fmt.Errorf("%s %w", strings.TrimPrefix(msg, "DUPLICATE "), ErrDuplicateEvent)
+	case strings.HasPrefix(msg, "VERSION "):
+		return fmt.Errorf("%s %w", strings.TrimPrefix(msg, "VERSION "), ErrVersionExists)
+	default:
+		return fmt.Errorf("insert events: %w", err)
+	}
+}
+
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	return s.Insert(ctx, storage.Events(events)...)
+}
+
+// Find fetches the event with the given id from the event store.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	b, err := s.client.Get(ctx, s.eventKey(id.String())).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("%s: %w", id, ErrEventNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get event: %w", err)
+	}
+
+	var e storage.Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("unmarshal event: %w", err)
+	}
+
+	return e.Raw().DecodeContext(ctx, s.enc)
+}
+
+// Delete deletes events from the event store.
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, evt := range events {
+			id := evt.ID().String()
+			pipe.Del(ctx, s.eventKey(id))
+			pipe.ZRem(ctx, s.indexKey(), id)
+			if aid, name, _ := evt.Aggregate(); name != "" {
+				pipe.ZRem(ctx, s.aggregateKey(name, aid), id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete events: %w", err)
+	}
+
+	return nil
+}
+
+// Query queries the event store for events.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	raws, err := s.load(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skip := event.UndecodableHandler(q)
+	events := make([]event.Event, 0, len(raws))
+	var decodeErr error
+	for _, raw := range raws {
+		evt, err := raw.DecodeContext(ctx, s.enc)
+		if err != nil {
+			if skip != nil {
+				var derr *event.DecodeError
+				if errors.As(err, &derr) {
+					skip(derr)
+				}
+				continue
+			}
+			decodeErr = fmt.Errorf("decode event: %w", err)
+			break
+		}
+		events = append(events, evt)
+	}
+
+	events = event.SortMulti(events, q.Sortings()...)
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		for _, evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+
+		if decodeErr != nil {
+			select {
+			case <-ctx.Done():
+			case errs <- decodeErr:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// load returns the unsorted events that match the query. Redis has no
+// secondary indexes, so the query is narrowed down using the sorted sets of
+// the store, and the remaining constraints are tested in memory.
+func (s *EventStore) load(ctx context.Context, q event.Query) ([]event.RawEvent, error) {
+	if err := s.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	ids, source, err := s.candidates(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("find candidates: %w", err)
+	}
+
+	out := make([]event.RawEvent, 0, len(ids))
+	expired := make(map[string][]any)
+
+	const chunkSize = 500
+	for start := 0; start < len(ids); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		keys := make([]string, end-start)
+		for i, id := range ids[start:end] {
+			keys[i] = s.eventKey(id.id)
+		}
+
+		vals, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("get events: %w", err)
+		}
+
+		for i, val := range vals {
+			c := ids[start+i]
+			str, ok := val.(string)
+			if !ok {
+				if c.set != "" {
+					expired[c.set] = append(expired[c.set], c.id)
+				}
+				continue
+			}
+
+			var e storage.Entry
+			if err := json.Unmarshal([]byte(str), &e); err != nil {
+				return nil, fmt.Errorf("unmarshal event %s: %w", c.id, err)
+			}
+
+			if !e.Matches(q) {
+				continue
+			}
+
+			out = append(out, e.Raw())
+		}
+	}
+
+	// Expired events are removed from the sorted sets lazily.
+	if len(expired) > 0 && source != sourceIDs {
+		pipe := s.client.Pipeline()
+		for set, members := range expired {
+			pipe.ZRem(ctx, set, members...)
+		}
+		pipe.Exec(ctx)
+	}
+
+	return out, nil
+}
+
+type candidateSource int
+
+const (
+	sourceIndex = candidateSource(iota)
+	sourceAggregates
+	sourceIDs
+)
+
+type candidate struct {
+	id  string
+	set string
+}
+
+func (s *EventStore) candidates(ctx context.Context, q event.Query) ([]candidate, candidateSource, error) {
+	if ids := q.IDs(); len(ids) > 0 {
+		out := make([]candidate, len(ids))
+		for i, id := range ids {
+			out[i] = candidate{id: id.String()}
+		}
+		return out, sourceIDs, nil
+	}
+
+	if keys := s.aggregateKeys(q); len(keys) > 0 {
+		var out []candidate
+		for _, key := range keys {
+			members, err := s.client.ZRange(ctx, key, 0, -1).Result()
+			if err != nil {
+				return nil, sourceAggregates, fmt.Errorf("get aggregate events: %w", err)
+			}
+			for _, id := range members {
+				out = append(out, candidate{id: id, set: key})
+			}
+		}
+		return out, sourceAggregates, nil
+	}
+
+	rng := goredis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if times := q.Times(); times != nil {
+		if min := times.Min(); !min.IsZero() {
+			rng.Min = strconv.FormatInt(int64(math.Floor(timeScore(min))), 10)
+		}
+		if max := times.Max(); !max.IsZero() {
+			rng.Max = strconv.FormatInt(int64(math.Ceil(timeScore(max))), 10)
+		}
+	}
+
+	members, err := s.client.ZRangeByScore(ctx, s.indexKey(), &rng).Result()
+	if err != nil {
+		return nil, sourceIndex, fmt.Errorf("get events: %w", err)
+	}
+
+	out := make([]candidate, len(members))
+	for i, id := range members {
+		out[i] = candidate{id: id, set: s.indexKey()}
+	}
+
+	return out, sourceIndex, nil
+}
+
+// aggregateKeys returns the keys of the aggregate sets that contain all events
+// that can match the query, or nil if the query does not target specific
+// aggregates.
+func (s *EventStore) aggregateKeys(q event.Query) []string {
+	if refs := q.Aggregates(); len(refs) > 0 {
+		keys := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			if ref.Name == "" || ref.ID == uuid.Nil {
+				return nil
+			}
+			keys = append(keys, s.aggregateKey(ref.Name, ref.ID))
+		}
+		return keys
+	}
+
+	names, ids := q.AggregateNames(), q.AggregateIDs()
+	if len(names) == 0 || len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(names)*len(ids))
+	for _, name := range names {
+		for _, id := range ids {
+			keys = append(keys, s.aggregateKey(name, id))
+		}
+	}
+	return keys
+}
+
+func (s *EventStore) indexKey() string {
+	return s.prefix + ":events"
+}
+
+func (s *EventStore) eventKey(id string) string {
+	return s.prefix + ":event:" + id
+}
+
+func (s *EventStore) aggregateKey(name string, id uuid.UUID) string {
+	return s.prefix + ":aggregate:" + name + ":" + id.String()
+}
+
+// timeScore returns the score of the given time in the index of all events.
+// Microseconds are used because sorted set scores are float64s, which cannot
+// represent nanosecond timestamps exactly.
+func timeScore(t stdtime.Time) float64 {
+	return float64(t.UnixMicro())
+}
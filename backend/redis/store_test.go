@@ -0,0 +1,112 @@
+//go:build redis
+
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/redis"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore(t *testing.T) {
+	eventstoretest.Run(t, "redis", func(enc codec.Encoding) event.Store {
+		return redis.NewEventStore(enc, redis.Prefix(nextPrefix()))
+	})
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	store := redis.NewEventStore(etest.NewEncoder(), redis.Prefix(nextPrefix()), redis.TTL("foo", 200*time.Millisecond))
+
+	fooID, barID := uuid.New(), uuid.New()
+	foo := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(fooID, "foo", 1))
+	bar := event.New[any]("bar", etest.BarEventData{}, event.Aggregate(barID, "bar", 1))
+
+	if err := store.Insert(ctx, foo, bar); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if _, err := store.Find(ctx, foo.ID()); err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	if _, err := store.Find(ctx, foo.ID()); !errors.Is(err, redis.ErrEventNotFound) {
+		t.Fatalf("Find should fail with %q for an expired event; got %q", redis.ErrEventNotFound, err)
+	}
+
+	if _, err := store.Find(ctx, bar.ID()); err != nil {
+		t.Fatalf("event without TTL should not expire; Find failed with %q", err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New())
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	if len(events) != 1 || events[0].ID() != bar.ID() {
+		t.Fatalf("Query should only return the %q event; got %v", "bar", events)
+	}
+
+	// the version of an expired event can be reused
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(fooID, "foo", 1)).Any()); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+}
+
+func TestEventStore_Insert_versionExists(t *testing.T) {
+	ctx := context.Background()
+	store := redis.NewEventStore(etest.NewEncoder(), redis.Prefix(nextPrefix()))
+
+	id := uuid.New()
+	if err := store.Insert(ctx, event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1))); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	err := store.Insert(ctx,
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1)),
+	)
+	if !errors.Is(err, redis.ErrVersionExists) {
+		t.Fatalf("Insert should fail with %q; got %q", redis.ErrVersionExists, err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.Aggregate("foo", id)))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("failed Insert should not insert any events; got %d events", len(events))
+	}
+}
+
+var prefixN uint64
+
+func nextPrefix() string {
+	n := atomic.AddUint64(&prefixN, 1)
+	return fmt.Sprintf("goes_test_%d_%d", time.Now().UnixNano(), n)
+}
@@ -0,0 +1,705 @@
+// Package s3 provides an event store that is backed by S3-compatible object
+// storage. The store is intended to be used as the archive of a tiered event
+// store (see eventstore.Tiered), which keeps recent events in a primary
+// backend and moves old events to cheap object storage.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/modernice/goes/backend/internal/storage"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// DefaultConcurrency is the default number of concurrent requests to the
+// object storage per operation.
+const DefaultConcurrency = 16
+
+var (
+	// ErrEventNotFound is returned by Find if the event does not exist.
+	ErrEventNotFound = storage.ErrEventNotFound
+
+	// ErrDuplicateEvent is returned by Insert if an event with the same id
+	// already exists.
+	ErrDuplicateEvent = storage.ErrDuplicateEvent
+
+	// ErrVersionExists is returned by Insert if an event with the same
+	// aggregate version already exists.
+	ErrVersionExists = storage.ErrVersionExists
+)
+
+// EventStore is an event store that is backed by S3-compatible object
+// storage. Each event is stored as a JSON object under its id, and is indexed
+// by its time and aggregate using empty objects whose keys can be listed in
+// order:
+//
+//	{prefix}/events/{id}
+//	{prefix}/time/{time}/{id}
+//	{prefix}/aggregates/{aggregateName}/{aggregateId}/{version}/{id}
+//
+// Object storage provides no transactions, so the uniqueness of event ids and
+// aggregate versions is checked before the events are written, but not
+// atomically. The store should therefore only have a single writer, like the
+// archiver of a tiered event store. Queries that are not restricted to
+// specific events or aggregates list the time index, which is slow for large
+// buckets; such queries should be restricted by time.
+type EventStore struct {
+	enc         codec.Encoding
+	endpoint    string
+	bucket      string
+	prefix      string
+	creds       *credentials.Credentials
+	secure      bool
+	region      string
+	concurrency int
+
+	onceConnect sync.Once
+	client      *minio.Client
+}
+
+// EventStoreOption is an option for the S3 event store.
+type EventStoreOption func(*EventStore)
+
+// Client returns an EventStoreOption that specifies the underlying S3 client.
+// If provided, the Endpoint, Credentials, Insecure, and Region options are
+// ignored.
+func Client(client *minio.Client) EventStoreOption {
+	return func(s *EventStore) {
+		s.client = client
+	}
+}
+
+// Endpoint returns an EventStoreOption that specifies the endpoint of the
+// object storage, e.g. "s3.amazonaws.com" or "localhost:9000".
+func Endpoint(endpoint string) EventStoreOption {
+	return func(s *EventStore) {
+		s.endpoint = endpoint
+	}
+}
+
+// Credentials returns an EventStoreOption that specifies the credentials of
+// the object storage. By default, the credentials are read from the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or MINIO_ACCESS_KEY and
+// MINIO_SECRET_KEY environment variables.
+func Credentials(accessKey, secretKey string) EventStoreOption {
+	return func(s *EventStore) {
+		s.creds = credentials.NewStaticV4(accessKey, secretKey, "")
+	}
+}
+
+// Insecure returns an EventStoreOption that connects to the object storage
+// over plain HTTP instead of HTTPS.
+func Insecure() EventStoreOption {
+	return func(s *EventStore) {
+		s.secure = false
+	}
+}
+
+// Region returns an EventStoreOption that specifies the region of the bucket.
+func Region(region string) EventStoreOption {
+	return func(s *EventStore) {
+		s.region = region
+	}
+}
+
+// Bucket returns an EventStoreOption that specifies the bucket of the event
+// store. The bucket is created if it does not exist.
+func Bucket(bucket string) EventStoreOption {
+	return func(s *EventStore) {
+		s.bucket = bucket
+	}
+}
+
+// Prefix returns an EventStoreOption that specifies the prefix of the object
+// keys that are used by the event store. Defaults to "goes".
+func Prefix(prefix string) EventStoreOption {
+	if prefix = strings.Trim(prefix, "/ "); prefix == "" {
+		panic("prefix cannot be empty")
+	}
+
+	return func(s *EventStore) {
+		s.prefix = prefix
+	}
+}
+
+// Concurrency returns an EventStoreOption that specifies the number of
+// concurrent requests to the object storage per operation. Defaults to
+// DefaultConcurrency.
+func Concurrency(n int) EventStoreOption {
+	return func(s *EventStore) {
+		s.concurrency = n
+	}
+}
+
+// NewEventStore returns a new S3 event store. If not otherwise specified using
+// the Endpoint and Bucket options, os.Getenv("S3_ENDPOINT") is used as the
+// endpoint and os.Getenv("S3_BUCKET") as the bucket.
+func NewEventStore(enc codec.Encoding, opts ...EventStoreOption) *EventStore {
+	s := &EventStore{
+		enc:         enc,
+		endpoint:    os.Getenv("S3_ENDPOINT"),
+		bucket:      os.Getenv("S3_BUCKET"),
+		prefix:      "goes",
+		secure:      true,
+		concurrency: DefaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.concurrency < 1 {
+		s.concurrency = 1
+	}
+	return s
+}
+
+// Client returns the underlying S3 client. Client must only be called AFTER
+// the connection has been established, unless the client was provided using
+// the Client option.
+func (s *EventStore) Client() *minio.Client {
+	return s.client
+}
+
+// Bucket returns the bucket of the event store.
+func (s *EventStore) Bucket() string {
+	return s.bucket
+}
+
+// Connect creates the S3 client and the bucket of the event store if it does
+// not exist. Connect is automatically called from the Insert, Find, Query, and
+// Delete methods if not called explicitly.
+func (s *EventStore) Connect(ctx context.Context) error {
+	var err error
+	s.onceConnect.Do(func() {
+		if s.bucket == "" {
+			err = errors.New("missing bucket")
+			return
+		}
+
+		if s.client == nil {
+			if s.endpoint == "" {
+				err = errors.New("missing endpoint")
+				return
+			}
+
+			creds := s.creds
+			if creds == nil {
+				creds = credentials.NewChainCredentials([]credentials.Provider{
+					&credentials.EnvAWS{},
+					&credentials.EnvMinio{},
+				})
+			}
+
+			if s.client, err = minio.New(s.endpoint, &minio.Options{
+				Creds:  creds,
+				Secure: s.secure,
+				Region: s.region,
+			}); err != nil {
+				err = fmt.Errorf("create client: %w", err)
+				return
+			}
+		}
+
+		var exists bool
+		if exists, err = s.client.BucketExists(ctx, s.bucket); err != nil {
+			err = fmt.Errorf("check bucket: %w", err)
+			return
+		}
+
+		if exists {
+			return
+		}
+
+		if err = s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{Region: s.region}); err != nil {
+			if resp := minio.ToErrorResponse(err); resp.Code == "BucketAlreadyOwnedByYou" {
+				err = nil
+				return
+			}
+			err = fmt.Errorf("create bucket: %w", err)
+		}
+	})
+	return err
+}
+
+// Insert inserts events into the event store.
+func (s *EventStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make(map[uuid.UUID]bool, len(events))
+	versions := make(map[string]bool, len(events))
+	entries := make([]storage.Entry, len(events))
+
+	for i, evt := range events {
+		if ids[evt.ID()] {
+			return fmt.Errorf("%s:%s %w", evt.Name(), evt.ID(), ErrDuplicateEvent)
+		}
+		ids[evt.ID()] = true
+
+		if id, name, v := evt.Aggregate(); v > 0 {
+			key := s.versionPrefix(name, id, v)
+			if versions[key] {
+				return fmt.Errorf("%s:%s %w [version=%d]", evt.Name(), evt.ID(), ErrVersionExists, v)
+			}
+			versions[key] = true
+		}
+
+		data, err := storage.Marshal(ctx, s.enc, evt)
+		if err != nil {
+			return fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		}
+
+		entries[i] = storage.NewEntry(evt, data)
+	}
+
+	if err := s.checkUnique(ctx, entries); err != nil {
+		return err
+	}
+
+	// Events are written before their indexes, so that an interrupted insert
+	// never leaves index keys of missing events behind.
+	if err := s.each(ctx, len(entries), func(ctx context.Context, i int) error {
+		e := entries[i]
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal %q event: %w", e.Name, err)
+		}
+		if err := s.put(ctx, s.eventKey(e.ID), b); err != nil {
+			return fmt.Errorf("put %q event: %w", e.Name, err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return s.each(ctx, len(entries), func(ctx context.Context, i int) error {
+		for _, key := range s.indexKeys(entries[i]) {
+			if err := s.put(ctx, key, nil); err != nil {
+				return fmt.Errorf("put index: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *EventStore) checkUnique(ctx context.Context, entries []storage.Entry) error {
+	return s.each(ctx, len(entries), func(ctx context.Context, i int) error {
+		e := entries[i]
+
+		exists, err := s.exists(ctx, s.eventKey(e.ID))
+		if err != nil {
+			return fmt.Errorf("check event: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("%s:%s %w", e.Name, e.ID, ErrDuplicateEvent)
+		}
+
+		if e.AggregateVersion <= 0 {
+			return nil
+		}
+
+		keys, err := s.list(ctx, s.versionPrefix(e.AggregateName, e.AggregateID, e.AggregateVersion), "")
+		if err != nil {
+			return fmt.Errorf("check aggregate version: %w", err)
+		}
+		if len(keys) > 0 {
+			return fmt.Errorf("%s:%s %w [version=%d]", e.Name, e.ID, ErrVersionExists, e.AggregateVersion)
+		}
+
+		return nil
+	})
+}
+
+// InsertRaw inserts events whose data is already encoded into the event
+// store. The encoded data is stored as-is, without using the encoder of the
+// store.
+//
+// InsertRaw implements event.RawInserter.
+func (s *EventStore) InsertRaw(ctx context.Context, events ...event.RawEvent) error {
+	return s.Insert(ctx, storage.Events(events)...)
+}
+
+// Find fetches the event with the given id from the event store.
+func (s *EventStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if err := s.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	e, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Raw().DecodeContext(ctx, s.enc)
+}
+
+// Delete deletes events from the event store. The index keys of the events
+// are deleted before the events themselves.
+func (s *EventStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.Connect(ctx); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+
+	if err := s.each(ctx, len(events), func(ctx context.Context, i int) error {
+		for _, key := range s.indexKeys(storage.NewEntry(events[i], nil)) {
+			if err := s.remove(ctx, key); err != nil {
+				return fmt.Errorf("delete index: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return s.each(ctx, len(events), func(ctx context.Context, i int) error {
+		if err := s.remove(ctx, s.eventKey(events[i].ID())); err != nil {
+			return fmt.Errorf("delete %q event: %w", events[i].Name(), err)
+		}
+		return nil
+	})
+}
+
+// Query queries the event store for events.
+func (s *EventStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if err := event.RejectTags(q); err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := s.load(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skip := event.UndecodableHandler(q)
+	events := make([]event.Event, 0, len(entries))
+	var decodeErr error
+	for _, e := range entries {
+		evt, err := e.Raw().DecodeContext(ctx, s.enc)
+		if err != nil {
+			if skip != nil {
+				var derr *event.DecodeError
+				if errors.As(err, &derr) {
+					skip(derr)
+				}
+				continue
+			}
+			decodeErr = fmt.Errorf("decode event: %w", err)
+			break
+		}
+		events = append(events, evt)
+	}
+
+	events = event.SortMulti(events, q.Sortings()...)
+
+	out := make(chan event.Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(errs)
+
+		for _, evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+
+		if decodeErr != nil {
+			select {
+			case <-ctx.Done():
+			case errs <- decodeErr:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// load returns the unsorted entries that match the query. The candidates of
+// the query are found by listing the index that fits the query best, and the
+// remaining constraints are tested in memory.
+func (s *EventStore) load(ctx context.Context, q event.Query) ([]storage.Entry, error) {
+	if err := s.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	ids, err := s.candidates(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("find candidates: %w", err)
+	}
+
+	found := make([]*storage.Entry, len(ids))
+	if err := s.each(ctx, len(ids), func(ctx context.Context, i int) error {
+		e, err := s.get(ctx, ids[i])
+		if errors.Is(err, ErrEventNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found[i] = &e
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	out := make([]storage.Entry, 0, len(found))
+	for _, e := range found {
+		if e == nil {
+			continue
+		}
+
+		if !e.Matches(q) {
+			continue
+		}
+
+		out = append(out, *e)
+	}
+
+	return out, nil
+}
+
+func (s *EventStore) candidates(ctx context.Context, q event.Query) ([]uuid.UUID, error) {
+	if ids := q.IDs(); len(ids) > 0 {
+		return ids, nil
+	}
+
+	if prefixes := s.aggregatePrefixes(q); len(prefixes) > 0 {
+		var out []uuid.UUID
+		seen := make(map[uuid.UUID]bool)
+		for _, prefix := range prefixes {
+			keys, err := s.list(ctx, prefix, "")
+			if err != nil {
+				return nil, fmt.Errorf("list aggregate events: %w", err)
+			}
+			for _, key := range keys {
+				id, err := idSuffix(key)
+				if err != nil {
+					return nil, err
+				}
+				if !seen[id] {
+					seen[id] = true
+					out = append(out, id)
+				}
+			}
+		}
+		return out, nil
+	}
+
+	prefix := s.prefix + "/time/"
+
+	var startAfter, end string
+	if times := q.Times(); times != nil {
+		if min := times.Min(); !min.IsZero() {
+			// the time segment of keys at min.UnixNano() sorts after this
+			startAfter = prefix + formatTime(min.UnixNano()-1) + "/~"
+		}
+		if max := times.Max(); !max.IsZero() {
+			end = prefix + formatTime(max.UnixNano()) + "/~"
+		}
+	}
+
+	keys, err := s.list(ctx, prefix, startAfter)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	out := make([]uuid.UUID, 0, len(keys))
+	for _, key := range keys {
+		if end != "" && key > end {
+			break
+		}
+		id, err := idSuffix(key)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+
+	return out, nil
+}
+
+// aggregatePrefixes returns the prefixes of the aggregate index keys that
+// contain all events that can match the query, or nil if the query does not
+// target specific aggregates.
+func (s *EventStore) aggregatePrefixes(q event.Query) []string {
+	if refs := q.Aggregates(); len(refs) > 0 {
+		prefixes := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			switch {
+			case ref.Name == "":
+				return nil
+			case ref.ID == uuid.Nil:
+				prefixes = append(prefixes, s.aggregateNamePrefix(ref.Name))
+			default:
+				prefixes = append(prefixes, s.aggregatePrefix(ref.Name, ref.ID))
+			}
+		}
+		return prefixes
+	}
+
+	names, ids := q.AggregateNames(), q.AggregateIDs()
+	if len(names) == 0 {
+		return nil
+	}
+
+	prefixes := make([]string, 0, len(names)*max(len(ids), 1))
+	for _, name := range names {
+		if len(ids) == 0 {
+			prefixes = append(prefixes, s.aggregateNamePrefix(name))
+			continue
+		}
+		for _, id := range ids {
+			prefixes = append(prefixes, s.aggregatePrefix(name, id))
+		}
+	}
+	return prefixes
+}
+
+func (s *EventStore) get(ctx context.Context, id uuid.UUID) (storage.Entry, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.eventKey(id), minio.GetObjectOptions{})
+	if err != nil {
+		return storage.Entry{}, fmt.Errorf("get event: %w", err)
+	}
+	defer obj.Close()
+
+	b, err := io.ReadAll(obj)
+	if isNotFound(err) {
+		return storage.Entry{}, fmt.Errorf("%s: %w", id, ErrEventNotFound)
+	}
+	if err != nil {
+		return storage.Entry{}, fmt.Errorf("read event: %w", err)
+	}
+
+	var e storage.Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return storage.Entry{}, fmt.Errorf("unmarshal event: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *EventStore) put(ctx context.Context, key string, b []byte) error {
+	contentType := "application/json"
+	if b == nil {
+		contentType = "application/octet-stream"
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(b), int64(len(b)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (s *EventStore) exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *EventStore) remove(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// list returns the keys with the given prefix in lexical order, starting
+// after the startAfter key if it is not empty.
+func (s *EventStore) list(ctx context.Context, prefix, startAfter string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var keys []string
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:     prefix,
+		StartAfter: startAfter,
+		Recursive:  true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+// each calls fn for the indices 0 to n-1 using the configured concurrency.
+func (s *EventStore) each(ctx context.Context, n int, fn func(context.Context, int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error { return fn(ctx, i) })
+	}
+	return g.Wait()
+}
+
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NotFound"
+}
+
+func (s *EventStore) eventKey(id uuid.UUID) string {
+	return s.prefix + "/events/" + id.String()
+}
+
+func (s *EventStore) indexKeys(e storage.Entry) []string {
+	keys := []string{s.prefix + "/time/" + formatTime(e.Time) + "/" + e.ID.String()}
+	if e.AggregateName != "" {
+		keys = append(keys, s.versionPrefix(e.AggregateName, e.AggregateID, e.AggregateVersion)+e.ID.String())
+	}
+	return keys
+}
+
+func (s *EventStore) aggregateNamePrefix(name string) string {
+	return s.prefix + "/aggregates/" + name + "/"
+}
+
+func (s *EventStore) aggregatePrefix(name string, id uuid.UUID) string {
+	return s.aggregateNamePrefix(name) + id.String() + "/"
+}
+
+func (s *EventStore) versionPrefix(name string, id uuid.UUID, v int) string {
+	return s.aggregatePrefix(name, id) + fmt.Sprintf("%010d", v) + "/"
+}
+
+// formatTime formats a unix nano timestamp so that the lexical order of the
+// formatted timestamps matches their chronological order, including negative
+// timestamps.
+func formatTime(nano int64) string {
+	return fmt.Sprintf("%020d", uint64(nano)^(1<<63))
+}
+
+func idSuffix(key string) (uuid.UUID, error) {
+	id, err := uuid.Parse(key[strings.LastIndexByte(key, '/')+1:])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("parse event id of index key %q: %w", key, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,82 @@
+//go:build s3
+
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/s3"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	querytime "github.com/modernice/goes/event/query/time"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestEventStore(t *testing.T) {
+	eventstoretest.Run(t, "s3", func(enc codec.Encoding) event.Store {
+		return newStore(enc)
+	})
+}
+
+func TestEventStore_Insert_versionExists(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(etest.NewEncoder())
+
+	id := uuid.New()
+	foo := event.New[any]("foo", etest.FooEventData{}, event.Aggregate(id, "foo", 1))
+	if err := store.Insert(ctx, foo); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	bar := event.New[any]("bar", etest.BarEventData{}, event.Aggregate(id, "foo", 1))
+	if err := store.Insert(ctx, bar); !errors.Is(err, s3.ErrVersionExists) {
+		t.Fatalf("Insert should fail with %q; got %q", s3.ErrVersionExists, err)
+	}
+}
+
+func TestEventStore_Query_timeIndex(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(etest.NewEncoder())
+
+	now := time.Now()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{}, event.Time(time.Unix(0, -1000))),
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now.Add(-time.Hour))),
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now.Add(-time.Minute))),
+		event.New[any]("foo", etest.FooEventData{}, event.Time(now)),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New(
+		query.Time(querytime.Min(now.Add(-time.Hour)), querytime.Max(now.Add(-time.Minute))),
+		query.SortBy(event.SortTime, event.SortAsc),
+	))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	result, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	etest.AssertEqualEvents(t, events[1:3], result)
+}
+
+var prefixes atomic.Int64
+
+func newStore(enc codec.Encoding) *s3.EventStore {
+	return s3.NewEventStore(enc, s3.Insecure(), s3.Prefix(fmt.Sprintf("goes-test-%d-%d", time.Now().UnixNano(), prefixes.Add(1))))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
 	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/query/time"
 	"github.com/modernice/goes/event/query/version"
@@ -31,6 +32,7 @@ func Run(t *testing.T, name string, newStore EventStoreFactory) {
 		run(t, "Delete", newStore, testDelete)
 		run(t, "Concurrency", newStore, testConcurrency)
 		run(t, "Query", newStore, testQuery)
+		run(t, "Stats", newStore, testStats)
 	})
 }
 
@@ -548,6 +550,56 @@ func testQuerySorting(t *testing.T, newStore EventStoreFactory) {
 	}
 }
 
+func testStats(t *testing.T, newStore EventStoreFactory) {
+	store := newStore(test.NewEncoder())
+
+	stats, err := eventstore.Stats(context.Background(), store)
+	if err != nil {
+		t.Fatalf("Stats failed with %q", err)
+	}
+	if stats.Events != 0 || stats.Aggregates != 0 || !stats.Oldest.IsZero() || !stats.Newest.IsZero() {
+		t.Fatalf("stats of an empty store should be empty; got %+v", stats)
+	}
+
+	now := xtime.Now()
+	fooID, barID := uuid.New(), uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{A: "foo"}, event.Time(now), event.Aggregate(fooID, "foo", 1)),
+		event.New[any]("foo", test.FooEventData{A: "foo"}, event.Time(now.Add(stdtime.Minute)), event.Aggregate(fooID, "foo", 2)),
+		event.New[any]("bar", test.BarEventData{A: "bar"}, event.Time(now.Add(stdtime.Hour)), event.Aggregate(barID, "bar", 1)),
+		event.New[any]("baz", test.BazEventData{A: "baz"}, event.Time(now.Add(-stdtime.Hour))),
+	}
+
+	if store, err = makeStore(newStore, events...); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats, err = eventstore.Stats(context.Background(), store); err != nil {
+		t.Fatalf("Stats failed with %q", err)
+	}
+
+	if stats.Events != 4 {
+		t.Errorf("Events should be %d; got %d", 4, stats.Events)
+	}
+
+	if stats.Aggregates != 2 {
+		t.Errorf("Aggregates should be %d; got %d", 2, stats.Aggregates)
+	}
+
+	wantPerName := map[string]int{"foo": 2, "bar": 1, "baz": 1}
+	if !cmp.Equal(wantPerName, stats.EventsPerName) {
+		t.Errorf("unexpected EventsPerName.\n\n%s", cmp.Diff(wantPerName, stats.EventsPerName))
+	}
+
+	if !stats.Oldest.Equal(events[3].Time()) {
+		t.Errorf("Oldest should be %v; got %v", events[3].Time(), stats.Oldest)
+	}
+
+	if !stats.Newest.Equal(events[2].Time()) {
+		t.Errorf("Newest should be %v; got %v", events[2].Time(), stats.Newest)
+	}
+}
+
 func makeStore(newStore EventStoreFactory, events ...event.Event) (event.Store, error) {
 	store := newStore(test.NewEncoder())
 	for i, evt := range events {
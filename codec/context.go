@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+var _ ContextEncoding = &Registry{}
+
+// ContextEncoding is an Encoding that accepts a context when encoding and
+// decoding data. The context carries request-scoped values like trace spans
+// or the current tenant, which encoders can use, e.g., to look up the
+// encryption key of a tenant, and allows encoders to honor deadlines.
+//
+// Components that encode or decode data use MarshalContext and
+// UnmarshalContext, which fall back to the context-less methods of plain
+// Encodings, so existing encoders keep working.
+type ContextEncoding interface {
+	Encoding
+
+	// MarshalContext marshals the provided data to a byte slice.
+	MarshalContext(context.Context, any) ([]byte, error)
+
+	// UnmarshalContext unmarshals the provided bytes to the data type that is
+	// registered under the given name.
+	UnmarshalContext(context.Context, []byte, string) (any, error)
+}
+
+// ContextMarshaler can be implemented by data types to override the default
+// marshaler with a context-aware marshaler.
+type ContextMarshaler interface {
+	MarshalContext(context.Context) ([]byte, error)
+}
+
+// ContextUnmarshaler can be implemented by data types to override the default
+// unmarshaler with a context-aware unmarshaler.
+type ContextUnmarshaler interface {
+	UnmarshalContext(context.Context, []byte) error
+}
+
+// MarshalContext marshals the provided data using the given Encoding. If enc
+// is a ContextEncoding, ctx is passed to it. Otherwise, enc.Marshal is called
+// and ctx is only checked for cancellation.
+func MarshalContext(ctx context.Context, enc Encoding, data any) ([]byte, error) {
+	if enc, ok := enc.(ContextEncoding); ok {
+		return enc.MarshalContext(ctx, data)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return enc.Marshal(data)
+}
+
+// UnmarshalContext unmarshals the provided bytes using the given Encoding. If
+// enc is a ContextEncoding, ctx is passed to it. Otherwise, enc.Unmarshal is
+// called and ctx is only checked for cancellation.
+func UnmarshalContext(ctx context.Context, enc Encoding, b []byte, name string) (any, error) {
+	if enc, ok := enc.(ContextEncoding); ok {
+		return enc.UnmarshalContext(ctx, b, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return enc.Unmarshal(b, name)
+}
+
+// WithContext adapts a context-less Encoding to a ContextEncoding. The
+// returned ContextEncoding ignores the context, apart from checking it for
+// cancellation. If enc already is a ContextEncoding, it is returned as-is.
+func WithContext(enc Encoding) ContextEncoding {
+	if enc, ok := enc.(ContextEncoding); ok {
+		return enc
+	}
+	return withContext{enc}
+}
+
+type withContext struct{ Encoding }
+
+func (enc withContext) MarshalContext(ctx context.Context, data any) ([]byte, error) {
+	return MarshalContext(ctx, enc.Encoding, data)
+}
+
+func (enc withContext) UnmarshalContext(ctx context.Context, b []byte, name string) (any, error) {
+	return UnmarshalContext(ctx, enc.Encoding, b, name)
+}
+
+// ContextFuncs returns a ContextEncoding that uses the provided functions to
+// marshal and unmarshal data. The context-less Marshal and Unmarshal methods
+// of the returned ContextEncoding call the functions with
+// context.Background(). Use ContextFuncs to implement encoders that depend on
+// the context, like an encryption codec that looks up the key of the current
+// tenant:
+//
+//	enc := codec.ContextFuncs(
+//		func(ctx context.Context, data any) ([]byte, error) {
+//			return encrypt(ctx, tenant.From(ctx), reg, data)
+//		},
+//		func(ctx context.Context, b []byte, name string) (any, error) {
+//			return decrypt(ctx, tenant.From(ctx), reg, b, name)
+//		},
+//	)
+func ContextFuncs(
+	marshal func(context.Context, any) ([]byte, error),
+	unmarshal func(context.Context, []byte, string) (any, error),
+) ContextEncoding {
+	if marshal == nil || unmarshal == nil {
+		panic("marshal and unmarshal functions must not be nil")
+	}
+	return contextFuncs{marshal: marshal, unmarshal: unmarshal}
+}
+
+type contextFuncs struct {
+	marshal   func(context.Context, any) ([]byte, error)
+	unmarshal func(context.Context, []byte, string) (any, error)
+}
+
+func (enc contextFuncs) Marshal(data any) ([]byte, error) {
+	return enc.marshal(context.Background(), data)
+}
+
+func (enc contextFuncs) Unmarshal(b []byte, name string) (any, error) {
+	return enc.unmarshal(context.Background(), b, name)
+}
+
+func (enc contextFuncs) MarshalContext(ctx context.Context, data any) ([]byte, error) {
+	return enc.marshal(ctx, data)
+}
+
+func (enc contextFuncs) UnmarshalContext(ctx context.Context, b []byte, name string) (any, error) {
+	return enc.unmarshal(ctx, b, name)
+}
+
+// MarshalContext marshals the provided data to a byte slice. Data types that
+// implement ContextMarshaler are marshaled using the provided context.
+func (r *Registry) MarshalContext(ctx context.Context, data any) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if m, ok := data.(ContextMarshaler); ok {
+		if r.debug {
+			log.Printf("[goes/codec.Registry@MarshalContext] marshaling type %T using custom ContextMarshaler", data)
+		}
+
+		return m.MarshalContext(ctx)
+	}
+
+	return r.Marshal(data)
+}
+
+// UnmarshalContext unmarshals the provided bytes to the data type that is
+// registered under the given name. Data types that implement
+// ContextUnmarshaler are unmarshaled using the provided context.
+func (r *Registry) UnmarshalContext(ctx context.Context, b []byte, name string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mux.RLock()
+	f, ok := r.factories[name]
+	r.mux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no data type registered for name %q", name)
+	}
+
+	ptr := f()
+
+	if m, ok := ptr.(ContextUnmarshaler); ok {
+		if r.debug {
+			log.Printf("[goes/codec.Registry@UnmarshalContext] unmarshaling type %T (%s) using custom ContextUnmarshaler", resolve(ptr), name)
+		}
+
+		if err := m.UnmarshalContext(ctx, b); err != nil {
+			return nil, err
+		}
+
+		return resolve(ptr), nil
+	}
+
+	return r.Unmarshal(b, name)
+}
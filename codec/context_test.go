@@ -0,0 +1,150 @@
+package codec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modernice/goes/codec"
+)
+
+type tenantKey struct{}
+
+// TenantData is encoded with the tenant from the context as a prefix, to test
+// context-aware encoding.
+type TenantData struct {
+	Tenant string
+	Value  string
+}
+
+func (data TenantData) MarshalContext(ctx context.Context) ([]byte, error) {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return []byte(tenant + ":" + data.Value), nil
+}
+
+func (data *TenantData) UnmarshalContext(ctx context.Context, b []byte) error {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	if len(b) < len(tenant)+1 || string(b[:len(tenant)]) != tenant {
+		return errors.New("wrong tenant")
+	}
+	data.Tenant = tenant
+	data.Value = string(b[len(tenant)+1:])
+	return nil
+}
+
+func TestRegistry_MarshalContext_UnmarshalContext(t *testing.T) {
+	reg := codec.New()
+	codec.Register[TenantData](reg, "tenant")
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	b, err := codec.MarshalContext(ctx, reg, TenantData{Value: "foo"})
+	if err != nil {
+		t.Fatalf("MarshalContext failed with %q", err)
+	}
+
+	if string(b) != "acme:foo" {
+		t.Fatalf("MarshalContext should return %q; got %q", "acme:foo", b)
+	}
+
+	data, err := codec.UnmarshalContext(ctx, reg, b, "tenant")
+	if err != nil {
+		t.Fatalf("UnmarshalContext failed with %q", err)
+	}
+
+	if want := (TenantData{Tenant: "acme", Value: "foo"}); data != want {
+		t.Fatalf("UnmarshalContext should return %v; got %v", want, data)
+	}
+
+	other := context.WithValue(context.Background(), tenantKey{}, "other")
+	if _, err := codec.UnmarshalContext(other, reg, b, "tenant"); err == nil {
+		t.Fatalf("UnmarshalContext should fail for data of another tenant")
+	}
+}
+
+func TestRegistry_MarshalContext_fallback(t *testing.T) {
+	reg := codec.New()
+	codec.Register[FooData](reg, "foo")
+
+	b, err := reg.MarshalContext(context.Background(), FooData{Foo: "foo", Bar: 3})
+	if err != nil {
+		t.Fatalf("MarshalContext failed with %q", err)
+	}
+
+	data, err := reg.UnmarshalContext(context.Background(), b, "foo")
+	if err != nil {
+		t.Fatalf("UnmarshalContext failed with %q", err)
+	}
+
+	if want := (FooData{Foo: "foo", Bar: 3}); data != want {
+		t.Fatalf("UnmarshalContext should return %v; got %v", want, data)
+	}
+}
+
+func TestMarshalContext_canceled(t *testing.T) {
+	reg := codec.New()
+	codec.Register[FooData](reg, "foo")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, enc := range map[string]codec.Encoding{
+		"Registry":    reg,
+		"Encoding":    plainEncoding{reg},
+		"WithContext": codec.WithContext(plainEncoding{reg}),
+	} {
+		if _, err := codec.MarshalContext(ctx, enc, FooData{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("[%s] MarshalContext should fail with %q; got %q", name, context.Canceled, err)
+		}
+		if _, err := codec.UnmarshalContext(ctx, enc, []byte("{}"), "foo"); !errors.Is(err, context.Canceled) {
+			t.Errorf("[%s] UnmarshalContext should fail with %q; got %q", name, context.Canceled, err)
+		}
+	}
+}
+
+func TestContextFuncs(t *testing.T) {
+	enc := codec.ContextFuncs(
+		func(ctx context.Context, data any) ([]byte, error) {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return []byte(tenant), nil
+		},
+		func(ctx context.Context, b []byte, name string) (any, error) {
+			return string(b) + "/" + name, nil
+		},
+	)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	b, err := codec.MarshalContext(ctx, enc, nil)
+	if err != nil {
+		t.Fatalf("MarshalContext failed with %q", err)
+	}
+
+	if string(b) != "acme" {
+		t.Fatalf("MarshalContext should pass the context to the marshaler; got %q", b)
+	}
+
+	if b, _ := enc.Marshal(nil); len(b) != 0 {
+		t.Fatalf("Marshal should use an empty context; got %q", b)
+	}
+
+	data, err := enc.Unmarshal([]byte("acme"), "foo")
+	if err != nil {
+		t.Fatalf("Unmarshal failed with %q", err)
+	}
+
+	if data != "acme/foo" {
+		t.Fatalf("Unmarshal should return %q; got %q", "acme/foo", data)
+	}
+}
+
+// plainEncoding hides the context-aware methods of a Registry.
+type plainEncoding struct{ reg *codec.Registry }
+
+func (enc plainEncoding) Marshal(data any) ([]byte, error) {
+	return enc.reg.Marshal(data)
+}
+
+func (enc plainEncoding) Unmarshal(b []byte, name string) (any, error) {
+	return enc.reg.Unmarshal(b, name)
+}
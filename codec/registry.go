@@ -2,6 +2,7 @@ package codec
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
@@ -10,6 +11,10 @@ import (
 
 var _ Encoding = &Registry{}
 
+// ErrNotRegistered is returned by Registry.Validate for names that have no
+// registered data type.
+var ErrNotRegistered = errors.New("data type not registered")
+
 // Encoding can be used to encode registered data types to and from bytes.
 type Encoding interface {
 	Marshal(any) ([]byte, error)
@@ -173,6 +178,61 @@ func (r *Registry) Map() map[string]func() any {
 	return out
 }
 
+// Validate validates that a data type is registered for each of the given
+// names, and that the zero value of each data type can be encoded and decoded
+// by the Registry. Call Validate at startup with the names of the events and
+// commands that are used by subscriptions, projections, and handlers, to fail
+// fast instead of producing decode errors at runtime:
+//
+//	var reg *codec.Registry
+//	var s *schedule.Continuous
+//	if err := reg.Validate(s.EventNames()...); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// The returned error joins the errors of all invalid names. Names that are
+// not registered produce an error that wraps ErrNotRegistered.
+func (r *Registry) Validate(names ...string) error {
+	var errs []error
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if err := r.validate(name); err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) validate(name string) error {
+	r.mux.RLock()
+	_, ok := r.factories[name]
+	r.mux.RUnlock()
+	if !ok {
+		return ErrNotRegistered
+	}
+
+	v, err := r.New(name)
+	if err != nil {
+		return err
+	}
+
+	b, err := r.Marshal(resolve(v))
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if _, err := r.Unmarshal(b, name); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	return nil
+}
+
 // resolves a pointer to the underlying data type.
 func resolve(p any) any {
 	rv := reflect.ValueOf(p)
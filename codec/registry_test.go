@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -194,3 +195,27 @@ func TestMake(t *testing.T) {
 		t.Fatalf("created data should be zero value %v, got %v", want, d)
 	}
 }
+
+func TestRegistry_Validate(t *testing.T) {
+	reg := codec.New()
+	codec.Register[FooData](reg, "foo")
+	codec.Register[BarData](reg, "bar")
+
+	if err := reg.Validate("foo", "bar", "foo"); err != nil {
+		t.Fatalf("Validate() failed with %q", err)
+	}
+
+	err := reg.Validate("foo", "baz")
+	if !errors.Is(err, codec.ErrNotRegistered) {
+		t.Fatalf("Validate() should fail with %q; got %q", codec.ErrNotRegistered, err)
+	}
+}
+
+func TestRegistry_Validate_encodingError(t *testing.T) {
+	reg := codec.New()
+	codec.Register[chan int](reg, "foo")
+
+	if err := reg.Validate("foo"); err == nil {
+		t.Fatalf("Validate() should fail for data that cannot be encoded")
+	}
+}
@@ -0,0 +1,289 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+
+	"github.com/modernice/goes/codec"
+)
+
+// Change is a change between a recorded and a current schema.
+type Change struct {
+	// Name is the registered name of the data type.
+	Name string
+
+	// Path is the path to the changed field, e.g. "data.items[].price".
+	Path string
+
+	// Description describes the change.
+	Description string
+
+	// Breaking is true if the change breaks the decoding of data that was
+	// encoded using the recorded schema.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%q %s: %s", c.Name, c.Path, c.Description)
+}
+
+// Diff returns the changes between the recorded and the current schemas.
+//
+// Breaking changes are data types that are no longer registered, fields that
+// were removed or renamed (so that stored values would be lost), changes of
+// the ",string" JSON tag option, and type changes that cannot decode the
+// recorded values. Widening an integer to a float, and changing a field to a
+// type with a custom or unknown encoding (interfaces, json.Marshaler) are not
+// breaking. New data types and new fields are not breaking.
+func Diff(recorded, current Snapshot) []Change {
+	var changes []Change
+	for _, name := range recorded.Names() {
+		cur, ok := current[name]
+		if !ok {
+			changes = append(changes, Change{Name: name, Path: "data", Description: "no longer registered", Breaking: true})
+			continue
+		}
+		changes = append(changes, diffType(name, "data", recorded[name], cur)...)
+	}
+
+	for _, name := range current.Names() {
+		if _, ok := recorded[name]; !ok {
+			changes = append(changes, Change{Name: name, Path: "data", Description: "newly registered"})
+		}
+	}
+
+	return changes
+}
+
+func diffType(name, path string, recorded, current Type) []Change {
+	change := func(breaking bool, format string, args ...any) []Change {
+		return []Change{{Name: name, Path: path, Description: fmt.Sprintf(format, args...), Breaking: breaking}}
+	}
+
+	if recorded.Kind != current.Kind {
+		return change(!convertible(recorded.Kind, current.Kind), "changed from %s to %s", describe(recorded), describe(current))
+	}
+
+	switch recorded.Kind {
+	case Struct:
+		if recorded.Recursive || current.Recursive {
+			return nil
+		}
+		return diffFields(name, path, recorded.Fields, current.Fields)
+	case Slice:
+		return diffType(name, path+"[]", *recorded.Elem, *current.Elem)
+	case Map:
+		out := diffType(name, path+"{key}", *recorded.Key, *current.Key)
+		return append(out, diffType(name, path+"{}", *recorded.Elem, *current.Elem)...)
+	case Custom:
+		if recorded.Name != current.Name {
+			return change(true, "changed from %s to %s", describe(recorded), describe(current))
+		}
+	}
+
+	return nil
+}
+
+func diffFields(name, path string, recorded, current []Field) []Change {
+	var out []Change
+
+	currentFields := make(map[string]Field, len(current))
+	for _, f := range current {
+		currentFields[f.Name] = f
+	}
+
+	recordedFields := make(map[string]bool, len(recorded))
+	for _, rf := range recorded {
+		recordedFields[rf.Name] = true
+		fieldPath := path + "." + rf.Name
+
+		cf, ok := currentFields[rf.Name]
+		if !ok {
+			desc := "removed"
+			if renamed, ok := findGoName(current, rf.GoName); ok {
+				desc = fmt.Sprintf("renamed to %q", renamed.Name)
+			}
+			out = append(out, Change{Name: name, Path: fieldPath, Description: desc, Breaking: true})
+			continue
+		}
+
+		if hasStringOption(rf.Tag) != hasStringOption(cf.Tag) {
+			out = append(out, Change{
+				Name:        name,
+				Path:        fieldPath,
+				Description: fmt.Sprintf("changed tag from `%s` to `%s`", rf.Tag, cf.Tag),
+				Breaking:    true,
+			})
+			continue
+		}
+
+		if rf.Tag != cf.Tag {
+			out = append(out, Change{
+				Name:        name,
+				Path:        fieldPath,
+				Description: fmt.Sprintf("changed tag from `%s` to `%s`", rf.Tag, cf.Tag),
+			})
+		}
+
+		out = append(out, diffType(name, fieldPath, rf.Type, cf.Type)...)
+	}
+
+	for _, cf := range current {
+		if !recordedFields[cf.Name] {
+			if _, renamed := findGoName(recorded, cf.GoName); renamed {
+				continue
+			}
+			out = append(out, Change{Name: name, Path: path + "." + cf.Name, Description: "added"})
+		}
+	}
+
+	return out
+}
+
+func findGoName(fields []Field, goName string) (Field, bool) {
+	for _, f := range fields {
+		if f.GoName == goName {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func hasStringOption(tag string) bool {
+	for _, part := range strings.Split(reflect.StructTag(tag).Get("json"), ",")[1:] {
+		if part == "string" {
+			return true
+		}
+	}
+	return false
+}
+
+// convertible reports whether values that were encoded as from can be decoded
+// into to.
+func convertible(from, to Kind) bool {
+	switch to {
+	case Interface, Custom:
+		return true
+	case Float:
+		return from == Int || from == Uint
+	case Int:
+		return from == Uint
+	case String:
+		return from == Text || from == Time
+	case Text:
+		return from == String
+	}
+	return false
+}
+
+func describe(t Type) string {
+	if t.Name != "" {
+		return fmt.Sprintf("%s (%s)", t.Kind, t.Name)
+	}
+	return string(t.Kind)
+}
+
+// Error is returned by Check and Verify if the registered data types have
+// breaking changes.
+type Error struct {
+	Changes []Change
+}
+
+func (err *Error) Error() string {
+	lines := make([]string, len(err.Changes))
+	for i, c := range err.Changes {
+		lines[i] = c.String()
+	}
+	return fmt.Sprintf("incompatible schema changes:\n\t%s", strings.Join(lines, "\n\t"))
+}
+
+// Check returns an *Error that contains the breaking changes between the
+// recorded and the current schemas, or nil if there are none.
+func Check(recorded, current Snapshot) error {
+	var breaking []Change
+	for _, c := range Diff(recorded, current) {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	if len(breaking) > 0 {
+		return &Error{Changes: breaking}
+	}
+
+	return nil
+}
+
+// Option is an option for Verify.
+type Option func(*config)
+
+type config struct {
+	update  bool
+	ignored map[string]bool
+}
+
+// Update returns an Option that overwrites the recorded schemas with the
+// current schemas instead of failing on breaking changes. Use Update after
+// breaking changes were made deliberately, e.g. after migrating the stored
+// events.
+func Update(update bool) Option {
+	return func(cfg *config) {
+		cfg.update = update
+	}
+}
+
+// Ignore returns an Option that excludes the given names from the
+// verification.
+func Ignore(names ...string) Option {
+	return func(cfg *config) {
+		for _, name := range names {
+			cfg.ignored[name] = true
+		}
+	}
+}
+
+// Verify compares the data types that are registered in reg against the
+// schemas that are recorded in the file at path. If the file does not exist,
+// the current schemas are recorded. If there are breaking changes, Verify
+// returns an *Error. Otherwise, the file is updated with the current schemas,
+// so that new data types and fields are recorded.
+func Verify(reg *codec.Registry, path string, opts ...Option) error {
+	cfg := config{ignored: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	current, err := Record(reg)
+	if err != nil {
+		return err
+	}
+	for name := range cfg.ignored {
+		delete(current, name)
+	}
+
+	recorded, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return current.Save(path)
+	}
+	if err != nil {
+		return fmt.Errorf("load recorded schemas: %w", err)
+	}
+	for name := range cfg.ignored {
+		delete(recorded, name)
+	}
+
+	if !cfg.update {
+		if err := Check(recorded, current); err != nil {
+			return err
+		}
+	}
+
+	if len(Diff(recorded, current)) == 0 {
+		return nil
+	}
+
+	return current.Save(path)
+}
@@ -0,0 +1,133 @@
+package schema_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/codec/schema"
+)
+
+type orderPlaced struct {
+	OrderID  uuid.UUID `json:"orderId"`
+	Items    []item    `json:"items"`
+	Total    int       `json:"total"`
+	Note     string    `json:"note,omitempty"`
+	PlacedAt time.Time `json:"placedAt"`
+}
+
+type item struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type orderPlacedAdded struct {
+	OrderID  uuid.UUID `json:"orderId"`
+	Items    []item    `json:"items"`
+	Total    float64   `json:"total"`
+	Note     string    `json:"note"`
+	PlacedAt time.Time `json:"placedAt"`
+	Currency string    `json:"currency"`
+}
+
+type orderPlacedBroken struct {
+	OrderID  uuid.UUID    `json:"orderId"`
+	Items    []brokenItem `json:"items"`
+	Amount   int          `json:"amount"`
+	PlacedAt string       `json:"placedAt"`
+	Note     string       `json:"note,string"`
+}
+
+type brokenItem struct {
+	SKU      int `json:"sku"`
+	Quantity int `json:"quantity"`
+}
+
+func TestDiff(t *testing.T) {
+	recorded := record(t, orderPlaced{})
+
+	if changes := schema.Diff(recorded, record(t, orderPlaced{})); len(changes) != 0 {
+		t.Fatalf("unchanged types should have no changes; got %v", changes)
+	}
+
+	if err := schema.Check(recorded, record(t, orderPlacedAdded{})); err != nil {
+		t.Fatalf("Check should not fail for compatible changes; got %q", err)
+	}
+
+	err := schema.Check(recorded, record(t, orderPlacedBroken{}))
+
+	var serr *schema.Error
+	if !errors.As(err, &serr) {
+		t.Fatalf("Check should fail with %T; got %q", serr, err)
+	}
+
+	want := map[string]bool{
+		"data.items[].sku": true,
+		"data.total":       true,
+		"data.note":        true,
+	}
+	for _, c := range serr.Changes {
+		delete(want, c.Path)
+	}
+	if len(want) != 0 {
+		t.Fatalf("Check should report breaking changes of %v; got %v", want, serr.Changes)
+	}
+}
+
+func TestDiff_unregistered(t *testing.T) {
+	recorded := record(t, orderPlaced{})
+
+	err := schema.Check(recorded, schema.Snapshot{})
+	if err == nil {
+		t.Fatalf("Check should fail if a data type is no longer registered")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.json")
+
+	reg := codec.New()
+	codec.Register[orderPlaced](reg, "order.placed")
+
+	// records the schemas
+	if err := schema.Verify(reg, path); err != nil {
+		t.Fatalf("Verify failed with %q", err)
+	}
+
+	reg = codec.New()
+	codec.Register[orderPlacedAdded](reg, "order.placed")
+	if err := schema.Verify(reg, path); err != nil {
+		t.Fatalf("Verify should not fail for compatible changes; got %q", err)
+	}
+
+	// the added field is recorded, so removing it again is breaking
+	reg = codec.New()
+	codec.Register[orderPlaced](reg, "order.placed")
+	if err := schema.Verify(reg, path); err == nil {
+		t.Fatalf("Verify should fail after removing a recorded field")
+	}
+
+	if err := schema.Verify(reg, path, schema.Update(true)); err != nil {
+		t.Fatalf("Verify should not fail with Update; got %q", err)
+	}
+
+	if err := schema.Verify(reg, path); err != nil {
+		t.Fatalf("Verify should not fail after updating the recorded schemas; got %q", err)
+	}
+}
+
+func record[T any](t *testing.T, data T) schema.Snapshot {
+	t.Helper()
+
+	reg := codec.New()
+	codec.Register[T](reg, "order.placed")
+
+	s, err := schema.Record(reg)
+	if err != nil {
+		t.Fatalf("Record failed with %q", err)
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package schema
+
+// JSONSchemaDraft4 is the URI of the JSON Schema version that is returned by
+// JSONSchema.
+const JSONSchemaDraft4 = "http://json-schema.org/draft-04/schema#"
+
+// JSONSchema returns the JSON Schema (draft 4) of the JSON encoding of the
+// type, e.g. to publish the schemas of events to a schema registry. Types with
+// an unknown encoding (interfaces and custom marshalers) accept any value. The
+// schema does not contain the "$schema" keyword, so that it can be embedded
+// into other schemas.
+func (t Type) JSONSchema() map[string]any {
+	switch t.Kind {
+	case String, Text:
+		return map[string]any{"type": "string"}
+	case Time:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case Bytes:
+		return map[string]any{"type": []string{"string", "null"}}
+	case Bool:
+		return map[string]any{"type": "boolean"}
+	case Int:
+		return map[string]any{"type": "integer"}
+	case Uint:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case Float:
+		return map[string]any{"type": "number"}
+	case Slice:
+		return map[string]any{"type": []string{"array", "null"}, "items": t.Elem.JSONSchema()}
+	case Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": t.Elem.JSONSchema()}
+	case Struct:
+		if t.Recursive {
+			return map[string]any{"type": "object"}
+		}
+		props := make(map[string]any, len(t.Fields))
+		for _, f := range t.Fields {
+			props[f.Name] = f.Type.JSONSchema()
+		}
+		return map[string]any{"type": "object", "properties": props}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,27 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestType_JSONSchema(t *testing.T) {
+	snap := record(t, orderPlaced{})
+
+	b, err := json.Marshal(snap["order.placed"].JSONSchema())
+	if err != nil {
+		t.Fatalf("encode JSON schema: %v", err)
+	}
+
+	want := `{"properties":{` +
+		`"items":{"items":{"properties":{"quantity":{"type":"integer"},"sku":{"type":"string"}},"type":"object"},"type":["array","null"]},` +
+		`"note":{"type":"string"},` +
+		`"orderId":{"type":"string"},` +
+		`"placedAt":{"format":"date-time","type":"string"},` +
+		`"total":{"type":"integer"}` +
+		`},"type":"object"}`
+
+	if string(b) != want {
+		t.Fatalf("JSONSchema() should return\n%s\n\ngot\n%s", want, b)
+	}
+}
@@ -0,0 +1,332 @@
+// Package schema records the schemas of the data types that are registered in
+// a codec.Registry and detects incompatible changes to these types, which
+// would break the decoding of events that are already stored. Use Verify in a
+// test or at startup to fail on incompatible changes:
+//
+//	func TestSchemas(t *testing.T) {
+//		reg := codec.New()
+//		myapp.RegisterEvents(reg)
+//
+//		if err := schema.Verify(reg, "testdata/schemas.json"); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+//
+// The first call to Verify records the schemas to the given file, which should
+// be committed. Subsequent calls compare the registered types against the
+// recorded schemas. Verify writes new data types and fields to the file, so
+// it is meant to be used in tests. To fail at startup without writing files,
+// use Check:
+//
+//	recorded, err := schema.Load("schemas.json")
+//	current, err := schema.Record(reg)
+//	if err := schema.Check(recorded, current); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// The schemas describe the JSON encoding of the types, which is the default
+// encoding of a codec.Registry.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modernice/goes/codec"
+)
+
+// Kind is the kind of a Type.
+type Kind string
+
+const (
+	String    = Kind("string")
+	Bool      = Kind("bool")
+	Int       = Kind("int")
+	Uint      = Kind("uint")
+	Float     = Kind("float")
+	Bytes     = Kind("bytes")
+	Time      = Kind("time")
+	Struct    = Kind("struct")
+	Slice     = Kind("slice")
+	Map       = Kind("map")
+	Interface = Kind("interface")
+
+	// Text is the kind of types that implement encoding.TextMarshaler, which
+	// are encoded as JSON strings.
+	Text = Kind("text")
+
+	// Custom is the kind of types that implement json.Marshaler or
+	// codec.Marshaler and whose encoding is therefore unknown.
+	Custom = Kind("custom")
+)
+
+// Type is the schema of a data type.
+type Type struct {
+	Kind Kind `json:"kind"`
+
+	// Name is the qualified name of named types, e.g. "time.Duration".
+	Name string `json:"name,omitempty"`
+
+	// Fields are the encoded fields of a Struct.
+	Fields []Field `json:"fields,omitempty"`
+
+	// Elem is the element type of a Slice or Map.
+	Elem *Type `json:"elem,omitempty"`
+
+	// Key is the key type of a Map.
+	Key *Type `json:"key,omitempty"`
+
+	// Recursive is true if the type is a Struct that contains itself. The
+	// fields of recursive occurrences are not recorded.
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+// Field is an encoded field of a Struct.
+type Field struct {
+	// Name is the name of the field in the encoded data.
+	Name string `json:"name"`
+
+	// GoName is the name of the Go struct field.
+	GoName string `json:"goName"`
+
+	// Tag is the struct tag of the field.
+	Tag string `json:"tag,omitempty"`
+
+	// Type is the type of the field.
+	Type Type `json:"type"`
+}
+
+// Snapshot are the recorded schemas of the data types of a registry, mapped
+// to the names they are registered with.
+type Snapshot map[string]Type
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	codecMarshalerType = reflect.TypeOf((*codec.Marshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Record returns the schemas of all data types that are registered in reg.
+func Record(reg *codec.Registry) (Snapshot, error) {
+	out := make(Snapshot)
+	for name := range reg.Map() {
+		data, err := reg.New(name)
+		if err != nil {
+			return out, err
+		}
+
+		if data == nil {
+			continue
+		}
+
+		t := reflect.TypeOf(data)
+		if implements(t, codecMarshalerType) {
+			out[name] = Type{Kind: Custom, Name: typeName(t)}
+			continue
+		}
+
+		typ, err := typeOf(t, nil)
+		if err != nil {
+			return out, fmt.Errorf("record schema of %q: %w", name, err)
+		}
+		out[name] = typ
+	}
+	return out, nil
+}
+
+// Names returns the registered names of the snapshot in alphabetical order.
+func (s Snapshot) Names() []string {
+	out := make([]string, 0, len(s))
+	for name := range s {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Load reads a snapshot from the given file.
+func Load(path string) (Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Save writes the snapshot to the given file. The directory of the file is
+// created if it does not exist.
+func (s Snapshot) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+func typeOf(t reflect.Type, visiting []reflect.Type) (Type, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	typ := Type{Name: typeName(t)}
+
+	switch {
+	case t == timeType:
+		typ.Kind = Time
+		return typ, nil
+	case implements(t, jsonMarshalerType):
+		typ.Kind = Custom
+		return typ, nil
+	case implements(t, textMarshalerType):
+		typ.Kind = Text
+		return typ, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		typ.Kind = String
+	case reflect.Bool:
+		typ.Kind = Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		typ.Kind = Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ.Kind = Uint
+	case reflect.Float32, reflect.Float64:
+		typ.Kind = Float
+	case reflect.Interface:
+		typ.Kind = Interface
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			typ.Kind = Bytes
+			return typ, nil
+		}
+		elem, err := typeOf(t.Elem(), visiting)
+		if err != nil {
+			return typ, err
+		}
+		typ.Kind = Slice
+		typ.Elem = &elem
+	case reflect.Map:
+		key, err := typeOf(t.Key(), visiting)
+		if err != nil {
+			return typ, err
+		}
+		elem, err := typeOf(t.Elem(), visiting)
+		if err != nil {
+			return typ, err
+		}
+		typ.Kind = Map
+		typ.Key = &key
+		typ.Elem = &elem
+	case reflect.Struct:
+		typ.Kind = Struct
+		for _, v := range visiting {
+			if v == t {
+				typ.Recursive = true
+				return typ, nil
+			}
+		}
+		fields, err := structFields(t, append(visiting, t))
+		if err != nil {
+			return typ, err
+		}
+		typ.Fields = fields
+	default:
+		return typ, fmt.Errorf("unsupported type %v", t)
+	}
+
+	return typ, nil
+}
+
+func structFields(t reflect.Type, visiting []reflect.Type) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, skip := jsonName(sf)
+		if skip {
+			continue
+		}
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded, err := structFields(ft, visiting)
+				if err != nil {
+					return fields, err
+				}
+				fields = append(fields, embedded...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		typ, err := typeOf(sf.Type, visiting)
+		if err != nil {
+			return fields, fmt.Errorf("field %q: %w", sf.Name, err)
+		}
+
+		fields = append(fields, Field{
+			Name:   name,
+			GoName: sf.Name,
+			Tag:    string(sf.Tag),
+			Type:   typ,
+		})
+	}
+	return fields, nil
+}
+
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok {
+		return "", false
+	}
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func typeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return ""
+	}
+	if t.PkgPath() == "" {
+		return t.Name()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
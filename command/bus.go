@@ -63,6 +63,11 @@ type DispatchConfig struct {
 	//
 	// A non-nil Reporter makes the dispatch synchronous.
 	Reporter Reporter
+
+	// If FailFast is true, the Bus returns an error immediately if no handler
+	// is known for the Command instead of waiting for the Command to be
+	// assigned to a handler.
+	FailFast bool
 }
 
 // A Reporter reports execution results of a Command.
@@ -38,6 +38,13 @@ const (
 	// command bus returns an error that unwraps to ErrReceiveTimeout.
 	// The default timeout is 10s. A zero Duration means no timeout.
 	DefaultReceiveTimeout = 10 * time.Second
+
+	// DefaultAdvertiseInterval is the default interval at which a command bus
+	// advertises the commands it handles to other command buses. Handlers that
+	// have not been advertised for three intervals are considered to be gone.
+	// The default interval is 30s. A zero Duration disables periodic
+	// advertisements and the expiry of advertised handlers.
+	DefaultAdvertiseInterval = 30 * time.Second
 )
 
 var (
@@ -49,6 +56,10 @@ var (
 	// when receiving remaining Commands from a canceled Command subscription.
 	ErrReceiveTimeout = errors.New("command dropped because of receive timeout")
 
+	// ErrNoHandler is returned by a Bus when a Command is dispatched with the
+	// dispatch.FailFast Option and no handler is subscribed to the Command.
+	ErrNoHandler = errors.New("no handler registered for command")
+
 	// Deprecated: Use ErrReceiveTimeout instead.
 	ErrDrainTimeout = ErrReceiveTimeout
 
@@ -71,6 +82,9 @@ type Bus[ErrorCode constraints.Integer] struct {
 	subscriptions map[string]*subscription
 	requested     map[uuid.UUID]command.Cmd[any]
 
+	handlersMux sync.RWMutex
+	handlers    map[uuid.UUID]advertisement
+
 	dispatchMux sync.RWMutex
 	dispatched  map[uuid.UUID]dispatcher
 	assigned    map[uuid.UUID]dispatcher
@@ -84,10 +98,13 @@ type Bus[ErrorCode constraints.Integer] struct {
 }
 
 type options struct {
-	assignTimeout  time.Duration
-	receiveTimeout time.Duration
-	filters        []func(command.Command) bool
-	debug          bool
+	assignTimeout     time.Duration
+	receiveTimeout    time.Duration
+	advertiseInterval time.Duration
+	filters           []func(command.Command) bool
+	hooks             hooks
+	catchUp           *catchUpConfig
+	debug             bool
 }
 
 type subscription struct {
@@ -127,6 +144,17 @@ func ReceiveTimeout(dur time.Duration) Option {
 	}
 }
 
+// AdvertiseInterval returns an Option that configures the interval at which the
+// command bus advertises the commands it handles to other command buses (see
+// Bus.Handlers).
+//
+// A zero Duration disables periodic advertisements. The default interval is 30s.
+func AdvertiseInterval(dur time.Duration) Option {
+	return func(opts *options) {
+		opts.advertiseInterval = dur
+	}
+}
+
 // Deprecated: Use ReceiveTimeout instead.
 func DrainTimeout(dur time.Duration) Option {
 	return ReceiveTimeout(dur)
@@ -156,11 +184,13 @@ func New[ErrorCode constraints.Integer](enc codec.Encoding, events event.Bus, op
 	b := &Bus[ErrorCode]{
 		Handler: handler.New(events),
 		options: options{
-			assignTimeout:  DefaultAssignTimeout,
-			receiveTimeout: DefaultReceiveTimeout,
+			assignTimeout:     DefaultAssignTimeout,
+			receiveTimeout:    DefaultReceiveTimeout,
+			advertiseInterval: DefaultAdvertiseInterval,
 		},
 		subscriptions: make(map[string]*subscription),
 		requested:     make(map[uuid.UUID]command.Cmd[any]),
+		handlers:      make(map[uuid.UUID]advertisement),
 		dispatched:    make(map[uuid.UUID]dispatcher),
 		assigned:      make(map[uuid.UUID]dispatcher),
 		enc:           enc,
@@ -186,15 +216,34 @@ func New[ErrorCode constraints.Integer](enc codec.Encoding, events event.Bus, op
 func (b *Bus[ErrorCode]) Run(ctx context.Context) (<-chan error, error) {
 	b.debugLog("starting command bus ...")
 
+	// the subscriptions are released if the bus fails to start
+	ctx, cancel := context.WithCancel(ctx)
+	var started bool
+	defer func() {
+		if !started {
+			cancel()
+		}
+	}()
+
 	errs, err := b.Handler.Run(ctx)
 	if err != nil {
 		return errs, err
 	}
 
+	// handler advertisements are received over a separate subscription so
+	// that they cannot delay the handling of commands
+	handlerEvents, handlerErrs, err := b.bus.Subscribe(ctx, HandlersAdvertised, HandlersRequested)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to handler advertisements: %w", err)
+	}
+
 	b.errs, b.fail = concurrent.Errors(ctx)
-	out, _ := streams.FanIn(b.errs, errs)
+	out, _ := streams.FanIn(b.errs, errs, handlerErrs)
+
+	go b.advertiseHandlers(ctx, handlerEvents)
 
 	b.debugLog("command bus started ...")
+	started = true
 
 	return out, nil
 }
@@ -260,6 +309,18 @@ func (b *Bus[ErrorCode]) Run(ctx context.Context) (<-chan error, error) {
 //	log.Println(fmt.Sprintf("Command: %v", rep.Command()))
 //	log.Println(fmt.Sprintf("Runtime: %v", rep.Runtime()))
 //	log.Println(fmt.Sprintf("Error: %v", err))
+//
+// # Fail fast
+//
+// If no Command Bus is subscribed to a Command, Dispatch waits until the
+// AssignTimeout is exceeded, or forever if the timeout is disabled. Use the
+// dispatch.FailFast() Option to return an error that unwraps to ErrNoHandler
+// immediately if no handler is known for the Command (see Bus.Handlers):
+//
+//	err := b.Dispatch(context.TODO(), cmd, dispatch.FailFast())
+//	if errors.Is(err, cmdbus.ErrNoHandler) {
+//		log.Println("service is not running")
+//	}
 func (b *Bus[ErrorCode]) Dispatch(ctx context.Context, cmd command.Command, opts ...command.DispatchOption) (err error) {
 	b.debugLog("dispatching %q command ...", cmd.Name())
 
@@ -275,7 +336,11 @@ func (b *Bus[ErrorCode]) Dispatch(ctx context.Context, cmd command.Command, opts
 
 	cfg := dispatch.Configure(opts...)
 
-	load, err := b.enc.Marshal(cmd.Payload())
+	if cfg.FailFast && !b.HasHandler(cmd.Name()) {
+		return fmt.Errorf("%w: %s", ErrNoHandler, cmd.Name())
+	}
+
+	load, err := codec.MarshalContext(ctx, b.enc, cmd.Payload())
 	if err != nil {
 		return fmt.Errorf("encode payload: %w", err)
 	}
@@ -296,6 +361,8 @@ func (b *Bus[ErrorCode]) Dispatch(ctx context.Context, cmd command.Command, opts
 		return fmt.Errorf("publish %q event: %w", evt.Name(), err)
 	}
 
+	b.hooks.onDispatched(ctx, cmd)
+
 	out := make(chan error)
 	accepted := make(chan struct{})
 	aborted := make(chan struct{})
@@ -324,6 +391,7 @@ func (b *Bus[ErrorCode]) Dispatch(ctx context.Context, cmd command.Command, opts
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-timeout:
+		b.hooks.onTimedOut(ctx, cmd, ErrAssignTimeout)
 		return ErrAssignTimeout
 	case <-accepted:
 	}
@@ -363,6 +431,9 @@ func (b *Bus[ErrorCode]) cleanupDispatch(cmdID uuid.UUID) {
 // a Command is received from the Context channel, no other Context channel will
 // receive that Command.
 //
+// If the CatchUp Option is used, commands that were dispatched while the bus was
+// not subscribed are also pushed into the Context channel (see CatchUp).
+//
 // When ctx is canceled, the remaining Commands that have already been received
 // are pushed into the Context channel before it is closed. Use the DrainTimeout
 // Option to specify the timeout after which the remaining Commands are being
@@ -401,6 +472,17 @@ func (b *Bus[ErrorCode]) Subscribe(ctx context.Context, names ...string) (<-chan
 		b.subscriptions[name] = sub
 	}
 
+	go b.advertise(b.Context())
+
+	if b.catchUp != nil {
+		subscribed := time.Now()
+		go func() {
+			if err := b.catchUpCommands(ctx, names, subscribed); err != nil {
+				b.fail(fmt.Errorf("[goes/command/cmdbus.Bus@Subscribe] Failed to catch up on commands: %w", err))
+			}
+		}()
+	}
+
 	// unsubscribe when the context is canceled
 	go func() {
 		<-ctx.Done()
@@ -413,6 +495,8 @@ func (b *Bus[ErrorCode]) Subscribe(ctx context.Context, names ...string) (<-chan
 		for _, name := range names {
 			delete(b.subscriptions, name)
 		}
+
+		go b.advertise(b.Context())
 	}()
 
 	return out, errs, nil
@@ -490,7 +574,6 @@ func (b *Bus[ErrorCode]) commandRequested(evt event.Of[CommandRequestedData]) {
 	}
 
 	b.dispatchMux.Lock()
-	defer b.dispatchMux.Unlock()
 
 	// otherwise remove the command from the dispatched commands
 	delete(b.dispatched, data.ID)
@@ -501,12 +584,18 @@ func (b *Bus[ErrorCode]) commandRequested(evt event.Of[CommandRequestedData]) {
 	b.debugLog("publishing %q event ...", assignEvent.Name())
 
 	if err := b.bus.Publish(b.Context(), assignEvent.Any()); err != nil {
+		b.dispatchMux.Unlock()
 		b.fail(fmt.Errorf("[goes/command/cmdbus.Bus@commandRequested] Failed to assign %q command to handler %q: %w", cmd.cmd.Name(), data.BusID, err))
 		return
 	}
 
 	// and add the command to the assigned commands
 	b.assigned[data.ID] = cmd
+	b.dispatchMux.Unlock()
+
+	// hooks are called without holding the lock, so that they can dispatch
+	// commands themselves
+	b.hooks.onAssigned(b.Context(), cmd.cmd, data.BusID)
 }
 
 func (b *Bus[ErrorCode]) commandAssigned(evt event.Of[CommandAssignedData]) {
@@ -537,6 +626,11 @@ func (b *Bus[ErrorCode]) commandAssigned(evt event.Of[CommandAssignedData]) {
 	}
 
 	// then pass the command to the subscription
+	b.deliver(cmd)
+}
+
+// deliver passes an accepted command to the subscription of the command.
+func (b *Bus[ErrorCode]) deliver(cmd command.Cmd[any]) {
 	b.subMux.Lock()
 	defer b.subMux.Unlock()
 	sub, ok := b.subscriptions[cmd.Name()]
@@ -554,6 +648,7 @@ func (b *Bus[ErrorCode]) commandAssigned(evt event.Of[CommandAssignedData]) {
 	select {
 	case <-b.Context().Done():
 	case <-timeout:
+		b.hooks.onTimedOut(b.Context(), cmd, ErrReceiveTimeout)
 		select {
 		case <-b.Context().Done():
 		case sub.errs <- fmt.Errorf("dropping %q command: %w", cmd.Name(), ErrReceiveTimeout):
@@ -565,10 +660,13 @@ func (b *Bus[ErrorCode]) commandAssigned(evt event.Of[CommandAssignedData]) {
 			return b.markDone(ctx, cmd, cfg)
 		}),
 	):
+		b.hooks.onExecuting(b.Context(), cmd)
 	}
 }
 
 func (b *Bus[ErrorCode]) markDone(ctx context.Context, cmd command.Command, cfg finish.Config) error {
+	b.hooks.onExecuted(ctx, cmd, cfg.Runtime, cfg.Err)
+
 	var errbytes []byte
 
 	if cfg.Err != nil {
@@ -603,13 +701,27 @@ func (b *Bus[ErrorCode]) commandAccepted(evt event.Of[CommandAcceptedData]) {
 	data := evt.Data()
 
 	// if the bus did not assign the command, return
-	b.dispatchMux.RLock()
+	var caughtUp bool
+	b.dispatchMux.Lock()
 	cmd, ok := b.assigned[data.ID]
-	b.dispatchMux.RUnlock()
+	if !ok {
+		// a command that is accepted without being assigned was caught up by
+		// a handler that was not running when the command was dispatched
+		if cmd, ok = b.dispatched[data.ID]; ok {
+			delete(b.dispatched, data.ID)
+			b.assigned[data.ID] = cmd
+			caughtUp = true
+		}
+	}
+	b.dispatchMux.Unlock()
 	if !ok {
 		return
 	}
 
+	if caughtUp {
+		b.hooks.onAssigned(b.Context(), cmd.cmd, data.BusID)
+	}
+
 	// otherwise mark the command as accepted
 	select {
 	case <-cmd.accepted:
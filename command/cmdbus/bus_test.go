@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/command"
 	"github.com/modernice/goes/command/cmdbus"
@@ -18,6 +21,8 @@ import (
 	"github.com/modernice/goes/command/finish"
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/internal/testutil"
 )
 
@@ -449,13 +454,374 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestBus_Handlers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	handlerBus, ebus, reg := newBus(ctx)
+
+	subCtx, cancelSub := context.WithCancel(ctx)
+	defer cancelSub()
+
+	if _, _, err := handlerBus.Subscribe(subCtx, "foo-cmd"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	// the dispatching bus is started after the subscription and must request
+	// the handlers of the other buses
+	b, _, _ := newBusWith(ctx, reg, ebus)
+	dispatchBus := b.(*cmdbus.Bus[int])
+
+	awaitHandlers(t, dispatchBus, map[string]int{"foo-cmd": 1})
+
+	if !dispatchBus.HasHandler("foo-cmd") {
+		t.Fatalf("HasHandler(%q) should return true", "foo-cmd")
+	}
+
+	if dispatchBus.HasHandler("bar-cmd") {
+		t.Fatalf("HasHandler(%q) should return false", "bar-cmd")
+	}
+
+	if _, _, err := dispatchBus.Subscribe(ctx, "foo-cmd", "bar-cmd"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	awaitHandlers(t, dispatchBus, map[string]int{"foo-cmd": 2, "bar-cmd": 1})
+
+	cancelSub()
+
+	awaitHandlers(t, dispatchBus, map[string]int{"foo-cmd": 1, "bar-cmd": 1})
+}
+
+func TestBus_Dispatch_FailFast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b, ebus, reg := newBus(ctx, cmdbus.AssignTimeout(0))
+	pubBus := b.(*cmdbus.Bus[int])
+	subBus, _, _ := newBusWith(ctx, reg, ebus)
+
+	cmd := command.New("foo-cmd", mockPayload{})
+	if err := pubBus.Dispatch(ctx, cmd.Any(), dispatch.FailFast()); !errors.Is(err, cmdbus.ErrNoHandler) {
+		t.Fatalf("Dispatch should fail with %q; got %q", cmdbus.ErrNoHandler, err)
+	}
+
+	commands, _, err := subBus.Subscribe(ctx, "foo-cmd")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	awaitHandlers(t, pubBus, map[string]int{"foo-cmd": 1})
+
+	go func() {
+		for ctx := range commands {
+			ctx.Finish(ctx)
+		}
+	}()
+
+	if err := pubBus.Dispatch(ctx, cmd.Any(), dispatch.FailFast(), dispatch.Sync()); err != nil {
+		t.Fatalf("Dispatch failed with %q", err)
+	}
+}
+
+func TestBus_hooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mux sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mux.Lock()
+		defer mux.Unlock()
+		calls = append(calls, call)
+	}
+
+	mockError := errors.New("mock error")
+	bus, _, _ := newBus(ctx,
+		cmdbus.AssignTimeout(50*time.Millisecond),
+		cmdbus.OnDispatched(func(_ context.Context, cmd command.Command) { record("dispatched:" + cmd.Name()) }),
+		cmdbus.OnAssigned(func(_ context.Context, cmd command.Command, _ uuid.UUID) { record("assigned:" + cmd.Name()) }),
+		cmdbus.OnExecuting(func(_ context.Context, cmd command.Command) { record("executing:" + cmd.Name()) }),
+		cmdbus.OnExecuted(func(_ context.Context, cmd command.Command, _ time.Duration) { record("executed:" + cmd.Name()) }),
+		cmdbus.OnFailed(func(_ context.Context, cmd command.Command, _ time.Duration, err error) {
+			if errors.Is(err, mockError) {
+				record("failed:" + cmd.Name())
+			}
+		}),
+		cmdbus.OnTimedOut(func(_ context.Context, cmd command.Command, err error) {
+			if errors.Is(err, cmdbus.ErrAssignTimeout) {
+				record("timedOut:" + cmd.Name())
+			}
+		}),
+	)
+
+	commands, _, err := bus.Subscribe(ctx, "foo-cmd")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for ctx := range commands {
+			ctx.Finish(ctx, finish.WithError(mockError))
+		}
+	}()
+
+	if err := bus.Dispatch(ctx, command.New("foo-cmd", mockPayload{}).Any(), dispatch.Sync()); err == nil {
+		t.Fatalf("Dispatch should fail")
+	}
+
+	if err := bus.Dispatch(ctx, command.New("bar-cmd", mockPayload{}).Any()); !errors.Is(err, cmdbus.ErrAssignTimeout) {
+		t.Fatalf("Dispatch should fail with %q; got %q", cmdbus.ErrAssignTimeout, err)
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	// hooks are called from different goroutines, so the order is not guaranteed
+	want := []string{"assigned:foo-cmd", "dispatched:bar-cmd", "dispatched:foo-cmd", "executing:foo-cmd", "failed:foo-cmd", "timedOut:bar-cmd"}
+	sort.Strings(calls)
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("hooks should be called %v; got %v", want, calls)
+	}
+}
+
+func TestBus_OnAssigned_dispatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The hook blocks the event handling of the bus, so the command that is
+	// dispatched by the hook cannot be assigned. The hook must be able to
+	// call Dispatch though, instead of blocking on the locks of the bus.
+	var bus command.Bus
+	hookDispatched := make(chan error, 1)
+	bus, _, _ = newBus(ctx, cmdbus.OnAssigned(func(ctx context.Context, cmd command.Command, _ uuid.UUID) {
+		if cmd.Name() == "foo-cmd" {
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			hookDispatched <- bus.Dispatch(ctx, command.New("bar-cmd", mockPayload{}).Any())
+		}
+	}))
+
+	commands, _, err := bus.Subscribe(ctx, "foo-cmd", "bar-cmd")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	go func() {
+		for ctx := range commands {
+			ctx.Finish(ctx)
+		}
+	}()
+
+	if err := bus.Dispatch(ctx, command.New("foo-cmd", mockPayload{}).Any()); err != nil {
+		t.Fatalf("Dispatch failed with %q", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("OnAssigned hook could not dispatch a command")
+	case err := <-hookDispatched:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Dispatch in OnAssigned hook should fail with %q; got %q", context.DeadlineExceeded, err)
+		}
+	}
+}
+
+func TestBus_Run_subscribeError(t *testing.T) {
+	ebus := &failingSubscribeBus{Bus: eventbus.New(), failAt: 2}
+	bus := cmdbus.New[int](newRegistry(), ebus)
+
+	if _, err := bus.Run(context.Background()); err == nil {
+		t.Fatalf("Run should fail if the handler advertisements cannot be subscribed to")
+	}
+
+	if len(ebus.contexts) != 1 {
+		t.Fatalf("Run should subscribe to the command events; got %d subscriptions", len(ebus.contexts))
+	}
+
+	select {
+	case <-ebus.contexts[0].Done():
+	case <-time.After(time.Second):
+		t.Fatalf("subscription to the command events should be released if Run fails")
+	}
+}
+
+type failingSubscribeBus struct {
+	event.Bus
+
+	failAt   int
+	contexts []context.Context
+}
+
+func (bus *failingSubscribeBus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	if len(bus.contexts)+1 == bus.failAt {
+		return nil, nil, errors.New("mock error")
+	}
+	bus.contexts = append(bus.contexts, ctx)
+	return bus.Bus.Subscribe(ctx, names...)
+}
+
+func TestCatchUp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := eventstore.New()
+	ebus := &storingBus{Bus: eventbus.New(), store: store}
+
+	assigned := make(chan uuid.UUID, 1)
+	b, _, reg := newBusWith(ctx, newRegistry(), ebus, cmdbus.AssignTimeout(time.Second), cmdbus.OnAssigned(func(_ context.Context, cmd command.Command, _ uuid.UUID) {
+		assigned <- cmd.ID()
+	}))
+	pubBus := b.(*cmdbus.Bus[int])
+
+	missed := command.New("foo-cmd", mockPayload{A: "missed"})
+	dispatched := make(chan error, 1)
+	go func() { dispatched <- pubBus.Dispatch(ctx, missed.Any()) }()
+
+	awaitDispatched(t, store, missed.ID())
+
+	for _, evt := range []event.Event{
+		// outside of the TTL
+		newDispatchedEvent("foo-cmd", []byte(`{"A":"expired"}`), time.Now().Add(-2*time.Hour)),
+		// the dispatcher has already timed out
+		newDispatchedEvent("foo-cmd", []byte(`{"A":"timed out"}`), time.Now().Add(-2*time.Second)),
+		// the payload cannot be decoded
+		newDispatchedEvent("foo-cmd", []byte(`{`), time.Now()),
+	} {
+		if err := store.Insert(ctx, evt); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+	}
+
+	subBus := cmdbus.New[int](reg, ebus, cmdbus.CatchUp(store, time.Hour), cmdbus.AssignTimeout(time.Second))
+	runErrs, err := subBus.Run(ctx)
+	if err != nil {
+		t.Fatalf("failed to run command bus: %v", err)
+	}
+
+	commands, errs, err := subBus.Subscribe(ctx, "foo-cmd")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatalf("missed command was not caught up")
+	case cmdCtx := <-commands:
+		assertEqualCommands(t, cmdCtx, missed.Any())
+		if err := cmdCtx.Finish(cmdCtx); err != nil {
+			t.Fatalf("finish command: %v", err)
+		}
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("undecodable command should be reported")
+	case err := <-runErrs:
+		if err == nil || !strings.Contains(err.Error(), "decode payload") {
+			t.Fatalf("command bus should report the undecodable command; got %v", err)
+		}
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("Dispatch should return after the command was caught up")
+	case err := <-dispatched:
+		if err != nil {
+			t.Fatalf("Dispatch failed with %q", err)
+		}
+	}
+
+	select {
+	case cmdCtx := <-commands:
+		t.Fatalf("command should be caught up only once and expired commands should be ignored; got %v", cmdCtx.Payload())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	select {
+	case id := <-assigned:
+		if id != missed.ID() {
+			t.Fatalf("OnAssigned should be called for command %s; got %s", missed.ID(), id)
+		}
+	default:
+		t.Fatalf("OnAssigned should be called for the caught up command")
+	}
+}
+
+func newDispatchedEvent(name string, payload []byte, t time.Time) event.Event {
+	return event.New(cmdbus.CommandDispatched, cmdbus.CommandDispatchedData{
+		ID:      uuid.New(),
+		Name:    name,
+		Payload: payload,
+	}, event.Time(t)).Any()
+}
+
+func awaitDispatched(t *testing.T, store event.Store, cmdID uuid.UUID) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		events, _, err := store.Query(context.Background(), query.New(query.Name(cmdbus.CommandDispatched)))
+		if err != nil {
+			t.Fatalf("query events: %v", err)
+		}
+
+		for evt := range events {
+			if data, ok := event.TryCast[cmdbus.CommandDispatchedData](evt); ok && data.Data().ID == cmdID {
+				return
+			}
+		}
+
+		select {
+		case <-timeout:
+			t.Fatalf("command %s was not dispatched", cmdID)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+type storingBus struct {
+	event.Bus
+	store event.Store
+}
+
+func (bus *storingBus) Publish(ctx context.Context, events ...event.Event) error {
+	if err := bus.store.Insert(ctx, events...); err != nil {
+		return err
+	}
+	return bus.Bus.Publish(ctx, events...)
+}
+
+func awaitHandlers(t *testing.T, bus *cmdbus.Bus[int], want map[string]int) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeout:
+			t.Fatalf("Handlers() should return %v; got %v", want, bus.Handlers())
+		case <-ticker.C:
+			if reflect.DeepEqual(bus.Handlers(), want) {
+				return
+			}
+		}
+	}
+}
+
 func newBus(ctx context.Context, opts ...cmdbus.Option) (command.Bus, event.Bus, *codec.Registry) {
+	return newBusWith(ctx, newRegistry(), eventbus.New(), opts...)
+}
+
+func newRegistry() *codec.Registry {
 	enc := codec.New()
 	codec.Register[mockPayload](enc, "foo-cmd")
 	codec.Register[mockPayload](enc, "bar-cmd")
-	ebus := eventbus.New()
-
-	return newBusWith(ctx, enc, ebus, opts...)
+	return enc
 }
 
 func newBusWith(ctx context.Context, reg *codec.Registry, ebus event.Bus, opts ...cmdbus.Option) (command.Bus, event.Bus, *codec.Registry) {
@@ -0,0 +1,136 @@
+package cmdbus
+
+import (
+	"context"
+	"fmt"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/helper/streams"
+	"golang.org/x/exp/slices"
+)
+
+type catchUpConfig struct {
+	store event.Store
+	ttl   stdtime.Duration
+}
+
+// CatchUp returns an Option that makes the command bus catch up on commands
+// that were dispatched while it was not running. This requires a durable
+// command transport: the events of the command bus must be persisted in the
+// provided event store, e.g. by using an event bus that inserts published
+// events into the store before publishing them.
+//
+// When the command bus subscribes to commands, it queries the store for
+// commands that have been dispatched within the given TTL before the
+// subscription and that have not been accepted by any command bus. The command
+// bus accepts these commands and passes them to the subscription, so that
+// commands that are dispatched during a rollout are not silently dropped. A
+// dispatcher that is still waiting for the command is notified as if the
+// command was assigned normally.
+//
+// Commands that were dispatched longer than the AssignTimeout ago are not
+// caught up, because their dispatchers have already failed with
+// ErrAssignTimeout. The AssignTimeout of the catching up command bus should
+// therefore match the AssignTimeout of the dispatching command buses, and the
+// TTL only limits the catch-up window if it is shorter than the AssignTimeout
+// or if the timeout is disabled. Commands whose payload cannot be decoded are
+// skipped and reported to the error channel of the command bus.
+//
+// Command buses that catch up concurrently may handle the same command more
+// than once, so handlers of caught up commands should be idempotent.
+func CatchUp(store event.Store, ttl stdtime.Duration) Option {
+	return func(opts *options) {
+		opts.catchUp = &catchUpConfig{store: store, ttl: ttl}
+	}
+}
+
+// catchUpCommands passes the missed commands with the given names to their
+// subscriptions. Missed commands are commands that were dispatched before the
+// given time and within the TTL and the AssignTimeout, and that have not been
+// accepted by any bus.
+func (b *Bus[ErrorCode]) catchUpCommands(ctx context.Context, names []string, before stdtime.Time) error {
+	q := query.New(
+		query.Name(CommandDispatched, CommandAccepted),
+		query.Time(time.Min(b.catchUpSince(before))),
+		query.SortByTime(),
+	)
+
+	events, errs, err := b.catchUp.store.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("query commands: %w", err)
+	}
+
+	var missed []event.Of[CommandDispatchedData]
+	accepted := make(map[uuid.UUID]bool)
+
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		// commands that are dispatched after the subscription are handled normally
+		if data, ok := event.TryCast[CommandDispatchedData](evt); ok && evt.Time().Before(before) && slices.Contains(names, data.Data().Name) {
+			missed = append(missed, data)
+		}
+		if data, ok := event.TryCast[CommandAcceptedData](evt); ok {
+			accepted[data.Data().ID] = true
+		}
+		return nil
+	}, events, errs); err != nil {
+		return fmt.Errorf("query commands: %w", err)
+	}
+
+	for _, evt := range missed {
+		data := evt.Data()
+		if accepted[data.ID] {
+			continue
+		}
+
+		// the dispatcher of the command has already timed out
+		if evt.Time().Before(b.catchUpSince(stdtime.Now())) {
+			b.debugLog("dropping %q command because its dispatcher timed out ... [id=%s]", data.Name, data.ID)
+			continue
+		}
+
+		load, err := codec.UnmarshalContext(ctx, b.enc, data.Payload, data.Name)
+		if err != nil {
+			b.fail(fmt.Errorf("[goes/command/cmdbus.Bus@Subscribe] Failed to catch up on %q command: decode payload: %w [id=%s]", data.Name, err, data.ID))
+			continue
+		}
+
+		cmd := command.New(data.Name, load, command.ID(data.ID), command.Aggregate(data.AggregateName, data.AggregateID))
+
+		if !b.filterAllows(cmd) {
+			continue
+		}
+
+		b.debugLog("catching up on %q command ... [id=%s]", data.Name, data.ID)
+
+		acceptEvt := event.New(CommandAccepted, CommandAcceptedData{
+			ID:    data.ID,
+			BusID: b.id,
+		})
+
+		if err := b.bus.Publish(ctx, acceptEvt.Any()); err != nil {
+			return fmt.Errorf("accept %q command: %w", data.Name, err)
+		}
+
+		b.deliver(cmd)
+	}
+
+	return nil
+}
+
+// catchUpSince returns the time after which commands must have been dispatched
+// to be caught up at the given time.
+func (b *Bus[ErrorCode]) catchUpSince(now stdtime.Time) stdtime.Time {
+	since := now.Add(-b.catchUp.ttl)
+	if b.assignTimeout > 0 {
+		if timeout := now.Add(-b.assignTimeout); timeout.After(since) {
+			since = timeout
+		}
+	}
+	return since
+}
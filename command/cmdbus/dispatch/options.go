@@ -41,3 +41,13 @@ func Report(r command.Reporter) command.DispatchOption {
 		cfg.Reporter = r
 	}
 }
+
+// FailFast returns a DispatchOption that makes the dispatch fail immediately
+// if no handler is subscribed to the command. Otherwise, the dispatch waits
+// until the command is assigned to a handler, which may never happen if the
+// service that handles the command is not running.
+func FailFast() command.DispatchOption {
+	return func(cfg *command.DispatchConfig) {
+		cfg.FailFast = true
+	}
+}
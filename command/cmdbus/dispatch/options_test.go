@@ -22,3 +22,10 @@ func TestReport(t *testing.T) {
 		t.Fatalf("cfg.Report should point to %p; got %v", &rep, cfg.Reporter)
 	}
 }
+
+func TestFailFast(t *testing.T) {
+	cfg := dispatch.Configure(dispatch.FailFast())
+	if !cfg.FailFast {
+		t.Fatalf("cfg.FailFast should be %t; got %t", true, cfg.FailFast)
+	}
+}
@@ -26,6 +26,14 @@ const (
 	// CommandExecuted is published by a Bus to notify other Buses that a
 	// Command has been executed.
 	CommandExecuted = "goes.command.executed"
+
+	// HandlersAdvertised is published by a Bus to advertise the Commands it
+	// currently handles to other Buses.
+	HandlersAdvertised = "goes.command.handlers_advertised"
+
+	// HandlersRequested is published by a Bus to request the other Buses to
+	// advertise the Commands they handle.
+	HandlersRequested = "goes.command.handlers_requested"
 )
 
 // CommandDispatchedData is the event Data for the CommandDispatched Event.
@@ -72,6 +80,20 @@ type CommandExecutedData struct {
 	Error   []byte // *google.protobuf.Any
 }
 
+// HandlersAdvertisedData is the event Data for the HandlersAdvertised Event.
+type HandlersAdvertisedData struct {
+	BusID uuid.UUID
+
+	// Commands are the names of the Commands the Bus currently handles. An
+	// empty list withdraws all previous advertisements of the Bus.
+	Commands []string
+}
+
+// HandlersRequestedData is the event Data for the HandlersRequested Event.
+type HandlersRequestedData struct {
+	BusID uuid.UUID
+}
+
 // RegisterEvents registers the command events into a Registry.
 func RegisterEvents(r codec.Registerer) {
 	codec.Register[CommandDispatchedData](r, CommandDispatched)
@@ -79,4 +101,6 @@ func RegisterEvents(r codec.Registerer) {
 	codec.Register[CommandAssignedData](r, CommandAssigned)
 	codec.Register[CommandAcceptedData](r, CommandAccepted)
 	codec.Register[CommandExecutedData](r, CommandExecuted)
+	codec.Register[HandlersAdvertisedData](r, HandlersAdvertised)
+	codec.Register[HandlersRequestedData](r, HandlersRequested)
 }
@@ -0,0 +1,170 @@
+package cmdbus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/modernice/goes/event"
+)
+
+// withdrawTimeout is the timeout for withdrawing the advertised handlers of a
+// command bus after it has been stopped.
+const withdrawTimeout = 5 * time.Second
+
+type advertisement struct {
+	commands []string
+	time     time.Time
+	seen     time.Time
+}
+
+// Handlers returns the names of the commands that currently have live handlers,
+// mapped to the number of command buses that handle them. Command buses
+// advertise the commands they handle to the other command buses that use the
+// same event bus, so the returned handlers include the handlers of remote
+// command buses. Dispatchers can use Handlers to fail fast instead of waiting
+// for the assign timeout when a command has no handler:
+//
+//	if bus.Handlers()["foo-cmd"] == 0 {
+//		return errors.New("no handler for foo-cmd")
+//	}
+//
+// Advertisements of remote command buses may arrive with a delay, so a command
+// bus that has just started may not know about all handlers yet.
+func (b *Bus[ErrorCode]) Handlers() map[string]int {
+	out := make(map[string]int)
+
+	for _, name := range b.subscribedCommands() {
+		out[name]++
+	}
+
+	b.handlersMux.RLock()
+	defer b.handlersMux.RUnlock()
+
+	for id, adv := range b.handlers {
+		if id == b.id || b.expired(adv) {
+			continue
+		}
+		for _, name := range adv.commands {
+			out[name]++
+		}
+	}
+
+	return out
+}
+
+// HasHandler returns whether the command with the given name currently has a
+// live handler (see Handlers).
+func (b *Bus[ErrorCode]) HasHandler(name string) bool {
+	return b.Handlers()[name] > 0
+}
+
+func (b *Bus[ErrorCode]) expired(adv advertisement) bool {
+	return b.advertiseInterval > 0 && time.Since(adv.seen) > 3*b.advertiseInterval
+}
+
+func (b *Bus[ErrorCode]) subscribedCommands() []string {
+	b.subMux.RLock()
+	defer b.subMux.RUnlock()
+
+	names := make([]string, 0, len(b.subscriptions))
+	for name := range b.subscriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// advertiseHandlers requests the handlers of the other command buses, handles
+// the advertisements of other buses and periodically advertises the handlers of
+// this bus until ctx is canceled. When ctx is canceled, the advertised handlers
+// are withdrawn.
+func (b *Bus[ErrorCode]) advertiseHandlers(ctx context.Context, events <-chan event.Event) {
+	evt := event.New(HandlersRequested, HandlersRequestedData{BusID: b.id})
+	if err := b.bus.Publish(ctx, evt.Any()); err != nil && ctx.Err() == nil {
+		b.fail(fmt.Errorf("[goes/command/cmdbus.Bus@advertiseHandlers] Failed to request handlers: %w", err))
+	}
+
+	var tick <-chan time.Time
+	if b.advertiseInterval > 0 {
+		ticker := time.NewTicker(b.advertiseInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			withdrawCtx, cancel := context.WithTimeout(context.Background(), withdrawTimeout)
+			defer cancel()
+			b.publishHandlers(withdrawCtx, nil)
+			return
+		case evt, ok := <-events:
+			if !ok {
+				events = nil
+				break
+			}
+			if adv, ok := event.TryCast[HandlersAdvertisedData](evt); ok {
+				b.handlersAdvertised(adv)
+			} else if req, ok := event.TryCast[HandlersRequestedData](evt); ok {
+				b.handlersRequested(req)
+			}
+		case <-tick:
+			if commands := b.subscribedCommands(); len(commands) > 0 {
+				b.publishHandlers(ctx, commands)
+			}
+		}
+	}
+}
+
+// advertise advertises the commands that are currently handled by the bus.
+func (b *Bus[ErrorCode]) advertise(ctx context.Context) {
+	b.publishHandlers(ctx, b.subscribedCommands())
+}
+
+func (b *Bus[ErrorCode]) publishHandlers(ctx context.Context, commands []string) {
+	evt := event.New(HandlersAdvertised, HandlersAdvertisedData{
+		BusID:    b.id,
+		Commands: commands,
+	})
+
+	b.debugLog("publishing %q event ...", evt.Name())
+
+	if err := b.bus.Publish(ctx, evt.Any()); err != nil && ctx.Err() == nil {
+		b.fail(fmt.Errorf("[goes/command/cmdbus.Bus@publishHandlers] Failed to advertise handlers: %w", err))
+	}
+}
+
+func (b *Bus[ErrorCode]) handlersAdvertised(evt event.Of[HandlersAdvertisedData]) {
+	data := evt.Data()
+	if data.BusID == b.id {
+		return
+	}
+
+	b.handlersMux.Lock()
+	defer b.handlersMux.Unlock()
+
+	// ignore advertisements that are older than the latest advertisement
+	if adv, ok := b.handlers[data.BusID]; ok && evt.Time().Before(adv.time) {
+		return
+	}
+
+	b.handlers[data.BusID] = advertisement{
+		commands: data.Commands,
+		time:     evt.Time(),
+		seen:     time.Now(),
+	}
+}
+
+func (b *Bus[ErrorCode]) handlersRequested(evt event.Of[HandlersRequestedData]) {
+	if evt.Data().BusID == b.id {
+		return
+	}
+
+	// publish asynchronously to not block the handling of advertisements
+	if commands := b.subscribedCommands(); len(commands) > 0 {
+		go b.publishHandlers(b.Context(), commands)
+	}
+}
@@ -0,0 +1,113 @@
+package cmdbus
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/command"
+)
+
+// hooks are the lifecycle hooks of a command bus. Hooks allow metrics and
+// tracing integrations to observe the lifecycle of commands without parsing the
+// internal events of the command bus.
+type hooks struct {
+	dispatched []func(context.Context, command.Command)
+	assigned   []func(context.Context, command.Command, uuid.UUID)
+	executing  []func(context.Context, command.Command)
+	executed   []func(context.Context, command.Command, time.Duration)
+	failed     []func(context.Context, command.Command, time.Duration, error)
+	timedOut   []func(context.Context, command.Command, error)
+}
+
+// OnDispatched returns an Option that adds a hook that is called by the
+// dispatching command bus after a command has been dispatched.
+func OnDispatched(fn func(context.Context, command.Command)) Option {
+	return func(opts *options) {
+		opts.hooks.dispatched = append(opts.hooks.dispatched, fn)
+	}
+}
+
+// OnAssigned returns an Option that adds a hook that is called by the
+// dispatching command bus after a command has been assigned to a handler. The
+// hook is called with the id of the command bus the command was assigned to.
+// The hook is called by the event handling of the bus, so it should return
+// quickly; hooks that wait for dispatched commands must dispatch them in a
+// separate goroutine.
+func OnAssigned(fn func(ctx context.Context, cmd command.Command, busID uuid.UUID)) Option {
+	return func(opts *options) {
+		opts.hooks.assigned = append(opts.hooks.assigned, fn)
+	}
+}
+
+// OnExecuting returns an Option that adds a hook that is called by the handling
+// command bus after a command has been received by its handler.
+func OnExecuting(fn func(context.Context, command.Command)) Option {
+	return func(opts *options) {
+		opts.hooks.executing = append(opts.hooks.executing, fn)
+	}
+}
+
+// OnExecuted returns an Option that adds a hook that is called by the handling
+// command bus after a command has been executed successfully.
+func OnExecuted(fn func(ctx context.Context, cmd command.Command, runtime time.Duration)) Option {
+	return func(opts *options) {
+		opts.hooks.executed = append(opts.hooks.executed, fn)
+	}
+}
+
+// OnFailed returns an Option that adds a hook that is called by the handling
+// command bus after the execution of a command failed.
+func OnFailed(fn func(ctx context.Context, cmd command.Command, runtime time.Duration, err error)) Option {
+	return func(opts *options) {
+		opts.hooks.failed = append(opts.hooks.failed, fn)
+	}
+}
+
+// OnTimedOut returns an Option that adds a hook that is called when a command
+// times out. The dispatching command bus calls the hook with ErrAssignTimeout
+// if a command could not be assigned to a handler within the AssignTimeout.
+// The handling command bus calls the hook with ErrReceiveTimeout if a command
+// was dropped because of the ReceiveTimeout.
+func OnTimedOut(fn func(ctx context.Context, cmd command.Command, err error)) Option {
+	return func(opts *options) {
+		opts.hooks.timedOut = append(opts.hooks.timedOut, fn)
+	}
+}
+
+func (h hooks) onDispatched(ctx context.Context, cmd command.Command) {
+	for _, fn := range h.dispatched {
+		fn(ctx, cmd)
+	}
+}
+
+func (h hooks) onAssigned(ctx context.Context, cmd command.Command, busID uuid.UUID) {
+	for _, fn := range h.assigned {
+		fn(ctx, cmd, busID)
+	}
+}
+
+func (h hooks) onExecuting(ctx context.Context, cmd command.Command) {
+	for _, fn := range h.executing {
+		fn(ctx, cmd)
+	}
+}
+
+func (h hooks) onExecuted(ctx context.Context, cmd command.Command, runtime time.Duration, err error) {
+	if err != nil {
+		for _, fn := range h.failed {
+			fn(ctx, cmd, runtime, err)
+		}
+		return
+	}
+
+	for _, fn := range h.executed {
+		fn(ctx, cmd, runtime)
+	}
+}
+
+func (h hooks) onTimedOut(ctx context.Context, cmd command.Command, err error) {
+	for _, fn := range h.timedOut {
+		fn(ctx, cmd, err)
+	}
+}
@@ -0,0 +1,82 @@
+package commandtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/finish"
+)
+
+// commandBus is an in-memory command bus that passes dispatched commands
+// directly to the subscriber of the command and waits until the command is
+// finished. Unlike the cmdbus package, commands are neither encoded nor sent
+// over an event bus, so errors of the handlers are returned as-is.
+type commandBus struct {
+	mux         sync.RWMutex
+	subscribers map[string]chan command.Context
+}
+
+func newCommandBus() *commandBus {
+	return &commandBus{subscribers: make(map[string]chan command.Context)}
+}
+
+func (bus *commandBus) Dispatch(ctx context.Context, cmd command.Command, _ ...command.DispatchOption) error {
+	bus.mux.RLock()
+	sub, ok := bus.subscribers[cmd.Name()]
+	bus.mux.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w %q", ErrNoHandler, cmd.Name())
+	}
+
+	done := make(chan error, 1)
+	cmdCtx := command.NewContext(ctx, cmd, command.WhenDone(func(_ context.Context, cfg finish.Config) error {
+		done <- cfg.Err
+		return nil
+	}))
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("dispatch %q command: %w", cmd.Name(), ctx.Err())
+	case sub <- cmdCtx:
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("handle %q command: %w", cmd.Name(), ctx.Err())
+	case err := <-done:
+		return err
+	}
+}
+
+func (bus *commandBus) Subscribe(ctx context.Context, names ...string) (<-chan command.Context, <-chan error, error) {
+	bus.mux.Lock()
+	defer bus.mux.Unlock()
+
+	for _, name := range names {
+		if _, ok := bus.subscribers[name]; ok {
+			return nil, nil, fmt.Errorf("%q command is already handled", name)
+		}
+	}
+
+	out := make(chan command.Context)
+	for _, name := range names {
+		bus.subscribers[name] = out
+	}
+
+	errs := make(chan error)
+	go func() {
+		<-ctx.Done()
+
+		bus.mux.Lock()
+		defer bus.mux.Unlock()
+		for _, name := range names {
+			delete(bus.subscribers, name)
+		}
+		close(errs)
+	}()
+
+	return out, errs, nil
+}
@@ -0,0 +1,450 @@
+// Package commandtest provides a given/when/then test harness for command
+// handlers. A command is dispatched to a set of handlers that are wired to an
+// in-memory event store, event bus and command bus, and the events that were
+// persisted and published by the handlers are asserted:
+//
+//	func TestPlaceOrder(t *testing.T) {
+//		id := uuid.New()
+//		commandtest.Given(
+//			event.New("order.created", OrderCreated{}, event.Aggregate(id, "order", 1)).Any(),
+//		).When(
+//			command.New("order.place", PlaceOrder{Total: 42}, command.Aggregate("order", id)).Any(),
+//			commandtest.Aggregate(NewOrder),
+//		).Then(t,
+//			commandtest.Expect("order.placed", commandtest.Version(2), commandtest.Field("Total", 42)),
+//		)
+//	}
+//
+// Commands are dispatched synchronously and without being encoded, so the
+// errors of the handlers can be asserted using errors.Is (see Result.ThenErr).
+// Use the contract package to verify the encoding of command payloads.
+package commandtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/handler"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// DefaultTimeout is the maximum time a command handler may take to handle the
+// command of a Scenario.
+const DefaultTimeout = 5 * time.Second
+
+// ErrNoHandler is returned when no handler of a Scenario handles the
+// dispatched command.
+var ErrNoHandler = errors.New("no handler for command")
+
+// Infra is the in-memory infrastructure that the handlers of a Scenario are
+// wired to.
+type Infra struct {
+	// Commands is the command bus that dispatches the command of the
+	// Scenario.
+	Commands command.Bus
+
+	// Events is the event bus that the event store publishes the persisted
+	// events to.
+	Events event.Bus
+
+	// Store is the event store that contains the events of the Scenario.
+	Store event.Store
+
+	// Repository is the aggregate repository of the event store.
+	Repository aggregate.Repository
+}
+
+// Handlers wires a set of command handlers to the infrastructure of a
+// Scenario. Handlers subscribes to the commands of the handlers until ctx is
+// canceled.
+type Handlers func(ctx context.Context, infra Infra) (<-chan error, error)
+
+// Aggregate returns the Handlers of the aggregate type that is instantiated
+// by newFunc (see handler.New).
+func Aggregate[A handler.Aggregate](newFunc func(uuid.UUID) A, opts ...command.HandlerOption) Handlers {
+	return func(ctx context.Context, infra Infra) (<-chan error, error) {
+		return handler.New(newFunc, infra.Repository, infra.Commands, opts...).Handle(ctx)
+	}
+}
+
+// Funcs returns the Handlers of the command.Handlers that are returned by fn,
+// e.g. the handlers of a service that use the repository of the Scenario.
+func Funcs(fn func(Infra) command.Handlers) Handlers {
+	return func(ctx context.Context, infra Infra) (<-chan error, error) {
+		handlers := fn(infra)
+
+		var errs []<-chan error
+		for _, name := range handlers.CommandNames() {
+			h, err := command.Handle(ctx, infra.Commands, name, handlers.CommandHandler(name))
+			if err != nil {
+				return nil, fmt.Errorf("handle %q commands: %w", name, err)
+			}
+			errs = append(errs, h)
+		}
+
+		return streams.FanInAll(errs...), nil
+	}
+}
+
+// Scenario is the "given" part of a command handler test. It holds the events
+// that exist in the event store before the command is dispatched.
+type Scenario struct {
+	events []event.Event
+}
+
+// Given returns a Scenario with the given events in the event store. The
+// events are not published.
+func Given(events ...event.Event) Scenario {
+	return Scenario{events: events}
+}
+
+// Result is the result of dispatching a command in a Scenario, which can be
+// checked using the Then methods.
+type Result struct {
+	err       error
+	persisted []event.Event
+	published []event.Event
+}
+
+// When wires the given handlers to the in-memory infrastructure of the
+// Scenario, dispatches the command to them and waits until the command was
+// handled.
+func (s Scenario) When(cmd command.Command, handlers ...Handlers) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	var res Result
+
+	store := eventstore.New()
+	if err := store.Insert(ctx, s.events...); err != nil {
+		res.err = fmt.Errorf("insert events: %w", err)
+		return res
+	}
+
+	rec := &recorder{}
+	bus := &recordingBus{Bus: eventbus.New(), rec: rec}
+	recStore := eventstore.WithBus(&recordingStore{Store: store, rec: rec}, bus)
+
+	infra := Infra{
+		Commands:   newCommandBus(),
+		Events:     bus,
+		Store:      recStore,
+		Repository: repository.New(recStore),
+	}
+
+	for _, h := range handlers {
+		errs, err := h(ctx, infra)
+		if err != nil {
+			res.err = fmt.Errorf("wire handlers: %w", err)
+			return res
+		}
+
+		// The errors of the handlers are also returned by Dispatch.
+		go func() {
+			for range errs {
+			}
+		}()
+	}
+
+	res.err = infra.Commands.Dispatch(ctx, cmd)
+	res.persisted, res.published = rec.events()
+
+	return res
+}
+
+// Persisted returns the events that were inserted into the event store while
+// the command was handled, in the order they were inserted.
+func (r Result) Persisted() []event.Event {
+	return r.persisted
+}
+
+// Published returns the events that were published over the event bus while
+// the command was handled, in the order they were published.
+func (r Result) Published() []event.Event {
+	return r.published
+}
+
+// Err returns the error of the command handler, if any.
+func (r Result) Err() error {
+	return r.err
+}
+
+// Then asserts that the command was handled without errors and that exactly
+// the expected events were persisted and published, in the given order. Pass
+// no expectations to assert that no events were persisted or published.
+func (r Result) Then(t testing.TB, want ...Expectation) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	check(t, "persisted", want, r.persisted)
+	check(t, "published", want, r.published)
+}
+
+// ThenPersisted asserts that the command was handled without errors and that
+// exactly the expected events were inserted into the event store, in the
+// given order.
+func (r Result) ThenPersisted(t testing.TB, want ...Expectation) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	check(t, "persisted", want, r.persisted)
+}
+
+// ThenPublished asserts that the command was handled without errors and that
+// exactly the expected events were published over the event bus, in the given
+// order. Use ThenPublished for handlers that publish events without inserting
+// them into the event store.
+func (r Result) ThenPublished(t testing.TB, want ...Expectation) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	check(t, "published", want, r.published)
+}
+
+// ThenErr asserts that handling the command failed with the given error, which
+// is compared using errors.Is, and that no events were persisted. If err is
+// nil, ThenErr asserts that handling the command failed with any error.
+func (r Result) ThenErr(t testing.TB, err error) {
+	t.Helper()
+
+	if r.err == nil {
+		t.Fatalf("expected error %v; got nil", err)
+	}
+
+	if err != nil && !errors.Is(r.err, err) {
+		t.Fatalf("expected error %q; got %q", err, r.err)
+	}
+
+	check(t, "persisted", nil, r.persisted)
+}
+
+// Expectation is an expected event of a Result.
+type Expectation struct {
+	name   string
+	checks []func(event.Event) error
+}
+
+// ExpectOption is an option for an Expectation.
+type ExpectOption func(*Expectation)
+
+// Expect returns an Expectation of an event with the given name.
+func Expect(name string, opts ...ExpectOption) Expectation {
+	exp := Expectation{name: name}
+	for _, opt := range opts {
+		opt(&exp)
+	}
+	return exp
+}
+
+// Version returns an ExpectOption that expects the given aggregate version.
+func Version(v int) ExpectOption {
+	return expect(func(evt event.Event) error {
+		if _, _, got := evt.Aggregate(); got != v {
+			return fmt.Errorf("aggregate version should be %d; got %d", v, got)
+		}
+		return nil
+	})
+}
+
+// Ref returns an ExpectOption that expects the event to belong to the given
+// aggregate.
+func Ref(ref aggregate.Ref) ExpectOption {
+	return expect(func(evt event.Event) error {
+		if id, name, _ := evt.Aggregate(); name != ref.Name || id != ref.ID {
+			return fmt.Errorf("aggregate should be %s(%s); got %s(%s)", ref.Name, ref.ID, name, id)
+		}
+		return nil
+	})
+}
+
+// Data returns an ExpectOption that expects the event data to be deeply equal
+// to the given data.
+func Data(want any) ExpectOption {
+	return expect(func(evt event.Event) error {
+		if !reflect.DeepEqual(want, evt.Data()) {
+			return fmt.Errorf("event data should be %#v; got %#v", want, evt.Data())
+		}
+		return nil
+	})
+}
+
+// Field returns an ExpectOption that expects a field of the event data to be
+// deeply equal to the given value. Nested fields are separated by dots, e.g.
+// "Customer.Name". Fields of maps with string keys are looked up by key. If
+// the value has another type than the field, it is converted to the type of
+// the field, so that Field("Total", 42) matches an int64 field.
+func Field(path string, want any) ExpectOption {
+	return expect(func(evt event.Event) error {
+		field, err := lookupField(reflect.ValueOf(evt.Data()), path)
+		if err != nil {
+			return err
+		}
+
+		wantVal := reflect.ValueOf(want)
+		if want != nil && wantVal.Type() != field.Type() && wantVal.Type().ConvertibleTo(field.Type()) {
+			wantVal = wantVal.Convert(field.Type())
+		}
+
+		var got any
+		if field.CanInterface() {
+			got = field.Interface()
+		}
+
+		if want == nil {
+			if field.IsValid() && !isNil(field) {
+				return fmt.Errorf("field %q should be nil; got %#v", path, got)
+			}
+			return nil
+		}
+
+		if !reflect.DeepEqual(wantVal.Interface(), got) {
+			return fmt.Errorf("field %q should be %#v; got %#v", path, want, got)
+		}
+
+		return nil
+	})
+}
+
+func expect(check func(event.Event) error) ExpectOption {
+	return func(exp *Expectation) {
+		exp.checks = append(exp.checks, check)
+	}
+}
+
+func check(t testing.TB, kind string, want []Expectation, got []event.Event) {
+	t.Helper()
+
+	wantNames := make([]string, len(want))
+	for i, exp := range want {
+		wantNames[i] = exp.name
+	}
+
+	gotNames := make([]string, len(got))
+	for i, evt := range got {
+		gotNames[i] = evt.Name()
+	}
+
+	if diff := cmp.Diff(wantNames, gotNames, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected %s events (-want +got):\n%s", kind, diff)
+	}
+
+	var problems []string
+	for i, exp := range want {
+		for _, check := range exp.checks {
+			if err := check(got[i]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s event #%d (%s): %v", kind, i, exp.name, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		t.Fatalf("unexpected %s events:\n%s", kind, strings.Join(problems, "\n"))
+	}
+}
+
+func lookupField(val reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+			if val.IsNil() {
+				return reflect.Value{}, fmt.Errorf("field %q: %q is nil", path, name)
+			}
+			val = val.Elem()
+		}
+
+		switch val.Kind() {
+		case reflect.Struct:
+			val = val.FieldByName(name)
+		case reflect.Map:
+			if val.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, fmt.Errorf("field %q: %s has no string keys", path, val.Type())
+			}
+			val = val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+		default:
+			return reflect.Value{}, fmt.Errorf("field %q: %s has no field %q", path, val.Type(), name)
+		}
+
+		if !val.IsValid() {
+			return reflect.Value{}, fmt.Errorf("field %q: no field %q", path, name)
+		}
+	}
+	return val, nil
+}
+
+func isNil(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return val.IsNil()
+	default:
+		return false
+	}
+}
+
+type recorder struct {
+	mux       sync.Mutex
+	persisted []event.Event
+	published []event.Event
+}
+
+func (r *recorder) events() (persisted, published []event.Event) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.persisted, r.published
+}
+
+type recordingStore struct {
+	event.Store
+	rec *recorder
+}
+
+func (s *recordingStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Store.Insert(ctx, events...); err != nil {
+		return err
+	}
+
+	s.rec.mux.Lock()
+	defer s.rec.mux.Unlock()
+	s.rec.persisted = append(s.rec.persisted, events...)
+
+	return nil
+}
+
+type recordingBus struct {
+	event.Bus
+	rec *recorder
+}
+
+func (b *recordingBus) Publish(ctx context.Context, events ...event.Event) error {
+	if err := b.Bus.Publish(ctx, events...); err != nil {
+		return err
+	}
+
+	b.rec.mux.Lock()
+	defer b.rec.mux.Unlock()
+	b.rec.published = append(b.rec.published, events...)
+
+	return nil
+}
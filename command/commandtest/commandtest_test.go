@@ -0,0 +1,145 @@
+package commandtest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/commandtest"
+	"github.com/modernice/goes/command/handler"
+	"github.com/modernice/goes/event"
+)
+
+var errClosed = errors.New("account is closed")
+
+type deposited struct {
+	Amount int64
+	Meta   struct{ Source string }
+}
+
+type closed struct{}
+
+type account struct {
+	*aggregate.Base
+	*handler.BaseHandler
+
+	balance int64
+	closed  bool
+}
+
+func newAccount(id uuid.UUID) *account {
+	a := &account{
+		Base:        aggregate.New("account", id),
+		BaseHandler: handler.NewBase(),
+	}
+
+	event.ApplyWith(a, func(evt event.Of[deposited]) { a.balance += evt.Data().Amount }, "deposited")
+	event.ApplyWith(a, func(event.Of[closed]) { a.closed = true }, "closed")
+
+	command.HandleWith(a, func(ctx command.Ctx[int64]) error {
+		if a.closed {
+			return errClosed
+		}
+		data := deposited{Amount: ctx.Payload()}
+		data.Meta.Source = "test"
+		aggregate.Next(a, "deposited", data)
+		return nil
+	}, "deposit")
+
+	return a
+}
+
+func TestScenario_When(t *testing.T) {
+	id := uuid.New()
+
+	commandtest.Given(
+		event.New[any]("deposited", deposited{Amount: 10}, event.Aggregate(id, "account", 1)),
+	).When(
+		command.New[any]("deposit", int64(5), command.Aggregate("account", id)),
+		commandtest.Aggregate(newAccount),
+	).Then(t,
+		commandtest.Expect("deposited",
+			commandtest.Ref(aggregate.Ref{Name: "account", ID: id}),
+			commandtest.Version(2),
+			commandtest.Field("Amount", 5),
+			commandtest.Field("Meta.Source", "test"),
+		),
+	)
+}
+
+func TestScenario_When_error(t *testing.T) {
+	id := uuid.New()
+
+	commandtest.Given(
+		event.New[any]("closed", closed{}, event.Aggregate(id, "account", 1)),
+	).When(
+		command.New[any]("deposit", int64(5), command.Aggregate("account", id)),
+		commandtest.Aggregate(newAccount),
+	).ThenErr(t, errClosed)
+}
+
+func TestScenario_When_noHandler(t *testing.T) {
+	commandtest.Given().When(command.New[any]("deposit", int64(5))).ThenErr(t, commandtest.ErrNoHandler)
+}
+
+func TestScenario_When_funcs(t *testing.T) {
+	res := commandtest.Given().When(
+		command.New[any]("notify", "hello"),
+		commandtest.Funcs(func(infra commandtest.Infra) command.Handlers {
+			return command.Handlers{
+				"notify": func(ctx command.Context) error {
+					return infra.Events.Publish(ctx, event.New[any]("notified", ctx.Payload()))
+				},
+			}
+		}),
+	)
+
+	res.ThenPersisted(t)
+	res.ThenPublished(t, commandtest.Expect("notified", commandtest.Data("hello")))
+}
+
+func TestExpectation_mismatch(t *testing.T) {
+	id := uuid.New()
+	res := commandtest.Given().When(
+		command.New[any]("deposit", int64(5), command.Aggregate("account", id)),
+		commandtest.Aggregate(newAccount),
+	)
+
+	for name, exp := range map[string]commandtest.Expectation{
+		"name":    commandtest.Expect("withdrawn"),
+		"version": commandtest.Expect("deposited", commandtest.Version(2)),
+		"field":   commandtest.Expect("deposited", commandtest.Field("Amount", 6)),
+		"missing": commandtest.Expect("deposited", commandtest.Field("Total", 5)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := &recordingTB{TB: t}
+			func() {
+				defer func() { recover() }()
+				res.Then(rec, exp)
+			}()
+			if !rec.failed {
+				t.Fatalf("Then() should fail")
+			}
+		})
+	}
+}
+
+// recordingTB records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Fatal(...any) {
+	tb.failed = true
+	panic("fatal")
+}
+
+func (tb *recordingTB) Fatalf(string, ...any) {
+	tb.failed = true
+	panic("fatal")
+}
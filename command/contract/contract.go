@@ -0,0 +1,191 @@
+// Package contract verifies the contract between the command payloads that are
+// registered in a codec.Registry and the command handlers of a service. It can
+// be used in tests or at startup to catch drift between the registries of
+// services, e.g. a command that is registered but not handled, or a payload
+// type that does not survive encoding.
+//
+//	reg := codec.New()
+//	auth.RegisterCommands(reg)
+//
+//	handled := contract.Names(actorHandlers, roleHandlers)
+//	if err := contract.Verify(reg, handled, contract.Ignore("billing.charge")); err != nil {
+//		log.Fatal(err)
+//	}
+package contract
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/goes/codec"
+	"golang.org/x/exp/slices"
+)
+
+// Option is an option for Verify.
+type Option func(*config)
+
+type config struct {
+	ignored map[string]bool
+	samples map[string]any
+}
+
+// Ignore returns an Option that excludes the given command names from the
+// verification. Use Ignore for payloads that are registered in a shared
+// registry but handled by other services.
+func Ignore(names ...string) Option {
+	return func(cfg *config) {
+		for _, name := range names {
+			cfg.ignored[name] = true
+		}
+	}
+}
+
+// Sample returns an Option that provides a sample payload for the given
+// command, which is used to verify the encoding round-trip of the payload.
+// By default, the zero value of the registered payload type is used, which
+// does not catch fields that are lost during encoding.
+func Sample(name string, payload any) Option {
+	return func(cfg *config) {
+		cfg.samples[name] = payload
+	}
+}
+
+// Names returns the command names of the given handler sets. command.Handlers,
+// and aggregates that embed *handler.BaseHandler, are handler sets.
+func Names(sets ...interface{ CommandNames() []string }) []string {
+	var out []string
+	for _, set := range sets {
+		for _, name := range set.CommandNames() {
+			if !slices.Contains(out, name) {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
+
+// Error is returned by Verify if the contract is violated.
+type Error struct {
+	// Unhandled are the registered commands that have no handler.
+	Unhandled []string
+
+	// Unregistered are the handled commands whose payloads are not registered.
+	Unregistered []string
+
+	// RoundTrip are the commands whose payloads did not survive encoding and
+	// decoding, mapped to the error.
+	RoundTrip map[string]error
+}
+
+// Error returns a description of all contract violations.
+func (err *Error) Error() string {
+	var lines []string
+	for _, name := range err.Unhandled {
+		lines = append(lines, fmt.Sprintf("%q command is registered but has no handler", name))
+	}
+	for _, name := range err.Unregistered {
+		lines = append(lines, fmt.Sprintf("%q command is handled but its payload is not registered", name))
+	}
+
+	names := make([]string, 0, len(err.RoundTrip))
+	for name := range err.RoundTrip {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%q command payload: %v", name, err.RoundTrip[name]))
+	}
+
+	return fmt.Sprintf("command contract violated:\n\t%s", strings.Join(lines, "\n\t"))
+}
+
+// Verify verifies that every command that is registered in the registry has a
+// handler in the given list of handled commands, that every handled command
+// is registered, and that the payload of every registered command round-trips
+// through the registry. If the contract is violated, Verify returns an *Error.
+func Verify(reg *codec.Registry, handled []string, opts ...Option) error {
+	cfg := config{
+		ignored: make(map[string]bool),
+		samples: make(map[string]any),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	factories := reg.Map()
+
+	registered := make([]string, 0, len(factories))
+	for name := range factories {
+		if !cfg.ignored[name] {
+			registered = append(registered, name)
+		}
+	}
+	sort.Strings(registered)
+
+	var verr Error
+	for _, name := range registered {
+		if !slices.Contains(handled, name) {
+			verr.Unhandled = append(verr.Unhandled, name)
+		}
+
+		if err := roundTrip(reg, name, cfg.samples); err != nil {
+			if verr.RoundTrip == nil {
+				verr.RoundTrip = make(map[string]error)
+			}
+			verr.RoundTrip[name] = err
+		}
+	}
+
+	for _, name := range handled {
+		if _, ok := factories[name]; !ok && !cfg.ignored[name] && !slices.Contains(verr.Unregistered, name) {
+			verr.Unregistered = append(verr.Unregistered, name)
+		}
+	}
+	sort.Strings(verr.Unregistered)
+
+	if len(verr.Unhandled) > 0 || len(verr.Unregistered) > 0 || len(verr.RoundTrip) > 0 {
+		return &verr
+	}
+
+	return nil
+}
+
+var exportAll = cmp.Exporter(func(reflect.Type) bool { return true })
+
+func roundTrip(reg *codec.Registry, name string, samples map[string]any) error {
+	sample, ok := samples[name]
+	if !ok {
+		ptr, err := reg.New(name)
+		if err != nil {
+			return err
+		}
+		v := reflect.ValueOf(ptr)
+		for v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		sample = v.Interface()
+	}
+
+	b, err := reg.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	decoded, err := reg.Unmarshal(b, name)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	if reflect.TypeOf(decoded) != reflect.TypeOf(sample) {
+		return fmt.Errorf("decoded payload has type %T; want %T", decoded, sample)
+	}
+
+	if diff := cmp.Diff(sample, decoded, exportAll); diff != "" {
+		return fmt.Errorf("decoded payload differs from the encoded payload (-want +got):\n%s", diff)
+	}
+
+	return nil
+}
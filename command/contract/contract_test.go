@@ -0,0 +1,65 @@
+package contract_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/contract"
+	"golang.org/x/exp/slices"
+)
+
+type fooPayload struct {
+	A string
+	B time.Time
+}
+
+type lossyPayload struct {
+	A string
+	b string
+}
+
+func TestVerify(t *testing.T) {
+	reg := codec.New()
+	codec.Register[fooPayload](reg, "foo")
+	codec.Register[string](reg, "bar")
+
+	handlers := command.Handlers{}
+	handlers.RegisterCommandHandler("foo", func(command.Context) error { return nil })
+	handlers.RegisterCommandHandler("bar", func(command.Context) error { return nil })
+
+	if err := contract.Verify(reg, contract.Names(handlers), contract.Sample("foo", fooPayload{A: "foo", B: time.Now().Round(0)})); err != nil {
+		t.Fatalf("Verify() failed with %q", err)
+	}
+}
+
+func TestVerify_violations(t *testing.T) {
+	reg := codec.New()
+	codec.Register[fooPayload](reg, "foo")
+	codec.Register[lossyPayload](reg, "lossy")
+	codec.Register[string](reg, "unhandled")
+	codec.Register[string](reg, "ignored")
+
+	handled := []string{"foo", "lossy", "unregistered"}
+
+	err := contract.Verify(reg, handled, contract.Ignore("ignored"), contract.Sample("lossy", lossyPayload{A: "a", b: "b"}))
+
+	var verr *contract.Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Verify() should fail with %T; got %T", verr, err)
+	}
+
+	if !slices.Equal(verr.Unhandled, []string{"unhandled"}) {
+		t.Errorf("Unhandled should be %v; got %v", []string{"unhandled"}, verr.Unhandled)
+	}
+
+	if !slices.Equal(verr.Unregistered, []string{"unregistered"}) {
+		t.Errorf("Unregistered should be %v; got %v", []string{"unregistered"}, verr.Unregistered)
+	}
+
+	if len(verr.RoundTrip) != 1 || verr.RoundTrip["lossy"] == nil {
+		t.Errorf("RoundTrip should contain an error for %q; got %v", "lossy", verr.RoundTrip)
+	}
+}
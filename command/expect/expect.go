@@ -0,0 +1,180 @@
+// Package expect provides a helper that dispatches a command and waits for the
+// events that are raised by the command, which allows HTTP handlers to respond
+// with the updated state of an aggregate (optimistic UI).
+package expect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+	"github.com/modernice/goes/event"
+)
+
+// DefaultTimeout is the default time Events waits for the expected events.
+const DefaultTimeout = 5 * time.Second
+
+var (
+	// ErrTimeout is returned by Events if the expected events were not
+	// received before the timeout.
+	ErrTimeout = errors.New("timed out waiting for events")
+
+	// ErrNoAggregate is returned by Events if the dispatched command does not
+	// act on an aggregate.
+	ErrNoAggregate = errors.New("command has no aggregate")
+)
+
+// Option is an option for Events.
+type Option func(*config)
+
+type config struct {
+	timeout      time.Duration
+	dispatchOpts []command.DispatchOption
+}
+
+// Timeout returns an Option that sets the maximum time to wait for the
+// expected events. A timeout <= 0 disables the timeout. Defaults to
+// DefaultTimeout.
+func Timeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = d
+	}
+}
+
+// DispatchOptions returns an Option that sets the options for dispatching the
+// command. Defaults to dispatch.Sync().
+func DispatchOptions(opts ...command.DispatchOption) Option {
+	return func(cfg *config) {
+		cfg.dispatchOpts = opts
+	}
+}
+
+// Events dispatches the command and waits until one event of each of the
+// provided event names has been published over the event bus for the
+// aggregate of the command. The events are returned in the order in which
+// they were received.
+//
+// Events have no correlation id, so the resulting events are matched by the
+// aggregate the command acts on. If other commands concurrently raise events
+// with the same names for the same aggregate, their events may be returned
+// instead.
+//
+//	cmd := command.New("add-task", "foo", command.Aggregate("todo.list", listID))
+//	events, err := expect.Events(ctx, commands, bus, cmd.Any(), []string{"todo.list.task_added"})
+//
+// Events subscribes to the events before dispatching the command, so events
+// that are published while the command is being handled are not missed. If
+// the dispatch fails, the dispatch error is returned. Events does not return
+// before the dispatch has returned, so the result of a synchronous dispatch is
+// always reported. If the expected events or the dispatch result are not
+// received in time, Events returns the events received so far and an
+// error that wraps ErrTimeout.
+func Events(
+	ctx context.Context,
+	commands command.Dispatcher,
+	bus event.Bus,
+	cmd command.Command,
+	names []string,
+	opts ...Option,
+) ([]event.Event, error) {
+	cfg := config{
+		timeout:      DefaultTimeout,
+		dispatchOpts: []command.DispatchOption{dispatch.Sync()},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ref := cmd.Aggregate()
+	if ref.ID == uuid.Nil || ref.Name == "" {
+		return nil, fmt.Errorf("%q command: %w", cmd.Name(), ErrNoAggregate)
+	}
+
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	subCtx, cancelSub := context.WithCancel(ctx)
+	defer cancelSub()
+
+	str, errs, err := bus.Subscribe(subCtx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %v events: %w", names, err)
+	}
+
+	dispatchErr := make(chan error, 1)
+	go func() { dispatchErr <- commands.Dispatch(ctx, cmd, cfg.dispatchOpts...) }()
+
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	var out []event.Event
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return out, fmt.Errorf("%q command: %w", cmd.Name(), ErrTimeout)
+			}
+			return out, ctx.Err()
+		case err := <-dispatchErr:
+			if err != nil {
+				return out, fmt.Errorf("dispatch %q command: %w", cmd.Name(), err)
+			}
+			dispatchErr = nil
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			return out, fmt.Errorf("event subscription: %w", err)
+		case evt, ok := <-str:
+			if !ok {
+				return out, fmt.Errorf("event subscription closed before all events were received")
+			}
+
+			if !pending[evt.Name()] {
+				break
+			}
+
+			if id, name, _ := evt.Aggregate(); id != ref.ID || name != ref.Name {
+				break
+			}
+
+			delete(pending, evt.Name())
+			out = append(out, evt)
+		}
+	}
+
+	if dispatchErr == nil {
+		return out, nil
+	}
+
+	// The events may arrive before the dispatch returns, e.g. before the
+	// handler of a synchronous dispatch reports its result. Canceling the
+	// dispatch at this point would turn a successful command into a failure.
+	cancelSub()
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return out, fmt.Errorf("%q command: %w", cmd.Name(), ErrTimeout)
+		}
+		return out, ctx.Err()
+	case err := <-dispatchErr:
+		if err != nil {
+			return out, fmt.Errorf("dispatch %q command: %w", cmd.Name(), err)
+		}
+		return out, nil
+	}
+}
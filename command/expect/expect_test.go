@@ -0,0 +1,122 @@
+package expect_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/expect"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	id := uuid.New()
+
+	commands := dispatcherFunc(func(ctx context.Context, cmd command.Command) error {
+		return bus.Publish(ctx,
+			event.New("foo", 1, event.Aggregate(uuid.New(), "foo", 1)).Any(),
+			event.New("foo", 2, event.Aggregate(id, "foo", 1)).Any(),
+			event.New("baz", 3, event.Aggregate(id, "foo", 2)).Any(),
+			event.New("bar", 4, event.Aggregate(id, "foo", 3)).Any(),
+		)
+	})
+
+	cmd := command.New("foo-cmd", 0, command.Aggregate("foo", id))
+
+	events, err := expect.Events(ctx, commands, bus, cmd.Any(), []string{"foo", "bar"})
+	if err != nil {
+		t.Fatalf("Events() failed with %q", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Events() should return %d events; got %d", 2, len(events))
+	}
+
+	for _, evt := range events {
+		if evtID, _, _ := evt.Aggregate(); evtID != id {
+			t.Fatalf("Events() should only return events of aggregate %s; got event of aggregate %s", id, evtID)
+		}
+	}
+}
+
+func TestEvents_timeout(t *testing.T) {
+	bus := eventbus.New()
+	id := uuid.New()
+
+	commands := dispatcherFunc(func(ctx context.Context, cmd command.Command) error {
+		return bus.Publish(ctx, event.New("foo", 1, event.Aggregate(id, "foo", 1)).Any())
+	})
+
+	cmd := command.New("foo-cmd", 0, command.Aggregate("foo", id))
+
+	events, err := expect.Events(context.Background(), commands, bus, cmd.Any(), []string{"foo", "bar"}, expect.Timeout(50*time.Millisecond))
+	if !errors.Is(err, expect.ErrTimeout) {
+		t.Fatalf("Events() should fail with %q; got %q", expect.ErrTimeout, err)
+	}
+
+	if len(events) != 1 || events[0].Name() != "foo" {
+		t.Fatalf("Events() should return the received %q event; got %v", "foo", events)
+	}
+}
+
+func TestEvents_dispatchError(t *testing.T) {
+	bus := eventbus.New()
+	mockErr := errors.New("mock error")
+
+	commands := dispatcherFunc(func(context.Context, command.Command) error {
+		return mockErr
+	})
+
+	cmd := command.New("foo-cmd", 0, command.Aggregate("foo", uuid.New()))
+
+	if _, err := expect.Events(context.Background(), commands, bus, cmd.Any(), []string{"foo"}); !errors.Is(err, mockErr) {
+		t.Fatalf("Events() should fail with %q; got %q", mockErr, err)
+	}
+}
+
+func TestEvents_waitsForDispatch(t *testing.T) {
+	bus := eventbus.New()
+	id := uuid.New()
+	mockErr := errors.New("mock error")
+
+	commands := dispatcherFunc(func(ctx context.Context, cmd command.Command) error {
+		if err := bus.Publish(ctx, event.New("foo", 1, event.Aggregate(id, "foo", 1)).Any()); err != nil {
+			return err
+		}
+
+		// The handler reports its result after the event was published.
+		time.Sleep(20 * time.Millisecond)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return mockErr
+	})
+
+	cmd := command.New("foo-cmd", 0, command.Aggregate("foo", id))
+
+	if _, err := expect.Events(context.Background(), commands, bus, cmd.Any(), []string{"foo"}); !errors.Is(err, mockErr) {
+		t.Fatalf("Events() should fail with %q; got %q", mockErr, err)
+	}
+}
+
+func TestEvents_noAggregate(t *testing.T) {
+	cmd := command.New("foo-cmd", 0)
+
+	if _, err := expect.Events(context.Background(), dispatcherFunc(nil), eventbus.New(), cmd.Any(), []string{"foo"}); !errors.Is(err, expect.ErrNoAggregate) {
+		t.Fatalf("Events() should fail with %q; got %q", expect.ErrNoAggregate, err)
+	}
+}
+
+type dispatcherFunc func(context.Context, command.Command) error
+
+func (fn dispatcherFunc) Dispatch(ctx context.Context, cmd command.Command, _ ...command.DispatchOption) error {
+	return fn(ctx, cmd)
+}
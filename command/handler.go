@@ -6,17 +6,41 @@ import (
 	"time"
 
 	"github.com/modernice/goes/command/finish"
+	"github.com/modernice/goes/helper/recovery"
 	"github.com/modernice/goes/internal/xtime"
 )
 
 // Handler wraps a Bus to provide a convenient way to subscribe to and handle commands.
 type Handler[P any] struct {
-	bus Bus
+	bus           Bus
+	recoverPanics bool
+}
+
+// HandlerOption is an option for a *Handler.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	recoverPanics bool
+}
+
+// RecoverPanics returns a HandlerOption that recovers from panics that occur
+// within command handler functions. A recovered panic is converted to a
+// *recovery.PanicError that references the command and its aggregate, and is
+// reported like any other error returned by the handler: it is sent into the
+// error channel of the handler and used as the execution result of the command.
+func RecoverPanics() HandlerOption {
+	return func(cfg *handlerConfig) {
+		cfg.recoverPanics = true
+	}
 }
 
 // NewHandler wraps the provided Bus in a *Handler.
-func NewHandler[P any](bus Bus) *Handler[P] {
-	return &Handler[P]{bus}
+func NewHandler[P any](bus Bus, opts ...HandlerOption) *Handler[P] {
+	var cfg handlerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Handler[P]{bus: bus, recoverPanics: cfg.recoverPanics}
 }
 
 // Handle is a shortcut for
@@ -109,7 +133,7 @@ func (h *Handler[P]) handle(
 			}
 
 			start := xtime.Now()
-			err := handler(casted)
+			err := h.call(handler, casted, ctx)
 			runtime := time.Since(start)
 
 			cmd := ctx
@@ -132,3 +156,18 @@ func (h *Handler[P]) handle(
 		}
 	}
 }
+
+func (h *Handler[P]) call(handler func(Ctx[P]) error, casted Ctx[P], ctx Context) error {
+	if !h.recoverPanics {
+		return handler(casted)
+	}
+
+	var err error
+	if perr := recovery.Command(ctx.Name(), ctx.Aggregate(), func() {
+		err = handler(casted)
+	}); perr != nil {
+		return perr
+	}
+
+	return err
+}
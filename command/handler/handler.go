@@ -172,8 +172,10 @@ type Of[A Aggregate] struct {
 // The provided newFunc is used to instantiate the aggregates and to initially
 // extract from the aggregate which commands it handles.
 //
-// Under the hood, a generic [*command.Handler] is used.
-func New[A Aggregate](newFunc func(uuid.UUID) A, repo aggregate.Repository, bus command.Bus) *Of[A] {
+// Under the hood, a generic [*command.Handler] is used. The provided
+// HandlerOptions are passed to it, so command.RecoverPanics() can be used to
+// recover from panics within the command handlers of the aggregate.
+func New[A Aggregate](newFunc func(uuid.UUID) A, repo aggregate.Repository, bus command.Bus, opts ...command.HandlerOption) *Of[A] {
 	if newFunc == nil {
 		panic("[goes/command.NewHandlerOf] newFunc is nil")
 	}
@@ -187,7 +189,7 @@ func New[A Aggregate](newFunc func(uuid.UUID) A, repo aggregate.Repository, bus
 	}
 
 	return &Of[A]{
-		handler: command.NewHandler[any](bus),
+		handler: command.NewHandler[any](bus, opts...),
 		repo:    repo,
 		newFunc: newFunc,
 	}
@@ -8,12 +8,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/command"
 	"github.com/modernice/goes/command/cmdbus"
 	"github.com/modernice/goes/command/cmdbus/dispatch"
 	"github.com/modernice/goes/command/cmdbus/report"
 	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/helper/recovery"
 )
 
 func TestHandler_Handle(t *testing.T) {
@@ -97,6 +99,49 @@ func TestHandler_Handle_error(t *testing.T) {
 	}
 }
 
+func TestHandler_Handle_RecoverPanics(t *testing.T) {
+	enc := newEncoder()
+	ebus := eventbus.New()
+	bus := cmdbus.New[int](enc, ebus)
+	h := command.NewHandler[any](bus, command.RecoverPanics())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errs, err := h.Handle(ctx, "foo-cmd", func(ctx command.Context) error {
+		panic("mock panic")
+	})
+	if err != nil {
+		t.Fatalf("subscribe Command handler: %v", err)
+	}
+
+	aggregateID := uuid.New()
+	cmd := command.New("foo-cmd", mockPayload{}, command.Aggregate("foo", aggregateID))
+	go bus.Dispatch(ctx, cmd.Any())
+
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timed out")
+	case err, ok := <-errs:
+		if !ok {
+			t.Fatal("error channel shouldn't be closed")
+		}
+
+		var perr *recovery.PanicError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected %T error; got %T (%v)", perr, err, err)
+		}
+
+		if perr.Command != "foo-cmd" {
+			t.Fatalf("PanicError.Command should be %q; is %q", "foo-cmd", perr.Command)
+		}
+
+		if perr.Aggregate.Name != "foo" || perr.Aggregate.ID != aggregateID {
+			t.Fatalf("PanicError.Aggregate should be %v; is %v", cmd.Aggregate(), perr.Aggregate)
+		}
+	}
+}
+
 func TestHandler_Handle_finish(t *testing.T) {
 	enc := newEncoder()
 	ebus := eventbus.New()
@@ -2,10 +2,13 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/event"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 // ActorAggregate is the name of the Actor aggregate.
@@ -25,6 +28,10 @@ var (
 	// ErrMissingActorID is returned when trying to grant or revoke permissions
 	// to or from a non-UUID-Actor before the actor has been identified.
 	ErrMissingActorID = errors.New("missing actor id")
+
+	// ErrActorMerged is returned when trying to modify an actor that has been
+	// merged into another actor.
+	ErrActorMerged = errors.New("actor has been merged")
 )
 
 // An Actor represents any kind of user in the system. Actors are granted
@@ -57,10 +64,13 @@ type Actor struct {
 
 	kind        string
 	id          any
+	aliases     []any
+	mergedInto  uuid.UUID
 	isUUIDActor bool
 	validateID  func(any) error
 	parseID     func(string) (any, error)
 	formatID    func(any) string
+	denied      Actions
 	Actions
 }
 
@@ -150,12 +160,18 @@ func NewActor[ID comparable](id uuid.UUID, cfg ActorConfig[ID]) *Actor {
 		},
 		parseID:  parseID,
 		formatID: formatID,
+		denied:   make(Actions),
 		Actions:  make(Actions),
 	}
 
 	event.ApplyWith(a, a.identify, ActorIdentified)
+	event.ApplyWith(a, a.aliased, ActorAliased)
+	event.ApplyWith(a, a.unaliased, ActorUnaliased)
+	event.ApplyWith(a, a.merged, ActorMerged)
 	event.ApplyWith(a, a.Actions.granted, PermissionGranted)
 	event.ApplyWith(a, a.Actions.revoked, PermissionRevoked)
+	event.ApplyWith(a, a.denied.denied, PermissionDenied)
+	event.ApplyWith(a, a.denied.undenied, PermissionUndenied)
 
 	return a
 }
@@ -206,18 +222,265 @@ func (a *Actor) identify(evt event.Of[ActorIdentifiedData]) {
 	a.id = id
 }
 
+// Aliases returns the additional actor ids that are linked to the actor.
+func (a *Actor) Aliases() []any {
+	return append([]any(nil), a.aliases...)
+}
+
+// HasAlias returns whether the given actor id is linked to the actor as an alias.
+func (a *Actor) HasAlias(id any) bool {
+	for _, alias := range a.aliases {
+		if alias == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Alias links an additional actor id to the actor. Aliases allow multiple
+// external ids (e.g. the ids of different identity providers) to refer to the
+// same actor. The alias must have the same type as the actor id. Aliases are
+// provided by the LookupTable, so that the actor can be looked up by any of its
+// aliases.
+//
+//	actor := auth.NewStringActor(uuid.New())
+//	actor.Identify("github:bob")
+//	actor.Alias("google:bob@example.com")
+func (a *Actor) Alias(id any) error {
+	if err := a.checkID(); err != nil {
+		return err
+	}
+
+	if err := a.validateID(id); err != nil {
+		return err
+	}
+
+	if a.id == id || a.HasAlias(id) {
+		return nil
+	}
+
+	aggregate.Next(a, ActorAliased, ActorAliasedData(a.formatID(id)))
+
+	return nil
+}
+
+func (a *Actor) aliased(evt event.Of[ActorAliasedData]) {
+	if id, err := a.parseID(string(evt.Data())); err == nil {
+		a.aliases = append(a.aliases, id)
+	}
+}
+
+// Unalias removes the given alias from the actor.
+func (a *Actor) Unalias(id any) error {
+	if err := a.checkID(); err != nil {
+		return err
+	}
+
+	if err := a.validateID(id); err != nil {
+		return err
+	}
+
+	if !a.HasAlias(id) {
+		return nil
+	}
+
+	aggregate.Next(a, ActorUnaliased, ActorUnaliasedData(a.formatID(id)))
+
+	return nil
+}
+
+func (a *Actor) unaliased(evt event.Of[ActorUnaliasedData]) {
+	id, err := a.parseID(string(evt.Data()))
+	if err != nil {
+		return
+	}
+
+	for i, alias := range a.aliases {
+		if alias == id {
+			a.aliases = append(a.aliases[:i], a.aliases[i+1:]...)
+			return
+		}
+	}
+}
+
+// MergedInto returns the aggregate id of the actor that this actor has been
+// merged into, or false if the actor has not been merged.
+func (a *Actor) MergedInto() (uuid.UUID, bool) {
+	return a.mergedInto, a.mergedInto != uuid.Nil
+}
+
+// MergeInto merges the actor into the given target actor. The permissions and
+// deny rules of the actor are moved to the target. The actor id and the
+// aliases of the actor are linked to the target as aliases, if they have the
+// same type as the target's actor id. After the merge, the actor can no longer
+// be granted permissions.
+//
+// MergeInto only changes the two actors; role memberships are not affected.
+// Use MergeActors to merge actors including their role memberships.
+func (a *Actor) MergeInto(target *Actor) error {
+	if err := a.transferTo(target); err != nil {
+		return err
+	}
+	a.retire(target.AggregateID())
+	return nil
+}
+
+// transferTo grants the permissions and links the ids of the actor to the target.
+func (a *Actor) transferTo(target *Actor) error {
+	if a.AggregateID() == target.AggregateID() {
+		return fmt.Errorf("cannot merge actor %s into itself", a.AggregateID())
+	}
+
+	if a.mergedInto != uuid.Nil {
+		return ErrActorMerged
+	}
+
+	if err := target.checkID(); err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+
+	for ref, actions := range a.Actions {
+		if err := target.Grant(ref, sortedActions(actions)...); err != nil {
+			return fmt.Errorf("grant %v permissions to target: %w", ref, err)
+		}
+	}
+
+	for ref, actions := range a.denied {
+		if err := target.Deny(ref, sortedActions(actions)...); err != nil {
+			return fmt.Errorf("deny %v permissions to target: %w", ref, err)
+		}
+	}
+
+	ids := a.aliases
+	if a.id != nil && !a.isUUIDActor {
+		ids = append([]any{a.id}, ids...)
+	}
+
+	for _, id := range ids {
+		if target.validateID(id) != nil {
+			continue
+		}
+		if err := target.Alias(id); err != nil {
+			return fmt.Errorf("alias %v to target: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// retire revokes all permissions from the actor and marks it as merged.
+func (a *Actor) retire(into uuid.UUID) {
+	for ref, actions := range a.Actions {
+		if len(actions) == 0 {
+			continue
+		}
+		aggregate.Next(a, PermissionRevoked, PermissionRevokedData{
+			Aggregate: ref,
+			Actions:   sortedActions(actions),
+		})
+	}
+
+	for ref, actions := range a.denied {
+		if len(actions) == 0 {
+			continue
+		}
+		aggregate.Next(a, PermissionUndenied, PermissionUndeniedData{
+			Aggregate: ref,
+			Actions:   sortedActions(actions),
+		})
+	}
+
+	aggregate.Next(a, ActorMerged, ActorMergedData{Into: into})
+}
+
+func (a *Actor) merged(evt event.Of[ActorMergedData]) {
+	a.mergedInto = evt.Data().Into
+}
+
+func sortedActions(actions map[string]int) []string {
+	out := maps.Keys(actions)
+	slices.Sort(out)
+	return out
+}
+
 // Allows returns whether the actor is allowed to perform the given action.
-// Allows does not account for the roles the actor is a member of (use the
-// Permissions read-model instead).
+// An action that is denied to the actor is never allowed, even if it was
+// granted (deny wins). Allows does not account for the roles the actor is a
+// member of (use the Permissions read-model instead).
 func (a *Actor) Allows(action string, ref aggregate.Ref) bool {
-	return a.allows(action, ref)
+	return !a.denied.allows(action, ref) && a.allows(action, ref)
 }
 
 // Disallows returns whether the actor is allowed to perform the given action.
 // Disallows does not account for the roles the actor is a member of (use the
 // Permissions read-model instead).
 func (a *Actor) Disallows(action string, ref aggregate.Ref) bool {
-	return !a.allows(action, ref)
+	return !a.Allows(action, ref)
+}
+
+// Denies returns whether the given action is explicitly denied to the actor.
+func (a *Actor) Denies(action string, ref aggregate.Ref) bool {
+	return a.denied.allows(action, ref)
+}
+
+// Denials returns the actions that are explicitly denied to the actor.
+func (a *Actor) Denials() Actions {
+	return a.denied
+}
+
+// Deny explicitly denies the actor to perform the given actions on the given
+// aggregate. Denied actions override any permissions that are granted to the
+// actor, either directly or through a role (deny wins). Like Grant, Deny
+// supports wildcards in the aggregate reference and actions.
+//
+// Example – Deny all actions on "payroll" aggregates:
+//	actor.Deny(aggregate.Ref{Name: "payroll", ID: uuid.Nil}, "*")
+func (a *Actor) Deny(ref aggregate.Ref, actions ...string) error {
+	if err := a.checkID(); err != nil {
+		return err
+	}
+
+	if err := validateRef(ref); err != nil {
+		return err
+	}
+
+	actions = a.denied.missingActions(ref, actions)
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	aggregate.Next(a, PermissionDenied, PermissionDeniedData{
+		Aggregate: ref,
+		Actions:   actions,
+	})
+
+	return nil
+}
+
+// Undeny removes the deny rules for the given actions on the given aggregate
+// from the actor.
+func (a *Actor) Undeny(ref aggregate.Ref, actions ...string) error {
+	if err := a.checkID(); err != nil {
+		return err
+	}
+
+	if err := validateRef(ref); err != nil {
+		return err
+	}
+
+	actions = a.denied.grantedActions(ref, actions)
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	aggregate.Next(a, PermissionUndenied, PermissionUndeniedData{
+		Aggregate: ref,
+		Actions:   actions,
+	})
+
+	return nil
 }
 
 // Grant grants the actor the permission to perform the given actions on the
@@ -269,6 +532,9 @@ func (a *Actor) checkID() error {
 	if a.id == nil {
 		return ErrMissingActorID
 	}
+	if a.mergedInto != uuid.Nil {
+		return ErrActorMerged
+	}
 	return nil
 }
 
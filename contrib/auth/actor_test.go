@@ -165,3 +165,108 @@ func TestActor_Identify_string(t *testing.T) {
 
 	test.Change(t, a, auth.ActorIdentified, test.EventData(auth.ActorIdentifiedData(id)))
 }
+
+func TestActor_Alias(t *testing.T) {
+	a := auth.NewStringActor(uuid.New())
+	a.Identify("foo")
+
+	if err := a.Alias(42); !errors.Is(err, auth.ErrIDType) {
+		t.Fatalf("Alias() should fail with %q if provided an id of the wrong type; got %q", auth.ErrIDType, err)
+	}
+
+	if err := a.Alias("bar"); err != nil {
+		t.Fatalf("Alias() failed with %q", err)
+	}
+
+	if !a.HasAlias("bar") {
+		t.Fatalf("HasAlias() should return true for %q", "bar")
+	}
+
+	test.Change(t, a, auth.ActorAliased, test.EventData(auth.ActorAliasedData("bar")), test.Exactly(1))
+
+	a.Alias("bar")
+	a.Alias("foo")
+
+	test.Change(t, a, auth.ActorAliased, test.Exactly(1))
+
+	if err := a.Unalias("bar"); err != nil {
+		t.Fatalf("Unalias() failed with %q", err)
+	}
+
+	if a.HasAlias("bar") {
+		t.Fatalf("HasAlias() should return false for %q after Unalias()", "bar")
+	}
+
+	test.Change(t, a, auth.ActorUnaliased, test.EventData(auth.ActorUnaliasedData("bar")))
+}
+
+func TestActor_MergeInto(t *testing.T) {
+	ref := aggregate.Ref{Name: "foo", ID: uuid.New()}
+
+	source := auth.NewStringActor(uuid.New())
+	source.Identify("foo")
+	source.Alias("bar")
+	source.Grant(ref, "view", "update")
+
+	target := auth.NewStringActor(uuid.New())
+	target.Identify("baz")
+
+	if err := source.MergeInto(target); err != nil {
+		t.Fatalf("MergeInto() failed with %q", err)
+	}
+
+	if !target.Allows("view", ref) || !target.Allows("update", ref) {
+		t.Fatalf("target should be granted the permissions of the source")
+	}
+
+	if source.Allows("view", ref) || source.Allows("update", ref) {
+		t.Fatalf("permissions should be revoked from the source")
+	}
+
+	if !target.HasAlias("foo") || !target.HasAlias("bar") {
+		t.Fatalf("target should have the actor id and aliases of the source as aliases; got %v", target.Aliases())
+	}
+
+	if into, ok := source.MergedInto(); !ok || into != target.AggregateID() {
+		t.Fatalf("MergedInto() should return %s; got %s", target.AggregateID(), into)
+	}
+
+	test.Change(t, source, auth.ActorMerged, test.EventData(auth.ActorMergedData{Into: target.AggregateID()}))
+
+	if err := source.Grant(ref, "view"); !errors.Is(err, auth.ErrActorMerged) {
+		t.Fatalf("Grant() should fail with %q after the actor was merged; got %q", auth.ErrActorMerged, err)
+	}
+
+	if err := source.MergeInto(target); !errors.Is(err, auth.ErrActorMerged) {
+		t.Fatalf("MergeInto() should fail with %q if the actor was already merged; got %q", auth.ErrActorMerged, err)
+	}
+}
+
+func TestActor_Deny(t *testing.T) {
+	a := auth.NewUUIDActor(uuid.New())
+	ref := aggregate.Ref{Name: "foo", ID: uuid.New()}
+
+	a.Grant(ref, "view", "update")
+
+	if err := a.Deny(aggregate.Ref{Name: "foo", ID: uuid.Nil}, "update"); err != nil {
+		t.Fatalf("Deny() failed with %q", err)
+	}
+
+	if !a.Allows("view", ref) {
+		t.Fatalf("actor should allow an action that is not denied")
+	}
+
+	if a.Allows("update", ref) || !a.Denies("update", ref) {
+		t.Fatalf("deny rule should override the granted permission")
+	}
+
+	a.Deny(aggregate.Ref{Name: "foo", ID: uuid.Nil}, "update")
+	test.Change(t, a, auth.PermissionDenied, test.Exactly(1))
+
+	a.Undeny(aggregate.Ref{Name: "foo", ID: uuid.Nil}, "update")
+	test.Change(t, a, auth.PermissionUndenied, test.Exactly(1))
+
+	if !a.Allows("update", ref) {
+		t.Fatalf("actor should allow the action after the deny rule was removed")
+	}
+}
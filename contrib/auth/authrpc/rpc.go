@@ -34,6 +34,8 @@ type Server struct {
 	handlesCommands bool
 	actors          auth.ActorRepositories
 	roles           auth.RoleRepository
+
+	onDecision []func(auth.Decision)
 }
 
 // ServerOption is an option for the *Server.
@@ -66,6 +68,15 @@ func HandleCommands(actors auth.ActorRepositories, roles auth.RoleRepository) Se
 	}
 }
 
+// OnDecision returns a ServerOption that adds a hook that is called for every
+// authorization decision that is made by the Allows() method of the server.
+// Hooks are called synchronously, so they should not block.
+func OnDecision(fn func(auth.Decision)) ServerOption {
+	return func(s *Server) {
+		s.onDecision = append(s.onDecision, fn)
+	}
+}
+
 // NewServer returns a new gRPC server for the authorization module.
 func NewServer(perms auth.PermissionRepository, lookup auth.Lookup, opts ...ServerOption) *Server {
 	s := &Server{
@@ -106,7 +117,12 @@ func (s *Server) Allows(ctx context.Context, req *authpb.AllowsReq) (*authpb.All
 		}).Err()
 	}
 
-	return &authpb.AllowsResp{Allowed: perms.Allows(action, ref)}, nil
+	d := perms.Decide(action, ref)
+	for _, fn := range s.onDecision {
+		fn(d)
+	}
+
+	return &authpb.AllowsResp{Allowed: d.Allowed}, nil
 }
 
 // LookupActor implements authpb.AuthServiceServer.
@@ -3,6 +3,7 @@ package authrpc_test
 import (
 	"context"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
@@ -21,8 +22,11 @@ import (
 	"github.com/modernice/goes/internal/testutil"
 	"github.com/modernice/goes/projection"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -129,6 +133,43 @@ func TestServer_Allows(t *testing.T) {
 	}
 }
 
+func TestClient_Permissions_denied(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := auth.InMemoryPermissionRepository()
+
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(testRef, "*")
+	actor.Deny(testRef, "delete")
+
+	perms := auth.PermissionsOf(actor.AggregateID())
+	projection.Apply(perms, actor.AggregateChanges())
+
+	if err := repo.Save(ctx, perms); err != nil {
+		t.Fatalf("save permissions: %v", err)
+	}
+
+	client := authrpc.NewClient(newClientConn(t, authrpc.NewServer(repo, nil)))
+
+	got, err := client.Permissions(ctx, actor.AggregateID())
+	if err != nil {
+		t.Fatalf("Permissions() failed with %q", err)
+	}
+
+	if !got.Equal(perms.PermissionsDTO) {
+		t.Fatalf("Permissions() returned wrong permissions.\n\n%s", cmp.Diff(perms.PermissionsDTO, got))
+	}
+
+	if !got.Allows("view", testRef) {
+		t.Fatalf("%q action should be allowed", "view")
+	}
+
+	if got.Allows("delete", testRef) {
+		t.Fatalf("%q action should be denied", "delete")
+	}
+}
+
 func TestServer_LookupActor(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -281,3 +322,25 @@ func givenAnActorWithViewPermission(t *testing.T) (*auth.Actor, *auth.Permission
 
 	return actor, perms, repo
 }
+
+func newClientConn(t *testing.T, srv authpb.AuthServiceServer) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	gsrv := grpc.NewServer()
+	authpb.RegisterAuthServiceServer(gsrv, srv)
+	go gsrv.Serve(lis)
+	t.Cleanup(gsrv.Stop)
+
+	conn, err := grpc.DialContext(
+		context.Background(), "",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("grpc.DialContext() failed with %q", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
@@ -14,6 +14,9 @@ import (
 // Commands
 const (
 	IdentifyActorCmd   = "goes.contrib.auth.actor.identify"
+	AliasActorCmd      = "goes.contrib.auth.actor.alias"
+	UnaliasActorCmd    = "goes.contrib.auth.actor.unalias"
+	MergeActorsCmd     = "goes.contrib.auth.actor.merge"
 	IdentifyRoleCmd    = "goes.contrib.auth.role.identify"
 	GiveRoleToCmd      = "goes.contrib.auth.role.give"
 	RemoveRoleFromCmd  = "goes.contrib.auth.role.remove"
@@ -21,6 +24,10 @@ const (
 	RevokeFromActorCmd = "goes.contib.auth.actor.revoke"
 	GrantToRoleCmd     = "goes.contib.auth.role.grant"
 	RevokeFromRoleCmd  = "goes.contib.auth.role.revoke"
+	DenyToActorCmd     = "goes.contrib.auth.actor.deny"
+	UndenyFromActorCmd = "goes.contrib.auth.actor.undeny"
+	DenyToRoleCmd      = "goes.contrib.auth.role.deny"
+	UndenyFromRoleCmd  = "goes.contrib.auth.role.undeny"
 )
 
 // IdentifyActor returns the command to specify the id of an actor that is not a UUID-Actor.
@@ -28,6 +35,22 @@ func IdentifyActor[ID comparable](uid uuid.UUID, id ID) command.Cmd[ID] {
 	return command.New(IdentifyActorCmd, id, command.Aggregate(ActorAggregate, uid))
 }
 
+// AliasActor returns the command to link an additional actor id to an actor.
+func AliasActor[ID comparable](uid uuid.UUID, id ID) command.Cmd[ID] {
+	return command.New(AliasActorCmd, id, command.Aggregate(ActorAggregate, uid))
+}
+
+// UnaliasActor returns the command to remove an alias from an actor.
+func UnaliasActor[ID comparable](uid uuid.UUID, id ID) command.Cmd[ID] {
+	return command.New(UnaliasActorCmd, id, command.Aggregate(ActorAggregate, uid))
+}
+
+// MergeActor returns the command to merge the source actor into the target
+// actor (see MergeActors).
+func MergeActor(source, target uuid.UUID) command.Cmd[uuid.UUID] {
+	return command.New(MergeActorsCmd, target, command.Aggregate(ActorAggregate, source))
+}
+
 // IdentifyRole returns the command to specify the name of the given role.
 func IdentifyRole(id uuid.UUID, name string) command.Cmd[string] {
 	return command.New(IdentifyRoleCmd, name, command.Aggregate(RoleAggregate, id))
@@ -83,9 +106,39 @@ func RevokeFromRole(roleID uuid.UUID, ref aggregate.Ref, actions ...string) comm
 	return command.New(RevokeFromRoleCmd, revokeRolePayload{Ref: ref, Actions: actions}, command.Aggregate(RoleAggregate, roleID))
 }
 
+type denyPayload struct {
+	Ref     aggregate.Ref
+	Actions []string
+}
+
+// DenyToActor returns the command to deny the given actions to the given actor.
+func DenyToActor(actorID uuid.UUID, ref aggregate.Ref, actions ...string) command.Cmd[denyPayload] {
+	return command.New(DenyToActorCmd, denyPayload{Ref: ref, Actions: actions}, command.Aggregate(ActorAggregate, actorID))
+}
+
+// UndenyFromActor returns the command to remove the deny rules for the given
+// actions from the given actor.
+func UndenyFromActor(actorID uuid.UUID, ref aggregate.Ref, actions ...string) command.Cmd[denyPayload] {
+	return command.New(UndenyFromActorCmd, denyPayload{Ref: ref, Actions: actions}, command.Aggregate(ActorAggregate, actorID))
+}
+
+// DenyToRole returns the command to deny the given actions to the given role.
+func DenyToRole(roleID uuid.UUID, ref aggregate.Ref, actions ...string) command.Cmd[denyPayload] {
+	return command.New(DenyToRoleCmd, denyPayload{Ref: ref, Actions: actions}, command.Aggregate(RoleAggregate, roleID))
+}
+
+// UndenyFromRole returns the command to remove the deny rules for the given
+// actions from the given role.
+func UndenyFromRole(roleID uuid.UUID, ref aggregate.Ref, actions ...string) command.Cmd[denyPayload] {
+	return command.New(UndenyFromRoleCmd, denyPayload{Ref: ref, Actions: actions}, command.Aggregate(RoleAggregate, roleID))
+}
+
 // RegisterCommands registers the commands of the auth package into a registry.
 func RegisterCommands(r codec.Registerer) {
 	codec.Register[any](r, IdentifyActorCmd)
+	codec.Register[any](r, AliasActorCmd)
+	codec.Register[any](r, UnaliasActorCmd)
+	codec.Register[uuid.UUID](r, MergeActorsCmd)
 	codec.Register[string](r, IdentifyRoleCmd)
 	codec.Register[[]uuid.UUID](r, GiveRoleToCmd)
 	codec.Register[[]uuid.UUID](r, RemoveRoleFromCmd)
@@ -93,6 +146,10 @@ func RegisterCommands(r codec.Registerer) {
 	codec.Register[revokeActorPayload](r, RevokeFromActorCmd)
 	codec.Register[grantRolePayload](r, GrantToRoleCmd)
 	codec.Register[revokeRolePayload](r, RevokeFromRoleCmd)
+	codec.Register[denyPayload](r, DenyToActorCmd)
+	codec.Register[denyPayload](r, UndenyFromActorCmd)
+	codec.Register[denyPayload](r, DenyToRoleCmd)
+	codec.Register[denyPayload](r, UndenyFromRoleCmd)
 }
 
 // HandleCommands handles commands until ctx is canceled.
@@ -121,6 +178,51 @@ func HandleCommands(
 		})
 	})
 
+	aliasActorErrors := command.MustHandle(ctx, bus, AliasActorCmd, func(ctx command.Context) error {
+		id := ctx.Payload()
+
+		kind, err := actorRepos.ParseKind(id)
+		if err != nil {
+			return fmt.Errorf("parse kind of %s: %w", id, err)
+		}
+
+		repo, err := actorRepos.Repository(kind)
+		if err != nil {
+			return fmt.Errorf("get %q repository: %w", kind, err)
+		}
+
+		return repo.Use(ctx, ctx.AggregateID(), func(a *Actor) error {
+			return a.Alias(id)
+		})
+	})
+
+	unaliasActorErrors := command.MustHandle(ctx, bus, UnaliasActorCmd, func(ctx command.Context) error {
+		id := ctx.Payload()
+
+		kind, err := actorRepos.ParseKind(id)
+		if err != nil {
+			return fmt.Errorf("parse kind of %s: %w", id, err)
+		}
+
+		repo, err := actorRepos.Repository(kind)
+		if err != nil {
+			return fmt.Errorf("get %q repository: %w", kind, err)
+		}
+
+		return repo.Use(ctx, ctx.AggregateID(), func(a *Actor) error {
+			return a.Unalias(id)
+		})
+	})
+
+	mergeActorsErrors := command.MustHandle(ctx, bus, MergeActorsCmd, func(ctx command.Ctx[uuid.UUID]) error {
+		actors, err := actorRepos.Repository(UUIDActor)
+		if err != nil {
+			return fmt.Errorf("get %q repository: %w", UUIDActor, err)
+		}
+
+		return MergeActors(ctx, actors, roles, ctx.AggregateID(), ctx.Payload())
+	})
+
 	identifyRoleErrors := command.MustHandle(ctx, bus, IdentifyRoleCmd, func(ctx command.Ctx[string]) error {
 		if id, ok := lookup.Role(ctx, ctx.Payload()); ok {
 			return fmt.Errorf("role %q already exists with id %s", ctx.Payload(), id)
@@ -183,8 +285,51 @@ func HandleCommands(
 		})
 	})
 
+	denyActorErrors := command.MustHandle(ctx, bus, DenyToActorCmd, func(ctx command.Ctx[denyPayload]) error {
+		load := ctx.Payload()
+
+		actors, err := actorRepos.Repository(UUIDActor)
+		if err != nil {
+			return fmt.Errorf("get %q repository: %w", UUIDActor, err)
+		}
+
+		return actors.Use(ctx, ctx.AggregateID(), func(a *Actor) error {
+			return a.Deny(load.Ref, load.Actions...)
+		})
+	})
+
+	undenyActorErrors := command.MustHandle(ctx, bus, UndenyFromActorCmd, func(ctx command.Ctx[denyPayload]) error {
+		load := ctx.Payload()
+
+		actors, err := actorRepos.Repository(UUIDActor)
+		if err != nil {
+			return fmt.Errorf("get %q repository: %w", UUIDActor, err)
+		}
+
+		return actors.Use(ctx, ctx.AggregateID(), func(a *Actor) error {
+			return a.Undeny(load.Ref, load.Actions...)
+		})
+	})
+
+	denyRoleErrors := command.MustHandle(ctx, bus, DenyToRoleCmd, func(ctx command.Ctx[denyPayload]) error {
+		load := ctx.Payload()
+		return roles.Use(ctx, ctx.AggregateID(), func(r *Role) error {
+			return r.Deny(load.Ref, load.Actions...)
+		})
+	})
+
+	undenyRoleErrors := command.MustHandle(ctx, bus, UndenyFromRoleCmd, func(ctx command.Ctx[denyPayload]) error {
+		load := ctx.Payload()
+		return roles.Use(ctx, ctx.AggregateID(), func(r *Role) error {
+			return r.Undeny(load.Ref, load.Actions...)
+		})
+	})
+
 	return streams.FanInAll(
 		identifyActorErrors,
+		aliasActorErrors,
+		unaliasActorErrors,
+		mergeActorsErrors,
 		identifyRoleErrors,
 		giveRoleErrors,
 		removeRoleErrors,
@@ -192,5 +337,9 @@ func HandleCommands(
 		revokeActorErrors,
 		grantRoleErrors,
 		revokeRoleErrors,
+		denyActorErrors,
+		undenyActorErrors,
+		denyRoleErrors,
+		undenyRoleErrors,
 	), nil
 }
@@ -47,24 +47,38 @@ func (a Actions) allowsActionWildcard(action string, ref aggregate.Ref) bool {
 }
 
 func (a Actions) granted(evt event.Of[PermissionGrantedData]) {
-	data := evt.Data()
-	perms, ok := a[data.Aggregate]
+	a.add(evt.Data().Aggregate, evt.Data().Actions)
+}
+
+func (a Actions) revoked(evt event.Of[PermissionRevokedData]) {
+	a.remove(evt.Data().Aggregate, evt.Data().Actions)
+}
+
+func (a Actions) denied(evt event.Of[PermissionDeniedData]) {
+	a.add(evt.Data().Aggregate, evt.Data().Actions)
+}
+
+func (a Actions) undenied(evt event.Of[PermissionUndeniedData]) {
+	a.remove(evt.Data().Aggregate, evt.Data().Actions)
+}
+
+func (a Actions) add(ref aggregate.Ref, actions []string) {
+	perms, ok := a[ref]
 	if !ok {
 		perms = make(map[string]int)
-		a[data.Aggregate] = perms
+		a[ref] = perms
 	}
-	for _, action := range data.Actions {
+	for _, action := range actions {
 		perms[action]++
 	}
 }
 
-func (a Actions) revoked(evt event.Of[PermissionRevokedData]) {
-	data := evt.Data()
-	perms, ok := a[data.Aggregate]
+func (a Actions) remove(ref aggregate.Ref, actions []string) {
+	perms, ok := a[ref]
 	if !ok {
 		return
 	}
-	for _, action := range data.Actions {
+	for _, action := range actions {
 		perms[action]--
 		if (perms[action]) <= 0 {
 			delete(perms, action)
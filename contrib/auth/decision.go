@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+)
+
+// Sources of authorization decisions.
+const (
+	// DecisionSourceActor means that a permission or deny rule of the actor
+	// itself determined the decision.
+	DecisionSourceActor = "actor"
+
+	// DecisionSourceRole means that a permission or deny rule of one of the
+	// actor's roles determined the decision.
+	DecisionSourceRole = "role"
+)
+
+// Decision is an authorization decision that is made by PermissionsDTO.Decide.
+// The components that make authorization decisions accept hooks that are called
+// for every decision, which can be used to stream authorization decisions to
+// external systems like a SIEM:
+//
+//   - Requirements (see RequirementsOption OnDecision)
+//   - the HTTP middleware (see goes/contrib/auth/http/middleware.OnDecision)
+//   - the gRPC server (see goes/contrib/auth/authrpc.OnDecision)
+//
+// Hooks are called synchronously, so they should not block.
+type Decision struct {
+	// ActorID is the aggregate id of the actor.
+	ActorID uuid.UUID
+
+	// Action is the requested action.
+	Action string
+
+	// Ref is the aggregate the action was requested for.
+	Ref aggregate.Ref
+
+	// Allowed is the decision.
+	Allowed bool
+
+	// Denied is true if the decision was made by a deny rule.
+	Denied bool
+
+	// Source is the source of the rule that determined the decision (either
+	// DecisionSourceActor or DecisionSourceRole). Source is empty if no rule
+	// matched and the action was disallowed by default.
+	Source string
+
+	// Rule is the matched rule. The aggregate and action of the rule may be
+	// wildcards. Rule is the zero value if no rule matched.
+	Rule Rule
+
+	// Roles are the roles of the actor if the decision was made by a rule of a
+	// role. The Permissions read-model merges the permissions of all roles of
+	// an actor, so the exact role that provided the rule is not known.
+	Roles []uuid.UUID
+}
+
+// Rule is a permission or deny rule of an actor or role.
+type Rule struct {
+	Ref    aggregate.Ref
+	Action string
+}
+
+// Decide returns the authorization decision for the given action on the given
+// aggregate, including the rule that determined the decision.
+func (perms PermissionsDTO) Decide(action string, ref aggregate.Ref) Decision {
+	d := Decision{
+		ActorID: perms.ActorID,
+		Action:  action,
+		Ref:     ref,
+	}
+
+	// deny rules are evaluated before grants
+	if rule, ok := perms.DeniedOfActor.match(action, ref); ok {
+		d.Denied, d.Source, d.Rule = true, DecisionSourceActor, rule
+		return d
+	}
+
+	if rule, ok := perms.DeniedOfRoles.match(action, ref); ok {
+		d.Denied, d.Source, d.Rule, d.Roles = true, DecisionSourceRole, rule, perms.Roles
+		return d
+	}
+
+	if rule, ok := perms.OfActor.match(action, ref); ok {
+		d.Allowed, d.Source, d.Rule = true, DecisionSourceActor, rule
+		return d
+	}
+
+	if rule, ok := perms.OfRoles.match(action, ref); ok {
+		d.Allowed, d.Source, d.Rule, d.Roles = true, DecisionSourceRole, rule, perms.Roles
+		return d
+	}
+
+	return d
+}
+
+// match returns the rule that allows the given action on the given aggregate.
+// The rules are matched in the same order as in a.allows.
+func (a Actions) match(action string, ref aggregate.Ref) (Rule, bool) {
+	refs := [...]aggregate.Ref{
+		ref,
+		allAggregatesWildcard,
+		{Name: "*", ID: ref.ID},
+		{Name: ref.Name, ID: uuid.Nil},
+	}
+
+	for _, r := range refs {
+		for _, act := range [...]string{action, "*"} {
+			if a[r][act] > 0 {
+				return Rule{Ref: r, Action: act}, true
+			}
+		}
+	}
+
+	return Rule{}, false
+}
@@ -0,0 +1,128 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/contrib/auth"
+	"github.com/modernice/goes/projection"
+)
+
+func TestPermissionsDTO_Decide(t *testing.T) {
+	payroll := aggregate.Ref{Name: "payroll", ID: uuid.New()}
+	order := aggregate.Ref{Name: "order", ID: uuid.New()}
+	customer := aggregate.Ref{Name: "customer", ID: uuid.New()}
+
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(order, "view")
+
+	role := auth.NewRole(uuid.New())
+	role.Identify("admin")
+	role.Grant(aggregate.Ref{Name: "*", ID: uuid.Nil}, "update")
+	role.Deny(aggregate.Ref{Name: "payroll", ID: uuid.Nil}, "*")
+	role.Add(actor.AggregateID())
+
+	perms := auth.PermissionsOf(actor.AggregateID())
+	projection.Apply(perms, append(actor.AggregateChanges(), role.AggregateChanges()...))
+
+	decisions := []auth.Decision{
+		perms.Decide("view", order),
+		perms.Decide("update", customer),
+		perms.Decide("update", payroll),
+		perms.Decide("delete", customer),
+	}
+
+	roles := []uuid.UUID{role.AggregateID()}
+	want := []auth.Decision{
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "view",
+			Ref:     order,
+			Allowed: true,
+			Source:  auth.DecisionSourceActor,
+			Rule:    auth.Rule{Ref: order, Action: "view"},
+		},
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "update",
+			Ref:     customer,
+			Allowed: true,
+			Source:  auth.DecisionSourceRole,
+			Rule:    auth.Rule{Ref: aggregate.Ref{Name: "*", ID: uuid.Nil}, Action: "update"},
+			Roles:   roles,
+		},
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "update",
+			Ref:     payroll,
+			Denied:  true,
+			Source:  auth.DecisionSourceRole,
+			Rule:    auth.Rule{Ref: aggregate.Ref{Name: "payroll", ID: uuid.Nil}, Action: "*"},
+			Roles:   roles,
+		},
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "delete",
+			Ref:     customer,
+		},
+	}
+
+	if !cmp.Equal(want, decisions) {
+		t.Fatalf("Decide() returned wrong decisions\n%s", cmp.Diff(want, decisions))
+	}
+}
+
+func TestOnDecision(t *testing.T) {
+	productID := uuid.New()
+	ref := aggregate.Ref{Name: "product", ID: productID}
+
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(ref, "stock.write")
+
+	perms := auth.PermissionFetcherFunc(func(_ context.Context, actorID uuid.UUID) (auth.PermissionsDTO, error) {
+		p := auth.PermissionsOf(actorID)
+		p.OfActor = actor.Actions
+		return p.PermissionsDTO, nil
+	})
+
+	var decisions []auth.Decision
+	reqs := auth.NewRequirements(auth.OnDecision(func(d auth.Decision) {
+		decisions = append(decisions, d)
+	}))
+	reqs.Command("stock.fill", "stock.write", "stock.fill")
+
+	cmd := command.New("stock.fill", struct{}{}, command.Aggregate("product", productID)).Any()
+	if err := reqs.Check(context.Background(), perms, []uuid.UUID{actor.AggregateID()}, cmd); !errors.Is(err, auth.ErrPermissionDenied) {
+		t.Fatalf("Check() should fail with %q; got %q", auth.ErrPermissionDenied, err)
+	}
+
+	want := []auth.Decision{
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "stock.write",
+			Ref:     ref,
+			Allowed: true,
+			Source:  auth.DecisionSourceActor,
+			Rule:    auth.Rule{Ref: ref, Action: "stock.write"},
+		},
+		{
+			ActorID: actor.AggregateID(),
+			Action:  "stock.fill",
+			Ref:     ref,
+		},
+	}
+
+	if !cmp.Equal(want, decisions) {
+		t.Fatalf("OnDecision() hook received wrong decisions\n%s", cmp.Diff(want, decisions))
+	}
+
+	// decisions of the read-model itself are not reported
+	if p, _ := perms.Fetch(context.Background(), actor.AggregateID()); !p.Allows("stock.write", ref) || len(decisions) != 2 {
+		t.Fatalf("Allows() should not report decisions to the hooks of Requirements")
+	}
+}
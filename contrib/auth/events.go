@@ -11,6 +11,9 @@ import (
 // the event data type ActorIdentifiedData in a codec registry.
 const (
 	ActorIdentified = "goes.contrib.auth.actor.identified"
+	ActorAliased    = "goes.contrib.auth.actor.aliased"
+	ActorUnaliased  = "goes.contrib.auth.actor.unaliased"
+	ActorMerged     = "goes.contrib.auth.actor.merged"
 
 	RoleIdentified = "goes.contrib.auth.role.identified"
 	RoleGiven      = "goes.contrib.auth.role.given"
@@ -19,11 +22,27 @@ const (
 	// Permission events are used by both the Permission and Role aggregate.
 	PermissionGranted = "goes.contrib.auth.permission_granted"
 	PermissionRevoked = "goes.contrib.auth.permission_revoked"
+
+	// Deny events are used by both the Actor and Role aggregate.
+	PermissionDenied   = "goes.contrib.auth.permission_denied"
+	PermissionUndenied = "goes.contrib.auth.permission_undenied"
 )
 
 // ActorIdentifiedData is the event data for ActorIdentified.
 type ActorIdentifiedData string
 
+// ActorAliasedData is the event data for ActorAliased.
+type ActorAliasedData string
+
+// ActorUnaliasedData is the event data for ActorUnaliased.
+type ActorUnaliasedData string
+
+// ActorMergedData is the event data for ActorMerged.
+type ActorMergedData struct {
+	// Into is the aggregate id of the actor that the actor was merged into.
+	Into uuid.UUID
+}
+
 // RoleIdentifiedData is the event data for RoleIdentified.
 type RoleIdentifiedData string
 
@@ -39,12 +58,29 @@ type PermissionRevokedData struct {
 	Actions   []string
 }
 
+// PermissionDeniedData is the event data for PermissionDenied.
+type PermissionDeniedData struct {
+	Aggregate aggregate.Ref
+	Actions   []string
+}
+
+// PermissionUndeniedData is the event data for PermissionUndenied.
+type PermissionUndeniedData struct {
+	Aggregate aggregate.Ref
+	Actions   []string
+}
+
 // RegisterEvents registers the events of the auth package into a registry.
 func RegisterEvents(r codec.Registerer) {
 	codec.Register[ActorIdentifiedData](r, ActorIdentified)
+	codec.Register[ActorAliasedData](r, ActorAliased)
+	codec.Register[ActorUnaliasedData](r, ActorUnaliased)
+	codec.Register[ActorMergedData](r, ActorMerged)
 	codec.Register[RoleIdentifiedData](r, RoleIdentified)
 	codec.Register[[]uuid.UUID](r, RoleGiven)
 	codec.Register[[]uuid.UUID](r, RoleRemoved)
 	codec.Register[PermissionGrantedData](r, PermissionGranted)
 	codec.Register[PermissionRevokedData](r, PermissionRevoked)
+	codec.Register[PermissionDeniedData](r, PermissionDenied)
+	codec.Register[PermissionUndeniedData](r, PermissionUndenied)
 }
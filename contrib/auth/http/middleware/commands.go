@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/contrib/auth"
+)
+
+// Commands returns a command bus that enforces the given Requirements when
+// dispatching commands. Before a command is dispatched, the bus checks if the
+// actors that were authorized by the Authorize() middleware are allowed to
+// perform the required actions of the command on the aggregate of the command.
+// If not, the command is not dispatched and Dispatch returns an error that
+// unwraps to auth.ErrPermissionDenied. The context that is passed to Dispatch
+// must be the context of the request (or be derived from it).
+//
+//	reqs := auth.NewRequirements()
+//	reqs.Command("stock.fill", "stock.write")
+//	bus := middleware.Commands(bus, perms, reqs)
+//
+//	http.HandleFunc("/fill", func(w http.ResponseWriter, r *http.Request) {
+//		err := bus.Dispatch(r.Context(), command.New("stock.fill", ...).Any())
+//		if errors.Is(err, auth.ErrPermissionDenied) {
+//			// 403 Forbidden
+//		}
+//	})
+func Commands(bus command.Bus, perms auth.PermissionFetcher, reqs *auth.Requirements) command.Bus {
+	return &commandBus{Bus: bus, perms: perms, reqs: reqs}
+}
+
+type commandBus struct {
+	command.Bus
+
+	perms auth.PermissionFetcher
+	reqs  *auth.Requirements
+}
+
+func (bus *commandBus) Dispatch(ctx context.Context, cmd command.Command, opts ...command.DispatchOption) error {
+	if err := bus.reqs.Check(ctx, bus.perms, AuthorizedActors(ctx), cmd); err != nil {
+		return fmt.Errorf("authorize %q command: %w", cmd.Name(), err)
+	}
+	return bus.Bus.Dispatch(ctx, cmd, opts...)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
 	"github.com/modernice/goes/contrib/auth"
 )
 
@@ -13,13 +14,16 @@ import (
 type Factory struct {
 	perms  auth.PermissionFetcher
 	lookup auth.Lookup
+	opts   []PermissionOption
 }
 
-// NewFactory returns a new middleware factory.
-func NewFactory(perms auth.PermissionFetcher, lookup auth.Lookup) Factory {
+// NewFactory returns a new middleware factory. The provided options are passed
+// to the Permission() and PermissionField() middleware.
+func NewFactory(perms auth.PermissionFetcher, lookup auth.Lookup, opts ...PermissionOption) Factory {
 	return Factory{
 		perms:  perms,
 		lookup: lookup,
+		opts:   opts,
 	}
 }
 
@@ -35,10 +39,15 @@ func (f Factory) AuthorizeField(field string) func(http.Handler) http.Handler {
 
 // Permission returns the Permission middleware.
 func (f Factory) Permission(action string, extractRef func(*http.Request) aggregate.Ref) func(http.Handler) http.Handler {
-	return Permission(f.perms, action, extractRef)
+	return Permission(f.perms, action, extractRef, f.opts...)
 }
 
 // PermissionField returns the PermissionField middleware.
 func (f Factory) PermissionField(action, aggregateName, field string) func(http.Handler) http.Handler {
-	return PermissionField(f.perms, action, aggregateName, field)
+	return PermissionField(f.perms, action, aggregateName, field, f.opts...)
+}
+
+// Commands returns a command bus that enforces the given Requirements.
+func (f Factory) Commands(bus command.Bus, reqs *auth.Requirements) command.Bus {
+	return Commands(bus, f.perms, reqs)
 }
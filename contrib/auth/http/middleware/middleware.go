@@ -105,6 +105,31 @@ func AuthorizeField(field string) func(http.Handler) http.Handler {
 	}
 }
 
+// PermissionOption is an option for the Permission() and PermissionField()
+// middleware.
+type PermissionOption func(*permissionConfig)
+
+type permissionConfig struct {
+	onDecision []func(auth.Decision)
+}
+
+// OnDecision returns a PermissionOption that adds a hook that is called for
+// every authorization decision that is made by the middleware. Hooks are
+// called synchronously, so they should not block.
+func OnDecision(fn func(auth.Decision)) PermissionOption {
+	return func(cfg *permissionConfig) {
+		cfg.onDecision = append(cfg.onDecision, fn)
+	}
+}
+
+func newPermissionConfig(opts []PermissionOption) permissionConfig {
+	var cfg permissionConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // Permission returns a middleware that protects routes from unauthorized access.
 // When called, the middleware extracts the aggregate that the user wants to act
 // on from the request body by calling the provided extractRef function.
@@ -112,7 +137,8 @@ func AuthorizeField(field string) func(http.Handler) http.Handler {
 // to perform the given action on the given aggregate. Only if an authorized
 // actor is allowed to perform the action, the next handler is called. Otherwise
 // the middleware returns 403 Forbidden.
-func Permission(perms auth.PermissionFetcher, action string, extractRef func(*http.Request) aggregate.Ref) func(http.Handler) http.Handler {
+func Permission(perms auth.PermissionFetcher, action string, extractRef func(*http.Request) aggregate.Ref, opts ...PermissionOption) func(http.Handler) http.Handler {
+	cfg := newPermissionConfig(opts)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			cloned, err := cloneRequest(r)
@@ -127,7 +153,7 @@ func Permission(perms auth.PermissionFetcher, action string, extractRef func(*ht
 				return
 			}
 
-			if !allowed(r.Context(), perms, ref, action) {
+			if !cfg.allowed(r.Context(), perms, ref, action) {
 				forbidden(w)
 				return
 			}
@@ -141,7 +167,8 @@ func Permission(perms auth.PermissionFetcher, action string, extractRef func(*ht
 // PermissionField differs from Permission in that it requires the aggregate
 // name to be passed as an argument and that it extracts the aggregate id from
 // the request body.
-func PermissionField(perms auth.PermissionFetcher, action, aggregateName, field string) func(http.Handler) http.Handler {
+func PermissionField(perms auth.PermissionFetcher, action, aggregateName, field string, opts ...PermissionOption) func(http.Handler) http.Handler {
+	cfg := newPermissionConfig(opts)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			cloned, err := cloneRequest(r)
@@ -178,7 +205,7 @@ func PermissionField(perms auth.PermissionFetcher, action, aggregateName, field
 				return
 			}
 
-			if !allowed(r.Context(), perms, ref, action) {
+			if !cfg.allowed(r.Context(), perms, ref, action) {
 				forbidden(w)
 				return
 			}
@@ -231,7 +258,7 @@ func withAuthorizedActor(ctx context.Context, actorID uuid.UUID) context.Context
 	return context.WithValue(ctx, authorizedActorsCtxKey, append(actors, actorID))
 }
 
-func allowed(ctx context.Context, permissions auth.PermissionFetcher, ref aggregate.Ref, action string) bool {
+func (cfg permissionConfig) allowed(ctx context.Context, permissions auth.PermissionFetcher, ref aggregate.Ref, action string) bool {
 	actors := AuthorizedActors(ctx)
 
 	for _, actor := range actors {
@@ -240,7 +267,12 @@ func allowed(ctx context.Context, permissions auth.PermissionFetcher, ref aggreg
 			continue
 		}
 
-		if perms.Allows(action, ref) {
+		d := perms.Decide(action, ref)
+		for _, fn := range cfg.onDecision {
+			fn(d)
+		}
+
+		if d.Allowed {
 			return true
 		}
 	}
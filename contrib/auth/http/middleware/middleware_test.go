@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/command"
 	"github.com/modernice/goes/contrib/auth"
 	"github.com/modernice/goes/contrib/auth/http/middleware"
 	"github.com/modernice/goes/event"
@@ -191,6 +192,46 @@ func TestPermission_granted(t *testing.T) {
 	}
 }
 
+func TestOnDecision(t *testing.T) {
+	actorID := uuid.New()
+	ref := aggregate.Ref{Name: "foo", ID: uuid.New()}
+
+	actor := auth.NewUUIDActor(actorID)
+	actor.Grant(ref, "view")
+
+	fetcher := auth.PermissionFetcherFunc(func(_ context.Context, id uuid.UUID) (auth.PermissionsDTO, error) {
+		p := auth.PermissionsOf(id)
+		p.OfActor = actor.Actions
+		return p.PermissionsDTO, nil
+	})
+
+	var decisions []auth.Decision
+	permission := middleware.Permission(fetcher, "view", func(*http.Request) aggregate.Ref { return ref }, middleware.OnDecision(func(d auth.Decision) {
+		decisions = append(decisions, d)
+	}))
+
+	h := middleware.AuthorizeField("actorId")(permission(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(`{"actorId": %q}`, actorID)))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if rec.Result().StatusCode != http.StatusNoContent {
+		t.Fatalf("StatusCode should be %v; is %v", http.StatusNoContent, rec.Result().StatusCode)
+	}
+
+	if len(decisions) != 1 {
+		t.Fatalf("OnDecision() hook should be called %d time; was called %d times", 1, len(decisions))
+	}
+
+	if d := decisions[0]; d.ActorID != actorID || d.Action != "view" || d.Ref != ref || !d.Allowed {
+		t.Fatalf("OnDecision() hook received wrong decision: %+v", d)
+	}
+}
+
 // PermissionTest represents a test suite for middleware that handles
 // authorization and permissions for HTTP requests. It sets up an event bus,
 // event store, actor and permission repositories, and an authorization
@@ -255,3 +296,67 @@ func panicOn(errs <-chan error) {
 		}
 	}
 }
+
+func TestCommands(t *testing.T) {
+	actorID := uuid.New()
+	ref := aggregate.Ref{Name: "product", ID: uuid.New()}
+
+	actor := auth.NewUUIDActor(actorID)
+	actor.Grant(ref, "stock.write")
+
+	perms := auth.PermissionFetcherFunc(func(_ context.Context, id uuid.UUID) (auth.PermissionsDTO, error) {
+		p := auth.PermissionsOf(id)
+		if id == actorID {
+			p.OfActor = actor.Actions
+		}
+		return p.PermissionsDTO, nil
+	})
+
+	reqs := auth.NewRequirements()
+	reqs.Command("stock.fill", "stock.write")
+
+	var dispatched []command.Command
+	bus := middleware.Commands(dispatcherFunc(func(_ context.Context, cmd command.Command, _ ...command.DispatchOption) error {
+		dispatched = append(dispatched, cmd)
+		return nil
+	}), perms, reqs)
+
+	mw := middleware.Authorize(nil, func(a middleware.Authorizer, r *http.Request) {
+		if r.Header.Get("X-Actor") == actorID.String() {
+			a.Authorize(actorID)
+		}
+	})
+
+	var dispatchErr error
+	h := mw(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		dispatchErr = bus.Dispatch(r.Context(), command.New("stock.fill", struct{}{}, command.Aggregate(ref.Name, ref.ID)).Any())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+
+	if !errors.Is(dispatchErr, auth.ErrPermissionDenied) {
+		t.Fatalf("Dispatch should fail with %q; got %q", auth.ErrPermissionDenied, dispatchErr)
+	}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Actor", actorID.String())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if dispatchErr != nil {
+		t.Fatalf("Dispatch failed with %q", dispatchErr)
+	}
+
+	if len(dispatched) != 1 {
+		t.Fatalf("%d command should have been dispatched; got %d", 1, len(dispatched))
+	}
+}
+
+type dispatcherFunc func(context.Context, command.Command, ...command.DispatchOption) error
+
+func (fn dispatcherFunc) Dispatch(ctx context.Context, cmd command.Command, opts ...command.DispatchOption) error {
+	return fn(ctx, cmd, opts...)
+}
+
+func (fn dispatcherFunc) Subscribe(context.Context, ...string) (<-chan command.Context, <-chan error, error) {
+	return nil, nil, errors.New("not implemented")
+}
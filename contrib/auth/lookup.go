@@ -14,6 +14,10 @@ const (
 
 	// LookupRole looks up the aggregate id of a role from a given role name.
 	LookupRole = "role"
+
+	// LookupMergedInto looks up the aggregate id of the actor that an actor
+	// has been merged into.
+	LookupMergedInto = "mergedInto"
 )
 
 // LookupTable provides lookups from actor ids to aggregate ids of those actors.
@@ -21,21 +25,47 @@ type LookupTable struct {
 	*lookup.Lookup
 }
 
-var lookupEvents = [...]string{ActorIdentified, RoleIdentified}
+var lookupEvents = [...]string{
+	ActorIdentified,
+	ActorAliased,
+	ActorUnaliased,
+	ActorMerged,
+	RoleIdentified,
+}
 
 // NewLookup returns a new lookup for aggregate ids of actors.
 func NewLookup(store event.Store, bus event.Bus, opts ...lookup.Option) *LookupTable {
 	return &LookupTable{Lookup: lookup.New(store, bus, lookupEvents[:], opts...)}
 }
 
-// Actor returns the aggregate id of the actor with the given formatted actor id.
+// Actor returns the aggregate id of the actor with the given formatted actor id
+// or alias. If the actor has been merged into another actor, the aggregate id
+// of the actor it was merged into is returned.
 func (l *LookupTable) Actor(ctx context.Context, id string) (uuid.UUID, bool) {
 	select {
 	case <-ctx.Done():
 		return uuid.Nil, false
 	case <-l.Ready():
 	}
-	return l.Reverse(ctx, ActorAggregate, LookupActor, id)
+
+	actorID, ok := l.Reverse(ctx, ActorAggregate, LookupActor, id)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	return l.mergedInto(ctx, actorID), true
+}
+
+func (l *LookupTable) mergedInto(ctx context.Context, actorID uuid.UUID) uuid.UUID {
+	seen := map[uuid.UUID]bool{actorID: true}
+	for {
+		into, err := lookup.Expect[uuid.UUID](ctx, l.Lookup, ActorAggregate, LookupMergedInto, actorID)
+		if err != nil || seen[into] {
+			return actorID
+		}
+		seen[into] = true
+		actorID = into
+	}
 }
 
 // Role returns the aggregate id of the role with the given name.
@@ -53,6 +83,27 @@ func (data ActorIdentifiedData) ProvideLookup(p lookup.Provider) {
 	p.Provide(LookupActor, string(data))
 }
 
+// ProvideLookup implements lookup.Event.
+func (data ActorAliasedData) ProvideLookup(p lookup.Provider) {
+	p.Provide(aliasLookupKey(string(data)), string(data))
+}
+
+// ProvideLookup implements lookup.Event.
+func (data ActorUnaliasedData) ProvideLookup(p lookup.Provider) {
+	p.Remove(aliasLookupKey(string(data)))
+}
+
+// ProvideLookup implements lookup.Event.
+func (data ActorMergedData) ProvideLookup(p lookup.Provider) {
+	p.Provide(LookupMergedInto, data.Into)
+}
+
+// aliasLookupKey returns the lookup key for an alias. Each alias has its own
+// key so that an actor can be looked up by any of its aliases.
+func aliasLookupKey(alias string) string {
+	return LookupActor + ":" + alias
+}
+
 // ProvideLookup implements lookup.Event.
 func (data RoleIdentifiedData) ProvideLookup(p lookup.Provider) {
 	p.Provide(LookupRole, string(data))
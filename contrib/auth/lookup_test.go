@@ -11,6 +11,8 @@ import (
 	"github.com/modernice/goes/event/eventbus"
 	"github.com/modernice/goes/event/eventstore"
 	"github.com/modernice/goes/internal/testutil"
+	"github.com/modernice/goes/projection/lookup"
+	"github.com/modernice/goes/projection/schedule"
 )
 
 func TestLookup(t *testing.T) {
@@ -60,3 +62,42 @@ func TestLookup(t *testing.T) {
 
 	// TODO(bounoable): Test lookup of roles.
 }
+
+func TestLookup_alias(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	actors := auth.NewStringActorRepository(repository.New(store))
+
+	actor := auth.NewStringActor(uuid.New())
+	actor.Identify("foo")
+	actor.Alias("bar")
+	actor.Alias("baz")
+	actor.Unalias("baz")
+
+	// debounce to apply the events in order
+	look := auth.NewLookup(store, bus, lookup.ScheduleOptions(schedule.Debounce(20*time.Millisecond)))
+	errs, err := look.Run(ctx)
+	if err != nil {
+		t.Fatalf("run lookup: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	if err := actors.Save(ctx, actor); err != nil {
+		t.Fatalf("save actor: %v", err)
+	}
+
+	<-time.After(100 * time.Millisecond)
+
+	for _, sid := range []string{"foo", "bar"} {
+		if id, ok := look.Actor(ctx, sid); !ok || id != actor.AggregateID() {
+			t.Fatalf("Actor(%q) should return %s; got %s", sid, actor.AggregateID(), id)
+		}
+	}
+
+	if _, ok := look.Actor(ctx, "baz"); ok {
+		t.Fatalf("Actor() should not provide the actor for a removed alias")
+	}
+}
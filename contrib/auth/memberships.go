@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/pick"
+	"github.com/modernice/goes/projection"
+	"github.com/modernice/goes/projection/schedule"
+	"golang.org/x/exp/slices"
+)
+
+// Page configures the pagination of membership queries. A Limit of 0 returns
+// all remaining items after the Offset.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// MembershipTable is a continuously projected read-model of role memberships.
+// It provides the members of roles and the roles of actors, which can be used
+// to build admin screens on top of the authorization module. Members and roles
+// are returned in the order in which they were added.
+//
+//	table := auth.NewMembershipTable(store, bus)
+//	errs, err := table.Run(context.TODO())
+//	// handle err
+//	members, total := table.Members(context.TODO(), roleID, auth.Page{Limit: 20})
+type MembershipTable struct {
+	*projection.Base
+	*projection.Progressor
+
+	schedule *schedule.Continuous
+	once     sync.Once
+	ready    chan struct{}
+
+	mux     sync.RWMutex
+	members map[uuid.UUID][]uuid.UUID
+	roles   map[uuid.UUID][]uuid.UUID
+}
+
+var membershipEvents = [...]string{RoleGiven, RoleRemoved}
+
+// NewMembershipTable returns a new MembershipTable.
+func NewMembershipTable(store event.Store, bus event.Bus, opts ...schedule.ContinuousOption) *MembershipTable {
+	table := &MembershipTable{
+		Base:       projection.New(),
+		Progressor: projection.NewProgressor(),
+		schedule:   schedule.Continuously(bus, store, membershipEvents[:], opts...),
+		ready:      make(chan struct{}),
+		members:    make(map[uuid.UUID][]uuid.UUID),
+		roles:      make(map[uuid.UUID][]uuid.UUID),
+	}
+
+	event.ApplyWith(table, table.roleGiven, RoleGiven)
+	event.ApplyWith(table, table.roleRemoved, RoleRemoved)
+
+	return table
+}
+
+// Ready returns a channel that is closed when the membership table is ready.
+// The membership table becomes ready after the first projection job has been
+// applied.
+func (table *MembershipTable) Ready() <-chan struct{} {
+	return table.ready
+}
+
+// Run projects the membership table until ctx is canceled.
+func (table *MembershipTable) Run(ctx context.Context) (<-chan error, error) {
+	errs, err := table.schedule.Subscribe(ctx, table.applyJob)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to projection schedule: %w", err)
+	}
+
+	go table.schedule.Trigger(ctx)
+
+	return errs, nil
+}
+
+func (table *MembershipTable) applyJob(ctx projection.Job) error {
+	defer table.once.Do(func() { close(table.ready) })
+	table.mux.Lock()
+	defer table.mux.Unlock()
+	return ctx.Apply(ctx, table)
+}
+
+// Members returns the given page of the members of the given role, and the
+// total number of members.
+func (table *MembershipTable) Members(ctx context.Context, roleID uuid.UUID, page Page) ([]uuid.UUID, int) {
+	return table.paginate(ctx, table.members, roleID, page)
+}
+
+// RolesOf returns the given page of the roles of the given actor, and the total
+// number of roles the actor is a member of.
+func (table *MembershipTable) RolesOf(ctx context.Context, actorID uuid.UUID, page Page) ([]uuid.UUID, int) {
+	return table.paginate(ctx, table.roles, actorID, page)
+}
+
+func (table *MembershipTable) paginate(ctx context.Context, m map[uuid.UUID][]uuid.UUID, id uuid.UUID, page Page) ([]uuid.UUID, int) {
+	select {
+	case <-ctx.Done():
+		return nil, 0
+	case <-table.ready:
+	}
+
+	table.mux.RLock()
+	defer table.mux.RUnlock()
+
+	ids := m[id]
+	total := len(ids)
+
+	start := page.Offset
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if page.Limit > 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+
+	return slices.Clone(ids[start:end]), total
+}
+
+func (table *MembershipTable) roleGiven(evt event.Of[[]uuid.UUID]) {
+	roleID := pick.AggregateID(evt)
+	for _, actorID := range evt.Data() {
+		table.members[roleID] = appendUnique(table.members[roleID], actorID)
+		table.roles[actorID] = appendUnique(table.roles[actorID], roleID)
+	}
+}
+
+func (table *MembershipTable) roleRemoved(evt event.Of[[]uuid.UUID]) {
+	roleID := pick.AggregateID(evt)
+	for _, actorID := range evt.Data() {
+		table.members[roleID] = removeID(table.members[roleID], actorID)
+		table.roles[actorID] = removeID(table.roles[actorID], roleID)
+	}
+}
+
+func appendUnique(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+func removeID(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	if i := slices.Index(ids, id); i >= 0 {
+		return slices.Delete(ids, i, i+1)
+	}
+	return ids
+}
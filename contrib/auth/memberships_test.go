@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/contrib/auth"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+	"github.com/modernice/goes/projection/schedule"
+)
+
+func TestMembershipTable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	roles := auth.NewRoleRepository(repository.New(store))
+
+	table := auth.NewMembershipTable(store, bus, schedule.Debounce(20*time.Millisecond))
+	errs, err := table.Run(ctx)
+	if err != nil {
+		t.Fatalf("run membership table: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	actors := make([]uuid.UUID, 5)
+	for i := range actors {
+		actors[i] = uuid.New()
+	}
+
+	admin := auth.NewRole(uuid.New())
+	admin.Identify("admin")
+	admin.Add(actors...)
+	admin.Remove(actors[1])
+
+	editor := auth.NewRole(uuid.New())
+	editor.Identify("editor")
+	editor.Add(actors[0])
+
+	if err := roles.Save(ctx, admin); err != nil {
+		t.Fatalf("save role: %v", err)
+	}
+
+	if err := roles.Save(ctx, editor); err != nil {
+		t.Fatalf("save role: %v", err)
+	}
+
+	<-time.After(100 * time.Millisecond)
+
+	tests := []struct {
+		page auth.Page
+		want []uuid.UUID
+	}{
+		{page: auth.Page{}, want: []uuid.UUID{actors[0], actors[2], actors[3], actors[4]}},
+		{page: auth.Page{Limit: 2}, want: []uuid.UUID{actors[0], actors[2]}},
+		{page: auth.Page{Offset: 2, Limit: 2}, want: []uuid.UUID{actors[3], actors[4]}},
+		{page: auth.Page{Offset: 3, Limit: 2}, want: []uuid.UUID{actors[4]}},
+		{page: auth.Page{Offset: 10}, want: []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		members, total := table.Members(ctx, admin.AggregateID(), tt.page)
+		if total != 4 {
+			t.Fatalf("Members() should return a total of %d members; got %d", 4, total)
+		}
+
+		if !cmp.Equal(tt.want, members) {
+			t.Fatalf("Members() returned the wrong members for page %+v\n%s", tt.page, cmp.Diff(tt.want, members))
+		}
+	}
+
+	roleIDs, total := table.RolesOf(ctx, actors[0], auth.Page{})
+	if want := []uuid.UUID{admin.AggregateID(), editor.AggregateID()}; total != 2 || !cmp.Equal(want, roleIDs) {
+		t.Fatalf("RolesOf() returned the wrong roles\n%s", cmp.Diff(want, roleIDs))
+	}
+
+	if roleIDs, total := table.RolesOf(ctx, actors[1], auth.Page{}); total != 0 || len(roleIDs) != 0 {
+		t.Fatalf("RolesOf() should return no roles for a removed member; got %v", roleIDs)
+	}
+}
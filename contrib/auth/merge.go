@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// MergeActors merges the source actor into the target actor, which is needed
+// after account consolidation. The permissions, actor id and aliases of the
+// source are moved to the target (see Actor.MergeInto), and the target replaces
+// the source as a member of all roles the source is a member of. The changes
+// are recorded as events, so the PermissionProjector re-points the projected
+// permissions and the LookupTable resolves the ids of the source to the target.
+//
+// The target is saved first, then the roles, and the source is saved last.
+// MergeActors is idempotent, so a merge that failed halfway can be retried. If
+// the source has already been merged into the target, MergeActors is a no-op.
+func MergeActors(ctx context.Context, actors ActorRepository, roles RoleRepository, source, target uuid.UUID) error {
+	src, err := actors.Fetch(ctx, source)
+	if err != nil {
+		return fmt.Errorf("fetch source actor: %w", err)
+	}
+
+	if into, merged := src.MergedInto(); merged {
+		if into == target {
+			return nil
+		}
+		return fmt.Errorf("source actor: %w into %s", ErrActorMerged, into)
+	}
+
+	tgt, err := actors.Fetch(ctx, target)
+	if err != nil {
+		return fmt.Errorf("fetch target actor: %w", err)
+	}
+
+	if err := src.transferTo(tgt); err != nil {
+		return err
+	}
+
+	if err := actors.Save(ctx, tgt); err != nil {
+		return fmt.Errorf("save target actor: %w", err)
+	}
+
+	if err := replaceRoleMember(ctx, roles, source, target); err != nil {
+		return err
+	}
+
+	// The events of the source are raised last because the PermissionProjector
+	// skips events that are older than the projected progress of an actor.
+	src.retire(target)
+
+	if err := actors.Save(ctx, src); err != nil {
+		return fmt.Errorf("save source actor: %w", err)
+	}
+
+	return nil
+}
+
+func replaceRoleMember(ctx context.Context, roles RoleRepository, member, replacement uuid.UUID) error {
+	str, errs, err := roles.Query(ctx, query.New())
+	if err != nil {
+		return fmt.Errorf("query roles: %w", err)
+	}
+
+	memberOf, err := streams.Drain(ctx, streams.Filter(str, func(r *Role) bool {
+		return r.IsMember(member)
+	}), errs)
+	if err != nil {
+		return fmt.Errorf("query roles: %w", err)
+	}
+
+	for _, role := range memberOf {
+		if err := role.Add(replacement); err != nil {
+			return fmt.Errorf("add target to role %q: %w", role.Name(), err)
+		}
+
+		if err := role.Remove(member); err != nil {
+			return fmt.Errorf("remove source from role %q: %w", role.Name(), err)
+		}
+
+		if err := roles.Save(ctx, role); err != nil {
+			return fmt.Errorf("save role %q: %w", role.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/contrib/auth"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+	"github.com/modernice/goes/projection/lookup"
+	"github.com/modernice/goes/projection/schedule"
+)
+
+func TestMergeActors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	repo := repository.New(store)
+	actors := auth.NewStringActorRepository(repo)
+	roles := auth.NewRoleRepository(repo)
+	permissions := auth.InMemoryPermissionRepository()
+
+	proj := auth.NewPermissionProjector(permissions, roles, bus, store, schedule.Debounce(20*time.Millisecond))
+	errs, err := proj.Run(ctx)
+	if err != nil {
+		t.Fatalf("run projector: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	look := auth.NewLookup(store, bus, lookup.ScheduleOptions(schedule.Debounce(20*time.Millisecond)))
+	errs, err = look.Run(ctx)
+	if err != nil {
+		t.Fatalf("run lookup: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	order := aggregate.Ref{Name: "order", ID: uuid.New()}
+	customer := aggregate.Ref{Name: "customer", ID: uuid.New()}
+
+	source := auth.NewStringActor(uuid.New())
+	source.Identify("github:bob")
+	source.Grant(order, "view")
+
+	target := auth.NewStringActor(uuid.New())
+	target.Identify("google:bob")
+
+	role := auth.NewRole(uuid.New())
+	role.Identify("admin")
+	role.Grant(customer, "update")
+	role.Add(source.AggregateID())
+
+	if err := actors.Save(ctx, source); err != nil {
+		t.Fatalf("save source actor: %v", err)
+	}
+
+	if err := actors.Save(ctx, target); err != nil {
+		t.Fatalf("save target actor: %v", err)
+	}
+
+	if err := roles.Save(ctx, role); err != nil {
+		t.Fatalf("save role: %v", err)
+	}
+
+	if err := auth.MergeActors(ctx, actors, roles, source.AggregateID(), target.AggregateID()); err != nil {
+		t.Fatalf("MergeActors() failed with %q", err)
+	}
+
+	if err := auth.MergeActors(ctx, actors, roles, source.AggregateID(), target.AggregateID()); err != nil {
+		t.Fatalf("MergeActors() should be a no-op if the actors are already merged; got %q", err)
+	}
+
+	<-time.After(200 * time.Millisecond)
+
+	role, err = roles.Fetch(ctx, role.AggregateID())
+	if err != nil {
+		t.Fatalf("fetch role: %v", err)
+	}
+
+	if role.IsMember(source.AggregateID()) || !role.IsMember(target.AggregateID()) {
+		t.Fatalf("the target should replace the source as a member of the role")
+	}
+
+	targetPerms, err := permissions.Fetch(ctx, target.AggregateID())
+	if err != nil {
+		t.Fatalf("fetch permissions of target: %v", err)
+	}
+
+	if !targetPerms.Allows("view", order) || !targetPerms.Allows("update", customer) {
+		t.Fatalf("the target should have the permissions of the source")
+	}
+
+	sourcePerms, err := permissions.Fetch(ctx, source.AggregateID())
+	if err != nil {
+		t.Fatalf("fetch permissions of source: %v", err)
+	}
+
+	if sourcePerms.Allows("view", order) || sourcePerms.Allows("update", customer) {
+		t.Fatalf("the permissions of the source should be revoked")
+	}
+
+	for _, sid := range []string{"github:bob", "google:bob"} {
+		if id, ok := look.Actor(ctx, sid); !ok || id != target.AggregateID() {
+			t.Fatalf("Actor(%q) should return the target actor %s; got %s", sid, target.AggregateID(), id)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/helper/pick"
 	"github.com/modernice/goes/projection"
+	"golang.org/x/exp/maps"
 )
 
 // Permissions is the read-model for the permissions of a specific actor.
@@ -34,6 +35,13 @@ import (
 //  2. Actor is revoked "view" permission on the aggregate.
 // Then the actor is also allowed to perform the "view" action on the aggregate
 // because the role still grants the permission its members.
+//
+// Deny rules
+//
+// Actions can be explicitly denied to actors and roles (see Actor.Deny and
+// Role.Deny). Deny rules are evaluated before any grants: if the actor itself
+// or any of its roles denies an action, the action is disallowed, regardless
+// of the permissions that were granted to the actor or its roles (deny wins).
 type Permissions struct {
 	*projection.Base
 	*projection.Progressor
@@ -48,6 +56,9 @@ type PermissionsDTO struct {
 	Roles   []uuid.UUID `json:"roles"`
 	OfActor Actions     `json:"ofActor"`
 	OfRoles Actions     `json:"ofRoles"`
+
+	DeniedOfActor Actions `json:"deniedOfActor"`
+	DeniedOfRoles Actions `json:"deniedOfRoles"`
 }
 
 // PermissionsOf returns the permissions read-model of the given actor.
@@ -66,13 +77,17 @@ func PermissionsOf(actorID uuid.UUID) *Permissions {
 		Progressor: projection.NewProgressor(),
 		PermissionsDTO: PermissionsDTO{
 			ActorID: actorID,
-			OfActor: make(Actions),
-			OfRoles: make(Actions),
+			OfActor:       make(Actions),
+			OfRoles:       make(Actions),
+			DeniedOfActor: make(Actions),
+			DeniedOfRoles: make(Actions),
 		},
 	}
 
 	event.ApplyWith(perms, perms.granted, PermissionGranted)
 	event.ApplyWith(perms, perms.revoked, PermissionRevoked)
+	event.ApplyWith(perms, perms.denied, PermissionDenied)
+	event.ApplyWith(perms, perms.undenied, PermissionUndenied)
 	event.ApplyWith(perms, perms.roleGiven, RoleGiven)
 	event.ApplyWith(perms, perms.roleRemoved, RoleRemoved)
 
@@ -85,24 +100,31 @@ func (perms PermissionsDTO) ModelID() uuid.UUID {
 }
 
 // Allows returns whether the actor is allowed to perform the given action on
-// the given aggregate. An actor is allowed to perform a given action if either
-// the actor itself was granted the permission, or if the actor is a member of a
-// role that was granted the permission.
-//
-// Read the documentation of Permissions for more details.
+// the given aggregate. Deny rules are evaluated first: if the action is denied
+// to the actor or any of its roles, Allows returns false. Otherwise, an actor is
+// allowed to perform a given action if either the actor itself was granted the
+// permission, or if the actor is a member of a role that was granted the
+// permission. Use Decide to get the rule that determined the decision.
 func (perms PermissionsDTO) Allows(action string, ref aggregate.Ref) bool {
-	return perms.ActorAllows(action, ref) || perms.RoleAllows(action, ref)
+	return perms.Decide(action, ref).Allowed
+}
+
+// Denies returns whether the given action on the given aggregate is explicitly
+// denied to the actor, either directly or through any of its roles.
+func (perms PermissionsDTO) Denies(action string, ref aggregate.Ref) bool {
+	return perms.DeniedOfActor.allows(action, ref) || perms.DeniedOfRoles.allows(action, ref)
 }
 
 // ActorAllows returns whether the actor is allowed to perform the given action
-// on the given aggregate, ignoring permissions of any roles the actor is member of.
+// on the given aggregate, ignoring permissions of any roles the actor is member
+// of. ActorAllows does not evaluate deny rules.
 func (perms PermissionsDTO) ActorAllows(action string, ref aggregate.Ref) bool {
 	return perms.OfActor.allows(action, ref)
 }
 
 // RoleAllows returns whether the actor is allowed to perform the given action
 // on the given aggregate, using only the permissions of the roles the actor is
-// member of.
+// member of. RoleAllows does not evaluate deny rules.
 func (perms PermissionsDTO) RoleAllows(action string, ref aggregate.Ref) bool {
 	return perms.OfRoles.allows(action, ref)
 }
@@ -119,7 +141,9 @@ func (perms PermissionsDTO) Disallows(action string, ref aggregate.Ref) bool {
 func (perms PermissionsDTO) Equal(other PermissionsDTO) bool {
 	return perms.ActorID == other.ActorID &&
 		perms.OfActor.Equal(other.OfActor) &&
-		perms.OfRoles.Equal(other.OfRoles)
+		perms.OfRoles.Equal(other.OfRoles) &&
+		perms.DeniedOfActor.Equal(other.DeniedOfActor) &&
+		perms.DeniedOfRoles.Equal(other.DeniedOfRoles)
 }
 
 func (perms *Permissions) granted(evt event.Of[PermissionGrantedData]) {
@@ -140,6 +164,24 @@ func (perms *Permissions) revoked(evt event.Of[PermissionRevokedData]) {
 	}
 }
 
+func (perms *Permissions) denied(evt event.Of[PermissionDeniedData]) {
+	switch pick.AggregateName(evt) {
+	case ActorAggregate:
+		perms.DeniedOfActor.denied(evt)
+	case RoleAggregate:
+		perms.DeniedOfRoles.denied(evt)
+	}
+}
+
+func (perms *Permissions) undenied(evt event.Of[PermissionUndeniedData]) {
+	switch pick.AggregateName(evt) {
+	case ActorAggregate:
+		perms.DeniedOfActor.undenied(evt)
+	case RoleAggregate:
+		perms.DeniedOfRoles.undenied(evt)
+	}
+}
+
 func (perms *Permissions) roleGiven(evt event.Of[[]uuid.UUID]) {
 	perms.Roles = append(perms.Roles, pick.AggregateID(evt))
 	perms.rolesHaveChanged = true
@@ -162,6 +204,7 @@ func (perms *Permissions) finalize(ctx context.Context, roles RoleRepository) er
 	}
 	perms.rolesHaveChanged = false
 	perms.OfRoles = make(Actions)
+	perms.DeniedOfRoles = make(Actions)
 
 	for _, roleID := range perms.Roles {
 		role, err := roles.Fetch(ctx, roleID)
@@ -170,14 +213,11 @@ func (perms *Permissions) finalize(ctx context.Context, roles RoleRepository) er
 		}
 
 		for target, actions := range role.Actions {
-			for action := range actions {
-				tactions, ok := perms.OfRoles[target]
-				if !ok {
-					tactions = make(map[string]int)
-					perms.OfRoles[target] = tactions
-				}
-				tactions[action]++
-			}
+			perms.OfRoles.add(target, maps.Keys(actions))
+		}
+
+		for target, actions := range role.denied {
+			perms.DeniedOfRoles.add(target, maps.Keys(actions))
 		}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/contrib/auth"
+	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/projection"
 )
 
@@ -148,3 +149,51 @@ func TestPermissions_cases(t *testing.T) {
 		})
 	}
 }
+
+func TestPermissions_deny(t *testing.T) {
+	payroll := aggregate.Ref{Name: "payroll", ID: uuid.New()}
+	order := aggregate.Ref{Name: "order", ID: uuid.New()}
+
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(payroll, "view")
+
+	role := auth.NewRole(uuid.New())
+	role.Identify("admin")
+	role.Grant(aggregate.Ref{Name: "*", ID: uuid.Nil}, "*")
+	role.Deny(aggregate.Ref{Name: "payroll", ID: uuid.Nil}, "*")
+	role.Add(actor.AggregateID())
+
+	perms := auth.PermissionsOf(actor.AggregateID())
+	projection.Apply(perms, append(actor.AggregateChanges(), role.AggregateChanges()...))
+
+	if !perms.Allows("update", order) {
+		t.Fatalf("Permissions should allow %q action on %s", "update", order)
+	}
+
+	if !perms.Denies("view", payroll) {
+		t.Fatalf("Denies() should return true for %q action on %s", "view", payroll)
+	}
+
+	if perms.Allows("view", payroll) {
+		t.Fatalf("deny rule of role should override the permission of the actor")
+	}
+
+	actor.Deny(order, "delete")
+	projection.Apply(perms, lastChange(actor))
+
+	if perms.Allows("delete", order) || !perms.Allows("update", order) {
+		t.Fatalf("deny rule of actor should override the permissions of the role")
+	}
+
+	role.Undeny(aggregate.Ref{Name: "payroll", ID: uuid.Nil}, "*")
+	projection.Apply(perms, lastChange(role))
+
+	if !perms.Allows("view", payroll) {
+		t.Fatalf("Permissions should allow %q action on %s after the deny rule was removed", "view", payroll)
+	}
+}
+
+func lastChange(a aggregate.Aggregate) []event.Event {
+	changes := a.AggregateChanges()
+	return changes[len(changes)-1:]
+}
@@ -3,14 +3,18 @@ package auth
 import (
 	"context"
 	"fmt"
+	stdtime "time"
 
 	"github.com/google/uuid"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
 	"github.com/modernice/goes/helper/pick"
 	"github.com/modernice/goes/helper/streams"
 	"github.com/modernice/goes/internal/slice"
 	"github.com/modernice/goes/projection"
 	"github.com/modernice/goes/projection/schedule"
+	"golang.org/x/exp/slices"
 )
 
 // PermissionProjector continuously projects the Permissions read-model for all actors.
@@ -18,11 +22,14 @@ type PermissionProjector struct {
 	schedule    *schedule.Continuous
 	permissions PermissionRepository
 	roles       RoleRepository
+	snapshots   *snapshotConfig
 }
 
 var projectorEvents = [...]string{
 	PermissionGranted,
 	PermissionRevoked,
+	PermissionDenied,
+	PermissionUndenied,
 	RoleGiven,
 	RoleRemoved,
 }
@@ -42,27 +49,45 @@ func NewPermissionProjector(
 	}
 }
 
-// Run projects permissions until ctx is canceled.
+// Run projects permissions until ctx is canceled. If snapshots are configured,
+// the permissions are restored from the latest snapshot before the projector
+// catches up on the events that were published after the snapshot was taken.
 func (proj *PermissionProjector) Run(ctx context.Context) (<-chan error, error) {
+	var opts []projection.TriggerOption
+	if proj.snapshots != nil {
+		since, err := proj.restoreSnapshot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("restore snapshot: %w", err)
+		}
+
+		if !since.IsZero() {
+			opts = append(opts, projection.Query(query.New(
+				query.Name(projectorEvents[:]...),
+				query.Time(time.Min(since)),
+				query.SortByTime(),
+			)))
+		}
+	}
+
 	errs, err := proj.schedule.Subscribe(ctx, proj.applyJob)
 	if err != nil {
 		return nil, fmt.Errorf("subscribe to projection schedule: %w", err)
 	}
 
-	go proj.schedule.Trigger(ctx)
+	go proj.schedule.Trigger(ctx, opts...)
 
 	return errs, nil
 }
 
 func (proj *PermissionProjector) applyJob(ctx projection.Job) error {
-	actors, err := proj.extractActorsFromJob(ctx)
+	actors, latest, err := proj.extractActorsFromJob(ctx)
 	if err != nil {
 		return fmt.Errorf("extract actors from job: %w", err)
 	}
 
 	for _, actorID := range actors {
 		if err := proj.permissions.Use(ctx, actorID, func(perms *Permissions) error {
-			if err := ctx.Apply(ctx, perms); err != nil {
+			if err := ctx.Apply(ctx, guardedPermissions{perms}); err != nil {
 				return err
 			}
 
@@ -76,17 +101,53 @@ func (proj *PermissionProjector) applyJob(ctx projection.Job) error {
 		}
 	}
 
+	if proj.snapshots != nil {
+		if err := proj.applied(ctx, actors, latest); err != nil {
+			return fmt.Errorf("snapshot permissions: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func (proj *PermissionProjector) extractActorsFromJob(ctx projection.Job) ([]uuid.UUID, error) {
+// guardedPermissions guards the permissions of an actor against the events of
+// other actors and roles, because a projection job may contain the events of
+// multiple actors (e.g. when actors are merged).
+type guardedPermissions struct {
+	*Permissions
+}
+
+// GuardProjection implements projection.Guard.
+func (perms guardedPermissions) GuardProjection(evt event.Event) bool {
+	id, name, _ := evt.Aggregate()
+	switch name {
+	case ActorAggregate:
+		return id == perms.ActorID
+	case RoleAggregate:
+		switch evt.Name() {
+		case RoleGiven, RoleRemoved:
+			actors, _ := evt.Data().([]uuid.UUID)
+			return slices.Contains(actors, perms.ActorID)
+		default:
+			return slices.Contains(perms.Roles, id)
+		}
+	}
+	return true
+}
+
+func (proj *PermissionProjector) extractActorsFromJob(ctx projection.Job) ([]uuid.UUID, stdtime.Time, error) {
 	events, errs, err := ctx.Events(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("extract events from job: %w", err)
+		return nil, stdtime.Time{}, fmt.Errorf("extract events from job: %w", err)
 	}
 
 	var out []uuid.UUID
+	var latest stdtime.Time
 	if err := streams.Walk(ctx, func(evt event.Event) error {
+		if evt.Time().After(latest) {
+			latest = evt.Time()
+		}
+
 		id, name, _ := evt.Aggregate()
 		switch name {
 		case ActorAggregate:
@@ -97,7 +158,7 @@ func (proj *PermissionProjector) extractActorsFromJob(ctx projection.Job) ([]uui
 				out = append(out, evt.Data().([]uuid.UUID)...)
 
 			// Slowest path. We need to fetch each role and extract its members.
-			case PermissionGranted, PermissionRevoked:
+			case PermissionGranted, PermissionRevoked, PermissionDenied, PermissionUndenied:
 				actors, err := proj.getActorsOfRole(ctx, pick.AggregateID(evt))
 				if err != nil {
 					return fmt.Errorf("get actors of role: %w [roleId=%v]", err, pick.AggregateID(evt))
@@ -107,10 +168,10 @@ func (proj *PermissionProjector) extractActorsFromJob(ctx projection.Job) ([]uui
 		}
 		return nil
 	}, events, errs); err != nil {
-		return out, err
+		return out, latest, err
 	}
 
-	return slice.Unique(out), nil
+	return slice.Unique(out), latest, nil
 }
 
 func (proj *PermissionProjector) getActorsOfRole(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) {
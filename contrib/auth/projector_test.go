@@ -89,3 +89,70 @@ func TestProjector(t *testing.T) {
 		t.Fatalf("admin should have permission to update the order")
 	}
 }
+
+func TestPermissionProjector_Snapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	repo := repository.New(store)
+	actors := auth.NewUUIDActorRepository(repo)
+	roles := auth.NewRoleRepository(repo)
+	snapshots := auth.InMemoryPermissionSnapshots()
+
+	proj := auth.NewPermissionProjector(auth.InMemoryPermissionRepository(), roles, bus, store, schedule.Debounce(20*time.Millisecond))
+	proj.Snapshots(snapshots, 0)
+
+	errs, err := proj.Run(ctx)
+	if err != nil {
+		t.Fatalf("run projector: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	order := aggregate.Ref{Name: "order", ID: uuid.New()}
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(order, "view")
+
+	if err := actors.Save(ctx, actor); err != nil {
+		t.Fatalf("save actor: %v", err)
+	}
+
+	<-time.After(200 * time.Millisecond)
+
+	snap, ok, err := snapshots.Latest(ctx)
+	if err != nil || !ok {
+		t.Fatalf("a snapshot should have been saved; got ok=%t, err=%v", ok, err)
+	}
+
+	if snap.Version != auth.PermissionSnapshotVersion || len(snap.Actors) != 1 || snap.Actors[0].ActorID != actor.AggregateID() {
+		t.Fatalf("unexpected snapshot: %#v", snap)
+	}
+
+	// a projector that starts from the snapshot does not need the event history
+	emptyBus := eventbus.New()
+	emptyStore := eventstore.WithBus(eventstore.New(), emptyBus)
+	permissions := auth.InMemoryPermissionRepository()
+
+	restored := auth.NewPermissionProjector(permissions, auth.NewRoleRepository(repository.New(emptyStore)), emptyBus, emptyStore)
+	restored.Snapshots(snapshots, time.Hour)
+
+	errs, err = restored.Run(ctx)
+	if err != nil {
+		t.Fatalf("run projector: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	perms, err := permissions.Fetch(ctx, actor.AggregateID())
+	if err != nil {
+		t.Fatalf("fetch permissions: %v", err)
+	}
+
+	if !perms.Allows("view", order) {
+		t.Fatalf("permissions should be restored from the snapshot")
+	}
+
+	if progress, _ := perms.Progress(); progress.IsZero() {
+		t.Fatalf("progress should be restored from the snapshot")
+	}
+}
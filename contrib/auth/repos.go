@@ -67,6 +67,8 @@ type mongoPermissions struct {
 	Roles                  []uuid.UUID               `bson:"roles"`
 	OfActor                map[string]map[string]int `bson:"ofActor"`
 	OfRoles                map[string]map[string]int `bson:"ofRoles"`
+	DeniedOfActor          map[string]map[string]int `bson:"deniedOfActor"`
+	DeniedOfRoles          map[string]map[string]int `bson:"deniedOfRoles"`
 }
 
 // MongoPermissionRepository returns a MongoDB repository for the permission read-models.
@@ -86,6 +88,9 @@ func MongoPermissionRepository(ctx context.Context, col *gomongo.Collection) (Pe
 				Roles:      perms.Roles,
 				OfActor:    perms.OfActor.withFlatKeys(),
 				OfRoles:    perms.OfRoles.withFlatKeys(),
+
+				DeniedOfActor: perms.DeniedOfActor.withFlatKeys(),
+				DeniedOfRoles: perms.DeniedOfRoles.withFlatKeys(),
 			}, nil
 		}),
 		mongo.ModelDecoder[*Permissions, uuid.UUID](func(res *gomongo.SingleResult, permsPtr **Permissions) error {
@@ -95,9 +100,12 @@ func MongoPermissionRepository(ctx context.Context, col *gomongo.Collection) (Pe
 			}
 
 			ofActor, ofRoles := make(Actions), make(Actions)
+			deniedOfActor, deniedOfRoles := make(Actions), make(Actions)
 
 			ofActor.unflatten(dto.OfActor)
 			ofRoles.unflatten(dto.OfRoles)
+			deniedOfActor.unflatten(dto.DeniedOfActor)
+			deniedOfRoles.unflatten(dto.DeniedOfRoles)
 
 			perms := *permsPtr
 
@@ -107,6 +115,9 @@ func MongoPermissionRepository(ctx context.Context, col *gomongo.Collection) (Pe
 				Roles:   dto.Roles,
 				OfActor: ofActor,
 				OfRoles: ofRoles,
+
+				DeniedOfActor: deniedOfActor,
+				DeniedOfRoles: deniedOfRoles,
 			}
 
 			return nil
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/internal/slice"
+)
+
+// ErrPermissionDenied is returned when none of the authorized actors has the
+// permission to perform a required action.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// PermissionError is returned by Requirements.Check if none of the authorized
+// actors has the permission to perform a required action.
+type PermissionError struct {
+	Actors []uuid.UUID
+	Action string
+	Ref    aggregate.Ref
+}
+
+// Error returns the error message.
+func (err *PermissionError) Error() string {
+	return fmt.Sprintf("%v: %q action on %s", ErrPermissionDenied, err.Action, err.Ref)
+}
+
+// Unwrap returns ErrPermissionDenied.
+func (err *PermissionError) Unwrap() error {
+	return ErrPermissionDenied
+}
+
+// ActionRequirer can be implemented by command payloads to declare the actions
+// that an actor must be allowed to perform on the aggregate of the command.
+//
+//	type FillStock struct { Quantity int }
+//
+//	func (FillStock) RequiredActions() []string { return []string{"stock.write"} }
+type ActionRequirer interface {
+	// RequiredActions returns the actions that are required to execute the command.
+	RequiredActions() []string
+}
+
+// Requirements declares the actions that are required to execute commands.
+// The required actions of a command are checked against the aggregate of the
+// command, so the authorized actor must be allowed to perform the actions on
+// that aggregate. Requirements can be declared per command, per aggregate, and
+// by command payloads that implement ActionRequirer.
+//
+//	reqs := auth.NewRequirements()
+//	reqs.Command("stock.fill", "stock.write")
+//	reqs.Aggregate("product", "product.access")
+//
+// The auth middleware (see goes/contrib/auth/http/middleware.Commands) uses
+// Requirements to automatically enforce the permissions of dispatched commands.
+type Requirements struct {
+	mux        sync.RWMutex
+	commands   map[string][]string
+	aggregates map[string][]string
+	onDecision []func(Decision)
+}
+
+// RequirementsOption is an option for Requirements.
+type RequirementsOption func(*Requirements)
+
+// OnDecision returns a RequirementsOption that adds a hook that is called for
+// every authorization decision that is made by Requirements.Check. Hooks are
+// called synchronously, so they should not block.
+//
+//	reqs := auth.NewRequirements(auth.OnDecision(func(d auth.Decision) {
+//		log.Printf("actor=%s action=%s ref=%s allowed=%t", d.ActorID, d.Action, d.Ref, d.Allowed)
+//	}))
+func OnDecision(fn func(Decision)) RequirementsOption {
+	return func(reqs *Requirements) {
+		reqs.onDecision = append(reqs.onDecision, fn)
+	}
+}
+
+// NewRequirements returns empty Requirements.
+func NewRequirements(opts ...RequirementsOption) *Requirements {
+	reqs := &Requirements{
+		commands:   make(map[string][]string),
+		aggregates: make(map[string][]string),
+	}
+	for _, opt := range opts {
+		opt(reqs)
+	}
+	return reqs
+}
+
+// Command declares the actions that are required to execute the command with
+// the given name.
+func (reqs *Requirements) Command(name string, actions ...string) {
+	reqs.mux.Lock()
+	defer reqs.mux.Unlock()
+	reqs.commands[name] = append(reqs.commands[name], actions...)
+}
+
+// Aggregate declares the actions that are required to execute any command of
+// the aggregate with the given name.
+func (reqs *Requirements) Aggregate(name string, actions ...string) {
+	reqs.mux.Lock()
+	defer reqs.mux.Unlock()
+	reqs.aggregates[name] = append(reqs.aggregates[name], actions...)
+}
+
+// Of returns the actions that are required to execute the given command.
+func (reqs *Requirements) Of(cmd command.Command) []string {
+	reqs.mux.RLock()
+	defer reqs.mux.RUnlock()
+
+	_, aggregateName := cmd.Aggregate().Split()
+
+	var out []string
+	out = append(out, reqs.aggregates[aggregateName]...)
+	out = append(out, reqs.commands[cmd.Name()]...)
+
+	if r, ok := cmd.Payload().(ActionRequirer); ok {
+		out = append(out, r.RequiredActions()...)
+	}
+
+	return slice.Unique(out)
+}
+
+// Check checks if the given actors are allowed to execute the given command.
+// Each required action must be allowed by at least one of the actors. If an
+// action is not allowed, Check returns a *PermissionError. Commands without
+// required actions are always allowed.
+func (reqs *Requirements) Check(ctx context.Context, perms PermissionFetcher, actors []uuid.UUID, cmd command.Command) error {
+	actions := reqs.Of(cmd)
+	if len(actions) == 0 {
+		return nil
+	}
+
+	fetched := make([]PermissionsDTO, 0, len(actors))
+	for _, actorID := range actors {
+		p, err := perms.Fetch(ctx, actorID)
+		if err != nil {
+			return fmt.Errorf("fetch permissions of actor %s: %w", actorID, err)
+		}
+		fetched = append(fetched, p)
+	}
+
+	ref := cmd.Aggregate()
+
+L:
+	for _, action := range actions {
+		for _, p := range fetched {
+			if reqs.allows(p, action, ref) {
+				continue L
+			}
+		}
+		return &PermissionError{Actors: actors, Action: action, Ref: ref}
+	}
+
+	return nil
+}
+
+func (reqs *Requirements) allows(perms PermissionsDTO, action string, ref aggregate.Ref) bool {
+	d := perms.Decide(action, ref)
+	for _, fn := range reqs.onDecision {
+		fn(d)
+	}
+	return d.Allowed
+}
@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/contrib/auth"
+)
+
+type fillStock struct{}
+
+func (fillStock) RequiredActions() []string { return []string{"stock.fill"} }
+
+func TestRequirements_Check(t *testing.T) {
+	productID := uuid.New()
+	ref := aggregate.Ref{Name: "product", ID: productID}
+
+	reqs := auth.NewRequirements()
+	reqs.Aggregate("product", "product.access")
+	reqs.Command("stock.fill", "stock.write")
+
+	cmd := command.New("stock.fill", fillStock{}, command.Aggregate("product", productID)).Any()
+
+	if actions := reqs.Of(cmd); len(actions) != 3 {
+		t.Fatalf("command should require %d actions; got %v", 3, actions)
+	}
+
+	actor := auth.NewUUIDActor(uuid.New())
+	actor.Grant(ref, "product.access", "stock.write")
+
+	perms := auth.PermissionFetcherFunc(func(_ context.Context, actorID uuid.UUID) (auth.PermissionsDTO, error) {
+		p := auth.PermissionsOf(actorID)
+		if actorID == actor.AggregateID() {
+			p.OfActor = actor.Actions
+		}
+		return p.PermissionsDTO, nil
+	})
+
+	err := reqs.Check(context.Background(), perms, []uuid.UUID{actor.AggregateID()}, cmd)
+
+	var perr *auth.PermissionError
+	if !errors.As(err, &perr) || !errors.Is(err, auth.ErrPermissionDenied) {
+		t.Fatalf("Check should fail with a *PermissionError; got %q", err)
+	}
+
+	if perr.Action != "stock.fill" || perr.Ref != ref {
+		t.Fatalf("Check should fail because of the %q action; got %q", "stock.fill", perr.Action)
+	}
+
+	actor.Grant(ref, "stock.fill")
+
+	if err := reqs.Check(context.Background(), perms, []uuid.UUID{actor.AggregateID()}, cmd); err != nil {
+		t.Fatalf("Check failed with %q", err)
+	}
+
+	if err := reqs.Check(context.Background(), perms, nil, command.New("foo", fillStock{}).Any()); !errors.Is(err, auth.ErrPermissionDenied) {
+		t.Fatalf("Check should fail with %q for unauthorized requests; got %q", auth.ErrPermissionDenied, err)
+	}
+
+	if err := reqs.Check(context.Background(), perms, nil, command.New("foo", struct{}{}).Any()); err != nil {
+		t.Fatalf("commands without requirements should be allowed; got %q", err)
+	}
+}
@@ -35,6 +35,7 @@ type Role struct {
 
 	name    string
 	members []uuid.UUID
+	denied  Actions
 	Actions
 }
 
@@ -42,12 +43,15 @@ type Role struct {
 func NewRole(id uuid.UUID) *Role {
 	r := &Role{
 		Base:    aggregate.New(RoleAggregate, id),
+		denied:  make(Actions),
 		Actions: make(Actions),
 	}
 
 	event.ApplyWith(r, r.identify, RoleIdentified)
 	event.ApplyWith(r, r.Actions.granted, PermissionGranted)
 	event.ApplyWith(r, r.Actions.revoked, PermissionRevoked)
+	event.ApplyWith(r, r.denied.denied, PermissionDenied)
+	event.ApplyWith(r, r.denied.undenied, PermissionUndenied)
 	event.ApplyWith(r, r.add, RoleGiven)
 	event.ApplyWith(r, r.remove, RoleRemoved)
 
@@ -74,15 +78,83 @@ func (r *Role) identify(evt event.Of[RoleIdentifiedData]) {
 	r.name = string(evt.Data())
 }
 
-// Allows returns whether the role has the permission to perform the given action.
+// Allows returns whether the role has the permission to perform the given
+// action. An action that is denied to the role is never allowed, even if it was
+// granted (deny wins).
 func (r *Role) Allows(action string, ref aggregate.Ref) bool {
-	return r.allows(action, ref)
+	return !r.denied.allows(action, ref) && r.allows(action, ref)
 }
 
 // Disallows returns whether the role does not have the permission to perform
 // the given action.
 func (r *Role) Disallows(action string, ref aggregate.Ref) bool {
-	return !r.allows(action, ref)
+	return !r.Allows(action, ref)
+}
+
+// Denies returns whether the given action is explicitly denied to the role.
+func (r *Role) Denies(action string, ref aggregate.Ref) bool {
+	return r.denied.allows(action, ref)
+}
+
+// Denials returns the actions that are explicitly denied to the role.
+func (r *Role) Denials() Actions {
+	return r.denied
+}
+
+// Deny explicitly denies the members of the role to perform the given actions
+// on the given aggregate. Denied actions override any permissions that are
+// granted to the members, either directly or through other roles (deny wins).
+// Like Grant, Deny supports wildcards in the aggregate reference and actions.
+//
+// Example – "admin" role that is allowed everything except for payroll aggregates:
+//	role.Grant(aggregate.Ref{Name: "*", ID: uuid.Nil}, "*")
+//	role.Deny(aggregate.Ref{Name: "payroll", ID: uuid.Nil}, "*")
+func (r *Role) Deny(ref aggregate.Ref, actions ...string) error {
+	if err := r.checkName(); err != nil {
+		return err
+	}
+
+	if err := validateRef(ref); err != nil {
+		return err
+	}
+
+	actions = r.denied.missingActions(ref, actions)
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	aggregate.Next(r, PermissionDenied, PermissionDeniedData{
+		Aggregate: ref,
+		Actions:   actions,
+	})
+
+	return nil
+}
+
+// Undeny removes the deny rules for the given actions on the given aggregate
+// from the role.
+func (r *Role) Undeny(ref aggregate.Ref, actions ...string) error {
+	if err := r.checkName(); err != nil {
+		return err
+	}
+
+	if err := validateRef(ref); err != nil {
+		return err
+	}
+
+	actions = r.denied.grantedActions(ref, actions)
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	aggregate.Next(r, PermissionUndenied, PermissionUndeniedData{
+		Aggregate: ref,
+		Actions:   actions,
+	})
+
+	return nil
 }
 
 // Grant grants the role the permission to perform the given actions on the given aggregate.
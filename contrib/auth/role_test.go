@@ -179,3 +179,39 @@ func TestRole_Add_Remove(t *testing.T) {
 
 	test.Change(t, r, auth.RoleRemoved, test.EventData(actors))
 }
+
+func TestRole_Deny(t *testing.T) {
+	role := auth.NewRole(uuid.New())
+	ref := aggregate.Ref{Name: "foo", ID: uuid.New()}
+
+	if err := role.Deny(ref, "view"); !errors.Is(err, auth.ErrMissingRoleName) {
+		t.Fatalf("Deny() should fail with %q; got %q", auth.ErrMissingRoleName, err)
+	}
+
+	role.Identify("admin")
+	role.Grant(aggregate.Ref{Name: "*", ID: uuid.Nil}, "*")
+
+	if err := role.Deny(ref, "view"); err != nil {
+		t.Fatalf("Deny() failed with %q", err)
+	}
+
+	test.Change(t, role, auth.PermissionDenied, test.EventData(auth.PermissionDeniedData{Aggregate: ref, Actions: []string{"view"}}))
+
+	if role.Allows("view", ref) || !role.Denies("view", ref) {
+		t.Fatalf("role should not allow a denied action")
+	}
+
+	if !role.Allows("update", ref) {
+		t.Fatalf("role should allow an action that is not denied")
+	}
+
+	if err := role.Undeny(ref, "view"); err != nil {
+		t.Fatalf("Undeny() failed with %q", err)
+	}
+
+	test.Change(t, role, auth.PermissionUndenied, test.EventData(auth.PermissionUndeniedData{Aggregate: ref, Actions: []string{"view"}}))
+
+	if !role.Allows("view", ref) {
+		t.Fatalf("role should allow the action after the deny rule was removed")
+	}
+}
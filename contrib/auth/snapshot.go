@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	stdtime "time"
+
+	"github.com/google/uuid"
+)
+
+// PermissionSnapshotVersion is the version of the PermissionSnapshot format.
+// Snapshots with a different version are ignored by the PermissionProjector.
+const PermissionSnapshotVersion = 1
+
+// PermissionSnapshot is a snapshot of the permissions that have been projected
+// by a PermissionProjector. A PermissionProjector that starts from a snapshot
+// only needs to replay the events that were published after the snapshot was
+// taken, instead of the whole grant/revoke history.
+//
+// PermissionSnapshot is JSON-serializable.
+type PermissionSnapshot struct {
+	// Version is the version of the snapshot format (see PermissionSnapshotVersion).
+	Version int `json:"version"`
+
+	// Time is the time of the latest event that has been applied to the
+	// permissions in the snapshot.
+	Time stdtime.Time `json:"time"`
+
+	// Actors are the permissions of the actors.
+	Actors []ActorPermissions `json:"actors"`
+}
+
+// ActorPermissions are the projected permissions of a single actor within a
+// PermissionSnapshot.
+type ActorPermissions struct {
+	ActorID       uuid.UUID                 `json:"actorId"`
+	Roles         []uuid.UUID               `json:"roles"`
+	OfActor       map[string]map[string]int `json:"ofActor"`
+	OfRoles       map[string]map[string]int `json:"ofRoles"`
+	DeniedOfActor map[string]map[string]int `json:"deniedOfActor"`
+	DeniedOfRoles map[string]map[string]int `json:"deniedOfRoles"`
+	Progress      stdtime.Time              `json:"progress"`
+	LastEvents    []uuid.UUID               `json:"lastEvents"`
+}
+
+// PermissionSnapshotStore persists snapshots of projected permissions.
+type PermissionSnapshotStore interface {
+	// Latest returns the latest snapshot. If there is no snapshot, Latest
+	// returns false.
+	Latest(context.Context) (PermissionSnapshot, bool, error)
+
+	// Save saves a snapshot.
+	Save(context.Context, PermissionSnapshot) error
+}
+
+// InMemoryPermissionSnapshots returns an in-memory PermissionSnapshotStore.
+func InMemoryPermissionSnapshots() PermissionSnapshotStore {
+	return &memorySnapshots{}
+}
+
+type memorySnapshots struct {
+	mux    sync.RWMutex
+	latest *PermissionSnapshot
+}
+
+func (s *memorySnapshots) Latest(context.Context) (PermissionSnapshot, bool, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	if s.latest == nil {
+		return PermissionSnapshot{}, false, nil
+	}
+	return *s.latest, true, nil
+}
+
+func (s *memorySnapshots) Save(_ context.Context, snap PermissionSnapshot) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.latest = &snap
+	return nil
+}
+
+func snapshotPermissions(perms *Permissions) ActorPermissions {
+	progress, ids := perms.Progress()
+	return ActorPermissions{
+		ActorID:       perms.ActorID,
+		Roles:         append([]uuid.UUID(nil), perms.Roles...),
+		OfActor:       perms.OfActor.withFlatKeys(),
+		OfRoles:       perms.OfRoles.withFlatKeys(),
+		DeniedOfActor: perms.DeniedOfActor.withFlatKeys(),
+		DeniedOfRoles: perms.DeniedOfRoles.withFlatKeys(),
+		Progress:      progress,
+		LastEvents:    append([]uuid.UUID(nil), ids...),
+	}
+}
+
+func (snap ActorPermissions) restore(perms *Permissions) {
+	ofActor, ofRoles := make(Actions), make(Actions)
+	deniedOfActor, deniedOfRoles := make(Actions), make(Actions)
+	ofActor.unflatten(snap.OfActor)
+	ofRoles.unflatten(snap.OfRoles)
+	deniedOfActor.unflatten(snap.DeniedOfActor)
+	deniedOfRoles.unflatten(snap.DeniedOfRoles)
+
+	perms.PermissionsDTO = PermissionsDTO{
+		ActorID:       snap.ActorID,
+		Roles:         snap.Roles,
+		OfActor:       ofActor,
+		OfRoles:       ofRoles,
+		DeniedOfActor: deniedOfActor,
+		DeniedOfRoles: deniedOfRoles,
+	}
+	perms.SetProgress(snap.Progress, snap.LastEvents...)
+}
+
+type snapshotConfig struct {
+	store    PermissionSnapshotStore
+	interval stdtime.Duration
+
+	mux      sync.Mutex
+	actors   map[uuid.UUID]struct{}
+	time     stdtime.Time
+	lastSave stdtime.Time
+}
+
+// Snapshots configures the projector to persist snapshots of the projected
+// permissions into the given store, at most once per interval. On startup, the
+// projector restores the permissions from the latest snapshot and only replays
+// the events that were published after the snapshot was taken. Snapshots must
+// be configured before the projector is run.
+//
+//	proj := auth.NewPermissionProjector(perms, roles, bus, store)
+//	proj.Snapshots(auth.InMemoryPermissionSnapshots(), time.Minute)
+//	errs, err := proj.Run(context.TODO())
+func (proj *PermissionProjector) Snapshots(store PermissionSnapshotStore, interval stdtime.Duration) {
+	proj.snapshots = &snapshotConfig{
+		store:    store,
+		interval: interval,
+		actors:   make(map[uuid.UUID]struct{}),
+	}
+}
+
+// restoreSnapshot restores the permissions from the latest snapshot and returns
+// the time of the snapshot.
+func (proj *PermissionProjector) restoreSnapshot(ctx context.Context) (stdtime.Time, error) {
+	snap, ok, err := proj.snapshots.store.Latest(ctx)
+	if err != nil {
+		return stdtime.Time{}, fmt.Errorf("load snapshot: %w", err)
+	}
+
+	if !ok || snap.Version != PermissionSnapshotVersion {
+		return stdtime.Time{}, nil
+	}
+
+	for _, actor := range snap.Actors {
+		actor := actor
+		if err := proj.permissions.Use(ctx, actor.ActorID, func(perms *Permissions) error {
+			actor.restore(perms)
+			return nil
+		}); err != nil {
+			return stdtime.Time{}, fmt.Errorf("restore permissions: %w [actor=%v]", err, actor.ActorID)
+		}
+		proj.snapshots.actors[actor.ActorID] = struct{}{}
+	}
+
+	proj.snapshots.time = snap.Time
+	proj.snapshots.lastSave = stdtime.Now()
+
+	return snap.Time, nil
+}
+
+// applied records the actors and the time of the latest event of an applied
+// job and saves a snapshot if the snapshot interval has elapsed.
+func (proj *PermissionProjector) applied(ctx context.Context, actors []uuid.UUID, latest stdtime.Time) error {
+	cfg := proj.snapshots
+
+	cfg.mux.Lock()
+	defer cfg.mux.Unlock()
+
+	for _, actorID := range actors {
+		cfg.actors[actorID] = struct{}{}
+	}
+
+	if latest.After(cfg.time) {
+		cfg.time = latest
+	}
+
+	if stdtime.Since(cfg.lastSave) < cfg.interval {
+		return nil
+	}
+
+	snap := PermissionSnapshot{
+		Version: PermissionSnapshotVersion,
+		Time:    cfg.time,
+		Actors:  make([]ActorPermissions, 0, len(cfg.actors)),
+	}
+
+	for actorID := range cfg.actors {
+		perms, err := proj.permissions.Fetch(ctx, actorID)
+		if err != nil {
+			return fmt.Errorf("fetch permissions: %w [actor=%v]", err, actorID)
+		}
+		snap.Actors = append(snap.Actors, snapshotPermissions(perms))
+	}
+
+	if err := cfg.store.Save(ctx, snap); err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+
+	cfg.lastSave = stdtime.Now()
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// CatalogAggregate is the name of the Catalog aggregate.
+const CatalogAggregate = "goes.contrib.contracts.catalog"
+
+// Published is the event that is raised when a service publishes its
+// contracts.
+const Published = "goes.contrib.contracts.published"
+
+// ErrEmptyService is returned when trying to publish contracts without a
+// service name.
+var ErrEmptyService = errors.New("empty service name")
+
+// CatalogID is the aggregate id of the catalog that is used by
+// NewCatalogRegistry.
+var CatalogID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(CatalogAggregate))
+
+// PublishedData is the event data for Published.
+type PublishedData struct {
+	Service   string
+	Contracts []Contract
+}
+
+// RegisterEvents registers the events of the Catalog aggregate into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[PublishedData](r, Published)
+}
+
+// Catalog is an aggregate that keeps the published contracts of all services.
+// The history of the aggregate is the audit log of the contracts.
+type Catalog struct {
+	*aggregate.Base
+
+	services map[string][]Contract
+}
+
+// NewCatalog returns the catalog with the given id.
+func NewCatalog(id uuid.UUID) *Catalog {
+	c := &Catalog{
+		Base:     aggregate.New(CatalogAggregate, id),
+		services: make(map[string][]Contract),
+	}
+
+	event.ApplyWith(c, c.published, Published)
+
+	return c
+}
+
+// Publish replaces the contracts of the given service. Publish does not raise
+// an event if the contracts did not change.
+func (c *Catalog) Publish(service string, contracts ...Contract) error {
+	if service == "" {
+		return ErrEmptyService
+	}
+
+	contracts = append([]Contract(nil), contracts...)
+	for i := range contracts {
+		contracts[i].Service = service
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Event < contracts[j].Event
+	})
+
+	if cmp.Equal(c.services[service], contracts) || (len(c.services[service]) == 0 && len(contracts) == 0) {
+		return nil
+	}
+
+	aggregate.Next(c, Published, PublishedData{
+		Service:   service,
+		Contracts: contracts,
+	})
+
+	return nil
+}
+
+func (c *Catalog) published(evt event.Of[PublishedData]) {
+	data := evt.Data()
+	if len(data.Contracts) == 0 {
+		delete(c.services, data.Service)
+		return
+	}
+	c.services[data.Service] = data.Contracts
+}
+
+// Services returns the names of the services that published contracts.
+func (c *Catalog) Services() []string {
+	out := make([]string, 0, len(c.services))
+	for service := range c.services {
+		out = append(out, service)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Contracts returns the published contracts of the given events. If no names
+// are provided, all contracts are returned.
+func (c *Catalog) Contracts(names ...string) []Contract {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var out []Contract
+	for _, service := range c.Services() {
+		for _, contract := range c.services[service] {
+			if len(names) == 0 || wanted[contract.Event] {
+				out = append(out, contract)
+			}
+		}
+	}
+	return out
+}
+
+// NewCatalogRegistry returns a Registry that stores the contracts in the
+// Catalog with the id CatalogID, using the given aggregate repository. The
+// events of the catalog must be registered using RegisterEvents.
+func NewCatalogRegistry(repo aggregate.Repository) Registry {
+	return &catalogRegistry{repo: repo}
+}
+
+type catalogRegistry struct {
+	repo aggregate.Repository
+}
+
+func (r *catalogRegistry) Publish(ctx context.Context, service string, contracts ...Contract) error {
+	catalog := NewCatalog(CatalogID)
+	return r.repo.Use(ctx, catalog, func() error {
+		return catalog.Publish(service, contracts...)
+	})
+}
+
+func (r *catalogRegistry) Contracts(ctx context.Context, names ...string) ([]Contract, error) {
+	catalog := NewCatalog(CatalogID)
+	if err := r.repo.Fetch(ctx, catalog); err != nil {
+		return nil, fmt.Errorf("fetch catalog: %w", err)
+	}
+	return catalog.Contracts(names...), nil
+}
@@ -0,0 +1,198 @@
+// Package contracts provides a central registry of event contracts. Services
+// publish the names and data schemas of the events they produce, and consumers
+// verify at startup that the events they subscribe to still exist and that
+// their data is compatible with the types the consumer decodes it into.
+//
+// The registry can be a goes-managed Catalog aggregate (see NewCatalogRegistry)
+// that is exposed to other services over HTTP (see NewHandler and NewClient):
+//
+//	// producer
+//	published, err := contracts.Of(eventReg, "billing", billing.Events[:]...)
+//	err = registry.Publish(ctx, "billing", published...)
+//
+//	// consumer
+//	err := contracts.Verify(ctx, registry, eventReg, []string{"billing.invoice_paid"})
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/projection/export"
+)
+
+// Contract is the contract of an event that is published by a service.
+type Contract struct {
+	// Event is the name of the event.
+	Event string `json:"event"`
+
+	// Service is the name of the service that publishes the event.
+	Service string `json:"service"`
+
+	// Data is the schema of the event data, derived from the data type that
+	// is registered for the event.
+	Data export.Field `json:"data"`
+}
+
+// Registry is a central registry of event contracts.
+type Registry interface {
+	// Publish replaces the contracts of the given service. Events of the
+	// service that are not in contracts are withdrawn.
+	Publish(ctx context.Context, service string, contracts ...Contract) error
+
+	// Contracts returns the published contracts of the given events. If no
+	// names are provided, all contracts are returned.
+	Contracts(ctx context.Context, names ...string) ([]Contract, error)
+}
+
+// Of returns the contracts of the given events of a service. The data schemas
+// are derived from the data types that are registered in reg.
+func Of(reg *codec.Registry, service string, names ...string) ([]Contract, error) {
+	out := make([]Contract, 0, len(names))
+	for _, name := range names {
+		schema, err := export.SchemaOf(reg, name)
+		if err != nil {
+			return out, fmt.Errorf("derive schema of %q event: %w", name, err)
+		}
+
+		c := Contract{Event: name, Service: service}
+		for _, f := range schema.Fields {
+			if f.Name == "data" {
+				c.Data = f
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Incompatibilities returns the reasons why data of the published contract
+// cannot be decoded into data of the expected contract. The published data
+// may contain fields that are not expected; every expected field must be
+// published with the same type. Fields of type JSON are compatible with any
+// type.
+func Incompatibilities(published, expected Contract) []string {
+	return diffField("data", published.Data, expected.Data)
+}
+
+func diffField(path string, published, expected export.Field) []string {
+	if published.Type == export.JSON || expected.Type == export.JSON {
+		return nil
+	}
+
+	if published.Type != expected.Type {
+		return []string{fmt.Sprintf("%s: published as %s, expected %s", path, published.Type, expected.Type)}
+	}
+
+	if published.Repeated != expected.Repeated {
+		return []string{fmt.Sprintf("%s: published as %s, expected %s", path, fieldMode(published), fieldMode(expected))}
+	}
+
+	var out []string
+	for _, ef := range expected.Fields {
+		pf, ok := findField(published.Fields, ef.Name)
+		if !ok {
+			out = append(out, fmt.Sprintf("%s.%s: not published", path, ef.Name))
+			continue
+		}
+		out = append(out, diffField(path+"."+ef.Name, pf, ef)...)
+	}
+
+	return out
+}
+
+func fieldMode(f export.Field) string {
+	if f.Repeated {
+		return "list"
+	}
+	return "single value"
+}
+
+func findField(fields []export.Field, name string) (export.Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return export.Field{}, false
+}
+
+// Error is returned by Verify if the contracts of the subscribed events are
+// violated.
+type Error struct {
+	// Missing are the subscribed events that no service publishes.
+	Missing []string
+
+	// Incompatible are the subscribed events whose published data cannot be
+	// decoded into the registered data types, mapped to the reasons.
+	Incompatible map[string][]string
+}
+
+// Error returns a description of all contract violations.
+func (err *Error) Error() string {
+	var lines []string
+	for _, name := range err.Missing {
+		lines = append(lines, fmt.Sprintf("%q event is not published by any service", name))
+	}
+
+	names := make([]string, 0, len(err.Incompatible))
+	for name := range err.Incompatible {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, reason := range err.Incompatible[name] {
+			lines = append(lines, fmt.Sprintf("%q event: %s", name, reason))
+		}
+	}
+
+	return fmt.Sprintf("event contract violated:\n\t%s", strings.Join(lines, "\n\t"))
+}
+
+// Verify verifies that every subscribed event is published by a service, and
+// that the published data is compatible with the data type that is registered
+// for the event in reg. If the contract is violated, Verify returns an *Error.
+func Verify(ctx context.Context, registry Registry, reg *codec.Registry, subscribed []string) error {
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	expected, err := Of(reg, "", subscribed...)
+	if err != nil {
+		return err
+	}
+
+	published, err := registry.Contracts(ctx, subscribed...)
+	if err != nil {
+		return fmt.Errorf("fetch contracts: %w", err)
+	}
+
+	byEvent := make(map[string][]Contract)
+	for _, c := range published {
+		byEvent[c.Event] = append(byEvent[c.Event], c)
+	}
+
+	verr := &Error{Incompatible: make(map[string][]string)}
+	for _, exp := range expected {
+		contracts := byEvent[exp.Event]
+		if len(contracts) == 0 {
+			verr.Missing = append(verr.Missing, exp.Event)
+			continue
+		}
+
+		for _, c := range contracts {
+			for _, reason := range Incompatibilities(c, exp) {
+				verr.Incompatible[exp.Event] = append(verr.Incompatible[exp.Event], fmt.Sprintf("%s (%s)", reason, c.Service))
+			}
+		}
+	}
+
+	if len(verr.Missing) > 0 || len(verr.Incompatible) > 0 {
+		return verr
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package contracts_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/contrib/contracts"
+	"github.com/modernice/goes/event/eventstore"
+)
+
+type invoicePaidV1 struct {
+	InvoiceID string `json:"invoiceId"`
+	Amount    int    `json:"amount"`
+}
+
+type invoicePaidV2 struct {
+	InvoiceID string  `json:"invoiceId"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+type invoicePaidConsumer struct {
+	InvoiceID string `json:"invoiceId"`
+	Amount    int    `json:"amount"`
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	registry := newRegistry(t)
+
+	producer := codec.New()
+	codec.Register[invoicePaidV1](producer, "billing.invoice_paid")
+	codec.Register[string](producer, "billing.invoice_voided")
+	publish(t, registry, producer, "billing", "billing.invoice_paid", "billing.invoice_voided")
+
+	consumer := codec.New()
+	codec.Register[invoicePaidConsumer](consumer, "billing.invoice_paid")
+	codec.Register[string](consumer, "billing.invoice_voided")
+
+	if err := contracts.Verify(ctx, registry, consumer, []string{"billing.invoice_paid", "billing.invoice_voided"}); err != nil {
+		t.Fatalf("Verify failed with %q", err)
+	}
+
+	// the producer changes the type of a field and withdraws an event
+	producer = codec.New()
+	codec.Register[invoicePaidV2](producer, "billing.invoice_paid")
+	publish(t, registry, producer, "billing", "billing.invoice_paid")
+
+	err := contracts.Verify(ctx, registry, consumer, []string{"billing.invoice_paid", "billing.invoice_voided"})
+
+	var verr *contracts.Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Verify should fail with %T; got %q", verr, err)
+	}
+
+	if len(verr.Missing) != 1 || verr.Missing[0] != "billing.invoice_voided" {
+		t.Fatalf("%q event should be missing; got %v", "billing.invoice_voided", verr.Missing)
+	}
+
+	reasons := verr.Incompatible["billing.invoice_paid"]
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "data.amount") {
+		t.Fatalf("%q event should be incompatible because of the amount field; got %v", "billing.invoice_paid", reasons)
+	}
+}
+
+func TestIncompatibilities(t *testing.T) {
+	reg := codec.New()
+	codec.Register[invoicePaidV1](reg, "v1")
+	codec.Register[invoicePaidV2](reg, "v2")
+	codec.Register[struct{ InvoiceID []string `json:"invoiceId"` }](reg, "list")
+	codec.Register[map[string]any](reg, "json")
+
+	of := func(name string) contracts.Contract {
+		c, err := contracts.Of(reg, "billing", name)
+		if err != nil {
+			t.Fatalf("Of failed with %q", err)
+		}
+		return c[0]
+	}
+
+	tests := []struct {
+		published, expected string
+		want                int
+	}{
+		{published: "v1", expected: "v1", want: 0},
+		{published: "v2", expected: "v1", want: 1},
+		{published: "v1", expected: "v2", want: 2},
+		{published: "list", expected: "v1", want: 2},
+		{published: "json", expected: "v1", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := contracts.Incompatibilities(of(tt.published), of(tt.expected)); len(got) != tt.want {
+			t.Errorf("%s -> %s should have %d incompatibilities; got %v", tt.published, tt.expected, tt.want, got)
+		}
+	}
+}
+
+func TestCatalog_Publish(t *testing.T) {
+	catalog := contracts.NewCatalog(contracts.CatalogID)
+
+	if err := catalog.Publish(""); !errors.Is(err, contracts.ErrEmptyService) {
+		t.Fatalf("Publish should fail with %q; got %q", contracts.ErrEmptyService, err)
+	}
+
+	published := []contracts.Contract{{Event: "foo"}, {Event: "bar"}}
+	if err := catalog.Publish("foo", published...); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+
+	if err := catalog.Publish("foo", published...); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+
+	if changes := catalog.AggregateChanges(); len(changes) != 1 {
+		t.Fatalf("publishing unchanged contracts should not raise an event; got %d events", len(changes))
+	}
+
+	if got := catalog.Contracts("bar"); len(got) != 1 || got[0].Service != "foo" {
+		t.Fatalf("Contracts should return the %q contract of the %q service; got %v", "bar", "foo", got)
+	}
+
+	if err := catalog.Publish("foo"); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+
+	if services := catalog.Services(); len(services) != 0 {
+		t.Fatalf("service without contracts should be removed; got %v", services)
+	}
+}
+
+// newRegistry returns a Client for a catalog registry that is exposed over
+// HTTP.
+func newRegistry(t *testing.T) contracts.Registry {
+	repo := repository.New(eventstore.New())
+	srv := httptest.NewServer(contracts.NewHandler(contracts.NewCatalogRegistry(repo)))
+	t.Cleanup(srv.Close)
+	return contracts.NewClient(srv.URL)
+}
+
+func publish(t *testing.T, registry contracts.Registry, reg *codec.Registry, service string, names ...string) {
+	t.Helper()
+
+	published, err := contracts.Of(reg, service, names...)
+	if err != nil {
+		t.Fatalf("Of failed with %q", err)
+	}
+
+	if err := registry.Publish(context.Background(), service, published...); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+}
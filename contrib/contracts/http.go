@@ -0,0 +1,168 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewHandler returns an http.Handler that exposes the registry to other
+// services:
+//
+//	PUT /services/{service}     publishes the contracts in the JSON body
+//	GET /contracts?event={name} returns the contracts of the given events
+func NewHandler(registry Registry) http.Handler {
+	return &handler{registry: registry}
+}
+
+type handler struct {
+	registry Registry
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/services/"):
+		h.publish(w, r, strings.TrimPrefix(r.URL.Path, "/services/"))
+	case r.Method == http.MethodGet && r.URL.Path == "/contracts":
+		h.contracts(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *handler) publish(w http.ResponseWriter, r *http.Request, service string) {
+	if service == "" || strings.Contains(service, "/") {
+		http.Error(w, "invalid service name", http.StatusBadRequest)
+		return
+	}
+
+	var contracts []Contract
+	if err := json.NewDecoder(r.Body).Decode(&contracts); err != nil {
+		http.Error(w, fmt.Sprintf("decode contracts: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.registry.Publish(r.Context(), service, contracts...); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) contracts(w http.ResponseWriter, r *http.Request) {
+	contracts, err := h.registry.Contracts(r.Context(), r.URL.Query()["event"]...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if contracts == nil {
+		contracts = []Contract{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contracts)
+}
+
+// ClientOption is an option for a Client.
+type ClientOption func(*Client)
+
+// HTTPClient returns a ClientOption that specifies the HTTP client that is used
+// to send requests to the registry. Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// Client is a Registry that talks to a registry that is exposed using
+// NewHandler.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+var _ Registry = (*Client)(nil)
+
+// NewClient returns a Client for the registry at the given URL.
+func NewClient(url string, opts ...ClientOption) *Client {
+	c := &Client{
+		url:    strings.TrimRight(url, "/"),
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Publish replaces the contracts of the given service.
+func (c *Client) Publish(ctx context.Context, service string, contracts ...Contract) error {
+	if contracts == nil {
+		contracts = []Contract{}
+	}
+
+	b, err := json.Marshal(contracts)
+	if err != nil {
+		return fmt.Errorf("encode contracts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.url+"/services/"+url.PathEscape(service), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return nil
+}
+
+// Contracts returns the published contracts of the given events. If no names
+// are provided, all contracts are returned.
+func (c *Client) Contracts(ctx context.Context, names ...string) ([]Contract, error) {
+	query := url.Values{"event": names}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/contracts?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var contracts []Contract
+	if err := json.NewDecoder(res.Body).Decode(&contracts); err != nil {
+		return nil, fmt.Errorf("decode contracts: %w", err)
+	}
+
+	return contracts, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return nil, fmt.Errorf("contract registry: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return res, nil
+}
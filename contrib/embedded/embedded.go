@@ -0,0 +1,171 @@
+// Package embedded wires a complete goes stack that runs without any external
+// services: an embedded BadgerDB event store, an in-memory event bus, and an
+// in-process command bus. Desktop and edge applications can use it to run
+// aggregates, commands, and projections offline.
+//
+//	stack := embedded.New("/var/lib/myapp/events")
+//	defer stack.Close()
+//
+//	myapp.RegisterEvents(stack.Events)
+//	myapp.RegisterCommands(stack.Commands)
+//
+//	errs, err := stack.Run(ctx)
+//
+// A Syncer synchronizes the stack with a remote event store when the
+// application is online:
+//
+//	syncer := stack.Syncer(remoteStore, embedded.Subscribe("todo.list"))
+//	syncErrs := syncer.Run(ctx, time.Minute)
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/backend/badger"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/cmdbus"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+)
+
+// Stack is a goes stack that runs in-process.
+type Stack struct {
+	// Events is the registry of events. Register the events of the
+	// application before the stack is used.
+	Events *codec.Registry
+
+	// Commands is the registry of commands. Register the commands of the
+	// application before the stack is used.
+	Commands *codec.Registry
+
+	// EventBus is the in-memory event bus.
+	EventBus event.Bus
+
+	// LocalStore is the embedded event store. Events that are inserted
+	// directly into LocalStore are not published over EventBus.
+	LocalStore event.Store
+
+	// EventStore is LocalStore decorated to publish inserted events over
+	// EventBus.
+	EventStore event.Store
+
+	// CommandBus is the in-process command bus.
+	CommandBus *cmdbus.Bus[int]
+
+	// Repository is the aggregate repository that uses EventStore.
+	Repository *repository.Repository
+}
+
+// Option is an option for New.
+type Option func(*config)
+
+type config struct {
+	inMemory    bool
+	store       func(codec.Encoding) event.Store
+	badgerOpts  []badger.EventStoreOption
+	commandOpts []cmdbus.Option
+	repoOpts    []repository.Option
+}
+
+// InMemory returns an Option that keeps the events in memory instead of on
+// disk, e.g., for tests.
+func InMemory() Option {
+	return func(cfg *config) {
+		cfg.inMemory = true
+	}
+}
+
+// Store returns an Option that replaces the embedded BadgerDB event store with
+// the event store returned by newStore, e.g., a file event store. newStore is
+// called with the event registry of the stack.
+func Store(newStore func(codec.Encoding) event.Store) Option {
+	return func(cfg *config) {
+		cfg.store = newStore
+	}
+}
+
+// BadgerOptions returns an Option that adds options to the embedded BadgerDB
+// event store.
+func BadgerOptions(opts ...badger.EventStoreOption) Option {
+	return func(cfg *config) {
+		cfg.badgerOpts = append(cfg.badgerOpts, opts...)
+	}
+}
+
+// CommandBusOptions returns an Option that adds options to the command bus.
+func CommandBusOptions(opts ...cmdbus.Option) Option {
+	return func(cfg *config) {
+		cfg.commandOpts = append(cfg.commandOpts, opts...)
+	}
+}
+
+// RepositoryOptions returns an Option that adds options to the aggregate
+// repository.
+func RepositoryOptions(opts ...repository.Option) Option {
+	return func(cfg *config) {
+		cfg.repoOpts = append(cfg.repoOpts, opts...)
+	}
+}
+
+// New returns a new Stack that stores its events in dir. The directory is
+// created on first use. dir is ignored if the InMemory or Store option is
+// provided.
+func New(dir string, opts ...Option) *Stack {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &Stack{
+		Events:   event.NewRegistry(),
+		Commands: command.NewRegistry(),
+		EventBus: eventbus.New(),
+	}
+
+	cmdbus.RegisterEvents(s.Events)
+
+	switch {
+	case cfg.store != nil:
+		s.LocalStore = cfg.store(s.Events)
+	case cfg.inMemory:
+		s.LocalStore = badger.NewEventStore(s.Events, append([]badger.EventStoreOption{badger.InMemory()}, cfg.badgerOpts...)...)
+	default:
+		s.LocalStore = badger.NewEventStore(s.Events, append([]badger.EventStoreOption{badger.Dir(dir)}, cfg.badgerOpts...)...)
+	}
+
+	s.EventStore = eventstore.WithBus(s.LocalStore, s.EventBus)
+	s.CommandBus = cmdbus.New[int](s.Commands, s.EventBus, cfg.commandOpts...)
+	s.Repository = repository.New(s.EventStore, cfg.repoOpts...)
+
+	return s
+}
+
+// Run runs the command bus until ctx is canceled, and returns the
+// asynchronous errors of the command bus.
+func (s *Stack) Run(ctx context.Context) (<-chan error, error) {
+	if opener, ok := s.LocalStore.(interface{ Open() error }); ok {
+		if err := opener.Open(); err != nil {
+			return nil, fmt.Errorf("open event store: %w", err)
+		}
+	}
+
+	errs, err := s.CommandBus.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("run command bus: %w", err)
+	}
+
+	return errs, nil
+}
+
+// Close closes the event store of the stack, if it can be closed.
+func (s *Stack) Close() error {
+	if closer, ok := s.LocalStore.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
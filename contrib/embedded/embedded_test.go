@@ -0,0 +1,70 @@
+package embedded_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+	"github.com/modernice/goes/contrib/embedded"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+type greeted struct {
+	Name string
+}
+
+func TestStack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stack := embedded.New(t.TempDir())
+	defer stack.Close()
+
+	codec.Register[greeted](stack.Events, "greeted")
+	codec.Register[string](stack.Commands, "greet")
+
+	if _, err := stack.Run(ctx); err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	published, _, err := stack.EventBus.Subscribe(ctx, "greeted")
+	if err != nil {
+		t.Fatalf("Subscribe failed with %q", err)
+	}
+
+	command.MustHandle(ctx, stack.CommandBus, "greet", func(ctx command.Ctx[string]) error {
+		return stack.EventStore.Insert(ctx, event.New("greeted", greeted{Name: ctx.Payload()}).Any())
+	})
+
+	if err := stack.CommandBus.Dispatch(ctx, command.New("greet", "Bob").Any(), dispatch.Sync()); err != nil {
+		t.Fatalf("Dispatch failed with %q", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for published event")
+	case evt := <-published:
+		if data, ok := evt.Data().(greeted); !ok || data.Name != "Bob" {
+			t.Fatalf("published event should have %v data; got %v", greeted{Name: "Bob"}, evt.Data())
+		}
+	}
+
+	str, errs, err := stack.LocalStore.Query(ctx, query.New(query.Name("greeted")))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("local store should contain %d event; got %d", 1, len(events))
+	}
+}
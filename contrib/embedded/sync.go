@@ -0,0 +1,516 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/version"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Conflict is a version conflict between the events of an aggregate that were
+// produced locally and the events of the same aggregate that were inserted
+// into the remote store by another client.
+type Conflict struct {
+	// Aggregate is the aggregate whose events conflict.
+	Aggregate event.AggregateRef
+
+	// Local are the events that were produced locally and are not yet in the
+	// remote store, sorted by version.
+	Local []event.Event
+
+	// Remote are the events of the remote store that are not yet in the local
+	// store, sorted by version.
+	Remote []event.Event
+}
+
+// ConflictError is returned by Syncer.Sync if a conflict is resolved using
+// FailOnConflict.
+type ConflictError struct {
+	Conflict Conflict
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"conflicting events for %s (%s): %d local, %d remote",
+		err.Conflict.Aggregate.Name, err.Conflict.Aggregate.ID,
+		len(err.Conflict.Local), len(err.Conflict.Remote),
+	)
+}
+
+// ConflictStrategy resolves a version conflict. It returns the events that
+// replace the conflicting local events. These events are uploaded to the
+// remote store and must therefore continue the remote events of the aggregate.
+// Returning no events discards the local events.
+type ConflictStrategy func(context.Context, Conflict) ([]event.Event, error)
+
+// FailOnConflict is a ConflictStrategy that does not resolve conflicts but
+// fails with a *ConflictError. The conflicting events are kept, so they can be
+// resolved manually.
+func FailOnConflict(_ context.Context, c Conflict) ([]event.Event, error) {
+	return nil, &ConflictError{Conflict: c}
+}
+
+// RemoteWins is a ConflictStrategy that discards the conflicting local events
+// in favor of the remote events.
+func RemoteWins(context.Context, Conflict) ([]event.Event, error) {
+	return nil, nil
+}
+
+// Rebase is a ConflictStrategy that moves the conflicting local events on top
+// of the remote events by renumbering their versions. The data of the events
+// is kept as-is, so the invariants of the aggregate are not re-validated
+// against the remote events.
+func Rebase(_ context.Context, c Conflict) ([]event.Event, error) {
+	next := 1
+	if len(c.Remote) > 0 {
+		next = aggregateVersion(c.Remote[len(c.Remote)-1]) + 1
+	}
+
+	out := make([]event.Event, len(c.Local))
+	for i, evt := range c.Local {
+		out[i] = event.New(
+			evt.Name(),
+			evt.Data(),
+			event.ID(evt.ID()),
+			event.Time(evt.Time()),
+			event.Aggregate(c.Aggregate.ID, c.Aggregate.Name, next+i),
+		)
+	}
+
+	return out, nil
+}
+
+// SyncOption is an option for a Syncer.
+type SyncOption func(*Syncer)
+
+// OnConflict returns a SyncOption that sets the strategy that resolves version
+// conflicts. Defaults to FailOnConflict.
+func OnConflict(strategy ConflictStrategy) SyncOption {
+	return func(s *Syncer) {
+		s.onConflict = strategy
+	}
+}
+
+// Subscribe returns a SyncOption that pulls the remote events of the given
+// aggregates into the local store. If no ids are provided, the events of all
+// aggregates with the given name are pulled.
+func Subscribe(name string, ids ...uuid.UUID) SyncOption {
+	return func(s *Syncer) {
+		if len(ids) == 0 {
+			s.subscriptions = append(s.subscriptions, event.AggregateRef{Name: name})
+			return
+		}
+		for _, id := range ids {
+			s.subscriptions = append(s.subscriptions, event.AggregateRef{Name: name, ID: id})
+		}
+	}
+}
+
+// SyncResult is the result of a synchronization.
+type SyncResult struct {
+	// Pushed is the number of events that were uploaded to the remote store.
+	Pushed int
+
+	// Pulled is the number of events that were inserted into the local store.
+	Pulled int
+
+	// Conflicts is the number of resolved conflicts.
+	Conflicts int
+}
+
+// Syncer synchronizes a local event store with a remote event store. Use
+// NewSyncer or Stack.Syncer to create a Syncer.
+//
+// Sync uploads the events of all local aggregates that are not yet in the
+// remote store. If another client inserted events with the same versions into
+// the remote store, the conflict is resolved using the configured
+// ConflictStrategy. Afterwards, the remote events of the subscribed aggregates
+// are pulled into the local store.
+//
+// Only events that belong to an aggregate are synchronized. The Syncer keeps
+// track of the synchronized versions in memory, so the first synchronization
+// after a restart compares the full event history of each local aggregate. If
+// the local store assigns global positions to its events (see
+// event.PositionQuerier), subsequent synchronizations only query the local
+// events that were inserted since the last successful push. Otherwise, every
+// synchronization queries all local events.
+type Syncer struct {
+	local         event.Store
+	remote        event.Store
+	onConflict    ConflictStrategy
+	subscriptions []event.AggregateRef
+
+	mux    sync.Mutex
+	synced map[event.AggregateRef]int
+
+	// pushed is the position of the last local event that was pushed, if the
+	// local store implements event.PositionQuerier.
+	pushed int64
+}
+
+// NewSyncer returns a Syncer that synchronizes the local store with the
+// remote store.
+func NewSyncer(local, remote event.Store, opts ...SyncOption) *Syncer {
+	s := &Syncer{
+		local:      local,
+		remote:     remote,
+		onConflict: FailOnConflict,
+		synced:     make(map[event.AggregateRef]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Syncer returns a Syncer that synchronizes the stack with the remote store.
+// Pulled events are inserted into s.EventStore and therefore published over
+// s.EventBus.
+func (s *Stack) Syncer(remote event.Store, opts ...SyncOption) *Syncer {
+	return NewSyncer(s.EventStore, remote, opts...)
+}
+
+// Sync pushes the local events to the remote store and pulls the events of the
+// subscribed aggregates from the remote store.
+func (s *Syncer) Sync(ctx context.Context) (SyncResult, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var result SyncResult
+
+	if err := s.push(ctx, &result); err != nil {
+		return result, fmt.Errorf("push: %w", err)
+	}
+
+	if err := s.pull(ctx, &result); err != nil {
+		return result, fmt.Errorf("pull: %w", err)
+	}
+
+	return result, nil
+}
+
+// Run calls Sync every time the given interval elapses, until ctx is canceled.
+// Errors of Sync are sent over the returned channel, which is closed when ctx
+// is canceled.
+func (s *Syncer) Run(ctx context.Context, every time.Duration) <-chan error {
+	out := make(chan error)
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+					select {
+					case <-ctx.Done():
+						return
+					case out <- err:
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (s *Syncer) push(ctx context.Context, result *SyncResult) error {
+	events, last, err := s.unpushed(ctx)
+	if err != nil {
+		return fmt.Errorf("query local events: %w", err)
+	}
+
+	var (
+		aggregates []event.AggregateRef
+		unsynced   = make(map[event.AggregateRef][]event.Event)
+	)
+	for _, evt := range events {
+		id, name, v := evt.Aggregate()
+		if name == "" || id == uuid.Nil {
+			continue
+		}
+
+		ref := event.AggregateRef{Name: name, ID: id}
+		if v <= s.synced[ref] {
+			continue
+		}
+
+		if _, ok := unsynced[ref]; !ok {
+			aggregates = append(aggregates, ref)
+		}
+		unsynced[ref] = append(unsynced[ref], evt)
+	}
+
+	var errs []error
+	for _, ref := range aggregates {
+		if err := s.pushAggregate(ctx, ref, unsynced[ref], result); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", ref.Name, ref.ID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	s.pushed = last
+
+	return nil
+}
+
+// unpushed returns the local events that may not have been pushed yet, sorted
+// by aggregate, and the position of the last returned event. If the local
+// store does not implement event.PositionQuerier, unpushed returns all local
+// events.
+func (s *Syncer) unpushed(ctx context.Context) ([]event.Event, int64, error) {
+	pq, ok := s.local.(event.PositionQuerier)
+	if !ok {
+		events, err := queryAll(ctx, s.local, query.New(query.SortByAggregate()))
+		return events, 0, err
+	}
+
+	str, errs, err := pq.QueryAfter(ctx, s.pushed, query.New())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	positioned, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	last := s.pushed
+	events := make([]event.Event, len(positioned))
+	for i, evt := range positioned {
+		events[i] = evt.Event
+		last = evt.Position
+	}
+
+	return event.SortMulti(events, query.New(query.SortByAggregate()).Sortings()...), last, nil
+}
+
+func (s *Syncer) pushAggregate(ctx context.Context, ref event.AggregateRef, local []event.Event, result *SyncResult) error {
+	remote, err := queryAll(ctx, s.remote, query.New(
+		query.Aggregate(ref.Name, ref.ID),
+		query.AggregateVersion(version.Min(s.synced[ref]+1)),
+		query.SortBy(event.SortAggregateVersion, event.SortAsc),
+	))
+	if err != nil {
+		return fmt.Errorf("query remote events: %w", err)
+	}
+
+	remoteIDs := make(map[uuid.UUID]bool, len(remote))
+	for _, evt := range remote {
+		remoteIDs[evt.ID()] = true
+	}
+
+	localIDs := make(map[uuid.UUID]bool, len(local))
+	var pending []event.Event
+	for _, evt := range local {
+		localIDs[evt.ID()] = true
+		if !remoteIDs[evt.ID()] {
+			pending = append(pending, evt)
+		}
+	}
+
+	var missing []event.Event
+	for _, evt := range remote {
+		if !localIDs[evt.ID()] {
+			missing = append(missing, evt)
+		}
+	}
+
+	if len(pending) == 0 {
+		s.markSynced(local)
+		return nil
+	}
+
+	if !conflicts(pending, missing) {
+		if err := s.remote.Insert(ctx, pending...); err != nil {
+			return fmt.Errorf("upload events: %w", err)
+		}
+		result.Pushed += len(pending)
+		s.markSynced(pending)
+		return nil
+	}
+
+	resolved, err := s.onConflict(ctx, Conflict{Aggregate: ref, Local: pending, Remote: missing})
+	if err != nil {
+		return fmt.Errorf("resolve conflict: %w", err)
+	}
+
+	if len(resolved) > 0 {
+		if err := s.remote.Insert(ctx, resolved...); err != nil {
+			return fmt.Errorf("upload resolved events: %w", err)
+		}
+	}
+
+	// The resolved events may reuse the ids and versions of the conflicting
+	// local events, so the local events must be deleted first. Event stores
+	// provide no transactions across Delete and Insert, so the local events
+	// are restored if the resolved events cannot be inserted.
+	if err := s.local.Delete(ctx, pending...); err != nil {
+		return fmt.Errorf("delete conflicting local events: %w", err)
+	}
+
+	replacement := append(missing, resolved...)
+	if err := s.local.Insert(ctx, replacement...); err != nil {
+		if rerr := s.restore(ctx, pending, replacement); rerr != nil {
+			return fmt.Errorf("insert resolved events: %w (restore conflicting local events: %v)", err, rerr)
+		}
+		return fmt.Errorf("insert resolved events: %w", err)
+	}
+
+	result.Pushed += len(resolved)
+	result.Pulled += len(missing)
+	result.Conflicts++
+	s.markSynced(missing, resolved)
+
+	return nil
+}
+
+// restore replaces the partially inserted replacement events with the deleted
+// local events.
+func (s *Syncer) restore(ctx context.Context, local, replacement []event.Event) error {
+	if err := s.local.Delete(ctx, replacement...); err != nil {
+		return fmt.Errorf("delete inserted events: %w", err)
+	}
+	return s.local.Insert(ctx, local...)
+}
+
+// conflicts returns whether the remote events use versions of the pending
+// local events.
+func conflicts(pending, missing []event.Event) bool {
+	if len(missing) == 0 {
+		return false
+	}
+	return aggregateVersion(missing[len(missing)-1]) >= aggregateVersion(pending[0])
+}
+
+func (s *Syncer) pull(ctx context.Context, result *SyncResult) error {
+	var errs []error
+	for _, ref := range s.subscriptions {
+		if err := s.pullSubscription(ctx, ref, result); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Syncer) pullSubscription(ctx context.Context, sub event.AggregateRef, result *SyncResult) error {
+	opts := []query.Option{query.SortByAggregate()}
+	if sub.ID == uuid.Nil {
+		opts = append(opts, query.AggregateName(sub.Name))
+	} else {
+		opts = append(opts, query.Aggregate(sub.Name, sub.ID))
+		if v := s.synced[sub]; v > 0 {
+			opts = append(opts, query.AggregateVersion(version.Min(v+1)))
+		}
+	}
+
+	remote, err := queryAll(ctx, s.remote, query.New(opts...))
+	if err != nil {
+		return fmt.Errorf("query remote events: %w", err)
+	}
+
+	versions := make(map[event.AggregateRef]int)
+	var missing []event.Event
+	for _, evt := range remote {
+		id, name, v := evt.Aggregate()
+		ref := event.AggregateRef{Name: name, ID: id}
+
+		current, ok := versions[ref]
+		if !ok {
+			if current, err = s.localVersion(ctx, ref); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			versions[ref] = current
+		}
+
+		if v > current {
+			missing = append(missing, evt)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := s.local.Insert(ctx, missing...); err != nil {
+		return fmt.Errorf("insert events: %w", err)
+	}
+	result.Pulled += len(missing)
+	s.markSynced(missing)
+
+	return nil
+}
+
+// localVersion returns the version of the aggregate in the local store.
+func (s *Syncer) localVersion(ctx context.Context, ref event.AggregateRef) (int, error) {
+	if v, ok := s.synced[ref]; ok {
+		return v, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := s.local.Query(ctx, query.New(
+		query.Aggregate(ref.Name, ref.ID),
+		query.SortBy(event.SortAggregateVersion, event.SortDesc),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("query local events: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			return 0, fmt.Errorf("query local events: %w", err)
+		case evt, ok := <-str:
+			if !ok {
+				return 0, nil
+			}
+			return aggregateVersion(evt), nil
+		}
+	}
+}
+
+func aggregateVersion(evt event.Event) int {
+	_, _, v := evt.Aggregate()
+	return v
+}
+
+// markSynced records the highest version of the given events as synced.
+func (s *Syncer) markSynced(events ...[]event.Event) {
+	for _, evts := range events {
+		for _, evt := range evts {
+			id, name, v := evt.Aggregate()
+			ref := event.AggregateRef{Name: name, ID: id}
+			if v > s.synced[ref] {
+				s.synced[ref] = v
+			}
+		}
+	}
+}
+
+func queryAll(ctx context.Context, store event.Store, q event.Query) ([]event.Event, error) {
+	str, errs, err := store.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return streams.Drain(ctx, str, errs)
+}
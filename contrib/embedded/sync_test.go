@@ -0,0 +1,249 @@
+package embedded_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/contrib/embedded"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	etest "github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestSyncer_Sync_push(t *testing.T) {
+	ctx := context.Background()
+	local, remote := eventstore.New(), eventstore.New()
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)),
+	}
+	insert(t, local, events...)
+
+	syncer := embedded.NewSyncer(local, remote)
+
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if result.Pushed != 2 {
+		t.Fatalf("Sync should push %d events; pushed %d", 2, result.Pushed)
+	}
+
+	etest.AssertEqualEvents(t, events, queryAggregate(t, remote, id))
+
+	// already synced events are not pushed again
+	if result, err = syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if result.Pushed != 0 {
+		t.Fatalf("Sync should not push synced events; pushed %d", result.Pushed)
+	}
+}
+
+func TestSyncer_Sync_pull(t *testing.T) {
+	ctx := context.Background()
+	local, remote := eventstore.New(), eventstore.New()
+
+	foo, bar := uuid.New(), uuid.New()
+	fooEvents := []event.Event{
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(foo, "foo", 1)),
+		event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(foo, "foo", 2)),
+	}
+	insert(t, local, fooEvents[0])
+	insert(t, remote, fooEvents...)
+	insert(t, remote, event.New[any]("bar", etest.BarEventData{A: "bar"}, event.Aggregate(bar, "bar", 1)))
+
+	syncer := embedded.NewSyncer(local, remote, embedded.Subscribe("foo"))
+
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if result.Pulled != 1 {
+		t.Fatalf("Sync should pull %d event; pulled %d", 1, result.Pulled)
+	}
+
+	etest.AssertEqualEvents(t, fooEvents, queryAggregate(t, local, foo))
+
+	if events := queryAggregate(t, local, bar); len(events) != 0 {
+		t.Fatalf("Sync should not pull events of unsubscribed aggregates; pulled %d", len(events))
+	}
+}
+
+func TestSyncer_Sync_conflict(t *testing.T) {
+	ctx := context.Background()
+	local, remote, id, localEvt, remoteEvt := setupConflict(t)
+
+	_, err := embedded.NewSyncer(local, remote).Sync(ctx)
+
+	var conflictErr *embedded.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Sync should fail with a %T; got %q", conflictErr, err)
+	}
+
+	etest.AssertEqualEvents(t, []event.Event{localEvt}, conflictErr.Conflict.Local)
+	etest.AssertEqualEvents(t, []event.Event{remoteEvt}, conflictErr.Conflict.Remote)
+
+	// conflicting events are kept
+	etest.AssertEqualEvents(t, []event.Event{localEvt}, queryAggregate(t, local, id))
+	etest.AssertEqualEvents(t, []event.Event{remoteEvt}, queryAggregate(t, remote, id))
+}
+
+func TestSyncer_Sync_RemoteWins(t *testing.T) {
+	ctx := context.Background()
+	local, remote, id, _, remoteEvt := setupConflict(t)
+
+	result, err := embedded.NewSyncer(local, remote, embedded.OnConflict(embedded.RemoteWins)).Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if result.Conflicts != 1 {
+		t.Fatalf("Sync should resolve %d conflict; resolved %d", 1, result.Conflicts)
+	}
+
+	etest.AssertEqualEvents(t, []event.Event{remoteEvt}, queryAggregate(t, local, id))
+	etest.AssertEqualEvents(t, []event.Event{remoteEvt}, queryAggregate(t, remote, id))
+}
+
+func TestSyncer_Sync_Rebase(t *testing.T) {
+	ctx := context.Background()
+	local, remote, id, localEvt, remoteEvt := setupConflict(t)
+
+	if _, err := embedded.NewSyncer(local, remote, embedded.OnConflict(embedded.Rebase)).Sync(ctx); err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	rebased := event.New(
+		localEvt.Name(),
+		localEvt.Data(),
+		event.ID(localEvt.ID()),
+		event.Time(localEvt.Time()),
+		event.Aggregate(id, "foo", 2),
+	).Any()
+
+	want := []event.Event{remoteEvt, rebased}
+	etest.AssertEqualEvents(t, want, queryAggregate(t, local, id))
+	etest.AssertEqualEvents(t, want, queryAggregate(t, remote, id))
+}
+
+func TestSyncer_Sync_insertError(t *testing.T) {
+	ctx := context.Background()
+	store, remote, id, localEvt, remoteEvt := setupConflict(t)
+
+	mockError := errors.New("mock error")
+	local := &failingStore{Store: store, err: mockError}
+
+	_, err := embedded.NewSyncer(local, remote, embedded.OnConflict(embedded.RemoteWins)).Sync(ctx)
+	if !errors.Is(err, mockError) {
+		t.Fatalf("Sync should fail with %q; got %q", mockError, err)
+	}
+
+	// the conflicting local events are restored
+	etest.AssertEqualEvents(t, []event.Event{localEvt}, queryAggregate(t, store, id))
+	etest.AssertEqualEvents(t, []event.Event{remoteEvt}, queryAggregate(t, remote, id))
+}
+
+func TestSyncer_Sync_positions(t *testing.T) {
+	ctx := context.Background()
+	store, remote := eventstore.New(), eventstore.New()
+	local := &positionRecorder{Store: store}
+
+	id := uuid.New()
+	insert(t, local, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)))
+
+	syncer := embedded.NewSyncer(local, remote)
+	if _, err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	insert(t, local, event.New[any]("foo", etest.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 2)))
+
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync failed with %q", err)
+	}
+
+	if result.Pushed != 1 {
+		t.Fatalf("Sync should push %d event; pushed %d", 1, result.Pushed)
+	}
+
+	if want := []int64{0, 1}; len(local.positions) != len(want) || local.positions[0] != want[0] || local.positions[1] != want[1] {
+		t.Fatalf("Sync should query the local events after positions %v; queried after %v", want, local.positions)
+	}
+}
+
+// failingStore fails to insert the events that were created by setupConflict
+// for the remote store.
+type failingStore struct {
+	event.Store
+
+	err error
+}
+
+func (s *failingStore) Insert(ctx context.Context, events ...event.Event) error {
+	for _, evt := range events {
+		if evt.Data() == (etest.FooEventData{A: "remote"}) {
+			return s.err
+		}
+	}
+	return s.Store.Insert(ctx, events...)
+}
+
+// positionRecorder records the positions that the local events are queried
+// after.
+type positionRecorder struct {
+	event.Store
+
+	positions []int64
+}
+
+func (s *positionRecorder) QueryAfter(ctx context.Context, position int64, q event.Query) (<-chan event.Positioned, <-chan error, error) {
+	s.positions = append(s.positions, position)
+	return s.Store.(event.PositionQuerier).QueryAfter(ctx, position, q)
+}
+
+func setupConflict(t *testing.T) (local, remote event.Store, id uuid.UUID, localEvt, remoteEvt event.Event) {
+	local, remote = eventstore.New(), eventstore.New()
+	id = uuid.New()
+	localEvt = event.New[any]("foo", etest.FooEventData{A: "local"}, event.Aggregate(id, "foo", 1))
+	remoteEvt = event.New[any]("foo", etest.FooEventData{A: "remote"}, event.Aggregate(id, "foo", 1))
+	insert(t, local, localEvt)
+	insert(t, remote, remoteEvt)
+	return
+}
+
+func insert(t *testing.T, store event.Store, events ...event.Event) {
+	t.Helper()
+	if err := store.Insert(context.Background(), events...); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+}
+
+func queryAggregate(t *testing.T, store event.Store, id uuid.UUID) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), query.New(
+		query.AggregateID(id),
+		query.SortBy(event.SortAggregateVersion, event.SortAsc),
+	))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	return events
+}
@@ -0,0 +1,585 @@
+// Package loadgen generates synthetic aggregates and event streams against a
+// real event store and event bus. Use it to validate the sizing of a store and
+// the throughput of projections before going live:
+//
+//	gen := loadgen.New(
+//		loadgen.Store(store),
+//		loadgen.Bus(bus),
+//		loadgen.Aggregates("order", 10_000),
+//		loadgen.Events("order.placed", "order.paid", "order.shipped"),
+//		loadgen.Rate(2_000),
+//		loadgen.Duration(5*time.Minute),
+//	)
+//	report, err := gen.Run(ctx)
+//	log.Println(report)
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"golang.org/x/time/rate"
+)
+
+// DefaultEventName is the name of generated events if no event names are
+// configured.
+const DefaultEventName = "loadgen.event"
+
+// latencySamples is the number of insert latencies that are sampled to
+// compute the latency percentiles of a Report.
+const latencySamples = 10_000
+
+// Data is the data of generated events if no data factory is configured.
+type Data struct {
+	// Seq is the sequence number of the event within the run.
+	Seq int64
+
+	// Payload is random padding to simulate the size of real events.
+	Payload string
+}
+
+// Generator generates synthetic events.
+type Generator struct {
+	store       event.Store
+	bus         event.Bus
+	aggregates  []aggregateConfig
+	names       []string
+	data        map[string]func(*rand.Rand, event.AggregateRef, int) any
+	payloadSize int
+	rate        float64
+	total       int64
+	duration    time.Duration
+	batchSize   int
+	workers     int
+	zipf        float64
+	seed        int64
+
+	progressInterval time.Duration
+	onProgress       func(Report)
+}
+
+type aggregateConfig struct {
+	name  string
+	count int
+}
+
+// Option is an option for a Generator.
+type Option func(*Generator)
+
+// Store returns an Option that inserts the generated events into the given
+// store.
+func Store(s event.Store) Option {
+	return func(g *Generator) {
+		g.store = s
+	}
+}
+
+// Bus returns an Option that publishes the generated events over the given
+// bus. If a store is configured as well, events are published after they have
+// been inserted.
+func Bus(b event.Bus) Option {
+	return func(g *Generator) {
+		g.bus = b
+	}
+}
+
+// Aggregates returns an Option that generates events for count aggregates of
+// the given name. Multiple calls add multiple aggregate types. If no
+// aggregates are configured, the generated events do not belong to an
+// aggregate.
+func Aggregates(name string, count int) Option {
+	return func(g *Generator) {
+		g.aggregates = append(g.aggregates, aggregateConfig{name: name, count: count})
+	}
+}
+
+// Events returns an Option that configures the names of the generated events.
+// Each event gets a random name out of the given names. Defaults to
+// DefaultEventName.
+func Events(names ...string) Option {
+	return func(g *Generator) {
+		g.names = append(g.names, names...)
+	}
+}
+
+// EventData returns an Option that configures the data factory for events
+// with the given name. The factory is called with the random source of the
+// generating worker, the aggregate of the event, and its version. By default,
+// events have Data with a random payload.
+func EventData(name string, fn func(r *rand.Rand, aggregate event.AggregateRef, version int) any) Option {
+	return func(g *Generator) {
+		g.data[name] = fn
+	}
+}
+
+// PayloadSize returns an Option that configures the size of the random
+// payload of the default event Data in bytes. Defaults to 0.
+func PayloadSize(n int) Option {
+	return func(g *Generator) {
+		g.payloadSize = n
+	}
+}
+
+// Rate returns an Option that limits the number of generated events per
+// second. By default, events are generated as fast as possible.
+func Rate(eventsPerSecond float64) Option {
+	return func(g *Generator) {
+		g.rate = eventsPerSecond
+	}
+}
+
+// Total returns an Option that stops the run after n events have been
+// generated.
+func Total(n int64) Option {
+	return func(g *Generator) {
+		g.total = n
+	}
+}
+
+// Duration returns an Option that stops the run after d.
+func Duration(d time.Duration) Option {
+	return func(g *Generator) {
+		g.duration = d
+	}
+}
+
+// BatchSize returns an Option that configures the number of events that are
+// inserted and published at once. The events of a batch are consecutive events
+// of a single aggregate. Defaults to 1.
+func BatchSize(n int) Option {
+	return func(g *Generator) {
+		g.batchSize = n
+	}
+}
+
+// Workers returns an Option that configures the number of concurrent workers.
+// The aggregates are partitioned between the workers, so that no two workers
+// write to the same aggregate. Defaults to 1.
+func Workers(n int) Option {
+	return func(g *Generator) {
+		g.workers = n
+	}
+}
+
+// Zipf returns an Option that picks aggregates with a Zipf distribution
+// instead of uniformly, to simulate hot aggregates. s must be greater than 1;
+// greater values produce a more skewed distribution.
+func Zipf(s float64) Option {
+	if s <= 1 {
+		panic("zipf parameter must be greater than 1")
+	}
+	return func(g *Generator) {
+		g.zipf = s
+	}
+}
+
+// Seed returns an Option that seeds the random sources of the workers, to
+// generate the same aggregates and events in every run. By default, the
+// current time is used as the seed.
+func Seed(seed int64) Option {
+	return func(g *Generator) {
+		g.seed = seed
+	}
+}
+
+// Progress returns an Option that calls fn with the intermediate report of the
+// run in the given interval.
+func Progress(interval time.Duration, fn func(Report)) Option {
+	return func(g *Generator) {
+		g.progressInterval = interval
+		g.onProgress = fn
+	}
+}
+
+// New returns a new Generator. At least one of the Store and Bus options must
+// be provided.
+func New(opts ...Option) *Generator {
+	g := &Generator{
+		data:      make(map[string]func(*rand.Rand, event.AggregateRef, int) any),
+		batchSize: 1,
+		workers:   1,
+		seed:      time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if len(g.names) == 0 {
+		g.names = []string{DefaultEventName}
+	}
+	if g.batchSize < 1 {
+		g.batchSize = 1
+	}
+	if g.workers < 1 {
+		g.workers = 1
+	}
+	return g
+}
+
+// RegisterCodec registers the default event Data for the event names that have
+// no data factory, so that consumers like projections can decode the
+// generated events.
+func (g *Generator) RegisterCodec(r codec.Registerer) {
+	for _, name := range g.names {
+		if _, ok := g.data[name]; !ok {
+			codec.Register[Data](r, name)
+		}
+	}
+}
+
+// Report is the report of a run.
+type Report struct {
+	// Events is the number of generated events.
+	Events int64
+
+	// Batches is the number of inserted or published batches.
+	Batches int64
+
+	// Failed is the number of batches that could not be inserted or
+	// published.
+	Failed int64
+
+	// Aggregates is the number of distinct aggregates that received events.
+	Aggregates int
+
+	// Elapsed is the duration of the run.
+	Elapsed time.Duration
+
+	// P50, P95, P99, and Max are latency percentiles of the batches.
+	P50, P95, P99, Max time.Duration
+}
+
+// Throughput returns the number of generated events per second.
+func (r Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Events) / r.Elapsed.Seconds()
+}
+
+// String returns a summary of the report.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d events in %d batches (%d failed) across %d aggregates in %s (%.1f events/s)",
+		r.Events, r.Batches, r.Failed, r.Aggregates, r.Elapsed.Round(time.Millisecond), r.Throughput())
+	fmt.Fprintf(&b, "; latency p50=%s p95=%s p99=%s max=%s", r.P50, r.P95, r.P99, r.Max)
+	return b.String()
+}
+
+// Run generates events until the configured Total or Duration is reached, or
+// ctx is canceled. Failed batches are counted in the Report and do not stop
+// the run; the returned error joins the errors of the failed batches, up to a
+// limit.
+func (g *Generator) Run(ctx context.Context) (Report, error) {
+	if g.store == nil && g.bus == nil {
+		return Report{}, errors.New("neither store nor bus configured")
+	}
+
+	if g.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.duration)
+		defer cancel()
+	}
+
+	r := &run{
+		gen:     g,
+		started: time.Now(),
+		seen:    make(map[event.AggregateRef]bool),
+	}
+
+	if g.rate > 0 {
+		burst := g.batchSize
+		if int(g.rate) > burst {
+			burst = int(g.rate)
+		}
+		r.limiter = rate.NewLimiter(rate.Limit(g.rate), burst)
+	}
+
+	stopProgress := r.reportProgress()
+	defer stopProgress()
+
+	var wg sync.WaitGroup
+	wg.Add(g.workers)
+	for i := 0; i < g.workers; i++ {
+		w := r.newWorker(i)
+		go func() {
+			defer wg.Done()
+			w.generate(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return r.report(), errors.Join(r.errs...)
+}
+
+const maxErrors = 10
+
+type run struct {
+	gen     *Generator
+	started time.Time
+	limiter *rate.Limiter
+
+	mux       sync.Mutex
+	events    int64
+	seq       int64
+	batches   int64
+	failed    int64
+	seen      map[event.AggregateRef]bool
+	latencies []time.Duration
+	sampled   int64
+	errs      []error
+	rand      *rand.Rand
+}
+
+// reserve reserves n events of the total, and returns the sequence number of
+// the first reserved event and how many events may be generated.
+func (r *run) reserve(n int) (int64, int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.gen.total > 0 {
+		if left := r.gen.total - r.events; int64(n) > left {
+			n = int(left)
+		}
+	}
+	seq := r.seq
+	r.events += int64(n)
+	r.seq += int64(n)
+	return seq, n
+}
+
+func (r *run) record(events []event.Event, latency time.Duration, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.batches++
+	if err != nil {
+		r.failed++
+		if len(r.errs) < maxErrors {
+			r.errs = append(r.errs, err)
+		}
+	}
+
+	for _, evt := range events {
+		if id, name, _ := evt.Aggregate(); name != "" {
+			r.seen[event.AggregateRef{Name: name, ID: id}] = true
+		}
+	}
+
+	// reservoir sampling of latencies
+	r.sampled++
+	if len(r.latencies) < latencySamples {
+		r.latencies = append(r.latencies, latency)
+		return
+	}
+	if r.rand == nil {
+		r.rand = rand.New(rand.NewSource(r.gen.seed))
+	}
+	if i := r.rand.Int63n(r.sampled); i < latencySamples {
+		r.latencies[i] = latency
+	}
+}
+
+func (r *run) report() Report {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	rep := Report{
+		Events:     r.events,
+		Batches:    r.batches,
+		Failed:     r.failed,
+		Aggregates: len(r.seen),
+		Elapsed:    time.Since(r.started),
+	}
+
+	if len(r.latencies) > 0 {
+		sorted := append([]time.Duration(nil), r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		rep.P50 = percentile(sorted, 0.5)
+		rep.P95 = percentile(sorted, 0.95)
+		rep.P99 = percentile(sorted, 0.99)
+		rep.Max = sorted[len(sorted)-1]
+	}
+
+	return rep
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+func (r *run) reportProgress() (stop func()) {
+	if r.gen.onProgress == nil || r.gen.progressInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(r.gen.progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.gen.onProgress(r.report())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+type worker struct {
+	run        *run
+	rand       *rand.Rand
+	aggregates []aggregateState
+	zipf       *rand.Zipf
+}
+
+type aggregateState struct {
+	ref     event.AggregateRef
+	version int
+}
+
+func (r *run) newWorker(n int) *worker {
+	g := r.gen
+	w := &worker{run: r, rand: rand.New(rand.NewSource(g.seed + int64(n)))}
+
+	// the ids of the aggregates are derived from the seed, so that runs with
+	// the same seed generate the same aggregates
+	idSource := rand.New(rand.NewSource(g.seed))
+	var i int
+	for _, cfg := range g.aggregates {
+		for j := 0; j < cfg.count; j++ {
+			id, _ := uuid.NewRandomFromReader(idSource)
+			if i%g.workers == n {
+				w.aggregates = append(w.aggregates, aggregateState{ref: event.AggregateRef{Name: cfg.name, ID: id}})
+			}
+			i++
+		}
+	}
+
+	if g.zipf > 1 && len(w.aggregates) > 1 {
+		w.zipf = rand.NewZipf(w.rand, g.zipf, 1, uint64(len(w.aggregates)-1))
+	}
+
+	return w
+}
+
+func (w *worker) generate(ctx context.Context) {
+	g := w.run.gen
+
+	// workers without aggregates only generate events if no aggregates are
+	// configured at all
+	if len(w.aggregates) == 0 && len(g.aggregates) > 0 {
+		return
+	}
+
+	for ctx.Err() == nil {
+		seq, n := w.run.reserve(g.batchSize)
+		if n == 0 {
+			return
+		}
+
+		if w.run.limiter != nil {
+			if err := w.run.limiter.WaitN(ctx, n); err != nil {
+				w.run.unreserve(n)
+				return
+			}
+		}
+
+		events := w.batch(seq, n)
+
+		start := time.Now()
+		err := w.send(ctx, events)
+		if ctx.Err() != nil && err != nil {
+			w.run.unreserve(n)
+			return
+		}
+		w.run.record(events, time.Since(start), err)
+	}
+}
+
+func (r *run) unreserve(n int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.events -= int64(n)
+}
+
+func (w *worker) batch(seq int64, n int) []event.Event {
+	var agg *aggregateState
+	if len(w.aggregates) > 0 {
+		i := w.rand.Intn(len(w.aggregates))
+		if w.zipf != nil {
+			i = int(w.zipf.Uint64())
+		}
+		agg = &w.aggregates[i]
+	}
+
+	events := make([]event.Event, n)
+	for i := range events {
+		name := w.run.gen.names[w.rand.Intn(len(w.run.gen.names))]
+
+		var (
+			ref     event.AggregateRef
+			version int
+			opts    []event.Option
+		)
+		if agg != nil {
+			agg.version++
+			ref, version = agg.ref, agg.version
+			opts = append(opts, event.Aggregate(ref.ID, ref.Name, version))
+		}
+
+		events[i] = event.New(name, w.data(name, seq+int64(i), ref, version), opts...).Any()
+	}
+
+	return events
+}
+
+const payloadChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func (w *worker) data(name string, seq int64, ref event.AggregateRef, version int) any {
+	if fn, ok := w.run.gen.data[name]; ok {
+		return fn(w.rand, ref, version)
+	}
+
+	payload := make([]byte, w.run.gen.payloadSize)
+	for i := range payload {
+		payload[i] = payloadChars[w.rand.Intn(len(payloadChars))]
+	}
+
+	return Data{Seq: seq, Payload: string(payload)}
+}
+
+func (w *worker) send(ctx context.Context, events []event.Event) error {
+	g := w.run.gen
+
+	if g.store != nil {
+		if err := g.store.Insert(ctx, events...); err != nil {
+			return fmt.Errorf("insert events: %w", err)
+		}
+	}
+
+	if g.bus != nil {
+		if err := g.bus.Publish(ctx, events...); err != nil {
+			return fmt.Errorf("publish events: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package loadgen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/contrib/loadgen"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestGenerator_Run(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := eventstore.New()
+	bus := eventbus.New()
+
+	published, _, err := bus.Subscribe(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatalf("Subscribe failed with %q", err)
+	}
+
+	gen := loadgen.New(
+		loadgen.Store(store),
+		loadgen.Bus(bus),
+		loadgen.Aggregates("foobar", 10),
+		loadgen.Events("foo", "bar"),
+		loadgen.PayloadSize(32),
+		loadgen.BatchSize(3),
+		loadgen.Workers(4),
+		loadgen.Total(100),
+		loadgen.Seed(1),
+	)
+
+	var received int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range published {
+			if received++; received == 100 {
+				return
+			}
+		}
+	}()
+
+	report, err := gen.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	if report.Events != 100 {
+		t.Errorf("Report should have %d events; got %d", 100, report.Events)
+	}
+
+	if report.Aggregates < 1 || report.Aggregates > 10 {
+		t.Errorf("Report should have between %d and %d aggregates; got %d", 1, 10, report.Aggregates)
+	}
+
+	if report.Failed != 0 {
+		t.Errorf("Report should have no failed batches; got %d", report.Failed)
+	}
+
+	str, errs := mustQuery(t, store, query.New(query.AggregateName("foobar")))
+	events, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	if len(events) != 100 {
+		t.Fatalf("store should contain %d events; got %d", 100, len(events))
+	}
+
+	seqs := make(map[int64]bool)
+	for _, evt := range events {
+		if _, _, v := evt.Aggregate(); v < 1 {
+			t.Errorf("event should have a positive aggregate version; got %d", v)
+		}
+		data, ok := evt.Data().(loadgen.Data)
+		if !ok {
+			t.Fatalf("event data should be %T; got %T", loadgen.Data{}, evt.Data())
+		}
+		if len(data.Payload) != 32 {
+			t.Errorf("payload should have %d bytes; got %d", 32, len(data.Payload))
+		}
+		seqs[data.Seq] = true
+	}
+
+	for i := int64(0); i < 100; i++ {
+		if !seqs[i] {
+			t.Errorf("no event with sequence number %d", i)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for published events; received %d", received)
+	case <-done:
+	}
+}
+
+func TestGenerator_Run_rate(t *testing.T) {
+	gen := loadgen.New(
+		loadgen.Bus(eventbus.New()),
+		loadgen.Rate(100),
+		loadgen.Duration(200*time.Millisecond),
+	)
+
+	report, err := gen.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	// the initial burst allows up to 100 events, and another 20 are allowed
+	// during the run
+	if report.Events < 100 || report.Events > 125 {
+		t.Errorf("Report should have ~120 events; got %d", report.Events)
+	}
+
+	if report.Aggregates != 0 {
+		t.Errorf("Report should have no aggregates; got %d", report.Aggregates)
+	}
+}
+
+func TestGenerator_RegisterCodec(t *testing.T) {
+	gen := loadgen.New(
+		loadgen.Events("foo", "bar"),
+		loadgen.EventData("bar", nil),
+	)
+
+	reg := codec.New()
+	gen.RegisterCodec(reg)
+
+	if _, err := reg.New("foo"); err != nil {
+		t.Errorf("%q should be registered; New failed with %q", "foo", err)
+	}
+
+	if _, err := reg.New("bar"); err == nil {
+		t.Errorf("%q should not be registered", "bar")
+	}
+}
+
+func TestGenerator_Run_noTarget(t *testing.T) {
+	if _, err := loadgen.New(loadgen.Total(1)).Run(context.Background()); err == nil {
+		t.Fatal("Run should fail without a store or bus")
+	}
+}
+
+func mustQuery(t *testing.T, store event.Store, q event.Query) (<-chan event.Event, <-chan error) {
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+	return str, errs
+}
@@ -0,0 +1,38 @@
+package sequence
+
+import (
+	"context"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+)
+
+// ReserveCmd is the command to reserve numbers of a sequence.
+const ReserveCmd = "goes.contrib.sequence.reserve"
+
+type reservePayload struct {
+	Sequence  string
+	Count     int
+	Reference string
+}
+
+// Reserve returns the command to reserve the next count numbers of the given
+// sequence. The reservation can be looked up by its reference after the
+// command was handled (see Generator.Reservation).
+func Reserve(name string, count int, reference string) command.Cmd[reservePayload] {
+	return command.New(ReserveCmd, reservePayload{Sequence: name, Count: count, Reference: reference}, command.Aggregate(Aggregate, ID(name)))
+}
+
+// RegisterCommands registers the commands of the sequence package into a registry.
+func RegisterCommands(r codec.Registerer) {
+	codec.Register[reservePayload](r, ReserveCmd)
+}
+
+// HandleCommands handles commands until ctx is canceled.
+func HandleCommands(ctx context.Context, bus command.Bus, gen *Generator) <-chan error {
+	return command.MustHandle(ctx, bus, ReserveCmd, func(ctx command.Ctx[reservePayload]) error {
+		load := ctx.Payload()
+		_, err := gen.Reserve(ctx, load.Sequence, load.Count, load.Reference)
+		return err
+	})
+}
@@ -0,0 +1,148 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/modernice/goes/aggregate"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of attempts of a reservation.
+	DefaultMaxAttempts = 10
+
+	// DefaultBackoff is the default delay before the first retry of a
+	// reservation.
+	DefaultBackoff = 5 * time.Millisecond
+)
+
+// Generator reserves numbers of sequences.
+type Generator struct {
+	repo        aggregate.Repository
+	maxAttempts int
+	backoff     time.Duration
+	seqOpts     []Option
+}
+
+// GeneratorOption is an option for a Generator.
+type GeneratorOption func(*Generator)
+
+// Retry returns a GeneratorOption that configures the retries of reservations
+// that fail because another reservation of the same sequence was saved
+// concurrently. A reservation is attempted at most maxAttempts times. The
+// delay between attempts grows exponentially, starting at backoff, and is
+// randomized to spread out competing reservations. Defaults to
+// DefaultMaxAttempts and DefaultBackoff.
+func Retry(maxAttempts int, backoff time.Duration) GeneratorOption {
+	return func(g *Generator) {
+		g.maxAttempts = maxAttempts
+		g.backoff = backoff
+	}
+}
+
+// SequenceOptions returns a GeneratorOption that specifies the options of the
+// sequences that are fetched by the generator, e.g. MaxReferences.
+func SequenceOptions(opts ...Option) GeneratorOption {
+	return func(g *Generator) {
+		g.seqOpts = append(g.seqOpts, opts...)
+	}
+}
+
+// NewGenerator returns a Generator that stores sequences in the given
+// repository.
+func NewGenerator(repo aggregate.Repository, opts ...GeneratorOption) *Generator {
+	g := &Generator{
+		repo:        repo,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.maxAttempts < 1 {
+		g.maxAttempts = 1
+	}
+	return g
+}
+
+// Next reserves the next number of the given sequence.
+func (g *Generator) Next(ctx context.Context, name string) (int64, error) {
+	r, err := g.Reserve(ctx, name, 1, "")
+	if err != nil {
+		return 0, err
+	}
+	return r.First, nil
+}
+
+// Reserve reserves the next count numbers of the given sequence. If a
+// reference is provided, and numbers were already reserved with that
+// reference, Reserve returns the existing reservation.
+func (g *Generator) Reserve(ctx context.Context, name string, count int, reference string) (Range, error) {
+	var err error
+	for attempt := 0; attempt < g.maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := g.wait(ctx, attempt); err != nil {
+				return Range{}, err
+			}
+		}
+
+		var r Range
+		if r, err = g.reserve(ctx, name, count, reference); err == nil {
+			return r, nil
+		}
+
+		if !aggregate.IsConsistencyError(err) {
+			return Range{}, err
+		}
+	}
+
+	return Range{}, fmt.Errorf("reserve numbers of %q sequence: gave up after %d attempts: %w", name, g.maxAttempts, err)
+}
+
+func (g *Generator) reserve(ctx context.Context, name string, count int, reference string) (Range, error) {
+	s := New(ID(name), g.seqOpts...)
+	if err := g.repo.Fetch(ctx, s); err != nil {
+		return Range{}, fmt.Errorf("fetch %q sequence: %w", name, err)
+	}
+
+	r, err := s.Reserve(count, reference)
+	if err != nil {
+		return Range{}, err
+	}
+
+	if err := g.repo.Save(ctx, s); err != nil {
+		return Range{}, fmt.Errorf("save %q sequence: %w", name, err)
+	}
+
+	return r, nil
+}
+
+// Reservation returns the reservation of the given sequence with the given
+// reference.
+func (g *Generator) Reservation(ctx context.Context, name, reference string) (Range, bool, error) {
+	s := New(ID(name), g.seqOpts...)
+	if err := g.repo.Fetch(ctx, s); err != nil {
+		return Range{}, false, fmt.Errorf("fetch %q sequence: %w", name, err)
+	}
+	r, ok := s.Reservation(reference)
+	return r, ok, nil
+}
+
+func (g *Generator) wait(ctx context.Context, attempt int) error {
+	delay := g.backoff << (attempt - 1)
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,238 @@
+// Package sequence provides an event-sourced generator for sequential numbers,
+// like invoice or ticket numbers.
+//
+// Each named sequence is a Sequence aggregate whose id is derived from the
+// name of the sequence (see ID). Numbers are handed out in reservations, and
+// every reservation is recorded as an event, so the numbers of a sequence are
+// unique and strictly increasing. Sequences are gap-tolerant: reserved
+// numbers are never handed out again, even if the caller fails to use them.
+//
+//	gen := sequence.NewGenerator(repository.New(store))
+//	n, err := gen.Next(ctx, "invoices")
+//
+// Concurrent reservations of the same sequence are resolved using the
+// optimistic concurrency of the event store: if another reservation was saved
+// in the meantime, the reservation is retried on the latest state of the
+// sequence (see Retry). The event store must therefore reject events whose
+// aggregate version already exists, as the MongoDB event store does by
+// default.
+//
+// A reservation can be given a reference, like the id of the invoice that the
+// number is reserved for. Reservations with the same reference are
+// idempotent; they return the numbers of the first reservation. This allows
+// to reserve numbers through commands, which may be delivered more than once:
+//
+//	errs := sequence.HandleCommands(ctx, commandBus, gen)
+//	cmd := sequence.Reserve("invoices", 1, invoiceID.String())
+//	err := commandBus.Dispatch(ctx, cmd.Any(), dispatch.Sync())
+//	r, ok, err := gen.Reservation(ctx, "invoices", invoiceID.String())
+//
+// A sequence only remembers the references of its most recent reservations
+// (see MaxReferences), so references must not be reused after a long time.
+//
+// Because every reservation is an event, fetching a sequence becomes slower
+// the more numbers were reserved. Sequences implement snapshot.Marshaler and
+// snapshot.Unmarshaler, so long-lived sequences should be stored in a
+// repository that takes snapshots:
+//
+//	repo := repository.New(store, repository.WithSnapshots(snapshots, snapshot.Every(100)))
+//	gen := sequence.NewGenerator(repo)
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/natural"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// Aggregate is the name of the Sequence aggregate.
+const Aggregate = "goes.contrib.sequence"
+
+// Reserved is raised when numbers of a sequence are reserved.
+const Reserved = "goes.contrib.sequence.reserved"
+
+// DefaultMaxReferences is the default number of references that a sequence
+// remembers (see MaxReferences).
+const DefaultMaxReferences = 1000
+
+// ErrInvalidCount is returned when trying to reserve less than one number.
+var ErrInvalidCount = errors.New("invalid count")
+
+// ReservedData is the event data for Reserved.
+type ReservedData struct {
+	Range
+	Reference string `json:",omitempty"`
+}
+
+// RegisterEvents registers the events of the sequence package into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[ReservedData](r, Reserved)
+}
+
+// ID returns the aggregate id of the sequence with the given name.
+func ID(name string) uuid.UUID {
+	return natural.ID(Aggregate, name)
+}
+
+// Range is a range of reserved numbers.
+type Range struct {
+	// First is the first reserved number.
+	First int64
+
+	// Last is the last reserved number.
+	Last int64
+}
+
+// Len returns the number of numbers in the range.
+func (r Range) Len() int {
+	return int(r.Last - r.First + 1)
+}
+
+// Numbers returns the numbers in the range.
+func (r Range) Numbers() []int64 {
+	out := make([]int64, 0, r.Len())
+	for n := r.First; n <= r.Last; n++ {
+		out = append(out, n)
+	}
+	return out
+}
+
+// Sequence is an aggregate that hands out sequential numbers, starting at 1.
+type Sequence struct {
+	*aggregate.Base
+
+	last          int64
+	references    map[string]Range
+	order         []string
+	maxReferences int
+}
+
+// Option is an option for a Sequence.
+type Option func(*Sequence)
+
+// MaxReferences returns an Option that limits the number of references that a
+// sequence remembers. When a reservation with a new reference exceeds the
+// limit, the reference of the oldest reservation is forgotten, and a
+// reservation with that reference reserves new numbers. A limit of zero or
+// less remembers all references. Defaults to DefaultMaxReferences.
+func MaxReferences(n int) Option {
+	return func(s *Sequence) {
+		s.maxReferences = n
+	}
+}
+
+// New returns the sequence with the given id.
+func New(id uuid.UUID, opts ...Option) *Sequence {
+	s := &Sequence{
+		Base:          aggregate.New(Aggregate, id),
+		references:    make(map[string]Range),
+		maxReferences: DefaultMaxReferences,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	event.ApplyWith(s, s.reserved, Reserved)
+
+	return s
+}
+
+// Last returns the last reserved number, or 0 if no numbers were reserved.
+func (s *Sequence) Last() int64 {
+	return s.last
+}
+
+// Reservation returns the reservation with the given reference.
+func (s *Sequence) Reservation(reference string) (Range, bool) {
+	r, ok := s.references[reference]
+	return r, ok
+}
+
+// Reserve reserves the next count numbers of the sequence. If a reference is
+// provided, and numbers were already reserved with that reference, Reserve
+// returns the existing reservation without raising an event.
+func (s *Sequence) Reserve(count int, reference string) (Range, error) {
+	if count < 1 {
+		return Range{}, fmt.Errorf("%w: %d", ErrInvalidCount, count)
+	}
+
+	if reference != "" {
+		if r, ok := s.references[reference]; ok {
+			return r, nil
+		}
+	}
+
+	r := Range{First: s.last + 1, Last: s.last + int64(count)}
+
+	aggregate.Next(s, Reserved, ReservedData{Range: r, Reference: reference})
+
+	return r, nil
+}
+
+func (s *Sequence) reserved(evt event.Of[ReservedData]) {
+	data := evt.Data()
+	s.last = data.Last
+	if data.Reference != "" {
+		s.remember(data.Reference, data.Range)
+	}
+}
+
+// remember adds a reference and forgets the oldest references that exceed
+// the limit of the sequence.
+func (s *Sequence) remember(reference string, r Range) {
+	if _, ok := s.references[reference]; !ok {
+		s.order = append(s.order, reference)
+	}
+	s.references[reference] = r
+
+	if s.maxReferences <= 0 {
+		return
+	}
+
+	for len(s.order) > s.maxReferences {
+		delete(s.references, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+type snapshotState struct {
+	Last       int64
+	References []referenceState `json:",omitempty"`
+}
+
+type referenceState struct {
+	Reference string
+	Range
+}
+
+// MarshalSnapshot implements snapshot.Marshaler.
+func (s *Sequence) MarshalSnapshot() ([]byte, error) {
+	state := snapshotState{Last: s.last}
+	for _, ref := range s.order {
+		state.References = append(state.References, referenceState{Reference: ref, Range: s.references[ref]})
+	}
+	return json.Marshal(state)
+}
+
+// UnmarshalSnapshot implements snapshot.Unmarshaler.
+func (s *Sequence) UnmarshalSnapshot(b []byte) error {
+	var state snapshotState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+
+	s.last = state.Last
+	s.references = make(map[string]Range, len(state.References))
+	s.order = nil
+	for _, ref := range state.References {
+		s.remember(ref.Reference, ref.Range)
+	}
+
+	return nil
+}
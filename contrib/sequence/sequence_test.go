@@ -0,0 +1,190 @@
+package sequence_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/aggregate/snapshot"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command/cmdbus"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+	"github.com/modernice/goes/contrib/sequence"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+)
+
+func TestSequence_Reserve(t *testing.T) {
+	s := sequence.New(sequence.ID("invoices"))
+
+	if _, err := s.Reserve(0, ""); !errors.Is(err, sequence.ErrInvalidCount) {
+		t.Fatalf("Reserve() should fail with %q; got %v", sequence.ErrInvalidCount, err)
+	}
+
+	r, err := s.Reserve(3, "")
+	if err != nil {
+		t.Fatalf("Reserve() failed with %q", err)
+	}
+	if r != (sequence.Range{First: 1, Last: 3}) {
+		t.Fatalf("Reserve() should reserve 1-3; got %v", r.Numbers())
+	}
+
+	r, _ = s.Reserve(1, "foo")
+	if again, _ := s.Reserve(5, "foo"); again != r || r.First != 4 {
+		t.Fatalf("Reserve() should be idempotent for the reference %q; got %v and %v", "foo", r, again)
+	}
+
+	if n := len(s.AggregateChanges()); n != 2 {
+		t.Fatalf("Sequence should have %d changes; got %d", 2, n)
+	}
+}
+
+func TestSequence_MaxReferences(t *testing.T) {
+	s := sequence.New(sequence.ID("invoices"), sequence.MaxReferences(2))
+
+	foo, _ := s.Reserve(1, "foo")
+	s.Reserve(1, "bar")
+	s.Reserve(1, "baz")
+
+	if _, ok := s.Reservation("foo"); ok {
+		t.Fatalf("Reservation() should forget the oldest reference %q", "foo")
+	}
+
+	if r, ok := s.Reservation("baz"); !ok || r.First != 3 {
+		t.Fatalf("Reservation() should return the reservation of %q; got %v (%v)", "baz", r, ok)
+	}
+
+	if r, _ := s.Reserve(1, "foo"); r == foo {
+		t.Fatalf("Reserve() should reserve new numbers for a forgotten reference")
+	}
+}
+
+func TestGenerator_snapshots(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	snapshots := snapshot.NewStore()
+	gen := sequence.NewGenerator(repository.New(store, repository.WithSnapshots(snapshots, snapshot.Every(2))))
+
+	for i := 0; i < 5; i++ {
+		if _, err := gen.Reserve(ctx, "invoices", 1, fmt.Sprintf("ref-%d", i)); err != nil {
+			t.Fatalf("Reserve() failed with %q", err)
+		}
+	}
+
+	if _, err := snapshots.Latest(ctx, sequence.Aggregate, sequence.ID("invoices")); err != nil {
+		t.Fatalf("a snapshot of the sequence should have been taken; Latest() failed with %q", err)
+	}
+
+	if n, err := gen.Next(ctx, "invoices"); err != nil || n != 6 {
+		t.Fatalf("Next() should return %d; got %d (%v)", 6, n, err)
+	}
+
+	if r, ok, err := gen.Reservation(ctx, "invoices", "ref-1"); err != nil || !ok || r.First != 2 {
+		t.Fatalf("Reservation() should return the reservation of %q; got %v (%v, %v)", "ref-1", r, ok, err)
+	}
+}
+
+func TestGenerator_Next_concurrent(t *testing.T) {
+	ctx := context.Background()
+	gen := sequence.NewGenerator(repository.New(newVersionedStore()), sequence.Retry(100, time.Millisecond))
+
+	const n = 20
+
+	var mux sync.Mutex
+	var numbers []int64
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			num, err := gen.Next(ctx, "tickets")
+			if err != nil {
+				t.Errorf("Next() failed with %q", err)
+				return
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			numbers = append(numbers, num)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for i, num := range numbers {
+		if num != int64(i+1) {
+			t.Fatalf("numbers should be unique and sequential; got %v", numbers)
+		}
+	}
+}
+
+func TestHandleCommands(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := codec.New()
+	sequence.RegisterCommands(reg)
+
+	bus := cmdbus.New[int](reg, eventbus.New())
+	errs, err := bus.Run(ctx)
+	if err != nil {
+		t.Fatalf("run command bus: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	gen := sequence.NewGenerator(repository.New(eventstore.New()))
+	go testutil.PanicOn(sequence.HandleCommands(ctx, bus, gen))
+
+	for i := 0; i < 2; i++ {
+		if err := bus.Dispatch(ctx, sequence.Reserve("invoices", 2, "inv-1").Any(), dispatch.Sync()); err != nil {
+			t.Fatalf("dispatch command: %v", err)
+		}
+	}
+
+	r, ok, err := gen.Reservation(ctx, "invoices", "inv-1")
+	if err != nil {
+		t.Fatalf("Reservation() failed with %q", err)
+	}
+	if !ok || r != (sequence.Range{First: 1, Last: 2}) {
+		t.Fatalf("reservation should be 1-2; got %v (found=%v)", r, ok)
+	}
+
+	if n, err := gen.Next(ctx, "invoices"); err != nil || n != 3 {
+		t.Fatalf("Next() should return %d; got %d (%v)", 3, n, err)
+	}
+}
+
+// versionedStore rejects events whose aggregate version already exists.
+type versionedStore struct {
+	event.Store
+
+	mux      sync.Mutex
+	versions map[aggregate.Ref]int
+}
+
+func newVersionedStore() *versionedStore {
+	return &versionedStore{Store: eventstore.New(), versions: make(map[aggregate.Ref]int)}
+}
+
+func (s *versionedStore) Insert(ctx context.Context, events ...event.Event) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, evt := range events {
+		id, name, v := evt.Aggregate()
+		ref := aggregate.Ref{Name: name, ID: id}
+		if v <= s.versions[ref] {
+			return &aggregate.ConsistencyError{Kind: aggregate.InconsistentVersion, Aggregate: ref, CurrentVersion: s.versions[ref]}
+		}
+		s.versions[ref] = v
+	}
+
+	return s.Store.Insert(ctx, events...)
+}
@@ -0,0 +1,43 @@
+package settings
+
+import (
+	"encoding/json"
+
+	"github.com/modernice/goes/codec"
+)
+
+const (
+	// ValueSet is raised when a setting is set to a new value.
+	ValueSet = "goes.contrib.settings.value_set"
+
+	// ValueRemoved is raised when a setting is removed.
+	ValueRemoved = "goes.contrib.settings.value_removed"
+)
+
+// Audit is the audit information of a change.
+type Audit struct {
+	// Actor is the user or service that made the change.
+	Actor string `json:",omitempty"`
+
+	// Comment is the reason for the change.
+	Comment string `json:",omitempty"`
+}
+
+// ValueSetData is the event data for ValueSet.
+type ValueSetData struct {
+	Key   string
+	Value json.RawMessage
+	Audit
+}
+
+// ValueRemovedData is the event data for ValueRemoved.
+type ValueRemovedData struct {
+	Key string
+	Audit
+}
+
+// RegisterEvents registers the events of the settings package into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[ValueSetData](r, ValueSet)
+	codec.Register[ValueRemovedData](r, ValueRemoved)
+}
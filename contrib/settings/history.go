@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Change is a change to a setting.
+type Change struct {
+	// Time is the time of the change.
+	Time time.Time
+
+	// Version is the version of the Settings aggregate after the change.
+	Version int
+
+	// Key is the key of the changed setting.
+	Key string
+
+	// Value is the JSON-encoded value of the setting after the change. Nil if
+	// the setting was removed.
+	Value json.RawMessage
+
+	// Removed is whether the setting was removed.
+	Removed bool
+
+	Audit
+}
+
+// History returns the changes of the settings of the Settings aggregate with
+// the given id, oldest first. If keys are provided, only the changes to those
+// settings are returned.
+func History(ctx context.Context, store event.Store, id uuid.UUID, keys ...string) ([]Change, error) {
+	str, errs, err := store.Query(ctx, query.New(
+		query.Name(projectorEvents[:]...),
+		query.Aggregate(Aggregate, id),
+		query.SortBy(event.SortAggregateVersion, event.SortAsc),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+
+	filter := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		filter[k] = true
+	}
+
+	var changes []Change
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		_, _, v := evt.Aggregate()
+		c := Change{Time: evt.Time(), Version: v}
+
+		switch data := evt.Data().(type) {
+		case ValueSetData:
+			c.Key, c.Value, c.Audit = data.Key, data.Value, data.Audit
+		case ValueRemovedData:
+			c.Key, c.Removed, c.Audit = data.Key, true, data.Audit
+		default:
+			return nil
+		}
+
+		if len(filter) == 0 || filter[c.Key] {
+			changes = append(changes, c)
+		}
+
+		return nil
+	}, str, errs); err != nil {
+		return changes, fmt.Errorf("query events: %w", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,149 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/pick"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/projection"
+	"github.com/modernice/goes/projection/schedule"
+)
+
+var projectorEvents = [...]string{ValueSet, ValueRemoved}
+
+// Values is the read-model of the settings of a namespace. Values is safe for
+// concurrent use.
+type Values struct {
+	*projection.Base
+	*projection.Progressor
+
+	id     uuid.UUID
+	mux    sync.RWMutex
+	values map[string]json.RawMessage
+}
+
+func newValues(id uuid.UUID) *Values {
+	v := &Values{
+		Base:       projection.New(),
+		Progressor: projection.NewProgressor(),
+		id:         id,
+		values:     make(map[string]json.RawMessage),
+	}
+
+	event.ApplyWith(v, v.set, ValueSet)
+	event.ApplyWith(v, v.remove, ValueRemoved)
+
+	return v
+}
+
+// Value returns the JSON-encoded value of the given setting.
+func (v *Values) Value(key string) (json.RawMessage, bool) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	b, ok := v.values[key]
+	return b, ok
+}
+
+// Keys returns the sorted keys of the settings.
+func (v *Values) Keys() []string {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	return sortedKeys(v.values)
+}
+
+// GuardProjection implements projection.Guard.
+func (v *Values) GuardProjection(evt event.Event) bool {
+	return pick.AggregateID(evt) == v.id
+}
+
+func (v *Values) set(evt event.Of[ValueSetData]) {
+	data := evt.Data()
+	v.values[data.Key] = data.Value
+}
+
+func (v *Values) remove(evt event.Of[ValueRemovedData]) {
+	delete(v.values, evt.Data().Key)
+}
+
+// Projector continuously projects the settings of all namespaces into
+// in-memory Values.
+type Projector struct {
+	schedule *schedule.Continuous
+
+	mux        sync.Mutex
+	namespaces map[uuid.UUID]*Values
+}
+
+// NewProjector returns a new settings projector.
+func NewProjector(bus event.Bus, store event.Store, opts ...schedule.ContinuousOption) *Projector {
+	return &Projector{
+		schedule:   schedule.Continuously(bus, store, projectorEvents[:], opts...),
+		namespaces: make(map[uuid.UUID]*Values),
+	}
+}
+
+// Run projects the settings until ctx is canceled. Run initially projects the
+// settings from the event store, and then applies changes as they are
+// published.
+func (proj *Projector) Run(ctx context.Context) (<-chan error, error) {
+	errs, err := proj.schedule.Subscribe(ctx, proj.applyJob)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to projection schedule: %w", err)
+	}
+
+	go proj.schedule.Trigger(ctx)
+
+	return errs, nil
+}
+
+// Namespace returns the settings of the given namespace. The returned Values
+// are updated by the projector, so they always reflect the latest projected
+// settings of the namespace.
+func (proj *Projector) Namespace(name string) *Values {
+	return proj.Values(ID(name))
+}
+
+// Values returns the settings of the Settings aggregate with the given id.
+func (proj *Projector) Values(id uuid.UUID) *Values {
+	proj.mux.Lock()
+	defer proj.mux.Unlock()
+
+	v, ok := proj.namespaces[id]
+	if !ok {
+		v = newValues(id)
+		proj.namespaces[id] = v
+	}
+
+	return v
+}
+
+func (proj *Projector) applyJob(ctx projection.Job) error {
+	refs, errs, err := ctx.Aggregates(ctx, Aggregate)
+	if err != nil {
+		return fmt.Errorf("extract aggregates from job: %w", err)
+	}
+
+	ids, err := streams.Drain(ctx, refs, errs)
+	if err != nil {
+		return fmt.Errorf("extract aggregates from job: %w", err)
+	}
+
+	for _, ref := range ids {
+		if err := proj.apply(ctx, proj.Values(ref.ID)); err != nil {
+			return fmt.Errorf("apply settings: %w [id=%v]", err, ref.ID)
+		}
+	}
+
+	return nil
+}
+
+func (proj *Projector) apply(ctx projection.Job, v *Values) error {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	return ctx.Apply(ctx, v)
+}
@@ -0,0 +1,224 @@
+// Package settings provides an event-sourced aggregate for runtime
+// configuration and feature flags. Every change to a setting is an event, so
+// the history of the Settings aggregate is the audit log of the configuration.
+//
+// Settings are grouped into namespaces. Each namespace is a Settings aggregate
+// whose id is derived from the namespace name (see ID):
+//
+//	repo := repository.Typed(repository.New(store), settings.New)
+//	s, err := repo.Fetch(ctx, settings.ID("checkout"))
+//	s.Set("max_items", 50, settings.By("admin@example.com"))
+//	s.Set("new_payment_flow", true, settings.Comment("rollout"))
+//	err = repo.Save(ctx, s)
+//
+// Applications read settings from a Projector, which keeps the current values
+// of all namespaces in memory:
+//
+//	proj := settings.NewProjector(bus, store)
+//	errs, err := proj.Run(ctx)
+//	values := proj.Namespace("checkout")
+//	maxItems := settings.GetOr(values, "max_items", 20)
+//	if settings.Enabled(values, "new_payment_flow") { ... }
+//
+// History returns the audited changes of a namespace:
+//
+//	changes, err := settings.History(ctx, store, settings.ID("checkout"), "max_items")
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/natural"
+	"github.com/modernice/goes/event"
+)
+
+// Aggregate is the name of the Settings aggregate.
+const Aggregate = "goes.contrib.settings"
+
+var (
+	// ErrEmptyKey is returned when trying to set or remove a setting without a key.
+	ErrEmptyKey = errors.New("empty key")
+
+	// ErrNotFound is returned by Get if a setting does not exist.
+	ErrNotFound = errors.New("setting not found")
+)
+
+// ID returns the aggregate id of the Settings aggregate of the given namespace.
+func ID(namespace string) uuid.UUID {
+	return natural.ID(Aggregate, namespace)
+}
+
+// Source provides the encoded values of settings. Both the Settings aggregate
+// and the Values read-model implement Source.
+type Source interface {
+	// Value returns the JSON-encoded value of the given setting.
+	Value(key string) (json.RawMessage, bool)
+}
+
+// Settings is an aggregate that keeps the settings of a namespace. Values are
+// stored as JSON and can be read using Get, GetOr and Enabled.
+type Settings struct {
+	*aggregate.Base
+
+	values map[string]json.RawMessage
+}
+
+// New returns the Settings aggregate with the given id.
+func New(id uuid.UUID) *Settings {
+	s := &Settings{
+		Base:   aggregate.New(Aggregate, id),
+		values: make(map[string]json.RawMessage),
+	}
+
+	event.ApplyWith(s, s.set, ValueSet)
+	event.ApplyWith(s, s.remove, ValueRemoved)
+
+	return s
+}
+
+// ChangeOption is an option for Set and Remove.
+type ChangeOption func(*Audit)
+
+// By returns a ChangeOption that records the actor that made a change.
+func By(actor string) ChangeOption {
+	return func(a *Audit) {
+		a.Actor = actor
+	}
+}
+
+// Comment returns a ChangeOption that records the reason for a change.
+func Comment(comment string) ChangeOption {
+	return func(a *Audit) {
+		a.Comment = comment
+	}
+}
+
+func newAudit(opts []ChangeOption) Audit {
+	var a Audit
+	for _, opt := range opts {
+		opt(&a)
+	}
+	return a
+}
+
+// Value returns the JSON-encoded value of the given setting.
+func (s *Settings) Value(key string) (json.RawMessage, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Keys returns the sorted keys of the settings.
+func (s *Settings) Keys() []string {
+	return sortedKeys(s.values)
+}
+
+// Set sets the given setting to the JSON encoding of value. Set does not raise
+// an event if the setting already has the given value.
+func (s *Settings) Set(key string, value any, opts ...ChangeOption) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal %q setting: %w", key, err)
+	}
+
+	if current, ok := s.values[key]; ok && bytes.Equal(current, b) {
+		return nil
+	}
+
+	aggregate.Next(s, ValueSet, ValueSetData{
+		Key:   key,
+		Value: b,
+		Audit: newAudit(opts),
+	})
+
+	return nil
+}
+
+func (s *Settings) set(evt event.Of[ValueSetData]) {
+	data := evt.Data()
+	s.values[data.Key] = data.Value
+}
+
+// Enable sets the given feature flag to true.
+func (s *Settings) Enable(flag string, opts ...ChangeOption) error {
+	return s.Set(flag, true, opts...)
+}
+
+// Disable sets the given feature flag to false.
+func (s *Settings) Disable(flag string, opts ...ChangeOption) error {
+	return s.Set(flag, false, opts...)
+}
+
+// Remove removes the given setting. Remove does not raise an event if the
+// setting does not exist.
+func (s *Settings) Remove(key string, opts ...ChangeOption) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+
+	if _, ok := s.values[key]; !ok {
+		return nil
+	}
+
+	aggregate.Next(s, ValueRemoved, ValueRemovedData{
+		Key:   key,
+		Audit: newAudit(opts),
+	})
+
+	return nil
+}
+
+func (s *Settings) remove(evt event.Of[ValueRemovedData]) {
+	delete(s.values, evt.Data().Key)
+}
+
+// Get returns the value of the given setting, decoded into a T. If the setting
+// does not exist, Get returns an error that satisfies errors.Is(err, ErrNotFound).
+func Get[T any](src Source, key string) (T, error) {
+	var out T
+
+	b, ok := src.Value(key)
+	if !ok {
+		return out, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		return out, fmt.Errorf("unmarshal %q setting: %w", key, err)
+	}
+
+	return out, nil
+}
+
+// GetOr returns the value of the given setting, decoded into a T, or def if
+// the setting does not exist or cannot be decoded into a T.
+func GetOr[T any](src Source, key string, def T) T {
+	v, err := Get[T](src, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// Enabled returns whether the given feature flag is set to true. Flags that
+// do not exist or are not booleans are disabled.
+func Enabled(src Source, flag string) bool {
+	return GetOr(src, flag, false)
+}
+
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
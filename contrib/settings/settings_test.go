@@ -0,0 +1,131 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/contrib/settings"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+	"github.com/modernice/goes/projection/schedule"
+)
+
+type limits struct {
+	MaxItems int
+}
+
+func TestSettings(t *testing.T) {
+	s := settings.New(settings.ID("checkout"))
+
+	if err := s.Set("", 1); !errors.Is(err, settings.ErrEmptyKey) {
+		t.Fatalf("Set() should fail with %q; got %v", settings.ErrEmptyKey, err)
+	}
+
+	s.Set("limits", limits{MaxItems: 50}, settings.By("admin"), settings.Comment("black friday"))
+	s.Set("limits", limits{MaxItems: 50})
+	s.Enable("new_flow")
+	s.Remove("unknown")
+
+	if n := len(s.AggregateChanges()); n != 2 {
+		t.Fatalf("Settings should have %d changes; got %d", 2, n)
+	}
+
+	l, err := settings.Get[limits](s, "limits")
+	if err != nil {
+		t.Fatalf("Get() failed with %q", err)
+	}
+	if l.MaxItems != 50 {
+		t.Fatalf("MaxItems should be %d; got %d", 50, l.MaxItems)
+	}
+
+	if _, err := settings.Get[int](s, "missing"); !errors.Is(err, settings.ErrNotFound) {
+		t.Fatalf("Get() should fail with %q; got %v", settings.ErrNotFound, err)
+	}
+
+	if v := settings.GetOr(s, "limits", 3); v != 3 {
+		t.Fatalf("GetOr() should return the default for undecodable values; got %v", v)
+	}
+
+	if !settings.Enabled(s, "new_flow") || settings.Enabled(s, "other_flow") {
+		t.Fatalf("only %q should be enabled", "new_flow")
+	}
+
+	s.Remove("new_flow")
+	if settings.Enabled(s, "new_flow") {
+		t.Fatalf("%q should be disabled after removal", "new_flow")
+	}
+}
+
+func TestProjector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	repo := repository.Typed(repository.New(store), settings.New)
+
+	initial := settings.New(settings.ID("checkout"))
+	initial.Set("max_items", 10)
+	if err := repo.Save(ctx, initial); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	proj := settings.NewProjector(bus, store, schedule.Debounce(10*time.Millisecond))
+	errs, err := proj.Run(ctx)
+	if err != nil {
+		t.Fatalf("run projector: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	values := proj.Namespace("checkout")
+	waitFor(t, func() bool { return settings.GetOr(values, "max_items", 0) == 10 })
+
+	s, err := repo.Fetch(ctx, settings.ID("checkout"))
+	if err != nil {
+		t.Fatalf("fetch settings: %v", err)
+	}
+	s.Set("max_items", 20, settings.By("admin"))
+	s.Enable("new_flow", settings.Comment("rollout"))
+	if err := repo.Save(ctx, s); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	waitFor(t, func() bool { return settings.Enabled(values, "new_flow") })
+
+	if v := settings.GetOr(values, "max_items", 0); v != 20 {
+		t.Fatalf("max_items should be %d; got %d", 20, v)
+	}
+
+	if keys := proj.Namespace("other").Keys(); len(keys) != 0 {
+		t.Fatalf("other namespace should be empty; got %v", keys)
+	}
+
+	changes, err := settings.History(ctx, store, settings.ID("checkout"), "max_items")
+	if err != nil {
+		t.Fatalf("History() failed with %q", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("History() should return %d changes; got %d", 2, len(changes))
+	}
+
+	if c := changes[1]; string(c.Value) != "20" || c.Actor != "admin" || c.Version != 2 {
+		t.Fatalf("unexpected change: %+v", c)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for !cond() {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
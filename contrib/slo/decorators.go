@@ -0,0 +1,152 @@
+package slo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+)
+
+// Store decorates the given event store so that the latency of its operations
+// is measured.
+func (m *Monitor) Store(s event.Store) event.Store {
+	return &store{Store: s, mon: m}
+}
+
+type store struct {
+	event.Store
+
+	mon *Monitor
+}
+
+func (s *store) Insert(ctx context.Context, events ...event.Event) error {
+	done := s.mon.start(ctx, Operation{Op: StoreInsert})
+	err := s.Store.Insert(ctx, events...)
+	done(Operation{Events: events, Err: err})
+	return err
+}
+
+func (s *store) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	done := s.mon.start(ctx, Operation{Op: StoreFind})
+	evt, err := s.Store.Find(ctx, id)
+	done(Operation{EventID: id, Err: err})
+	return evt, err
+}
+
+func (s *store) Delete(ctx context.Context, events ...event.Event) error {
+	done := s.mon.start(ctx, Operation{Op: StoreDelete})
+	err := s.Store.Delete(ctx, events...)
+	done(Operation{Events: events, Err: err})
+	return err
+}
+
+// Query measures the query until its result stream is closed.
+func (s *store) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	done := s.mon.start(ctx, Operation{Op: StoreQuery})
+
+	events, errs, err := s.Store.Query(ctx, q)
+	if err != nil {
+		done(Operation{Query: q, Err: err})
+		return events, errs, err
+	}
+
+	out := make(chan event.Event)
+	outErrs := make(chan error)
+
+	go func() {
+		defer close(out)
+		defer close(outErrs)
+
+		var (
+			results int
+			lastErr error
+		)
+		defer func() { done(Operation{Query: q, Results: results, Err: lastErr}) }()
+
+		for events != nil || errs != nil {
+			select {
+			case <-ctx.Done():
+				lastErr = ctx.Err()
+				return
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					break
+				}
+				lastErr = err
+				select {
+				case <-ctx.Done():
+					return
+				case outErrs <- err:
+				}
+			case evt, ok := <-events:
+				if !ok {
+					events = nil
+					break
+				}
+				results++
+				select {
+				case <-ctx.Done():
+					lastErr = ctx.Err()
+					return
+				case out <- evt:
+				}
+			}
+		}
+	}()
+
+	return out, outErrs, nil
+}
+
+// Stats returns the statistics of the decorated store.
+func (s *store) Stats(ctx context.Context) (event.StoreStats, error) {
+	return eventstore.Stats(ctx, s.Store)
+}
+
+// Bus decorates the given event bus so that the latency of its operations is
+// measured.
+func (m *Monitor) Bus(b event.Bus) event.Bus {
+	return &bus{Bus: b, mon: m}
+}
+
+type bus struct {
+	event.Bus
+
+	mon *Monitor
+}
+
+func (b *bus) Publish(ctx context.Context, events ...event.Event) error {
+	done := b.mon.start(ctx, Operation{Op: BusPublish})
+	err := b.Bus.Publish(ctx, events...)
+	done(Operation{Events: events, Err: err})
+	return err
+}
+
+func (b *bus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	done := b.mon.start(ctx, Operation{Op: BusSubscribe})
+	events, errs, err := b.Bus.Subscribe(ctx, names...)
+	done(Operation{EventNames: names, Err: err})
+	return events, errs, err
+}
+
+// Commands decorates the given command bus so that the latency of dispatched
+// commands is measured. When dispatching synchronously, the measured duration
+// includes the execution of the command.
+func (m *Monitor) Commands(b command.Bus) command.Bus {
+	return &commands{Bus: b, mon: m}
+}
+
+type commands struct {
+	command.Bus
+
+	mon *Monitor
+}
+
+func (b *commands) Dispatch(ctx context.Context, cmd command.Command, opts ...command.DispatchOption) error {
+	done := b.mon.start(ctx, Operation{Op: CommandDispatch})
+	err := b.Bus.Dispatch(ctx, cmd, opts...)
+	done(Operation{Command: cmd, Err: err})
+	return err
+}
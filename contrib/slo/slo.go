@@ -0,0 +1,269 @@
+// Package slo measures the latency of event store, event bus, and command bus
+// operations and reports operations that exceed configurable thresholds, so
+// that latency SLOs can be monitored and slow queries, events, and commands
+// can be identified.
+//
+//	mon := slo.New(
+//		slo.Threshold(slo.StoreQuery, 200*time.Millisecond),
+//		slo.OnOperation(func(ctx context.Context, op slo.Operation) {
+//			latency.WithLabelValues(string(op.Op)).Observe(op.Duration.Seconds())
+//		}),
+//	)
+//	store = mon.Store(store)
+//	bus = mon.Bus(bus)
+//	commands = mon.Commands(commands)
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/event"
+)
+
+// DefaultThreshold is the default threshold of all operations.
+const DefaultThreshold = time.Second
+
+// Op is an operation that is measured by a Monitor.
+type Op string
+
+// Operations of event stores, event buses, and command buses.
+const (
+	StoreInsert     = Op("store.insert")
+	StoreFind       = Op("store.find")
+	StoreQuery      = Op("store.query")
+	StoreDelete     = Op("store.delete")
+	BusPublish      = Op("bus.publish")
+	BusSubscribe    = Op("bus.subscribe")
+	CommandDispatch = Op("command.dispatch")
+)
+
+// Operation is a measured operation.
+type Operation struct {
+	// Op is the operation.
+	Op Op
+
+	// Duration is the duration of the operation. The duration of a query is
+	// measured until its result stream is closed, so it includes the time
+	// that the caller needs to consume the result.
+	Duration time.Duration
+
+	// Threshold is the threshold of the operation.
+	Threshold time.Duration
+
+	// Err is the error of the operation, if any.
+	Err error
+
+	// Events are the inserted, deleted, or published events.
+	Events []event.Event
+
+	// EventID is the id of the event that was fetched by StoreFind.
+	EventID uuid.UUID
+
+	// Query is the query of StoreQuery.
+	Query event.Query
+
+	// Results is the number of events that were returned by StoreQuery.
+	Results int
+
+	// EventNames are the subscribed events of BusSubscribe.
+	EventNames []string
+
+	// Command is the dispatched command of CommandDispatch.
+	Command command.Command
+}
+
+// Slow reports whether the operation exceeded its threshold.
+func (op Operation) Slow() bool {
+	return op.Threshold >= 0 && op.Duration > op.Threshold
+}
+
+// String returns a description of the operation and its subject.
+func (op Operation) String() string {
+	var subject string
+	switch {
+	case op.Command != nil:
+		subject = fmt.Sprintf("command=%s id=%s", op.Command.Name(), op.Command.ID())
+	case op.Query != nil:
+		subject = fmt.Sprintf("query=%s results=%d", describeQuery(op.Query), op.Results)
+	case op.EventID != uuid.Nil:
+		subject = fmt.Sprintf("event=%s", op.EventID)
+	case len(op.Events) > 0:
+		subject = fmt.Sprintf("events=%s", describeEvents(op.Events))
+	case len(op.EventNames) > 0:
+		subject = fmt.Sprintf("names=%v", op.EventNames)
+	}
+
+	out := fmt.Sprintf("%s took %s (threshold %s)", op.Op, op.Duration, op.Threshold)
+	if subject != "" {
+		out += " " + subject
+	}
+	if op.Err != nil {
+		out += fmt.Sprintf(" err=%q", op.Err)
+	}
+	return out
+}
+
+// Monitor measures the latency of operations. Use the Store, Bus, and
+// Commands methods to decorate event stores, event buses, and command buses.
+type Monitor struct {
+	defaultThreshold time.Duration
+	thresholds       map[Op]time.Duration
+	onOperation      []func(context.Context, Operation)
+	onSlow           []func(context.Context, Operation)
+	logger           *log.Logger
+	now              func() time.Time
+}
+
+// Option is an option for a Monitor.
+type Option func(*Monitor)
+
+// Threshold returns an Option that sets the threshold of the given operation.
+// A negative threshold disables the reporting of slow operations for op.
+func Threshold(op Op, d time.Duration) Option {
+	return func(m *Monitor) {
+		m.thresholds[op] = d
+	}
+}
+
+// DefaultThresholdOf returns an Option that sets the threshold of operations
+// without a threshold. Defaults to DefaultThreshold.
+func DefaultThresholdOf(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.defaultThreshold = d
+	}
+}
+
+// OnOperation returns an Option that adds a hook that is called after every
+// operation, e.g., to record the durations in a histogram.
+func OnOperation(fn func(context.Context, Operation)) Option {
+	return func(m *Monitor) {
+		m.onOperation = append(m.onOperation, fn)
+	}
+}
+
+// OnSlow returns an Option that adds a hook that is called after operations
+// that exceeded their threshold. If no OnSlow hook is provided, slow
+// operations are logged as warnings.
+func OnSlow(fn func(context.Context, Operation)) Option {
+	return func(m *Monitor) {
+		m.onSlow = append(m.onSlow, fn)
+	}
+}
+
+// Logger returns an Option that sets the logger of slow operations, which is
+// used if no OnSlow hook is provided. Defaults to the standard logger.
+func Logger(l *log.Logger) Option {
+	return func(m *Monitor) {
+		m.logger = l
+	}
+}
+
+// New returns a new Monitor.
+func New(opts ...Option) *Monitor {
+	m := &Monitor{
+		defaultThreshold: DefaultThreshold,
+		thresholds:       make(map[Op]time.Duration),
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ThresholdOf returns the threshold of the given operation.
+func (m *Monitor) ThresholdOf(op Op) time.Duration {
+	if d, ok := m.thresholds[op]; ok {
+		return d
+	}
+	return m.defaultThreshold
+}
+
+// start returns a function that reports op with the duration since the call
+// to start.
+func (m *Monitor) start(ctx context.Context, op Operation) func(Operation) {
+	started := m.now()
+	return func(done Operation) {
+		done.Op = op.Op
+		done.Duration = m.now().Sub(started)
+		done.Threshold = m.ThresholdOf(op.Op)
+		m.report(ctx, done)
+	}
+}
+
+func (m *Monitor) report(ctx context.Context, op Operation) {
+	for _, fn := range m.onOperation {
+		fn(ctx, op)
+	}
+
+	if !op.Slow() {
+		return
+	}
+
+	if len(m.onSlow) == 0 {
+		m.logf("[goes/contrib/slo] WARNING: slow %s", op)
+		return
+	}
+
+	for _, fn := range m.onSlow {
+		fn(ctx, op)
+	}
+}
+
+func (m *Monitor) logf(format string, v ...any) {
+	if m.logger != nil {
+		m.logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
+func describeEvents(events []event.Event) string {
+	const max = 5
+	parts := make([]string, 0, max+1)
+	for i, evt := range events {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("... (%d more)", len(events)-max))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s(%s)", evt.Name(), evt.ID()))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func describeQuery(q event.Query) string {
+	var parts []string
+	if names := q.Names(); len(names) > 0 {
+		parts = append(parts, fmt.Sprintf("names:%v", names))
+	}
+	if ids := q.IDs(); len(ids) > 0 {
+		parts = append(parts, fmt.Sprintf("ids:%d", len(ids)))
+	}
+	if names := q.AggregateNames(); len(names) > 0 {
+		parts = append(parts, fmt.Sprintf("aggregateNames:%v", names))
+	}
+	if ids := q.AggregateIDs(); len(ids) > 0 {
+		parts = append(parts, fmt.Sprintf("aggregateIds:%v", ids))
+	}
+	if refs := q.Aggregates(); len(refs) > 0 {
+		parts = append(parts, fmt.Sprintf("aggregates:%v", refs))
+	}
+	if times := q.Times(); times != nil {
+		if min := times.Min(); !min.IsZero() {
+			parts = append(parts, fmt.Sprintf("after:%s", min.Format(time.RFC3339Nano)))
+		}
+		if max := times.Max(); !max.IsZero() {
+			parts = append(parts, fmt.Sprintf("before:%s", max.Format(time.RFC3339Nano)))
+		}
+	}
+	if sorts := q.Sortings(); len(sorts) > 0 {
+		parts = append(parts, fmt.Sprintf("sortings:%d", len(sorts)))
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
@@ -0,0 +1,167 @@
+package slo_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/contrib/slo"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestMonitor_Store(t *testing.T) {
+	ctx := context.Background()
+
+	var rec recorder
+	mon := slo.New(
+		slo.Threshold(slo.StoreInsert, -1),
+		slo.Threshold(slo.StoreQuery, 20*time.Millisecond),
+		slo.OnOperation(rec.record),
+		slo.OnSlow(rec.slow),
+	)
+	store := mon.Store(eventstore.New())
+
+	foo := event.New[any]("foo", 1)
+	if err := store.Insert(ctx, foo); err != nil {
+		t.Fatalf("Insert failed with %q", err)
+	}
+
+	if _, err := store.Find(ctx, foo.ID()); err != nil {
+		t.Fatalf("Find failed with %q", err)
+	}
+
+	str, errs, err := store.Query(ctx, query.New(query.Name("foo")))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	// the query is measured until its result is consumed
+	time.Sleep(40 * time.Millisecond)
+
+	if _, err := streams.Drain(ctx, str, errs); err != nil {
+		t.Fatalf("Drain failed with %q", err)
+	}
+
+	ops := rec.waitFor(t, 3)
+	if ops[0].Op != slo.StoreInsert || len(ops[0].Events) != 1 {
+		t.Errorf("first operation should be the insert of 1 event; got %s", ops[0])
+	}
+	if ops[1].Op != slo.StoreFind || ops[1].EventID != foo.ID() {
+		t.Errorf("second operation should be the find of %s; got %s", foo.ID(), ops[1])
+	}
+	if ops[2].Op != slo.StoreQuery || ops[2].Results != 1 {
+		t.Errorf("third operation should be the query with 1 result; got %s", ops[2])
+	}
+
+	slow := rec.slowOps()
+	if len(slow) != 1 || slow[0].Op != slo.StoreQuery {
+		t.Fatalf("only the query should be slow; got %v", slow)
+	}
+
+	if !strings.Contains(slow[0].String(), "names:[foo]") {
+		t.Errorf("slow query should be described; got %q", slow[0].String())
+	}
+}
+
+func TestMonitor_Bus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var rec recorder
+	mon := slo.New(slo.DefaultThresholdOf(time.Hour), slo.OnOperation(rec.record))
+	bus := mon.Bus(eventbus.New())
+
+	if _, _, err := bus.Subscribe(ctx, "foo"); err != nil {
+		t.Fatalf("Subscribe failed with %q", err)
+	}
+
+	if err := bus.Publish(ctx, event.New[any]("foo", 1)); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+
+	ops := rec.waitFor(t, 2)
+	if ops[0].Op != slo.BusSubscribe || len(ops[0].EventNames) != 1 {
+		t.Errorf("first operation should be the subscription; got %s", ops[0])
+	}
+	if ops[1].Op != slo.BusPublish || ops[1].Slow() {
+		t.Errorf("second operation should be the publish, which is not slow; got %s", ops[1])
+	}
+}
+
+func TestMonitor_Commands_log(t *testing.T) {
+	var buf bytes.Buffer
+	mon := slo.New(slo.Threshold(slo.CommandDispatch, 0), slo.Logger(log.New(&buf, "", 0)))
+
+	bus := mon.Commands(slowCommandBus{delay: 5 * time.Millisecond})
+	cmd := command.New("foo", 1).Any()
+
+	if err := bus.Dispatch(context.Background(), cmd); err != nil {
+		t.Fatalf("Dispatch failed with %q", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "slow command.dispatch") || !strings.Contains(out, cmd.ID().String()) {
+		t.Fatalf("slow command should be logged with its id; got %q", out)
+	}
+}
+
+type recorder struct {
+	mux    sync.Mutex
+	ops    []slo.Operation
+	slowed []slo.Operation
+}
+
+func (r *recorder) record(_ context.Context, op slo.Operation) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.ops = append(r.ops, op)
+}
+
+func (r *recorder) slow(_ context.Context, op slo.Operation) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.slowed = append(r.slowed, op)
+}
+
+func (r *recorder) slowOps() []slo.Operation {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]slo.Operation(nil), r.slowed...)
+}
+
+func (r *recorder) waitFor(t *testing.T, n int) []slo.Operation {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		r.mux.Lock()
+		if len(r.ops) >= n {
+			ops := append([]slo.Operation(nil), r.ops...)
+			r.mux.Unlock()
+			return ops
+		}
+		r.mux.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d operations", n)
+	return nil
+}
+
+type slowCommandBus struct {
+	command.Bus
+
+	delay time.Duration
+}
+
+func (b slowCommandBus) Dispatch(context.Context, command.Command, ...command.DispatchOption) error {
+	time.Sleep(b.delay)
+	return nil
+}
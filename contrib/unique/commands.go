@@ -0,0 +1,80 @@
+package unique
+
+import (
+	"context"
+	"time"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Commands
+const (
+	ReserveCmd = "goes.contrib.unique.reserve"
+	ConfirmCmd = "goes.contrib.unique.confirm"
+	ReleaseCmd = "goes.contrib.unique.release"
+)
+
+type reservePayload struct {
+	Scope string
+	Value string
+	Owner aggregate.Ref
+	TTL   time.Duration
+}
+
+// Reserve returns the command to reserve the given value within the given
+// scope for the given owner. If ttl is positive, the reservation expires after
+// ttl unless it is confirmed.
+func Reserve(scope, value string, owner aggregate.Ref, ttl time.Duration) command.Cmd[reservePayload] {
+	return command.New(ReserveCmd, reservePayload{
+		Scope: scope,
+		Value: value,
+		Owner: owner,
+		TTL:   ttl,
+	}, command.Aggregate(Aggregate, ID(scope, value)))
+}
+
+// Confirm returns the command to confirm the reservation of the given value
+// within the given scope.
+func Confirm(scope, value string, owner aggregate.Ref) command.Cmd[aggregate.Ref] {
+	return command.New(ConfirmCmd, owner, command.Aggregate(Aggregate, ID(scope, value)))
+}
+
+// Release returns the command to release the given value within the given
+// scope.
+func Release(scope, value string, owner aggregate.Ref) command.Cmd[aggregate.Ref] {
+	return command.New(ReleaseCmd, owner, command.Aggregate(Aggregate, ID(scope, value)))
+}
+
+// RegisterCommands registers the commands of the unique package into a registry.
+func RegisterCommands(r codec.Registerer) {
+	codec.Register[reservePayload](r, ReserveCmd)
+	codec.Register[aggregate.Ref](r, ConfirmCmd)
+	codec.Register[aggregate.Ref](r, ReleaseCmd)
+}
+
+// HandleCommands handles commands until ctx is canceled.
+func HandleCommands(ctx context.Context, bus command.Bus, reservations Repository) <-chan error {
+	reserveErrors := command.MustHandle(ctx, bus, ReserveCmd, func(ctx command.Ctx[reservePayload]) error {
+		load := ctx.Payload()
+		return reservations.Use(ctx, ctx.AggregateID(), func(r *Reservation) error {
+			return r.Reserve(load.Scope, load.Value, load.Owner, load.TTL)
+		})
+	})
+
+	confirmErrors := command.MustHandle(ctx, bus, ConfirmCmd, func(ctx command.Ctx[aggregate.Ref]) error {
+		return reservations.Use(ctx, ctx.AggregateID(), func(r *Reservation) error {
+			return r.Confirm(ctx.Payload())
+		})
+	})
+
+	releaseErrors := command.MustHandle(ctx, bus, ReleaseCmd, func(ctx command.Ctx[aggregate.Ref]) error {
+		return reservations.Use(ctx, ctx.AggregateID(), func(r *Reservation) error {
+			return r.Release(ctx.Payload())
+		})
+	})
+
+	return streams.FanInAll(reserveErrors, confirmErrors, releaseErrors)
+}
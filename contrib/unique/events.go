@@ -0,0 +1,47 @@
+package unique
+
+import (
+	"time"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/codec"
+)
+
+const (
+	// Reserved is raised when a value is reserved.
+	Reserved = "goes.contrib.unique.reserved"
+
+	// Confirmed is raised when a reservation is confirmed.
+	Confirmed = "goes.contrib.unique.confirmed"
+
+	// Released is raised when a value is released.
+	Released = "goes.contrib.unique.released"
+)
+
+// ReservedData is the event data for Reserved.
+type ReservedData struct {
+	Scope string
+	Value string
+	Owner aggregate.Ref
+
+	// ExpiresAt is the time at which the reservation expires if it is not
+	// confirmed. Zero if the reservation does not expire.
+	ExpiresAt time.Time
+}
+
+// ConfirmedData is the event data for Confirmed.
+type ConfirmedData struct {
+	Owner aggregate.Ref
+}
+
+// ReleasedData is the event data for Released.
+type ReleasedData struct {
+	Owner aggregate.Ref
+}
+
+// RegisterEvents registers the events of the unique package into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[ReservedData](r, Reserved)
+	codec.Register[ConfirmedData](r, Confirmed)
+	codec.Register[ReleasedData](r, Released)
+}
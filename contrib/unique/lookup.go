@@ -0,0 +1,89 @@
+package unique
+
+import (
+	"context"
+	"time"
+
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+	"github.com/modernice/goes/projection/lookup"
+)
+
+const (
+	// LookupOwner looks up the owner of a value.
+	LookupOwner = "owner"
+
+	// LookupStatus looks up the status of a reservation.
+	LookupStatus = "status"
+
+	// LookupExpiresAt looks up the expiry of a pending reservation.
+	LookupExpiresAt = "expiresAt"
+)
+
+var lookupEvents = [...]string{Reserved, Confirmed, Released}
+
+// Lookup provides lookups of the owners of reserved values. Lookups are
+// eventually consistent; use the Reservation aggregate to reserve values.
+type Lookup struct {
+	*lookup.Lookup
+}
+
+// NewLookup returns a new lookup for the owners of reserved values.
+func NewLookup(store event.Store, bus event.Bus, opts ...lookup.Option) *Lookup {
+	return &Lookup{Lookup: lookup.New(store, bus, lookupEvents[:], opts...)}
+}
+
+// Owner returns the owner of the given value within the given scope, or false
+// if the value is free.
+func (l *Lookup) Owner(ctx context.Context, scope, value string) (aggregate.Ref, bool) {
+	select {
+	case <-ctx.Done():
+		return aggregate.Ref{}, false
+	case <-l.Ready():
+	}
+
+	id := ID(scope, value)
+
+	status, err := lookup.Expect[Status](ctx, l.Lookup, Aggregate, LookupStatus, id)
+	if err != nil || status == Free {
+		return aggregate.Ref{}, false
+	}
+
+	if status == Pending {
+		if expiresAt, err := lookup.Expect[time.Time](ctx, l.Lookup, Aggregate, LookupExpiresAt, id); err == nil && !expiresAt.IsZero() && !xtime.Now().Before(expiresAt) {
+			return aggregate.Ref{}, false
+		}
+	}
+
+	owner, err := lookup.Expect[aggregate.Ref](ctx, l.Lookup, Aggregate, LookupOwner, id)
+	if err != nil {
+		return aggregate.Ref{}, false
+	}
+
+	return owner, true
+}
+
+// Available returns whether the given value within the given scope is free.
+func (l *Lookup) Available(ctx context.Context, scope, value string) bool {
+	_, taken := l.Owner(ctx, scope, value)
+	return !taken
+}
+
+// ProvideLookup implements lookup.Data.
+func (data ReservedData) ProvideLookup(p lookup.Provider) {
+	p.Provide(LookupOwner, data.Owner)
+	p.Provide(LookupStatus, Pending)
+	p.Provide(LookupExpiresAt, data.ExpiresAt)
+}
+
+// ProvideLookup implements lookup.Data.
+func (data ConfirmedData) ProvideLookup(p lookup.Provider) {
+	p.Provide(LookupStatus, Taken)
+	p.Remove(LookupExpiresAt)
+}
+
+// ProvideLookup implements lookup.Data.
+func (data ReleasedData) ProvideLookup(p lookup.Provider) {
+	p.Remove(LookupOwner, LookupStatus, LookupExpiresAt)
+}
@@ -0,0 +1,247 @@
+// Package unique provides reservations of unique values, like email addresses
+// or usernames.
+//
+// Checking the uniqueness of a value against a projection is race-prone,
+// because two requests may both see the value as available before either of
+// them is projected. Instead, each value is its own Reservation aggregate,
+// whose id is derived from the value and its scope (see ID). Concurrent
+// reservations of the same value are then detected by the optimistic
+// concurrency of the event store: only one of them can be saved. The event
+// store must therefore reject events whose aggregate version already exists,
+// as the MongoDB event store does by default.
+//
+// A value is first reserved for an owner, usually the aggregate that will use
+// the value. When the owner was successfully created or updated, the
+// reservation is confirmed. Unconfirmed reservations can expire, so that
+// values of failed operations become available again. When the owner no
+// longer uses the value, it is released:
+//
+//	reservations := unique.NewRepository(repository.New(store))
+//	owner := aggregate.Ref{Name: "user", ID: userID}
+//
+//	err := reservations.Use(ctx, unique.ID("email", email), func(r *unique.Reservation) error {
+//		return r.Reserve("email", email, owner, time.Minute)
+//	})
+//	if errors.Is(err, unique.ErrTaken) {
+//		// email is already in use
+//	}
+//
+//	// create the user, then:
+//	err = reservations.Use(ctx, unique.ID("email", email), func(r *unique.Reservation) error {
+//		return r.Confirm(owner)
+//	})
+//
+// The same operations are available as commands (see HandleCommands), and the
+// owners of values can be looked up using a Lookup.
+package unique
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/natural"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// Aggregate is the name of the Reservation aggregate.
+const Aggregate = "goes.contrib.unique.reservation"
+
+var (
+	// ErrEmptyValue is returned when trying to reserve an empty value or a
+	// value without a scope.
+	ErrEmptyValue = errors.New("empty value")
+
+	// ErrMissingOwner is returned when trying to reserve a value without an
+	// owner.
+	ErrMissingOwner = errors.New("missing owner")
+
+	// ErrTaken is returned when trying to reserve a value that is reserved by
+	// another owner.
+	ErrTaken = errors.New("value is already taken")
+
+	// ErrNotReserved is returned when trying to confirm a value that is not
+	// reserved.
+	ErrNotReserved = errors.New("value is not reserved")
+
+	// ErrNotOwner is returned when trying to confirm or release a value that
+	// is reserved by another owner.
+	ErrNotOwner = errors.New("value is reserved by another owner")
+)
+
+// Status is the status of a reservation.
+type Status string
+
+const (
+	// Free means that the value is not reserved.
+	Free = Status("")
+
+	// Pending means that the value is reserved but not yet confirmed.
+	Pending = Status("pending")
+
+	// Taken means that the reservation of the value is confirmed.
+	Taken = Status("taken")
+)
+
+// ID returns the aggregate id of the reservation of the given value within the
+// given scope.
+func ID(scope, value string) uuid.UUID {
+	return natural.ID(Aggregate, natural.Key(scope, value))
+}
+
+// Repository is the repository for Reservations.
+type Repository = aggregate.TypedRepository[*Reservation]
+
+// NewRepository returns the repository for Reservations.
+func NewRepository(repo aggregate.Repository) Repository {
+	return repository.Typed(repo, New)
+}
+
+// Reservation is an aggregate that keeps the reservation of a unique value.
+type Reservation struct {
+	*aggregate.Base
+
+	scope     string
+	value     string
+	owner     aggregate.Ref
+	status    Status
+	expiresAt time.Time
+}
+
+// New returns the reservation with the given id.
+func New(id uuid.UUID) *Reservation {
+	r := &Reservation{Base: aggregate.New(Aggregate, id)}
+
+	event.ApplyWith(r, r.reserved, Reserved)
+	event.ApplyWith(r, r.confirmed, Confirmed)
+	event.ApplyWith(r, r.released, Released)
+
+	return r
+}
+
+// Scope returns the scope of the reserved value.
+func (r *Reservation) Scope() string {
+	return r.scope
+}
+
+// Value returns the reserved value.
+func (r *Reservation) Value() string {
+	return r.value
+}
+
+// Status returns the status of the reservation. Expired reservations are Free.
+func (r *Reservation) Status() Status {
+	if r.expired() {
+		return Free
+	}
+	return r.status
+}
+
+// Owner returns the owner of the value, or false if the value is free.
+func (r *Reservation) Owner() (aggregate.Ref, bool) {
+	if r.Status() == Free {
+		return aggregate.Ref{}, false
+	}
+	return r.owner, true
+}
+
+func (r *Reservation) expired() bool {
+	return r.status == Pending && !r.expiresAt.IsZero() && !xtime.Now().Before(r.expiresAt)
+}
+
+// Reserve reserves the given value within the given scope for the given owner.
+// If ttl is positive, the reservation expires after ttl unless it is
+// confirmed. Reserve returns ErrTaken if the value is reserved by another
+// owner, and does nothing if the value is already reserved by the owner.
+func (r *Reservation) Reserve(scope, value string, owner aggregate.Ref, ttl time.Duration) error {
+	if scope == "" || value == "" {
+		return ErrEmptyValue
+	}
+
+	if owner.Name == "" || owner.ID == uuid.Nil {
+		return ErrMissingOwner
+	}
+
+	if id := ID(scope, value); id != r.AggregateID() {
+		return fmt.Errorf("reservation %s does not belong to %q in scope %q (expected %s)", r.AggregateID(), value, scope, id)
+	}
+
+	if current, ok := r.Owner(); ok {
+		if current == owner {
+			return nil
+		}
+		return fmt.Errorf("%w [scope=%s, value=%s]", ErrTaken, scope, value)
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = xtime.Now().Add(ttl)
+	}
+
+	aggregate.Next(r, Reserved, ReservedData{
+		Scope:     scope,
+		Value:     value,
+		Owner:     owner,
+		ExpiresAt: expiresAt,
+	})
+
+	return nil
+}
+
+func (r *Reservation) reserved(evt event.Of[ReservedData]) {
+	data := evt.Data()
+	r.scope = data.Scope
+	r.value = data.Value
+	r.owner = data.Owner
+	r.status = Pending
+	r.expiresAt = data.ExpiresAt
+}
+
+// Confirm confirms the reservation of the owner, so that it does not expire.
+// An expired reservation can be confirmed as long as the value was not
+// reserved by another owner.
+func (r *Reservation) Confirm(owner aggregate.Ref) error {
+	switch {
+	case r.status == Free:
+		return ErrNotReserved
+	case r.owner != owner:
+		return ErrNotOwner
+	case r.status == Taken:
+		return nil
+	}
+
+	aggregate.Next(r, Confirmed, ConfirmedData{Owner: owner})
+
+	return nil
+}
+
+func (r *Reservation) confirmed(evt event.Of[ConfirmedData]) {
+	r.status = Taken
+	r.expiresAt = time.Time{}
+}
+
+// Release releases the value, so that it can be reserved by other owners.
+// Release does nothing if the value is not reserved.
+func (r *Reservation) Release(owner aggregate.Ref) error {
+	if r.status == Free {
+		return nil
+	}
+
+	if r.owner != owner {
+		return ErrNotOwner
+	}
+
+	aggregate.Next(r, Released, ReleasedData{Owner: owner})
+
+	return nil
+}
+
+func (r *Reservation) released(evt event.Of[ReleasedData]) {
+	r.owner = aggregate.Ref{}
+	r.status = Free
+	r.expiresAt = time.Time{}
+}
@@ -0,0 +1,161 @@
+package unique_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command/cmdbus"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+	"github.com/modernice/goes/contrib/unique"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+)
+
+func TestReservation(t *testing.T) {
+	alice := aggregate.Ref{Name: "user", ID: uuid.New()}
+	bob := aggregate.Ref{Name: "user", ID: uuid.New()}
+
+	r := unique.New(unique.ID("email", "alice@example.com"))
+
+	if err := r.Reserve("email", "bob@example.com", alice, 0); err == nil {
+		t.Fatalf("Reserve() should fail for a value of another reservation")
+	}
+
+	if err := r.Confirm(alice); !errors.Is(err, unique.ErrNotReserved) {
+		t.Fatalf("Confirm() should fail with %q; got %v", unique.ErrNotReserved, err)
+	}
+
+	if err := r.Reserve("email", "alice@example.com", alice, time.Minute); err != nil {
+		t.Fatalf("Reserve() failed with %q", err)
+	}
+
+	if err := r.Reserve("email", "alice@example.com", alice, time.Minute); err != nil {
+		t.Fatalf("Reserve() should be idempotent; failed with %q", err)
+	}
+
+	if err := r.Reserve("email", "alice@example.com", bob, 0); !errors.Is(err, unique.ErrTaken) {
+		t.Fatalf("Reserve() should fail with %q; got %v", unique.ErrTaken, err)
+	}
+
+	if err := r.Confirm(bob); !errors.Is(err, unique.ErrNotOwner) {
+		t.Fatalf("Confirm() should fail with %q; got %v", unique.ErrNotOwner, err)
+	}
+
+	if err := r.Confirm(alice); err != nil {
+		t.Fatalf("Confirm() failed with %q", err)
+	}
+
+	if r.Status() != unique.Taken {
+		t.Fatalf("Status() should be %q; got %q", unique.Taken, r.Status())
+	}
+
+	if err := r.Release(bob); !errors.Is(err, unique.ErrNotOwner) {
+		t.Fatalf("Release() should fail with %q; got %v", unique.ErrNotOwner, err)
+	}
+
+	if err := r.Release(alice); err != nil {
+		t.Fatalf("Release() failed with %q", err)
+	}
+
+	if err := r.Reserve("email", "alice@example.com", bob, 0); err != nil {
+		t.Fatalf("Reserve() should succeed for a released value; failed with %q", err)
+	}
+
+	if n := len(r.AggregateChanges()); n != 4 {
+		t.Fatalf("Reservation should have %d changes; got %d", 4, n)
+	}
+}
+
+func TestReservation_expired(t *testing.T) {
+	alice := aggregate.Ref{Name: "user", ID: uuid.New()}
+	bob := aggregate.Ref{Name: "user", ID: uuid.New()}
+
+	r := unique.New(unique.ID("username", "alice"))
+	if err := r.Reserve("username", "alice", alice, time.Nanosecond); err != nil {
+		t.Fatalf("Reserve() failed with %q", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if _, ok := r.Owner(); ok {
+		t.Fatalf("an expired reservation should have no owner")
+	}
+
+	if err := r.Reserve("username", "alice", bob, 0); err != nil {
+		t.Fatalf("Reserve() should succeed for an expired reservation; failed with %q", err)
+	}
+
+	if owner, _ := r.Owner(); owner != bob {
+		t.Fatalf("owner should be %v; got %v", bob, owner)
+	}
+}
+
+func TestHandleCommands_Lookup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := codec.New()
+	unique.RegisterCommands(reg)
+
+	ebus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), ebus)
+
+	bus := cmdbus.New[int](reg, eventbus.New())
+	errs, err := bus.Run(ctx)
+	if err != nil {
+		t.Fatalf("run command bus: %v", err)
+	}
+	go testutil.PanicOn(errs)
+
+	reservations := unique.NewRepository(repository.New(store))
+	handleErrs := unique.HandleCommands(ctx, bus, reservations)
+	go func() {
+		for range handleErrs {
+		}
+	}()
+
+	l := unique.NewLookup(store, ebus)
+	lerrs, err := l.Run(ctx)
+	if err != nil {
+		t.Fatalf("run lookup: %v", err)
+	}
+	go testutil.PanicOn(lerrs)
+
+	alice := aggregate.Ref{Name: "user", ID: uuid.New()}
+	bob := aggregate.Ref{Name: "user", ID: uuid.New()}
+
+	if err := bus.Dispatch(ctx, unique.Reserve("email", "a@example.com", alice, time.Minute).Any(), dispatch.Sync()); err != nil {
+		t.Fatalf("dispatch reserve command: %v", err)
+	}
+
+	if err := bus.Dispatch(ctx, unique.Reserve("email", "a@example.com", bob, time.Minute).Any(), dispatch.Sync()); err == nil {
+		t.Fatalf("reserving a taken value should fail")
+	}
+
+	if err := bus.Dispatch(ctx, unique.Confirm("email", "a@example.com", alice).Any(), dispatch.Sync()); err != nil {
+		t.Fatalf("dispatch confirm command: %v", err)
+	}
+
+	<-time.After(100 * time.Millisecond)
+
+	if owner, ok := l.Owner(ctx, "email", "a@example.com"); !ok || owner != alice {
+		t.Fatalf("Owner() should return %v; got %v", alice, owner)
+	}
+
+	if err := bus.Dispatch(ctx, unique.Release("email", "a@example.com", alice).Any(), dispatch.Sync()); err != nil {
+		t.Fatalf("dispatch release command: %v", err)
+	}
+
+	<-time.After(100 * time.Millisecond)
+
+	if !l.Available(ctx, "email", "a@example.com") {
+		t.Fatalf("released value should be available")
+	}
+}
@@ -0,0 +1,388 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/query"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	equery "github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of delivery attempts per event
+	// and webhook.
+	DefaultMaxAttempts = 5
+
+	// DefaultBackoff is the default delay before the first retry of a failed
+	// delivery. The delay doubles with every retry.
+	DefaultBackoff = time.Second
+
+	// DefaultMaxBackoff is the default maximum delay between two delivery
+	// attempts.
+	DefaultMaxBackoff = time.Minute
+)
+
+// Payload is the request body of a webhook request.
+type Payload struct {
+	ID        uuid.UUID         `json:"id"`
+	Name      string            `json:"name"`
+	Time      time.Time         `json:"time"`
+	Aggregate *PayloadAggregate `json:"aggregate,omitempty"`
+	Data      json.RawMessage   `json:"data"`
+}
+
+// PayloadAggregate is the aggregate of a delivered event.
+type PayloadAggregate struct {
+	Name    string    `json:"name"`
+	ID      uuid.UUID `json:"id"`
+	Version int       `json:"version"`
+}
+
+// Dispatcher delivers events to the registered webhooks. Every request is
+// signed with the secret of the webhook, which is resolved through the
+// SecretStore of the Dispatcher (see Sign and Verify). Failed deliveries are
+// retried with exponential backoff. If an event cannot be delivered after the
+// configured number of attempts, a DeliveryFailed event is recorded as a dead
+// letter (see DeadLetterStore).
+//
+// The Dispatcher keeps track of the registered webhooks by subscribing to the
+// events of the Webhook aggregate, so these events must be published over the
+// event bus (see eventstore.WithBus).
+type Dispatcher struct {
+	bus         event.Bus
+	repo        Repository
+	secrets     SecretStore
+	deadLetters event.Store
+	enc         codec.Encoding
+	events      []string
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+
+	mux   sync.RWMutex
+	hooks map[uuid.UUID]*Webhook
+}
+
+// DispatcherOption is an option for a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// HTTPClient returns a DispatcherOption that configures the HTTP client that is
+// used to deliver events. Default is http.DefaultClient.
+func HTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// MaxAttempts returns a DispatcherOption that configures the number of delivery
+// attempts per event and webhook. Default is DefaultMaxAttempts.
+func MaxAttempts(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = n
+	}
+}
+
+// Backoff returns a DispatcherOption that configures the delay before the first
+// retry of a failed delivery and the maximum delay between two attempts.
+// Default is DefaultBackoff and DefaultMaxBackoff.
+func Backoff(initial, max time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.backoff = initial
+		d.maxBackoff = max
+	}
+}
+
+// DeadLetterStore returns a DispatcherOption that inserts the DeliveryFailed
+// events of failed deliveries into the given event store (see DeadLetters).
+// Without a dead-letter store, DeliveryFailed events are only published over
+// the event bus of the Dispatcher.
+func DeadLetterStore(store event.Store) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.deadLetters = store
+	}
+}
+
+// NewDispatcher returns a Dispatcher that delivers the events with the given
+// names to the webhooks that subscribed to them. The secrets of the webhooks
+// are resolved through the provided SecretStore. The provided encoding is used
+// to encode the event data, which must be encoded as JSON.
+func NewDispatcher(bus event.Bus, repo Repository, secrets SecretStore, enc codec.Encoding, events []string, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		bus:         bus,
+		repo:        repo,
+		secrets:     secrets,
+		enc:         enc,
+		events:      events,
+		client:      http.DefaultClient,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		hooks:       make(map[uuid.UUID]*Webhook),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = 1
+	}
+	return d
+}
+
+// Run runs the Dispatcher until ctx is canceled. Errors that occur while
+// delivering events are sent to the returned channel, which is closed after
+// ctx is canceled and all pending deliveries have finished. The error channel
+// must be drained by the caller.
+func (d *Dispatcher) Run(ctx context.Context) (<-chan error, error) {
+	events, errs, err := d.bus.Subscribe(ctx, append(Events[:], d.events...)...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to events: %w", err)
+	}
+
+	if err := d.load(ctx); err != nil {
+		return nil, fmt.Errorf("load webhooks: %w", err)
+	}
+
+	out := make(chan error)
+	var wg sync.WaitGroup
+
+	fail := func(err error) {
+		select {
+		case <-ctx.Done():
+		case out <- err:
+		}
+	}
+
+	go func() {
+		defer close(out)
+		defer wg.Wait()
+
+		streams.ForEach(ctx, func(evt event.Event) {
+			if isWebhookEvent(evt) {
+				if err := d.refresh(ctx, evt); err != nil {
+					fail(err)
+				}
+				return
+			}
+
+			for _, hook := range d.subscribers(evt.Name()) {
+				hook := hook
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := d.deliver(ctx, hook, evt); err != nil {
+						fail(err)
+					}
+				}()
+			}
+		}, fail, events, errs)
+	}()
+
+	return out, nil
+}
+
+func (d *Dispatcher) load(ctx context.Context) error {
+	hooks, errs, err := d.repo.Query(ctx, query.New(query.Name(Aggregate)))
+	if err != nil {
+		return err
+	}
+
+	return streams.Walk(ctx, func(hook *Webhook) error {
+		d.mux.Lock()
+		defer d.mux.Unlock()
+		d.hooks[hook.AggregateID()] = hook
+		return nil
+	}, hooks, errs)
+}
+
+func (d *Dispatcher) refresh(ctx context.Context, evt event.Event) error {
+	id, _, _ := evt.Aggregate()
+
+	hook, err := d.repo.Fetch(ctx, id)
+	if err != nil {
+		return fmt.Errorf("fetch webhook %s: %w", id, err)
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.hooks[id] = hook
+
+	return nil
+}
+
+type subscriber struct {
+	id       uuid.UUID
+	url      string
+	secretID string
+}
+
+func (d *Dispatcher) subscribers(name string) []subscriber {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	var out []subscriber
+	for id, hook := range d.hooks {
+		if hook.Subscribes(name) {
+			out = append(out, subscriber{id: id, url: hook.URL(), secretID: hook.SecretID()})
+		}
+	}
+	return out
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, hook subscriber, evt event.Event) error {
+	body, err := d.payload(evt)
+	if err != nil {
+		return err
+	}
+
+	var (
+		attempts int
+		lastErr  error
+		delay    = d.backoff
+	)
+
+	for attempts < d.maxAttempts {
+		if attempts > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			if delay *= 2; d.maxBackoff > 0 && delay > d.maxBackoff {
+				delay = d.maxBackoff
+			}
+		}
+
+		attempts++
+
+		if lastErr = d.send(ctx, hook, evt, body); lastErr == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if err := d.recordDeadLetter(ctx, DeliveryFailedData{
+		Webhook:   hook.id,
+		EventID:   evt.ID(),
+		EventName: evt.Name(),
+		Attempts:  attempts,
+		Error:     lastErr.Error(),
+		FailedAt:  time.Now(),
+	}); err != nil {
+		return fmt.Errorf("record dead letter for %q event of webhook %s: %w", evt.Name(), hook.id, err)
+	}
+
+	return fmt.Errorf("deliver %q event to webhook %s: %w", evt.Name(), hook.id, lastErr)
+}
+
+func (d *Dispatcher) recordDeadLetter(ctx context.Context, data DeliveryFailedData) error {
+	evt := event.New(DeliveryFailed, data).Any()
+	if d.deadLetters != nil {
+		return d.deadLetters.Insert(ctx, evt)
+	}
+	return d.bus.Publish(ctx, evt)
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook subscriber, evt event.Event, body []byte) error {
+	secret, err := d.secrets.Secret(ctx, hook.secretID)
+	if err != nil {
+		return fmt.Errorf("resolve secret: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, evt.Name())
+	req.Header.Set(HeaderEventID, evt.ID().String())
+	req.Header.Set(HeaderWebhook, hook.id.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) payload(evt event.Event) ([]byte, error) {
+	data, err := d.enc.Marshal(evt.Data())
+	if err != nil {
+		return nil, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	p := Payload{
+		ID:   evt.ID(),
+		Name: evt.Name(),
+		Time: evt.Time(),
+		Data: data,
+	}
+
+	if id, name, v := evt.Aggregate(); name != "" {
+		p.Aggregate = &PayloadAggregate{Name: name, ID: id, Version: v}
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("encode %q event payload: %w", evt.Name(), err)
+	}
+
+	return b, nil
+}
+
+// DeadLetters returns the dead letters of the given webhook that have been
+// inserted into the given dead-letter store (see DeadLetterStore), sorted by
+// time.
+func DeadLetters(ctx context.Context, store event.Store, webhookID uuid.UUID) ([]DeliveryFailedData, error) {
+	events, errs, err := store.Query(ctx, equery.New(equery.Name(DeliveryFailed), equery.SortByTime()))
+	if err != nil {
+		return nil, fmt.Errorf("query %q events: %w", DeliveryFailed, err)
+	}
+
+	var out []DeliveryFailedData
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		data, ok := evt.Data().(DeliveryFailedData)
+		if !ok {
+			return fmt.Errorf("invalid data for %q event: %T", evt.Name(), evt.Data())
+		}
+		if data.Webhook == webhookID {
+			out = append(out, data)
+		}
+		return nil
+	}, events, errs); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+func isWebhookEvent(evt event.Event) bool {
+	return slices.Contains(Events[:], evt.Name())
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+)
+
+// Webhook events
+const (
+	Registered    = "goes.contrib.webhook.registered"
+	EventsChanged = "goes.contrib.webhook.events_changed"
+	Disabled      = "goes.contrib.webhook.disabled"
+	Enabled       = "goes.contrib.webhook.enabled"
+	Removed       = "goes.contrib.webhook.removed"
+	SecretRotated = "goes.contrib.webhook.secret_rotated"
+
+	// DeliveryFailed is not an event of the Webhook aggregate, but the
+	// dead-letter event of the Dispatcher (see DeliveryFailedData).
+	DeliveryFailed = "goes.contrib.webhook.delivery_failed"
+)
+
+// Events are the events of a Webhook.
+var Events = [...]string{
+	Registered,
+	EventsChanged,
+	Disabled,
+	Enabled,
+	Removed,
+	SecretRotated,
+}
+
+// RegisteredData is the event data for Registered. The secret of the webhook
+// is referenced by its id in the SecretStore of the Dispatcher.
+type RegisteredData struct {
+	URL      string
+	Events   []string
+	SecretID string
+}
+
+// DeliveryFailedData is the event data for DeliveryFailed. It is the dead-letter
+// record of an event that could not be delivered to a webhook. Dead letters
+// are standalone events that are not part of the history of the webhook, so
+// that they do not slow down the loading of webhooks (see DeadLetterStore).
+type DeliveryFailedData struct {
+	Webhook   uuid.UUID
+	EventID   uuid.UUID
+	EventName string
+	Attempts  int
+	Error     string
+	FailedAt  time.Time
+}
+
+// RegisterEvents registers the events of the webhook package into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[RegisteredData](r, Registered)
+	codec.Register[[]string](r, EventsChanged)
+	codec.Register[struct{}](r, Disabled)
+	codec.Register[struct{}](r, Enabled)
+	codec.Register[struct{}](r, Removed)
+	codec.Register[string](r, SecretRotated)
+	codec.Register[DeliveryFailedData](r, DeliveryFailed)
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSecretNotFound is returned by a SecretStore if a secret does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore resolves the secrets that are used to sign the requests to
+// webhooks. Webhooks only store the id of their secret, because the events of
+// a Webhook are immutable and published to every subscriber, so they must not
+// contain the secret itself. Secrets are resolved for every delivery, so a
+// secret can be rotated by updating it in the SecretStore, or by assigning
+// another secret id to the webhook (see Webhook.RotateSecret).
+type SecretStore interface {
+	// Secret returns the secret with the given id, or an error that wraps
+	// ErrSecretNotFound if the secret does not exist.
+	Secret(ctx context.Context, id string) (string, error)
+}
+
+// SecretMap is an in-memory SecretStore that maps secret ids to secrets.
+type SecretMap map[string]string
+
+// Secret implements SecretStore.
+func (m SecretMap) Secret(_ context.Context, id string) (string, error) {
+	secret, ok := m[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrSecretNotFound, id)
+	}
+	return secret, nil
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers that are set by the Dispatcher.
+const (
+	HeaderEvent     = "X-Goes-Event"
+	HeaderEventID   = "X-Goes-Event-Id"
+	HeaderWebhook   = "X-Goes-Webhook"
+	HeaderTimestamp = "X-Goes-Timestamp"
+	HeaderSignature = "X-Goes-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+var (
+	// ErrInvalidSignature is returned by Verify if the signature of a request
+	// is missing or invalid.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrExpiredSignature is returned by Verify if the timestamp of a request
+	// is outside of the allowed tolerance.
+	ErrExpiredSignature = errors.New("expired signature")
+)
+
+// Sign returns the signature of a webhook request with the given timestamp and
+// body. The signature is the hex-encoded HMAC-SHA256 of "<timestamp>.<body>",
+// where <timestamp> is the Unix time in seconds, prefixed with "sha256=".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac(secret, timestamp.Unix(), body))
+}
+
+// Verify verifies the signature of a webhook request and returns the request
+// body. If tolerance is greater than 0, requests whose timestamp differs from
+// the current time by more than tolerance are rejected, which protects against
+// replay attacks.
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		body, err := webhook.Verify(r, secret, 5*time.Minute)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusUnauthorized)
+//			return
+//		}
+//		var payload webhook.Payload
+//		json.Unmarshal(body, &payload)
+//	}
+func Verify(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	unix, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+
+	if tolerance > 0 {
+		if d := time.Since(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+			return nil, ErrExpiredSignature
+		}
+	}
+
+	signature := r.Header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return nil, ErrInvalidSignature
+	}
+
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	if !hmac.Equal(sum, mac(secret, unix, body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	return body, nil
+}
+
+func mac(secret string, unix int64, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(unix, 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,263 @@
+// Package webhook delivers selected events to registered HTTP webhooks. The
+// webhook subscriptions are themselves event-sourced: a Webhook is an
+// aggregate that records its registration and its subscribed events. The
+// secrets that are used to sign the requests are not stored in the events of a
+// Webhook; they are referenced by id and resolved through a SecretStore.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/event"
+	"golang.org/x/exp/slices"
+)
+
+// Aggregate is the name of the Webhook aggregate.
+const Aggregate = "goes.contrib.webhook"
+
+var (
+	// ErrInvalidURL is returned when registering a webhook with an invalid URL.
+	ErrInvalidURL = errors.New("invalid url")
+
+	// ErrNoEvents is returned when registering a webhook without events.
+	ErrNoEvents = errors.New("no events")
+
+	// ErrNoSecret is returned when registering a webhook without a secret id.
+	ErrNoSecret = errors.New("no secret")
+
+	// ErrAlreadyRegistered is returned when registering a webhook twice.
+	ErrAlreadyRegistered = errors.New("webhook already registered")
+
+	// ErrNotRegistered is returned when updating a webhook that has not been
+	// registered.
+	ErrNotRegistered = errors.New("webhook not registered")
+
+	// ErrRemoved is returned when updating a webhook that has been removed.
+	ErrRemoved = errors.New("webhook removed")
+)
+
+// Repository is the repository for Webhooks.
+type Repository = aggregate.TypedRepository[*Webhook]
+
+// NewRepository returns the repository for Webhooks.
+func NewRepository(repo aggregate.Repository) Repository {
+	return repository.Typed(repo, New)
+}
+
+// Webhook is a subscription of an HTTP endpoint to events. A Webhook must be
+// registered before events can be delivered to it.
+//
+//	hook := webhook.New(uuid.New())
+//	hook.Register("https://example.com/hooks", []string{"foo", "bar"}, "secret-id")
+type Webhook struct {
+	*aggregate.Base
+
+	url      string
+	events   []string
+	secretID string
+	disabled bool
+	removed  bool
+}
+
+// New returns the webhook with the given id.
+func New(id uuid.UUID) *Webhook {
+	w := &Webhook{Base: aggregate.New(Aggregate, id)}
+
+	event.ApplyWith(w, w.register, Registered)
+	event.ApplyWith(w, w.changeEvents, EventsChanged)
+	event.ApplyWith(w, w.disable, Disabled)
+	event.ApplyWith(w, w.enable, Enabled)
+	event.ApplyWith(w, w.remove, Removed)
+	event.ApplyWith(w, w.rotateSecret, SecretRotated)
+
+	return w
+}
+
+// URL returns the URL of the webhook.
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+// Events returns the names of the events that are delivered to the webhook.
+func (w *Webhook) Events() []string {
+	return w.events
+}
+
+// SecretID returns the id of the secret that is used to sign the requests to
+// the webhook (see SecretStore).
+func (w *Webhook) SecretID() string {
+	return w.secretID
+}
+
+// Registered returns whether the webhook has been registered.
+func (w *Webhook) Registered() bool {
+	return w.url != ""
+}
+
+// Active returns whether events are delivered to the webhook, which is the case
+// if the webhook is registered, enabled, and not removed.
+func (w *Webhook) Active() bool {
+	return w.Registered() && !w.disabled && !w.removed
+}
+
+// Subscribes returns whether the webhook is active and subscribed to the
+// event with the given name.
+func (w *Webhook) Subscribes(name string) bool {
+	return w.Active() && slices.Contains(w.events, name)
+}
+
+// Register registers the webhook with the given URL, events, and secret id.
+// The secret with the given id is resolved through the SecretStore of the
+// Dispatcher and used to sign the requests to the webhook (see Sign).
+func (w *Webhook) Register(u string, events []string, secretID string) error {
+	if w.removed {
+		return ErrRemoved
+	}
+
+	if w.Registered() {
+		return ErrAlreadyRegistered
+	}
+
+	if err := validateURL(u); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return ErrNoEvents
+	}
+
+	if secretID == "" {
+		return ErrNoSecret
+	}
+
+	aggregate.Next(w, Registered, RegisteredData{
+		URL:      u,
+		Events:   events,
+		SecretID: secretID,
+	})
+
+	return nil
+}
+
+func (w *Webhook) register(evt event.Of[RegisteredData]) {
+	data := evt.Data()
+	w.url = data.URL
+	w.events = data.Events
+	w.secretID = data.SecretID
+}
+
+// RotateSecret changes the id of the secret that is used to sign the requests
+// to the webhook.
+func (w *Webhook) RotateSecret(secretID string) error {
+	if err := w.checkUpdate(); err != nil {
+		return err
+	}
+
+	if secretID == "" {
+		return ErrNoSecret
+	}
+
+	if secretID != w.secretID {
+		aggregate.Next(w, SecretRotated, secretID)
+	}
+
+	return nil
+}
+
+func (w *Webhook) rotateSecret(evt event.Of[string]) {
+	w.secretID = evt.Data()
+}
+
+// ChangeEvents changes the events that are delivered to the webhook.
+func (w *Webhook) ChangeEvents(events ...string) error {
+	if err := w.checkUpdate(); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return ErrNoEvents
+	}
+
+	aggregate.Next(w, EventsChanged, events)
+
+	return nil
+}
+
+func (w *Webhook) changeEvents(evt event.Of[[]string]) {
+	w.events = evt.Data()
+}
+
+// Disable stops the delivery of events to the webhook.
+func (w *Webhook) Disable() error {
+	if err := w.checkUpdate(); err != nil {
+		return err
+	}
+
+	if !w.disabled {
+		aggregate.Next(w, Disabled, struct{}{})
+	}
+
+	return nil
+}
+
+func (w *Webhook) disable(event.Of[struct{}]) {
+	w.disabled = true
+}
+
+// Enable resumes the delivery of events to a disabled webhook.
+func (w *Webhook) Enable() error {
+	if err := w.checkUpdate(); err != nil {
+		return err
+	}
+
+	if w.disabled {
+		aggregate.Next(w, Enabled, struct{}{})
+	}
+
+	return nil
+}
+
+func (w *Webhook) enable(event.Of[struct{}]) {
+	w.disabled = false
+}
+
+// Remove removes the webhook. A removed webhook cannot be updated anymore.
+func (w *Webhook) Remove() error {
+	if err := w.checkUpdate(); err != nil {
+		return err
+	}
+
+	aggregate.Next(w, Removed, struct{}{})
+
+	return nil
+}
+
+func (w *Webhook) remove(event.Of[struct{}]) {
+	w.removed = true
+}
+
+func (w *Webhook) checkUpdate() error {
+	if w.removed {
+		return ErrRemoved
+	}
+	if !w.Registered() {
+		return ErrNotRegistered
+	}
+	return nil
+}
+
+func validateURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, u)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/repository"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/contrib/webhook"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestWebhook_Register(t *testing.T) {
+	hook := webhook.New(uuid.New())
+
+	if err := hook.Register("not-a-url", []string{"foo"}, "secret"); !errors.Is(err, webhook.ErrInvalidURL) {
+		t.Fatalf("Register should fail with %q; got %q", webhook.ErrInvalidURL, err)
+	}
+
+	if err := hook.Register("https://example.com", nil, "secret"); !errors.Is(err, webhook.ErrNoEvents) {
+		t.Fatalf("Register should fail with %q; got %q", webhook.ErrNoEvents, err)
+	}
+
+	if err := hook.Register("https://example.com", []string{"foo"}, ""); !errors.Is(err, webhook.ErrNoSecret) {
+		t.Fatalf("Register should fail with %q; got %q", webhook.ErrNoSecret, err)
+	}
+
+	if err := hook.Register("https://example.com", []string{"foo"}, "secret"); err != nil {
+		t.Fatalf("Register failed with %q", err)
+	}
+
+	if err := hook.RotateSecret("rotated"); err != nil {
+		t.Fatalf("RotateSecret failed with %q", err)
+	}
+
+	if hook.SecretID() != "rotated" {
+		t.Fatalf("secret id should be %q; is %q", "rotated", hook.SecretID())
+	}
+
+	if !hook.Subscribes("foo") {
+		t.Fatalf("webhook should subscribe to %q events", "foo")
+	}
+
+	if err := hook.Disable(); err != nil {
+		t.Fatalf("Disable failed with %q", err)
+	}
+
+	if hook.Subscribes("foo") {
+		t.Fatalf("disabled webhook should not subscribe to events")
+	}
+
+	if err := hook.Enable(); err != nil {
+		t.Fatalf("Enable failed with %q", err)
+	}
+
+	if err := hook.Remove(); err != nil {
+		t.Fatalf("Remove failed with %q", err)
+	}
+
+	if err := hook.ChangeEvents("bar"); !errors.Is(err, webhook.ErrRemoved) {
+		t.Fatalf("ChangeEvents should fail with %q; got %q", webhook.ErrRemoved, err)
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts int32
+	received := make(chan webhook.Payload)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := webhook.Verify(r, "s3cr3t", time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// fail the first attempt
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var p webhook.Payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		received <- p
+	}))
+	defer srv.Close()
+
+	bus, store, repo, enc := setup()
+	hooks := webhook.NewRepository(repo)
+
+	hook := webhook.New(uuid.New())
+	hook.Register(srv.URL, []string{"foo"}, "secret-id")
+	if err := hooks.Save(ctx, hook); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+
+	if err := assertNoSecret(ctx, store, "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := webhook.SecretMap{"secret-id": "s3cr3t"}
+	d := webhook.NewDispatcher(bus, hooks, secrets, enc, []string{"foo", "bar"}, webhook.Backoff(10*time.Millisecond, 50*time.Millisecond))
+
+	errs, err := d.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	evt := event.New("foo", test.FooEventData{A: "foo"})
+	if err := bus.Publish(ctx, evt.Any(), event.New("bar", test.BarEventData{A: "bar"}).Any()); err != nil {
+		t.Fatalf("publish events: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		t.Fatalf("dispatcher failed with %q", err)
+	case <-time.After(time.Second):
+		t.Fatalf("event was not delivered")
+	case p := <-received:
+		if p.ID != evt.ID() || p.Name != "foo" {
+			t.Fatalf("unexpected payload: %#v", p)
+		}
+
+		var data test.FooEventData
+		if err := json.Unmarshal(p.Data, &data); err != nil || data.A != "foo" {
+			t.Fatalf("unexpected payload data: %s", p.Data)
+		}
+	}
+
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Fatalf("event should be delivered after %d attempts; got %d", 2, n)
+	}
+}
+
+func TestDispatcher_Run_deadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	bus, store, repo, enc := setup()
+	hooks := webhook.NewRepository(repo)
+
+	hook := webhook.New(uuid.New())
+	hook.Register(srv.URL, []string{"foo"}, "secret-id")
+	if err := hooks.Save(ctx, hook); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+
+	secrets := webhook.SecretMap{"secret-id": "secret"}
+	d := webhook.NewDispatcher(bus, hooks, secrets, enc, []string{"foo"}, webhook.MaxAttempts(3), webhook.Backoff(time.Millisecond, time.Millisecond), webhook.DeadLetterStore(store))
+
+	errs, err := d.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	evt := event.New("foo", test.FooEventData{A: "foo"})
+	if err := bus.Publish(ctx, evt.Any()); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("delivery should fail")
+	case <-errs:
+	}
+
+	letters, err := webhook.DeadLetters(ctx, store, hook.AggregateID())
+	if err != nil {
+		t.Fatalf("DeadLetters failed with %q", err)
+	}
+
+	if len(letters) != 1 {
+		t.Fatalf("webhook should have %d dead letter; got %d", 1, len(letters))
+	}
+
+	if letters[0].EventID != evt.ID() || letters[0].Attempts != 3 {
+		t.Fatalf("unexpected dead letter: %#v", letters[0])
+	}
+
+	if hook, err = hooks.Fetch(ctx, hook.AggregateID()); err != nil {
+		t.Fatalf("fetch webhook: %v", err)
+	}
+
+	if v := hook.AggregateVersion(); v != 1 {
+		t.Fatalf("dead letters should not be part of the webhook history; version is %d", v)
+	}
+}
+
+func TestDispatcher_Run_rotateSecret(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	verified := make(chan error)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := webhook.Verify(r, "new-secret", time.Minute)
+		verified <- err
+	}))
+	defer srv.Close()
+
+	bus, _, repo, enc := setup()
+	hooks := webhook.NewRepository(repo)
+
+	hook := webhook.New(uuid.New())
+	hook.Register(srv.URL, []string{"foo"}, "old")
+	hook.RotateSecret("new")
+	if err := hooks.Save(ctx, hook); err != nil {
+		t.Fatalf("save webhook: %v", err)
+	}
+
+	secrets := webhook.SecretMap{"old": "old-secret", "new": "new-secret"}
+	d := webhook.NewDispatcher(bus, hooks, secrets, enc, []string{"foo"}, webhook.MaxAttempts(1))
+
+	if _, err := d.Run(ctx); err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	if err := bus.Publish(ctx, event.New("foo", test.FooEventData{}).Any()); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("event was not delivered")
+	case err := <-verified:
+		if err != nil {
+			t.Fatalf("request should be signed with the rotated secret; Verify failed with %q", err)
+		}
+	}
+}
+
+// assertNoSecret returns an error if the given secret is contained in the
+// encoded data of the stored webhook events.
+func assertNoSecret(ctx context.Context, store event.Store, secret string) error {
+	events, errs, err := store.Query(ctx, query.New(query.AggregateName(webhook.Aggregate)))
+	if err != nil {
+		return err
+	}
+
+	all, err := streams.Drain(ctx, events, errs)
+	if err != nil {
+		return err
+	}
+
+	for _, evt := range all {
+		b, err := json.Marshal(evt.Data())
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(b), secret) {
+			return fmt.Errorf("%q event should not contain the secret: %s", evt.Name(), b)
+		}
+	}
+
+	return nil
+}
+
+func setup() (event.Bus, event.Store, *repository.Repository, *codec.Registry) {
+	enc := test.NewEncoder()
+	webhook.RegisterEvents(enc)
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+	return bus, store, repository.New(store), enc
+}
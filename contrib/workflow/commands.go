@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+)
+
+// Command is the serializable form of a command. Workflow engines pass the
+// inputs and results of workflows and activities through their own
+// serialization, so workflows build a Command using NewCommand, and an
+// activity dispatches it using Commands.Dispatch.
+type Command struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	AggregateName string    `json:"aggregateName,omitempty"`
+	AggregateID   uuid.UUID `json:"aggregateId,omitempty"`
+	Payload       []byte    `json:"payload,omitempty"`
+}
+
+// NewCommand encodes the given command using the provided encoding. The id of
+// the command is kept, so workflows should create commands with a
+// deterministic id (e.g. using command.ID), which allows command handlers to
+// detect commands that are dispatched more than once when an activity is
+// retried.
+func NewCommand(enc codec.Encoding, cmd command.Command) (Command, error) {
+	payload, err := enc.Marshal(cmd.Payload())
+	if err != nil {
+		return Command{}, fmt.Errorf("encode %q command payload: %w", cmd.Name(), err)
+	}
+
+	id, name := cmd.Aggregate().Split()
+
+	return Command{
+		ID:            cmd.ID(),
+		Name:          cmd.Name(),
+		AggregateName: name,
+		AggregateID:   id,
+		Payload:       payload,
+	}, nil
+}
+
+// Command decodes the command using the provided encoding.
+func (cmd Command) Command(enc codec.Encoding) (command.Command, error) {
+	payload, err := enc.Unmarshal(cmd.Payload, cmd.Name)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q command payload: %w", cmd.Name, err)
+	}
+
+	opts := []command.Option{command.ID(cmd.ID)}
+	if cmd.AggregateName != "" {
+		opts = append(opts, command.Aggregate(cmd.AggregateName, cmd.AggregateID))
+	}
+
+	return command.New(cmd.Name, payload, opts...), nil
+}
+
+// Commands dispatches the commands of workflows through a command bus.
+// Commands is usually registered as an activity of the workflow engine:
+//
+//	commands := workflow.NewCommands(bus, enc)
+//	w.RegisterActivityWithOptions(commands.Dispatch, activity.RegisterOptions{Name: "goes.dispatch"})
+type Commands struct {
+	bus  command.Bus
+	enc  codec.Encoding
+	opts []command.DispatchOption
+}
+
+// CommandsOption is an option for Commands.
+type CommandsOption func(*Commands)
+
+// DispatchOptions returns a CommandsOption that adds options to every
+// dispatch of a command. By default, commands are dispatched synchronously
+// (see dispatch.Sync), so that a failed command fails the activity.
+func DispatchOptions(opts ...command.DispatchOption) CommandsOption {
+	return func(c *Commands) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// NewCommands returns Commands that dispatch commands over the given bus. The
+// provided encoding is used to decode the command payloads, and must be the
+// encoding that was used to create the commands (see NewCommand).
+func NewCommands(bus command.Bus, enc codec.Encoding, opts ...CommandsOption) *Commands {
+	c := Commands{
+		bus:  bus,
+		enc:  enc,
+		opts: []command.DispatchOption{dispatch.Sync()},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// Dispatch decodes and dispatches the given command.
+func (c *Commands) Dispatch(ctx context.Context, cmd Command) error {
+	decoded, err := cmd.Command(c.enc)
+	if err != nil {
+		return err
+	}
+
+	if err := c.bus.Dispatch(ctx, decoded, c.opts...); err != nil {
+		return fmt.Errorf("dispatch %q command: %w", cmd.Name, err)
+	}
+
+	return nil
+}
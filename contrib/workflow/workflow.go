@@ -0,0 +1,153 @@
+// Package workflow connects goes to workflow engines like Temporal, for
+// applications that mix event sourcing with workflow orchestration. Events
+// start and signal workflows (see StartOn and SignalOn), and workflows
+// dispatch commands back through a command bus (see Commands).
+//
+// The package does not depend on a specific workflow engine. Workflows are
+// started and signaled through an Engine, which is usually implemented using
+// the client of the workflow engine, e.g. for Temporal:
+//
+//	engine := workflow.EngineFuncs{
+//		Start: func(ctx context.Context, req workflow.Start) error {
+//			_, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+//				ID:                    req.ID,
+//				TaskQueue:             "orders",
+//				WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+//			}, req.Workflow, req.Input)
+//			var started *serviceerror.WorkflowExecutionAlreadyStarted
+//			if errors.As(err, &started) {
+//				return workflow.ErrAlreadyStarted
+//			}
+//			return err
+//		},
+//		Signal: func(ctx context.Context, req workflow.Signal) error {
+//			return c.SignalWorkflow(ctx, req.WorkflowID, "", req.Signal, req.Input)
+//		},
+//	}
+//
+//	r := reactor.New(bus)
+//	workflow.StartOn(r, engine, "order.placed", "fulfillment", func(evt event.Of[OrderPlaced]) (string, any) {
+//		return workflow.AggregateID("fulfillment", evt), evt.Data()
+//	})
+//	workflow.SignalOn(r, engine, "order.canceled", "cancel", func(evt event.Of[OrderCanceled]) (string, any) {
+//		return workflow.AggregateID("fulfillment", evt), evt.Data()
+//	})
+//	errs, err := r.Run(ctx)
+//
+// Workflows are started and signaled by the reactions of a reactor.Reactor,
+// so they are retried and deduplicated like any other reaction.
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/reactor"
+)
+
+// ErrAlreadyStarted is returned by an Engine if a workflow with the same id is
+// already running or has already run. Reactions that start workflows treat the
+// error as success, so that redelivered events do not fail.
+var ErrAlreadyStarted = errors.New("workflow already started")
+
+// Start is a request to start a workflow.
+type Start struct {
+	// ID is the id of the workflow execution. Engines should reject a
+	// workflow with an id that was already used (see ErrAlreadyStarted).
+	ID string
+
+	// Workflow is the name of the workflow.
+	Workflow string
+
+	// Input is the input of the workflow.
+	Input any
+}
+
+// Signal is a request to signal a running workflow.
+type Signal struct {
+	// WorkflowID is the id of the workflow execution.
+	WorkflowID string
+
+	// Signal is the name of the signal.
+	Signal string
+
+	// Input is the input of the signal.
+	Input any
+}
+
+// Engine starts and signals workflows.
+type Engine interface {
+	// StartWorkflow starts a workflow. If a workflow with the same id was
+	// already started, StartWorkflow returns ErrAlreadyStarted.
+	StartWorkflow(context.Context, Start) error
+
+	// SignalWorkflow signals a running workflow.
+	SignalWorkflow(context.Context, Signal) error
+}
+
+// EngineFuncs allows functions to be used as an Engine.
+type EngineFuncs struct {
+	Start  func(context.Context, Start) error
+	Signal func(context.Context, Signal) error
+}
+
+// StartWorkflow calls e.Start(ctx, req).
+func (e EngineFuncs) StartWorkflow(ctx context.Context, req Start) error {
+	if e.Start == nil {
+		return errors.New("starting workflows is not supported")
+	}
+	return e.Start(ctx, req)
+}
+
+// SignalWorkflow calls e.Signal(ctx, req).
+func (e EngineFuncs) SignalWorkflow(ctx context.Context, req Signal) error {
+	if e.Signal == nil {
+		return errors.New("signaling workflows is not supported")
+	}
+	return e.Signal(ctx, req)
+}
+
+// StartOn registers a reaction that starts the given workflow whenever an
+// event with the given name is published. fn returns the id and the input of
+// the workflow. The reaction is named "workflow:start:<workflow>:<event>".
+func StartOn[D any](r *reactor.Reactor, engine Engine, eventName, workflow string, fn func(event.Of[D]) (id string, input any), opts ...reactor.ReactionOption) {
+	name := fmt.Sprintf("workflow:start:%s:%s", workflow, eventName)
+	reactor.When(r, name, eventName, func(ctx context.Context, evt event.Of[D]) error {
+		id, input := fn(evt)
+		if err := engine.StartWorkflow(ctx, Start{ID: id, Workflow: workflow, Input: input}); err != nil && !errors.Is(err, ErrAlreadyStarted) {
+			return fmt.Errorf("start %q workflow (%s): %w", workflow, id, err)
+		}
+		return nil
+	}, opts...)
+}
+
+// SignalOn registers a reaction that sends the given signal whenever an event
+// with the given name is published. fn returns the id of the workflow that
+// receives the signal, and the input of the signal. The reaction is named
+// "workflow:signal:<signal>:<event>".
+func SignalOn[D any](r *reactor.Reactor, engine Engine, eventName, signal string, fn func(event.Of[D]) (workflowID string, input any), opts ...reactor.ReactionOption) {
+	name := fmt.Sprintf("workflow:signal:%s:%s", signal, eventName)
+	reactor.When(r, name, eventName, func(ctx context.Context, evt event.Of[D]) error {
+		id, input := fn(evt)
+		if err := engine.SignalWorkflow(ctx, Signal{WorkflowID: id, Signal: signal, Input: input}); err != nil {
+			return fmt.Errorf("send %q signal to workflow %s: %w", signal, id, err)
+		}
+		return nil
+	}, opts...)
+}
+
+// AggregateID returns a workflow id that is derived from the aggregate of the
+// given event: "<workflow>/<aggregate name>/<aggregate id>". Events of the
+// same aggregate map to the same workflow, so that a workflow is started at
+// most once per aggregate and can be signaled by later events of the
+// aggregate. Events that do not belong to an aggregate map to
+// "<workflow>/<event id>".
+func AggregateID[D any](workflow string, evt event.Of[D]) string {
+	id, name, _ := evt.Aggregate()
+	if name == "" {
+		return fmt.Sprintf("%s/%s", workflow, evt.ID())
+	}
+	return fmt.Sprintf("%s/%s/%s", workflow, name, id)
+}
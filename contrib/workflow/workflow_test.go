@@ -0,0 +1,166 @@
+package workflow_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/command"
+	"github.com/modernice/goes/command/cmdbus/dispatch"
+	"github.com/modernice/goes/contrib/workflow"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/reactor"
+)
+
+func TestStartOn_SignalOn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	r := reactor.New(bus)
+	engine := newRecordingEngine()
+
+	workflow.StartOn(r, engine, "foo", "fulfillment", func(evt event.Of[test.FooEventData]) (string, any) {
+		return workflow.AggregateID("fulfillment", evt), evt.Data().A
+	})
+	workflow.SignalOn(r, engine, "bar", "cancel", func(evt event.Of[test.BarEventData]) (string, any) {
+		return workflow.AggregateID("fulfillment", evt), evt.Data().A
+	})
+
+	errs, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() failed with %q", err)
+	}
+	go func() {
+		for err := range errs {
+			t.Errorf("reactor: %v", err)
+		}
+	}()
+
+	aggregateID := uuid.New()
+	wantID := "fulfillment/order/" + aggregateID.String()
+
+	if err := bus.Publish(ctx,
+		event.New("foo", test.FooEventData{A: "placed"}, event.Aggregate(aggregateID, "order", 1)).Any(),
+		event.New("foo", test.FooEventData{A: "placed again"}, event.Aggregate(aggregateID, "order", 2)).Any(),
+		event.New("bar", test.BarEventData{A: "canceled"}, event.Aggregate(aggregateID, "order", 3)).Any(),
+	); err != nil {
+		t.Fatalf("Publish() failed with %q", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("signal was not sent")
+	case sig := <-engine.signals:
+		if sig.WorkflowID != wantID || sig.Signal != "cancel" || sig.Input != "canceled" {
+			t.Fatalf("unexpected signal: %+v", sig)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for engine.startCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	engine.mux.Lock()
+	defer engine.mux.Unlock()
+
+	if len(engine.started) != 1 {
+		t.Fatalf("workflow should be started once; got %d starts", len(engine.started))
+	}
+
+	if start := engine.started[0]; start.ID != wantID || start.Workflow != "fulfillment" || start.Input != "placed" {
+		t.Fatalf("unexpected start: %+v", start)
+	}
+}
+
+func TestCommands_Dispatch(t *testing.T) {
+	enc := codec.New()
+	codec.Register[test.FooEventData](enc, "foo")
+
+	aggregateID := uuid.New()
+	cmd := command.New("foo", test.FooEventData{A: "foo"}, command.Aggregate("order", aggregateID)).Any()
+
+	serialized, err := workflow.NewCommand(enc, cmd)
+	if err != nil {
+		t.Fatalf("NewCommand() failed with %q", err)
+	}
+
+	bus := &recordingBus{}
+	if err := workflow.NewCommands(bus, enc).Dispatch(context.Background(), serialized); err != nil {
+		t.Fatalf("Dispatch() failed with %q", err)
+	}
+
+	if len(bus.dispatched) != 1 {
+		t.Fatalf("%d command should have been dispatched; got %d", 1, len(bus.dispatched))
+	}
+
+	got := bus.dispatched[0]
+	if got.ID() != cmd.ID() || got.Name() != "foo" || got.Aggregate() != cmd.Aggregate() {
+		t.Fatalf("dispatched command should be %+v; got %+v", cmd, got)
+	}
+
+	if got.Payload() != (test.FooEventData{A: "foo"}) {
+		t.Fatalf("payload should be decoded; got %v", got.Payload())
+	}
+
+	if !bus.synchronous {
+		t.Fatalf("commands should be dispatched synchronously by default")
+	}
+}
+
+type recordingEngine struct {
+	mux     sync.Mutex
+	starts  int
+	started []workflow.Start
+	signals chan workflow.Signal
+}
+
+func newRecordingEngine() *recordingEngine {
+	return &recordingEngine{signals: make(chan workflow.Signal, 1)}
+}
+
+func (e *recordingEngine) StartWorkflow(_ context.Context, req workflow.Start) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.starts++
+	for _, s := range e.started {
+		if s.ID == req.ID {
+			return workflow.ErrAlreadyStarted
+		}
+	}
+	e.started = append(e.started, req)
+	return nil
+}
+
+func (e *recordingEngine) startCount() int {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.starts
+}
+
+func (e *recordingEngine) SignalWorkflow(_ context.Context, req workflow.Signal) error {
+	e.signals <- req
+	return nil
+}
+
+type recordingBus struct {
+	dispatched  []command.Command
+	synchronous bool
+}
+
+func (bus *recordingBus) Dispatch(_ context.Context, cmd command.Command, opts ...command.DispatchOption) error {
+	bus.dispatched = append(bus.dispatched, cmd)
+	bus.synchronous = dispatch.Configure(opts...).Synchronous
+	return nil
+}
+
+func (bus *recordingBus) Subscribe(context.Context, ...string) (<-chan command.Context, <-chan error, error) {
+	return nil, nil, nil
+}
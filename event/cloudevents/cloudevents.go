@@ -0,0 +1,275 @@
+// Package cloudevents converts goes events from and to CloudEvents 1.0, so
+// that goes events can be exchanged with CloudEvents-based ecosystems like
+// Knative Eventing or Amazon EventBridge. The package implements the JSON event
+// format and the HTTP protocol binding of the specification. The NATS protocol
+// binding is implemented by the nats backend.
+//
+// Goes events are mapped to CloudEvents as follows:
+//
+//	id           event id
+//	type         event name
+//	source       source of the Format
+//	time         event time
+//	subject      "<aggregate name>/<aggregate id>" (if the event belongs to an aggregate)
+//	data         encoded event data
+//
+// The aggregate of an event is additionally stored in the "aggregatename",
+// "aggregateid", and "aggregateversion" extension attributes. Further extension
+// attributes, e.g. correlation ids, can be added using the Extensions option.
+//
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents specification version that is implemented by
+// this package.
+const SpecVersion = "1.0"
+
+// MediaType is the media type of CloudEvents in the structured JSON format.
+const MediaType = "application/cloudevents+json"
+
+// Extension attributes that hold the aggregate of an event.
+const (
+	AggregateNameExtension    = "aggregatename"
+	AggregateIDExtension      = "aggregateid"
+	AggregateVersionExtension = "aggregateversion"
+)
+
+var (
+	// ErrInvalidEvent is returned when a CloudEvent misses a required attribute
+	// or has an unsupported spec version.
+	ErrInvalidEvent = errors.New("invalid cloudevent")
+
+	// ErrNotCloudEvent is returned when a message that is read using a protocol
+	// binding does not contain a CloudEvent.
+	ErrNotCloudEvent = errors.New("not a cloudevent")
+)
+
+// contextAttributes are the attributes that are defined by the specification
+// and therefore cannot be used as extension attributes.
+var contextAttributes = map[string]bool{
+	"specversion":     true,
+	"id":              true,
+	"source":          true,
+	"type":            true,
+	"subject":         true,
+	"time":            true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"data":            true,
+	"data_base64":     true,
+}
+
+// Event is a CloudEvent. Data holds the raw bytes of the event data, which
+// are encoded as JSON or base64 depending on DataContentType when the event is
+// marshaled in the structured JSON format.
+type Event struct {
+	SpecVersion     string
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	Data            []byte
+
+	// Extensions are the extension attributes of the event. Extension values
+	// must be strings, booleans, integers, or times. Extension values that are
+	// read from protocol headers are always strings.
+	Extensions map[string]any
+}
+
+// Validate returns an error if the event misses a required attribute, has an
+// unsupported spec version, or has an invalid extension attribute.
+func (e Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("%w: unsupported spec version %q", ErrInvalidEvent, e.SpecVersion)
+	}
+
+	for attr, v := range map[string]string{"id": e.ID, "source": e.Source, "type": e.Type} {
+		if v == "" {
+			return fmt.Errorf("%w: missing %q attribute", ErrInvalidEvent, attr)
+		}
+	}
+
+	for name := range e.Extensions {
+		if !validExtensionName(name) {
+			return fmt.Errorf("%w: invalid extension name %q", ErrInvalidEvent, name)
+		}
+	}
+
+	return nil
+}
+
+// Extension returns the value of the extension attribute with the given name
+// as a string. Extension returns false if the event has no such extension.
+func (e Event) Extension(name string) (string, bool) {
+	v, ok := e.Extensions[name]
+	if !ok || v == nil {
+		return "", false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// MarshalJSON encodes the event in the structured JSON format. If the content
+// type of the data is JSON and the data is valid JSON, the data is embedded as
+// JSON; otherwise, it is encoded as base64.
+func (e Event) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any, 9+len(e.Extensions))
+	for name, v := range e.Extensions {
+		if t, ok := v.(time.Time); ok {
+			v = t.Format(time.RFC3339Nano)
+		}
+		m[name] = v
+	}
+
+	m["specversion"] = e.SpecVersion
+	m["id"] = e.ID
+	m["source"] = e.Source
+	m["type"] = e.Type
+
+	if e.Subject != "" {
+		m["subject"] = e.Subject
+	}
+
+	if !e.Time.IsZero() {
+		m["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+
+	if e.DataContentType != "" {
+		m["datacontenttype"] = e.DataContentType
+	}
+
+	if e.DataSchema != "" {
+		m["dataschema"] = e.DataSchema
+	}
+
+	if e.Data != nil {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			m["data"] = json.RawMessage(e.Data)
+		} else {
+			m["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes an event from the structured JSON format.
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	var out Event
+	for name, raw := range m {
+		var err error
+		switch name {
+		case "specversion":
+			err = json.Unmarshal(raw, &out.SpecVersion)
+		case "id":
+			err = json.Unmarshal(raw, &out.ID)
+		case "source":
+			err = json.Unmarshal(raw, &out.Source)
+		case "type":
+			err = json.Unmarshal(raw, &out.Type)
+		case "subject":
+			err = json.Unmarshal(raw, &out.Subject)
+		case "time":
+			var s string
+			if err = json.Unmarshal(raw, &s); err == nil {
+				out.Time, err = time.Parse(time.RFC3339Nano, s)
+			}
+		case "datacontenttype":
+			err = json.Unmarshal(raw, &out.DataContentType)
+		case "dataschema":
+			err = json.Unmarshal(raw, &out.DataSchema)
+		case "data":
+			if string(raw) != "null" {
+				out.Data = raw
+			}
+		case "data_base64":
+			var s string
+			if err = json.Unmarshal(raw, &s); err == nil {
+				out.Data, err = base64.StdEncoding.DecodeString(s)
+			}
+		default:
+			var v any
+			if err = json.Unmarshal(raw, &v); err == nil {
+				if out.Extensions == nil {
+					out.Extensions = make(map[string]any)
+				}
+				out.Extensions[name] = v
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("decode %q attribute: %w", name, err)
+		}
+	}
+
+	if err := out.Validate(); err != nil {
+		return err
+	}
+
+	*e = out
+
+	return nil
+}
+
+// isJSON returns whether the given content type is a JSON media type. Events
+// without a content type are JSON by definition of the JSON event format.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validExtensionName returns whether name is a valid extension attribute name,
+// i.e. consists of lower-case letters and digits and is not the name of a
+// context attribute.
+func validExtensionName(name string) bool {
+	if name == "" || contextAttributes[name] {
+		return false
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,225 @@
+package cloudevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/cloudevents"
+	"github.com/modernice/goes/event/test"
+)
+
+func newEvent() event.Event {
+	return event.New[any](
+		"foo",
+		test.FooEventData{A: "foo"},
+		event.Time(time.Now().Round(time.Microsecond)),
+		event.Aggregate(uuid.New(), "order", 3),
+	)
+}
+
+func newFormat(opts ...cloudevents.Option) *cloudevents.Format {
+	return cloudevents.New(test.NewEncoder(), "https://example.com/orders", append([]cloudevents.Option{
+		cloudevents.Extensions(func(context.Context, event.Event) map[string]any {
+			return map[string]any{"correlationid": "abc 123"}
+		}),
+	}, opts...)...)
+}
+
+func TestFormat_Encode(t *testing.T) {
+	evt := newEvent()
+
+	ce, err := newFormat().Encode(context.Background(), evt)
+	if err != nil {
+		t.Fatalf("Encode() failed with %q", err)
+	}
+
+	id, name, v := evt.Aggregate()
+
+	if ce.SpecVersion != "1.0" || ce.ID != evt.ID().String() || ce.Type != "foo" || ce.Source != "https://example.com/orders" {
+		t.Fatalf("unexpected context attributes %+v", ce)
+	}
+
+	if !ce.Time.Equal(evt.Time()) || ce.Subject != name+"/"+id.String() || ce.DataContentType != cloudevents.DefaultContentType {
+		t.Fatalf("unexpected context attributes %+v", ce)
+	}
+
+	want := map[string]any{
+		cloudevents.AggregateNameExtension:    name,
+		cloudevents.AggregateIDExtension:      id.String(),
+		cloudevents.AggregateVersionExtension: v,
+		"correlationid":                       "abc 123",
+	}
+	for key, val := range want {
+		if ce.Extensions[key] != val {
+			t.Fatalf("extension %q should be %v; got %v", key, val, ce.Extensions[key])
+		}
+	}
+}
+
+func TestFormat_Marshal_Unmarshal(t *testing.T) {
+	ctx := context.Background()
+	f := newFormat()
+	evt := newEvent()
+
+	b, err := f.Marshal(ctx, evt)
+	if err != nil {
+		t.Fatalf("Marshal() failed with %q", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+
+	if data, ok := m["data"].(map[string]any); !ok || data["A"] != "foo" {
+		t.Fatalf("JSON data should be embedded as JSON; got %v", m["data"])
+	}
+
+	if m["correlationid"] != "abc 123" || m["aggregateversion"] != float64(3) {
+		t.Fatalf("extensions should be top-level attributes; got %v", m)
+	}
+
+	decoded, err := f.Unmarshal(ctx, b)
+	if err != nil {
+		t.Fatalf("Unmarshal() failed with %q", err)
+	}
+
+	test.AssertEqualEvents(t, []event.Event{evt}, []event.Event{decoded})
+}
+
+func TestEvent_MarshalJSON_base64(t *testing.T) {
+	ce := cloudevents.Event{
+		SpecVersion:     cloudevents.SpecVersion,
+		ID:              "1",
+		Source:          "test",
+		Type:            "foo",
+		DataContentType: "application/octet-stream",
+		Data:            []byte{0, 1, 2},
+	}
+
+	b, err := json.Marshal(ce)
+	if err != nil {
+		t.Fatalf("Marshal() failed with %q", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+
+	if m["data_base64"] != "AAEC" || m["data"] != nil {
+		t.Fatalf("binary data should be encoded as base64; got %v", m)
+	}
+
+	var decoded cloudevents.Event
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Unmarshal() failed with %q", err)
+	}
+
+	if string(decoded.Data) != string(ce.Data) {
+		t.Fatalf("data should be %v; got %v", ce.Data, decoded.Data)
+	}
+}
+
+func TestEvent_Validate(t *testing.T) {
+	valid := cloudevents.Event{SpecVersion: cloudevents.SpecVersion, ID: "1", Source: "test", Type: "foo"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() failed with %q", err)
+	}
+
+	invalid := []cloudevents.Event{
+		{SpecVersion: "0.3", ID: "1", Source: "test", Type: "foo"},
+		{SpecVersion: cloudevents.SpecVersion, Source: "test", Type: "foo"},
+		{SpecVersion: cloudevents.SpecVersion, ID: "1", Type: "foo"},
+		{SpecVersion: cloudevents.SpecVersion, ID: "1", Source: "test"},
+		{SpecVersion: cloudevents.SpecVersion, ID: "1", Source: "test", Type: "foo", Extensions: map[string]any{"Invalid-Name": 1}},
+	}
+
+	for _, ce := range invalid {
+		if err := ce.Validate(); !errors.Is(err, cloudevents.ErrInvalidEvent) {
+			t.Errorf("Validate() should fail with %q for %+v; got %v", cloudevents.ErrInvalidEvent, ce, err)
+		}
+	}
+}
+
+func TestFormat_Decode_foreignID(t *testing.T) {
+	f := newFormat()
+	ce := cloudevents.Event{
+		SpecVersion: cloudevents.SpecVersion,
+		ID:          "A234-1234-1234",
+		Source:      "https://example.com/other",
+		Type:        "foo",
+		Data:        []byte(`{"A":"foo"}`),
+	}
+
+	evt, err := f.Decode(context.Background(), ce)
+	if err != nil {
+		t.Fatalf("Decode() failed with %q", err)
+	}
+
+	again, err := f.Decode(context.Background(), ce)
+	if err != nil {
+		t.Fatalf("Decode() failed with %q", err)
+	}
+
+	if evt.ID() == uuid.Nil || evt.ID() != again.ID() {
+		t.Fatalf("Decode() should derive a deterministic event id; got %s and %s", evt.ID(), again.ID())
+	}
+
+	if data, ok := evt.Data().(test.FooEventData); !ok || data.A != "foo" {
+		t.Fatalf("unexpected event data %v", evt.Data())
+	}
+}
+
+func TestFormat_NewRequest_ReadRequest(t *testing.T) {
+	for _, binary := range []bool{false, true} {
+		var opts []cloudevents.Option
+		if binary {
+			opts = append(opts, cloudevents.BinaryMode())
+		}
+		f := newFormat(opts...)
+		evt := newEvent()
+
+		var received event.Event
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if binary && r.Header.Get("Ce-Correlationid") != "abc%20123" {
+				t.Errorf("binary mode should write extensions to escaped headers; got %q", r.Header.Get("Ce-Correlationid"))
+			}
+
+			var err error
+			if received, err = f.ReadRequest(r); err != nil {
+				t.Errorf("ReadRequest() failed with %q", err)
+			}
+		}))
+
+		req, err := f.NewRequest(context.Background(), http.MethodPost, srv.URL, evt)
+		if err != nil {
+			t.Fatalf("NewRequest() failed with %q", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("send request: %v", err)
+		}
+		resp.Body.Close()
+		srv.Close()
+
+		test.AssertEqualEvents(t, []event.Event{evt}, []event.Event{received})
+	}
+}
+
+func TestReadHTTP_notCloudEvent(t *testing.T) {
+	h := make(http.Header)
+	h.Set("Content-Type", "application/json")
+
+	if _, err := cloudevents.ReadHTTP(h, []byte(`{}`)); !errors.Is(err, cloudevents.ErrNotCloudEvent) {
+		t.Fatalf("ReadHTTP() should fail with %q; got %v", cloudevents.ErrNotCloudEvent, err)
+	}
+}
@@ -0,0 +1,206 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// DefaultContentType is the default content type of the event data.
+const DefaultContentType = "application/json"
+
+// Namespace is the namespace of the event ids that are derived from CloudEvent
+// ids that are not UUIDs.
+var Namespace = uuid.MustParse("c7a1d3f2-4e6b-4b8a-9d15-0f2e8c6a7b31")
+
+// Format converts goes events from and to CloudEvents. The data of the events
+// is encoded and decoded using the codec.Encoding of the Format.
+type Format struct {
+	enc         codec.Encoding
+	source      string
+	contentType string
+	binary      bool
+	extensions  []func(context.Context, event.Event) map[string]any
+}
+
+// Option is an option for a Format.
+type Option func(*Format)
+
+// ContentType returns an Option that specifies the content type of the data of
+// encoded events. The content type must match the encoding of the Format.
+// Defaults to DefaultContentType.
+func ContentType(contentType string) Option {
+	return func(f *Format) {
+		f.contentType = contentType
+	}
+}
+
+// Extensions returns an Option that adds the extension attributes that are
+// returned by fn to encoded events. Use Extensions to propagate correlation
+// data that is not part of the goes event itself:
+//
+//	cloudevents.Extensions(func(ctx context.Context, _ event.Event) map[string]any {
+//		return map[string]any{"correlationid": correlationIDFromContext(ctx)}
+//	})
+//
+// Extensions cannot override the aggregate extensions of the Format.
+func Extensions(fn func(context.Context, event.Event) map[string]any) Option {
+	return func(f *Format) {
+		f.extensions = append(f.extensions, fn)
+	}
+}
+
+// BinaryMode returns an Option that writes events in the binary content mode
+// of protocol bindings, where the event attributes are transferred as headers
+// and the event data as the message body. By default, events are written in
+// the structured content mode, where the message body is the JSON-encoded
+// CloudEvent. Events are always read in either mode.
+func BinaryMode() Option {
+	return func(f *Format) {
+		f.binary = true
+	}
+}
+
+// New returns a Format that converts goes events from and to CloudEvents. The
+// source identifies the context in which the events happen, e.g. the URI of
+// the service that publishes the events.
+func New(enc codec.Encoding, source string, opts ...Option) *Format {
+	f := &Format{
+		enc:         enc,
+		source:      source,
+		contentType: DefaultContentType,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Binary reports whether the Format writes events in binary content mode.
+func (f *Format) Binary() bool {
+	return f.binary
+}
+
+// Encode converts the given goes event into a CloudEvent.
+func (f *Format) Encode(ctx context.Context, evt event.Event) (Event, error) {
+	data, err := codec.MarshalContext(ctx, f.enc, evt.Data())
+	if err != nil {
+		return Event{}, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	ce := Event{
+		SpecVersion:     SpecVersion,
+		ID:              evt.ID().String(),
+		Source:          f.source,
+		Type:            evt.Name(),
+		Time:            evt.Time(),
+		DataContentType: f.contentType,
+		Data:            data,
+	}
+
+	for _, fn := range f.extensions {
+		for name, v := range fn(ctx, evt) {
+			if ce.Extensions == nil {
+				ce.Extensions = make(map[string]any)
+			}
+			ce.Extensions[name] = v
+		}
+	}
+
+	if id, name, v := evt.Aggregate(); name != "" {
+		if ce.Extensions == nil {
+			ce.Extensions = make(map[string]any)
+		}
+		ce.Subject = name + "/" + id.String()
+		ce.Extensions[AggregateNameExtension] = name
+		ce.Extensions[AggregateIDExtension] = id.String()
+		ce.Extensions[AggregateVersionExtension] = v
+	}
+
+	if err := ce.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	return ce, nil
+}
+
+// Decode converts the given CloudEvent into a goes event. The type of the
+// CloudEvent is used as the event name, and its data is decoded using the
+// encoding of the Format. If the id of the CloudEvent is not a UUID, a
+// deterministic event id is derived from its source and id. If the CloudEvent
+// has no time, the current time is used.
+func (f *Format) Decode(ctx context.Context, ce Event) (event.Event, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(ce.ID)
+	if err != nil {
+		id = uuid.NewSHA1(Namespace, []byte(ce.Source+"\x00"+ce.ID))
+	}
+
+	t := ce.Time
+	if t.IsZero() {
+		t = xtime.Now()
+	}
+
+	opts := []event.Option{event.ID(id), event.Time(t)}
+
+	aggregateName, ok := ce.Extension(AggregateNameExtension)
+	if ok && aggregateName != "" {
+		rawID, _ := ce.Extension(AggregateIDExtension)
+		aggregateID, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %q extension: %w", ErrInvalidEvent, AggregateIDExtension, err)
+		}
+
+		var version int
+		if raw, ok := ce.Extension(AggregateVersionExtension); ok {
+			if version, err = strconv.Atoi(raw); err != nil {
+				return nil, fmt.Errorf("%w: invalid %q extension: %w", ErrInvalidEvent, AggregateVersionExtension, err)
+			}
+		}
+
+		opts = append(opts, event.Aggregate(aggregateID, aggregateName, version))
+	}
+
+	data, err := codec.UnmarshalContext(ctx, f.enc, ce.Data, ce.Type)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q event data: %w", ce.Type, err)
+	}
+
+	return event.New(ce.Type, data, opts...).Any(), nil
+}
+
+// Marshal encodes the given goes event as a CloudEvent in the structured JSON
+// format.
+func (f *Format) Marshal(ctx context.Context, evt event.Event) ([]byte, error) {
+	ce, err := f.Encode(ctx, evt)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ce)
+}
+
+// Unmarshal decodes a goes event from a CloudEvent in the structured JSON
+// format.
+func (f *Format) Unmarshal(ctx context.Context, b []byte) (event.Event, error) {
+	var ce Event
+	if err := json.Unmarshal(b, &ce); err != nil {
+		return nil, fmt.Errorf("decode cloudevent: %w", err)
+	}
+	return f.Decode(ctx, ce)
+}
+
+// structured reports whether contentType is the media type of the structured
+// JSON format.
+func structured(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), MediaType)
+}
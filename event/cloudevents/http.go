@@ -0,0 +1,190 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/modernice/goes/event"
+)
+
+// headerPrefix is the prefix of the headers that hold the attributes of events
+// in the binary content mode of the HTTP protocol binding.
+const headerPrefix = "ce-"
+
+// WriteHTTP writes the given CloudEvent to the header h and returns the
+// message body, using the HTTP protocol binding. In structured mode, the body
+// is the JSON-encoded event. In binary mode, the attributes of the event are
+// written to "ce-" headers and the body is the event data.
+func WriteHTTP(h http.Header, ce Event, binary bool) ([]byte, error) {
+	if !binary {
+		b, err := json.Marshal(ce)
+		if err != nil {
+			return nil, err
+		}
+		h.Set("Content-Type", MediaType+"; charset=utf-8")
+		return b, nil
+	}
+
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+
+	set := func(attr, v string) {
+		if v != "" {
+			h.Set(headerPrefix+attr, escapeHeader(v))
+		}
+	}
+
+	set("specversion", ce.SpecVersion)
+	set("id", ce.ID)
+	set("source", ce.Source)
+	set("type", ce.Type)
+	set("subject", ce.Subject)
+	set("dataschema", ce.DataSchema)
+
+	if !ce.Time.IsZero() {
+		set("time", ce.Time.Format(time.RFC3339Nano))
+	}
+
+	for name := range ce.Extensions {
+		v, _ := ce.Extension(name)
+		set(name, v)
+	}
+
+	if ce.DataContentType != "" {
+		h.Set("Content-Type", ce.DataContentType)
+	}
+
+	return ce.Data, nil
+}
+
+// ReadHTTP reads a CloudEvent from the header h and message body of an HTTP
+// request or response. The content mode is detected from the Content-Type
+// header. If the message contains no CloudEvent, ReadHTTP returns
+// ErrNotCloudEvent.
+func ReadHTTP(h http.Header, body []byte) (Event, error) {
+	if structured(h.Get("Content-Type")) {
+		var ce Event
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return Event{}, fmt.Errorf("decode cloudevent: %w", err)
+		}
+		return ce, nil
+	}
+
+	if h.Get(headerPrefix+"specversion") == "" {
+		return Event{}, ErrNotCloudEvent
+	}
+
+	ce := Event{DataContentType: h.Get("Content-Type")}
+	if len(body) > 0 {
+		ce.Data = body
+	}
+
+	for key, values := range h {
+		attr := strings.ToLower(key)
+		if !strings.HasPrefix(attr, headerPrefix) || len(values) == 0 {
+			continue
+		}
+		attr = strings.TrimPrefix(attr, headerPrefix)
+
+		v, err := url.PathUnescape(values[0])
+		if err != nil {
+			return Event{}, fmt.Errorf("decode %q header: %w", key, err)
+		}
+
+		switch attr {
+		case "specversion":
+			ce.SpecVersion = v
+		case "id":
+			ce.ID = v
+		case "source":
+			ce.Source = v
+		case "type":
+			ce.Type = v
+		case "subject":
+			ce.Subject = v
+		case "dataschema":
+			ce.DataSchema = v
+		case "time":
+			if ce.Time, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return Event{}, fmt.Errorf("decode %q header: %w", key, err)
+			}
+		default:
+			if ce.Extensions == nil {
+				ce.Extensions = make(map[string]any)
+			}
+			ce.Extensions[attr] = v
+		}
+	}
+
+	if err := ce.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	return ce, nil
+}
+
+// NewRequest returns an HTTP request that sends the given event as a
+// CloudEvent to the given URL. The event is written in structured mode, or in
+// binary mode if the BinaryMode option is used.
+func (f *Format) NewRequest(ctx context.Context, method, url string, evt event.Event) (*http.Request, error) {
+	ce, err := f.Encode(ctx, evt)
+	if err != nil {
+		return nil, err
+	}
+
+	h := make(http.Header)
+	body, err := WriteHTTP(h, ce, f.binary)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range h {
+		req.Header[key] = values
+	}
+
+	return req, nil
+}
+
+// ReadRequest reads the CloudEvent of the given HTTP request and decodes it
+// into a goes event.
+func (f *Format) ReadRequest(r *http.Request) (event.Event, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	ce, err := ReadHTTP(r.Header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Decode(r.Context(), ce)
+}
+
+// escapeHeader percent-encodes the characters of v that must be encoded in
+// header values: spaces, double quotes, percent signs, and all characters
+// outside of printable ASCII.
+func escapeHeader(v string) string {
+	var b strings.Builder
+	for _, c := range []byte(v) {
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
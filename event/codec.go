@@ -1,9 +1,46 @@
 package event
 
-import "github.com/modernice/goes/codec"
+import (
+	"fmt"
+
+	"github.com/modernice/goes/codec"
+)
 
 // NewRegistry returns a new event registry for encoding and decoding of event
 // data for transmission over a network.
 func NewRegistry(opts ...codec.Option) *codec.Registry {
 	return codec.New(opts...)
 }
+
+// DecodeError is the error that is reported by event stores for stored events
+// whose data cannot be decoded, e.g. because the data is corrupt or because
+// the event is not registered in the codec. It provides the stored event
+// as a RawEvent.
+type DecodeError struct {
+	RawEvent
+
+	// Err is the error that was returned by the codec.
+	Err error
+}
+
+// Error returns a description of the decode error.
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("decode %q event data: %v [id=%s]", err.Name, err.Err, err.ID)
+}
+
+// Unwrap returns the error that was returned by the codec.
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// UndecodableHandler returns the function that was provided to the query using
+// the query.SkipUndecodable option, or nil if the option was not used. Event
+// stores that decode event data should call this function for every event
+// whose data cannot be decoded and skip the event, instead of reporting the
+// error in the error channel of the query result.
+func UndecodableHandler(q Query) func(*DecodeError) {
+	if q, ok := q.(interface{ Undecodable() func(*DecodeError) }); ok {
+		return q.Undecodable()
+	}
+	return nil
+}
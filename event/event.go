@@ -85,6 +85,11 @@ type Data[D any] struct {
 	AggregateName    string
 	AggregateID      uuid.UUID
 	AggregateVersion int
+
+	// Tags points to the tags of the event (see Tag). It is a pointer to keep
+	// events comparable, and the tags must not be modified after they were
+	// assigned.
+	Tags *[]string
 }
 
 // ID returns the unique identifier of the event.
@@ -113,6 +118,24 @@ func Aggregate(id uuid.UUID, name string, version int) Option {
 	}
 }
 
+// Tag returns an Option that adds the given tags to an event. Tags group events
+// across aggregates, e.g. all events of an import batch, and can be queried
+// using the query.Tag option. Tags are persisted by the in-memory event store
+// and the MongoDB event store. Other event stores fail queries for tags with
+// ErrTagsUnsupported:
+//
+//	evt := event.New("foo", 3, event.Tag("import:42"))
+//	str, errs, err := store.Query(ctx, query.New(query.Tag("import:42")))
+func Tag(tags ...string) Option {
+	return func(evt *Evt[any]) {
+		if len(tags) == 0 {
+			return
+		}
+		merged := appendTags(evt.Tags(), tags...)
+		evt.D.Tags = &merged
+	}
+}
+
 // Previous sets the aggregate information for an event based on the provided
 // previous event, incrementing the aggregate version by 1. It returns an Option
 // to be used when creating a new event with New.
@@ -147,6 +170,7 @@ func New[D any](name string, data D, opts ...Option) Evt[D] {
 			AggregateName:    evt.D.AggregateName,
 			AggregateID:      evt.D.AggregateID,
 			AggregateVersion: evt.D.AggregateVersion,
+			Tags:             evt.D.Tags,
 		},
 	}
 }
@@ -189,19 +213,22 @@ func Sort[Events ~[]Of[D], D any](events Events, sort Sorting, dir SortDirection
 // SortMulti sorts a slice of events based on the provided sort options, in the
 // order they appear. If multiple events have the same value for a specified
 // sort option, they will be sorted based on the next sort option in the list.
-// If all sort options are equal, the original order is preserved.
+// If all sort options are equal, the events are sorted by their ID (see
+// TieBreak). Without sort options, the original order is preserved.
 func SortMulti[Events ~[]Of[D], D any](events Events, sorts ...SortOptions) Events {
 	sorted := make(Events, len(events))
 	copy(sorted, events)
 
-	sort.Slice(sorted, func(i, j int) bool {
+	sorts = TieBreak(sorts)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
 		for _, opts := range sorts {
 			cmp := CompareSorting(opts.Sort, sorted[i], sorted[j])
 			if cmp != 0 {
 				return opts.Dir.Bool(cmp < 0)
 			}
 		}
-		return true
+		return false
 	})
 
 	return sorted
@@ -233,6 +260,14 @@ func (evt Evt[D]) Aggregate() (uuid.UUID, string, int) {
 	return evt.D.AggregateID, evt.D.AggregateName, evt.D.AggregateVersion
 }
 
+// Tags returns the tags of the event.
+func (evt Evt[D]) Tags() []string {
+	if evt.D.Tags == nil {
+		return nil
+	}
+	return *evt.D.Tags
+}
+
 // Any converts an event with a specific data type (Of[Data]) to an event with
 // the generic any data type (Evt[any]).
 func (evt Evt[D]) Any() Evt[any] {
@@ -264,6 +299,7 @@ func Cast[To, From any](evt Of[From]) Evt[To] {
 		ID(evt.ID()),
 		Time(evt.Time()),
 		Aggregate(evt.Aggregate()),
+		Tag(TagsOf(evt)...),
 	)
 }
 
@@ -282,6 +318,7 @@ func TryCast[To, From any](evt Of[From]) (Evt[To], bool) {
 		ID(evt.ID()),
 		Time(evt.Time()),
 		Aggregate(evt.Aggregate()),
+		Tag(TagsOf(evt)...),
 	), true
 }
 
@@ -293,7 +330,16 @@ func Expand[D any](evt Of[D]) Evt[D] {
 	if evt, ok := evt.(Evt[D]); ok {
 		return evt
 	}
-	return New(evt.Name(), evt.Data(), ID(evt.ID()), Time(evt.Time()), Aggregate(evt.Aggregate()))
+	return New(evt.Name(), evt.Data(), ID(evt.ID()), Time(evt.Time()), Aggregate(evt.Aggregate()), Tag(TagsOf(evt)...))
+}
+
+// TagsOf returns the tags of the given event, if the event provides a Tags
+// method (see Tag). Otherwise, TagsOf returns nil.
+func TagsOf[D any](evt Of[D]) []string {
+	if evt, ok := evt.(interface{ Tags() []string }); ok {
+		return evt.Tags()
+	}
+	return nil
 }
 
 func Test[Data any](q Query, evt Of[Data]) bool {
@@ -358,6 +404,19 @@ func Test[Data any](q Query, evt Of[Data]) bool {
 		}
 	}
 
+	if tags := QueryTags(q); len(tags) > 0 {
+		var found bool
+		for _, tag := range TagsOf(evt) {
+			if stringsContains(tags, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
 	if aggregates := q.Aggregates(); len(aggregates) > 0 {
 		var found bool
 		for _, aggregate := range aggregates {
@@ -375,6 +434,16 @@ func Test[Data any](q Query, evt Of[Data]) bool {
 	return true
 }
 
+func appendTags(tags []string, add ...string) []string {
+	tags = append(make([]string, 0, len(tags)+len(add)), tags...)
+	for _, tag := range add {
+		if !stringsContains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func stringsContains(vals []string, val string) bool {
 	for _, v := range vals {
 		if v == val {
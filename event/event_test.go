@@ -2,6 +2,7 @@ package event_test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -77,6 +78,27 @@ func TestNew_aggregate(t *testing.T) {
 	}
 }
 
+func TestNew_tag(t *testing.T) {
+	evt := event.New("foo", newMockData(), event.Tag("a", "b"), event.Tag("b", "c"))
+
+	want := []string{"a", "b", "c"}
+	if got := evt.Tags(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tags() should return %v; got %v", want, got)
+	}
+
+	if got := event.TagsOf(evt.Any()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Any() should keep the tags %v; got %v", want, got)
+	}
+
+	untagged := event.New("foo", newMockData())
+	if got := untagged.Tags(); got != nil {
+		t.Fatalf("Tags() should return nil for an untagged event; got %v", got)
+	}
+
+	// Events must stay comparable.
+	_ = map[event.Event]bool{evt.Any(): true}
+}
+
 func TestNew_previous(t *testing.T) {
 	aggregateID := uuid.New()
 	prev := event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(aggregateID, "foobar", 3))
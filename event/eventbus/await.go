@@ -2,10 +2,13 @@ package eventbus
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/internal/concurrent"
+	"golang.org/x/exp/slices"
 )
 
 // Await returns a channel that receives the first occurrence of an event with
@@ -80,3 +83,181 @@ func (a Awaiter[D]) Once(ctx context.Context, names ...string) (<-chan event.Of[
 
 	return out, outErrs, nil
 }
+
+// ErrAwaitTimeout is returned by AwaitAll and AwaitAny if the awaited events
+// were not received before the timeout.
+var ErrAwaitTimeout = errors.New("timed out waiting for events")
+
+// Condition is a condition for awaited events, which can be used with AwaitAll
+// and AwaitAny. A Condition matches an event if the event has one of the names
+// of the Condition, and all predicates of the Condition return true for the
+// event.
+type Condition struct {
+	names      []string
+	predicates []func(event.Event) bool
+}
+
+// On returns a Condition that matches events with one of the given names.
+// Use On with multiple names to await "either of these events".
+func On(names ...string) Condition {
+	return Condition{names: names}
+}
+
+// OnData returns a Condition that matches events with the given name whose
+// data is of type D and satisfies the given predicate.
+//
+//	cond := eventbus.OnData("order_placed", func(data OrderPlacedData) bool {
+//		return data.Total > 100
+//	})
+func OnData[D any](name string, predicate func(D) bool) Condition {
+	return On(name).Where(func(evt event.Event) bool {
+		data, ok := evt.Data().(D)
+		return ok && predicate(data)
+	})
+}
+
+// Where returns a copy of the Condition with an additional predicate that
+// must return true for matching events.
+func (c Condition) Where(predicate func(event.Event) bool) Condition {
+	c.predicates = append(append([]func(event.Event) bool(nil), c.predicates...), predicate)
+	return c
+}
+
+// Matches returns whether the given event matches the Condition.
+func (c Condition) Matches(evt event.Event) bool {
+	if !slices.Contains(c.names, evt.Name()) && !slices.Contains(c.names, event.All) {
+		return false
+	}
+	for _, predicate := range c.predicates {
+		if !predicate(evt) {
+			return false
+		}
+	}
+	return true
+}
+
+// AwaitAll waits until each of the given conditions is matched by an event that
+// is published over the bus, and returns the matching events in the order of
+// the conditions. Each event matches at most one condition. If conditions
+// overlap, a received event may be reassigned to another condition it matches,
+// so that AwaitAll returns as soon as the received events can satisfy all
+// conditions. A timeout <= 0 disables the timeout. If the timeout is exceeded,
+// AwaitAll returns an error that wraps ErrAwaitTimeout.
+//
+//	events, err := eventbus.AwaitAll(ctx, bus, 5*time.Second,
+//		eventbus.On("order_placed"),
+//		eventbus.OnData("payment_received", func(data PaymentReceivedData) bool {
+//			return data.OrderID == orderID
+//		}),
+//	)
+func AwaitAll(ctx context.Context, bus event.Bus, timeout time.Duration, conds ...Condition) ([]event.Event, error) {
+	out := make([]event.Event, len(conds))
+	remaining := len(conds)
+
+	// assign finds an augmenting path for evt (bipartite matching of events to
+	// conditions), moving previously assigned events to other conditions they
+	// match if necessary.
+	var assign func(evt event.Event, visited []bool) bool
+	assign = func(evt event.Event, visited []bool) bool {
+		for i, cond := range conds {
+			if visited[i] || !cond.Matches(evt) {
+				continue
+			}
+			visited[i] = true
+			if out[i] == nil || assign(out[i], visited) {
+				out[i] = evt
+				return true
+			}
+		}
+		return false
+	}
+
+	err := awaitConditions(ctx, bus, timeout, conds, func(evt event.Event) bool {
+		if assign(evt, make([]bool, len(conds))) {
+			remaining--
+		}
+		return remaining == 0
+	})
+
+	return out, err
+}
+
+// AwaitAny waits until one of the given conditions is matched by an event that
+// is published over the bus, and returns the event and the index of the
+// matched condition. A timeout <= 0 disables the timeout. If the timeout is
+// exceeded, AwaitAny returns an error that wraps ErrAwaitTimeout.
+//
+//	evt, i, err := eventbus.AwaitAny(ctx, bus, 5*time.Second,
+//		eventbus.On("payment_succeeded"),
+//		eventbus.On("payment_failed", "payment_canceled"),
+//	)
+func AwaitAny(ctx context.Context, bus event.Bus, timeout time.Duration, conds ...Condition) (event.Event, int, error) {
+	var (
+		out event.Event
+		idx = -1
+	)
+
+	err := awaitConditions(ctx, bus, timeout, conds, func(evt event.Event) bool {
+		for i, cond := range conds {
+			if cond.Matches(evt) {
+				out, idx = evt, i
+				return true
+			}
+		}
+		return false
+	})
+
+	return out, idx, err
+}
+
+func awaitConditions(ctx context.Context, bus event.Bus, timeout time.Duration, conds []Condition, handle func(event.Event) bool) error {
+	if len(conds) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, cond := range conds {
+		for _, name := range cond.names {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := bus.Subscribe(ctx, names...)
+	if err != nil {
+		return fmt.Errorf("subscribe to %q events: %w", names, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("await %q events: %w", names, ErrAwaitTimeout)
+			}
+			return ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			return err
+		case evt, ok := <-events:
+			if !ok {
+				return fmt.Errorf("await %q events: subscription closed", names)
+			}
+			if handle(evt) {
+				return nil
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package eventbus_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -39,3 +40,156 @@ func TestAwaiter_Once(t *testing.T) {
 	ex.Closed(sub, 50*time.Millisecond)
 	ex.Apply(t)
 }
+
+func TestAwaitAll(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+
+	result := make(chan []event.Event)
+	errs := make(chan error, 1)
+	go func() {
+		events, err := eventbus.AwaitAll(ctx, bus, time.Second,
+			eventbus.On("foo"),
+			eventbus.OnData("bar", func(data test.BarEventData) bool { return data.A == "match" }),
+		)
+		if err != nil {
+			errs <- err
+			return
+		}
+		result <- events
+	}()
+
+	publishUntilAwaited(t, bus, result, errs,
+		event.New[any]("bar", test.BarEventData{A: "no match"}),
+		event.New[any]("baz", test.BazEventData{}),
+		event.New[any]("bar", test.BarEventData{A: "match"}),
+		event.New[any]("foo", test.FooEventData{}),
+	)
+}
+
+func TestAwaitAny(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+
+	type res struct {
+		evt event.Event
+		idx int
+	}
+
+	result := make(chan res)
+	errs := make(chan error, 1)
+	go func() {
+		evt, i, err := eventbus.AwaitAny(ctx, bus, time.Second, eventbus.On("foo"), eventbus.On("bar", "baz"))
+		if err != nil {
+			errs <- err
+			return
+		}
+		result <- res{evt, i}
+	}()
+
+	timeout := time.After(time.Second)
+	for {
+		if err := bus.Publish(ctx, event.New[any]("baz", test.BazEventData{})); err != nil {
+			t.Fatalf("publish event: %v", err)
+		}
+
+		select {
+		case <-timeout:
+			t.Fatalf("timed out")
+		case err := <-errs:
+			t.Fatalf("AwaitAny() failed with %q", err)
+		case r := <-result:
+			if r.evt.Name() != "baz" || r.idx != 1 {
+				t.Fatalf("AwaitAny() should return the %q event and index %d; got %q and %d", "baz", 1, r.evt.Name(), r.idx)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestAwaitAll_timeout(t *testing.T) {
+	bus := eventbus.New()
+
+	if _, err := eventbus.AwaitAll(context.Background(), bus, 20*time.Millisecond, eventbus.On("foo")); !errors.Is(err, eventbus.ErrAwaitTimeout) {
+		t.Fatalf("AwaitAll() should fail with %q; got %q", eventbus.ErrAwaitTimeout, err)
+	}
+}
+
+func TestAwaitAll_overlappingConditions(t *testing.T) {
+	bus := &readyBus{Bus: eventbus.New(), ready: make(chan struct{})}
+
+	result := make(chan []event.Event, 1)
+	errs := make(chan error, 1)
+	go func() {
+		events, err := eventbus.AwaitAll(context.Background(), bus, time.Second, eventbus.On("foo", "bar"), eventbus.On("foo"))
+		if err != nil {
+			errs <- err
+			return
+		}
+		result <- events
+	}()
+
+	<-bus.ready
+
+	for _, name := range []string{"foo", "bar"} {
+		if err := bus.Publish(context.Background(), event.New[any](name, test.FooEventData{})); err != nil {
+			t.Fatalf("publish event: %v", err)
+		}
+	}
+
+	select {
+	case err := <-errs:
+		t.Fatalf("AwaitAll() failed with %q", err)
+	case events := <-result:
+		if events[0].Name() != "bar" || events[1].Name() != "foo" {
+			t.Fatalf("AwaitAll() should return [bar foo]; got [%s %s]", events[0].Name(), events[1].Name())
+		}
+	}
+}
+
+type readyBus struct {
+	event.Bus
+	ready chan struct{}
+}
+
+func (bus *readyBus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	events, errs, err := bus.Bus.Subscribe(ctx, names...)
+	close(bus.ready)
+	return events, errs, err
+}
+
+// publishUntilAwaited repeatedly publishes the events until AwaitAll returns, because
+// the subscription of AwaitAll may not be ready when the events are published
+// for the first time.
+func publishUntilAwaited(t *testing.T, bus event.Bus, result <-chan []event.Event, errs <-chan error, events ...event.Event) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		for _, evt := range events {
+			if err := bus.Publish(context.Background(), event.New(evt.Name(), evt.Data()).Any()); err != nil {
+				t.Fatalf("publish event: %v", err)
+			}
+		}
+
+		select {
+		case <-timeout:
+			t.Fatalf("timed out")
+		case err := <-errs:
+			t.Fatalf("AwaitAll() failed with %q", err)
+		case events := <-result:
+			if len(events) != 2 {
+				t.Fatalf("AwaitAll() should return %d events; got %d", 2, len(events))
+			}
+			if events[0].Name() != "foo" {
+				t.Fatalf("first event should be %q; got %q", "foo", events[0].Name())
+			}
+			if data, ok := events[1].Data().(test.BarEventData); !ok || data.A != "match" {
+				t.Fatalf("second event should be the matching %q event; got %v", "bar", events[1].Data())
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
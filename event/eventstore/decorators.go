@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
 )
 
 // WithBus decorates the given event store with the given event bus. Events are
@@ -46,3 +47,43 @@ func (s *storeWithBus) Insert(ctx context.Context, events ...event.Event) error
 
 	return nil
 }
+
+// Stats returns the statistics of the decorated store.
+func (s *storeWithBus) Stats(ctx context.Context) (event.StoreStats, error) {
+	return Stats(ctx, s.Store)
+}
+
+// WithDefaultSort decorates the given event store so that queries that do not
+// specify any sortings are sorted by the given sortings. Stores return the
+// events of unsorted queries in an order that is specific to the store and
+// may change between queries, e.g. the insertion order of the in-memory store
+// or the natural order of the database. Stores break ties between events that
+// are equal in all sortings by the event id (see event.TieBreak), so the
+// default sort makes the order of all queries deterministic:
+//
+//	store = eventstore.WithDefaultSort(store, event.SortOptions{Sort: event.SortTime})
+func WithDefaultSort(s event.Store, sorts ...event.SortOptions) event.Store {
+	return &storeWithDefaultSort{
+		Store: s,
+		sorts: sorts,
+	}
+}
+
+type storeWithDefaultSort struct {
+	event.Store
+	sorts []event.SortOptions
+}
+
+// Query queries the decorated store. If q is unsorted, it is sorted by the
+// default sort of the store.
+func (s *storeWithDefaultSort) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if len(q.Sortings()) == 0 && len(s.sorts) > 0 {
+		q = query.Merge(q, query.New(query.SortByMulti(s.sorts...)))
+	}
+	return s.Store.Query(ctx, q)
+}
+
+// Stats returns the statistics of the decorated store.
+func (s *storeWithDefaultSort) Stats(ctx context.Context) (event.StoreStats, error) {
+	return Stats(ctx, s.Store)
+}
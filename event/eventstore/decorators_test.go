@@ -3,13 +3,17 @@ package eventstore_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/eventbus"
 	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/test"
 	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/internal/xtime"
 )
 
 func TestWithBus(t *testing.T) {
@@ -44,3 +48,25 @@ func TestWithBus(t *testing.T) {
 		t.Errorf("received wrong event. want=%v got=%v\n\n%s", evt, walkedEvent, cmp.Diff(walkedEvent, evt.Any().Event()))
 	}
 }
+
+func TestWithDefaultSort(t *testing.T) {
+	now := xtime.Now()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.ID(uuid.MustParse("C0000000-0000-0000-0000-000000000000")), event.Time(now)),
+		event.New[any]("foo", test.FooEventData{}, event.ID(uuid.MustParse("A0000000-0000-0000-0000-000000000000")), event.Time(now.Add(time.Minute))),
+		event.New[any]("foo", test.FooEventData{}, event.ID(uuid.MustParse("B0000000-0000-0000-0000-000000000000")), event.Time(now)),
+	}
+
+	store := eventstore.WithDefaultSort(eventstore.New(), event.SortOptions{Sort: event.SortTime, Dir: event.SortAsc})
+
+	ctx := context.Background()
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	result := drain(t, store, query.New())
+	test.AssertEqualEvents(t, []event.Event{events[2], events[0], events[1]}, result)
+
+	result = drain(t, store, query.New(query.SortBy(event.SortTime, event.SortDesc)))
+	test.AssertEqualEvents(t, []event.Event{events[1], events[0], events[2]}, result)
+}
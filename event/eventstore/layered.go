@@ -0,0 +1,278 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// LayeredStore is an event store that combines a fast hot store with a
+// persistent cold store. Use Layered to create a LayeredStore.
+type LayeredStore struct {
+	hot  event.Store
+	cold event.Store
+
+	mux sync.RWMutex
+
+	// Events are written to the hot store while it is warming up or loading
+	// an aggregate, so that events that are inserted while loading are not
+	// missed. Queries are only served from the hot store after loading.
+	warming  bool
+	complete bool
+	tracked  map[event.AggregateRef]bool
+	loaded   map[event.AggregateRef]bool
+}
+
+// Layered returns an event store that writes events to both the hot and the
+// cold store, and serves queries from the hot store when possible. The cold
+// store is the source of truth, while the hot store (typically an in-memory
+// store created by New) caches events for read performance:
+//
+//	store := eventstore.Layered(eventstore.New(), mongoStore)
+//	err := store.Warm(ctx) // optional
+//
+// Queries are served from the hot store if the hot store contains all events
+// (after calling Warm), or if the query is restricted to specific aggregates
+// that are already loaded into the hot store. Otherwise, queries are served
+// from the cold store, and the aggregates of queries that are restricted to
+// specific aggregates are loaded into the hot store, so that subsequent
+// queries for these aggregates, e.g. by an aggregate repository, are served
+// from the hot store.
+//
+// The hot store must not be written to directly.
+func Layered(hot, cold event.Store) *LayeredStore {
+	return &LayeredStore{
+		hot:     hot,
+		cold:    cold,
+		tracked: make(map[event.AggregateRef]bool),
+		loaded:  make(map[event.AggregateRef]bool),
+	}
+}
+
+// Hot returns the hot store.
+func (s *LayeredStore) Hot() event.Store {
+	return s.hot
+}
+
+// Cold returns the cold store.
+func (s *LayeredStore) Cold() event.Store {
+	return s.cold
+}
+
+// Warm loads all events of the cold store into the hot store. Afterwards, all
+// queries are served from the hot store.
+func (s *LayeredStore) Warm(ctx context.Context) error {
+	s.mux.Lock()
+	s.warming = true
+	s.mux.Unlock()
+
+	if err := s.load(ctx, query.New()); err != nil {
+		s.mux.Lock()
+		s.warming = false
+		s.mux.Unlock()
+		return err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.complete = true
+
+	return nil
+}
+
+// Insert inserts events into the cold store and then into the hot store, if
+// the hot store caches the events' aggregates.
+func (s *LayeredStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.cold.Insert(ctx, events...); err != nil {
+		return err
+	}
+
+	cached := make([]event.Event, 0, len(events))
+	for _, evt := range events {
+		if s.caches(aggregateRef(evt)) {
+			cached = append(cached, evt)
+		}
+	}
+
+	if len(cached) == 0 {
+		return nil
+	}
+
+	if err := s.hot.Insert(ctx, cached...); err != nil {
+		// The hot store may now be missing events, so it must no longer be
+		// used to serve queries for the affected aggregates.
+		s.invalidate(cached)
+		return fmt.Errorf("insert into hot store: %w", err)
+	}
+
+	return nil
+}
+
+// Find returns the event with the given id from the hot store, or from the
+// cold store if the hot store does not contain the event.
+func (s *LayeredStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	if evt, err := s.hot.Find(ctx, id); err == nil {
+		return evt, nil
+	}
+	return s.cold.Find(ctx, id)
+}
+
+// Query queries the hot store if it contains all events that match the query.
+// Otherwise, the cold store is queried.
+func (s *LayeredStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	if s.servesFromHot(q) {
+		return s.hot.Query(ctx, q)
+	}
+
+	if refs, ok := queriedAggregates(q); ok {
+		if err := s.loadAggregates(ctx, refs); err != nil {
+			return nil, nil, fmt.Errorf("load aggregates into hot store: %w", err)
+		}
+		return s.hot.Query(ctx, q)
+	}
+
+	return s.cold.Query(ctx, q)
+}
+
+// Delete deletes events from the cold store and the hot store.
+func (s *LayeredStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.cold.Delete(ctx, events...); err != nil {
+		return err
+	}
+
+	if err := s.hot.Delete(ctx, events...); err != nil {
+		s.invalidate(events)
+		return fmt.Errorf("delete from hot store: %w", err)
+	}
+
+	return nil
+}
+
+// Stats returns the statistics of the hot store if it contains all events.
+// Otherwise, the statistics of the cold store are returned.
+func (s *LayeredStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	s.mux.RLock()
+	complete := s.complete
+	s.mux.RUnlock()
+
+	if complete {
+		return Stats(ctx, s.hot)
+	}
+	return Stats(ctx, s.cold)
+}
+
+func (s *LayeredStore) caches(ref event.AggregateRef) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.warming || s.complete || s.tracked[ref]
+}
+
+func (s *LayeredStore) invalidate(events []event.Event) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.warming = false
+	s.complete = false
+	for _, evt := range events {
+		ref := aggregateRef(evt)
+		delete(s.tracked, ref)
+		delete(s.loaded, ref)
+	}
+}
+
+func (s *LayeredStore) servesFromHot(q event.Query) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.complete {
+		return true
+	}
+
+	refs, ok := queriedAggregates(q)
+	if !ok {
+		return false
+	}
+
+	for _, ref := range refs {
+		if !s.loaded[ref] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *LayeredStore) loadAggregates(ctx context.Context, refs []event.AggregateRef) error {
+	for _, ref := range refs {
+		s.mux.Lock()
+		if s.complete || s.loaded[ref] {
+			s.mux.Unlock()
+			continue
+		}
+		s.tracked[ref] = true
+		s.mux.Unlock()
+
+		if err := s.load(ctx, query.New(query.Aggregate(ref.Name, ref.ID))); err != nil {
+			s.mux.Lock()
+			delete(s.tracked, ref)
+			s.mux.Unlock()
+			return fmt.Errorf("%s(%s): %w", ref.Name, ref.ID, err)
+		}
+
+		s.mux.Lock()
+		s.loaded[ref] = true
+		s.mux.Unlock()
+	}
+	return nil
+}
+
+// load inserts the events of the cold store that match the query into the hot
+// store, skipping events that the hot store already contains.
+func (s *LayeredStore) load(ctx context.Context, q event.Query) error {
+	str, errs, err := s.cold.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("query cold store: %w", err)
+	}
+
+	return streams.Walk(ctx, func(evt event.Event) error {
+		if _, err := s.hot.Find(ctx, evt.ID()); err == nil {
+			return nil
+		}
+
+		if err := s.hot.Insert(ctx, evt); err != nil {
+			// the event may have been inserted concurrently
+			if _, ferr := s.hot.Find(ctx, evt.ID()); ferr == nil {
+				return nil
+			}
+			return fmt.Errorf("insert %q event into hot store: %w", evt.Name(), err)
+		}
+
+		return nil
+	}, str, errs)
+}
+
+// queriedAggregates returns the aggregates that the query is restricted to,
+// if it is restricted to specific aggregates.
+func queriedAggregates(q event.Query) ([]event.AggregateRef, bool) {
+	refs := q.Aggregates()
+	if len(refs) == 0 {
+		return nil, false
+	}
+
+	for _, ref := range refs {
+		if ref.Name == "" || ref.ID == uuid.Nil {
+			return nil, false
+		}
+	}
+
+	return refs, true
+}
+
+func aggregateRef(evt event.Event) event.AggregateRef {
+	id, name, _ := evt.Aggregate()
+	return event.AggregateRef{Name: name, ID: id}
+}
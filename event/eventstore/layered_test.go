@@ -0,0 +1,100 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestLayered(t *testing.T) {
+	eventstoretest.Run(t, "layered", func(codec.Encoding) event.Store {
+		return eventstore.Layered(eventstore.New(), eventstore.New())
+	})
+
+	eventstoretest.Run(t, "layered (warm)", func(codec.Encoding) event.Store {
+		store := eventstore.Layered(eventstore.New(), eventstore.New())
+		if err := store.Warm(context.Background()); err != nil {
+			t.Fatalf("Warm() failed with %q", err)
+		}
+		return store
+	})
+}
+
+func TestLayeredStore_Query_loadsAggregates(t *testing.T) {
+	ctx := context.Background()
+
+	hot, cold := eventstore.New(), eventstore.New()
+	store := eventstore.Layered(hot, cold)
+
+	fooID, barID := uuid.New(), uuid.New()
+	foo := []event.Event{
+		event.New("foo", test.FooEventData{}, event.Aggregate(fooID, "foo", 1)).Any(),
+		event.New("foo", test.FooEventData{}, event.Aggregate(fooID, "foo", 2)).Any(),
+	}
+	bar := event.New("bar", test.BarEventData{}, event.Aggregate(barID, "bar", 1)).Any()
+
+	if err := cold.Insert(ctx, append(foo, bar)...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	q := query.New(query.Aggregate("foo", fooID), query.SortByAggregate())
+	test.AssertEqualEvents(t, foo, drain(t, store, q))
+
+	// the aggregate is loaded into the hot store
+	test.AssertEqualEvents(t, foo, drain(t, hot, q))
+
+	// new events of loaded aggregates are inserted into both stores
+	next := event.New("foo", test.FooEventData{}, event.Aggregate(fooID, "foo", 3)).Any()
+	if err := store.Insert(ctx, next); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+	test.AssertEqualEvents(t, append(foo, next), drain(t, hot, q))
+
+	// events of other aggregates are only inserted into the cold store
+	other := event.New("bar", test.BarEventData{}, event.Aggregate(barID, "bar", 2)).Any()
+	if err := store.Insert(ctx, other); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+	if events := drain(t, hot, query.New(query.AggregateID(barID))); len(events) != 0 {
+		t.Fatalf("hot store should not contain events of unloaded aggregates; got %d events", len(events))
+	}
+
+	// queries that are not restricted to aggregates are served from the cold store
+	if events := drain(t, store, query.New()); len(events) != 5 {
+		t.Fatalf("Query() should return %d events; got %d", 5, len(events))
+	}
+}
+
+func TestLayeredStore_Warm(t *testing.T) {
+	ctx := context.Background()
+
+	hot, cold := eventstore.New(), eventstore.New()
+	store := eventstore.Layered(hot, cold)
+
+	events := []event.Event{
+		event.New("foo", test.FooEventData{}).Any(),
+		event.New("bar", test.BarEventData{}, event.Aggregate(uuid.New(), "bar", 1)).Any(),
+	}
+	if err := cold.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := store.Warm(ctx); err != nil {
+		t.Fatalf("Warm() failed with %q", err)
+	}
+
+	test.AssertEqualEventsUnsorted(t, events, drain(t, hot, query.New()))
+
+	// after warming up, queries are served from the hot store
+	if err := cold.Delete(ctx, events[0]); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+	test.AssertEqualEventsUnsorted(t, events, drain(t, store, query.New()))
+}
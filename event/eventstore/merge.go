@@ -0,0 +1,155 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// QueryAll runs the query against the provided stores concurrently and merges
+// the results into a single stream. QueryAll can be used by store decorators
+// that compose multiple stores (shards, archives, replicas) without losing the
+// sorting guarantees of the query: if the query has sortings, the results of
+// the stores are merged into a stream that is sorted accordingly; otherwise
+// the results are returned in the order they are received.
+//
+// If any of the stores fails to start the query, the already started queries
+// are canceled and the errors of all failed stores are returned.
+func QueryAll(ctx context.Context, q event.Query, stores ...event.Store) (<-chan event.Event, <-chan error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	type result struct {
+		events <-chan event.Event
+		errs   <-chan error
+		err    error
+	}
+
+	results := make([]result, len(stores))
+	done := make(chan struct{}, len(stores))
+	for i, store := range stores {
+		i, store := i, store
+		go func() {
+			defer func() { done <- struct{}{} }()
+			events, errs, err := store.Query(ctx, q)
+			results[i] = result{events, errs, err}
+		}()
+	}
+	for range stores {
+		<-done
+	}
+
+	var (
+		events = make([]<-chan event.Event, 0, len(stores))
+		errs   = make([]<-chan error, 0, len(stores))
+		failed []error
+	)
+	for i, res := range results {
+		if res.err != nil {
+			failed = append(failed, fmt.Errorf("store #%d: %w", i, res.err))
+			continue
+		}
+		events = append(events, res.events)
+		errs = append(errs, res.errs)
+	}
+
+	if len(failed) > 0 {
+		cancel()
+		return nil, nil, errors.Join(failed...)
+	}
+
+	merged := MergeSorted(ctx, q.Sortings(), events...)
+
+	// release the context after the merged stream has been drained
+	out := make(chan event.Event)
+	go func() {
+		defer cancel()
+		defer close(out)
+		for evt := range merged {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, streams.FanInContext(ctx, errs...), nil
+}
+
+// MergeSorted merges the given streams, each of which must be sorted by the
+// given sortings, into a single stream that is sorted by the same sortings.
+// If no sortings are provided, the streams are merged in the order the events
+// are received. The returned stream is closed when all input streams are
+// closed or ctx is canceled.
+func MergeSorted(ctx context.Context, sortings []event.SortOptions, in ...<-chan event.Event) <-chan event.Event {
+	if len(sortings) == 0 {
+		return streams.FanInContext(ctx, in...)
+	}
+
+	sortings = event.TieBreak(sortings)
+
+	less := func(a, b event.Event) bool {
+		for _, opts := range sortings {
+			if cmp := opts.Sort.Compare(a, b); cmp != 0 {
+				return opts.Dir.Bool(cmp < 0)
+			}
+		}
+		return false
+	}
+
+	in = append([]<-chan event.Event(nil), in...)
+	out := make(chan event.Event)
+
+	go func() {
+		defer close(out)
+
+		heads := make([]event.Event, len(in))
+		next := func(i int) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case evt, ok := <-in[i]:
+				if ok {
+					heads[i] = evt
+				} else {
+					heads[i] = nil
+				}
+				return true
+			}
+		}
+
+		for i := range in {
+			if !next(i) {
+				return
+			}
+		}
+
+		for {
+			min := -1
+			for i, head := range heads {
+				if head != nil && (min < 0 || less(head, heads[min])) {
+					min = i
+				}
+			}
+
+			if min < 0 {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- heads[min]:
+			}
+
+			if !next(min) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,99 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestQueryAll(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	stores := []event.Store{eventstore.New(), eventstore.New(), eventstore.New()}
+
+	var events []event.Event
+	for i := 0; i < 30; i++ {
+		evt := event.New("foo", test.FooEventData{}, event.Time(now.Add(time.Duration(i)*time.Millisecond))).Any()
+		events = append(events, evt)
+		if err := stores[i%len(stores)].Insert(ctx, evt); err != nil {
+			t.Fatalf("Insert() failed with %q", err)
+		}
+	}
+
+	str, errs, err := eventstore.QueryAll(ctx, query.New(query.SortBy(event.SortTime, event.SortDesc)), stores...)
+	if err != nil {
+		t.Fatalf("QueryAll() failed with %q", err)
+	}
+
+	result, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	if len(result) != len(events) {
+		t.Fatalf("QueryAll() should return %d events; got %d", len(events), len(result))
+	}
+
+	for i, evt := range result {
+		want := events[len(events)-1-i]
+		if evt.ID() != want.ID() {
+			t.Fatalf("event #%d should be %s; got %s", i, want.ID(), evt.ID())
+		}
+	}
+}
+
+func TestQueryAll_error(t *testing.T) {
+	mockErr := errors.New("mock error")
+	store := failingStore{Store: eventstore.New(), err: mockErr}
+
+	_, _, err := eventstore.QueryAll(context.Background(), query.New(), eventstore.New(), store)
+	if !errors.Is(err, mockErr) {
+		t.Fatalf("QueryAll() should fail with %q; got %q", mockErr, err)
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	now := time.Now()
+	events := make([]event.Event, 10)
+	for i := range events {
+		events[i] = event.New("foo", test.FooEventData{}, event.Time(now.Add(time.Duration(i)*time.Millisecond))).Any()
+	}
+
+	a := streams.New([]event.Event{events[0], events[3], events[4], events[9]})
+	b := streams.New([]event.Event{events[1], events[2], events[8]})
+	c := streams.New([]event.Event{events[5], events[6], events[7]})
+
+	merged := eventstore.MergeSorted(context.Background(), []event.SortOptions{{Sort: event.SortTime, Dir: event.SortAsc}}, a, b, c)
+
+	result, err := streams.All(merged)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	if len(result) != len(events) {
+		t.Fatalf("MergeSorted() should return %d events; got %d", len(events), len(result))
+	}
+
+	for i, evt := range result {
+		if evt.ID() != events[i].ID() {
+			t.Fatalf("event #%d should be %s; got %s", i, events[i].ID(), evt.ID())
+		}
+	}
+}
+
+type failingStore struct {
+	event.Store
+	err error
+}
+
+func (s failingStore) Query(context.Context, event.Query) (<-chan event.Event, <-chan error, error) {
+	return nil, nil, s.err
+}
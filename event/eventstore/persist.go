@@ -0,0 +1,173 @@
+package eventstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// restoreBatchSize is the number of events that Restore inserts at once.
+const restoreBatchSize = 1000
+
+// Save writes all events of the store to the file at path, so that they can be
+// restored using Load. Together with Load, Save makes the in-memory store
+// usable for demos and prototypes that need to survive restarts without a
+// database:
+//
+//	store := eventstore.New()
+//	if err := eventstore.Load(ctx, store, reg, "events.ndjson"); err != nil {
+//		log.Fatal(err)
+//	}
+//	defer eventstore.Save(context.Background(), store, reg, "events.ndjson")
+//
+// The file is replaced atomically, so that a failed Save does not corrupt a
+// previously saved file. See Dump for the format of the file.
+func Save(ctx context.Context, store event.Store, enc codec.Encoding, path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := Dump(ctx, store, enc, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Load inserts the events of a file that was written by Save into the store.
+// If the file does not exist, Load does nothing.
+func Load(ctx context.Context, store event.Store, enc codec.Encoding, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := Restore(ctx, store, enc, f); err != nil {
+		return fmt.Errorf("restore %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Dump writes all events of the store to w as newline-delimited JSON, sorted
+// by time. Each line is a JSON-encoded event.RawEvent whose data is encoded
+// using enc. If the store implements event.RawQuerier, the events are written
+// without decoding their data.
+func Dump(ctx context.Context, store event.Store, enc codec.Encoding, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bw := bufio.NewWriter(w)
+	jenc := json.NewEncoder(bw)
+
+	q := query.New(query.SortBy(event.SortTime, event.SortAsc))
+
+	write := func(raw event.RawEvent) error {
+		if err := jenc.Encode(raw); err != nil {
+			return fmt.Errorf("write %q event: %w", raw.Name, err)
+		}
+		return nil
+	}
+
+	var err error
+	if rq, ok := store.(event.RawQuerier); ok {
+		err = dumpRaw(ctx, rq, q, write)
+	} else {
+		err = dumpEvents(ctx, store, enc, q, write)
+	}
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func dumpRaw(ctx context.Context, store event.RawQuerier, q event.Query, write func(event.RawEvent) error) error {
+	str, errs, err := store.QueryRaw(ctx, q)
+	if err != nil {
+		return fmt.Errorf("query events: %w", err)
+	}
+	return streams.Walk(ctx, write, str, errs)
+}
+
+func dumpEvents(ctx context.Context, store event.Store, enc codec.Encoding, q event.Query, write func(event.RawEvent) error) error {
+	str, errs, err := store.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("query events: %w", err)
+	}
+
+	return streams.Walk(ctx, func(evt event.Event) error {
+		raw, err := event.Raw(enc, evt)
+		if err != nil {
+			return err
+		}
+		return write(raw)
+	}, str, errs)
+}
+
+// Restore inserts the events that were written by Dump into the store.
+func Restore(ctx context.Context, store event.Store, enc codec.Encoding, r io.Reader) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	batch := make([]event.Event, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := store.Insert(ctx, batch...); err != nil {
+			return fmt.Errorf("insert events: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var raw event.RawEvent
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("read event: %w", err)
+		}
+
+		evt, err := raw.DecodeContext(ctx, enc)
+		if err != nil {
+			return err
+		}
+
+		if batch = append(batch, evt); len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
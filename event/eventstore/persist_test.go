@@ -0,0 +1,48 @@
+package eventstore_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestSave(t *testing.T) {
+	ctx := context.Background()
+	enc := test.NewEncoder()
+	path := filepath.Join(t.TempDir(), "events", "events.ndjson")
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Aggregate(id, "foo", 2)).Any(),
+		event.New("baz", test.BazEventData{A: "baz"}).Any(),
+	}
+
+	if err := eventstore.Save(ctx, eventstore.New(events...), enc, path); err != nil {
+		t.Fatalf("Save() failed with %q", err)
+	}
+
+	store := eventstore.New()
+	if err := eventstore.Load(ctx, store, enc, path); err != nil {
+		t.Fatalf("Load() failed with %q", err)
+	}
+
+	test.AssertEqualEvents(t, events, drain(t, store, query.New(query.SortBy(event.SortTime, event.SortAsc))))
+}
+
+func TestLoad_notExist(t *testing.T) {
+	store := eventstore.New()
+	if err := eventstore.Load(context.Background(), store, test.NewEncoder(), filepath.Join(t.TempDir(), "events.ndjson")); err != nil {
+		t.Fatalf("Load() should not fail if the file does not exist; failed with %q", err)
+	}
+
+	if events := drain(t, store, query.New()); len(events) != 0 {
+		t.Fatalf("store should be empty; got %d events", len(events))
+	}
+}
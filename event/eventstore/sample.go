@@ -0,0 +1,187 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+)
+
+// DefaultSampleSize is the default number of events that Sample returns per
+// event name.
+const DefaultSampleSize = 3
+
+// SampleOption is an option for Sample.
+type SampleOption func(*sampleConfig)
+
+type sampleConfig struct {
+	size   int
+	names  []string
+	random bool
+}
+
+// SampleSize returns a SampleOption that sets the maximum number of events per
+// event name. Defaults to DefaultSampleSize.
+func SampleSize(n int) SampleOption {
+	return func(cfg *sampleConfig) {
+		cfg.size = n
+	}
+}
+
+// SampleNames returns a SampleOption that restricts the sample to the given
+// event names. By default, every event name of the store is sampled.
+func SampleNames(names ...string) SampleOption {
+	return func(cfg *sampleConfig) {
+		cfg.names = append(cfg.names, names...)
+	}
+}
+
+// SampleRandom returns a SampleOption that picks random events instead of the
+// most recent ones. Random sampling has to read all events of a name, whereas
+// the most recent events can be read without a full scan.
+func SampleRandom() SampleOption {
+	return func(cfg *sampleConfig) {
+		cfg.random = true
+	}
+}
+
+// Sample returns a sample of the events in the store, grouped by event name.
+// By default, the most recent DefaultSampleSize events of every event name are
+// returned, with the newest event first. Sample is intended for debugging, to
+// quickly inspect what real payloads look like in an environment; use
+// WriteSample to pretty-print the result.
+//
+// Events that cannot be decoded are skipped.
+func Sample(ctx context.Context, store event.Store, opts ...SampleOption) (map[string][]event.Event, error) {
+	cfg := sampleConfig{size: DefaultSampleSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	names := cfg.names
+	if len(names) == 0 {
+		stats, err := Stats(ctx, store)
+		if err != nil {
+			return nil, fmt.Errorf("get event names: %w", err)
+		}
+		for name := range stats.EventsPerName {
+			names = append(names, name)
+		}
+	}
+
+	out := make(map[string][]event.Event, len(names))
+	for _, name := range names {
+		events, err := sampleName(ctx, store, name, cfg)
+		if err != nil {
+			return out, fmt.Errorf("sample %q events: %w", name, err)
+		}
+		if len(events) > 0 {
+			out[name] = events
+		}
+	}
+
+	return out, nil
+}
+
+func sampleName(ctx context.Context, store event.Store, name string, cfg sampleConfig) ([]event.Event, error) {
+	if cfg.size <= 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := store.Query(ctx, query.New(
+		query.Name(name),
+		query.SortBy(event.SortTime, event.SortDesc),
+		query.SkipUndecodable(func(*event.DecodeError) {}),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+
+	out := make([]event.Event, 0, cfg.size)
+	var seen int
+	for {
+		select {
+		case <-ctx.Done():
+			return out, ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			return out, err
+		case evt, ok := <-str:
+			if !ok {
+				if cfg.random {
+					sortByTimeDesc(out)
+				}
+				return out, nil
+			}
+
+			seen++
+			if len(out) < cfg.size {
+				out = append(out, evt)
+				continue
+			}
+
+			if !cfg.random {
+				return out, nil
+			}
+
+			// reservoir sampling
+			if i := rand.Intn(seen); i < cfg.size {
+				out[i] = evt
+			}
+		}
+	}
+}
+
+func sortByTimeDesc(events []event.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time().After(events[j].Time())
+	})
+}
+
+// WriteSample pretty-prints a sample that was returned by Sample to w. Event
+// names are written in alphabetical order, and the data of each event is
+// written as indented JSON.
+func WriteSample(w io.Writer, sample map[string][]event.Event) error {
+	names := make([]string, 0, len(sample))
+	for name := range sample {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s (%d)\n", name, len(sample[name])); err != nil {
+			return err
+		}
+
+		for _, evt := range sample[name] {
+			id, aggregateName, version := evt.Aggregate()
+
+			header := fmt.Sprintf("  %s  %s", evt.ID(), evt.Time().Format("2006-01-02T15:04:05.000Z07:00"))
+			if aggregateName != "" {
+				header += fmt.Sprintf("  %s(%s)@%d", aggregateName, id, version)
+			}
+
+			data, err := json.MarshalIndent(evt.Data(), "    ", "  ")
+			if err != nil {
+				data = []byte(fmt.Sprintf("%#v", evt.Data()))
+			}
+
+			if _, err := fmt.Fprintf(w, "%s\n    %s\n", header, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package eventstore_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/pick"
+)
+
+func TestSample(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	var foos []event.Event
+	store := eventstore.New()
+	for i := 0; i < 10; i++ {
+		evt := event.New("foo", test.FooEventData{A: "foo"}, event.Time(now.Add(time.Duration(i)*time.Second))).Any()
+		foos = append(foos, evt)
+		if err := store.Insert(ctx, evt); err != nil {
+			t.Fatalf("Insert() failed with %q", err)
+		}
+	}
+	bar := event.New("bar", test.BarEventData{A: "bar"}, event.Aggregate(uuid.New(), "bar", 1)).Any()
+	if err := store.Insert(ctx, bar); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	sample, err := eventstore.Sample(ctx, store)
+	if err != nil {
+		t.Fatalf("Sample() failed with %q", err)
+	}
+
+	if len(sample) != 2 {
+		t.Fatalf("sample should contain %d event names; got %d", 2, len(sample))
+	}
+
+	test.AssertEqualEvents(t, []event.Event{foos[9], foos[8], foos[7]}, sample["foo"])
+	test.AssertEqualEvents(t, []event.Event{bar}, sample["bar"])
+
+	var buf bytes.Buffer
+	if err := eventstore.WriteSample(&buf, sample); err != nil {
+		t.Fatalf("WriteSample() failed with %q", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "bar (1)\n") {
+		t.Errorf("output should start with the alphabetically first event name; got\n%s", out)
+	}
+	if !strings.Contains(out, `"A": "foo"`) {
+		t.Errorf("output should contain the indented event data; got\n%s", out)
+	}
+	if !strings.Contains(out, "bar("+pick.AggregateID(bar).String()+")@1") {
+		t.Errorf("output should contain the aggregate of the event; got\n%s", out)
+	}
+}
+
+func TestSample_random(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store := eventstore.New()
+	for i := 0; i < 20; i++ {
+		evt := event.New("foo", test.FooEventData{}, event.Time(now.Add(time.Duration(i)*time.Second))).Any()
+		if err := store.Insert(ctx, evt); err != nil {
+			t.Fatalf("Insert() failed with %q", err)
+		}
+	}
+
+	sample, err := eventstore.Sample(ctx, store, eventstore.SampleRandom(), eventstore.SampleSize(5), eventstore.SampleNames("foo", "bar"))
+	if err != nil {
+		t.Fatalf("Sample() failed with %q", err)
+	}
+
+	if _, ok := sample["bar"]; ok {
+		t.Errorf("sample should not contain event names without events")
+	}
+
+	events := sample["foo"]
+	if len(events) != 5 {
+		t.Fatalf("sample should contain %d %q events; got %d", 5, "foo", len(events))
+	}
+
+	for i := 1; i < len(events); i++ {
+		if events[i].Time().After(events[i-1].Time()) {
+			t.Fatalf("sampled events should be sorted by time, newest first")
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// DefaultVirtualNodes is the default number of virtual nodes per shard on the
+// consistent hash ring of a sharded event store.
+const DefaultVirtualNodes = 128
+
+// uniqueBatchSize is the maximum number of event ids that are queried at once
+// when a sharded event store checks the uniqueness of inserted events.
+const uniqueBatchSize = 500
+
+// ShardedOption is an option for a sharded event store.
+type ShardedOption func(*sharded)
+
+// VirtualNodes returns a ShardedOption that sets the number of virtual nodes
+// per shard on the consistent hash ring. More virtual nodes distribute the
+// aggregates more evenly across the shards. Defaults to DefaultVirtualNodes.
+func VirtualNodes(n int) ShardedOption {
+	return func(s *sharded) {
+		s.virtualNodes = n
+	}
+}
+
+// Sharded returns an event store that distributes events across the provided
+// stores (shards), which can be used to spread the events of very large
+// installations across multiple database clusters. The returned store is
+// presented as a single event.Store.
+//
+// Events are routed to the shards by consistent hashing of their aggregate
+// ids, so all events of an aggregate are always stored in the same shard.
+// Events that do not belong to an aggregate are routed by their event id. The
+// shards are identified by the keys of the provided map, which must be stable:
+// the hash ring is built from the shard names, so adding a shard only moves
+// about 1/N of the aggregates to the new shard, but renaming a shard moves all
+// of its aggregates.
+//
+// Queries that are restricted to specific aggregate ids are only sent to the
+// shards of these aggregates. Other queries are fanned out to all shards and
+// the results are merged into a single stream that respects the sortings of
+// the query.
+//
+//	store := eventstore.Sharded(map[string]event.Store{
+//		"cluster-a": mongoStoreA,
+//		"cluster-b": mongoStoreB,
+//	})
+//
+// Inserts that contain the events of aggregates in multiple shards are not
+// atomic across shards.
+func Sharded(shards map[string]event.Store, opts ...ShardedOption) event.Store {
+	if len(shards) == 0 {
+		panic("[goes/event/eventstore.Sharded] no shards provided")
+	}
+
+	s := &sharded{
+		shards:       shards,
+		virtualNodes: DefaultVirtualNodes,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.virtualNodes < 1 {
+		s.virtualNodes = 1
+	}
+
+	for name := range shards {
+		for i := 0; i < s.virtualNodes; i++ {
+			s.ring = append(s.ring, ringNode{
+				hash:  hashKey([]byte(name + "#" + strconv.Itoa(i))),
+				shard: name,
+			})
+		}
+	}
+
+	sort.Slice(s.ring, func(i, j int) bool {
+		if s.ring[i].hash == s.ring[j].hash {
+			return s.ring[i].shard < s.ring[j].shard
+		}
+		return s.ring[i].hash < s.ring[j].hash
+	})
+
+	return s
+}
+
+type sharded struct {
+	shards       map[string]event.Store
+	virtualNodes int
+	ring         []ringNode
+}
+
+type ringNode struct {
+	hash  uint64
+	shard string
+}
+
+func hashKey(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
+
+// shardOf returns the name of the shard for the given routing key.
+func (s *sharded) shardOf(key uuid.UUID) string {
+	h := hashKey(key[:])
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].shard
+}
+
+func routingKey(evt event.Event) uuid.UUID {
+	if id, _, _ := evt.Aggregate(); id != uuid.Nil {
+		return id
+	}
+	return evt.ID()
+}
+
+func (s *sharded) group(events []event.Event) map[string][]event.Event {
+	out := make(map[string][]event.Event)
+	for _, evt := range events {
+		shard := s.shardOf(routingKey(evt))
+		out[shard] = append(out[shard], evt)
+	}
+	return out
+}
+
+// Insert inserts the events into the shards of their aggregates. Each shard
+// only enforces the uniqueness of event ids within itself, so the other shards
+// are queried for existing events with the same ids before inserting. Insert
+// fails if any of these queries fails.
+func (s *sharded) Insert(ctx context.Context, events ...event.Event) error {
+	groups := s.group(events)
+
+	if len(s.shards) > 1 {
+		if err := s.checkUnique(ctx, groups); err != nil {
+			return err
+		}
+	}
+
+	for shard, events := range groups {
+		if err := s.shards[shard].Insert(ctx, events...); err != nil {
+			return fmt.Errorf("insert into %q shard: %w", shard, err)
+		}
+	}
+	return nil
+}
+
+// checkUnique concurrently queries each shard for the events that are inserted
+// into the other shards.
+func (s *sharded) checkUnique(ctx context.Context, groups map[string][]event.Event) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan error, len(s.shards))
+	for name, store := range s.shards {
+		var ids []uuid.UUID
+		for shard, events := range groups {
+			if shard == name {
+				continue
+			}
+			for _, evt := range events {
+				ids = append(ids, evt.ID())
+			}
+		}
+
+		name, store := name, store
+		go func() { results <- findExisting(ctx, name, store, ids) }()
+	}
+
+	var errs []error
+	for range s.shards {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findExisting queries the given shard for events with the given ids in
+// batches, and returns an error if any of the events exists.
+func findExisting(ctx context.Context, name string, store event.Store, ids []uuid.UUID) error {
+	for len(ids) > 0 {
+		n := min(len(ids), uniqueBatchSize)
+
+		str, errs, err := store.Query(ctx, query.New(query.ID(ids[:n]...)))
+		if err != nil {
+			return fmt.Errorf("query %q shard: %w", name, err)
+		}
+
+		existing, err := streams.Drain(ctx, str, errs)
+		if err != nil {
+			return fmt.Errorf("query %q shard: %w", name, err)
+		}
+
+		if len(existing) > 0 {
+			evt := existing[0]
+			return fmt.Errorf("%s:%s %w in %q shard", evt.Name(), evt.ID(), errDuplicateEvent, name)
+		}
+
+		ids = ids[n:]
+	}
+	return nil
+}
+
+// Delete deletes the events from the shards of their aggregates.
+func (s *sharded) Delete(ctx context.Context, events ...event.Event) error {
+	for shard, events := range s.group(events) {
+		if err := s.shards[shard].Delete(ctx, events...); err != nil {
+			return fmt.Errorf("delete from %q shard: %w", shard, err)
+		}
+	}
+	return nil
+}
+
+// Find finds the event with the given id in any of the shards. The shard of an
+// event cannot be derived from its event id, so all shards are queried.
+func (s *sharded) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		evt event.Event
+		err error
+	}
+
+	results := make(chan result, len(s.shards))
+	for _, store := range s.shards {
+		store := store
+		go func() {
+			evt, err := store.Find(ctx, id)
+			results <- result{evt, err}
+		}()
+	}
+
+	var errs []error
+	for range s.shards {
+		res := <-results
+		if res.err == nil {
+			return res.evt, nil
+		}
+		errs = append(errs, res.err)
+	}
+
+	return nil, fmt.Errorf("find event %s: %w", id, errors.Join(errs...))
+}
+
+// Query queries the shards concurrently and merges the results into a single
+// stream. If the query has sortings, the merged stream is sorted accordingly.
+func (s *sharded) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	names := s.queryShards(q)
+	stores := make([]event.Store, len(names))
+	for i, name := range names {
+		stores[i] = s.shards[name]
+	}
+	return QueryAll(ctx, q, stores...)
+}
+
+// queryShards returns the names of the shards that may contain events that
+// match the given query.
+func (s *sharded) queryShards(q event.Query) []string {
+	ids := q.AggregateIDs()
+	if len(ids) == 0 {
+		for _, ref := range q.Aggregates() {
+			if ref.ID == uuid.Nil {
+				ids = nil
+				break
+			}
+			ids = append(ids, ref.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		out := make([]string, 0, len(s.shards))
+		for name := range s.shards {
+			out = append(out, name)
+		}
+		return out
+	}
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, id := range ids {
+		if shard := s.shardOf(id); !seen[shard] {
+			seen[shard] = true
+			out = append(out, shard)
+		}
+	}
+
+	return out
+}
+
+// Stats returns the combined statistics of all shards. The shards are queried
+// concurrently. Because events of the same aggregate always live in the same
+// shard, the aggregate counts of the shards can be summed up.
+func (s *sharded) Stats(ctx context.Context) (event.StoreStats, error) {
+	type result struct {
+		name  string
+		stats event.StoreStats
+		err   error
+	}
+
+	results := make(chan result, len(s.shards))
+	for name, store := range s.shards {
+		name, store := name, store
+		go func() {
+			stats, err := Stats(ctx, store)
+			results <- result{name, stats, err}
+		}()
+	}
+
+	out := event.StoreStats{EventsPerName: make(map[string]int)}
+	var errs []error
+	for range s.shards {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("shard %q: %w", res.name, res.err))
+			continue
+		}
+		out = out.Merge(res.stats)
+	}
+
+	if len(errs) > 0 {
+		return event.StoreStats{}, errors.Join(errs...)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestSharded(t *testing.T) {
+	eventstoretest.Run(t, "sharded", func(codec.Encoding) event.Store {
+		return eventstore.Sharded(map[string]event.Store{
+			"a": eventstore.New(),
+			"b": eventstore.New(),
+			"c": eventstore.New(),
+		})
+	})
+}
+
+func TestSharded_routing(t *testing.T) {
+	ctx := context.Background()
+
+	shards := map[string]event.Store{
+		"a": eventstore.New(),
+		"b": eventstore.New(),
+	}
+	store := eventstore.Sharded(shards)
+
+	var events []event.Event
+	for i := 0; i < 50; i++ {
+		id := uuid.New()
+		events = append(events,
+			event.New("foo", test.FooEventData{}, event.Aggregate(id, "foo", 1)).Any(),
+			event.New("foo", test.FooEventData{}, event.Aggregate(id, "foo", 2)).Any(),
+		)
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	shardOf := make(map[uuid.UUID]string)
+	for name, shard := range shards {
+		str, errs, err := shard.Query(ctx, query.New())
+		if err != nil {
+			t.Fatalf("query %q shard: %v", name, err)
+		}
+
+		shardEvents, err := streams.Drain(ctx, str, errs)
+		if err != nil {
+			t.Fatalf("query %q shard: %v", name, err)
+		}
+
+		if len(shardEvents) == 0 {
+			t.Fatalf("%q shard should contain events", name)
+		}
+
+		for _, evt := range shardEvents {
+			id, _, _ := evt.Aggregate()
+			if other, ok := shardOf[id]; ok && other != name {
+				t.Fatalf("events of aggregate %s should be stored in a single shard; found in %q and %q", id, other, name)
+			}
+			shardOf[id] = name
+		}
+	}
+
+	id, _, _ := events[0].Aggregate()
+	str, errs, err := store.Query(ctx, query.New(query.AggregateID(id), query.SortByAggregate()))
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	result, err := streams.Drain(ctx, str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	if len(result) != 2 || result[0].ID() != events[0].ID() || result[1].ID() != events[1].ID() {
+		t.Fatalf("Query() should return the events of the aggregate in order")
+	}
+}
+
+func TestSharded_Insert_duplicate(t *testing.T) {
+	ctx := context.Background()
+
+	shards := map[string]event.Store{
+		"a": eventstore.New(),
+		"b": eventstore.New(),
+	}
+	store := eventstore.Sharded(shards)
+
+	id := uuid.New()
+	first := event.New("foo", test.FooEventData{}, event.Aggregate(id, "foo", 1)).Any()
+	if err := store.Insert(ctx, first); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	other := "a"
+	if _, err := shards["a"].Find(ctx, first.ID()); err == nil {
+		other = "b"
+	}
+
+	// insert the event into the shard that it is not routed to
+	dup := event.New("foo", test.FooEventData{}, event.Aggregate(id, "foo", 2)).Any()
+	if err := shards[other].Insert(ctx, dup); err != nil {
+		t.Fatalf("insert into %q shard: %v", other, err)
+	}
+
+	if err := store.Insert(ctx, dup); err == nil {
+		t.Fatalf("Insert() should fail for an event id that exists in another shard")
+	}
+}
+
+func TestSharded_Insert_queryError(t *testing.T) {
+	mockErr := errors.New("mock error")
+	store := eventstore.Sharded(map[string]event.Store{
+		"a": eventstore.New(),
+		"b": failingQueryStore{Store: eventstore.New(), err: mockErr},
+	})
+
+	var events []event.Event
+	for i := 0; i < 50; i++ {
+		events = append(events, event.New("foo", test.FooEventData{}, event.Aggregate(uuid.New(), "foo", 1)).Any())
+	}
+
+	if err := store.Insert(context.Background(), events...); !errors.Is(err, mockErr) {
+		t.Fatalf("Insert() should fail with %q; got %q", mockErr, err)
+	}
+}
+
+type failingQueryStore struct {
+	event.Store
+
+	err error
+}
+
+func (s failingQueryStore) Query(context.Context, event.Query) (<-chan event.Event, <-chan error, error) {
+	return nil, nil, s.err
+}
@@ -0,0 +1,73 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Stats returns statistics about the events in the given store. If the store
+// implements event.StatsProvider, its Stats method is used. Otherwise, all
+// events of the store are queried to compute the statistics, which may be
+// slow for large stores.
+func Stats(ctx context.Context, store event.Store) (event.StoreStats, error) {
+	if p, ok := store.(event.StatsProvider); ok {
+		return p.Stats(ctx)
+	}
+
+	str, errs, err := store.Query(ctx, query.New())
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("query events: %w", err)
+	}
+
+	var c statsCollector
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		c.add(evt)
+		return nil
+	}, str, errs); err != nil {
+		return event.StoreStats{}, err
+	}
+
+	return c.stats(), nil
+}
+
+// Stats returns statistics about the events in the store.
+func (s *memstore) Stats(ctx context.Context) (event.StoreStats, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	var c statsCollector
+	for _, evt := range s.events {
+		c.add(evt)
+	}
+
+	return c.stats(), nil
+}
+
+type statsCollector struct {
+	out        event.StoreStats
+	aggregates map[event.AggregateRef]struct{}
+}
+
+func (c *statsCollector) add(evt event.Event) {
+	c.out.Add(evt.Name(), evt.Time())
+	if id, name, _ := evt.Aggregate(); name != "" && id != uuid.Nil {
+		if c.aggregates == nil {
+			c.aggregates = make(map[event.AggregateRef]struct{})
+		}
+		c.aggregates[event.AggregateRef{Name: name, ID: id}] = struct{}{}
+	}
+}
+
+func (c *statsCollector) stats() event.StoreStats {
+	out := c.out
+	out.Aggregates = len(c.aggregates)
+	if out.EventsPerName == nil {
+		out.EventsPerName = make(map[string]int)
+	}
+	return out
+}
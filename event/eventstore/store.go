@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/google/uuid"
@@ -14,16 +15,29 @@ import (
 // New returns a thread-safe in-memory event store. The provided events are
 // immediately inserted into the store.
 //
+// The store keeps its events sorted by time and indexes them by aggregate and
+// by event name, so that queries for specific aggregates or events only test
+// the indexed events instead of all events in the store.
+//
+// Use Save and Load to persist the events of the store to a file.
+//
+// The store assigns a global position to every inserted event, starting at 1,
+// and implements event.PositionQuerier.
+//
 // This event store is not production ready. It is intended to be used for
 // testing and prototyping. In production, use the MongoDB event store instead.
 // TODO(bounoable): List other event store implementations when they are ready.
 func New(events ...event.Event) event.Store {
 	store := &memstore{
-		idMap:  make(map[uuid.UUID]event.Event),
-		events: events,
+		idMap:       make(map[uuid.UUID]event.Event),
+		byAggregate: make(map[event.AggregateRef][]event.Event),
+		byName:      make(map[string][]event.Event),
+		positions:   make(map[uuid.UUID]int64),
 	}
 	for _, evt := range events {
-		store.idMap[evt.ID()] = evt
+		if _, ok := store.idMap[evt.ID()]; !ok {
+			store.add(evt)
+		}
 	}
 	return store
 }
@@ -34,33 +48,39 @@ var (
 )
 
 type memstore struct {
-	mux    sync.RWMutex
+	mux sync.RWMutex
+
+	// events are all events, sorted by time.
 	events []event.Event
 	idMap  map[uuid.UUID]event.Event
+
+	// byAggregate are the events of each aggregate, sorted by version.
+	byAggregate map[event.AggregateRef][]event.Event
+
+	// byName are the events of each event name, sorted by time.
+	byName map[string][]event.Event
+
+	// inserted are all events in the order of insertion, together with their
+	// positions.
+	inserted  []event.Positioned
+	positions map[uuid.UUID]int64
+	position  int64
 }
 
 // Insert inserts the provided events into the in-memory event store. If an
 // event with the same ID already exists, an error is returned.
 func (s *memstore) Insert(ctx context.Context, events ...event.Event) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
 	for _, evt := range events {
-		if err := s.insert(ctx, evt); err != nil {
-			return fmt.Errorf("%s:%s %w", evt.Name(), evt.ID(), err)
+		if _, ok := s.idMap[evt.ID()]; ok {
+			return fmt.Errorf("%s:%s %w", evt.Name(), evt.ID(), errDuplicateEvent)
 		}
+		s.add(evt)
 	}
 	return nil
 }
 
-func (s *memstore) insert(ctx context.Context, evt event.Event) error {
-	if _, err := s.Find(ctx, evt.ID()); err == nil {
-		return errDuplicateEvent
-	}
-	defer s.reslice()
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	s.idMap[evt.ID()] = evt
-	return nil
-}
-
 // Find returns the event with the given UUID or errEventNotFound if no such
 // event exists in the store.
 func (s *memstore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
@@ -77,15 +97,7 @@ func (s *memstore) Find(ctx context.Context, id uuid.UUID) (event.Event, error)
 // the emitted events is determined by the query's sorting options. If the
 // context is cancelled, the function will return immediately.
 func (s *memstore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-	var events []event.Event
-	for _, evt := range s.events {
-		if query.Test(q, evt) {
-			events = append(events, evt)
-		}
-	}
-	events = event.SortMulti(events, q.Sortings()...)
+	events := s.query(q)
 
 	out := make(chan event.Event)
 	errs := make(chan error)
@@ -105,23 +117,299 @@ func (s *memstore) Query(ctx context.Context, q event.Query) (<-chan event.Event
 	return out, errs, nil
 }
 
+// QueryAfter returns the events that have a position greater than the given
+// position and are matched by the query, in the order of insertion. The
+// sortings of the query are ignored.
+func (s *memstore) QueryAfter(ctx context.Context, position int64, q event.Query) (<-chan event.Positioned, <-chan error, error) {
+	events := s.queryAfter(position, q)
+
+	out := make(chan event.Positioned)
+	errs := make(chan error)
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+		for _, evt := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
 // Delete removes the specified events from the store. Events are provided as a
 // slice of event.Event.
 func (s *memstore) Delete(ctx context.Context, events ...event.Event) error {
-	defer s.reslice()
 	s.mux.Lock()
 	defer s.mux.Unlock()
 	for _, evt := range events {
-		delete(s.idMap, evt.ID())
+		if stored, ok := s.idMap[evt.ID()]; ok {
+			s.remove(stored)
+		}
 	}
 	return nil
 }
 
-func (s *memstore) reslice() {
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	s.events = s.events[:0]
-	for _, evt := range s.idMap {
-		s.events = append(s.events, evt)
+// query returns the sorted events that match the query.
+func (s *memstore) query(q event.Query) []event.Event {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	var events []event.Event
+	for _, candidates := range s.candidates(q) {
+		for _, evt := range candidates {
+			if query.Test(q, evt) {
+				events = append(events, evt)
+			}
+		}
+	}
+
+	events = event.SortMulti(events, q.Sortings()...)
+
+	if limit, offset := event.Paging(q); offset > 0 || limit > 0 {
+		events = events[min(offset, len(events)):]
+		if limit > 0 && limit < len(events) {
+			events = events[:limit]
+		}
+	}
+
+	return events
+}
+
+// queryAfter returns the events that have a position greater than the given
+// position and match the query.
+func (s *memstore) queryAfter(position int64, q event.Query) []event.Positioned {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	i := sort.Search(len(s.inserted), func(i int) bool {
+		return s.inserted[i].Position > position
+	})
+
+	var events []event.Positioned
+	for _, evt := range s.inserted[i:] {
+		if query.Test(q, evt.Event) {
+			events = append(events, evt)
+		}
+	}
+
+	if limit, offset := event.Paging(q); offset > 0 || limit > 0 {
+		events = events[min(offset, len(events)):]
+		if limit > 0 && limit < len(events) {
+			events = events[:limit]
+		}
+	}
+
+	return events
+}
+
+// candidates returns the smallest set of events that contains all events that
+// match the query, using the id map and the aggregate and name indexes. The
+// returned slices are disjoint.
+func (s *memstore) candidates(q event.Query) [][]event.Event {
+	best := [][]event.Event{s.events}
+	bestLen := len(s.events)
+
+	consider := func(sets [][]event.Event) {
+		var n int
+		for _, set := range sets {
+			n += len(set)
+		}
+		if n < bestLen {
+			best, bestLen = sets, n
+		}
+	}
+
+	if ids := q.IDs(); len(ids) > 0 {
+		found := make([]event.Event, 0, len(ids))
+		for _, id := range uniqueIDs(ids) {
+			if evt, ok := s.idMap[id]; ok {
+				found = append(found, evt)
+			}
+		}
+		consider([][]event.Event{found})
+	}
+
+	if refs, ok := indexedAggregates(q); ok {
+		sets := make([][]event.Event, 0, len(refs))
+		for _, ref := range refs {
+			sets = append(sets, s.byAggregate[ref])
+		}
+		consider(sets)
+	}
+
+	if names := q.Names(); len(names) > 0 {
+		sets := make([][]event.Event, 0, len(names))
+		for _, name := range uniqueStrings(names) {
+			sets = append(sets, s.byName[name])
+		}
+		consider(sets)
+	}
+
+	return best
+}
+
+// indexedAggregates returns the aggregates that the query is restricted to, if
+// the aggregates can be looked up in the aggregate index.
+func indexedAggregates(q event.Query) ([]event.AggregateRef, bool) {
+	if refs := q.Aggregates(); len(refs) > 0 {
+		for _, ref := range refs {
+			if ref.Name == "" || ref.ID == uuid.Nil {
+				return nil, false
+			}
+		}
+		return uniqueRefs(refs), true
+	}
+
+	names, ids := q.AggregateNames(), q.AggregateIDs()
+	if len(names) == 0 || len(ids) == 0 {
+		return nil, false
+	}
+
+	refs := make([]event.AggregateRef, 0, len(names)*len(ids))
+	for _, name := range uniqueStrings(names) {
+		for _, id := range uniqueIDs(ids) {
+			refs = append(refs, event.AggregateRef{Name: name, ID: id})
+		}
+	}
+
+	return refs, true
+}
+
+// add adds an event to the store and its indexes. The caller must hold the
+// write lock.
+func (s *memstore) add(evt event.Event) {
+	s.position++
+	s.inserted = append(s.inserted, event.Positioned{Event: evt, Position: s.position})
+	s.positions[evt.ID()] = s.position
+	s.idMap[evt.ID()] = evt
+	s.events = insertSorted(s.events, evt, byTime)
+	s.byName[evt.Name()] = insertSorted(s.byName[evt.Name()], evt, byTime)
+	if ref, ok := indexedAggregate(evt); ok {
+		s.byAggregate[ref] = insertSorted(s.byAggregate[ref], evt, byVersion)
+	}
+}
+
+// remove removes an event from the store and its indexes. The caller must hold
+// the write lock.
+func (s *memstore) remove(evt event.Event) {
+	delete(s.idMap, evt.ID())
+
+	pos := s.positions[evt.ID()]
+	delete(s.positions, evt.ID())
+	if i := sort.Search(len(s.inserted), func(i int) bool {
+		return s.inserted[i].Position >= pos
+	}); i < len(s.inserted) && s.inserted[i].Position == pos {
+		s.inserted = append(s.inserted[:i], s.inserted[i+1:]...)
+	}
+
+	s.events = removeSorted(s.events, evt, byTime)
+
+	if events := removeSorted(s.byName[evt.Name()], evt, byTime); len(events) > 0 {
+		s.byName[evt.Name()] = events
+	} else {
+		delete(s.byName, evt.Name())
+	}
+
+	if ref, ok := indexedAggregate(evt); ok {
+		if events := removeSorted(s.byAggregate[ref], evt, byVersion); len(events) > 0 {
+			s.byAggregate[ref] = events
+		} else {
+			delete(s.byAggregate, ref)
+		}
+	}
+}
+
+func indexedAggregate(evt event.Event) (event.AggregateRef, bool) {
+	id, name, _ := evt.Aggregate()
+	if name == "" || id == uuid.Nil {
+		return event.AggregateRef{}, false
+	}
+	return event.AggregateRef{Name: name, ID: id}, true
+}
+
+// byTime compares events by time.
+func byTime(a, b event.Event) int {
+	return a.Time().Compare(b.Time())
+}
+
+// byVersion compares events by aggregate version.
+func byVersion(a, b event.Event) int {
+	_, _, av := a.Aggregate()
+	_, _, bv := b.Aggregate()
+	switch {
+	case av < bv:
+		return -1
+	case av > bv:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// insertSorted inserts evt into the sorted events after all events that
+// compare equal to it.
+func insertSorted(events []event.Event, evt event.Event, compare func(a, b event.Event) int) []event.Event {
+	i := sort.Search(len(events), func(i int) bool {
+		return compare(events[i], evt) > 0
+	})
+	events = append(events, nil)
+	copy(events[i+1:], events[i:])
+	events[i] = evt
+	return events
+}
+
+// removeSorted removes the event with the id of evt from the sorted events.
+func removeSorted(events []event.Event, evt event.Event, compare func(a, b event.Event) int) []event.Event {
+	i := sort.Search(len(events), func(i int) bool {
+		return compare(events[i], evt) >= 0
+	})
+	for ; i < len(events) && compare(events[i], evt) == 0; i++ {
+		if events[i].ID() == evt.ID() {
+			copy(events[i:], events[i+1:])
+			events[len(events)-1] = nil
+			return events[:len(events)-1]
+		}
+	}
+	return events
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func uniqueStrings(vals []string) []string {
+	seen := make(map[string]bool, len(vals))
+	out := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func uniqueRefs(refs []event.AggregateRef) []event.AggregateRef {
+	seen := make(map[event.AggregateRef]bool, len(refs))
+	out := make([]event.AggregateRef, 0, len(refs))
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			out = append(out, ref)
+		}
 	}
+	return out
 }
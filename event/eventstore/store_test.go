@@ -1,12 +1,22 @@
 package eventstore_test
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/modernice/goes/backend/testing/eventstoretest"
 	"github.com/modernice/goes/codec"
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/version"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
 )
 
 var _ event.Store = eventstore.New()
@@ -16,3 +26,177 @@ func TestMemstore(t *testing.T) {
 		return eventstore.New()
 	})
 }
+
+func TestMemstore_indexes(t *testing.T) {
+	ctx := context.Background()
+
+	events := makeEvents(10, 10)
+	store := eventstore.New(events...)
+
+	// delete every third event to verify that the indexes are updated
+	var deleted []event.Event
+	for i := 0; i < len(events); i += 3 {
+		deleted = append(deleted, events[i])
+	}
+	if err := store.Delete(ctx, deleted...); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+	var remaining []event.Event
+	for i, evt := range events {
+		if i%3 != 0 {
+			remaining = append(remaining, evt)
+		}
+	}
+
+	id, name, _ := events[1].Aggregate()
+	otherID, otherName, _ := events[len(events)-1].Aggregate()
+
+	tests := map[string]event.Query{
+		"all":            query.New(),
+		"ids":            query.New(query.ID(events[0].ID(), events[1].ID(), events[2].ID())),
+		"names":          query.New(query.Name("foo", "bar")),
+		"aggregate":      query.New(query.Aggregate(name, id)),
+		"aggregates":     query.New(query.Aggregate(name, id), query.Aggregate(otherName, otherID)),
+		"aggregate name": query.New(query.Aggregate(name, uuid.Nil)),
+		"aggregate ids":  query.New(query.AggregateName(name, otherName), query.AggregateID(id, otherID)),
+		"combined": query.New(
+			query.Name("foo"),
+			query.Aggregate(name, id),
+			query.AggregateVersion(version.Min(3)),
+		),
+	}
+
+	for name, q := range tests {
+		t.Run(name, func(t *testing.T) {
+			want := query.Apply(q, remaining...)
+			got := drain(t, store, q)
+			test.AssertEqualEventsUnsorted(t, want, got)
+		})
+	}
+}
+
+func BenchmarkMemstore_Query_aggregate(b *testing.B) {
+	ctx := context.Background()
+
+	events := makeEvents(1000, 100)
+	store := eventstore.New(events...)
+
+	id, name, _ := events[len(events)/2].Aggregate()
+	q := query.New(query.Aggregate(name, id), query.SortByAggregate())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		str, errs, err := store.Query(ctx, q)
+		if err != nil {
+			b.Fatalf("Query() failed with %q", err)
+		}
+		if _, err := streams.Drain(ctx, str, errs); err != nil {
+			b.Fatalf("Drain() failed with %q", err)
+		}
+	}
+}
+
+// makeEvents returns events of the given number of aggregates with the given
+// number of events per aggregate. The events are raised in random order.
+func makeEvents(aggregates, perAggregate int) []event.Event {
+	names := []string{"foo", "bar", "baz"}
+
+	events := make([]event.Event, 0, aggregates*perAggregate)
+	for a := 0; a < aggregates; a++ {
+		id := uuid.New()
+		for v := 1; v <= perAggregate; v++ {
+			events = append(events, event.New(
+				names[(a+v)%len(names)],
+				test.FooEventData{},
+				event.Time(time.Now().Add(time.Duration(rand.Intn(1000))*time.Millisecond)),
+				event.Aggregate(id, fmt.Sprintf("aggregate-%d", a%3), v),
+			).Any())
+		}
+	}
+
+	rand.Shuffle(len(events), func(i, j int) {
+		events[i], events[j] = events[j], events[i]
+	})
+
+	return events
+}
+
+func TestMemstore_Query_paging(t *testing.T) {
+	events := makeEvents(1, 10)
+	store := eventstore.New(events...)
+
+	sorted := event.SortMulti(events, event.SortOptions{Sort: event.SortAggregateVersion, Dir: event.SortAsc})
+
+	tests := map[string]struct {
+		opts []query.Option
+		want []event.Event
+	}{
+		"limit":           {opts: []query.Option{query.Limit(3)}, want: sorted[:3]},
+		"offset":          {opts: []query.Option{query.Offset(7)}, want: sorted[7:]},
+		"limit & offset":  {opts: []query.Option{query.Limit(3), query.Offset(2)}, want: sorted[2:5]},
+		"offset overflow": {opts: []query.Option{query.Offset(20)}, want: nil},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := query.New(append(tt.opts, query.SortByAggregate())...)
+			test.AssertEqualEvents(t, tt.want, drain(t, store, q))
+		})
+	}
+}
+
+func TestMemstore_QueryAfter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	late := event.New[any]("foo", test.FooEventData{}, event.Time(now.Add(-time.Hour)))
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Time(now)),
+		event.New[any]("bar", test.BarEventData{}, event.Time(now.Add(time.Second))),
+	}
+
+	store := eventstore.New(events...)
+	if err := store.Insert(ctx, late); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	querier, ok := store.(event.PositionQuerier)
+	if !ok {
+		t.Fatalf("store should implement %T", querier)
+	}
+
+	positioned, errs, err := querier.QueryAfter(ctx, 1, query.New())
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	after, err := streams.Drain(ctx, positioned, errs)
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if len(after) != 2 || after[0].ID() != events[1].ID() || after[1].ID() != late.ID() {
+		t.Fatalf("QueryAfter() should return the events in the order of insertion; got %v", after)
+	}
+
+	if after[0].Position != 2 || after[1].Position != 3 {
+		t.Fatalf("QueryAfter() should return positions %v; got %v", []int64{2, 3}, []int64{after[0].Position, after[1].Position})
+	}
+
+	if err := store.Delete(ctx, events[1]); err != nil {
+		t.Fatalf("Delete() failed with %q", err)
+	}
+
+	positioned, errs, err = querier.QueryAfter(ctx, 0, query.New(query.Name("foo")))
+	if err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if after, err = streams.Drain(ctx, positioned, errs); err != nil {
+		t.Fatalf("QueryAfter() failed with %q", err)
+	}
+
+	if len(after) != 2 || after[0].Position != 1 || after[1].Position != 3 {
+		t.Fatalf("QueryAfter() should return the %q events at positions %v; got %v", "foo", []int64{1, 3}, after)
+	}
+}
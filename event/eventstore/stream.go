@@ -0,0 +1,30 @@
+package eventstore
+
+import (
+	"context"
+
+	"github.com/modernice/goes/event"
+)
+
+// QueryStream queries the events of the given store as an event.Stream, which
+// can be re-read (see Stream.Seek, Stream.SeekVersion, and Stream.Rewind)
+// without querying the store again. If the store implements
+// event.StreamQuerier, its QueryStream method is used. Otherwise, the events
+// of store.Query are buffered while the stream is read.
+func QueryStream(ctx context.Context, store event.Store, q event.Query) (event.Stream, error) {
+	if sq, ok := store.(event.StreamQuerier); ok {
+		return sq.QueryStream(ctx, q)
+	}
+
+	events, errs, err := store.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewStream(events, errs), nil
+}
+
+// QueryStream returns the events that match the query as an event.Stream.
+func (s *memstore) QueryStream(ctx context.Context, q event.Query) (event.Stream, error) {
+	return event.StreamOf(s.query(q)...), nil
+}
@@ -0,0 +1,63 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate/stream"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestQueryStream(t *testing.T) {
+	ctx := context.Background()
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("foo", test.FooEventData{}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("foo", test.FooEventData{}, event.Aggregate(id, "foo", 3)),
+	}
+
+	stores := map[string]event.Store{
+		"memstore": eventstore.New(events...),
+		"fallback": struct{ event.Store }{eventstore.New(events...)},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			str, err := eventstore.QueryStream(ctx, store, query.New(query.SortByAggregate()))
+			if err != nil {
+				t.Fatalf("QueryStream failed with %q", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				evts, errs := str.Events(ctx)
+				histories, historyErrs := stream.New(ctx, evts, stream.Errors(errs), stream.Grouped(true), stream.Sorted(true))
+
+				result, err := streams.Drain(ctx, histories, historyErrs)
+				if err != nil {
+					t.Fatalf("drain histories: %v", err)
+				}
+
+				if len(result) != 1 || result[0].Aggregate().ID != id {
+					t.Fatalf("stream should contain the history of the aggregate; got %v", result)
+				}
+
+				str.Rewind()
+			}
+
+			if err := str.SeekVersion(ctx, 3); err != nil {
+				t.Fatalf("SeekVersion failed with %q", err)
+			}
+
+			if str.Position() != 2 {
+				t.Fatalf("SeekVersion should seek to position %d; got %d", 2, str.Position())
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// DefaultArchiveBatchSize is the default number of events that are moved to
+// the archive of a tiered event store at once.
+const DefaultArchiveBatchSize = 500
+
+// TieredOption is an option for a tiered event store.
+type TieredOption func(*TieredStore)
+
+// ArchiveBatchSize returns a TieredOption that sets the number of events that
+// are moved to the archive at once. Defaults to DefaultArchiveBatchSize.
+func ArchiveBatchSize(n int) TieredOption {
+	return func(s *TieredStore) {
+		s.batchSize = n
+	}
+}
+
+// TieredStore is an event store that keeps recent events in a primary store
+// and moves events that exceed a maximum age to an archive store. Use Tiered
+// to create a TieredStore.
+type TieredStore struct {
+	primary   event.Store
+	archive   event.Store
+	maxAge    stdtime.Duration
+	batchSize int
+}
+
+// Tiered returns an event store that keeps recent events in the primary store
+// (e.g. a MongoDB event store) and moves events that are older than maxAge to
+// the archive store (e.g. an S3 event store). Events are moved by calling
+// ArchiveEvents, or periodically by running Run.
+//
+// Events are always inserted into the primary store. Queries are only sent to
+// the primary store if they are restricted to events that are younger than
+// maxAge. Other queries are sent to both stores and the results are merged
+// into a single stream that respects the sortings of the query. Find reads
+// through to the archive if the event is not found in the primary store.
+//
+//	store := eventstore.Tiered(mongoStore, s3Store, 90*24*time.Hour)
+//	errs := store.Run(ctx, time.Hour)
+//
+// The primary store must not rely on the presence of an aggregate's previous
+// events to validate the versions of new events, because these events may
+// have been moved to the archive.
+func Tiered(primary, archive event.Store, maxAge stdtime.Duration, opts ...TieredOption) *TieredStore {
+	s := &TieredStore{
+		primary:   primary,
+		archive:   archive,
+		maxAge:    maxAge,
+		batchSize: DefaultArchiveBatchSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.batchSize < 1 {
+		s.batchSize = 1
+	}
+	return s
+}
+
+// Primary returns the primary store.
+func (s *TieredStore) Primary() event.Store {
+	return s.primary
+}
+
+// Archive returns the archive store.
+func (s *TieredStore) Archive() event.Store {
+	return s.archive
+}
+
+// Insert inserts events into the primary store.
+func (s *TieredStore) Insert(ctx context.Context, events ...event.Event) error {
+	return s.primary.Insert(ctx, events...)
+}
+
+// Find finds the event with the given id in the primary store, or in the
+// archive if the event is not found in the primary store.
+func (s *TieredStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	evt, err := s.primary.Find(ctx, id)
+	if err == nil {
+		return evt, nil
+	}
+
+	evt, archiveErr := s.archive.Find(ctx, id)
+	if archiveErr == nil {
+		return evt, nil
+	}
+
+	return nil, fmt.Errorf("find event %s: %w", id, errors.Join(err, archiveErr))
+}
+
+// Delete deletes events from both the primary store and the archive.
+func (s *TieredStore) Delete(ctx context.Context, events ...event.Event) error {
+	if err := s.primary.Delete(ctx, events...); err != nil {
+		return fmt.Errorf("delete from primary store: %w", err)
+	}
+
+	if err := s.archive.Delete(ctx, events...); err != nil {
+		return fmt.Errorf("delete from archive: %w", err)
+	}
+
+	return nil
+}
+
+// Query queries the primary store, and reads through to the archive if the
+// query may match events that are older than the maximum age. While events
+// are being moved to the archive, they may exist in both stores, so events
+// that are returned by both stores are only returned once.
+func (s *TieredStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	cutoff := s.cutoff()
+
+	if times := q.Times(); times != nil {
+		if min := times.Min(); !min.IsZero() && !min.Before(cutoff) {
+			return s.primary.Query(ctx, q)
+		}
+	}
+
+	events, errs, err := QueryAll(ctx, q, s.primary, s.archive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan event.Event)
+	go func() {
+		defer close(out)
+
+		// only events that exceed the maximum age can be in both stores
+		seen := make(map[uuid.UUID]bool)
+		for evt := range events {
+			if evt.Time().Before(cutoff) {
+				if seen[evt.ID()] {
+					continue
+				}
+				seen[evt.ID()] = true
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs, nil
+}
+
+// Stats returns the combined statistics of the primary store and the archive.
+// Aggregates whose events are split across both stores are counted twice.
+func (s *TieredStore) Stats(ctx context.Context) (event.StoreStats, error) {
+	primary, err := Stats(ctx, s.primary)
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("primary store: %w", err)
+	}
+
+	archive, err := Stats(ctx, s.archive)
+	if err != nil {
+		return event.StoreStats{}, fmt.Errorf("archive: %w", err)
+	}
+
+	return primary.Merge(archive), nil
+}
+
+// ArchiveEvents moves the events of the primary store that are older than the
+// maximum age to the archive, and returns the number of moved events. Events
+// are first inserted into the archive and then deleted from the primary
+// store, so an interrupted run never loses events; events that were already
+// archived by an interrupted run are skipped.
+func (s *TieredStore) ArchiveEvents(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := s.primary.Query(ctx, query.New(
+		query.Time(time.Before(s.cutoff())),
+		query.SortBy(event.SortTime, event.SortAsc),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("query primary store: %w", err)
+	}
+
+	var (
+		moved int
+		batch = make([]event.Event, 0, s.batchSize)
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.move(ctx, batch); err != nil {
+			return err
+		}
+		moved += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for str != nil || errs != nil {
+		select {
+		case <-ctx.Done():
+			return moved, ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			return moved, fmt.Errorf("query primary store: %w", err)
+		case evt, ok := <-str:
+			if !ok {
+				str = nil
+				break
+			}
+			if batch = append(batch, evt); len(batch) >= s.batchSize {
+				if err := flush(); err != nil {
+					return moved, err
+				}
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return moved, err
+	}
+
+	return moved, nil
+}
+
+func (s *TieredStore) move(ctx context.Context, events []event.Event) error {
+	if err := s.archive.Insert(ctx, events...); err != nil {
+		// The batch may have been partially archived by an interrupted run,
+		// so the events are archived one by one, skipping archived events.
+		for _, evt := range events {
+			if _, err := s.archive.Find(ctx, evt.ID()); err == nil {
+				continue
+			}
+			if err := s.archive.Insert(ctx, evt); err != nil {
+				return fmt.Errorf("archive %q event (%s): %w", evt.Name(), evt.ID(), err)
+			}
+		}
+	}
+
+	if err := s.primary.Delete(ctx, events...); err != nil {
+		return fmt.Errorf("delete archived events from primary store: %w", err)
+	}
+
+	return nil
+}
+
+// Run calls ArchiveEvents in the given interval until ctx is canceled. Errors
+// that occur while archiving are sent over the returned channel, which is
+// closed when ctx is canceled. Callers must receive from the returned channel;
+// otherwise archiving is blocked when an error occurs.
+func (s *TieredStore) Run(ctx context.Context, every stdtime.Duration) <-chan error {
+	out := make(chan error)
+	go func() {
+		defer close(out)
+		ticker := stdtime.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ArchiveEvents(ctx); err != nil && ctx.Err() == nil {
+					select {
+					case <-ctx.Done():
+						return
+					case out <- err:
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (s *TieredStore) cutoff() stdtime.Time {
+	return xtime.Now().Add(-s.maxAge)
+}
@@ -0,0 +1,106 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/backend/testing/eventstoretest"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	querytime "github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestTiered(t *testing.T) {
+	eventstoretest.Run(t, "tiered", func(codec.Encoding) event.Store {
+		return eventstore.Tiered(eventstore.New(), eventstore.New(), time.Hour)
+	})
+}
+
+func TestTieredStore_ArchiveEvents(t *testing.T) {
+	ctx := context.Background()
+
+	primary, archive := eventstore.New(), eventstore.New()
+	store := eventstore.Tiered(primary, archive, time.Hour, eventstore.ArchiveBatchSize(2))
+
+	now := time.Now()
+	aggregateID := uuid.New()
+	events := []event.Event{
+		event.New("foo", test.FooEventData{}, event.Time(now.Add(-3*time.Hour)), event.Aggregate(aggregateID, "foo", 1)).Any(),
+		event.New("foo", test.FooEventData{}, event.Time(now.Add(-2*time.Hour)), event.Aggregate(aggregateID, "foo", 2)).Any(),
+		event.New("foo", test.FooEventData{}, event.Time(now.Add(-90*time.Minute)), event.Aggregate(aggregateID, "foo", 3)).Any(),
+		event.New("foo", test.FooEventData{}, event.Time(now.Add(-time.Minute)), event.Aggregate(aggregateID, "foo", 4)).Any(),
+	}
+
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	// simulate an interrupted run that archived the first event but did not
+	// delete it from the primary store
+	if err := archive.Insert(ctx, events[0]); err != nil {
+		t.Fatalf("insert into archive: %v", err)
+	}
+
+	moved, err := store.ArchiveEvents(ctx)
+	if err != nil {
+		t.Fatalf("ArchiveEvents() failed with %q", err)
+	}
+
+	if moved != 3 {
+		t.Fatalf("ArchiveEvents() should move %d events; moved %d", 3, moved)
+	}
+
+	test.AssertEqualEventsUnsorted(t, events[3:], drain(t, primary, query.New()))
+	test.AssertEqualEventsUnsorted(t, events[:3], drain(t, archive, query.New()))
+
+	result := drain(t, store, query.New(query.AggregateID(aggregateID), query.SortByAggregate()))
+	test.AssertEqualEvents(t, events, result)
+
+	if _, err := store.Find(ctx, events[0].ID()); err != nil {
+		t.Fatalf("Find() should read through to the archive; got %q", err)
+	}
+
+	recent := drain(t, store, query.New(query.Time(querytime.After(now.Add(-30*time.Minute)))))
+	test.AssertEqualEvents(t, events[3:], recent)
+}
+
+func TestTieredStore_Query_deduplicate(t *testing.T) {
+	ctx := context.Background()
+
+	primary, archive := eventstore.New(), eventstore.New()
+	store := eventstore.Tiered(primary, archive, time.Hour)
+
+	evt := event.New("foo", test.FooEventData{}, event.Time(time.Now().Add(-2*time.Hour))).Any()
+	for _, s := range []event.Store{primary, archive} {
+		if err := s.Insert(ctx, evt); err != nil {
+			t.Fatalf("Insert() failed with %q", err)
+		}
+	}
+
+	result := drain(t, store, query.New())
+	if len(result) != 1 {
+		t.Fatalf("events that are in both stores should be returned once; got %d events", len(result))
+	}
+}
+
+func drain(t *testing.T, store event.Store, q event.Query) []event.Event {
+	t.Helper()
+
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("Query() failed with %q", err)
+	}
+
+	return events
+}
@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	stdtime "time"
+
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// Position is the position of a consumer in a feed: the URL of a page and the
+// number of events of the page that have already been consumed. Consumers
+// save the Position that is passed to the callback of Client.Poll to resume
+// polling after a restart.
+type Position struct {
+	Page   string `json:"page"`
+	Offset int    `json:"offset"`
+}
+
+// ClientOption is an option for a Client.
+type ClientOption func(*Client)
+
+// HTTPClient returns a ClientOption that specifies the HTTP client that is used
+// to fetch pages. Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// Client reads feeds that are served by a Handler.
+type Client struct {
+	base   *url.URL
+	enc    codec.Encoding
+	client *http.Client
+}
+
+// NewClient returns a Client for the feeds at the given base URL. The provided
+// encoding is used to decode the event data.
+func NewClient(baseURL string, enc codec.Encoding, opts ...ClientOption) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	c := &Client{
+		base:   base,
+		enc:    enc,
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Page fetches the page at the given reference, which is resolved against the
+// base URL of the client, e.g. "events" or a link of another page.
+func (c *Client) Page(ctx context.Context, ref string) (Page, error) {
+	page, _, _, err := c.fetch(ctx, ref, "")
+	return page, err
+}
+
+// fetch fetches a page. If the page still has the given ETag, fetch returns
+// modified == false.
+func (c *Client) fetch(ctx context.Context, ref, etag string) (page Page, newETag string, modified bool, err error) {
+	target, err := c.resolve(ref)
+	if err != nil {
+		return page, etag, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return page, etag, false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", MediaType)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return page, etag, false, fmt.Errorf("send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return page, etag, false, nil
+	}
+
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return page, etag, false, fmt.Errorf("feed: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return page, etag, false, fmt.Errorf("decode page: %w", err)
+	}
+
+	// Links are relative to the page, so they are resolved to absolute URLs.
+	for _, link := range []*string{&page.Links.Self, &page.Links.First, &page.Links.Prev, &page.Links.Next} {
+		if *link == "" {
+			continue
+		}
+		if *link, err = resolveAgainst(target, *link); err != nil {
+			return page, etag, false, err
+		}
+	}
+
+	return page, res.Header.Get("ETag"), true, nil
+}
+
+func (c *Client) resolve(ref string) (string, error) {
+	return resolveAgainst(c.base.String(), strings.TrimPrefix(ref, "/"))
+}
+
+func resolveAgainst(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse link %q: %w", ref, err)
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+// Poll consumes the feed starting at the given position and calls fn for
+// every event, until ctx is canceled or fn returns an error. Poll follows the
+// "next" links of the pages. When it reaches the last page, it polls the page
+// at the given interval, using conditional requests, until new events or a
+// "next" link appear. fn receives the position after the event, which can be
+// saved to resume polling later:
+//
+//	err := client.Poll(ctx, feed.Position{Page: "events"}, time.Second, func(evt event.Event, pos feed.Position) error {
+//		// handle event and save pos
+//	})
+//
+// Poll returns ctx.Err() when ctx is canceled.
+func (c *Client) Poll(ctx context.Context, start Position, interval stdtime.Duration, fn func(event.Event, Position) error) error {
+	pos := start
+
+	var (
+		etag  string
+		timer *stdtime.Timer
+	)
+
+	for {
+		page, newETag, modified, err := c.fetch(ctx, pos.Page, etag)
+		if err != nil {
+			return err
+		}
+
+		if modified {
+			etag = newETag
+
+			if page.Links.Self != "" {
+				pos.Page = page.Links.Self
+			}
+
+			for i := pos.Offset; i < len(page.Events); i++ {
+				evt, err := page.Events[i].Event(ctx, c.enc)
+				if err != nil {
+					return err
+				}
+
+				pos.Offset = i + 1
+				if err := fn(evt, pos); err != nil {
+					return err
+				}
+			}
+
+			if page.Links.Next != "" && pos.Offset >= len(page.Events) {
+				pos = Position{Page: page.Links.Next}
+				etag = ""
+				continue
+			}
+		}
+
+		if timer == nil {
+			timer = stdtime.NewTimer(interval)
+			defer timer.Stop()
+		} else {
+			timer.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
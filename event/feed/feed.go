@@ -0,0 +1,104 @@
+// Package feed exposes events as paginated, hypermedia HTTP feeds, so that
+// consumers can poll events and mirror them into archives without access to
+// a message broker. Two kinds of feeds are provided by the Handler:
+//
+//	GET /events[?after={cursor}][&name={event}...]  the global feed
+//	GET /aggregates/{name}/{id}?page={n}            the stream of an aggregate
+//
+// Feeds are split into pages of a fixed size, starting at the oldest event.
+// Pages of the global feed are identified by a cursor that points to the last
+// event of the previous page; pages of aggregate streams are numbered. Each
+// page links to the first and next pages (and pages of aggregate streams to
+// the previous page), both in the body and in a "Link" header. A page only
+// links to the next page when it is full, so consumers poll the last page
+// until a "next" link appears (see Client.Poll). Full pages of aggregate
+// streams never change, which makes them cacheable by proxies; every page
+// carries an ETag and supports conditional requests.
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// MediaType is the media type of feed pages.
+const MediaType = "application/json"
+
+// DefaultPageSize is the default number of events per page.
+const DefaultPageSize = 100
+
+// Page is a page of a feed.
+type Page struct {
+	Links  Links   `json:"links"`
+	Events []Entry `json:"events"`
+}
+
+// Links are the hypermedia links of a Page. Links are relative to the URL of
+// the page.
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// Entry is an event of a Page.
+type Entry struct {
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Time             stdtime.Time    `json:"time"`
+	AggregateName    string          `json:"aggregateName,omitempty"`
+	AggregateID      *uuid.UUID      `json:"aggregateId,omitempty"`
+	AggregateVersion int             `json:"aggregateVersion,omitempty"`
+	Data             json.RawMessage `json:"data"`
+}
+
+// NewEntry returns the Entry of the given event. The event data is encoded
+// using the provided encoding, which must produce JSON.
+func NewEntry(ctx context.Context, enc codec.Encoding, evt event.Event) (Entry, error) {
+	data, err := codec.MarshalContext(ctx, enc, evt.Data())
+	if err != nil {
+		return Entry{}, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	if !json.Valid(data) {
+		return Entry{}, fmt.Errorf("encode %q event data: encoded data is not JSON", evt.Name())
+	}
+
+	entry := Entry{
+		ID:   evt.ID(),
+		Name: evt.Name(),
+		Time: evt.Time(),
+		Data: data,
+	}
+
+	if id, name, v := evt.Aggregate(); name != "" {
+		entry.AggregateName = name
+		entry.AggregateID = &id
+		entry.AggregateVersion = v
+	}
+
+	return entry, nil
+}
+
+// Event decodes the entry into an event, using the provided encoding to
+// decode the event data.
+func (e Entry) Event(ctx context.Context, enc codec.Encoding) (event.Event, error) {
+	data, err := codec.UnmarshalContext(ctx, enc, e.Data, e.Name)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q event data: %w", e.Name, err)
+	}
+
+	opts := []event.Option{event.ID(e.ID), event.Time(e.Time)}
+	if e.AggregateName != "" && e.AggregateID != nil {
+		opts = append(opts, event.Aggregate(*e.AggregateID, e.AggregateName, e.AggregateVersion))
+	}
+
+	return event.New(e.Name, data, opts...), nil
+}
@@ -0,0 +1,231 @@
+package feed_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/feed"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestHandler_global(t *testing.T) {
+	ctx := context.Background()
+	store, events := newStore(5)
+	srv := httptest.NewServer(feed.NewHandler(store, test.NewEncoder(), feed.PageSize(2)))
+	defer srv.Close()
+
+	client, err := feed.NewClient(srv.URL, test.NewEncoder())
+	if err != nil {
+		t.Fatalf("NewClient() failed with %q", err)
+	}
+
+	var got []uuid.UUID
+	ref := "events"
+	for pages := 0; ref != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("feed should have %d pages", 3)
+		}
+
+		page, err := client.Page(ctx, ref)
+		if err != nil {
+			t.Fatalf("Page(%q) failed with %q", ref, err)
+		}
+
+		for _, entry := range page.Events {
+			got = append(got, entry.ID)
+		}
+		ref = page.Links.Next
+	}
+
+	if len(got) != len(events) {
+		t.Fatalf("feed should contain %d events; got %d", len(events), len(got))
+	}
+
+	for i, evt := range events {
+		if got[i] != evt.ID() {
+			t.Fatalf("event #%d should be %s; got %s", i, evt.ID(), got[i])
+		}
+	}
+}
+
+func TestHandler_global_equalTimes(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	events := make([]event.Event, 5)
+	for i := range events {
+		events[i] = event.New("foo", test.FooEventData{A: "foo"}, event.Time(now)).Any()
+	}
+	events = event.SortMulti(events, event.SortOptions{Sort: event.SortTime, Dir: event.SortAsc})
+
+	srv := httptest.NewServer(feed.NewHandler(eventstore.New(events...), test.NewEncoder(), feed.PageSize(2)))
+	defer srv.Close()
+
+	client, err := feed.NewClient(srv.URL, test.NewEncoder())
+	if err != nil {
+		t.Fatalf("NewClient() failed with %q", err)
+	}
+
+	var got []uuid.UUID
+	for ref, pages := "events", 0; ref != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("feed should have %d pages", 3)
+		}
+
+		page, err := client.Page(ctx, ref)
+		if err != nil {
+			t.Fatalf("Page(%q) failed with %q", ref, err)
+		}
+
+		for _, entry := range page.Events {
+			got = append(got, entry.ID)
+		}
+		ref = page.Links.Next
+	}
+
+	if len(got) != len(events) {
+		t.Fatalf("feed should contain %d events; got %d", len(events), len(got))
+	}
+
+	for i, evt := range events {
+		if got[i] != evt.ID() {
+			t.Fatalf("event #%d should be %s; got %s", i, evt.ID(), got[i])
+		}
+	}
+}
+
+func TestHandler_invalidPage(t *testing.T) {
+	srv := httptest.NewServer(feed.NewHandler(eventstore.New(), test.NewEncoder(), feed.PageSize(2)))
+	defer srv.Close()
+
+	for _, ref := range []string{
+		"/aggregates/foo/" + uuid.NewString() + "?page=9223372036854775807",
+		"/aggregates/foo/" + uuid.NewString() + "?page=-1",
+		"/events?after=foo",
+	} {
+		res, err := http.Get(srv.URL + ref)
+		if err != nil {
+			t.Fatalf("GET failed with %q", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("status of %q should be %d; got %d", ref, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+}
+
+func TestHandler_aggregate(t *testing.T) {
+	store, events := newStore(3)
+	srv := httptest.NewServer(feed.NewHandler(store, test.NewEncoder(), feed.PageSize(2), feed.MaxAge(time.Hour)))
+	defer srv.Close()
+
+	id, name, _ := events[0].Aggregate()
+	res, err := http.Get(srv.URL + "/aggregates/" + name + "/" + id.String())
+	if err != nil {
+		t.Fatalf("GET failed with %q", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status should be %d; got %d", http.StatusOK, res.StatusCode)
+	}
+
+	if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("full pages of aggregate streams should be cacheable; Cache-Control is %q", cc)
+	}
+
+	want := `<` + id.String() + `?page=0>; rel="self", <` + id.String() + `?page=0>; rel="first", <` + id.String() + `?page=1>; rel="next"`
+	if link := res.Header.Get("Link"); link != want {
+		t.Errorf("Link header should be\n\n%s\n\ngot\n\n%s", want, link)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/aggregates/"+name+"/"+id.String(), nil)
+	req.Header.Set("If-None-Match", res.Header.Get("ETag"))
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed with %q", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNotModified {
+		t.Fatalf("status should be %d for a matching ETag; got %d", http.StatusNotModified, res.StatusCode)
+	}
+}
+
+func TestClient_Poll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, events := newStore(3)
+	srv := httptest.NewServer(feed.NewHandler(store, test.NewEncoder(), feed.PageSize(2)))
+	defer srv.Close()
+
+	client, err := feed.NewClient(srv.URL, test.NewEncoder())
+	if err != nil {
+		t.Fatalf("NewClient() failed with %q", err)
+	}
+
+	received := make(chan event.Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Poll(ctx, feed.Position{Page: "events"}, 10*time.Millisecond, func(evt event.Event, _ feed.Position) error {
+			select {
+			case <-ctx.Done():
+			case received <- evt:
+			}
+			return nil
+		})
+	}()
+
+	receive := func(want event.Event) {
+		t.Helper()
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("event %s was not received", want.ID())
+		case evt := <-received:
+			if evt.ID() != want.ID() || evt.Data() != want.Data() {
+				t.Fatalf("event should be %v; got %v", want, evt)
+			}
+		}
+	}
+
+	for _, evt := range events {
+		receive(evt)
+	}
+
+	id, name, _ := events[0].Aggregate()
+	next := event.New("foo", test.FooEventData{A: "next"}, event.Time(events[2].Time().Add(time.Second)), event.Aggregate(id, name, 4)).Any()
+	if err := store.Insert(ctx, next); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	receive(next)
+
+	select {
+	case evt := <-received:
+		t.Fatalf("event %s should be received only once", evt.ID())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Poll() should return %q; got %q", context.Canceled, err)
+	}
+}
+
+func newStore(n int) (event.Store, []event.Event) {
+	id := uuid.New()
+	now := time.Now()
+	events := make([]event.Event, n)
+	for i := range events {
+		events[i] = event.New("foo", test.FooEventData{A: "foo"}, event.Time(now.Add(time.Duration(i)*time.Millisecond)), event.Aggregate(id, "foobar", i+1)).Any()
+	}
+	return eventstore.New(events...), events
+}
@@ -0,0 +1,365 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	stdtime "time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/time"
+	"github.com/modernice/goes/event/query/version"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// HandlerOption is an option for NewHandler.
+type HandlerOption func(*handler)
+
+type handler struct {
+	store    event.Store
+	enc      codec.Encoding
+	pageSize int
+	maxAge   stdtime.Duration
+}
+
+// PageSize returns a HandlerOption that specifies the number of events per
+// page. Defaults to DefaultPageSize. Changing the page size changes the
+// contents of existing pages, so consumers should restart from the first page
+// afterwards.
+func PageSize(n int) HandlerOption {
+	return func(h *handler) {
+		h.pageSize = n
+	}
+}
+
+// MaxAge returns a HandlerOption that allows caches to store full pages of
+// aggregate streams for the given duration. Full pages of aggregate streams
+// never change, because events are never inserted into the past of an
+// aggregate. All other pages must always be revalidated using their ETag.
+func MaxAge(d stdtime.Duration) HandlerOption {
+	return func(h *handler) {
+		h.maxAge = d
+	}
+}
+
+// NewHandler returns an http.Handler that serves the events of the given
+// store as feeds. The data of the events is encoded using the provided
+// encoding, which must produce JSON.
+//
+// The global feed is sorted by event time and event id, and paged by a cursor
+// that points to the last event of the previous page, so it works with every
+// store. Events must be inserted in time order, because events that are
+// inserted with a time before the cursor of a consumer are not returned to
+// that consumer. The streams of aggregates are paged by aggregate version.
+func NewHandler(store event.Store, enc codec.Encoding, opts ...HandlerOption) http.Handler {
+	h := handler{
+		store:    store,
+		enc:      enc,
+		pageSize: DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	if h.pageSize <= 0 {
+		h.pageSize = DefaultPageSize
+	}
+	return &h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/events":
+		after, err := parseCursor(r.URL.Query().Get("after"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.global(w, r, after)
+	case strings.HasPrefix(r.URL.Path, "/aggregates/"):
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/aggregates/"), "/")
+		if len(parts) != 2 || parts[0] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		id, err := uuid.Parse(parts[1])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid aggregate id: %v", err), http.StatusBadRequest)
+			return
+		}
+		page, err := h.pageNumber(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.aggregate(w, r, page, parts[0], id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// pageNumber returns the page number of an aggregate stream. Page numbers
+// whose aggregate versions would overflow are rejected.
+func (h *handler) pageNumber(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("page")
+	if v == "" {
+		return 0, nil
+	}
+	page, err := strconv.Atoi(v)
+	if err != nil || page < 0 || page >= math.MaxInt32/h.pageSize {
+		return 0, fmt.Errorf("invalid page %q", v)
+	}
+	return page, nil
+}
+
+// cursor is the position of an event in the global feed.
+type cursor struct {
+	time stdtime.Time
+	id   uuid.UUID
+}
+
+// parseCursor parses a cursor in the "<unix nano>.<event id>" format. An empty
+// string is the cursor of the first page.
+func parseCursor(v string) (cursor, error) {
+	if v == "" {
+		return cursor{}, nil
+	}
+
+	nano, id, ok := strings.Cut(v, ".")
+	if !ok {
+		return cursor{}, fmt.Errorf("invalid cursor %q", v)
+	}
+
+	n, err := strconv.ParseInt(nano, 10, 64)
+	if err != nil {
+		return cursor{}, fmt.Errorf("invalid cursor %q", v)
+	}
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return cursor{}, fmt.Errorf("invalid cursor %q", v)
+	}
+
+	return cursor{time: stdtime.Unix(0, n), id: uid}, nil
+}
+
+func (c cursor) String() string {
+	return strconv.FormatInt(c.time.UnixNano(), 10) + "." + c.id.String()
+}
+
+// before reports whether the event is at or before the cursor.
+func (c cursor) before(evt event.Event) bool {
+	if c.time.IsZero() {
+		return false
+	}
+	if t := evt.Time().UnixNano(); t != c.time.UnixNano() {
+		return t < c.time.UnixNano()
+	}
+	id := evt.ID()
+	return bytes.Compare(id[:], c.id[:]) <= 0
+}
+
+func (h *handler) global(w http.ResponseWriter, r *http.Request, after cursor) {
+	opts := []query.Option{query.SortByMulti(
+		event.SortOptions{Sort: event.SortTime, Dir: event.SortAsc},
+		event.SortOptions{Sort: event.SortID, Dir: event.SortAsc},
+	)}
+	if !after.time.IsZero() {
+		opts = append(opts, query.Time(time.Min(after.time)))
+	}
+	if names := r.URL.Query()["name"]; len(names) > 0 {
+		opts = append(opts, query.Name(names...))
+	}
+
+	// The query is canceled as soon as the page is full, so that stores that
+	// stream their results do not load the remaining events.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	str, errs, err := h.store.Query(ctx, query.New(opts...))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("query events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]event.Event, 0, h.pageSize)
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		if after.before(evt) {
+			return nil
+		}
+		events = append(events, evt)
+		if len(events) >= h.pageSize {
+			return errPageFull
+		}
+		return nil
+	}, str, errs); err != nil && !errors.Is(err, errPageFull) {
+		http.Error(w, fmt.Sprintf("query events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	link := func(c cursor) string {
+		values := make(url.Values)
+		for key, v := range r.URL.Query() {
+			if key != "after" {
+				values[key] = v
+			}
+		}
+		if !c.time.IsZero() {
+			values.Set("after", c.String())
+		}
+		if len(values) == 0 {
+			return "events"
+		}
+		return "events?" + values.Encode()
+	}
+
+	links := Links{Self: link(after), First: link(cursor{})}
+	if len(events) >= h.pageSize {
+		last := events[len(events)-1]
+		links.Next = link(cursor{time: last.Time(), id: last.ID()})
+	}
+
+	h.write(w, r, links, events, false)
+}
+
+var errPageFull = errors.New("page is full")
+
+func (h *handler) aggregate(w http.ResponseWriter, r *http.Request, page int, name string, id uuid.UUID) {
+	q := query.New(
+		query.Aggregate(name, id),
+		query.AggregateVersion(
+			version.Min(page*h.pageSize+1),
+			version.Max((page+1)*h.pageSize),
+		),
+		query.SortBy(event.SortAggregateVersion, event.SortAsc),
+	)
+
+	str, errs, err := h.store.Query(r.Context(), q)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("query events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	events, err := streams.Drain(r.Context(), str, errs)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("query events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.write(w, r, h.links(r, page, len(events) >= h.pageSize), events, true)
+}
+
+func (h *handler) write(w http.ResponseWriter, r *http.Request, links Links, events []event.Event, immutable bool) {
+	out := Page{
+		Links:  links,
+		Events: make([]Entry, 0, len(events)),
+	}
+
+	for _, evt := range events {
+		entry, err := NewEntry(r.Context(), h.enc, evt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out.Events = append(out.Events, entry)
+	}
+
+	body, err := json.Marshal(out)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encode page: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Link", linkHeader(out.Links))
+	if immutable && out.Links.Next != "" && h.maxAge > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", MediaType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+func (h *handler) links(r *http.Request, page int, full bool) Links {
+	link := func(page int) string {
+		values := make(url.Values)
+		for key, v := range r.URL.Query() {
+			if key != "page" {
+				values[key] = v
+			}
+		}
+		values.Set("page", strconv.Itoa(page))
+		return path.Base(r.URL.EscapedPath()) + "?" + values.Encode()
+	}
+
+	links := Links{Self: link(page), First: link(0)}
+	if page > 0 {
+		links.Prev = link(page - 1)
+	}
+	if full {
+		links.Next = link(page + 1)
+	}
+	return links
+}
+
+func linkHeader(links Links) string {
+	var buf bytes.Buffer
+	add := func(rel, target string) {
+		if target == "" {
+			return
+		}
+		if buf.Len() > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "<%s>; rel=%q", target, rel)
+	}
+	add("self", links.Self)
+	add("first", links.First)
+	add("prev", links.Prev)
+	add("next", links.Next)
+	return buf.String()
+}
+
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
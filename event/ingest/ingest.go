@@ -0,0 +1,307 @@
+// Package ingest provides an anti-corruption layer for ingesting messages of
+// external systems as goes events. Foreign messages, e.g. from Kafka topics,
+// webhooks, or SQS queues, are converted into transport-agnostic Messages,
+// translated into events by user-defined Translators, assigned to aggregates,
+// and inserted into an event store (and optionally published over an event
+// bus) idempotently.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/internal/xtime"
+)
+
+// ErrUnknownMessage is returned when a message has a type without a
+// Translator. Use IgnoreUnknown to skip such messages instead.
+var ErrUnknownMessage = errors.New("unknown message type")
+
+// Namespace is the namespace of the deterministic event ids that are derived
+// from the ids of ingested messages.
+var Namespace = uuid.MustParse("8d2f7a3e-7bc4-4f5e-9a51-6e1d3c0f2b94")
+
+// Message is a message of an external system.
+type Message struct {
+	// Source identifies the external system, e.g. a Kafka topic or the name
+	// of a webhook. Source and ID together must uniquely identify a message.
+	Source string
+
+	// ID is the id of the message within its source. Redelivered messages must
+	// have the same id.
+	ID string
+
+	// Type is the type of the message and selects the Translator.
+	Type string
+
+	// Time is the time of the message. If zero, the time of ingestion is used.
+	Time time.Time
+
+	// Payload is the raw payload of the message.
+	Payload []byte
+
+	// Metadata holds additional information about the message, like headers.
+	Metadata map[string]string
+}
+
+// Translation describes an event that is created from a Message.
+type Translation struct {
+	// Name is the name of the event.
+	Name string
+
+	// Data is the data of the event.
+	Data any
+
+	// Aggregate is the aggregate of the event. If set, the event is assigned
+	// the next version of the aggregate.
+	Aggregate event.AggregateRef
+
+	// Time is the time of the event. If zero, the time of the message is used.
+	Time time.Time
+}
+
+// Translator translates a foreign message into zero or more events.
+type Translator interface {
+	Translate(context.Context, Message) ([]Translation, error)
+}
+
+// TranslatorFunc is a function that implements Translator.
+type TranslatorFunc func(context.Context, Message) ([]Translation, error)
+
+// Translate calls fn(ctx, msg).
+func (fn TranslatorFunc) Translate(ctx context.Context, msg Message) ([]Translation, error) {
+	return fn(ctx, msg)
+}
+
+// JSON returns a Translator that decodes the JSON payload of messages into a
+// T and passes it to fn.
+//
+//	ingest.Route("order_created", ingest.JSON(func(msg ingest.Message, p shopify.Order) ([]ingest.Translation, error) {
+//		return []ingest.Translation{{
+//			Name:      "order.imported",
+//			Data:      OrderImported{ExternalID: p.ID},
+//			Aggregate: event.AggregateRef{Name: "order", ID: uuid.NewSHA1(ingest.Namespace, []byte(p.ID))},
+//		}}, nil
+//	}))
+func JSON[T any](fn func(Message, T) ([]Translation, error)) Translator {
+	return TranslatorFunc(func(_ context.Context, msg Message) ([]Translation, error) {
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("decode payload: %w", err)
+		}
+		return fn(msg, payload)
+	})
+}
+
+// Ingestor ingests foreign messages as events.
+type Ingestor struct {
+	store         event.Store
+	bus           event.Bus
+	translators   map[string]Translator
+	ignoreUnknown bool
+}
+
+// Option is an option for an Ingestor.
+type Option func(*Ingestor)
+
+// Route returns an Option that translates messages of the given type using
+// the provided Translator.
+func Route(msgType string, t Translator) Option {
+	return func(i *Ingestor) {
+		i.translators[msgType] = t
+	}
+}
+
+// Bus returns an Option that publishes ingested events over the given bus
+// after they have been inserted into the store.
+func Bus(bus event.Bus) Option {
+	return func(i *Ingestor) {
+		i.bus = bus
+	}
+}
+
+// IgnoreUnknown returns an Option that skips messages whose type has no
+// Translator, instead of failing with ErrUnknownMessage.
+func IgnoreUnknown() Option {
+	return func(i *Ingestor) {
+		i.ignoreUnknown = true
+	}
+}
+
+// New returns an Ingestor that inserts ingested events into the given store.
+func New(store event.Store, opts ...Option) *Ingestor {
+	i := &Ingestor{store: store, translators: make(map[string]Translator)}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Result is the result of ingesting messages.
+type Result struct {
+	// Inserted are the events that were inserted into the store.
+	Inserted []event.Event
+
+	// Duplicates is the number of events that were skipped because they had
+	// already been ingested.
+	Duplicates int
+}
+
+// Ingest translates the given messages and inserts the resulting events into
+// the store. The ids of the events are derived from the source and id of
+// their message, so that redelivered messages are detected and skipped. Events
+// with an aggregate are assigned the next version of that aggregate.
+//
+// If ingestion fails, it can safely be retried with the same messages: events
+// that were already inserted are skipped.
+func (i *Ingestor) Ingest(ctx context.Context, msgs ...Message) (Result, error) {
+	var res Result
+	for _, msg := range msgs {
+		if err := i.ingest(ctx, msg, &res); err != nil {
+			return res, fmt.Errorf("ingest %q message %q from %q: %w", msg.Type, msg.ID, msg.Source, err)
+		}
+	}
+	return res, nil
+}
+
+func (i *Ingestor) ingest(ctx context.Context, msg Message, res *Result) error {
+	if msg.ID == "" {
+		return errors.New("message has no id")
+	}
+
+	t, ok := i.translators[msg.Type]
+	if !ok {
+		if i.ignoreUnknown {
+			return nil
+		}
+		return ErrUnknownMessage
+	}
+
+	translations, err := t.Translate(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("translate: %w", err)
+	}
+
+	if msg.Time.IsZero() {
+		msg.Time = xtime.Now()
+	}
+
+	for n, tr := range translations {
+		id := EventID(msg, n)
+
+		if _, err := i.store.Find(ctx, id); err == nil {
+			res.Duplicates++
+			continue
+		}
+
+		evt, err := i.makeEvent(ctx, id, msg, tr)
+		if err != nil {
+			return err
+		}
+
+		if err := i.store.Insert(ctx, evt); err != nil {
+			return fmt.Errorf("insert %q event: %w", evt.Name(), err)
+		}
+		res.Inserted = append(res.Inserted, evt)
+
+		if i.bus != nil {
+			if err := i.bus.Publish(ctx, evt); err != nil {
+				return fmt.Errorf("publish %q event: %w", evt.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Ingestor) makeEvent(ctx context.Context, id uuid.UUID, msg Message, tr Translation) (event.Event, error) {
+	t := tr.Time
+	if t.IsZero() {
+		t = msg.Time
+	}
+
+	opts := []event.Option{event.ID(id), event.Time(t)}
+
+	if !tr.Aggregate.IsZero() {
+		v, err := i.currentVersion(ctx, tr.Aggregate)
+		if err != nil {
+			return nil, fmt.Errorf("get version of %s: %w", tr.Aggregate, err)
+		}
+		opts = append(opts, event.Aggregate(tr.Aggregate.ID, tr.Aggregate.Name, v+1))
+	}
+
+	return event.New(tr.Name, tr.Data, opts...).Any(), nil
+}
+
+func (i *Ingestor) currentVersion(ctx context.Context, ref event.AggregateRef) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := i.store.Query(ctx, query.New(
+		query.Aggregate(ref.Name, ref.ID),
+		query.SortBy(event.SortAggregateVersion, event.SortDesc),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	var version int
+	err = streams.Walk(ctx, func(evt event.Event) error {
+		_, _, version = evt.Aggregate()
+		return errStop
+	}, str, errs)
+	if err != nil && !errors.Is(err, errStop) {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+var errStop = errors.New("stop")
+
+// EventID returns the id of the nth event that is created from the given
+// message.
+func EventID(msg Message, n int) uuid.UUID {
+	return uuid.NewSHA1(Namespace, []byte(fmt.Sprintf("%s\x00%s\x00%d", msg.Source, msg.ID, n)))
+}
+
+// Run ingests the messages from the given channel until the channel is closed
+// or ctx is canceled. Messages are acknowledged by calling ack after they
+// have been ingested, if ack is non-nil. The returned channel receives the
+// errors of failed messages, which are not acknowledged, and is closed when
+// Run returns.
+func (i *Ingestor) Run(ctx context.Context, msgs <-chan Message, ack func(Message)) <-chan error {
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				if _, err := i.Ingest(ctx, msg); err != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case errs <- err:
+					}
+					continue
+				}
+				if ack != nil {
+					ack(msg)
+				}
+			}
+		}
+	}()
+	return errs
+}
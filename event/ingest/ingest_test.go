@@ -0,0 +1,223 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/ingest"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/pick"
+	"github.com/modernice/goes/helper/streams"
+)
+
+type externalOrder struct {
+	ID    string `json:"id"`
+	Items []string
+}
+
+var orderID = uuid.New()
+
+func newIngestor(store event.Store, opts ...ingest.Option) *ingest.Ingestor {
+	return ingest.New(store, append([]ingest.Option{
+		ingest.Route("order_created", ingest.JSON(func(msg ingest.Message, p externalOrder) ([]ingest.Translation, error) {
+			out := []ingest.Translation{{
+				Name:      "foo",
+				Data:      test.FooEventData{A: p.ID},
+				Aggregate: event.AggregateRef{Name: "order", ID: orderID},
+			}}
+			for _, item := range p.Items {
+				out = append(out, ingest.Translation{
+					Name:      "bar",
+					Data:      test.BarEventData{A: item},
+					Aggregate: event.AggregateRef{Name: "order", ID: orderID},
+				})
+			}
+			return out, nil
+		})),
+	}, opts...)...)
+}
+
+func TestIngestor_Ingest(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+	ing := newIngestor(store)
+
+	msg := ingest.Message{
+		Source:  "shop",
+		ID:      "msg-1",
+		Type:    "order_created",
+		Time:    time.Now(),
+		Payload: []byte(`{"id": "order-1", "Items": ["a", "b"]}`),
+	}
+
+	res, err := ing.Ingest(ctx, msg)
+	if err != nil {
+		t.Fatalf("Ingest failed with %q", err)
+	}
+
+	if len(res.Inserted) != 3 || res.Duplicates != 0 {
+		t.Fatalf("Ingest should insert %d events; got %d (duplicates=%d)", 3, len(res.Inserted), res.Duplicates)
+	}
+
+	for i, evt := range res.Inserted {
+		if pick.AggregateVersion(evt) != i+1 {
+			t.Errorf("event #%d should have aggregate version %d; got %d", i, i+1, pick.AggregateVersion(evt))
+		}
+		if evt.ID() != ingest.EventID(msg, i) {
+			t.Errorf("event #%d should have a deterministic id", i)
+		}
+		if !evt.Time().Equal(msg.Time) {
+			t.Errorf("event #%d should have the time of the message", i)
+		}
+	}
+
+	res, err = ing.Ingest(ctx, msg)
+	if err != nil {
+		t.Fatalf("Ingest failed with %q", err)
+	}
+
+	if len(res.Inserted) != 0 || res.Duplicates != 3 {
+		t.Fatalf("redelivered message should be skipped; inserted %d events (duplicates=%d)", len(res.Inserted), res.Duplicates)
+	}
+
+	msg.ID = "msg-2"
+	msg.Payload = []byte(`{"id": "order-1"}`)
+	res, err = ing.Ingest(ctx, msg)
+	if err != nil {
+		t.Fatalf("Ingest failed with %q", err)
+	}
+
+	if len(res.Inserted) != 1 || pick.AggregateVersion(res.Inserted[0]) != 4 {
+		t.Fatalf("event should be assigned the next aggregate version %d; got %v", 4, res.Inserted)
+	}
+
+	events := queryAll(t, store, query.New(query.Aggregate("order", orderID)))
+
+	if len(events) != 4 {
+		t.Fatalf("store should contain %d events; got %d", 4, len(events))
+	}
+}
+
+func TestIngestor_Ingest_unknownMessage(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newIngestor(eventstore.New()).Ingest(ctx, ingest.Message{Source: "shop", ID: "1", Type: "unknown"})
+	if !errors.Is(err, ingest.ErrUnknownMessage) {
+		t.Fatalf("Ingest should fail with %q; got %q", ingest.ErrUnknownMessage, err)
+	}
+
+	res, err := newIngestor(eventstore.New(), ingest.IgnoreUnknown()).Ingest(ctx, ingest.Message{Source: "shop", ID: "1", Type: "unknown"})
+	if err != nil {
+		t.Fatalf("Ingest should ignore unknown messages; got %q", err)
+	}
+
+	if len(res.Inserted) != 0 {
+		t.Fatalf("no events should be inserted for unknown messages")
+	}
+}
+
+func TestIngestor_Ingest_bus(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	bus := eventbus.New()
+	events, errs, err := bus.Subscribe(ctx, "foo")
+	if err != nil {
+		t.Fatalf("subscribe to events: %v", err)
+	}
+
+	if _, err := newIngestor(eventstore.New(), ingest.Bus(bus)).Ingest(ctx, ingest.Message{
+		Source:  "shop",
+		ID:      "1",
+		Type:    "order_created",
+		Payload: []byte(`{"id": "order-1"}`),
+	}); err != nil {
+		t.Fatalf("Ingest failed with %q", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out")
+	case err := <-errs:
+		t.Fatal(err)
+	case evt := <-events:
+		if evt.Data() != (test.FooEventData{A: "order-1"}) {
+			t.Fatalf("unexpected event data %v", evt.Data())
+		}
+	}
+}
+
+func TestIngestor_Webhook(t *testing.T) {
+	store := eventstore.New()
+	srv := httptest.NewServer(newIngestor(store).Webhook("shop"))
+	defer srv.Close()
+
+	post := func(id, typ, body string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" {
+			req.Header.Set(ingest.DefaultIDHeader, id)
+		}
+		req.Header.Set(ingest.DefaultTypeHeader, typ)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if code := post("1", "order_created", `{"id": "order-1"}`); code != http.StatusAccepted {
+		t.Errorf("webhook should respond with %d; got %d", http.StatusAccepted, code)
+	}
+
+	if code := post("1", "order_created", `{"id": "order-1"}`); code != http.StatusAccepted {
+		t.Errorf("webhook should accept redelivered messages; got %d", code)
+	}
+
+	if code := post("", "order_created", `{}`); code != http.StatusBadRequest {
+		t.Errorf("webhook should respond with %d to messages without id; got %d", http.StatusBadRequest, code)
+	}
+
+	if code := post("2", "unknown", `{}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("webhook should respond with %d to unknown messages; got %d", http.StatusUnprocessableEntity, code)
+	}
+
+	if code := post("3", "order_created", `invalid`); code != http.StatusInternalServerError {
+		t.Errorf("webhook should respond with %d to failed messages; got %d", http.StatusInternalServerError, code)
+	}
+
+	events := queryAll(t, store, query.New())
+
+	if len(events) != 1 {
+		t.Fatalf("store should contain %d event; got %d", 1, len(events))
+	}
+}
+
+func queryAll(t *testing.T, store event.Store, q event.Query) []event.Event {
+	str, errs, err := store.Query(context.Background(), q)
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+
+	events, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	return events
+}
@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Default headers that are read by a webhook handler.
+const (
+	DefaultIDHeader   = "Idempotency-Key"
+	DefaultTypeHeader = "X-Message-Type"
+)
+
+// DefaultMaxPayload is the default maximum size of a webhook payload.
+const DefaultMaxPayload = 1 << 20
+
+// WebhookOption is an option for Webhook.
+type WebhookOption func(*webhook)
+
+type webhook struct {
+	ingestor   *Ingestor
+	source     string
+	id         func(*http.Request) string
+	typ        func(*http.Request) string
+	maxPayload int64
+}
+
+// WebhookID returns a WebhookOption that reads the message id of a request
+// using fn. By default, the id is read from the "Idempotency-Key" header.
+func WebhookID(fn func(*http.Request) string) WebhookOption {
+	return func(w *webhook) {
+		w.id = fn
+	}
+}
+
+// WebhookType returns a WebhookOption that reads the message type of a
+// request using fn. By default, the type is read from the "X-Message-Type"
+// header.
+func WebhookType(fn func(*http.Request) string) WebhookOption {
+	return func(w *webhook) {
+		w.typ = fn
+	}
+}
+
+// MaxPayload returns a WebhookOption that limits the size of request bodies.
+// Defaults to DefaultMaxPayload.
+func MaxPayload(n int64) WebhookOption {
+	return func(w *webhook) {
+		w.maxPayload = n
+	}
+}
+
+// Webhook returns an http.Handler that ingests the body of each request as a
+// message from the given source. The handler responds with 202 Accepted when
+// the message has been ingested (or was already ingested), with 400 Bad
+// Request for requests without a message id, with 422 Unprocessable Entity
+// for messages of unknown type, and with 500 Internal Server Error otherwise,
+// so that the sender retries the delivery.
+func (i *Ingestor) Webhook(source string, opts ...WebhookOption) http.Handler {
+	w := &webhook{
+		ingestor:   i,
+		source:     source,
+		id:         headerFunc(DefaultIDHeader),
+		typ:        headerFunc(DefaultTypeHeader),
+		maxPayload: DefaultMaxPayload,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := wh.id(r)
+	if id == "" {
+		http.Error(w, "missing message id", http.StatusBadRequest)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, wh.maxPayload))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("read payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	metadata := make(map[string]string, len(r.Header))
+	for key := range r.Header {
+		metadata[key] = r.Header.Get(key)
+	}
+
+	if _, err := wh.ingestor.Ingest(r.Context(), Message{
+		Source:   wh.source,
+		ID:       id,
+		Type:     wh.typ(r),
+		Payload:  payload,
+		Metadata: metadata,
+	}); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrUnknownMessage) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func headerFunc(key string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(key)
+	}
+}
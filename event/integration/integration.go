@@ -0,0 +1,175 @@
+// Package integration separates internal domain events from the public
+// integration events that a service publishes to other services. Domain events
+// are translated into versioned integration events before they leave the
+// service, so that internal refactors of domain events do not break external
+// consumers. Domain events without a registered translation never leave the
+// service.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/transform"
+)
+
+// Translator translates domain events into integration events. Register
+// translations using Register. A Translator is a transform.Transformer, so it
+// can be used with the helpers of the transform package.
+type Translator struct {
+	translations map[string][]translation
+	codecs       map[string]func() any
+}
+
+type translation struct {
+	name      string
+	translate func(event.Event) (any, error)
+}
+
+// New returns a Translator without any translations.
+func New() *Translator {
+	return &Translator{
+		translations: make(map[string][]translation),
+		codecs:       make(map[string]func() any),
+	}
+}
+
+// Name returns the name of the integration event with the given public name
+// and version, e.g. Name("shop.order_placed", 2) returns
+// "shop.order_placed.v2".
+func Name(public string, version int) string {
+	return fmt.Sprintf("%s.v%d", public, version)
+}
+
+// Register registers a translation of the domain event with the given name
+// into version v of the integration event with the given public name. The
+// integration event is named Name(public, v) and has the data that is returned
+// by fn. The same domain event can be translated into multiple integration
+// events, e.g. to publish two versions of an integration event while consumers
+// migrate to the new version.
+//
+//	integration.Register(t, "order.placed", "shop.order_placed", 1, func(data OrderPlaced) (OrderPlacedV1, error) {
+//		return OrderPlacedV1{OrderID: data.ID, Total: data.Total}, nil
+//	})
+func Register[Domain, Public any](t *Translator, domain, public string, v int, fn func(Domain) (Public, error)) {
+	name := Name(public, v)
+	t.translations[domain] = append(t.translations[domain], translation{
+		name: name,
+		translate: func(evt event.Event) (any, error) {
+			data, ok := evt.Data().(Domain)
+			if !ok {
+				var zero Domain
+				return nil, fmt.Errorf("data is %T, not %T", evt.Data(), zero)
+			}
+			return fn(data)
+		},
+	})
+	t.codecs[name] = func() any {
+		var out Public
+		return &out
+	}
+}
+
+// DomainEvents returns the names of the domain events that have a
+// translation, sorted alphabetically.
+func (t *Translator) DomainEvents() []string {
+	out := make([]string, 0, len(t.translations))
+	for name := range t.translations {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IntegrationEvents returns the names of the integration events that the
+// Translator produces, sorted alphabetically.
+func (t *Translator) IntegrationEvents() []string {
+	out := make([]string, 0, len(t.codecs))
+	for name := range t.codecs {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RegisterCodec registers the data types of the integration events in the
+// given registry, so that the encoder of the public event bus can encode and
+// decode the integration events.
+func (t *Translator) RegisterCodec(r codec.Registerer) {
+	for name, factory := range t.codecs {
+		r.Register(name, factory)
+	}
+}
+
+// Transform translates the given domain event into its integration events.
+// Domain events without a translation are dropped. Integration events get a
+// deterministic id that is derived from the id of the domain event and the
+// name of the integration event, which allows consumers to deduplicate
+// re-published events. The time of the domain event is kept, but its
+// aggregate is not, because the aggregate is an internal detail of the
+// service; include the data that consumers need in the integration event.
+func (t *Translator) Transform(_ context.Context, evt event.Event) ([]event.Event, error) {
+	translations := t.translations[evt.Name()]
+	if len(translations) == 0 {
+		return nil, nil
+	}
+
+	out := make([]event.Event, 0, len(translations))
+	for _, tr := range translations {
+		data, err := tr.translate(evt)
+		if err != nil {
+			return nil, fmt.Errorf("translate %q event into %q: %w", evt.Name(), tr.name, err)
+		}
+		out = append(out, event.New(
+			tr.name,
+			data,
+			event.ID(uuid.NewSHA1(evt.ID(), []byte(tr.name))),
+			event.Time(evt.Time()),
+		).Any())
+	}
+
+	return out, nil
+}
+
+// Translate translates the given domain events into integration events.
+func (t *Translator) Translate(ctx context.Context, events ...event.Event) ([]event.Event, error) {
+	return transform.Apply(ctx, t, events...)
+}
+
+// Bridge subscribes to the translated domain events over the internal bus and
+// publishes their integration events over the public bus. Bridge returns a
+// channel of asynchronous errors that is closed when ctx is canceled.
+func Bridge(ctx context.Context, internal, public event.Bus, t *Translator) (<-chan error, error) {
+	return transform.Bridge(ctx, internal, public, t.DomainEvents(), t)
+}
+
+// Publisher decorates the given public event bus so that published domain
+// events are translated into integration events before they are published.
+// Domain events without a translation are not published. Subscriptions are
+// passed to the public bus as-is, so subscribers receive integration events.
+func Publisher(public event.Bus, t *Translator) event.Bus {
+	return &publisher{Bus: public, translator: t}
+}
+
+type publisher struct {
+	event.Bus
+
+	translator *Translator
+}
+
+func (p *publisher) Publish(ctx context.Context, events ...event.Event) error {
+	translated, err := p.translator.Translate(ctx, events...)
+	if err != nil {
+		return err
+	}
+
+	if len(translated) == 0 {
+		return nil
+	}
+
+	return p.Bus.Publish(ctx, translated...)
+}
@@ -0,0 +1,178 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/integration"
+	"github.com/modernice/goes/event/test"
+)
+
+type orderPlacedV1 struct {
+	Order string
+}
+
+type orderPlacedV2 struct {
+	OrderID string
+	Source  string
+}
+
+func newTranslator() *integration.Translator {
+	t := integration.New()
+	integration.Register(t, "foo", "shop.order_placed", 1, func(data test.FooEventData) (orderPlacedV1, error) {
+		return orderPlacedV1{Order: data.A}, nil
+	})
+	integration.Register(t, "foo", "shop.order_placed", 2, func(data test.FooEventData) (orderPlacedV2, error) {
+		if data.A == "" {
+			return orderPlacedV2{}, errors.New("missing order")
+		}
+		return orderPlacedV2{OrderID: data.A, Source: "shop"}, nil
+	})
+	return t
+}
+
+func TestTranslator_Translate(t *testing.T) {
+	tr := newTranslator()
+
+	foo := event.New[any]("foo", test.FooEventData{A: "order-1"}, event.Aggregate(uuid.New(), "order", 3))
+	bar := event.New[any]("bar", test.BarEventData{A: "internal"})
+
+	events, err := tr.Translate(context.Background(), foo, bar)
+	if err != nil {
+		t.Fatalf("Translate failed with %q", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Translate should return %d events; got %d", 2, len(events))
+	}
+
+	v1, v2 := events[0], events[1]
+
+	if v1.Name() != "shop.order_placed.v1" || v2.Name() != "shop.order_placed.v2" {
+		t.Fatalf("unexpected integration event names %q and %q", v1.Name(), v2.Name())
+	}
+
+	if v1.Data() != (orderPlacedV1{Order: "order-1"}) {
+		t.Errorf("unexpected v1 data %v", v1.Data())
+	}
+
+	if v2.Data() != (orderPlacedV2{OrderID: "order-1", Source: "shop"}) {
+		t.Errorf("unexpected v2 data %v", v2.Data())
+	}
+
+	if v1.ID() == foo.ID() || v1.ID() == v2.ID() {
+		t.Errorf("integration events should have their own ids")
+	}
+
+	again, err := tr.Translate(context.Background(), foo)
+	if err != nil {
+		t.Fatalf("Translate failed with %q", err)
+	}
+	if again[0].ID() != v1.ID() {
+		t.Errorf("integration event ids should be deterministic")
+	}
+
+	if !v1.Time().Equal(foo.Time()) {
+		t.Errorf("integration event should keep the time of the domain event")
+	}
+
+	if id, name, _ := v1.Aggregate(); id != uuid.Nil || name != "" {
+		t.Errorf("integration event should not expose the aggregate; got %s(%s)", name, id)
+	}
+}
+
+func TestTranslator_Translate_error(t *testing.T) {
+	tr := newTranslator()
+
+	if _, err := tr.Translate(context.Background(), event.New[any]("foo", test.FooEventData{})); err == nil {
+		t.Fatalf("Translate should fail if a translation fails")
+	}
+
+	if _, err := tr.Translate(context.Background(), event.New[any]("foo", test.BarEventData{A: "x"})); err == nil {
+		t.Fatalf("Translate should fail if the domain event has unexpected data")
+	}
+}
+
+func TestTranslator_RegisterCodec(t *testing.T) {
+	tr := newTranslator()
+	reg := codec.New()
+	tr.RegisterCodec(reg)
+
+	if err := reg.Validate(tr.IntegrationEvents()...); err != nil {
+		t.Fatalf("integration events should be registered: %v", err)
+	}
+
+	if names := tr.DomainEvents(); len(names) != 1 || names[0] != "foo" {
+		t.Fatalf("DomainEvents should return %v; got %v", []string{"foo"}, names)
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	bus := eventbus.New()
+	pub := integration.Publisher(bus, newTranslator())
+
+	events, errs, err := pub.Subscribe(ctx, "shop.order_placed.v1", "bar")
+	if err != nil {
+		t.Fatalf("subscribe to events: %v", err)
+	}
+
+	if err := pub.Publish(ctx,
+		event.New[any]("bar", test.BarEventData{A: "internal"}),
+		event.New[any]("foo", test.FooEventData{A: "order-1"}),
+	); err != nil {
+		t.Fatalf("Publish failed with %q", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out")
+	case err := <-errs:
+		t.Fatal(err)
+	case evt := <-events:
+		if evt.Name() != "shop.order_placed.v1" {
+			t.Fatalf("only integration events should be published; got %q", evt.Name())
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	internal := eventbus.New()
+	public := eventbus.New()
+
+	events, _, err := public.Subscribe(ctx, "shop.order_placed.v2")
+	if err != nil {
+		t.Fatalf("subscribe to events: %v", err)
+	}
+
+	errs, err := integration.Bridge(ctx, internal, public, newTranslator())
+	if err != nil {
+		t.Fatalf("Bridge failed with %q", err)
+	}
+
+	if err := internal.Publish(ctx, event.New[any]("foo", test.FooEventData{A: "order-1"})); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out")
+	case err := <-errs:
+		t.Fatalf("bridge failed with %q", err)
+	case evt := <-events:
+		if evt.Data() != (orderPlacedV2{OrderID: "order-1", Source: "shop"}) {
+			t.Fatalf("unexpected integration event data %v", evt.Data())
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package event
+
+import "context"
+
+// Positioned is an event and its global position within an event store.
+type Positioned struct {
+	Event
+
+	// Position is the global position of the event.
+	Position int64
+}
+
+// PositionQuerier is implemented by event stores that assign a strictly
+// increasing global position to every inserted event. Unlike event times,
+// positions reflect the order in which events were inserted, so consumers can
+// checkpoint their progress on the position of the last consumed event without
+// skipping events that were inserted later but have an earlier time.
+type PositionQuerier interface {
+	// QueryAfter queries the events that have a position greater than the
+	// given position and are matched by the given query, sorted by their
+	// position. The sortings of the query are ignored. Stores that can be
+	// configured to not assign positions fail with an error that wraps
+	// errors.ErrUnsupported if positions are disabled.
+	QueryAfter(ctx context.Context, position int64, q Query) (<-chan Positioned, <-chan error, error)
+}
@@ -19,6 +19,15 @@ type Query struct {
 
 	times             time.Constraints
 	aggregateVersions version.Constraints
+
+	undecodable func(*event.DecodeError)
+
+	limit  int
+	offset int
+
+	includeDeleted bool
+
+	tags []string
 }
 
 // Option is an option for building a query.
@@ -182,6 +191,74 @@ func SortByTime() Option {
 	return SortBy(event.SortTime, event.SortAsc)
 }
 
+// SkipUndecodable returns an Option that makes event stores skip events whose
+// data cannot be decoded, instead of reporting the error in the error channel
+// of the query result. For every skipped event, fn is called with a
+// *event.DecodeError that provides the encoded event. This way, a single
+// corrupt or unregistered event does not prevent the remaining events from
+// being queried:
+//
+//	str, errs, err := store.Query(context.TODO(), query.New(
+//		query.AggregateName("foo"),
+//		query.SkipUndecodable(func(err *event.DecodeError) {
+//			log.Printf("skipped undecodable event: %v", err)
+//		}),
+//	))
+//
+// fn may be called concurrently by the event store and should not block.
+func SkipUndecodable(fn func(*event.DecodeError)) Option {
+	return func(b *builder) {
+		b.undecodable = fn
+	}
+}
+
+// IncludeDeleted returns an Option that makes event stores that support soft
+// deletes also return events that were marked as deleted. By default, deleted
+// events are excluded from queries.
+func IncludeDeleted() Option {
+	return func(b *builder) {
+		b.includeDeleted = true
+	}
+}
+
+// Tag returns an Option that filters events by their tags (see event.Tag). An
+// event matches if it has at least one of the provided tags:
+//
+//	q := query.New(query.Tag("import:42"))
+func Tag(tags ...string) Option {
+	return func(b *builder) {
+	L:
+		for _, tag := range tags {
+			for _, t := range b.tags {
+				if t == tag {
+					continue L
+				}
+			}
+			b.tags = append(b.tags, tag)
+		}
+	}
+}
+
+// Limit returns an Option that limits the number of events that are returned
+// by event stores that support paging (see event.Paging). A limit <= 0 means
+// no limit. Use Limit together with Offset and a sorting to page through the
+// events of a large store:
+//
+//	q := query.New(query.SortByTime(), query.Limit(1000), query.Offset(page*1000))
+func Limit(n int) Option {
+	return func(b *builder) {
+		b.limit = max(n, 0)
+	}
+}
+
+// Offset returns an Option that makes event stores that support paging (see
+// event.Paging) skip the first n matching events.
+func Offset(n int) Option {
+	return func(b *builder) {
+		b.offset = max(n, 0)
+	}
+}
+
 // Test tests the event evt against the Query q and returns true if q should
 // include evt in its results. Test can be used by in-memory event.Store
 // implementations to filter events based on the query.
@@ -228,7 +305,20 @@ func Merge(queries ...event.Query) Query {
 			Aggregates(q.Aggregates()...),
 			Time(timeOpts...),
 			SortByMulti(q.Sortings()...),
+			Tag(event.QueryTags(q)...),
 		)
+
+		if fn := event.UndecodableHandler(q); fn != nil {
+			opts = append(opts, SkipUndecodable(fn))
+		}
+
+		if limit, offset := event.Paging(q); limit > 0 || offset > 0 {
+			opts = append(opts, Limit(limit), Offset(offset))
+		}
+
+		if event.IncludesDeleted(q) {
+			opts = append(opts, IncludeDeleted())
+		}
 	}
 	return New(opts...)
 }
@@ -277,6 +367,33 @@ func (q Query) Sortings() []event.SortOptions {
 	return q.sortings
 }
 
+// Undecodable returns the function that was provided using the
+// SkipUndecodable option, or nil.
+func (q Query) Undecodable() func(*event.DecodeError) {
+	return q.undecodable
+}
+
+// Limit returns the maximum number of events to query, or 0 if the number of
+// events is not limited.
+func (q Query) Limit() int {
+	return q.limit
+}
+
+// Offset returns the number of matching events to skip.
+func (q Query) Offset() int {
+	return q.offset
+}
+
+// IncludesDeleted returns whether the IncludeDeleted option was used.
+func (q Query) IncludesDeleted() bool {
+	return q.includeDeleted
+}
+
+// Tags returns the event tags to query for.
+func (q Query) Tags() []string {
+	return q.tags
+}
+
 func (b builder) build() Query {
 	b.times = time.Filter(b.timeConstraints...)
 	b.aggregateVersions = version.Filter(b.versionConstraints...)
@@ -305,6 +305,16 @@ func TestTest(t *testing.T) {
 				event.New[any]("foo", test.FooEventData{}, event.Aggregate(aggregateID, "bar", 0)): true,
 			},
 		},
+		{
+			name:  "Tag",
+			query: New(Tag("foo", "bar")),
+			tests: map[event.Event]bool{
+				event.New[any]("foo", test.FooEventData{}):                             false,
+				event.New[any]("foo", test.FooEventData{}, event.Tag("foo")):           true,
+				event.New[any]("foo", test.FooEventData{}, event.Tag("baz", "bar")):    true,
+				event.New[any]("foo", test.FooEventData{}, event.Tag("baz", "foobar")): false,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -403,3 +413,47 @@ func TestMerge(t *testing.T) {
 		t.Fatalf("Aggregates should return %v; got %v", wantAggregates, q.Aggregates())
 	}
 }
+
+func TestMerge_SkipUndecodable(t *testing.T) {
+	var called bool
+	q := Merge(New(Name("foo")), New(SkipUndecodable(func(*event.DecodeError) { called = true })), New(Name("bar")))
+
+	fn := event.UndecodableHandler(q)
+	if fn == nil {
+		t.Fatalf("UndecodableHandler should return the function provided to SkipUndecodable")
+	}
+
+	fn(&event.DecodeError{})
+
+	if !called {
+		t.Fatalf("UndecodableHandler should return the function provided to SkipUndecodable")
+	}
+}
+
+func TestMerge_IncludeDeleted(t *testing.T) {
+	if event.IncludesDeleted(Merge(New(Name("foo")), New(Name("bar")))) {
+		t.Fatalf("IncludesDeleted should return false if no query uses IncludeDeleted")
+	}
+
+	if !event.IncludesDeleted(Merge(New(Name("foo")), New(IncludeDeleted()), New(Name("bar")))) {
+		t.Fatalf("IncludesDeleted should return true if a merged query uses IncludeDeleted")
+	}
+}
+
+func TestMerge_Tag(t *testing.T) {
+	q := Merge(New(Tag("foo")), New(Name("foo")), New(Tag("bar", "foo")))
+
+	want := []string{"foo", "bar"}
+	if got := event.QueryTags(q); !reflect.DeepEqual(got, want) {
+		t.Fatalf("QueryTags should return %v; got %v", want, got)
+	}
+}
+
+func TestMerge_Paging(t *testing.T) {
+	q := Merge(New(Limit(10)), New(Name("foo")), New(Limit(20), Offset(40)))
+
+	limit, offset := event.Paging(q)
+	if limit != 20 || offset != 40 {
+		t.Fatalf("Paging should return (%d, %d); got (%d, %d)", 20, 40, limit, offset)
+	}
+}
@@ -0,0 +1,103 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+)
+
+// RawEvent is an event whose data has not been decoded. RawEvents allow
+// generic components like relays, archivers, and audit pipelines to read and
+// forward events without knowing the concrete types of the event data.
+type RawEvent struct {
+	ID               uuid.UUID
+	Name             string
+	Time             time.Time
+	Aggregate        AggregateRef
+	AggregateVersion int
+
+	// Data is the encoded event data.
+	Data []byte
+
+	// Tags are the tags of the event (see Tag).
+	Tags []string
+}
+
+// RawQuerier is implemented by event stores that can query events without
+// decoding their data.
+type RawQuerier interface {
+	// QueryRaw queries events like Store.Query does, but returns the events
+	// without decoding their data.
+	QueryRaw(context.Context, Query) (<-chan RawEvent, <-chan error, error)
+}
+
+// RawInserter is implemented by event stores that can insert events whose
+// data is already encoded.
+type RawInserter interface {
+	// InsertRaw inserts the given events into the store as-is.
+	InsertRaw(context.Context, ...RawEvent) error
+}
+
+// RawPublisher is implemented by event buses that can publish events whose
+// data is already encoded.
+type RawPublisher interface {
+	// PublishRaw publishes the given events as-is.
+	PublishRaw(context.Context, ...RawEvent) error
+}
+
+// RawSubscriber is implemented by event buses that can subscribe to events
+// without decoding their data.
+type RawSubscriber interface {
+	// SubscribeRaw subscribes to events like Bus.Subscribe does, but returns
+	// the events without decoding their data.
+	SubscribeRaw(context.Context, ...string) (<-chan RawEvent, <-chan error, error)
+}
+
+// Raw encodes the data of the given event using the provided encoder and
+// returns the event as a RawEvent.
+func Raw[D any](enc codec.Encoding, evt Of[D]) (RawEvent, error) {
+	b, err := enc.Marshal(evt.Data())
+	if err != nil {
+		return RawEvent{}, fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+	}
+
+	id, name, v := evt.Aggregate()
+
+	return RawEvent{
+		ID:               evt.ID(),
+		Name:             evt.Name(),
+		Time:             evt.Time(),
+		Aggregate:        AggregateRef{Name: name, ID: id},
+		AggregateVersion: v,
+		Data:             b,
+		Tags:             TagsOf(evt),
+	}, nil
+}
+
+// Decode decodes the data of the raw event using the provided decoder and
+// returns the decoded event. If the data cannot be decoded, a *DecodeError is
+// returned.
+func (raw RawEvent) Decode(enc codec.Encoding) (Event, error) {
+	return raw.DecodeContext(context.Background(), enc)
+}
+
+// DecodeContext decodes the data of the raw event like Decode does, but passes
+// ctx to the decoder if it is a codec.ContextEncoding.
+func (raw RawEvent) DecodeContext(ctx context.Context, enc codec.Encoding) (Event, error) {
+	data, err := codec.UnmarshalContext(ctx, enc, raw.Data, raw.Name)
+	if err != nil {
+		return nil, &DecodeError{RawEvent: raw, Err: err}
+	}
+
+	return New(
+		raw.Name,
+		data,
+		ID(raw.ID),
+		Time(raw.Time),
+		Aggregate(raw.Aggregate.ID, raw.Aggregate.Name, raw.AggregateVersion),
+		Tag(raw.Tags...),
+	), nil
+}
@@ -0,0 +1,55 @@
+package event_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestRaw(t *testing.T) {
+	enc := test.NewEncoder()
+	aggregateID := uuid.New()
+	evt := event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(aggregateID, "foo", 3))
+
+	raw, err := event.Raw(enc, evt)
+	if err != nil {
+		t.Fatalf("Raw failed with %q", err)
+	}
+
+	if raw.ID != evt.ID() || raw.Name != evt.Name() || !raw.Time.Equal(evt.Time()) {
+		t.Fatalf("raw event should have the same id, name and time as the event")
+	}
+
+	if raw.Aggregate.ID != aggregateID || raw.Aggregate.Name != "foo" || raw.AggregateVersion != 3 {
+		t.Fatalf("raw event should have the same aggregate as the event")
+	}
+
+	decoded, err := raw.Decode(enc)
+	if err != nil {
+		t.Fatalf("Decode failed with %q", err)
+	}
+
+	test.AssertEqualEvents(t, []event.Event{evt.Any()}, []event.Event{decoded})
+}
+
+func TestRawEvent_Decode_error(t *testing.T) {
+	raw, err := event.Raw(test.NewEncoder(), event.New("foo", test.FooEventData{A: "foo"}))
+	if err != nil {
+		t.Fatalf("Raw failed with %q", err)
+	}
+
+	_, err = raw.Decode(codec.New())
+
+	var decodeErr *event.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Decode should fail with a %T; got %T", decodeErr, err)
+	}
+
+	if decodeErr.ID != raw.ID || string(decodeErr.Data) != string(raw.Data) {
+		t.Fatalf("DecodeError should provide the raw event")
+	}
+}
@@ -0,0 +1,286 @@
+// Package replay republishes the events of an event store onto an event bus,
+// e.g. to feed the history of a service into a new consumer.
+//
+// Replays usually publish onto the same bus as live traffic. To avoid that a
+// replay starves live consumers, a Replayer can be throttled by the lag of
+// the consumers: before each batch of events is published, the Replayer
+// checks the lag, and if it exceeds the configured maximum, it waits and
+// shrinks its batches until the consumers have caught up:
+//
+//	r := replay.New(store, bus,
+//		replay.Rate(500),
+//		replay.Throttle(replay.LagFunc(func(ctx context.Context) (int, error) {
+//			info, err := js.ConsumerInfo("orders", "projector")
+//			if err != nil {
+//				return 0, err
+//			}
+//			return int(info.NumPending), nil
+//		}), 1000),
+//	)
+//
+//	n, err := r.Replay(ctx, query.New(query.AggregateName("order")))
+package replay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+)
+
+const (
+	// DefaultBatchSize is the default number of events that are published
+	// at once.
+	DefaultBatchSize = 100
+
+	// DefaultMinBackoff is the default delay after which the lag of the
+	// consumers is checked again if it exceeds the maximum lag.
+	DefaultMinBackoff = 50 * time.Millisecond
+
+	// DefaultMaxBackoff is the default maximum delay between two checks of
+	// the consumer lag.
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// Lag reports the lag of the consumers of a bus, i.e. the number of
+// published events that have not been processed yet.
+type Lag interface {
+	Lag(context.Context) (int, error)
+}
+
+// LagFunc is a function that implements Lag.
+type LagFunc func(context.Context) (int, error)
+
+// Lag calls fn(ctx).
+func (fn LagFunc) Lag(ctx context.Context) (int, error) {
+	return fn(ctx)
+}
+
+// Throttling describes a pause of a replay because of consumer lag.
+type Throttling struct {
+	// Lag is the lag that caused the pause.
+	Lag int
+
+	// Delay is the duration of the pause.
+	Delay time.Duration
+
+	// BatchSize is the reduced batch size after the pause.
+	BatchSize int
+}
+
+// Replayer republishes events from an event store onto an event bus.
+type Replayer struct {
+	store      event.Store
+	bus        event.Bus
+	batchSize  int
+	rate       float64
+	lag        Lag
+	maxLag     int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	onThrottle func(Throttling)
+}
+
+// Option is an option for a Replayer.
+type Option func(*Replayer)
+
+// BatchSize returns an Option that specifies the maximum number of events
+// that are published at once. Defaults to DefaultBatchSize.
+func BatchSize(n int) Option {
+	return func(r *Replayer) {
+		r.batchSize = n
+	}
+}
+
+// Rate returns an Option that limits the number of events that are published
+// per second. A rate <= 0 means no limit.
+func Rate(eventsPerSecond float64) Option {
+	return func(r *Replayer) {
+		r.rate = eventsPerSecond
+	}
+}
+
+// Throttle returns an Option that throttles the replay if the lag of the
+// consumers exceeds maxLag. While the lag exceeds maxLag, the Replayer waits
+// with an exponential backoff (see Backoff) and halves its batch size. When
+// the lag drops to half of maxLag or below, the batch size grows back to the
+// configured BatchSize.
+func Throttle(lag Lag, maxLag int) Option {
+	return func(r *Replayer) {
+		r.lag = lag
+		r.maxLag = maxLag
+	}
+}
+
+// Backoff returns an Option that specifies the minimum and maximum delay
+// between two checks of the consumer lag while the replay is throttled.
+// Defaults to DefaultMinBackoff and DefaultMaxBackoff.
+func Backoff(min, max time.Duration) Option {
+	return func(r *Replayer) {
+		r.minBackoff = min
+		r.maxBackoff = max
+	}
+}
+
+// OnThrottle returns an Option that calls fn whenever the replay is paused
+// because of consumer lag, e.g. for logging or metrics.
+func OnThrottle(fn func(Throttling)) Option {
+	return func(r *Replayer) {
+		r.onThrottle = fn
+	}
+}
+
+// New returns a Replayer that republishes events of the given store onto the
+// given bus.
+func New(store event.Store, bus event.Bus, opts ...Option) *Replayer {
+	r := &Replayer{
+		store:      store,
+		bus:        bus,
+		batchSize:  DefaultBatchSize,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = DefaultBatchSize
+	}
+	if r.minBackoff <= 0 {
+		r.minBackoff = DefaultMinBackoff
+	}
+	if r.maxBackoff < r.minBackoff {
+		r.maxBackoff = r.minBackoff
+	}
+	return r
+}
+
+// Replay publishes the events that are returned by the given query onto the
+// bus, in the order of the query, and returns the number of published events.
+// The query stream stays open while the replay is throttled, so stores whose
+// cursors time out should be queried in smaller ranges.
+func (r *Replayer) Replay(ctx context.Context, q event.Query) (int, error) {
+	// Canceling the query when the replay fails releases the goroutine of the
+	// store that sends the remaining events.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str, errs, err := r.store.Query(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	s := replay{Replayer: r, size: r.batchSize, start: time.Now()}
+
+	batch := make([]event.Event, 0, r.batchSize)
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		batch = append(batch, evt)
+		if len(batch) < s.size {
+			return nil
+		}
+		err := s.publish(ctx, batch)
+		batch = make([]event.Event, 0, s.size)
+		return err
+	}, str, errs); err != nil {
+		return s.published, err
+	}
+
+	if len(batch) > 0 {
+		if err := s.publish(ctx, batch); err != nil {
+			return s.published, err
+		}
+	}
+
+	return s.published, nil
+}
+
+// replay is the state of a single replay.
+type replay struct {
+	*Replayer
+
+	size      int
+	start     time.Time
+	published int
+}
+
+func (s *replay) publish(ctx context.Context, batch []event.Event) error {
+	if err := s.throttle(ctx); err != nil {
+		return err
+	}
+
+	if err := s.pace(ctx, len(batch)); err != nil {
+		return err
+	}
+
+	if err := s.bus.Publish(ctx, batch...); err != nil {
+		return fmt.Errorf("publish events: %w", err)
+	}
+	s.published += len(batch)
+
+	return nil
+}
+
+// throttle waits until the lag of the consumers does not exceed the maximum
+// lag, and adjusts the batch size to the lag.
+func (s *replay) throttle(ctx context.Context) error {
+	if s.lag == nil {
+		return nil
+	}
+
+	delay := s.minBackoff
+	for {
+		lag, err := s.lag.Lag(ctx)
+		if err != nil {
+			return fmt.Errorf("check consumer lag: %w", err)
+		}
+
+		if lag <= s.maxLag {
+			if lag <= s.maxLag/2 && s.size < s.batchSize {
+				s.size = min(s.size*2, s.batchSize)
+			}
+			return nil
+		}
+
+		s.size = max(s.size/2, 1)
+
+		if s.onThrottle != nil {
+			s.onThrottle(Throttling{Lag: lag, Delay: delay, BatchSize: s.size})
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+
+		// The paused time does not count towards the rate.
+		s.start = s.start.Add(delay)
+
+		delay = min(delay*2, s.maxBackoff)
+	}
+}
+
+// pace waits until publishing n more events does not exceed the rate.
+func (s *replay) pace(ctx context.Context, n int) error {
+	if s.rate <= 0 {
+		return nil
+	}
+	due := s.start.Add(time.Duration(float64(s.published+n) / s.rate * float64(time.Second)))
+	return sleep(ctx, time.Until(due))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
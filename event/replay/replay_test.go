@@ -0,0 +1,194 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/replay"
+)
+
+func TestReplayer_Replay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, events := newStore(25)
+	bus := eventbus.New()
+
+	received := make(chan event.Event, len(events))
+	sub := subscribe(t, ctx, bus)
+	go func() {
+		for evt := range sub {
+			received <- evt
+		}
+	}()
+
+	r := replay.New(store, bus, replay.BatchSize(10))
+
+	n, err := r.Replay(ctx, query.New(query.SortBy(event.SortTime, event.SortAsc)))
+	if err != nil {
+		t.Fatalf("Replay() failed with %q", err)
+	}
+
+	if n != len(events) {
+		t.Fatalf("Replay() should publish %d events; published %d", len(events), n)
+	}
+
+	for i, evt := range events {
+		select {
+		case <-time.After(time.Second):
+			t.Fatalf("didn't receive event #%d after %s", i, time.Second)
+		case got := <-received:
+			if got.ID() != evt.ID() {
+				t.Fatalf("event #%d should be %s; got %s", i, evt.ID(), got.ID())
+			}
+		}
+	}
+}
+
+func TestReplayer_Replay_publishError(t *testing.T) {
+	store, _ := newStore(25)
+	qs := &queryCtxStore{Store: store}
+	mockErr := errors.New("mock error")
+
+	r := replay.New(qs, failingBus{Bus: eventbus.New(), err: mockErr}, replay.BatchSize(10))
+
+	if _, err := r.Replay(context.Background(), query.New()); !errors.Is(err, mockErr) {
+		t.Fatalf("Replay() should fail with %q; got %q", mockErr, err)
+	}
+
+	select {
+	case <-qs.ctx.Done():
+	default:
+		t.Fatalf("Replay() should cancel the query after a failed publish")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, events := newStore(20)
+	bus := eventbus.New()
+
+	received := subscribe(t, ctx, bus)
+	go func() {
+		for range received {
+		}
+	}()
+
+	// The consumers lag behind for the first three checks.
+	var mux sync.Mutex
+	lags := []int{50, 50, 50}
+	lag := replay.LagFunc(func(context.Context) (int, error) {
+		mux.Lock()
+		defer mux.Unlock()
+		if len(lags) == 0 {
+			return 0, nil
+		}
+		l := lags[0]
+		lags = lags[1:]
+		return l, nil
+	})
+
+	var throttled []replay.Throttling
+	r := replay.New(store, bus,
+		replay.BatchSize(8),
+		replay.Throttle(lag, 10),
+		replay.Backoff(time.Millisecond, 2*time.Millisecond),
+		replay.OnThrottle(func(th replay.Throttling) {
+			throttled = append(throttled, th)
+		}),
+	)
+
+	n, err := r.Replay(ctx, query.New())
+	if err != nil {
+		t.Fatalf("Replay() failed with %q", err)
+	}
+
+	if n != len(events) {
+		t.Fatalf("Replay() should publish %d events; published %d", len(events), n)
+	}
+
+	if len(throttled) != 3 {
+		t.Fatalf("replay should be throttled %d times; was throttled %d times", 3, len(throttled))
+	}
+
+	want := []replay.Throttling{
+		{Lag: 50, Delay: time.Millisecond, BatchSize: 4},
+		{Lag: 50, Delay: 2 * time.Millisecond, BatchSize: 2},
+		{Lag: 50, Delay: 2 * time.Millisecond, BatchSize: 1},
+	}
+	for i, th := range throttled {
+		if th != want[i] {
+			t.Errorf("throttling #%d should be %+v; got %+v", i, want[i], th)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, _ := newStore(20)
+	bus := eventbus.New()
+
+	r := replay.New(store, bus, replay.BatchSize(5), replay.Rate(200))
+
+	start := time.Now()
+	if _, err := r.Replay(ctx, query.New()); err != nil {
+		t.Fatalf("Replay() failed with %q", err)
+	}
+
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("replaying %d events at %d events/s should take at least %s; took %s", 20, 200, 100*time.Millisecond, d)
+	}
+}
+
+func newStore(n int) (event.Store, []event.Event) {
+	now := time.Now()
+	events := make([]event.Event, n)
+	for i := range events {
+		events[i] = event.New[any]("foo", struct{}{}, event.Time(now.Add(time.Duration(i)*time.Millisecond)))
+	}
+	return eventstore.New(events...), events
+}
+
+func subscribe(t *testing.T, ctx context.Context, bus event.Bus) <-chan event.Event {
+	events, errs, err := bus.Subscribe(ctx, "foo")
+	if err != nil {
+		t.Fatalf("subscribe to events: %v", err)
+	}
+	go func() {
+		for range errs {
+		}
+	}()
+	return events
+}
+
+type queryCtxStore struct {
+	event.Store
+
+	ctx context.Context
+}
+
+func (s *queryCtxStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	s.ctx = ctx
+	return s.Store.Query(ctx, q)
+}
+
+type failingBus struct {
+	event.Bus
+
+	err error
+}
+
+func (bus failingBus) Publish(context.Context, ...event.Event) error {
+	return bus.err
+}
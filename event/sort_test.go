@@ -138,3 +138,49 @@ func TestSortMulti(t *testing.T) {
 		events[3], events[4], events[5],
 	}, sorted)
 }
+
+func TestSortMulti_tieBreak(t *testing.T) {
+	now := xtime.Now()
+	events := []event.Of[test.FooEventData]{
+		event.New("foo", test.FooEventData{}, event.ID(uuid.MustParse("A0000000-0000-0000-0000-000000000000")), event.Time(now)),
+		event.New("foo", test.FooEventData{}, event.ID(uuid.MustParse("B0000000-0000-0000-0000-000000000000")), event.Time(now)),
+		event.New("foo", test.FooEventData{}, event.ID(uuid.MustParse("C0000000-0000-0000-0000-000000000000")), event.Time(now)),
+	}
+
+	for i := 0; i < 10; i++ {
+		shuffled := xevent.Shuffle(events)
+
+		sorted := event.SortMulti(shuffled, event.SortOptions{Sort: event.SortTime, Dir: event.SortAsc})
+		test.AssertEqualEvents(t, events, sorted)
+
+		sorted = event.SortMulti(shuffled, event.SortOptions{Sort: event.SortTime, Dir: event.SortDesc})
+		test.AssertEqualEvents(t, []event.Of[test.FooEventData]{events[2], events[1], events[0]}, sorted)
+
+		sorted = event.SortMulti(shuffled)
+		test.AssertEqualEvents(t, shuffled, sorted)
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	if sorts := event.TieBreak(nil); len(sorts) != 0 {
+		t.Fatalf("TieBreak() should not sort unsorted queries; got %v", sorts)
+	}
+
+	sorts := []event.SortOptions{
+		{Sort: event.SortAggregateName, Dir: event.SortAsc},
+		{Sort: event.SortTime, Dir: event.SortDesc},
+	}
+	got := event.TieBreak(sorts)
+
+	if len(got) != 3 || got[2] != (event.SortOptions{Sort: event.SortID, Dir: event.SortDesc}) {
+		t.Fatalf("TieBreak() should append %v; got %v", event.SortOptions{Sort: event.SortID, Dir: event.SortDesc}, got)
+	}
+
+	if len(sorts) != 2 {
+		t.Fatalf("TieBreak() should not modify the provided sortings")
+	}
+
+	if again := event.TieBreak(got); len(again) != len(got) {
+		t.Fatalf("TieBreak() should not tie-break twice; got %v", again)
+	}
+}
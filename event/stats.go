@@ -0,0 +1,80 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// StoreStats are statistics about the events in an event store, intended for
+// capacity planning and dashboards.
+type StoreStats struct {
+	// Events is the total number of events in the store.
+	Events int
+
+	// EventsPerName is the number of events per event name.
+	EventsPerName map[string]int
+
+	// Aggregates is the number of distinct aggregates that have events in the
+	// store.
+	Aggregates int
+
+	// Oldest is the time of the oldest event in the store, or the zero time if
+	// the store is empty.
+	Oldest time.Time
+
+	// Newest is the time of the newest event in the store, or the zero time if
+	// the store is empty.
+	Newest time.Time
+}
+
+// StatsProvider is implemented by event stores that can efficiently compute
+// statistics about their events.
+type StatsProvider interface {
+	// Stats returns statistics about the events in the store.
+	Stats(context.Context) (StoreStats, error)
+}
+
+// Add adds a single event to the statistics. The number of aggregates is not
+// changed, because a single event does not tell whether its aggregate was
+// already counted.
+func (s *StoreStats) Add(name string, t time.Time) {
+	s.Events++
+	if s.EventsPerName == nil {
+		s.EventsPerName = make(map[string]int)
+	}
+	s.EventsPerName[name]++
+	s.observeTime(t)
+}
+
+// Merge returns the combined statistics of s and other. The aggregate counts
+// are summed up, which is only correct if s and other do not share aggregates,
+// as is the case for shards of the same store.
+func (s StoreStats) Merge(other StoreStats) StoreStats {
+	out := StoreStats{
+		Events:        s.Events + other.Events,
+		EventsPerName: make(map[string]int, len(s.EventsPerName)+len(other.EventsPerName)),
+		Aggregates:    s.Aggregates + other.Aggregates,
+		Oldest:        s.Oldest,
+		Newest:        s.Newest,
+	}
+	for name, n := range s.EventsPerName {
+		out.EventsPerName[name] += n
+	}
+	for name, n := range other.EventsPerName {
+		out.EventsPerName[name] += n
+	}
+	if other.Events > 0 {
+		out.observeTime(other.Oldest)
+		out.observeTime(other.Newest)
+	}
+	return out
+}
+
+func (s *StoreStats) observeTime(t time.Time) {
+	if s.Oldest.IsZero() || t.Before(s.Oldest) {
+		s.Oldest = t
+	}
+	if s.Newest.IsZero() || t.After(s.Newest) {
+		s.Newest = t
+	}
+}
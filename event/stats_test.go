@@ -0,0 +1,40 @@
+package event_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/goes/event"
+)
+
+func TestStoreStats_Merge(t *testing.T) {
+	now := time.Now()
+
+	var a event.StoreStats
+	a.Add("foo", now)
+	a.Add("foo", now.Add(time.Minute))
+	a.Aggregates = 1
+
+	var b event.StoreStats
+	b.Add("foo", now.Add(-time.Hour))
+	b.Add("bar", now.Add(time.Hour))
+	b.Aggregates = 2
+
+	got := a.Merge(b)
+	want := event.StoreStats{
+		Events:        4,
+		EventsPerName: map[string]int{"foo": 3, "bar": 1},
+		Aggregates:    3,
+		Oldest:        now.Add(-time.Hour),
+		Newest:        now.Add(time.Hour),
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Fatalf("unexpected merged stats.\n\n%s", cmp.Diff(want, got))
+	}
+
+	if got := (event.StoreStats{}).Merge(event.StoreStats{}); !got.Oldest.IsZero() || !got.Newest.IsZero() {
+		t.Fatalf("merging empty stats should not set times; got %+v", got)
+	}
+}
@@ -1,7 +1,9 @@
 package event
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 
@@ -28,6 +30,12 @@ const (
 	// SortAggregateVersion is a Sorting option that sorts events based on their
 	// aggregate version, with lower versions coming first.
 	SortAggregateVersion
+
+	// SortID is a Sorting option that sorts events based on the byte order
+	// of their ID, which equals the lexicographical order of the string
+	// representation of their ID. Stores use SortID to break ties
+	// between events that are equal in all other sortings (see TieBreak).
+	SortID
 )
 
 const (
@@ -115,6 +123,55 @@ type Query interface {
 
 // #endregion query
 
+// Paging returns the maximum number of events and the number of skipped events
+// of a query, which are provided using the query.Limit and query.Offset
+// options. A limit of 0 means that the number of events is not limited. The
+// limit and offset are applied after sorting the matching events. Event stores
+// that do not support paging return all matching events.
+func Paging(q Query) (limit, offset int) {
+	if q, ok := q.(interface {
+		Limit() int
+		Offset() int
+	}); ok {
+		return q.Limit(), q.Offset()
+	}
+	return 0, 0
+}
+
+// IncludesDeleted returns whether a query should also return events that were
+// soft-deleted, which is requested using the query.IncludeDeleted option.
+// Event stores that do not support soft deletes ignore this.
+func IncludesDeleted(q Query) bool {
+	if q, ok := q.(interface{ IncludesDeleted() bool }); ok {
+		return q.IncludesDeleted()
+	}
+	return false
+}
+
+// ErrTagsUnsupported is returned by event stores that do not persist tags when
+// they are queried for tags. It wraps errors.ErrUnsupported.
+var ErrTagsUnsupported = fmt.Errorf("event store does not persist tags: %w", errors.ErrUnsupported)
+
+// QueryTags returns the tags that are queried for using the query.Tag option.
+// A query with tags matches the events that have at least one of the tags.
+// Event stores that do not persist tags fail such queries with
+// ErrTagsUnsupported (see RejectTags).
+func QueryTags(q Query) []string {
+	if q, ok := q.(interface{ Tags() []string }); ok {
+		return q.Tags()
+	}
+	return nil
+}
+
+// RejectTags returns ErrTagsUnsupported if q queries for tags. Event stores
+// that do not persist tags call RejectTags before running a query.
+func RejectTags(q Query) error {
+	if len(QueryTags(q)) > 0 {
+		return ErrTagsUnsupported
+	}
+	return nil
+}
+
 // AggregateRef represents a reference to an aggregate with a specific Name and
 // ID. It provides methods to check if it's a zero value, retrieve aggregate
 // information, split the Name and ID, and parse a string into an AggregateRef.
@@ -168,10 +225,35 @@ func CompareSorting[A, B any](s Sorting, a Of[A], b Of[B]) (cmp int8) {
 			av < bv,
 			av == bv,
 		)
+	case SortID:
+		aid, bid := a.ID(), b.ID()
+		return int8(bytes.Compare(aid[:], bid[:]))
 	}
 	return
 }
 
+// TieBreak returns the given sortings with SortID appended as the last
+// sorting, so that events that are equal in all sortings, e.g. events with
+// the same time, are returned in a deterministic order by all stores. The tie
+// is broken in the direction of the last sorting, which allows stores to
+// serve the tie-breaker from the same index as the last sorting in reverse.
+// TieBreak returns unsorted and already tie-broken sortings unchanged.
+func TieBreak(sorts []SortOptions) []SortOptions {
+	if len(sorts) == 0 {
+		return sorts
+	}
+
+	for _, opts := range sorts {
+		if opts.Sort == SortID {
+			return sorts
+		}
+	}
+
+	out := make([]SortOptions, len(sorts), len(sorts)+1)
+	copy(out, sorts)
+	return append(out, SortOptions{Sort: SortID, Dir: sorts[len(sorts)-1].Dir})
+}
+
 // Compare returns the comparison result of two events, a and b, based on the
 // provided Sorting value s. The comparison result is -1 if a < b, 1 if a > b,
 // or 0 if a == b.
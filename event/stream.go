@@ -1,6 +1,10 @@
 package event
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/modernice/goes/helper/streams"
 )
 
@@ -21,3 +25,206 @@ func Filter[D any](events <-chan Of[D], queries ...Query) <-chan Of[D] {
 
 	return streams.Filter(events, filters...)
 }
+
+// ErrEndOfStream is returned by Stream.Next when there are no more events.
+var ErrEndOfStream = errors.New("end of stream")
+
+// Stream is a stream of events that can be re-read without querying the
+// events again. Consumers like debuggers or the aggregate stream grouper can
+// seek to a position or an aggregate version, or rewind the stream to the
+// first event.
+//
+// A Stream is safe for concurrent use, but it has a single read position.
+type Stream interface {
+	// Next returns the event at the current position and advances the
+	// position. Next returns ErrEndOfStream if there are no more events, or
+	// the error of the underlying query.
+	Next(context.Context) (Event, error)
+
+	// Position returns the current position, which is the number of events
+	// that have been read since the start of the stream.
+	Position() int
+
+	// Seek sets the position to pos. Seeking beyond the last event returns
+	// ErrEndOfStream and sets the position to the end of the stream.
+	Seek(ctx context.Context, pos int) error
+
+	// SeekVersion sets the position to the first event (from the start of the
+	// stream) whose aggregate version is at least v. If there is no such event,
+	// SeekVersion returns ErrEndOfStream.
+	SeekVersion(ctx context.Context, v int) error
+
+	// Rewind sets the position to the start of the stream.
+	Rewind()
+
+	// Events returns the events from the current position to the end of the
+	// stream as channels. The position is advanced while the events are read.
+	Events(context.Context) (<-chan Event, <-chan error)
+}
+
+// StreamQuerier is implemented by event stores that can query events as a
+// Stream.
+type StreamQuerier interface {
+	// QueryStream queries events like Store.Query does, but returns the result
+	// as a Stream.
+	QueryStream(context.Context, Query) (Stream, error)
+}
+
+// NewStream returns a Stream that reads the events from the given channels.
+// Read events are buffered, so that the Stream can be re-read after the
+// channels have been drained. Events are read from the channels lazily, when
+// the Stream is read or seeked beyond the buffered events.
+func NewStream(events <-chan Event, errs ...<-chan error) Stream {
+	errChan, stop := streams.FanIn(errs...)
+	return &stream{src: events, errs: errChan, stop: stop}
+}
+
+// StreamOf returns a Stream of the given events.
+func StreamOf(events ...Event) Stream {
+	return &stream{buf: events, done: true}
+}
+
+type stream struct {
+	mux  sync.Mutex
+	buf  []Event
+	pos  int
+	src  <-chan Event
+	errs <-chan error
+	stop func()
+	done bool
+	err  error
+}
+
+func (s *stream) Next(ctx context.Context) (Event, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.fill(ctx, s.pos+1); err != nil {
+		return nil, err
+	}
+
+	evt := s.buf[s.pos]
+	s.pos++
+
+	return evt, nil
+}
+
+func (s *stream) Position() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.pos
+}
+
+func (s *stream) Seek(ctx context.Context, pos int) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if pos < 0 {
+		pos = 0
+	}
+
+	if err := s.fill(ctx, pos); err != nil {
+		s.pos = len(s.buf)
+		return err
+	}
+	s.pos = pos
+
+	return nil
+}
+
+func (s *stream) SeekVersion(ctx context.Context, v int) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for i := 0; ; i++ {
+		if err := s.fill(ctx, i+1); err != nil {
+			s.pos = len(s.buf)
+			return err
+		}
+		if _, _, ev := s.buf[i].Aggregate(); ev >= v {
+			s.pos = i
+			return nil
+		}
+	}
+}
+
+func (s *stream) Rewind() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.pos = 0
+}
+
+func (s *stream) Events(ctx context.Context) (<-chan Event, <-chan error) {
+	out := make(chan Event)
+	errs := make(chan error)
+
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		for {
+			evt, err := s.Next(ctx)
+			if errors.Is(err, ErrEndOfStream) {
+				return
+			}
+
+			if err != nil {
+				select {
+				case <-ctx.Done():
+				case errs <- err:
+				}
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+
+	return out, errs
+}
+
+// fill reads events from the source until at least n events are buffered.
+func (s *stream) fill(ctx context.Context, n int) error {
+	for len(s.buf) < n {
+		if s.done {
+			if s.err != nil {
+				return s.err
+			}
+			return ErrEndOfStream
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-s.errs:
+			if !ok {
+				if s.errs = nil; s.src == nil {
+					s.finish(nil)
+				}
+				break
+			}
+			s.finish(err)
+		case evt, ok := <-s.src:
+			if !ok {
+				if s.src = nil; s.errs == nil {
+					s.finish(nil)
+				}
+				break
+			}
+			s.buf = append(s.buf, evt)
+		}
+	}
+	return nil
+}
+
+func (s *stream) finish(err error) {
+	s.done = true
+	s.err = err
+	if s.stop != nil {
+		s.stop()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/test"
@@ -152,3 +153,94 @@ func makeEvents() []event.Event {
 		event.New[any]("baz", test.BazEventData{}),
 	}
 }
+
+func TestNewStream(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Aggregate(id, "foo", 1)),
+		event.New[any]("bar", test.BarEventData{}, event.Aggregate(id, "foo", 2)),
+		event.New[any]("baz", test.BazEventData{}, event.Aggregate(id, "foo", 3)),
+	}
+
+	str := event.NewStream(streams.New(events))
+
+	first, err := str.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed with %q", err)
+	}
+	if first != events[0] || str.Position() != 1 {
+		t.Fatalf("Next should return the first event and advance the position")
+	}
+
+	remaining, errs := str.Events(ctx)
+	result, err := streams.Drain(ctx, remaining, errs)
+	if err != nil {
+		t.Fatalf("drain stream: %v", err)
+	}
+	test.AssertEqualEvents(t, events[1:], result)
+
+	if _, err := str.Next(ctx); !errors.Is(err, event.ErrEndOfStream) {
+		t.Fatalf("Next should return %q at the end of the stream; got %q", event.ErrEndOfStream, err)
+	}
+
+	str.Rewind()
+	remaining, errs = str.Events(ctx)
+	result, err = streams.Drain(ctx, remaining, errs)
+	if err != nil {
+		t.Fatalf("drain stream: %v", err)
+	}
+	test.AssertEqualEvents(t, events, result)
+
+	if err := str.SeekVersion(ctx, 2); err != nil {
+		t.Fatalf("SeekVersion failed with %q", err)
+	}
+	if evt, _ := str.Next(ctx); evt != events[1] {
+		t.Fatalf("SeekVersion should seek to the event with version %d", 2)
+	}
+
+	if err := str.Seek(ctx, 2); err != nil {
+		t.Fatalf("Seek failed with %q", err)
+	}
+	if evt, _ := str.Next(ctx); evt != events[2] {
+		t.Fatalf("Seek should seek to position %d", 2)
+	}
+
+	if err := str.Seek(ctx, 5); !errors.Is(err, event.ErrEndOfStream) {
+		t.Fatalf("Seek beyond the end should fail with %q; got %q", event.ErrEndOfStream, err)
+	}
+	if str.Position() != 3 {
+		t.Fatalf("Seek beyond the end should set the position to the end; got %d", str.Position())
+	}
+
+	if err := str.SeekVersion(ctx, 4); !errors.Is(err, event.ErrEndOfStream) {
+		t.Fatalf("SeekVersion beyond the last version should fail with %q; got %q", event.ErrEndOfStream, err)
+	}
+}
+
+func TestNewStream_error(t *testing.T) {
+	ctx := context.Background()
+	mockError := errors.New("mock error")
+
+	events := make(chan event.Event, 1)
+	errs := make(chan error, 1)
+	events <- event.New[any]("foo", test.FooEventData{})
+	close(events)
+	errs <- mockError
+	close(errs)
+
+	str := event.NewStream(events, errs)
+
+	if err := str.Seek(ctx, 2); !errors.Is(err, mockError) {
+		t.Fatalf("Seek should fail with %q; got %q", mockError, err)
+	}
+
+	str.Rewind()
+	if _, err := str.Next(ctx); err != nil {
+		t.Fatalf("buffered events should still be readable; got %q", err)
+	}
+
+	if _, err := str.Next(ctx); !errors.Is(err, mockError) {
+		t.Fatalf("Next should fail with %q; got %q", mockError, err)
+	}
+}
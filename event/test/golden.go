@@ -0,0 +1,253 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden
+// (re)write golden files instead of comparing against them:
+//
+//	GOES_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "GOES_UPDATE_GOLDEN"
+
+// Recorder records the events that flow through event stores and event buses
+// during a test run. The recorded events can be written to a golden file using
+// WriteGolden, and later be loaded using LoadGolden to replay realistic event
+// histories in regression tests.
+//
+//	rec := test.NewRecorder()
+//	store := rec.Store(eventstore.New())
+//	bus := rec.Bus(eventbus.New())
+//	// run the test using store and bus
+//	err := rec.WriteGolden("testdata/checkout.golden.json", enc)
+type Recorder struct {
+	mux    sync.Mutex
+	events []event.Event
+	seen   map[uuid.UUID]bool
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{seen: make(map[uuid.UUID]bool)}
+}
+
+// Store returns an event store that records the events that are inserted
+// into the given store.
+func (r *Recorder) Store(store event.Store) event.Store {
+	return &recordingStore{Store: store, rec: r}
+}
+
+// Bus returns an event bus that records the events that are published over
+// the given bus.
+func (r *Recorder) Bus(bus event.Bus) event.Bus {
+	return &recordingBus{Bus: bus, rec: r}
+}
+
+// Record records the given events. Events that have already been recorded
+// are ignored, so an event that is both inserted and published is recorded
+// only once.
+func (r *Recorder) Record(events ...event.Event) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, evt := range events {
+		if r.seen[evt.ID()] {
+			continue
+		}
+		r.seen[evt.ID()] = true
+		r.events = append(r.events, evt)
+	}
+}
+
+// Events returns the recorded events in the order they were recorded.
+func (r *Recorder) Events() []event.Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]event.Event(nil), r.events...)
+}
+
+// WriteGolden writes the recorded events to the golden file at the given path,
+// encoding the event data using the provided encoding.
+func (r *Recorder) WriteGolden(path string, enc codec.Encoding) error {
+	return WriteGolden(path, enc, r.Events()...)
+}
+
+type recordingStore struct {
+	event.Store
+	rec *Recorder
+}
+
+func (s *recordingStore) Insert(ctx context.Context, events ...event.Event) error {
+	if err := s.Store.Insert(ctx, events...); err != nil {
+		return err
+	}
+	s.rec.Record(events...)
+	return nil
+}
+
+type recordingBus struct {
+	event.Bus
+	rec *Recorder
+}
+
+func (b *recordingBus) Publish(ctx context.Context, events ...event.Event) error {
+	if err := b.Bus.Publish(ctx, events...); err != nil {
+		return err
+	}
+	b.rec.Record(events...)
+	return nil
+}
+
+// goldenEvent is the representation of an event in a golden file. Event data
+// that is encoded as JSON is embedded as-is to keep golden files readable and
+// reviewable; other encodings are stored as base64.
+type goldenEvent struct {
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Time             time.Time       `json:"time"`
+	AggregateName    string          `json:"aggregateName,omitempty"`
+	AggregateID      uuid.UUID       `json:"aggregateId,omitempty"`
+	AggregateVersion int             `json:"aggregateVersion,omitempty"`
+	Data             json.RawMessage `json:"data,omitempty"`
+	RawData          []byte          `json:"rawData,omitempty"`
+}
+
+// WriteGolden writes the given events to the golden file at the given path,
+// encoding the event data using the provided encoding. Missing directories
+// are created.
+func WriteGolden(path string, enc codec.Encoding, events ...event.Event) error {
+	out := make([]goldenEvent, len(events))
+	for i, evt := range events {
+		raw, err := event.Raw(enc, evt)
+		if err != nil {
+			return err
+		}
+
+		ge := goldenEvent{
+			ID:               raw.ID,
+			Name:             raw.Name,
+			Time:             raw.Time,
+			AggregateName:    raw.Aggregate.Name,
+			AggregateID:      raw.Aggregate.ID,
+			AggregateVersion: raw.AggregateVersion,
+		}
+
+		if json.Valid(raw.Data) {
+			ge.Data = raw.Data
+		} else {
+			ge.RawData = raw.Data
+		}
+
+		out[i] = ge
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal golden events: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create golden directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write golden file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadGolden loads the events from the golden file at the given path, decoding
+// the event data using the provided encoding. The loaded events can be
+// replayed by inserting them into an event store or publishing them over an
+// event bus.
+func LoadGolden(path string, enc codec.Encoding) ([]event.Event, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read golden file: %w", err)
+	}
+
+	var golden []goldenEvent
+	if err := json.Unmarshal(b, &golden); err != nil {
+		return nil, fmt.Errorf("unmarshal golden file %q: %w", path, err)
+	}
+
+	out := make([]event.Event, len(golden))
+	for i, ge := range golden {
+		raw := event.RawEvent{
+			ID:               ge.ID,
+			Name:             ge.Name,
+			Time:             ge.Time,
+			Aggregate:        event.AggregateRef{Name: ge.AggregateName, ID: ge.AggregateID},
+			AggregateVersion: ge.AggregateVersion,
+			Data:             ge.RawData,
+		}
+		if len(ge.Data) > 0 {
+			raw.Data = ge.Data
+		}
+
+		if out[i], err = raw.Decode(enc); err != nil {
+			return nil, fmt.Errorf("golden event #%d: %w", i, err)
+		}
+	}
+
+	return out, nil
+}
+
+// AssertGolden compares the given events against the events in the golden
+// file at the given path. Events are compared by their names, aggregate names,
+// aggregate versions, and data; ids, times, and aggregate ids are ignored
+// because they usually differ between test runs. If the UpdateGoldenEnv
+// environment variable is set, AssertGolden writes the events to the golden
+// file instead.
+func AssertGolden(t testing.TB, path string, enc codec.Encoding, events []event.Event) {
+	t.Helper()
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := WriteGolden(path, enc, events...); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+
+	golden, err := LoadGolden(path, enc)
+	if err != nil {
+		t.Fatalf("load golden file: %v (set %s=1 to create it)", err, UpdateGoldenEnv)
+	}
+
+	if diff := cmp.Diff(goldenComparable(golden), goldenComparable(events)); diff != "" {
+		t.Fatalf("events don't match golden file %q (-want +got):\n%s\n\nset %s=1 to update the golden file", path, diff, UpdateGoldenEnv)
+	}
+}
+
+type comparableEvent struct {
+	Name             string
+	AggregateName    string
+	AggregateVersion int
+	Data             any
+}
+
+func goldenComparable(events []event.Event) []comparableEvent {
+	out := make([]comparableEvent, len(events))
+	for i, evt := range events {
+		_, name, v := evt.Aggregate()
+		out[i] = comparableEvent{
+			Name:             evt.Name(),
+			AggregateName:    name,
+			AggregateVersion: v,
+			Data:             evt.Data(),
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package test_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/test"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	enc := test.NewEncoder()
+	rec := test.NewRecorder()
+
+	store := rec.Store(eventstore.New())
+	bus := rec.Bus(eventbus.New())
+
+	id := uuid.New()
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(id, "foo", 1)).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Aggregate(id, "foo", 2)).Any(),
+		event.New("foobar", test.FoobarEventData{A: 3}).Any(),
+	}
+
+	if err := store.Insert(ctx, events[:2]...); err != nil {
+		t.Fatalf("Insert() failed with %q", err)
+	}
+
+	if err := bus.Publish(ctx, events...); err != nil {
+		t.Fatalf("Publish() failed with %q", err)
+	}
+
+	test.AssertEqualEvents(t, events, rec.Events())
+
+	path := filepath.Join(t.TempDir(), "testdata", "events.golden.json")
+	if err := rec.WriteGolden(path, enc); err != nil {
+		t.Fatalf("WriteGolden() failed with %q", err)
+	}
+
+	loaded, err := test.LoadGolden(path, enc)
+	if err != nil {
+		t.Fatalf("LoadGolden() failed with %q", err)
+	}
+
+	if len(loaded) != len(events) {
+		t.Fatalf("LoadGolden() should return %d events; got %d", len(events), len(loaded))
+	}
+
+	for i, evt := range loaded {
+		if !evt.Time().Equal(events[i].Time()) {
+			t.Fatalf("event #%d should have time %v; got %v", i, events[i].Time(), evt.Time())
+		}
+		if evt.ID() != events[i].ID() || evt.Data() != events[i].Data() || pickVersion(evt) != pickVersion(events[i]) {
+			t.Fatalf("event #%d should be %v; got %v", i, events[i], evt)
+		}
+	}
+
+	// different ids and times should not make AssertGolden fail
+	test.AssertGolden(t, path, enc, []event.Event{
+		event.New("foo", test.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "foo", 1)).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Aggregate(uuid.New(), "foo", 2)).Any(),
+		event.New("foobar", test.FoobarEventData{A: 3}).Any(),
+	})
+}
+
+func TestAssertGolden_update(t *testing.T) {
+	t.Setenv(test.UpdateGoldenEnv, "1")
+
+	enc := test.NewEncoder()
+	path := filepath.Join(t.TempDir(), "events.golden.json")
+	events := []event.Event{event.New("foo", test.FooEventData{A: "foo"}).Any()}
+
+	test.AssertGolden(t, path, enc, events)
+
+	loaded, err := test.LoadGolden(path, enc)
+	if err != nil {
+		t.Fatalf("LoadGolden() failed with %q", err)
+	}
+
+	if len(loaded) != 1 || loaded[0].ID() != events[0].ID() {
+		t.Fatalf("AssertGolden() should have written the golden file")
+	}
+}
+
+func pickVersion(evt event.Event) int {
+	_, _, v := evt.Aggregate()
+	return v
+}
@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modernice/goes/event"
+)
+
+// Bridge subscribes to the given events over one bus and publishes them,
+// passed through the provided Transformer, over another bus. Bridge returns a
+// channel of asynchronous errors that is closed when ctx is canceled.
+//
+//	var legacy, bus event.Bus
+//	errs, err := transform.Bridge(ctx, legacy, bus, []string{"foo"}, transform.Chain(
+//		transform.Rename("foo", "foo.v2"),
+//		transform.Map("foo.v2", func(data LegacyFoo) (Foo, error) { ... }),
+//	))
+func Bridge(ctx context.Context, from, to event.Bus, names []string, t Transformer) (<-chan error, error) {
+	events, errs, err := from.Subscribe(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %v events: %w", names, err)
+	}
+
+	transformed, transformErrs := Stream(ctx, t, events, errs)
+
+	out := make(chan error)
+	go func() {
+		defer close(out)
+
+		fail := func(err error) {
+			select {
+			case <-ctx.Done():
+			case out <- err:
+			}
+		}
+
+		for transformed != nil || transformErrs != nil {
+			select {
+			case err, ok := <-transformErrs:
+				if !ok {
+					transformErrs = nil
+					break
+				}
+				fail(err)
+			case evt, ok := <-transformed:
+				if !ok {
+					transformed = nil
+					break
+				}
+				if err := to.Publish(ctx, evt); err != nil {
+					fail(fmt.Errorf("publish %q event: %w", evt.Name(), err))
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Store decorates the given event store so that queried events are passed
+// through a Transformer before they are returned. This allows to replay
+// legacy events from a store in their current shape. newTransformer is called
+// for every query, so that stateful transformers are not shared between
+// queries. Inserted events are not transformed.
+func Store(store event.Store, newTransformer func() Transformer) event.Store {
+	return &transformedStore{Store: store, newTransformer: newTransformer}
+}
+
+type transformedStore struct {
+	event.Store
+
+	newTransformer func() Transformer
+}
+
+func (s *transformedStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	events, errs, err := s.Store.Query(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, outErrs := Stream(ctx, s.newTransformer(), events, errs)
+	return out, outErrs, nil
+}
+
+func (s *transformedStore) Find(ctx context.Context, id uuid.UUID) (event.Event, error) {
+	evt, err := s.Store.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := Apply(ctx, s.newTransformer(), evt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, evt := range events {
+		if evt.ID() == id {
+			return evt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("event %s was dropped by the transformer", id)
+}
+
+// Migrate queries the events from one store, passes them through the provided
+// Transformer, and inserts the transformed events into another store. Events
+// are inserted in the order in which they are returned by the Transformer, so
+// the query should sort the events, e.g. by time. Migrate returns the number of
+// inserted events.
+func Migrate(ctx context.Context, from, to event.Store, q event.Query, t Transformer) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := from.Query(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("query events: %w", err)
+	}
+
+	transformed, transformErrs := Stream(ctx, t, events, errs)
+
+	var n int
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		if err := to.Insert(ctx, evt); err != nil {
+			return fmt.Errorf("insert %q event: %w [id=%s]", evt.Name(), err, evt.ID())
+		}
+		n++
+		return nil
+	}, transformed, transformErrs); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
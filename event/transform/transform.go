@@ -0,0 +1,349 @@
+// Package transform provides composable transformation stages for events.
+// Transformers adapt events en route, e.g. to rename events, to enrich their
+// metadata, or to split and merge events, so that legacy event shapes can be
+// adapted when forwarding events from one bus to another, when replaying
+// events from a store, or when migrating events between stores.
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// A Transformer transforms a single event into zero or more events.
+type Transformer interface {
+	// Transform transforms the given event. Returning no events drops the
+	// event; returning multiple events splits the event.
+	Transform(context.Context, event.Event) ([]event.Event, error)
+}
+
+// A Flusher is a Transformer that buffers events. Flush is called when the
+// transformed stream ends, and returns the events that are still buffered.
+type Flusher interface {
+	Transformer
+
+	// Flush returns the buffered events and resets the buffer.
+	Flush(context.Context) ([]event.Event, error)
+}
+
+// Func is a function that implements Transformer.
+type Func func(context.Context, event.Event) ([]event.Event, error)
+
+// Transform calls fn(ctx, evt).
+func (fn Func) Transform(ctx context.Context, evt event.Event) ([]event.Event, error) {
+	return fn(ctx, evt)
+}
+
+// Chain returns a Transformer that passes events through the given
+// transformers in order. Every event that is returned by a transformer is
+// passed to the next transformer. The returned Transformer is a Flusher that
+// flushes the given transformers that are Flushers in order, and passes the
+// flushed events through the remaining transformers.
+func Chain(transformers ...Transformer) Transformer {
+	return chain(transformers)
+}
+
+type chain []Transformer
+
+func (c chain) Transform(ctx context.Context, evt event.Event) ([]event.Event, error) {
+	return c.transformFrom(ctx, 0, []event.Event{evt})
+}
+
+func (c chain) Flush(ctx context.Context) ([]event.Event, error) {
+	var out []event.Event
+	for i, t := range c {
+		f, ok := t.(Flusher)
+		if !ok {
+			continue
+		}
+
+		flushed, err := f.Flush(ctx)
+		if err != nil {
+			return out, err
+		}
+
+		transformed, err := c.transformFrom(ctx, i+1, flushed)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, transformed...)
+	}
+	return out, nil
+}
+
+func (c chain) transformFrom(ctx context.Context, start int, events []event.Event) ([]event.Event, error) {
+	for _, t := range c[start:] {
+		var next []event.Event
+		for _, evt := range events {
+			out, err := t.Transform(ctx, evt)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, out...)
+		}
+		events = next
+	}
+	return events, nil
+}
+
+// Rename returns a Transformer that renames events with the given name. The
+// event data and metadata are kept as-is.
+func Rename(from, to string) Transformer {
+	return Func(func(_ context.Context, evt event.Event) ([]event.Event, error) {
+		if evt.Name() != from {
+			return []event.Event{evt}, nil
+		}
+		return []event.Event{With(evt, to, evt.Data())}, nil
+	})
+}
+
+// Enrich returns a Transformer that applies the event options that are
+// returned by fn to every event. Use Enrich to adjust the metadata of events,
+// e.g. to assign events to an aggregate or to adjust their time.
+func Enrich(fn func(event.Event) []event.Option) Transformer {
+	return Func(func(_ context.Context, evt event.Event) ([]event.Event, error) {
+		opts := fn(evt)
+		if len(opts) == 0 {
+			return []event.Event{evt}, nil
+		}
+		return []event.Event{With(evt, evt.Name(), evt.Data(), opts...)}, nil
+	})
+}
+
+// Map returns a Transformer that replaces the data of events with the given
+// name with the data that is returned by fn.
+func Map[From, To any](name string, fn func(From) (To, error)) Transformer {
+	return Func(func(_ context.Context, evt event.Event) ([]event.Event, error) {
+		if evt.Name() != name {
+			return []event.Event{evt}, nil
+		}
+
+		data, ok := evt.Data().(From)
+		if !ok {
+			return nil, fmt.Errorf("map %q event: data is %T, not %T", name, evt.Data(), data)
+		}
+
+		mapped, err := fn(data)
+		if err != nil {
+			return nil, fmt.Errorf("map %q event: %w", name, err)
+		}
+
+		return []event.Event{With(evt, evt.Name(), any(mapped))}, nil
+	})
+}
+
+// Split returns a Transformer that splits events with the given name into the
+// events that are returned by fn.
+func Split(name string, fn func(event.Event) ([]event.Event, error)) Transformer {
+	return Func(func(_ context.Context, evt event.Event) ([]event.Event, error) {
+		if evt.Name() != name {
+			return []event.Event{evt}, nil
+		}
+
+		out, err := fn(evt)
+		if err != nil {
+			return nil, fmt.Errorf("split %q event: %w", name, err)
+		}
+
+		return out, nil
+	})
+}
+
+// Merge returns a Transformer that merges consecutive events that have one of
+// the given names and belong to the same aggregate into a single event, using
+// the provided merge function. The returned Transformer is a Flusher, because
+// it must buffer events until it knows that no more events can be merged. It
+// is stateful and must not be used for multiple streams concurrently.
+func Merge(names []string, fn func([]event.Event) (event.Event, error)) Transformer {
+	return &merger{names: names, merge: fn}
+}
+
+type merger struct {
+	names  []string
+	merge  func([]event.Event) (event.Event, error)
+	buffer []event.Event
+}
+
+func (m *merger) Transform(ctx context.Context, evt event.Event) ([]event.Event, error) {
+	if !m.matches(evt) {
+		out, err := m.Flush(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return append(out, evt), nil
+	}
+
+	if len(m.buffer) > 0 && aggregateOf(m.buffer[0]) != aggregateOf(evt) {
+		out, err := m.Flush(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.buffer = append(m.buffer, evt)
+		return out, nil
+	}
+
+	m.buffer = append(m.buffer, evt)
+
+	return nil, nil
+}
+
+func (m *merger) Flush(context.Context) ([]event.Event, error) {
+	if len(m.buffer) == 0 {
+		return nil, nil
+	}
+
+	buffered := m.buffer
+	m.buffer = nil
+
+	if len(buffered) == 1 {
+		return buffered, nil
+	}
+
+	merged, err := m.merge(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("merge %d events: %w", len(buffered), err)
+	}
+
+	return []event.Event{merged}, nil
+}
+
+func (m *merger) matches(evt event.Event) bool {
+	for _, name := range m.names {
+		if name == evt.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a copy of the given event with the provided name and data. The
+// id, time, aggregate, and tags of the event are kept, unless overridden by
+// the provided options.
+func With(evt event.Event, name string, data any, opts ...event.Option) event.Event {
+	id, aname, v := evt.Aggregate()
+	return event.New(name, data, append([]event.Option{
+		event.ID(evt.ID()),
+		event.Time(evt.Time()),
+		event.Aggregate(id, aname, v),
+		event.Tag(event.TagsOf(evt)...),
+	}, opts...)...).Any()
+}
+
+// Apply transforms the given events and flushes the Transformer afterwards.
+func Apply(ctx context.Context, t Transformer, events ...event.Event) ([]event.Event, error) {
+	var out []event.Event
+	for _, evt := range events {
+		transformed, err := t.Transform(ctx, evt)
+		if err != nil {
+			return out, fmt.Errorf("transform %q event: %w", evt.Name(), err)
+		}
+		out = append(out, transformed...)
+	}
+
+	flushed, err := flush(ctx, t)
+	if err != nil {
+		return out, err
+	}
+
+	return append(out, flushed...), nil
+}
+
+// Stream transforms the events of the given stream. The returned error
+// channel receives the errors of the provided error channels and the errors
+// that are returned by the Transformer. Both returned channels are closed when
+// the input streams are closed and the Transformer has been flushed, or when
+// ctx is canceled.
+func Stream(ctx context.Context, t Transformer, events <-chan event.Event, errs ...<-chan error) (<-chan event.Event, <-chan error) {
+	out := make(chan event.Event)
+	outErrs := make(chan error)
+
+	inErrs := streams.FanInContext(ctx, errs...)
+
+	go func() {
+		defer close(out)
+		defer close(outErrs)
+
+		push := func(events ...event.Event) bool {
+			for _, evt := range events {
+				select {
+				case <-ctx.Done():
+					return false
+				case out <- evt:
+				}
+			}
+			return true
+		}
+
+		fail := func(err error) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case outErrs <- err:
+				return true
+			}
+		}
+
+		for events != nil || inErrs != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-inErrs:
+				if !ok {
+					inErrs = nil
+					break
+				}
+				if !fail(err) {
+					return
+				}
+			case evt, ok := <-events:
+				if !ok {
+					events = nil
+					break
+				}
+
+				transformed, err := t.Transform(ctx, evt)
+				if err != nil {
+					if !fail(fmt.Errorf("transform %q event: %w", evt.Name(), err)) {
+						return
+					}
+					continue
+				}
+
+				if !push(transformed...) {
+					return
+				}
+			}
+		}
+
+		flushed, err := flush(ctx, t)
+		if err != nil {
+			fail(err)
+			return
+		}
+		push(flushed...)
+	}()
+
+	return out, outErrs
+}
+
+func flush(ctx context.Context, t Transformer) ([]event.Event, error) {
+	f, ok := t.(Flusher)
+	if !ok {
+		return nil, nil
+	}
+
+	events, err := f.Flush(ctx)
+	if err != nil {
+		return events, fmt.Errorf("flush: %w", err)
+	}
+
+	return events, nil
+}
+
+func aggregateOf(evt event.Event) event.AggregateRef {
+	id, name, _ := evt.Aggregate()
+	return event.AggregateRef{Name: name, ID: id}
+}
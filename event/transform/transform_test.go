@@ -0,0 +1,244 @@
+package transform_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/event/transform"
+	"github.com/modernice/goes/helper/streams"
+)
+
+func TestRename(t *testing.T) {
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"}, event.Aggregate(uuid.New(), "foo", 1))
+
+	events, err := transform.Apply(context.Background(), transform.Rename("foo", "bar"), evt)
+	if err != nil {
+		t.Fatalf("Apply failed with %q", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Apply should return %d event; got %d", 1, len(events))
+	}
+
+	want := transform.With(evt, "bar", evt.Data())
+	test.AssertEqualEvents(t, []event.Event{want}, events)
+
+	if events[0].ID() != evt.ID() {
+		t.Fatalf("renamed event should keep the id of the original event")
+	}
+}
+
+func TestRename_tags(t *testing.T) {
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"}, event.Tag("tenant:foo", "bar"))
+
+	events, err := transform.Apply(context.Background(), transform.Rename("foo", "bar"), evt)
+	if err != nil {
+		t.Fatalf("Apply failed with %q", err)
+	}
+
+	if !reflect.DeepEqual(event.TagsOf(events[0]), event.TagsOf(evt)) {
+		t.Fatalf("transformed event should have tags %v; got %v", event.TagsOf(evt), event.TagsOf(events[0]))
+	}
+}
+
+func TestMap(t *testing.T) {
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"})
+
+	events, err := transform.Apply(context.Background(), transform.Map("foo", func(data test.FooEventData) (test.BarEventData, error) {
+		return test.BarEventData{A: data.A + "bar"}, nil
+	}), evt)
+	if err != nil {
+		t.Fatalf("Apply failed with %q", err)
+	}
+
+	if data, ok := events[0].Data().(test.BarEventData); !ok || data.A != "foobar" {
+		t.Fatalf("event data should be mapped to %v; got %v", test.BarEventData{A: "foobar"}, events[0].Data())
+	}
+}
+
+func TestChain_splitAndMerge(t *testing.T) {
+	aggregateID := uuid.New()
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{A: "a,b"}, event.Aggregate(aggregateID, "foo", 1)),
+		event.New[any]("bar", test.BarEventData{A: "c"}, event.Aggregate(aggregateID, "foo", 2)),
+		event.New[any]("baz", test.BazEventData{A: "d"}, event.Aggregate(aggregateID, "foo", 3)),
+		event.New[any]("bar", test.BarEventData{A: "e"}, event.Aggregate(aggregateID, "foo", 4)),
+		event.New[any]("bar", test.BarEventData{A: "f"}, event.Aggregate(aggregateID, "foo", 5)),
+	}
+
+	tf := transform.Chain(
+		transform.Split("foo", func(evt event.Event) ([]event.Event, error) {
+			return []event.Event{
+				event.New[any]("bar", test.BarEventData{A: "a"}).Any(),
+				event.New[any]("bar", test.BarEventData{A: "b"}).Any(),
+			}, nil
+		}),
+		transform.Merge([]string{"bar"}, func(events []event.Event) (event.Event, error) {
+			var merged string
+			for _, evt := range events {
+				merged += evt.Data().(test.BarEventData).A
+			}
+			return event.New[any]("bar", test.BarEventData{A: merged}).Any(), nil
+		}),
+	)
+
+	result, err := transform.Apply(context.Background(), tf, events...)
+	if err != nil {
+		t.Fatalf("Apply failed with %q", err)
+	}
+
+	want := []string{"ab", "c", "d", "ef"}
+	if len(result) != len(want) {
+		t.Fatalf("Apply should return %d events; got %d", len(want), len(result))
+	}
+
+	for i, evt := range result {
+		var got string
+		switch data := evt.Data().(type) {
+		case test.BarEventData:
+			got = data.A
+		case test.BazEventData:
+			got = data.A
+		}
+		if got != want[i] {
+			t.Errorf("event #%d should have data %q; got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Time(time.Now())),
+		event.New[any]("bar", test.BarEventData{}, event.Time(time.Now().Add(time.Second))),
+	}
+	store := transform.Store(eventstore.New(events...), func() transform.Transformer {
+		return transform.Rename("foo", "baz")
+	})
+
+	str, errs, err := store.Query(context.Background(), query.New(query.SortByTime()))
+	if err != nil {
+		t.Fatalf("Query failed with %q", err)
+	}
+
+	result, err := streams.Drain(context.Background(), str, errs)
+	if err != nil {
+		t.Fatalf("drain events: %v", err)
+	}
+
+	if len(result) != 2 || result[0].Name() != "baz" || result[1].Name() != "bar" {
+		t.Fatalf("queried events should be transformed; got %v", result)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	from := eventstore.New(
+		event.New[any]("foo", test.FooEventData{}, event.Time(time.Now())),
+		event.New[any]("bar", test.BarEventData{}, event.Time(time.Now().Add(time.Second))),
+	)
+	to := eventstore.New()
+
+	n, err := transform.Migrate(ctx, from, to, query.New(query.SortByTime()), transform.Rename("foo", "baz"))
+	if err != nil {
+		t.Fatalf("Migrate failed with %q", err)
+	}
+
+	if n != 2 {
+		t.Fatalf("Migrate should insert %d events; inserted %d", 2, n)
+	}
+
+	str, errs, err := to.Query(ctx, query.New(query.Name("baz")))
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+
+	if result, err := streams.Drain(ctx, str, errs); err != nil || len(result) != 1 {
+		t.Fatalf("migrated store should contain the renamed event; got %v (%v)", result, err)
+	}
+}
+
+func TestMigrate_insertError(t *testing.T) {
+	var events []event.Event
+	for i := 0; i < 10; i++ {
+		events = append(events, event.New[any]("foo", test.FooEventData{}))
+	}
+	from := &queryCtxStore{Store: eventstore.New(events...)}
+	mockError := errors.New("mock error")
+
+	if _, err := transform.Migrate(context.Background(), from, failingStore{mockError}, query.New(), transform.Rename("foo", "bar")); !errors.Is(err, mockError) {
+		t.Fatalf("Migrate should fail with %q; got %q", mockError, err)
+	}
+
+	select {
+	case <-from.ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("query of the source store should be canceled if an insert fails")
+	}
+}
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	from := eventbus.New()
+	to := eventbus.New()
+
+	events, _, err := to.Subscribe(ctx, "bar")
+	if err != nil {
+		t.Fatalf("subscribe to events: %v", err)
+	}
+
+	errs, err := transform.Bridge(ctx, from, to, []string{"foo"}, transform.Rename("foo", "bar"))
+	if err != nil {
+		t.Fatalf("Bridge failed with %q", err)
+	}
+
+	evt := event.New[any]("foo", test.FooEventData{A: "foo"})
+	if err := from.Publish(ctx, evt); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("timed out")
+	case err := <-errs:
+		t.Fatalf("bridge failed with %q", err)
+	case received := <-events:
+		if received.ID() != evt.ID() || received.Name() != "bar" {
+			t.Fatalf("bridged event should be renamed to %q; got %q", "bar", received.Name())
+		}
+	}
+}
+
+type queryCtxStore struct {
+	event.Store
+	ctx context.Context
+}
+
+func (s *queryCtxStore) Query(ctx context.Context, q event.Query) (<-chan event.Event, <-chan error, error) {
+	s.ctx = ctx
+	return s.Store.Query(ctx, q)
+}
+
+type failingStore struct {
+	err error
+}
+
+func (s failingStore) Insert(context.Context, ...event.Event) error { return s.err }
+
+func (s failingStore) Find(context.Context, uuid.UUID) (event.Event, error) { return nil, s.err }
+
+func (s failingStore) Query(context.Context, event.Query) (<-chan event.Event, <-chan error, error) {
+	return nil, nil, s.err
+}
+
+func (s failingStore) Delete(context.Context, ...event.Event) error { return s.err }
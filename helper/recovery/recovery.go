@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/modernice/goes/event"
+)
+
+// PanicError is the error that is returned when a panic is recovered. It
+// provides the recovered value, the stack trace of the panicking goroutine,
+// and the event or command (and its aggregate) that was being processed when
+// the panic occurred.
+type PanicError struct {
+	// Value is the value that was passed to panic().
+	Value any
+
+	// Event is the name of the event that was being applied, if any.
+	Event string
+
+	// Command is the name of the command that was being handled, if any.
+	Command string
+
+	// Aggregate is the aggregate that the event or command belongs to, if any.
+	Aggregate event.AggregateRef
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns a description of the panic, including the event or command
+// and the aggregate that were being processed. The stack trace is not
+// included; use the Stack field to access it.
+func (err *PanicError) Error() string {
+	var b strings.Builder
+	b.WriteString("recovered from panic")
+
+	var details []string
+	if err.Event != "" {
+		details = append(details, fmt.Sprintf("event=%s", err.Event))
+	}
+	if err.Command != "" {
+		details = append(details, fmt.Sprintf("command=%s", err.Command))
+	}
+	if !err.Aggregate.IsZero() {
+		details = append(details, fmt.Sprintf("aggregate=%s", err.Aggregate))
+	}
+	if len(details) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(details, ", "))
+	}
+
+	fmt.Fprintf(&b, ": %v", err.Value)
+
+	return b.String()
+}
+
+// Unwrap returns the recovered value if it is an error.
+func (err *PanicError) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// Recover calls fn and returns a *PanicError if fn panics. If fn returns
+// normally, Recover returns nil.
+func Recover(fn func()) error {
+	return recoverWith(&PanicError{}, fn)
+}
+
+// Event calls fn and returns a *PanicError that references the given event
+// and its aggregate if fn panics. If fn returns normally, Event returns nil.
+func Event(evt event.Event, fn func()) error {
+	id, name, _ := evt.Aggregate()
+	return recoverWith(&PanicError{
+		Event:     evt.Name(),
+		Aggregate: event.AggregateRef{Name: name, ID: id},
+	}, fn)
+}
+
+// Command calls fn and returns a *PanicError that references the given
+// command and its aggregate if fn panics. If fn returns normally, Command
+// returns nil.
+func Command(name string, aggregate event.AggregateRef, fn func()) error {
+	return recoverWith(&PanicError{
+		Command:   name,
+		Aggregate: aggregate,
+	}, fn)
+}
+
+func recoverWith(perr *PanicError, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr.Value = r
+			perr.Stack = debug.Stack()
+			err = perr
+		}
+	}()
+	fn()
+	return nil
+}
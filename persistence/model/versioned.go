@@ -0,0 +1,45 @@
+package model
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStale is returned by versioned saves when the repository already
+// contains the model with the same or a newer version.
+var ErrStale = errors.New("stale model")
+
+// Versioned is a model that is projected from the events of an aggregate and
+// knows the version of the aggregate it was projected from.
+type Versioned interface {
+	// ModelVersion returns the version of the aggregate that the model was
+	// projected from.
+	ModelVersion() int
+}
+
+// VersionedSaver is a repository that supports versioned saves. Versioned
+// saves make writes of read-models safe against out-of-order and stale
+// writes, e.g. when a projection is rebuilt while live events are processed:
+// a model is only saved if the repository does not already contain a model
+// with the same or a newer version.
+type VersionedSaver[M Model[IDT], IDT ID] interface {
+	// SaveVersioned saves the given model if the stored model has a lower
+	// version than the given model, or if the model does not exist. Otherwise,
+	// an error that unwraps to ErrStale is returned. The model must implement
+	// Versioned.
+	SaveVersioned(ctx context.Context, m M) error
+}
+
+// IgnoreStale returns nil if err unwraps to ErrStale. Otherwise, err is
+// returned. Projections that may write older versions of their read-models
+// (e.g. during rebuilds) can use IgnoreStale to skip rejected writes:
+//
+//	if err := model.IgnoreStale(repo.SaveVersioned(ctx, m)); err != nil {
+//		return err
+//	}
+func IgnoreStale(err error) error {
+	if errors.Is(err, ErrStale) {
+		return nil
+	}
+	return err
+}
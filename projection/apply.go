@@ -1,10 +1,12 @@
 package projection
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/recovery"
 	"github.com/modernice/goes/helper/streams"
 )
 
@@ -13,6 +15,19 @@ type ApplyOption func(*applyConfig)
 
 type applyConfig struct {
 	ignoreProgress bool
+	recoverPanics  bool
+	notify         *notifyConfig
+	applied        func(event.Event)
+	quarantine     *quarantineConfig
+	dryRun         *DryRunReport
+	ctx            context.Context
+}
+
+func (cfg applyConfig) context() context.Context {
+	if cfg.ctx != nil {
+		return cfg.ctx
+	}
+	return context.Background()
 }
 
 // IgnoreProgress returns an ApplyOption that makes Apply ignore the current
@@ -24,6 +39,28 @@ func IgnoreProgress() ApplyOption {
 	}
 }
 
+// RecoverPanics returns an ApplyOption that recovers from panics that occur
+// while applying an event to a projection. When a panic is recovered, the
+// remaining events are not applied, and the progress of the projection is
+// only updated up to the last successfully applied event. Apply, ApplyStream
+// and Job.Apply return the recovered panic as a *recovery.PanicError, which
+// provides the panic value and the stack trace of the panic. Projection
+// schedules report the error on their error channel.
+func RecoverPanics() ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.recoverPanics = true
+	}
+}
+
+// OnApplied returns an ApplyOption that calls fn for every event that has
+// been applied to a projection. Events that are skipped by the Guard or the
+// progress of the projection are not passed to fn.
+func OnApplied(fn func(event.Event)) ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.onApplied(fn)
+	}
+}
+
 // Apply applies events to the given projection.
 //
 // If the projection implements Guard, proj.GuardProjection(evt) is called for
@@ -31,8 +68,10 @@ func IgnoreProgress() ApplyOption {
 //
 // If the projection implements ProgressAware, the time of the last applied
 // event is applied to the projection by calling proj.SetProgress(evt).
-func Apply(proj Target[any], events []event.Event, opts ...ApplyOption) {
-	ApplyStream(proj, streams.New(events), opts...)
+//
+// Apply only returns an error if a panic was recovered (see RecoverPanics).
+func Apply(proj Target[any], events []event.Event, opts ...ApplyOption) error {
+	return ApplyStream(proj, streams.New(events), opts...)
 }
 
 // ApplyStream applies events to the given projection.
@@ -42,14 +81,22 @@ func Apply(proj Target[any], events []event.Event, opts ...ApplyOption) {
 //
 // If the projection implements ProgressAware, the time of the last applied
 // event is applied to the projection by calling proj.SetProgress(evt).
-func ApplyStream(target Target[any], events <-chan event.Event, opts ...ApplyOption) {
-	cfg := newApplyConfig(opts...)
+//
+// ApplyStream only returns an error if a panic was recovered (see
+// RecoverPanics). The remaining events of the stream are drained in that case.
+func ApplyStream(target Target[any], events <-chan event.Event, opts ...ApplyOption) error {
+	return applyStream(target, events, newApplyConfig(opts...))
+}
+
+func applyStream(target Target[any], events <-chan event.Event, cfg applyConfig) error {
+	var panicErr error
 
 	progressor, isProgressor := target.(ProgressAware)
 	guard, hasGuard := target.(Guard)
 
 	var lastEventTime time.Time
 	var lastEvents []uuid.UUID
+L:
 	for evt := range events {
 		if hasGuard && !guard.GuardProjection(evt) {
 			continue
@@ -59,7 +106,25 @@ func ApplyStream(target Target[any], events <-chan event.Event, opts ...ApplyOpt
 			continue
 		}
 
-		target.ApplyEvent(evt)
+		var skipped bool
+		switch {
+		case cfg.quarantine != nil:
+			if skipped, panicErr = cfg.quarantine.apply(cfg.context(), target, evt); panicErr != nil {
+				break L
+			}
+		case cfg.recoverPanics:
+			if panicErr = recovery.Event(evt, func() { target.ApplyEvent(evt) }); panicErr != nil {
+				break L
+			}
+		default:
+			target.ApplyEvent(evt)
+		}
+
+		if !skipped {
+			if cfg.applied != nil {
+				cfg.applied(evt)
+			}
+		}
 
 		// Avoid unnecessary computations.
 		if !isProgressor {
@@ -79,6 +144,27 @@ func ApplyStream(target Target[any], events <-chan event.Event, opts ...ApplyOpt
 	if isProgressor && !lastEventTime.IsZero() {
 		progressor.SetProgress(lastEventTime, lastEvents...)
 	}
+
+	if panicErr != nil {
+		// Drain the stream so that the sender is not blocked forever.
+		for range events {
+		}
+	}
+
+	return panicErr
+}
+
+// onApplied adds fn to the functions that are called for every applied event.
+func (cfg *applyConfig) onApplied(fn func(event.Event)) {
+	prev := cfg.applied
+	if prev == nil {
+		cfg.applied = fn
+		return
+	}
+	cfg.applied = func(evt event.Event) {
+		prev(evt)
+		fn(evt)
+	}
 }
 
 func newApplyConfig(opts ...ApplyOption) applyConfig {
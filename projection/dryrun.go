@@ -0,0 +1,102 @@
+package projection
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+)
+
+// ErrNotCloneable is returned by Job.Apply if the DryRun option is used for a
+// projection that does not implement Cloner.
+var ErrNotCloneable = errors.New("projection does not implement Cloner")
+
+// A Cloner is a projection that can be cloned for dry runs. CloneProjection
+// must return a new projection with a copy of the current state (including the
+// progress) that does not share mutable state with the original projection.
+// Because event appliers that are registered using event.ApplyWith are bound
+// to the projection they were registered on, the clone should be created
+// using the projection's constructor:
+//
+//	func (p *Orders) CloneProjection() projection.Target[any] {
+//		clone := NewOrders()
+//		progress, ids := p.Progress()
+//		clone.SetProgress(progress, ids...)
+//		for id, order := range p.orders {
+//			clone.orders[id] = order
+//		}
+//		return clone
+//	}
+type Cloner interface {
+	CloneProjection() Target[any]
+}
+
+// DryRunReport reports what applying a projection job to a projection would
+// change. A DryRunReport is filled by Job.Apply when the DryRun option is used.
+type DryRunReport struct {
+	// Applied are the events that would have been applied, in the order they
+	// were applied to the clone.
+	Applied []event.Event
+
+	// ProgressBefore and ProgressAfter are the progress times of the
+	// projection before and after applying the job, if the projection is
+	// ProgressAware.
+	ProgressBefore time.Time
+	ProgressAfter  time.Time
+
+	// LastEvents are the ids of the last applied events after applying the
+	// job, if the projection is ProgressAware.
+	LastEvents []uuid.UUID
+
+	// Projection is the clone of the projection with the resulting state.
+	Projection Target[any]
+}
+
+// DryRun returns an ApplyOption that applies a projection job to a clone of
+// the projection instead of the projection itself, and reports what would
+// change into the provided report. The projection must implement Cloner.
+// Dry runs can be used to validate new projection code against the
+// production event history without modifying the actual projection.
+//
+// During a dry run, NotifyUpdates does not publish events, and
+// QuarantineFailures only recovers from panics without quarantining events.
+//
+//	var report projection.DryRunReport
+//	err := job.Apply(job, proj, projection.DryRun(&report))
+//	log.Printf("%d events would be applied", len(report.Applied))
+//
+// DryRun only has an effect when passed to Job.Apply.
+func DryRun(report *DryRunReport) ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.dryRun = report
+	}
+}
+
+func (r *DryRunReport) prepare(target Target[any]) (Target[any], error) {
+	cloner, ok := target.(Cloner)
+	if !ok {
+		return nil, fmt.Errorf("dry run %T: %w", target, ErrNotCloneable)
+	}
+
+	*r = DryRunReport{}
+	if progressor, ok := target.(ProgressAware); ok {
+		r.ProgressBefore, _ = progressor.Progress()
+	}
+
+	return cloner.CloneProjection(), nil
+}
+
+func (r *DryRunReport) applied(evt event.Event) {
+	r.Applied = append(r.Applied, evt)
+}
+
+func (r *DryRunReport) finish(clone Target[any]) {
+	r.Projection = clone
+	if progressor, ok := clone.(ProgressAware); ok {
+		var ids []uuid.UUID
+		r.ProgressAfter, ids = progressor.Progress()
+		r.LastEvents = append([]uuid.UUID(nil), ids...)
+	}
+}
@@ -0,0 +1,103 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/projection"
+)
+
+type cloneableList struct {
+	*projection.Base
+	*projection.Progressor
+
+	items []string
+}
+
+func newCloneableList() *cloneableList {
+	l := &cloneableList{
+		Base:       projection.New(),
+		Progressor: projection.NewProgressor(),
+	}
+	event.ApplyWith(l, func(evt event.Of[string]) { l.items = append(l.items, evt.Data()) }, "foo")
+	return l
+}
+
+func (l *cloneableList) CloneProjection() projection.Target[any] {
+	clone := newCloneableList()
+	progress, ids := l.Progress()
+	clone.SetProgress(progress, ids...)
+	clone.items = append(clone.items, l.items...)
+	return clone
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+
+	now := time.Now()
+	events := []event.Event{
+		event.New("foo", "a", event.Time(now)).Any(),
+		event.New("foo", "b", event.Time(now.Add(time.Second))).Any(),
+	}
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+
+	proj := newCloneableList()
+	projection.Apply(proj, events[:1])
+
+	bus := eventbus.New()
+	updates, _, err := bus.Subscribe(ctx, projection.ReadModelUpdated)
+	if err != nil {
+		t.Fatalf("subscribe to %q events: %v", projection.ReadModelUpdated, err)
+	}
+
+	var report projection.DryRunReport
+	job := projection.NewJob(ctx, store, query.New(query.SortByTime()))
+	if err := job.Apply(job, proj, projection.DryRun(&report), projection.NotifyUpdates(bus, "list")); err != nil {
+		t.Fatalf("Apply() failed with %q", err)
+	}
+
+	if len(proj.items) != 1 {
+		t.Fatalf("dry run should not modify the projection; got items %v", proj.items)
+	}
+
+	if progress, _ := proj.Progress(); !progress.Equal(events[0].Time()) {
+		t.Fatalf("dry run should not modify the progress of the projection; got %v", progress)
+	}
+
+	if len(report.Applied) != 1 || report.Applied[0].ID() != events[1].ID() {
+		t.Fatalf("report should contain the %q event; got %v", "b", report.Applied)
+	}
+
+	if !report.ProgressBefore.Equal(events[0].Time()) || !report.ProgressAfter.Equal(events[1].Time()) {
+		t.Fatalf("report should contain the progress before and after the dry run; got %v and %v", report.ProgressBefore, report.ProgressAfter)
+	}
+
+	if clone := report.Projection.(*cloneableList); len(clone.items) != 2 {
+		t.Fatalf("report should contain the resulting projection; got items %v", clone.items)
+	}
+
+	select {
+	case <-updates:
+		t.Fatalf("dry run should not publish %q events", projection.ReadModelUpdated)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDryRun_notCloneable(t *testing.T) {
+	ctx := context.Background()
+
+	var report projection.DryRunReport
+	job := projection.NewJob(ctx, eventstore.New(), query.New())
+	if err := job.Apply(job, projection.New(), projection.DryRun(&report)); !errors.Is(err, projection.ErrNotCloneable) {
+		t.Fatalf("Apply() should fail with %q; got %q", projection.ErrNotCloneable, err)
+	}
+}
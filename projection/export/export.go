@@ -0,0 +1,333 @@
+// Package export provides a projection that exports events to data warehouses
+// and object storages for analytics. Events are batched into JSONL files that
+// are partitioned by date and event name, e.g.
+//
+//	events/date=2023-09-01/event=shop.order.placed/<id>.jsonl
+//
+// Files are written by a Writer, which can upload the files to GCS or S3, or
+// load them into BigQuery. Every file carries the Schema of its rows, which is
+// derived from the codec registry.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/projection"
+)
+
+// DefaultBatchSize is the default maximum number of rows per exported file.
+const DefaultBatchSize = 1000
+
+// Row is an exported event.
+type Row struct {
+	EventID          string          `json:"event_id"`
+	EventName        string          `json:"event_name"`
+	EventTime        time.Time       `json:"event_time"`
+	AggregateName    string          `json:"aggregate_name,omitempty"`
+	AggregateID      string          `json:"aggregate_id,omitempty"`
+	AggregateVersion int             `json:"aggregate_version,omitempty"`
+	Data             json.RawMessage `json:"data"`
+}
+
+// Partition is the partition of an exported file.
+type Partition struct {
+	// Date is the day of the exported events, in UTC.
+	Date time.Time
+
+	// Event is the name of the exported events.
+	Event string
+}
+
+// Path returns the path of the partition, relative to the export prefix.
+func (p Partition) Path() string {
+	return path.Join("date="+p.Date.Format("2006-01-02"), "event="+p.Event)
+}
+
+// File is a batch of exported events.
+type File struct {
+	// Path is the path of the file. The path is derived from the partition and
+	// the id of the first event in the file, so that re-exporting the same
+	// events overwrites the file instead of creating a duplicate.
+	Path      string
+	Partition Partition
+	Schema    Schema
+
+	// Data contains the rows of the file in JSONL format.
+	Data []byte
+
+	// Rows is the number of rows in the file.
+	Rows int
+}
+
+// Writer writes exported files.
+type Writer interface {
+	Write(context.Context, File) error
+}
+
+// WriterFunc allows a function to be used as a Writer.
+type WriterFunc func(context.Context, File) error
+
+// Write calls fn(ctx, f).
+func (fn WriterFunc) Write(ctx context.Context, f File) error {
+	return fn(ctx, f)
+}
+
+// Dir returns a Writer that writes the exported files to the given directory.
+func Dir(dir string) Writer {
+	return WriterFunc(func(_ context.Context, f File) error {
+		p := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		return os.WriteFile(p, f.Data, 0o644)
+	})
+}
+
+// Exporter is a projection that exports the events it is applied to. Events
+// are buffered per partition until Flush is called. ApplyJob applies a
+// projection job and flushes the buffered events afterwards:
+//
+//	var reg *codec.Registry
+//	var s projection.Schedule
+//	exp := export.New(reg, export.Dir("/var/export"))
+//	errs, err := s.Subscribe(context.TODO(), exp.ApplyJob)
+//
+// Exporter implements projection.ProgressAware, so that events are exported
+// only once per process. Buffered events that cannot be written are kept and
+// retried on the next flush. If the data of an event cannot be encoded, the
+// event and the events after it are not exported, and the progress of the
+// Exporter is only updated up to the event before it, so that the event is
+// exported again by the next job.
+type Exporter struct {
+	*projection.Progressor
+
+	reg       *codec.Registry
+	writer    Writer
+	prefix    string
+	batchSize int
+
+	mux     sync.Mutex
+	buffers map[Partition][]Row
+	schemas map[string]Schema
+	err     error
+
+	// time and ids of the last buffered events, used as the progress of the
+	// Exporter if an event cannot be encoded
+	lastTime time.Time
+	lastIDs  []uuid.UUID
+}
+
+// Option is an option for an Exporter.
+type Option func(*Exporter)
+
+// Prefix returns an Option that prefixes the paths of exported files with the
+// given prefix.
+func Prefix(prefix string) Option {
+	return func(e *Exporter) {
+		e.prefix = prefix
+	}
+}
+
+// BatchSize returns an Option that configures the maximum number of rows per
+// exported file. Default is DefaultBatchSize.
+func BatchSize(size int) Option {
+	return func(e *Exporter) {
+		e.batchSize = size
+	}
+}
+
+// New returns an Exporter that exports events using the given Writer. The
+// provided registry is used to encode the event data and to derive the schema
+// of the exported files.
+func New(reg *codec.Registry, w Writer, opts ...Option) *Exporter {
+	e := &Exporter{
+		Progressor: projection.NewProgressor(),
+		reg:        reg,
+		writer:     w,
+		batchSize:  DefaultBatchSize,
+		buffers:    make(map[Partition][]Row),
+		schemas:    make(map[string]Schema),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultBatchSize
+	}
+	return e
+}
+
+// ApplyJob applies the given projection job to the Exporter and flushes the
+// buffered events.
+func (e *Exporter) ApplyJob(ctx projection.Job) error {
+	if err := ctx.Apply(ctx, e); err != nil {
+		return fmt.Errorf("apply job: %w", err)
+	}
+	return e.Flush(ctx)
+}
+
+// ApplyEvent buffers the given event. If the event data cannot be encoded as
+// JSON, the error is returned by the next call to Flush, and further events
+// are ignored until then.
+func (e *Exporter) ApplyEvent(evt event.Event) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.err != nil {
+		return
+	}
+
+	data, err := e.reg.Marshal(evt.Data())
+	if err != nil {
+		e.err = fmt.Errorf("encode %q event data: %w", evt.Name(), err)
+		return
+	}
+
+	if !json.Valid(data) {
+		e.err = fmt.Errorf("encode %q event data: data is not encoded as JSON", evt.Name())
+		return
+	}
+
+	id, name, v := evt.Aggregate()
+	row := Row{
+		EventID:          evt.ID().String(),
+		EventName:        evt.Name(),
+		EventTime:        evt.Time(),
+		AggregateName:    name,
+		AggregateVersion: v,
+		Data:             data,
+	}
+	if name != "" {
+		row.AggregateID = id.String()
+	}
+
+	p := Partition{
+		Date:  evt.Time().UTC().Truncate(24 * time.Hour),
+		Event: evt.Name(),
+	}
+	e.buffers[p] = append(e.buffers[p], row)
+
+	if !e.lastTime.Equal(evt.Time()) {
+		e.lastTime = evt.Time()
+		e.lastIDs = e.lastIDs[:0]
+	}
+	e.lastIDs = append(e.lastIDs, evt.ID())
+}
+
+// SetProgress implements projection.ProgressAware. If an event could not be
+// encoded since the last Flush, the progress is only updated up to the last
+// buffered event, so that the failed event is applied again by the next job.
+func (e *Exporter) SetProgress(t time.Time, ids ...uuid.UUID) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.err == nil {
+		e.Progressor.SetProgress(t, ids...)
+		return
+	}
+
+	if !e.lastTime.IsZero() {
+		e.Progressor.SetProgress(e.lastTime, e.lastIDs...)
+	}
+}
+
+// Flush writes the buffered events to the Writer.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.lastTime, e.lastIDs = time.Time{}, nil
+
+	if err := e.err; err != nil {
+		e.err = nil
+		return err
+	}
+
+	partitions := make([]Partition, 0, len(e.buffers))
+	for p := range e.buffers {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if !partitions[i].Date.Equal(partitions[j].Date) {
+			return partitions[i].Date.Before(partitions[j].Date)
+		}
+		return partitions[i].Event < partitions[j].Event
+	})
+
+	for _, p := range partitions {
+		schema, err := e.schema(p.Event)
+		if err != nil {
+			return err
+		}
+
+		rows := e.buffers[p]
+		for len(rows) > 0 {
+			n := e.batchSize
+			if n > len(rows) {
+				n = len(rows)
+			}
+
+			f, err := e.file(p, schema, rows[:n])
+			if err != nil {
+				return err
+			}
+
+			if err := e.writer.Write(ctx, f); err != nil {
+				return fmt.Errorf("write %q: %w", f.Path, err)
+			}
+
+			rows = rows[n:]
+			if len(rows) == 0 {
+				delete(e.buffers, p)
+			} else {
+				e.buffers[p] = rows
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) schema(name string) (Schema, error) {
+	if s, ok := e.schemas[name]; ok {
+		return s, nil
+	}
+
+	s, err := SchemaOf(e.reg, name)
+	if err != nil {
+		return s, fmt.Errorf("schema of %q event: %w", name, err)
+	}
+	e.schemas[name] = s
+
+	return s, nil
+}
+
+func (e *Exporter) file(p Partition, schema Schema, rows []Row) (File, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return File{}, fmt.Errorf("encode %q event: %w", row.EventName, err)
+		}
+	}
+
+	return File{
+		Path:      path.Join(e.prefix, p.Path(), rows[0].EventID+".jsonl"),
+		Partition: p,
+		Schema:    schema,
+		Data:      buf.Bytes(),
+		Rows:      len(rows),
+	}, nil
+}
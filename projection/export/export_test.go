@@ -0,0 +1,217 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/projection"
+	"github.com/modernice/goes/projection/export"
+)
+
+func TestExporter_ApplyJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	day := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo-1"}, event.Time(day), event.Aggregate(uuid.New(), "foobar", 1)).Any(),
+		event.New("foo", test.FooEventData{A: "foo-2"}, event.Time(day.Add(time.Minute))).Any(),
+		event.New("foo", test.FooEventData{A: "foo-3"}, event.Time(day.Add(2*time.Minute))).Any(),
+		event.New("bar", test.BarEventData{A: "bar"}, event.Time(day.Add(24*time.Hour))).Any(),
+	}
+
+	store := eventstore.New(events...)
+	job := projection.NewJob(ctx, store, query.New(query.SortByTime()))
+
+	var files []export.File
+	exp := export.New(test.NewEncoder(), export.WriterFunc(func(_ context.Context, f export.File) error {
+		files = append(files, f)
+		return nil
+	}), export.Prefix("events"), export.BatchSize(2))
+
+	if err := exp.ApplyJob(job); err != nil {
+		t.Fatalf("ApplyJob failed with %q", err)
+	}
+
+	wantPaths := []string{
+		"events/date=2023-09-01/event=foo/" + events[0].ID().String() + ".jsonl",
+		"events/date=2023-09-01/event=foo/" + events[2].ID().String() + ".jsonl",
+		"events/date=2023-09-02/event=bar/" + events[3].ID().String() + ".jsonl",
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	if !cmp.Equal(wantPaths, paths) {
+		t.Fatalf("unexpected exported files\n%s", cmp.Diff(wantPaths, paths))
+	}
+
+	rows := readRows(t, files[0].Data)
+	if len(rows) != 2 || files[0].Rows != 2 {
+		t.Fatalf("first file should contain %d rows; got %d", 2, len(rows))
+	}
+
+	if rows[0].EventID != events[0].ID().String() {
+		t.Fatalf("first row should be the %q event; got %q", events[0].ID(), rows[0].EventID)
+	}
+
+	var data test.FooEventData
+	if err := json.Unmarshal(rows[1].Data, &data); err != nil {
+		t.Fatalf("unmarshal row data: %v", err)
+	}
+
+	if data.A != "foo-2" {
+		t.Fatalf("row data should be %q; got %q", "foo-2", data.A)
+	}
+
+	if files[2].Schema.Event != "bar" {
+		t.Fatalf("file should carry the schema of the %q event; got %q", "bar", files[2].Schema.Event)
+	}
+
+	files = nil
+	if err := exp.ApplyJob(projection.NewJob(ctx, store, query.New(query.SortByTime()))); err != nil {
+		t.Fatalf("ApplyJob failed with %q", err)
+	}
+
+	if len(files) != 0 {
+		t.Fatalf("events should not be exported twice; got %d files", len(files))
+	}
+}
+
+func TestExporter_ApplyJob_encodeError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	day := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	events := []event.Event{
+		event.New("foo", test.FooEventData{A: "foo-1"}, event.Time(day)).Any(),
+		event.New("flaky", flakyData{}, event.Time(day.Add(time.Minute))).Any(),
+		event.New("foo", test.FooEventData{A: "foo-2"}, event.Time(day.Add(2*time.Minute))).Any(),
+	}
+
+	reg := test.NewEncoder()
+	codec.Register[flakyData](reg, "flaky")
+
+	store := eventstore.New(events...)
+
+	exported := make(map[string]int)
+	exp := export.New(reg, export.WriterFunc(func(_ context.Context, f export.File) error {
+		for _, row := range readRows(t, f.Data) {
+			exported[row.EventID]++
+		}
+		return nil
+	}))
+
+	failEncoding = true
+	if err := exp.ApplyJob(projection.NewJob(ctx, store, query.New(query.SortByTime()))); err == nil {
+		t.Fatalf("ApplyJob should fail if an event cannot be encoded")
+	}
+
+	if progress, _ := exp.Progress(); !progress.Equal(events[0].Time()) {
+		t.Fatalf("progress should be the time of the last exported event (%v); got %v", events[0].Time(), progress)
+	}
+
+	failEncoding = false
+	if err := exp.ApplyJob(projection.NewJob(ctx, store, query.New(query.SortByTime()))); err != nil {
+		t.Fatalf("ApplyJob failed with %q", err)
+	}
+
+	for _, evt := range events {
+		if n := exported[evt.ID().String()]; n != 1 {
+			t.Fatalf("%q event should be exported once; exported %d times", evt.ID(), n)
+		}
+	}
+}
+
+var failEncoding bool
+
+type flakyData struct{}
+
+func (flakyData) MarshalJSON() ([]byte, error) {
+	if failEncoding {
+		return nil, errors.New("mock error")
+	}
+	return []byte("{}"), nil
+}
+
+func TestSchemaOf(t *testing.T) {
+	type nested struct {
+		Tags []string
+		At   time.Time
+	}
+
+	type data struct {
+		ID      uuid.UUID `json:"id"`
+		Name    string    `json:"name,omitempty"`
+		Count   int
+		Ratio   float64
+		Enabled bool
+		Raw     []byte
+		Nested  nested
+		Items   []nested
+		Meta    map[string]any
+		Ignored string `json:"-"`
+		private string
+	}
+
+	reg := codec.New()
+	codec.Register[data](reg, "foo")
+
+	s, err := export.SchemaOf(reg, "foo")
+	if err != nil {
+		t.Fatalf("SchemaOf failed with %q", err)
+	}
+
+	nestedFields := []export.Field{
+		{Name: "Tags", Type: export.String, Repeated: true},
+		{Name: "At", Type: export.Timestamp},
+	}
+
+	want := export.Field{
+		Name: "data",
+		Type: export.Record,
+		Fields: []export.Field{
+			{Name: "id", Type: export.String},
+			{Name: "name", Type: export.String},
+			{Name: "Count", Type: export.Integer},
+			{Name: "Ratio", Type: export.Float},
+			{Name: "Enabled", Type: export.Boolean},
+			{Name: "Raw", Type: export.Bytes},
+			{Name: "Nested", Type: export.Record, Fields: nestedFields},
+			{Name: "Items", Type: export.Record, Repeated: true, Fields: nestedFields},
+			{Name: "Meta", Type: export.JSON},
+		},
+	}
+
+	got := s.Fields[len(s.Fields)-1]
+	if !cmp.Equal(want, got) {
+		t.Fatalf("unexpected data schema\n%s", cmp.Diff(want, got))
+	}
+}
+
+func readRows(t *testing.T, b []byte) []export.Row {
+	var rows []export.Row
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var row export.Row
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("unmarshal row: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
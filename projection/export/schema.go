@@ -0,0 +1,226 @@
+package export
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modernice/goes/codec"
+)
+
+// FieldType is the type of a schema field. The field types are modeled after
+// the column types of common data warehouses like BigQuery.
+type FieldType string
+
+const (
+	String    = FieldType("STRING")
+	Integer   = FieldType("INTEGER")
+	Float     = FieldType("FLOAT")
+	Boolean   = FieldType("BOOLEAN")
+	Timestamp = FieldType("TIMESTAMP")
+	Bytes     = FieldType("BYTES")
+	Record    = FieldType("RECORD")
+
+	// JSON is the type of fields whose structure is not known statically,
+	// e.g. maps, interfaces, or types that implement json.Marshaler.
+	JSON = FieldType("JSON")
+)
+
+// Field is a field of a Schema.
+type Field struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Repeated bool      `json:"repeated,omitempty"`
+
+	// Fields are the nested fields of a Record field.
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Schema is the schema of the rows that are exported for an event.
+type Schema struct {
+	Event  string  `json:"event"`
+	Fields []Field `json:"fields"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the schema of the rows that are exported for the event with
+// the given name. The schema of the event data is derived from the data type
+// that is registered under the name in the provided registry, following the
+// rules of encoding/json: exported struct fields are used as fields, and the
+// "json" struct tag is respected.
+func SchemaOf(reg *codec.Registry, name string) (Schema, error) {
+	data, err := reg.New(name)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	dataField, err := fieldOf("data", reflect.TypeOf(data), nil)
+	if err != nil {
+		return Schema{}, fmt.Errorf("derive schema of %q event data: %w", name, err)
+	}
+
+	return Schema{
+		Event: name,
+		Fields: []Field{
+			{Name: "event_id", Type: String},
+			{Name: "event_name", Type: String},
+			{Name: "event_time", Type: Timestamp},
+			{Name: "aggregate_name", Type: String},
+			{Name: "aggregate_id", Type: String},
+			{Name: "aggregate_version", Type: Integer},
+			dataField,
+		},
+	}, nil
+}
+
+// Schemas returns the schemas of all events that are registered in the given
+// registry, sorted by event name.
+func Schemas(reg *codec.Registry) ([]Schema, error) {
+	names := make([]string, 0, len(reg.Map()))
+	for name := range reg.Map() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]Schema, 0, len(names))
+	for _, name := range names {
+		s, err := SchemaOf(reg, name)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, s)
+	}
+
+	return out, nil
+}
+
+func fieldOf(name string, t reflect.Type, visiting []reflect.Type) (Field, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	f := Field{Name: name}
+
+	switch {
+	case t == timeType:
+		f.Type = Timestamp
+		return f, nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		f.Type = JSON
+		return f, nil
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		f.Type = String
+		return f, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		f.Type = String
+	case reflect.Bool:
+		f.Type = Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.Type = Integer
+	case reflect.Float32, reflect.Float64:
+		f.Type = Float
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			f.Type = Bytes
+			return f, nil
+		}
+		elem, err := fieldOf(name, t.Elem(), visiting)
+		if err != nil {
+			return f, err
+		}
+		if elem.Repeated {
+			// Nested lists cannot be represented as columns.
+			f.Type = JSON
+			return f, nil
+		}
+		elem.Repeated = true
+		return elem, nil
+	case reflect.Struct:
+		for _, v := range visiting {
+			if v == t {
+				// Recursive types cannot be represented as columns.
+				f.Type = JSON
+				return f, nil
+			}
+		}
+		fields, err := structFields(t, append(visiting, t))
+		if err != nil {
+			return f, err
+		}
+		f.Type = Record
+		f.Fields = fields
+	case reflect.Map, reflect.Interface:
+		f.Type = JSON
+	default:
+		return f, fmt.Errorf("unsupported type %v", t)
+	}
+
+	return f, nil
+}
+
+func structFields(t reflect.Type, visiting []reflect.Type) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, skip := jsonName(sf)
+		if skip {
+			continue
+		}
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded, err := structFields(ft, visiting)
+				if err != nil {
+					return fields, err
+				}
+				fields = append(fields, embedded...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		f, err := fieldOf(name, sf.Type, visiting)
+		if err != nil {
+			return fields, fmt.Errorf("field %q: %w", sf.Name, err)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok {
+		return "", false
+	}
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
@@ -339,6 +339,25 @@ func (j *job) Aggregate(ctx context.Context, name string) (uuid.UUID, error) {
 // returned by EventsFor(). A job may be applied concurrently to multiple
 // projections.
 func (j *job) Apply(ctx context.Context, target Target[any], opts ...ApplyOption) error {
+	cfg := newApplyConfig(opts...)
+	cfg.ctx = ctx
+
+	if cfg.dryRun != nil {
+		clone, err := cfg.dryRun.prepare(target)
+		if err != nil {
+			return err
+		}
+		target = clone
+
+		// a dry run must not have side effects
+		cfg.notify = nil
+		if cfg.quarantine != nil {
+			cfg.quarantine = nil
+			cfg.recoverPanics = true
+		}
+		cfg.onApplied(cfg.dryRun.applied)
+	}
+
 	if j.reset {
 		if progressor, isProgressor := target.(ProgressAware); isProgressor {
 			progressor.SetProgress(stdtime.Time{})
@@ -354,11 +373,16 @@ func (j *job) Apply(ctx context.Context, target Target[any], opts ...ApplyOption
 		return fmt.Errorf("fetch events: %w", err)
 	}
 
-	done := make(chan struct{})
+	var tracker *updateTracker
+	if cfg.notify != nil {
+		tracker = newUpdateTracker()
+		cfg.onApplied(tracker.applied)
+	}
+
+	done := make(chan error, 1)
 
 	go func() {
-		defer close(done)
-		ApplyStream(target, events, opts...)
+		done <- applyStream(target, events, cfg)
 	}()
 
 	for {
@@ -370,8 +394,14 @@ func (j *job) Apply(ctx context.Context, target Target[any], opts ...ApplyOption
 				return err
 			}
 			errs = nil
-		case <-done:
-			return nil
+		case err := <-done:
+			if cfg.dryRun != nil {
+				cfg.dryRun.finish(target)
+			}
+			if err != nil || tracker == nil {
+				return err
+			}
+			return cfg.notify.publish(ctx, tracker)
 		}
 	}
 }
@@ -2,6 +2,7 @@ package projection_test
 
 import (
 	"context"
+	"errors"
 	"log"
 	"reflect"
 	"testing"
@@ -11,10 +12,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/modernice/goes/aggregate"
 	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
 	"github.com/modernice/goes/event/eventstore"
 	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/test"
 	"github.com/modernice/goes/helper/pick"
+	"github.com/modernice/goes/helper/recovery"
 	"github.com/modernice/goes/helper/streams"
 	"github.com/modernice/goes/internal/projectiontest"
 	"github.com/modernice/goes/projection"
@@ -347,6 +350,99 @@ func TestJob_Apply(t *testing.T) {
 	test.AssertEqualEvents(t, storeEvents[:3], proj.AppliedEvents)
 }
 
+func TestJob_Apply_RecoverPanics(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	storeEvents := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Time(now)),
+		event.New[any]("bar", test.FooEventData{}, event.Time(now.Add(time.Second))),
+		event.New[any]("baz", test.FooEventData{}, event.Time(now.Add(time.Minute))),
+	}
+	store, _ := newEventStore(t, storeEvents...)
+
+	job := projection.NewJob(ctx, store, query.New(query.SortBy(event.SortTime, event.SortAsc)))
+
+	proj := &panickingProjection{MockProjection: projectiontest.NewMockProjection(), panicOn: "bar"}
+
+	err := job.Apply(job, proj, projection.RecoverPanics())
+
+	var perr *recovery.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Apply should fail with a %T; got %T (%v)", perr, err, err)
+	}
+
+	if perr.Event != "bar" {
+		t.Fatalf("PanicError.Event should be %q; is %q", "bar", perr.Event)
+	}
+
+	if len(perr.Stack) == 0 {
+		t.Fatalf("PanicError.Stack should not be empty")
+	}
+
+	test.AssertEqualEvents(t, storeEvents[:1], proj.AppliedEvents)
+}
+
+func TestJob_Apply_NotifyUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	aggregateID := uuid.New()
+	now := time.Now()
+	storeEvents := []event.Event{
+		event.New[any]("foo", test.FooEventData{}, event.Time(now), event.Aggregate(aggregateID, "foobar", 1)),
+		event.New[any]("bar", test.FooEventData{}, event.Time(now.Add(time.Second)), event.Aggregate(aggregateID, "foobar", 2)),
+		event.New[any]("baz", test.FooEventData{}, event.Time(now.Add(time.Minute))),
+	}
+	store, _ := newEventStore(t, storeEvents...)
+
+	bus := eventbus.New()
+	updates, errs, err := bus.Subscribe(ctx, projection.ReadModelUpdated)
+	if err != nil {
+		t.Fatalf("subscribe to %q events: %v", projection.ReadModelUpdated, err)
+	}
+
+	job := projection.NewJob(ctx, store, query.New(query.SortByTime()))
+	proj := projectiontest.NewMockProjection()
+
+	if err := job.Apply(job, proj, projection.NotifyUpdates(bus, "foo-projection")); err != nil {
+		t.Fatalf("Apply failed with %q", err)
+	}
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatalf("%q event was not published", projection.ReadModelUpdated)
+	case evt := <-updates:
+		data, ok := evt.Data().(projection.ReadModelUpdatedData)
+		if !ok {
+			t.Fatalf("event data should be %T; got %T", data, evt.Data())
+		}
+
+		want := projection.ReadModelUpdatedData{
+			Projection: "foo-projection",
+			Aggregates: []projection.UpdatedAggregate{{Name: "foobar", ID: aggregateID, Version: 2}},
+		}
+
+		if !cmp.Equal(want, data) {
+			t.Fatalf("unexpected event data\n%s", cmp.Diff(want, data))
+		}
+	}
+}
+
+type panickingProjection struct {
+	*projectiontest.MockProjection
+
+	panicOn string
+}
+
+func (proj *panickingProjection) ApplyEvent(evt event.Event) {
+	if evt.Name() == proj.panicOn {
+		panic("mock panic")
+	}
+	proj.MockProjection.ApplyEvent(evt)
+}
+
 func TestJob_Events_cache(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
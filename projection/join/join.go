@@ -0,0 +1,350 @@
+// Package join provides joins of read-models with the state of other
+// aggregates.
+//
+// Projections often need data of aggregates other than the one they project,
+// e.g. an order list that shows the name of the customer of each order. A Join
+// keeps the joined values (the customer names) in a cache that is built from
+// the events of the joined aggregates, and keeps track of the read-models
+// that depend on each joined aggregate. When a joined aggregate changes, the
+// Join reports the change together with its dependents, so that the
+// projection can update the affected read-models:
+//
+//	customers := join.New(store, bus, "customer", []string{"customer.registered", "customer.renamed"},
+//		func(name string, evt event.Event) string {
+//			switch data := evt.Data().(type) {
+//			case CustomerRegistered:
+//				return data.Name
+//			case CustomerRenamed:
+//				return data.Name
+//			}
+//			return name
+//		},
+//	)
+//
+//	// when projecting an order
+//	name, err := customers.Link(ctx, customerID, orderID)
+//	order.CustomerName = name
+//
+//	// re-emit orders when a customer is renamed
+//	errs, err := customers.Run(ctx, func(ctx context.Context, c join.Change[string]) error {
+//		for _, orderID := range c.Dependents {
+//			// update the customer name of the order
+//		}
+//		return nil
+//	})
+package join
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/aggregate"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/event/query/version"
+	"github.com/modernice/goes/helper/streams"
+)
+
+// Reducer applies an event of a joined aggregate to the joined value.
+type Reducer[V any] func(V, event.Event) V
+
+// Change is a change of a joined value.
+type Change[V any] struct {
+	// Ref is the aggregate whose value changed.
+	Ref aggregate.Ref
+
+	// Value is the new value.
+	Value V
+
+	// Dependents are the read-models that are linked to the aggregate.
+	Dependents []uuid.UUID
+}
+
+// Join caches values that are projected from the events of an aggregate, and
+// tracks the read-models that depend on those values. A Join is safe for
+// concurrent use.
+type Join[V any] struct {
+	store         event.Store
+	bus           event.Bus
+	aggregateName string
+	events        []string
+	reduce        Reducer[V]
+
+	mux     sync.Mutex
+	entries map[uuid.UUID]*entry[V]
+}
+
+type entry[V any] struct {
+	value      V
+	version    int
+	err        error
+	dependents map[uuid.UUID]struct{}
+
+	// ready is closed when the value is loaded. Events that are published
+	// while the value is loaded mark the entry as stale, so that the value is
+	// loaded again.
+	ready chan struct{}
+	stale bool
+}
+
+// New returns a Join for the aggregates with the given name. The joined
+// values are projected from the given events using the provided Reducer.
+func New[V any](store event.Store, bus event.Bus, aggregateName string, events []string, reduce Reducer[V]) *Join[V] {
+	return &Join[V]{
+		store:         store,
+		bus:           bus,
+		aggregateName: aggregateName,
+		events:        events,
+		reduce:        reduce,
+		entries:       make(map[uuid.UUID]*entry[V]),
+	}
+}
+
+// Get returns the value of the given aggregate. Values are loaded from the
+// event store on first access, and cached while the aggregate has dependents
+// (see Link).
+func (j *Join[V]) Get(ctx context.Context, id uuid.UUID) (V, error) {
+	j.mux.Lock()
+	e, ok := j.entries[id]
+	j.mux.Unlock()
+
+	if ok {
+		return j.wait(ctx, e)
+	}
+
+	var zero V
+	value, _, err := j.load(ctx, id, zero, 0)
+	if err != nil {
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// Link returns the value of the given aggregate, and links the given
+// dependent to the aggregate, so that changes of the aggregate are reported
+// with the dependent (see Run).
+func (j *Join[V]) Link(ctx context.Context, id, dependent uuid.UUID) (V, error) {
+	j.mux.Lock()
+	e, ok := j.entries[id]
+	if !ok {
+		e = &entry[V]{dependents: make(map[uuid.UUID]struct{}), ready: make(chan struct{})}
+		j.entries[id] = e
+	}
+	e.dependents[dependent] = struct{}{}
+	j.mux.Unlock()
+
+	if ok {
+		return j.wait(ctx, e)
+	}
+
+	var value V
+	var version int
+	for {
+		var err error
+		value, version, err = j.load(ctx, id, value, version)
+
+		j.mux.Lock()
+		if err != nil {
+			e.err = err
+			if j.entries[id] == e {
+				delete(j.entries, id)
+			}
+			close(e.ready)
+			j.mux.Unlock()
+			return value, err
+		}
+
+		if e.stale {
+			e.stale = false
+			j.mux.Unlock()
+			continue
+		}
+
+		e.value, e.version = value, version
+		close(e.ready)
+		j.mux.Unlock()
+
+		return value, nil
+	}
+}
+
+func (j *Join[V]) wait(ctx context.Context, e *entry[V]) (V, error) {
+	select {
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	case <-e.ready:
+	}
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return e.value, e.err
+}
+
+// Unlink removes the links between the given dependent and the given
+// aggregates, or all aggregates if no ids are provided. Values of aggregates
+// without dependents are removed from the cache.
+func (j *Join[V]) Unlink(dependent uuid.UUID, ids ...uuid.UUID) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if len(ids) == 0 {
+		for id := range j.entries {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		e, ok := j.entries[id]
+		if !ok {
+			continue
+		}
+		delete(e.dependents, dependent)
+		if len(e.dependents) == 0 {
+			delete(j.entries, id)
+		}
+	}
+}
+
+// Dependents returns the dependents that are linked to the given aggregate.
+func (j *Join[V]) Dependents(id uuid.UUID) []uuid.UUID {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	e, ok := j.entries[id]
+	if !ok {
+		return nil
+	}
+
+	return e.dependentIDs()
+}
+
+// Run subscribes to the events of the joined aggregates and updates the
+// cached values until ctx is canceled. For each change of a value that has
+// dependents, onChange is called with the new value and the dependents, which
+// allows to re-project the dependent read-models. Errors of onChange are sent
+// to the returned channel.
+func (j *Join[V]) Run(ctx context.Context, onChange func(context.Context, Change[V]) error) (<-chan error, error) {
+	events, errs, err := j.bus.Subscribe(ctx, j.events...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to events: %w", err)
+	}
+
+	out := make(chan error)
+
+	go func() {
+		defer close(out)
+
+		fail := func(err error) {
+			select {
+			case <-ctx.Done():
+			case out <- err:
+			}
+		}
+
+		streams.ForEach(ctx, func(evt event.Event) {
+			change, ok, err := j.apply(ctx, evt)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			if !ok || onChange == nil {
+				return
+			}
+
+			if err := onChange(ctx, change); err != nil {
+				fail(fmt.Errorf("handle change of %s(%s): %w", change.Ref.Name, change.Ref.ID, err))
+			}
+		}, fail, events, errs)
+	}()
+
+	return out, nil
+}
+
+// apply applies an event to the cached value of its aggregate, and reports
+// whether the value of an aggregate with dependents changed.
+func (j *Join[V]) apply(ctx context.Context, evt event.Event) (Change[V], bool, error) {
+	id, name, v := evt.Aggregate()
+	if name != j.aggregateName {
+		return Change[V]{}, false, nil
+	}
+
+	j.mux.Lock()
+	e, ok := j.entries[id]
+	if !ok {
+		j.mux.Unlock()
+		return Change[V]{}, false, nil
+	}
+
+	select {
+	case <-e.ready:
+	default:
+		e.stale = true
+		j.mux.Unlock()
+		return Change[V]{}, false, nil
+	}
+
+	if v <= e.version {
+		j.mux.Unlock()
+		return Change[V]{}, false, nil
+	}
+
+	value, version := e.value, e.version
+	j.mux.Unlock()
+
+	// Events of the aggregate may be delivered out of order or not at all,
+	// and the joined events may not be all events of the aggregate, so the
+	// event is not applied directly. Instead, all events that were not yet
+	// applied are loaded from the store.
+	value, version, err := j.load(ctx, id, value, version)
+	if err != nil {
+		return Change[V]{}, false, err
+	}
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if j.entries[id] != e || version <= e.version {
+		return Change[V]{}, false, nil
+	}
+	e.value, e.version = value, version
+
+	return Change[V]{Ref: aggregate.Ref{Name: name, ID: id}, Value: e.value, Dependents: e.dependentIDs()}, true, nil
+}
+
+// load applies the events of the given aggregate that have a version greater
+// than v to the given value.
+func (j *Join[V]) load(ctx context.Context, id uuid.UUID, value V, v int) (V, int, error) {
+	str, errs, err := j.store.Query(ctx, query.New(
+		query.Aggregate(j.aggregateName, id),
+		query.Name(j.events...),
+		query.AggregateVersion(version.Min(v+1)),
+		query.SortBy(event.SortAggregateVersion, event.SortAsc),
+	))
+	if err != nil {
+		return value, v, fmt.Errorf("query events of %s(%s): %w", j.aggregateName, id, err)
+	}
+
+	if err := streams.Walk(ctx, func(evt event.Event) error {
+		value = j.reduce(value, evt)
+		_, _, v = evt.Aggregate()
+		return nil
+	}, str, errs); err != nil {
+		return value, v, fmt.Errorf("query events of %s(%s): %w", j.aggregateName, id, err)
+	}
+
+	return value, v, nil
+}
+
+func (e *entry[V]) dependentIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(e.dependents))
+	for id := range e.dependents {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,147 @@
+package join_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/internal/testutil"
+	"github.com/modernice/goes/projection/join"
+)
+
+type customerNamed struct {
+	Name string
+}
+
+func reduceName(name string, evt event.Event) string {
+	if data, ok := evt.Data().(customerNamed); ok {
+		return data.Name
+	}
+	return name
+}
+
+func TestJoin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.WithBus(eventstore.New(), bus)
+
+	customer := uuid.New()
+	order := uuid.New()
+
+	if err := store.Insert(ctx, event.New[any]("customer.registered", customerNamed{Name: "Bob"}, event.Aggregate(customer, "customer", 1))); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	j := join.New(store, bus, "customer", []string{"customer.registered", "customer.renamed"}, reduceName)
+
+	changes := make(chan join.Change[string])
+	errs, err := j.Run(ctx, func(_ context.Context, c join.Change[string]) error {
+		changes <- c
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run() failed with %q", err)
+	}
+	go testutil.PanicOn(errs)
+
+	name, err := j.Link(ctx, customer, order)
+	if err != nil {
+		t.Fatalf("Link() failed with %q", err)
+	}
+	if name != "Bob" {
+		t.Fatalf("Link() should return %q; got %q", "Bob", name)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("customer.renamed", customerNamed{Name: "Alice"}, event.Aggregate(customer, "customer", 2))); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("didn't receive change after %s", time.Second)
+	case c := <-changes:
+		if c.Ref.ID != customer || c.Value != "Alice" {
+			t.Fatalf("unexpected change: %+v", c)
+		}
+		if len(c.Dependents) != 1 || c.Dependents[0] != order {
+			t.Fatalf("Dependents should be %v; got %v", []uuid.UUID{order}, c.Dependents)
+		}
+	}
+
+	if name, err := j.Get(ctx, customer); err != nil || name != "Alice" {
+		t.Fatalf("Get() should return %q; got %q (%v)", "Alice", name, err)
+	}
+
+	j.Unlink(order)
+
+	if deps := j.Dependents(customer); len(deps) != 0 {
+		t.Fatalf("Dependents() should return no dependents after Unlink(); got %v", deps)
+	}
+
+	if err := store.Insert(ctx, event.New[any]("customer.renamed", customerNamed{Name: "Carol"}, event.Aggregate(customer, "customer", 3))); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	select {
+	case c := <-changes:
+		t.Fatalf("unlinked aggregates should not be reported; got %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if name, err := j.Get(ctx, customer); err != nil || name != "Carol" {
+		t.Fatalf("Get() should return %q; got %q (%v)", "Carol", name, err)
+	}
+}
+
+func TestJoin_missedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	store := eventstore.New()
+	customer := uuid.New()
+
+	if err := store.Insert(ctx, event.New[any]("customer.registered", customerNamed{Name: "Bob"}, event.Aggregate(customer, "customer", 1))); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	j := join.New(store, bus, "customer", []string{"customer.registered", "customer.renamed"}, reduceName)
+
+	changes := make(chan join.Change[string], 1)
+	if _, err := j.Run(ctx, func(_ context.Context, c join.Change[string]) error {
+		changes <- c
+		return nil
+	}); err != nil {
+		t.Fatalf("Run() failed with %q", err)
+	}
+
+	if _, err := j.Link(ctx, customer, uuid.New()); err != nil {
+		t.Fatalf("Link() failed with %q", err)
+	}
+
+	// The event of version 2 is inserted without being published.
+	missed := event.New[any]("customer.renamed", customerNamed{Name: "Alice"}, event.Aggregate(customer, "customer", 2))
+	latest := event.New[any]("customer.renamed", customerNamed{Name: "Carol"}, event.Aggregate(customer, "customer", 3))
+	if err := store.Insert(ctx, missed, latest); err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+
+	if err := bus.Publish(ctx, latest); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("didn't receive change after %s", time.Second)
+	case c := <-changes:
+		if c.Value != "Carol" {
+			t.Fatalf("Value should be %q; got %q", "Carol", c.Value)
+		}
+	}
+}
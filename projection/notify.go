@@ -0,0 +1,118 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/codec"
+	"github.com/modernice/goes/event"
+)
+
+// ReadModelUpdated is the name of the event that is published when a
+// projection job has been applied to a read-model (see NotifyUpdates).
+const ReadModelUpdated = "readmodel.updated"
+
+// ReadModelUpdatedData is the event data for ReadModelUpdated.
+type ReadModelUpdatedData struct {
+	// Projection is the name of the updated projection.
+	Projection string
+
+	// Aggregates are the aggregates whose events have been applied to the
+	// projection.
+	Aggregates []UpdatedAggregate
+}
+
+// UpdatedAggregate is an aggregate whose events have been applied to a
+// projection.
+type UpdatedAggregate struct {
+	Name string
+	ID   uuid.UUID
+
+	// Version is the highest version of the aggregate that has been applied.
+	Version int
+}
+
+// RegisterEvents registers the events of the projection package into a registry.
+func RegisterEvents(r codec.Registerer) {
+	codec.Register[ReadModelUpdatedData](r, ReadModelUpdated)
+}
+
+type notifyConfig struct {
+	bus        event.Bus
+	projection string
+}
+
+// NotifyUpdates returns an ApplyOption that publishes a ReadModelUpdated event
+// over the given bus after a Job has been applied to a projection. API layers
+// can subscribe to this event to invalidate caches or to push updates to
+// clients without coupling to the internals of the projection. No event is
+// published if no events were applied to the projection.
+//
+//	var job projection.Job
+//	var proj projection.Target[any]
+//	err := job.Apply(job, proj, projection.NotifyUpdates(bus, "orders"))
+//
+// NotifyUpdates only has an effect when passed to Job.Apply.
+func NotifyUpdates(bus event.Bus, projection string) ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.notify = &notifyConfig{bus: bus, projection: projection}
+	}
+}
+
+type updateTracker struct {
+	count      int
+	aggregates map[aggregateKey]int
+}
+
+type aggregateKey struct {
+	name string
+	id   uuid.UUID
+}
+
+func newUpdateTracker() *updateTracker {
+	return &updateTracker{aggregates: make(map[aggregateKey]int)}
+}
+
+func (t *updateTracker) applied(evt event.Event) {
+	t.count++
+
+	id, name, v := evt.Aggregate()
+	if name == "" || id == uuid.Nil {
+		return
+	}
+
+	key := aggregateKey{name: name, id: id}
+	if cur, ok := t.aggregates[key]; !ok || v > cur {
+		t.aggregates[key] = v
+	}
+}
+
+func (t *updateTracker) data(projection string) ReadModelUpdatedData {
+	data := ReadModelUpdatedData{Projection: projection}
+	for key, v := range t.aggregates {
+		data.Aggregates = append(data.Aggregates, UpdatedAggregate{Name: key.name, ID: key.id, Version: v})
+	}
+	sort.Slice(data.Aggregates, func(i, j int) bool {
+		a, b := data.Aggregates[i], data.Aggregates[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return data
+}
+
+func (cfg *notifyConfig) publish(ctx context.Context, t *updateTracker) error {
+	if t.count == 0 {
+		return nil
+	}
+
+	evt := event.New(ReadModelUpdated, t.data(cfg.projection))
+	if err := cfg.bus.Publish(ctx, evt.Any()); err != nil {
+		return fmt.Errorf("publish %q event: %w", ReadModelUpdated, err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package projection_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/modernice/goes/event"
 	"github.com/modernice/goes/event/query"
 	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/helper/recovery"
 	"github.com/modernice/goes/internal/projectiontest"
 	"github.com/modernice/goes/internal/slice"
 	"github.com/modernice/goes/projection"
@@ -119,3 +121,48 @@ func TestApply_Guard(t *testing.T) {
 
 	proj.ExpectApplied(t, events[:2]...)
 }
+
+func TestOnApplied(t *testing.T) {
+	guard := projection.QueryGuard(query.New(query.Name("foo", "bar")))
+	proj := projectiontest.NewMockGuardedProjection(guard)
+
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}),
+		event.New[any]("baz", test.FooEventData{}),
+		event.New[any]("bar", test.FooEventData{}),
+	}
+
+	var applied []event.Event
+	projection.Apply(proj, events, projection.OnApplied(func(evt event.Event) {
+		applied = append(applied, evt)
+	}))
+
+	test.AssertEqualEvents(t, []event.Event{events[0], events[2]}, applied)
+}
+
+func TestApply_RecoverPanics(t *testing.T) {
+	proj := &panickingProjection{MockProjection: projectiontest.NewMockProjection(), panicOn: "bar"}
+
+	events := []event.Event{
+		event.New[any]("foo", test.FooEventData{}),
+		event.New[any]("bar", test.FooEventData{}),
+		event.New[any]("baz", test.FooEventData{}),
+	}
+
+	err := projection.Apply(proj, events, projection.RecoverPanics())
+
+	var perr *recovery.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Apply should fail with a %T; got %T (%v)", perr, err, err)
+	}
+
+	if perr.Value != "mock panic" {
+		t.Fatalf("PanicError.Value should be %q; is %v", "mock panic", perr.Value)
+	}
+
+	if len(perr.Stack) == 0 {
+		t.Fatalf("PanicError.Stack should not be empty")
+	}
+
+	test.AssertEqualEvents(t, events[:1], proj.AppliedEvents)
+}
@@ -0,0 +1,204 @@
+// Package projectiontest provides a given/apply/then test harness for
+// projections.
+//
+//	func TestCounter(t *testing.T) {
+//		counter := NewCounter()
+//
+//		projectiontest.Given(
+//			event.New("incremented", 1).Any(),
+//			event.New("incremented", 2).Any(),
+//		).Apply(counter).Then(t, func() error {
+//			if counter.Value != 3 {
+//				return fmt.Errorf("Value should be %d; got %d", 3, counter.Value)
+//			}
+//			return nil
+//		})
+//	}
+package projectiontest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/projection"
+)
+
+// Scenario is the "given" part of a projection test. It holds the fixed event
+// sequence that is applied to a projection.
+type Scenario struct {
+	events []event.Event
+}
+
+// Given returns a Scenario with the given event sequence. The events keep their
+// ids and times, so applying a Scenario to a ProgressAware projection that has
+// already applied some of the events can be used to test the deduplication of
+// redelivered events.
+func Given(events ...event.Event) Scenario {
+	return Scenario{events: events}
+}
+
+// Result is the result of applying a Scenario to a projection, which can be
+// checked using the Then methods.
+type Result struct {
+	target  projection.Target[any]
+	err     error
+	applied []event.Event
+}
+
+// Apply applies the events of the Scenario to the projection using
+// projection.ApplyStream.
+func (s Scenario) Apply(target projection.Target[any], opts ...projection.ApplyOption) Result {
+	res := Result{target: target}
+	opts = append(opts, projection.OnApplied(func(evt event.Event) {
+		res.applied = append(res.applied, evt)
+	}))
+
+	str := make(chan event.Event, len(s.events))
+	for _, evt := range s.events {
+		str <- evt
+	}
+	close(str)
+
+	if err := projection.ApplyStream(target, str, opts...); err != nil {
+		res.err = fmt.Errorf("apply events: %w", err)
+	}
+
+	return res
+}
+
+// ApplyJob inserts the events of the Scenario into an in-memory event store
+// and applies a projection job that queries all events, sorted by time, to the
+// projection. Use ApplyJob instead of Apply to test projections against the
+// behavior of projection jobs, e.g. a projection that is reset by a job.
+func (s Scenario) ApplyJob(target projection.Target[any], jobOpts []projection.JobOption, opts ...projection.ApplyOption) Result {
+	res := Result{target: target}
+
+	ctx := context.Background()
+	store := eventstore.New()
+	if err := store.Insert(ctx, s.events...); err != nil {
+		res.err = fmt.Errorf("insert events: %w", err)
+		return res
+	}
+
+	opts = append(opts, projection.OnApplied(func(evt event.Event) {
+		res.applied = append(res.applied, evt)
+	}))
+
+	job := projection.NewJob(ctx, store, query.New(query.SortByTime()), jobOpts...)
+	if err := job.Apply(job, target, opts...); err != nil {
+		res.err = fmt.Errorf("apply job: %w", err)
+	}
+
+	return res
+}
+
+// Applied returns the events that have been applied to the projection, in the
+// order they were applied. Events that were skipped by the Guard or the
+// progress of the projection are not included.
+func (r Result) Applied() []event.Event {
+	return r.applied
+}
+
+// Err returns the error that occurred while applying the events.
+func (r Result) Err() error {
+	return r.err
+}
+
+// Then asserts that the events were applied without errors and calls check to
+// assert the resulting state of the projection.
+func (r Result) Then(t testing.TB, check func() error) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	if check == nil {
+		return
+	}
+
+	if err := check(); err != nil {
+		t.Fatalf("unexpected projection state: %v", err)
+	}
+}
+
+// ThenApplied asserts that exactly the given events have been applied to the
+// projection, in the given order. Events are compared by their ids. Pass no
+// events to assert that no events were applied, e.g. because a ProgressAware
+// projection has already applied them.
+func (r Result) ThenApplied(t testing.TB, events ...event.Event) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	want := make([]string, len(events))
+	for i, evt := range events {
+		want[i] = describe(evt)
+	}
+
+	got := make([]string, len(r.applied))
+	for i, evt := range r.applied {
+		got[i] = describe(evt)
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected applied events (-want +got):\n%s", diff)
+	}
+}
+
+// ThenProgress asserts the progress of a ProgressAware projection. The ids are
+// compared regardless of their order.
+func (r Result) ThenProgress(t testing.TB, want time.Time, ids ...uuid.UUID) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	progressor, ok := r.target.(projection.ProgressAware)
+	if !ok {
+		t.Fatalf("%T does not implement projection.ProgressAware", r.target)
+	}
+
+	got, gotIDs := progressor.Progress()
+	if !got.Equal(want) {
+		t.Fatalf("projection progress should be %v; got %v", want, got)
+	}
+
+	sortIDs := cmpopts.SortSlices(func(a, b uuid.UUID) bool { return a.String() < b.String() })
+	if diff := cmp.Diff(ids, gotIDs, sortIDs, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected progress event ids (-want +got):\n%s", diff)
+	}
+}
+
+// ThenErr asserts that applying the events failed with the given error, which
+// is compared using errors.Is. If err is nil, ThenErr asserts that applying
+// the events failed with any error. Only results of ApplyJob can have an error,
+// e.g. if the projection panics and the projection.RecoverPanics option is
+// used.
+func (r Result) ThenErr(t testing.TB, err error) {
+	t.Helper()
+
+	if r.err == nil {
+		t.Fatalf("expected error %v; got nil", err)
+	}
+
+	if err != nil && !errors.Is(r.err, err) {
+		t.Fatalf("expected error %q; got %q", err, r.err)
+	}
+}
+
+func describe(evt event.Event) string {
+	return fmt.Sprintf("%s (%s)", evt.Name(), evt.ID())
+}
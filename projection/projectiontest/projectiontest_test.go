@@ -0,0 +1,92 @@
+package projectiontest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/recovery"
+	"github.com/modernice/goes/projection"
+	"github.com/modernice/goes/projection/projectiontest"
+)
+
+type counter struct {
+	*projection.Base
+	*projection.Progressor
+
+	value int
+}
+
+func newCounter() *counter {
+	c := &counter{
+		Base:       projection.New(),
+		Progressor: projection.NewProgressor(),
+	}
+	event.ApplyWith(c, func(evt event.Of[int]) { c.value += evt.Data() }, "incremented")
+	event.ApplyWith(c, func(event.Of[int]) { panic("boom") }, "exploded")
+	return c
+}
+
+func (c *counter) expect(value int) func() error {
+	return func() error {
+		if c.value != value {
+			return fmt.Errorf("value should be %d; got %d", value, c.value)
+		}
+		return nil
+	}
+}
+
+func TestScenario_Apply(t *testing.T) {
+	now := time.Now()
+	events := []event.Event{
+		event.New("incremented", 1, event.Time(now)).Any(),
+		event.New("incremented", 2, event.Time(now.Add(time.Second))).Any(),
+	}
+
+	c := newCounter()
+
+	res := projectiontest.Given(events...).Apply(c)
+	res.Then(t, c.expect(3))
+	res.ThenApplied(t, events...)
+	res.ThenProgress(t, events[1].Time(), events[1].ID())
+
+	// re-applying the last applied event should not change the projection
+	res = projectiontest.Given(events[1]).Apply(c)
+	res.Then(t, c.expect(3))
+	res.ThenApplied(t)
+	res.ThenProgress(t, events[1].Time(), events[1].ID())
+
+	// unless the progress is ignored
+	projectiontest.Given(events[1]).Apply(c, projection.IgnoreProgress()).Then(t, c.expect(5))
+}
+
+func TestScenario_ApplyJob(t *testing.T) {
+	now := time.Now()
+	events := []event.Event{
+		event.New("incremented", 2, event.Time(now.Add(time.Second))).Any(),
+		event.New("incremented", 1, event.Time(now)).Any(),
+	}
+
+	c := newCounter()
+
+	res := projectiontest.Given(events...).ApplyJob(c, nil)
+	res.Then(t, c.expect(3))
+	res.ThenApplied(t, events[1], events[0])
+	res.ThenProgress(t, events[0].Time(), events[0].ID())
+}
+
+func TestResult_ThenErr(t *testing.T) {
+	res := projectiontest.Given(
+		event.New("incremented", 1).Any(),
+		event.New("exploded", 0).Any(),
+	).ApplyJob(newCounter(), nil, projection.RecoverPanics())
+
+	res.ThenErr(t, nil)
+
+	var panicErr *recovery.PanicError
+	if err := res.Err(); !errors.As(err, &panicErr) {
+		t.Fatalf("Err() should return a %T; got %T", panicErr, err)
+	}
+}
@@ -0,0 +1,277 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/recovery"
+)
+
+// DefaultQuarantineAttempts is the default number of failed attempts to apply
+// an event to a projection, after which the event is quarantined.
+const DefaultQuarantineAttempts = 3
+
+// QuarantinedEvent is an event that repeatedly failed to be applied to a
+// projection and has therefore been quarantined.
+type QuarantinedEvent struct {
+	// Projection is the name of the projection.
+	Projection string
+
+	// EventID is the id of the event.
+	EventID uuid.UUID
+
+	// EventName is the name of the event.
+	EventName string
+
+	// EventTime is the time of the event.
+	EventTime time.Time
+
+	// Aggregate is the aggregate of the event, if any.
+	Aggregate event.AggregateRef
+
+	// Error is the error of the last failed attempt.
+	Error string
+
+	// Attempts is the number of failed attempts.
+	Attempts int
+
+	// QuarantinedAt is the time at which the event was quarantined.
+	QuarantinedAt time.Time
+}
+
+// QuarantineStore persists quarantined events.
+type QuarantineStore interface {
+	// Quarantine stores the quarantined event. If the event is already
+	// quarantined for the projection, it is replaced.
+	Quarantine(context.Context, QuarantinedEvent) error
+
+	// Quarantined returns the quarantined events of the given projection.
+	Quarantined(ctx context.Context, projection string) ([]QuarantinedEvent, error)
+
+	// Release removes the event from the quarantine of the given projection.
+	Release(ctx context.Context, projection string, eventID uuid.UUID) error
+}
+
+// NewQuarantineStore returns an in-memory QuarantineStore.
+func NewQuarantineStore() QuarantineStore {
+	return &quarantineStore{events: make(map[string]map[uuid.UUID]QuarantinedEvent)}
+}
+
+type quarantineStore struct {
+	mux    sync.RWMutex
+	events map[string]map[uuid.UUID]QuarantinedEvent
+}
+
+func (s *quarantineStore) Quarantine(_ context.Context, evt QuarantinedEvent) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.events[evt.Projection] == nil {
+		s.events[evt.Projection] = make(map[uuid.UUID]QuarantinedEvent)
+	}
+	s.events[evt.Projection][evt.EventID] = evt
+	return nil
+}
+
+func (s *quarantineStore) Quarantined(_ context.Context, projection string) ([]QuarantinedEvent, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	out := make([]QuarantinedEvent, 0, len(s.events[projection]))
+	for _, evt := range s.events[projection] {
+		out = append(out, evt)
+	}
+	return out, nil
+}
+
+func (s *quarantineStore) Release(_ context.Context, projection string, eventID uuid.UUID) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.events[projection], eventID)
+	return nil
+}
+
+// Quarantine quarantines poison events of projections. When an event
+// repeatedly fails to be applied to a projection (the event applier panics),
+// the event is recorded in the QuarantineStore and skipped, so that the
+// projection job can continue with the remaining events. After the projection
+// has been fixed, quarantined events can be reprocessed using Reprocess.
+//
+//	q := projection.NewQuarantine(projection.NewQuarantineStore(), 3)
+//	err := job.Apply(job, proj, projection.QuarantineFailures(q, "orders"))
+//
+// A panicking event applier may leave the projection partially updated.
+type Quarantine struct {
+	store       QuarantineStore
+	maxAttempts int
+
+	mux         sync.Mutex
+	attempts    map[quarantineKey]int
+	quarantined map[quarantineKey]bool
+	loaded      map[string]bool
+}
+
+type quarantineKey struct {
+	projection string
+	eventID    uuid.UUID
+}
+
+// NewQuarantine returns a Quarantine that quarantines an event after it failed
+// to be applied to a projection maxAttempts times. If maxAttempts < 1,
+// DefaultQuarantineAttempts is used.
+func NewQuarantine(store QuarantineStore, maxAttempts int) *Quarantine {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultQuarantineAttempts
+	}
+	return &Quarantine{
+		store:       store,
+		maxAttempts: maxAttempts,
+		attempts:    make(map[quarantineKey]int),
+		quarantined: make(map[quarantineKey]bool),
+		loaded:      make(map[string]bool),
+	}
+}
+
+type quarantineConfig struct {
+	quarantine *Quarantine
+	projection string
+}
+
+// QuarantineFailures returns an ApplyOption that recovers from panics while
+// applying events to the projection with the given name, and quarantines
+// events that repeatedly fail to be applied. Until an event is quarantined, a
+// failed attempt behaves like the RecoverPanics option: the remaining events
+// are not applied and the *recovery.PanicError is returned. Quarantined events
+// are skipped but count towards the progress of ProgressAware projections.
+func QuarantineFailures(q *Quarantine, projection string) ApplyOption {
+	return func(cfg *applyConfig) {
+		cfg.quarantine = &quarantineConfig{quarantine: q, projection: projection}
+	}
+}
+
+func (cfg *quarantineConfig) apply(ctx context.Context, target Target[any], evt event.Event) (skipped bool, _ error) {
+	q := cfg.quarantine
+	key := quarantineKey{projection: cfg.projection, eventID: evt.ID()}
+
+	quarantined, err := q.isQuarantined(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if quarantined {
+		return true, nil
+	}
+
+	applyErr := recovery.Event(evt, func() { target.ApplyEvent(evt) })
+
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if applyErr == nil {
+		delete(q.attempts, key)
+		return false, nil
+	}
+
+	q.attempts[key]++
+	attempts := q.attempts[key]
+	if attempts < q.maxAttempts {
+		return false, applyErr
+	}
+
+	id, name, _ := evt.Aggregate()
+	if err := q.store.Quarantine(ctx, QuarantinedEvent{
+		Projection:    cfg.projection,
+		EventID:       evt.ID(),
+		EventName:     evt.Name(),
+		EventTime:     evt.Time(),
+		Aggregate:     event.AggregateRef{Name: name, ID: id},
+		Error:         applyErr.Error(),
+		Attempts:      attempts,
+		QuarantinedAt: time.Now(),
+	}); err != nil {
+		return false, fmt.Errorf("quarantine %q event: %w [error=%v]", evt.Name(), err, applyErr)
+	}
+
+	delete(q.attempts, key)
+	q.quarantined[key] = true
+
+	return true, nil
+}
+
+func (q *Quarantine) isQuarantined(ctx context.Context, key quarantineKey) (bool, error) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if !q.loaded[key.projection] {
+		events, err := q.store.Quarantined(ctx, key.projection)
+		if err != nil {
+			return false, fmt.Errorf("load quarantined events: %w", err)
+		}
+		for _, evt := range events {
+			q.quarantined[quarantineKey{projection: key.projection, eventID: evt.EventID}] = true
+		}
+		q.loaded[key.projection] = true
+	}
+
+	return q.quarantined[key], nil
+}
+
+// Quarantined returns the quarantined events of the given projection, sorted
+// by event time.
+func (q *Quarantine) Quarantined(ctx context.Context, projection string) ([]QuarantinedEvent, error) {
+	events, err := q.store.Quarantined(ctx, projection)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventTime.Before(events[j].EventTime)
+	})
+	return events, nil
+}
+
+// Reprocess fetches the quarantined events of the given projection from the
+// event store and applies them to the target, in the order of their event
+// time. Events that are applied successfully are released from the
+// quarantine. Events that fail again stay quarantined with the new error.
+// Reprocess does not update the progress of ProgressAware targets, because
+// the progress has already passed the quarantined events. The returned error
+// joins the errors of all events that could not be reprocessed.
+func (q *Quarantine) Reprocess(ctx context.Context, store event.Store, projection string, target Target[any]) error {
+	events, err := q.Quarantined(ctx, projection)
+	if err != nil {
+		return fmt.Errorf("fetch quarantined events: %w", err)
+	}
+
+	var errs []error
+	for _, qevt := range events {
+		evt, err := store.Find(ctx, qevt.EventID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("find %q event %s: %w", qevt.EventName, qevt.EventID, err))
+			continue
+		}
+
+		if applyErr := recovery.Event(evt, func() { target.ApplyEvent(evt) }); applyErr != nil {
+			qevt.Error = applyErr.Error()
+			qevt.Attempts++
+			if err := q.store.Quarantine(ctx, qevt); err != nil {
+				errs = append(errs, fmt.Errorf("update quarantined %q event %s: %w", qevt.EventName, qevt.EventID, err))
+			}
+			errs = append(errs, applyErr)
+			continue
+		}
+
+		if err := q.store.Release(ctx, projection, qevt.EventID); err != nil {
+			errs = append(errs, fmt.Errorf("release %q event %s: %w", qevt.EventName, qevt.EventID, err))
+			continue
+		}
+
+		q.mux.Lock()
+		delete(q.quarantined, quarantineKey{projection: projection, eventID: qevt.EventID})
+		q.mux.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,114 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventstore"
+	"github.com/modernice/goes/event/query"
+	"github.com/modernice/goes/helper/recovery"
+	"github.com/modernice/goes/projection"
+)
+
+type poisonable struct {
+	*projection.Base
+	*projection.Progressor
+
+	fixed   bool
+	applied []string
+}
+
+func newPoisonable() *poisonable {
+	p := &poisonable{
+		Base:       projection.New(),
+		Progressor: projection.NewProgressor(),
+	}
+	event.ApplyWith(p, func(evt event.Of[string]) { p.applied = append(p.applied, evt.Data()) }, "foo")
+	event.ApplyWith(p, func(evt event.Of[string]) {
+		if !p.fixed {
+			panic("poison")
+		}
+		p.applied = append(p.applied, evt.Data())
+	}, "poison")
+	return p
+}
+
+func TestQuarantineFailures(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.New()
+
+	now := time.Now()
+	events := []event.Event{
+		event.New("foo", "a", event.Time(now)).Any(),
+		event.New("poison", "b", event.Time(now.Add(time.Second))).Any(),
+		event.New("foo", "c", event.Time(now.Add(2*time.Second))).Any(),
+	}
+	if err := store.Insert(ctx, events...); err != nil {
+		t.Fatalf("insert events: %v", err)
+	}
+
+	q := projection.NewQuarantine(projection.NewQuarantineStore(), 2)
+	proj := newPoisonable()
+
+	apply := func() error {
+		job := projection.NewJob(ctx, store, query.New(query.SortByTime()))
+		return job.Apply(job, proj, projection.QuarantineFailures(q, "poisonable"))
+	}
+
+	// first attempt fails
+	var panicErr *recovery.PanicError
+	if err := apply(); !errors.As(err, &panicErr) {
+		t.Fatalf("Apply() should fail with %T; got %T", panicErr, err)
+	}
+
+	if len(proj.applied) != 1 {
+		t.Fatalf("%d event should have been applied; got %d", 1, len(proj.applied))
+	}
+
+	// second attempt quarantines the event and continues
+	if err := apply(); err != nil {
+		t.Fatalf("Apply() failed with %q", err)
+	}
+
+	if len(proj.applied) != 2 || proj.applied[1] != "c" {
+		t.Fatalf("events after the quarantined event should have been applied; got %v", proj.applied)
+	}
+
+	quarantined, err := q.Quarantined(ctx, "poisonable")
+	if err != nil {
+		t.Fatalf("Quarantined() failed with %q", err)
+	}
+
+	if len(quarantined) != 1 || quarantined[0].EventID != events[1].ID() || quarantined[0].Attempts != 2 {
+		t.Fatalf("the poison event should have been quarantined after %d attempts; got %v", 2, quarantined)
+	}
+
+	// quarantined events are skipped by subsequent jobs
+	proj.SetProgress(time.Time{})
+	proj.applied = nil
+	if err := apply(); err != nil {
+		t.Fatalf("Apply() failed with %q", err)
+	}
+
+	if len(proj.applied) != 2 {
+		t.Fatalf("quarantined event should have been skipped; got %v", proj.applied)
+	}
+
+	// reprocess after the projection has been fixed
+	proj.fixed = true
+	proj.applied = nil
+	if err := q.Reprocess(ctx, store, "poisonable", proj); err != nil {
+		t.Fatalf("Reprocess() failed with %q", err)
+	}
+
+	if len(proj.applied) != 1 || proj.applied[0] != "b" {
+		t.Fatalf("quarantined event should have been reprocessed; got %v", proj.applied)
+	}
+
+	if quarantined, _ := q.Quarantined(ctx, "poisonable"); len(quarantined) != 0 {
+		t.Fatalf("reprocessed event should have been released; got %v", quarantined)
+	}
+}
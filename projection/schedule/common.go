@@ -25,6 +25,13 @@ func newSchedule(store event.Store, eventNames []string) *schedule {
 	}
 }
 
+// EventNames returns the names of the events that trigger the schedule. The
+// names can be passed to codec.Registry.Validate to verify at startup that the
+// events are registered.
+func (schedule *schedule) EventNames() []string {
+	return append([]string(nil), schedule.eventNames...)
+}
+
 // Trigger manually triggers the schedule. When triggering a schedule, a
 // projection Job is created and passed to subscribers of the schedule. Trigger
 // does not wait for the created Job to be applied. The only error ever returned
@@ -84,6 +84,15 @@ L:
 	proj.ExpectApplied(t, events[:3]...)
 }
 
+func TestContinuous_EventNames(t *testing.T) {
+	names := []string{"foo", "bar", "baz"}
+	s := schedule.Continuously(eventbus.New(), eventstore.New(), names)
+
+	if diff := cmp.Diff(names, s.EventNames()); diff != "" {
+		t.Fatalf("EventNames() returned wrong names:\n%s", diff)
+	}
+}
+
 func TestDebounce(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
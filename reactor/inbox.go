@@ -0,0 +1,105 @@
+package reactor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultInboxRetention is the default time for which a MemoryInbox remembers
+// handled events.
+const DefaultInboxRetention = time.Hour
+
+// Inbox keeps track of the events that have already been handled by the
+// reactions of a Reactor. The Inbox makes reactions idempotent: an event that
+// is delivered more than once (e.g. because the event bus redelivers it, or
+// because it is replayed on startup) runs a reaction only once.
+type Inbox interface {
+	// Handled returns whether the given reaction has already handled the event
+	// with the given id.
+	Handled(ctx context.Context, reaction string, eventID uuid.UUID) (bool, error)
+
+	// MarkHandled marks the event with the given id as handled by the given
+	// reaction.
+	MarkHandled(ctx context.Context, reaction string, eventID uuid.UUID) error
+}
+
+// MemoryInboxOption is an option for MemoryInbox.
+type MemoryInboxOption func(*memoryInbox)
+
+// InboxRetention returns a MemoryInboxOption that configures the time for
+// which handled events are remembered. Events that are delivered again after
+// the retention window run their reactions again. Default is
+// DefaultInboxRetention.
+func InboxRetention(d time.Duration) MemoryInboxOption {
+	return func(inbox *memoryInbox) {
+		inbox.retention = d
+	}
+}
+
+type memoryInbox struct {
+	retention time.Duration
+
+	mux     sync.RWMutex
+	handled map[handledKey]time.Time
+	order   []handledKey
+}
+
+type handledKey struct {
+	reaction string
+	eventID  uuid.UUID
+}
+
+// MemoryInbox returns an in-memory Inbox. Handled events are forgotten when
+// the process exits, so an in-memory Inbox only protects against duplicate
+// deliveries within a single process. To bound the memory usage of
+// long-running reactors, handled events are only remembered for the retention
+// window of the Inbox (see InboxRetention).
+func MemoryInbox(opts ...MemoryInboxOption) Inbox {
+	inbox := &memoryInbox{
+		retention: DefaultInboxRetention,
+		handled:   make(map[handledKey]time.Time),
+	}
+	for _, opt := range opts {
+		opt(inbox)
+	}
+	return inbox
+}
+
+func (inbox *memoryInbox) Handled(_ context.Context, reaction string, eventID uuid.UUID) (bool, error) {
+	inbox.mux.RLock()
+	defer inbox.mux.RUnlock()
+	handledAt, ok := inbox.handled[handledKey{reaction, eventID}]
+	return ok && time.Since(handledAt) < inbox.retention, nil
+}
+
+func (inbox *memoryInbox) MarkHandled(_ context.Context, reaction string, eventID uuid.UUID) error {
+	inbox.mux.Lock()
+	defer inbox.mux.Unlock()
+
+	now := time.Now()
+	inbox.evict(now)
+
+	key := handledKey{reaction, eventID}
+	if _, ok := inbox.handled[key]; !ok {
+		inbox.order = append(inbox.order, key)
+	}
+	inbox.handled[key] = now
+
+	return nil
+}
+
+// evict forgets the events that were handled before the retention window.
+// Events are evicted in the order in which they were first marked as handled.
+func (inbox *memoryInbox) evict(now time.Time) {
+	for len(inbox.order) > 0 {
+		key := inbox.order[0]
+		if now.Sub(inbox.handled[key]) < inbox.retention {
+			return
+		}
+		delete(inbox.handled, key)
+		inbox.order = inbox.order[1:]
+	}
+}
@@ -0,0 +1,42 @@
+package reactor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/reactor"
+)
+
+func TestMemoryInbox_retention(t *testing.T) {
+	ctx := context.Background()
+	inbox := reactor.MemoryInbox(reactor.InboxRetention(20 * time.Millisecond))
+
+	id := uuid.New()
+	if err := inbox.MarkHandled(ctx, "foo", id); err != nil {
+		t.Fatalf("MarkHandled() failed with %q", err)
+	}
+
+	if handled, err := inbox.Handled(ctx, "foo", id); err != nil || !handled {
+		t.Fatalf("Handled() should return true; got %v (%v)", handled, err)
+	}
+
+	if handled, _ := inbox.Handled(ctx, "bar", id); handled {
+		t.Fatalf("Handled() should return false for another reaction")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if handled, _ := inbox.Handled(ctx, "foo", id); handled {
+		t.Fatalf("Handled() should return false after the retention window")
+	}
+
+	if err := inbox.MarkHandled(ctx, "foo", id); err != nil {
+		t.Fatalf("MarkHandled() failed with %q", err)
+	}
+
+	if handled, _ := inbox.Handled(ctx, "foo", id); !handled {
+		t.Fatalf("Handled() should return true after the event was handled again")
+	}
+}
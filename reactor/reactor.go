@@ -0,0 +1,385 @@
+// Package reactor provides declarative "when event X happens, run side-effect
+// Y" handlers, e.g. to send emails or notifications. Reactions are idempotent
+// (see Inbox), are retried when they fail, and can be suppressed for a window
+// of time to avoid notification floods. Reactors fill the gap between raw
+// event subscriptions and full SAGAs for simple use cases.
+//
+//	r := reactor.New(bus)
+//	reactor.When(r, "welcome-email", "user.registered", func(ctx context.Context, evt event.Of[UserRegistered]) error {
+//		return mailer.SendWelcome(ctx, evt.Data().Email)
+//	}, reactor.Retry(3, time.Second))
+//	errs, err := r.Run(context.TODO())
+package reactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/helper/streams"
+	"github.com/modernice/goes/internal/concurrent"
+)
+
+var (
+	// ErrRunning is returned when trying to run a Reactor that is already
+	// running.
+	ErrRunning = errors.New("reactor is already running")
+
+	// ErrDuplicateReaction is returned by Run if multiple reactions were
+	// registered with the same name.
+	ErrDuplicateReaction = errors.New("duplicate reaction")
+)
+
+// Error is the error that is returned when a reaction fails.
+type Error struct {
+	Reaction string
+	Event    event.Event
+	Attempts int
+	Err      error
+}
+
+// Error returns the error message.
+func (err *Error) Error() string {
+	return fmt.Sprintf("reaction %q failed for %q event (%s) after %d attempt(s): %v", err.Reaction, err.Event.Name(), err.Event.ID(), err.Attempts, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Reactor runs reactions to events that are published over an event bus.
+type Reactor struct {
+	bus     event.Bus
+	inbox   Inbox
+	workers int
+
+	mux       sync.Mutex
+	reactions []*reaction
+	running   bool
+}
+
+// Option is an option for a Reactor.
+type Option func(*Reactor)
+
+// WithInbox returns an Option that configures the Inbox of the Reactor.
+// Default is an in-memory Inbox that remembers handled events for
+// DefaultInboxRetention (see MemoryInbox).
+func WithInbox(inbox Inbox) Option {
+	return func(r *Reactor) {
+		r.inbox = inbox
+	}
+}
+
+// Workers returns an Option that configures the number of events that are
+// handled concurrently. Events of the same aggregate (or, for events without
+// an aggregate, deliveries of the same event) are always handled by the same
+// worker, so they are handled in order and a redelivered event cannot run a
+// reaction concurrently to its first delivery. Default is 1.
+func Workers(n int) Option {
+	return func(r *Reactor) {
+		r.workers = n
+	}
+}
+
+// New returns a Reactor that subscribes to events over the given bus.
+func New(bus event.Bus, opts ...Option) *Reactor {
+	r := &Reactor{bus: bus}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.inbox == nil {
+		r.inbox = MemoryInbox()
+	}
+	if r.workers < 1 {
+		r.workers = 1
+	}
+	return r
+}
+
+type reaction struct {
+	name       string
+	events     []string
+	fn         func(context.Context, event.Event) error
+	attempts   int
+	backoff    time.Duration
+	suppress   time.Duration
+	suppressBy func(event.Event) string
+
+	mux     sync.Mutex
+	lastRun map[string]time.Time
+}
+
+// ReactionOption is an option for a reaction.
+type ReactionOption func(*reaction)
+
+// Retry returns a ReactionOption that retries a failed reaction until it has
+// been attempted the given number of times. The delay between two attempts
+// starts at backoff and doubles with every retry.
+func Retry(attempts int, backoff time.Duration) ReactionOption {
+	return func(r *reaction) {
+		r.attempts = attempts
+		r.backoff = backoff
+	}
+}
+
+// Suppress returns a ReactionOption that suppresses a reaction for the given
+// window after it has run. The window starts at the time of the event that
+// ran the reaction. Events that occur within the window are marked as handled
+// without running the reaction. By default, the window applies per
+// aggregate, so that a reaction runs at most once per window for the same
+// aggregate. Use SuppressBy to configure the key of the window.
+func Suppress(window time.Duration) ReactionOption {
+	return func(r *reaction) {
+		r.suppress = window
+	}
+}
+
+// SuppressBy returns a ReactionOption that configures the key of the
+// suppression window of a reaction (see Suppress). Events with the same key
+// share the same window.
+func SuppressBy(fn func(event.Event) string) ReactionOption {
+	return func(r *reaction) {
+		r.suppressBy = fn
+	}
+}
+
+// When registers a reaction with the given name that runs fn whenever an
+// event with the given name is published. The name of the reaction identifies
+// the reaction in the Inbox, so it must be unique and should not change.
+func When[D any](r *Reactor, name, eventName string, fn func(context.Context, event.Of[D]) error, opts ...ReactionOption) {
+	WhenAny(r, name, []string{eventName}, func(ctx context.Context, evt event.Event) error {
+		casted, ok := event.TryCast[D](evt)
+		if !ok {
+			var zero D
+			return fmt.Errorf("cannot cast %T to %T", evt.Data(), zero)
+		}
+		return fn(ctx, casted)
+	}, opts...)
+}
+
+// WhenAny registers a reaction with the given name that runs fn whenever one
+// of the given events is published.
+func WhenAny(r *Reactor, name string, events []string, fn func(context.Context, event.Event) error, opts ...ReactionOption) {
+	re := &reaction{
+		name:       name,
+		events:     events,
+		fn:         fn,
+		attempts:   1,
+		suppressBy: aggregateKey,
+		lastRun:    make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(re)
+	}
+	if re.attempts < 1 {
+		re.attempts = 1
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reactions = append(r.reactions, re)
+}
+
+// Run subscribes to the events of the registered reactions and runs the
+// reactions until ctx is canceled. Errors of failed reactions are sent to the
+// returned channel as *Error. Errors of the event bus subscription are sent
+// to the returned channel as well, without stopping the Reactor. Failed
+// reactions are not marked as handled in the Inbox, so they run again when the
+// event is delivered again.
+func (r *Reactor) Run(ctx context.Context) (<-chan error, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.running {
+		return nil, ErrRunning
+	}
+
+	names := make(map[string]struct{})
+	var eventNames []string
+	for _, re := range r.reactions {
+		if _, ok := names[re.name]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateReaction, re.name)
+		}
+		names[re.name] = struct{}{}
+		for _, name := range re.events {
+			if !contains(eventNames, name) {
+				eventNames = append(eventNames, name)
+			}
+		}
+	}
+
+	events, errs, err := r.bus.Subscribe(ctx, eventNames...)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to events: %w [events=%v]", err, eventNames)
+	}
+
+	r.running = true
+
+	out, fail := concurrent.Errors(ctx)
+
+	queues := make([]chan event.Event, r.workers)
+	for i := range queues {
+		queues[i] = make(chan event.Event)
+	}
+
+	go func() {
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+		}()
+		streams.ForEach(ctx, func(evt event.Event) {
+			select {
+			case <-ctx.Done():
+			case queues[worker(evt, len(queues))] <- evt:
+			}
+		}, fail, events, errs)
+	}()
+
+	reactions := append([]*reaction(nil), r.reactions...)
+
+	var wg sync.WaitGroup
+	wg.Add(len(queues))
+	for _, queue := range queues {
+		go func(queue <-chan event.Event) {
+			defer wg.Done()
+			for evt := range queue {
+				for _, re := range reactions {
+					if !re.handles(evt.Name()) {
+						continue
+					}
+					if err := r.react(ctx, re, evt); err != nil {
+						fail(err)
+					}
+				}
+			}
+		}(queue)
+	}
+
+	go func() {
+		wg.Wait()
+		r.mux.Lock()
+		defer r.mux.Unlock()
+		r.running = false
+	}()
+
+	return out, nil
+}
+
+func (r *Reactor) react(ctx context.Context, re *reaction, evt event.Event) error {
+	handled, err := r.inbox.Handled(ctx, re.name, evt.ID())
+	if err != nil {
+		return fmt.Errorf("check inbox for %q event (%s): %w", evt.Name(), evt.ID(), err)
+	}
+
+	if handled {
+		return nil
+	}
+
+	if !re.suppressed(evt) {
+		if err := re.run(ctx, evt); err != nil {
+			return err
+		}
+	}
+
+	if err := r.inbox.MarkHandled(ctx, re.name, evt.ID()); err != nil {
+		return fmt.Errorf("mark %q event (%s) as handled: %w", evt.Name(), evt.ID(), err)
+	}
+
+	return nil
+}
+
+// worker returns the index of the worker that handles the given event. Events
+// of the same aggregate are handled by the same worker. Events without an
+// aggregate are distributed by their id.
+func worker(evt event.Event, workers int) int {
+	if workers == 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	if id, name, _ := evt.Aggregate(); id != uuid.Nil {
+		h.Write([]byte(name))
+		h.Write(id[:])
+	} else {
+		id := evt.ID()
+		h.Write(id[:])
+	}
+
+	return int(h.Sum32() % uint32(workers))
+}
+
+func (re *reaction) handles(name string) bool {
+	return contains(re.events, name)
+}
+
+func (re *reaction) suppressed(evt event.Event) bool {
+	if re.suppress <= 0 {
+		return false
+	}
+
+	key := re.suppressBy(evt)
+
+	re.mux.Lock()
+	defer re.mux.Unlock()
+
+	last, ok := re.lastRun[key]
+	return ok && evt.Time().Before(last.Add(re.suppress))
+}
+
+func (re *reaction) run(ctx context.Context, evt event.Event) error {
+	var (
+		attempts int
+		err      error
+		delay    = re.backoff
+	)
+
+	for attempts < re.attempts {
+		if attempts > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return &Error{Reaction: re.name, Event: evt, Attempts: attempts, Err: ctx.Err()}
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+
+		attempts++
+
+		if err = re.fn(ctx, evt); err == nil {
+			if re.suppress > 0 {
+				re.mux.Lock()
+				re.lastRun[re.suppressBy(evt)] = evt.Time()
+				re.mux.Unlock()
+			}
+			return nil
+		}
+	}
+
+	return &Error{Reaction: re.name, Event: evt, Attempts: attempts, Err: err}
+}
+
+func aggregateKey(evt event.Event) string {
+	id, name, _ := evt.Aggregate()
+	if id == uuid.Nil {
+		return ""
+	}
+	return name + ":" + id.String()
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
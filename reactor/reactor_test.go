@@ -0,0 +1,246 @@
+package reactor_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modernice/goes/event"
+	"github.com/modernice/goes/event/eventbus"
+	"github.com/modernice/goes/event/test"
+	"github.com/modernice/goes/reactor"
+)
+
+func TestWhen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	r := reactor.New(bus)
+
+	reacted := make(chan event.Of[test.FooEventData])
+	reactor.When(r, "foo-reaction", "foo", func(ctx context.Context, evt event.Of[test.FooEventData]) error {
+		reacted <- evt
+		return nil
+	})
+
+	errs, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	evt := event.New("foo", test.FooEventData{A: "foo"})
+	if err := bus.Publish(ctx, evt.Any(), evt.Any()); err != nil {
+		t.Fatalf("publish events: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		t.Fatalf("reaction failed with %q", err)
+	case <-time.After(time.Second):
+		t.Fatalf("reaction did not run")
+	case got := <-reacted:
+		if got.ID() != evt.ID() || got.Data().A != "foo" {
+			t.Fatalf("reaction should receive the published event")
+		}
+	}
+
+	select {
+	case <-reacted:
+		t.Fatalf("reaction should run only once for the same event")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	r := reactor.New(bus)
+
+	mockError := errors.New("mock error")
+	var calls int32
+	reactor.WhenAny(r, "foo-reaction", []string{"foo"}, func(context.Context, event.Event) error {
+		atomic.AddInt32(&calls, 1)
+		return mockError
+	}, reactor.Retry(3, time.Millisecond))
+
+	errs, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	if err := bus.Publish(ctx, event.New("foo", test.FooEventData{}).Any()); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("reaction should fail")
+	case err := <-errs:
+		var rerr *reactor.Error
+		if !errors.As(err, &rerr) || !errors.Is(err, mockError) {
+			t.Fatalf("Run should fail with a *reactor.Error that wraps %q; got %q", mockError, err)
+		}
+
+		if rerr.Attempts != 3 {
+			t.Fatalf("reaction should be attempted %d times; got %d", 3, rerr.Attempts)
+		}
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("reaction should be called %d times; got %d", 3, n)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	r := reactor.New(bus)
+
+	reacted := make(chan event.Event, 3)
+	reactor.WhenAny(r, "foo-reaction", []string{"foo"}, func(_ context.Context, evt event.Event) error {
+		reacted <- evt
+		return nil
+	}, reactor.Suppress(time.Hour))
+
+	errs, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	aggregateID := uuid.New()
+	now := time.Now()
+	first := event.New("foo", test.FooEventData{}, event.Time(now), event.Aggregate(aggregateID, "foobar", 1)).Any()
+	suppressed := event.New("foo", test.FooEventData{}, event.Time(now.Add(time.Minute)), event.Aggregate(aggregateID, "foobar", 2)).Any()
+	afterWindow := event.New("foo", test.FooEventData{}, event.Time(now.Add(2*time.Hour)), event.Aggregate(aggregateID, "foobar", 3)).Any()
+
+	for _, evt := range []event.Event{first, suppressed, afterWindow} {
+		if err := bus.Publish(ctx, evt); err != nil {
+			t.Fatalf("publish event: %v", err)
+		}
+
+		select {
+		case err := <-errs:
+			t.Fatalf("reaction failed with %q", err)
+		case <-reacted:
+			if evt.ID() == suppressed.ID() {
+				t.Fatalf("reaction should be suppressed")
+			}
+		case <-time.After(50 * time.Millisecond):
+			if evt.ID() != suppressed.ID() {
+				t.Fatalf("reaction should run")
+			}
+		}
+	}
+}
+
+func TestReactor_Run_duplicateReaction(t *testing.T) {
+	r := reactor.New(eventbus.New())
+	fn := func(context.Context, event.Event) error { return nil }
+	reactor.WhenAny(r, "foo", []string{"foo"}, fn)
+	reactor.WhenAny(r, "foo", []string{"bar"}, fn)
+
+	if _, err := r.Run(context.Background()); !errors.Is(err, reactor.ErrDuplicateReaction) {
+		t.Fatalf("Run should fail with %q; got %q", reactor.ErrDuplicateReaction, err)
+	}
+}
+
+func TestReactor_Run_busError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := &errorBus{Bus: eventbus.New(), errs: make(chan error)}
+	r := reactor.New(bus)
+
+	reacted := make(chan event.Event)
+	reactor.WhenAny(r, "foo-reaction", []string{"foo"}, func(_ context.Context, evt event.Event) error {
+		reacted <- evt
+		return nil
+	})
+	reactor.WhenAny(r, "bar-reaction", []string{"foo"}, func(context.Context, event.Event) error { return nil })
+
+	errs, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	if len(bus.subscribed) != 1 {
+		t.Fatalf("Run should subscribe to %v; subscribed to %v", []string{"foo"}, bus.subscribed)
+	}
+
+	mockError := errors.New("mock error")
+	bus.errs <- mockError
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("Run should report the bus error")
+	case err := <-errs:
+		if !errors.Is(err, mockError) {
+			t.Fatalf("Run should report %q; got %q", mockError, err)
+		}
+	}
+
+	if err := bus.Publish(ctx, event.New("foo", test.FooEventData{}).Any()); err != nil {
+		t.Fatalf("publish event: %v", err)
+	}
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("reaction should run after a bus error")
+	case <-reacted:
+	}
+}
+
+func TestWorkers_redelivery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	r := reactor.New(bus, reactor.Workers(4))
+
+	var calls int32
+	reactor.WhenAny(r, "foo-reaction", []string{"foo"}, func(context.Context, event.Event) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if _, err := r.Run(ctx); err != nil {
+		t.Fatalf("Run failed with %q", err)
+	}
+
+	evt := event.New("foo", test.FooEventData{}, event.Aggregate(uuid.New(), "foobar", 1)).Any()
+	for i := 0; i < 4; i++ {
+		if err := bus.Publish(ctx, evt); err != nil {
+			t.Fatalf("publish event: %v", err)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("reaction should run once for a redelivered event; ran %d times", n)
+	}
+}
+
+// errorBus is an event bus whose subscriptions report the errors that are
+// sent to errs.
+type errorBus struct {
+	event.Bus
+
+	errs       chan error
+	subscribed []string
+}
+
+func (bus *errorBus) Subscribe(ctx context.Context, names ...string) (<-chan event.Event, <-chan error, error) {
+	bus.subscribed = names
+	events, _, err := bus.Bus.Subscribe(ctx, names...)
+	return events, bus.errs, err
+}